@@ -24,6 +24,11 @@ CREATE TABLE findings (
     line_number INTEGER,
     chunk_index INTEGER,
     metadata JSONB NOT NULL DEFAULT '{}',
+    summary JSONB,                            -- Optional LLM root-cause summary
+    context_note TEXT NOT NULL DEFAULT '',    -- Why the context is short, e.g. truncated at chunk start
+    remediation JSONB,                        -- Suggested fix for a well-known failure
+    category TEXT NOT NULL DEFAULT '',        -- Language or tool ecosystem, e.g. go or docker
+    assignee TEXT NOT NULL DEFAULT '',        -- Who is triaging the finding
     
     -- Timestamps
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
@@ -39,6 +44,25 @@ CREATE INDEX idx_findings_message_hash ON findings(message_hash);
 CREATE INDEX idx_findings_job_name ON findings(job_name);
 CREATE INDEX idx_findings_confidence ON findings(confidence_score DESC);
 CREATE INDEX idx_findings_created_at ON findings(created_at DESC);
+CREATE INDEX idx_findings_build_url ON findings(build_url);  -- Previous analyses of a build
+
+-- Log chunks table: raw log chunks, for reading context around findings
+CREATE TABLE log_chunks (
+    request_id VARCHAR(255) NOT NULL,
+    job_id VARCHAR(255) NOT NULL,
+    section VARCHAR(20) NOT NULL DEFAULT '',  -- step_index for logs split by step
+    chunk_index INTEGER NOT NULL,
+    line_start INTEGER NOT NULL,
+    line_end INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    header JSONB,  -- The chunk's other fields, for destill reanalyze
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (request_id, job_id, section, chunk_index)
+);
+
+CREATE INDEX idx_log_chunks_lines ON log_chunks(request_id, job_id, section, line_start, line_end);
+CREATE INDEX idx_log_chunks_created_at ON log_chunks(created_at);  -- Pruning by age
 
 -- Requests table: tracks analysis requests
 CREATE TABLE requests (
@@ -46,8 +70,12 @@ CREATE TABLE requests (
     request_id VARCHAR(255) UNIQUE NOT NULL,
     build_url TEXT NOT NULL,
     status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    stage TEXT NOT NULL DEFAULT '',
+    truncated TEXT NOT NULL DEFAULT '', -- What the agents' limits cut, one note per line
     
     -- Counts
+    jobs_fetched INTEGER NOT NULL DEFAULT 0,
+    jobs_total INTEGER NOT NULL DEFAULT 0,
     chunks_total INTEGER DEFAULT 0,
     chunks_processed INTEGER DEFAULT 0,
     findings_count INTEGER DEFAULT 0,
@@ -63,6 +91,36 @@ CREATE TABLE requests (
 CREATE INDEX idx_requests_status ON requests(status);
 CREATE INDEX idx_requests_created_at ON requests(created_at DESC);
 
+-- Message hash history: builds of each pipeline each message was seen in,
+-- for cross-build recurrence. Filled by a trigger on findings.
+CREATE TABLE message_hash_history (
+    pipeline VARCHAR(255) NOT NULL,
+    build_url TEXT NOT NULL,
+    message_hash VARCHAR(64) NOT NULL,
+    request_id VARCHAR(255) NOT NULL,        -- First request the message was seen in
+    seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (pipeline, build_url, message_hash)
+);
+
+CREATE INDEX idx_message_hash_history_hash ON message_hash_history(pipeline, message_hash);
+CREATE INDEX idx_message_hash_history_seen_at ON message_hash_history(pipeline, seen_at DESC);
+
+CREATE FUNCTION record_message_hash_history() RETURNS trigger AS $$
+BEGIN
+    IF COALESCE(NEW.metadata->>'pipeline_name', '') <> '' THEN
+        INSERT INTO message_hash_history (pipeline, build_url, message_hash, request_id, seen_at)
+        VALUES (NEW.metadata->>'pipeline_name', NEW.build_url, NEW.message_hash, NEW.request_id,
+                COALESCE(NEW.created_at, CURRENT_TIMESTAMP))
+        ON CONFLICT DO NOTHING;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER findings_message_hash_history
+    AFTER INSERT ON findings
+    FOR EACH ROW EXECUTE FUNCTION record_message_hash_history();
+
 -- View for aggregated findings by hash (recurrence tracking)
 CREATE VIEW findings_summary AS
 SELECT 
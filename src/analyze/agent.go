@@ -6,57 +6,259 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"destill-agent/src/broker"
 	"destill-agent/src/contracts"
 	"destill-agent/src/logger"
+	"destill-agent/src/rules"
+	"destill-agent/src/summarize"
+)
+
+// MetadataPatternConfigHash is the finding metadata key recording which
+// pattern config produced the finding.
+const MetadataPatternConfigHash = "pattern_config_hash"
+
+// Finding metadata keys set from pipeline rule hints (see rules.PipelineRule).
+const (
+	MetadataPriority       = "priority"
+	MetadataNotify         = "notify"
+	MetadataTriageDeadline = "triage_deadline" // RFC3339
+	MetadataHintRule       = "hint_rule"
 )
 
 // Agent consumes log chunks and publishes analysis findings.
 type Agent struct {
+	id     string // Tells the agent's chunk counts from others' (see completion)
 	broker broker.Broker
 	logger logger.Logger
+	rules  atomic.Pointer[rules.RuleSet]
+
+	summarizer   *summarize.Stage
+	analyze      chunkAnalyzer
+	chunkTimeout time.Duration
+	completion   *completion
+	aggregate    *aggregator          // Set by the processing loop, which flushes it
+	handled      func(broker.Message) // Commits a chunk once processed (see Run)
+	held         []heldChunk          // Processed chunks waiting for their findings to be flushed
+
+	drain     chan struct{} // Closed by Drain
+	drainOnce sync.Once
 }
 
+// heldChunk is a processed chunk whose commit waits until every batch of
+// findings merged by then (see aggregator) is published.
+type heldChunk struct {
+	msg       broker.Message
+	requestID string // Empty for an invalid chunk
+	chunkID   string // See contracts.LogChunk.ID
+	upTo      int    // ID of the newest batch when it was processed
+}
+
+// agents numbers the agents created in this process, such as the pipeline
+// tests' several, so their IDs differ.
+var agents atomic.Int64
+
 // NewAgent creates a new analyze agent.
 func NewAgent(brk broker.Broker, log logger.Logger) *Agent {
+	hostname, _ := os.Hostname()
 	return &Agent{
-		broker: brk,
-		logger: log,
+		id:           fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), agents.Add(1)),
+		broker:       brk,
+		logger:       log,
+		analyze:      AnalyzeChunkWithStats,
+		chunkTimeout: chunkTimeout,
+		completion:   newCompletion(),
+		drain:        make(chan struct{}),
 	}
 }
 
+// SetRules sets the user patterns and suppressions applied to each chunk.
+// Safe to call while the agent is running; later chunks use the new rules.
+func (a *Agent) SetRules(rs *rules.RuleSet) {
+	a.rules.Store(rs)
+}
+
+// SetSummarizer enables the LLM summarization stage. Call before Run.
+func (a *Agent) SetSummarizer(stage *summarize.Stage) {
+	a.summarizer = stage
+}
+
+// SetChunkTimeout sets how long one chunk is analyzed before it's skipped
+// and dead-lettered (default DESTILL_CHUNK_TIMEOUT); 0 disables the
+// timeout. Call before Run.
+func (a *Agent) SetChunkTimeout(timeout time.Duration) {
+	a.chunkTimeout = timeout
+}
+
+// SetMaxFindings sets the most findings published for one request (default
+// DESTILL_MAX_FINDINGS); 0 disables the limit. Call before Run.
+func (a *Agent) SetMaxFindings(n int) {
+	a.completion.maxFindings = n
+}
+
+// Drain stops the agent taking new chunks, for a graceful shutdown: Run
+// returns nil once the chunk being analyzed is done and the findings held
+// back to merge are published (and, on brokers that support it, the chunks
+// are committed), without reading another. Cancel Run's context to stop without waiting.
+func (a *Agent) Drain() {
+	a.drainOnce.Do(func() { close(a.drain) })
+}
+
+// WatchRules reloads the pattern config at path whenever it changes.
+// Blocks until ctx is done.
+func (a *Agent) WatchRules(ctx context.Context, path string, interval time.Duration) {
+	rules.Watch(ctx, path, interval, a.rules.Load(),
+		func(rs *rules.RuleSet) {
+			a.SetRules(rs)
+			a.logger.Info("[AnalyzeAgent] Reloaded pattern config %s (hash %s)", path, rs.Hash())
+		},
+		func(err error) {
+			a.logger.Error("[AnalyzeAgent] Ignoring invalid pattern config, keeping previous rules: %v", err)
+		})
+}
+
 // Run starts the agent's main loop.
-// It subscribes to destill.logs.raw and processes incoming chunks.
+// It subscribes to destill.logs.raw and destill.control, and processes
+// incoming chunks. On brokers that support it (broker.Committer), a chunk
+// is committed only once its findings are published or it's dead-lettered,
+// so chunks in flight when the agent crashes are analyzed again. That
+// includes findings held back to merge with their recurrences in other
+// jobs (see RunWithChannels). Publishing is retried while the broker fails
+// (see broker.RetryUntilDone), so a chunk is never committed past.
+//
+// Agents sharing the destill-analyze consumer group split a build's jobs
+// between them (see contracts.LogChunk.Key), so each reads destill.control
+// in a group of its own, to sum the chunk counts the others report.
 func (a *Agent) Run(ctx context.Context) error {
 	a.logger.Info("[AnalyzeAgent] Starting...")
 
 	// Subscribe to log chunks topic
-	msgChan, err := a.broker.Subscribe(ctx, contracts.TopicLogsRaw, "destill-analyze")
+	msgChan, handled, err := broker.SubscribeAtLeastOnce(ctx, a.broker, contracts.TopicLogsRaw, "destill-analyze")
 	if err != nil {
 		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicLogsRaw, err)
 	}
+	a.handled = handled
+	controlChan, err := a.broker.Subscribe(ctx, contracts.TopicControl, "destill-analyze-control-"+a.id)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicControl, err)
+	}
 
-	return a.RunWithChannel(ctx, msgChan)
+	return a.RunWithChannels(ctx, msgChan, controlChan)
 }
 
 // RunWithChannel runs the agent's processing loop using a pre-subscribed channel.
 // This allows the caller to control subscription timing to avoid race conditions.
+// Without control messages, the agent never reports a request's analysis
+// complete (see RunWithChannels).
 func (a *Agent) RunWithChannel(ctx context.Context, msgChan <-chan broker.Message) error {
-	a.logger.Info("[AnalyzeAgent] Listening for log chunks on '%s' topic...", contracts.TopicLogsRaw)
+	return a.RunWithChannels(ctx, msgChan, nil)
+}
+
+// RunWithChannels is RunWithChannel with a pre-subscribed channel of
+// destill.control messages as well. When the ingest agent reports how many
+// chunks it published for a request, and the agent has processed them all,
+// it publishes contracts.ControlAnalysisComplete for the request. As it
+// commits chunks, it also reports how many of the request's it has
+// processed (contracts.ControlChunksAnalyzed), so that when agents share a
+// request's chunks, one of them publishes the completion once their counts
+// add up (see completion). controlChan must carry every control message,
+// the agent's own reports included.
+//
+// Findings whose message recurs across a request's jobs are merged into one
+// card listing the jobs (see aggregator). They're published when the
+// request's analysis completes, once no chunk arrives for aggregateIdle,
+// or at most aggregateWindow after the first of them.
+func (a *Agent) RunWithChannels(ctx context.Context, msgChan, controlChan <-chan broker.Message) error {
+	a.logger.Info("[AnalyzeAgent] Listening for log chunks on '%s' topic as %s...", contracts.TopicLogsRaw, a.id)
+	a.aggregate = newAggregator()
 
 	// Process messages
 	for {
+		// A drain takes precedence over chunks already waiting
 		select {
+		case <-a.drain:
+			a.logger.Info("[AnalyzeAgent] Drained, shutting down")
+			return a.flush(ctx, a.aggregate.due(true))
+		default:
+		}
+
+		var idle <-chan time.Time
+		if a.aggregate.pending() {
+			idle = time.After(a.aggregate.idle)
+		}
+
+		select {
+		case <-a.drain:
+			a.logger.Info("[AnalyzeAgent] Drained, shutting down")
+			return a.flush(ctx, a.aggregate.due(true))
+
 		case msg, ok := <-msgChan:
 			if !ok {
 				a.logger.Info("[AnalyzeAgent] Message channel closed, shutting down")
-				return nil
+				return a.flush(ctx, a.aggregate.due(true))
 			}
 
-			if err := a.processChunk(ctx, msg); err != nil {
-				a.logger.Error("[AnalyzeAgent] Error processing chunk: %v", err)
+			// Processing only fails once ctx is done; the chunk is left
+			// uncommitted, to be analyzed again
+			held, err := a.processChunk(ctx, msg)
+			if err != nil {
+				a.logger.Info("[AnalyzeAgent] Context cancelled, shutting down")
+				return err
+			}
+			held.upTo = a.aggregate.lastID
+			a.held = append(a.held, held)
+			if err := a.flush(ctx, a.aggregate.due(false)); err != nil {
+				return err
+			}
+
+		case <-idle:
+			if err := a.flush(ctx, a.aggregate.due(true)); err != nil {
+				return err
+			}
+
+		case msg, ok := <-controlChan:
+			if !ok {
+				controlChan = nil
+				continue
+			}
+			var control contracts.ControlMessage
+			if err := json.Unmarshal(msg.Value, &control); err != nil {
+				a.logger.Error("[AnalyzeAgent] Failed to unmarshal control message: %v", err)
+				continue
+			}
+			if sent, err := time.Parse(time.RFC3339, control.Timestamp); err == nil && a.completion.now().Sub(sent) > completionTTL {
+				continue // Replayed to a new agent, about a request long forgotten
+			}
+			var done contracts.ControlMessage
+			var complete bool
+			switch control.Type {
+			case contracts.ControlIngestComplete:
+				var progress contracts.ProgressUpdate
+				progress, done, complete = a.completion.ingestDone(control, a.id)
+				// An agent without any of the request's chunks has no progress to add
+				if progress.Current > 0 || complete {
+					a.publishProgress(ctx, progress)
+				}
+			case contracts.ControlChunksAnalyzed:
+				done, complete = a.completion.shareReported(control, a.id)
+			case contracts.ControlAnalysisComplete:
+				a.completion.analysisDone(control.RequestID)
+			}
+			if complete {
+				n, err := a.flushRequest(ctx, done.RequestID)
+				if err != nil {
+					return err
+				}
+				done.Findings += n
+				a.publishComplete(ctx, done)
+				if err := a.flush(ctx, nil); err != nil {
+					return err
+				}
 			}
 
 		case <-ctx.Done():
@@ -66,49 +268,321 @@ func (a *Agent) RunWithChannel(ctx context.Context, msgChan <-chan broker.Messag
 	}
 }
 
-// processChunk analyzes a single log chunk and publishes findings.
-func (a *Agent) processChunk(ctx context.Context, msg broker.Message) error {
+// processChunk analyzes a single log chunk and publishes findings,
+// returning the chunk to hold until it's committed. It returns an error
+// only if ctx is done before they're all published (or the chunk is
+// dead-lettered), leaving the chunk to be analyzed again.
+func (a *Agent) processChunk(ctx context.Context, msg broker.Message) (held heldChunk, err error) {
+	held.msg = msg
+
 	// Parse log chunk
 	var chunk contracts.LogChunk
 	if err := json.Unmarshal(msg.Value, &chunk); err != nil {
-		return fmt.Errorf("failed to unmarshal chunk: %w", err)
+		// Redelivering it can't help, so keep it for inspection instead
+		a.logger.Error("[AnalyzeAgent] Dead-lettering invalid chunk: %v", err)
+		failure := &chunkFailure{reason: contracts.SkipReasonInvalid, detail: err.Error()}
+		letter := deadLetter(msg, failure, 1)
+		return held, a.publishDeadLetter(ctx, letter.RequestID(), letter)
 	}
+	held.requestID, held.chunkID = chunk.RequestID, chunk.ID()
 
 	a.logger.Debug("[AnalyzeAgent] Processing chunk %d/%d for job '%s'",
 		chunk.ChunkIndex+1, chunk.TotalChunks, chunk.JobName)
 
-	// Analyze chunk (stateless)
-	findings := AnalyzeChunk(chunk)
+	// Analyzed or skipped, the chunk counts towards the request's progress
+	published := 0
+	defer func() {
+		if err != nil {
+			return
+		}
+		progress, done, ok := a.completion.chunkDone(held.requestID, held.chunkID, published)
+		a.publishProgress(ctx, progress)
+		if ok {
+			var n int
+			if n, err = a.flushRequest(ctx, chunk.RequestID); err == nil {
+				done.Findings += n
+				a.publishComplete(ctx, done)
+			}
+		}
+	}()
+
+	// Analyze chunk (stateless). Load the rules once so every finding
+	// from this chunk is scored and tagged with the same config.
+	ruleSet := a.rules.Load()
+	findings, stats, failure := analyzeIsolated(a.analyze, chunk, ruleSet, a.chunkTimeout)
+	if failure != nil {
+		published, err = a.skipChunk(ctx, msg, chunk, failure)
+		return held, err
+	}
+	hints := ruleSet.Hints(buildInfo(chunk))
+	if stats.Skipped > 0 || stats.Truncated > 0 {
+		a.logger.Debug("[AnalyzeAgent] Chunk %d/%d of job '%s': skipped %d binary/encoded lines, truncated %d long lines",
+			chunk.ChunkIndex+1, chunk.TotalChunks, chunk.JobName, stats.Skipped, stats.Truncated)
+	}
+
+	findings, inBaseline := withoutBaseline(findings, chunk.BaselineHashes)
+	if inBaseline > 0 {
+		a.logger.Debug("[AnalyzeAgent] Suppressed %d findings of chunk %d/%d of job '%s' also in the baseline build",
+			inBaseline, chunk.ChunkIndex+1, chunk.TotalChunks, chunk.JobName)
+	}
+
+	if limited := a.completion.limit(chunk.RequestID, findings); len(limited) < len(findings) {
+		a.logger.Info("[AnalyzeAgent] Dropped %d findings of chunk %d/%d of job '%s' over the limit of %d per request",
+			len(findings)-len(limited), chunk.ChunkIndex+1, chunk.TotalChunks, chunk.JobName, a.completion.maxFindings)
+		findings = limited
+	}
 
 	if len(findings) == 0 {
 		a.logger.Debug("[AnalyzeAgent] No findings in chunk %d/%d",
 			chunk.ChunkIndex+1, chunk.TotalChunks)
-		return nil
+		return held, nil
 	}
 
 	a.logger.Info("[AnalyzeAgent] Found %d issues in chunk %d/%d of job '%s'",
 		len(findings), chunk.ChunkIndex+1, chunk.TotalChunks, chunk.JobName)
 
-	// Convert and publish each finding
+	// Convert and publish each finding, or hold it back to merge
 	for _, finding := range findings {
-		card := ConvertToTriageCard(finding, chunk, chunk.RequestID)
-		card.Timestamp = time.Now().Format(time.RFC3339)
-
-		data, err := json.Marshal(card)
-		if err != nil {
-			a.logger.Error("[AnalyzeAgent] Failed to marshal finding: %v", err)
+		card := findingCard(finding, chunk, ruleSet, hints)
+		if a.aggregate != nil && aggregates(card) {
+			a.aggregate.add(card)
 			continue
 		}
+		ok, err := a.publishFinding(ctx, card)
+		if err != nil {
+			return held, err
+		}
+		if ok {
+			published++
+		}
+	}
 
-		// Publish to destill.analysis.findings with requestID as key for grouping
-		if err := a.broker.Publish(ctx, contracts.TopicAnalysisFindings, chunk.RequestID, data); err != nil {
-			a.logger.Error("[AnalyzeAgent] Failed to publish finding: %v", err)
-			continue
+	return held, nil
+}
+
+// publishFinding summarizes a finding, if the summarization stage is
+// enabled, and publishes it. Returns whether it was published: not if it
+// can't be marshaled, which publishing again can't help. The error is ctx's,
+// if it's done first.
+func (a *Agent) publishFinding(ctx context.Context, card contracts.TriageCard) (bool, error) {
+	if a.summarizer != nil {
+		if err := a.summarizer.Apply(ctx, &card); err != nil {
+			a.logger.Error("[AnalyzeAgent] Failed to summarize finding: %v", err)
 		}
+	}
 
-		a.logger.Debug("[AnalyzeAgent] Published finding: %s (confidence: %.2f)",
-			finding.Severity, finding.ConfidenceScore)
+	data, err := json.Marshal(card)
+	if err != nil {
+		a.logger.Error("[AnalyzeAgent] Failed to marshal finding: %v", err)
+		return false, nil
+	}
+
+	// Publish to destill.analysis.findings with requestID as key for grouping
+	if err := a.publish(ctx, contracts.TopicAnalysisFindings, card.RequestID, data, "finding"); err != nil {
+		return false, err
 	}
 
+	a.logger.Debug("[AnalyzeAgent] Published finding: %s (confidence: %.2f)",
+		card.Severity, card.ConfidenceScore)
+	return true, nil
+}
+
+// publish publishes a message produced from a chunk, retrying while the
+// broker fails (see broker.RetryUntilDone): the chunk is committed only once
+// it's published. Returns an error only once ctx is done.
+func (a *Agent) publish(ctx context.Context, topic, key string, data []byte, what string) error {
+	return broker.RetryUntilDone(ctx, func() error {
+		return a.broker.Publish(ctx, topic, key, data)
+	}, func(err error, retryIn time.Duration) {
+		a.logger.Error("[AnalyzeAgent] Failed to publish %s, retrying in %s: %v", what, retryIn, err)
+	})
+}
+
+// flushRequest publishes the merged findings held back for a request whose
+// analysis is complete. Returns how many it published.
+func (a *Agent) flushRequest(ctx context.Context, requestID string) (int, error) {
+	if a.aggregate == nil {
+		return 0, nil
+	}
+	b := a.aggregate.take(requestID)
+	if b == nil {
+		return 0, nil
+	}
+	return a.publishBatch(ctx, b)
+}
+
+// flush publishes the merged findings of batches, counting them towards
+// their requests' progress, then commits the chunks whose findings are now
+// all published. If ctx is done first, nothing more is committed.
+func (a *Agent) flush(ctx context.Context, batches []*batch) error {
+	for _, b := range batches {
+		n, err := a.publishBatch(ctx, b)
+		if err != nil {
+			return err
+		}
+		a.completion.published(b.requestID, n)
+	}
+
+	// Report the requests' new counts once their chunks are committed, so
+	// they never count a chunk that could be analyzed again
+	var reports []string
+	oldest := a.aggregate.oldest()
+	for len(a.held) > 0 && a.held[0].upTo < oldest {
+		held := a.held[0]
+		if a.handled != nil {
+			a.handled(held.msg)
+		}
+		if held.requestID != "" && a.completion.released(held.requestID, held.chunkID) && !slices.Contains(reports, held.requestID) {
+			reports = append(reports, held.requestID)
+		}
+		a.held = a.held[1:]
+	}
+	for _, requestID := range reports {
+		if err := a.publishControl(ctx, a.completion.report(requestID, a.id), "chunk count"); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// publishBatch publishes a batch's merged findings. Returns how many it
+// published.
+func (a *Agent) publishBatch(ctx context.Context, b *batch) (int, error) {
+	published, occurrences := 0, 0
+	for _, card := range b.cards {
+		occurrences += card.GetRecurrenceCount()
+		ok, err := a.publishFinding(ctx, card)
+		if err != nil {
+			return published, err
+		}
+		if ok {
+			published++
+		}
+	}
+	a.logger.Debug("[AnalyzeAgent] Published %d findings of request %s, merged from %d",
+		published, b.requestID, occurrences)
+	return published, nil
+}
+
+// skipChunk gives up on a chunk that timed out or panicked: the message
+// goes to the dead-letter topic, and a finding records the skip so the
+// results show the gap. Returns how many findings it published.
+func (a *Agent) skipChunk(ctx context.Context, msg broker.Message, chunk contracts.LogChunk, failure *chunkFailure) (int, error) {
+	a.logger.Error("[AnalyzeAgent] Skipping chunk %d/%d of job '%s': %s",
+		chunk.ChunkIndex+1, chunk.TotalChunks, chunk.JobName, failure.reason)
+
+	if err := a.publishDeadLetter(ctx, chunk.RequestID, deadLetter(msg, failure, chunk.DeadLetters+1)); err != nil {
+		return 0, err
+	}
+
+	data, err := json.Marshal(skippedChunkCard(chunk, failure))
+	if err != nil {
+		a.logger.Error("[AnalyzeAgent] Failed to marshal skipped chunk finding: %v", err)
+		return 0, nil
+	}
+	if err := a.publish(ctx, contracts.TopicAnalysisFindings, chunk.RequestID, data, "skipped chunk finding"); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// publishDeadLetter publishes a message the agent gave up on to the
+// dead-letter topic, where 'destill dlq' lists and replays it. The error
+// is ctx's, if it's done before the letter is published.
+func (a *Agent) publishDeadLetter(ctx context.Context, key string, letter contracts.DeadLetter) error {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		a.logger.Error("[AnalyzeAgent] Failed to marshal dead letter: %v", err)
+		return nil
+	}
+	return a.publish(ctx, contracts.TopicLogsDLQ, key, data, "dead letter")
+}
+
+// publishProgress publishes how many of a request's chunks are analyzed.
+func (a *Agent) publishProgress(ctx context.Context, update contracts.ProgressUpdate) {
+	update.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.Marshal(update)
+	if err != nil {
+		a.logger.Error("[AnalyzeAgent] Failed to marshal progress update: %v", err)
+		return
+	}
+	if err := a.broker.Publish(ctx, contracts.TopicProgress, update.RequestID, data); err != nil {
+		a.logger.Error("[AnalyzeAgent] Failed to publish progress update: %v", err)
+	}
+}
+
+// publishComplete publishes a request's ControlAnalysisComplete.
+func (a *Agent) publishComplete(ctx context.Context, msg contracts.ControlMessage) {
+	a.logger.Info("[AnalyzeAgent] Analysis of request %s complete (%d chunks, %d findings)",
+		msg.RequestID, msg.Chunks, msg.Findings)
+
+	msg.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		a.logger.Error("[AnalyzeAgent] Failed to marshal control message: %v", err)
+		return
+	}
+	if err := a.broker.Publish(ctx, contracts.TopicControl, msg.RequestID, data); err != nil {
+		a.logger.Error("[AnalyzeAgent] Failed to publish control message: %v", err)
+	}
+}
+
+// publishControl publishes a control message the request's completion
+// depends on, retrying while the broker fails. The error is ctx's, if it's
+// done first.
+func (a *Agent) publishControl(ctx context.Context, msg contracts.ControlMessage, what string) error {
+	msg.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		a.logger.Error("[AnalyzeAgent] Failed to marshal control message: %v", err)
+		return nil
+	}
+	return a.publish(ctx, contracts.TopicControl, msg.RequestID, data, what)
+}
+
+// findingCard converts a finding of chunk to the card the agent publishes,
+// tagged with the rule set's hash, hints, ignore rule, and remediation.
+func findingCard(finding Finding, chunk contracts.LogChunk, ruleSet *rules.RuleSet, hints rules.Hints) contracts.TriageCard {
+	card := ConvertToTriageCard(finding, chunk, chunk.RequestID)
+	card.Timestamp = time.Now().Format(time.RFC3339)
+	if hash := ruleSet.Hash(); hash != "" {
+		card.Metadata[MetadataPatternConfigHash] = hash
+	}
+	applyHints(card.Metadata, hints)
+	if rule, ok := ruleSet.Ignored(card.RawMessage, card.MessageHash, chunk.Metadata["pipeline_name"]); ok {
+		card.Metadata[contracts.MetadataSuppressedBy] = rule.Name
+		if rule.Reason != "" {
+			card.Metadata[contracts.MetadataSuppressedReason] = rule.Reason
+		}
+	}
+	if r, ok := ruleSet.Remediate(card.RawMessage, card.PreContext, card.PostContext); ok {
+		card.Remediation = &contracts.Remediation{Name: r.Name, Fix: r.Fix, Description: r.Description}
+	}
+	return card
+}
+
+// buildInfo extracts the build metadata pipeline rules match on from a chunk.
+func buildInfo(chunk contracts.LogChunk) rules.BuildInfo {
+	return rules.BuildInfo{
+		Pipeline: chunk.Metadata["pipeline_name"],
+		Branch:   chunk.Metadata["branch"],
+		Trigger:  chunk.Metadata["trigger"],
+	}
+}
+
+// applyHints records priority, notification behavior, and triage deadline
+// in finding metadata. The deadline counts from the build's creation time.
+func applyHints(metadata map[string]string, hints rules.Hints) {
+	metadata[MetadataPriority] = hints.Priority
+	metadata[MetadataNotify] = hints.Notify
+	metadata[MetadataHintRule] = hints.Rule
+
+	if hints.Deadline <= 0 {
+		return
+	}
+	start, err := time.Parse(time.RFC3339, metadata["build_created_at"])
+	if err != nil {
+		start = time.Now()
+	}
+	metadata[MetadataTriageDeadline] = start.Add(hints.Deadline).Format(time.RFC3339)
+}
@@ -3,12 +3,15 @@ package analyze
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"destill-agent/src/broker"
 	"destill-agent/src/contracts"
 	"destill-agent/src/logger"
+	"destill-agent/src/rules"
 )
 
 func TestAgent_Creation(t *testing.T) {
@@ -72,7 +75,7 @@ func TestAgent_ProcessChunk(t *testing.T) {
 	}
 
 	// Process chunk
-	if err := agent.processChunk(ctx, msg); err != nil {
+	if _, err := agent.processChunk(ctx, msg); err != nil {
 		t.Fatalf("processChunk failed: %v", err)
 	}
 
@@ -112,6 +115,138 @@ func TestAgent_ProcessChunk(t *testing.T) {
 	t.Logf("Successfully received %d findings", findingsReceived)
 }
 
+func TestAgent_RecordsPatternConfigHash(t *testing.T) {
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	findingsChan, err := brk.Subscribe(ctx, contracts.TopicAnalysisFindings, "test-consumer")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	rs, err := rules.Compile(&rules.Config{
+		Patterns: []rules.Pattern{{Name: "db", Regex: "database", Weight: 0.2}},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	agent := NewAgent(brk, logger.NewSilentLogger())
+	agent.SetRules(rs)
+
+	chunkData, _ := json.Marshal(contracts.LogChunk{
+		RequestID: "req-hash",
+		Content:   "ERROR: database connection refused",
+		LineStart: 1,
+	})
+	if _, err := agent.processChunk(ctx, broker.Message{Topic: contracts.TopicLogsRaw, Value: chunkData}); err != nil {
+		t.Fatalf("processChunk failed: %v", err)
+	}
+
+	select {
+	case msg := <-findingsChan:
+		var card contracts.TriageCard
+		if err := json.Unmarshal(msg.Value, &card); err != nil {
+			t.Fatalf("Failed to unmarshal finding: %v", err)
+		}
+		if got := card.Metadata[MetadataPatternConfigHash]; got != rs.Hash() {
+			t.Errorf("Metadata[%s] = %q, want %q", MetadataPatternConfigHash, got, rs.Hash())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for finding")
+	}
+}
+
+func TestAgent_AppliesPipelineHints(t *testing.T) {
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	findingsChan, err := brk.Subscribe(ctx, contracts.TopicAnalysisFindings, "test-consumer")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	rs, err := rules.Compile(&rules.Config{
+		Pipelines: []rules.PipelineRule{{Name: "nightly", Trigger: "schedule", Notify: rules.NotifyNone, Deadline: "2h"}},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	agent := NewAgent(brk, logger.NewSilentLogger())
+	agent.SetRules(rs)
+
+	chunkData, _ := json.Marshal(contracts.LogChunk{
+		RequestID: "req-hints",
+		Content:   "ERROR: database connection refused",
+		LineStart: 1,
+		Metadata: map[string]string{
+			"pipeline_name":    "acme/api",
+			"trigger":          "schedule",
+			"build_created_at": "2024-01-01T02:00:00Z",
+		},
+	})
+	if _, err := agent.processChunk(ctx, broker.Message{Topic: contracts.TopicLogsRaw, Value: chunkData}); err != nil {
+		t.Fatalf("processChunk failed: %v", err)
+	}
+
+	select {
+	case msg := <-findingsChan:
+		var card contracts.TriageCard
+		if err := json.Unmarshal(msg.Value, &card); err != nil {
+			t.Fatalf("Failed to unmarshal finding: %v", err)
+		}
+		want := map[string]string{
+			MetadataPriority:       rules.PriorityLow,
+			MetadataNotify:         rules.NotifyNone,
+			MetadataHintRule:       "nightly",
+			MetadataTriageDeadline: "2024-01-01T04:00:00Z",
+		}
+		for key, value := range want {
+			if got := card.Metadata[key]; got != value {
+				t.Errorf("Metadata[%s] = %q, want %q", key, got, value)
+			}
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for finding")
+	}
+}
+
+func TestAgent_AnnotatesIgnoredFindings(t *testing.T) {
+	rs, err := rules.Compile(&rules.Config{
+		Ignores: []rules.IgnoreRule{{Name: "teardown", Pipeline: "acme/*", Regex: "teardown", Reason: "known noisy teardown"}},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	chunk := func(pipeline string) contracts.LogChunk {
+		return contracts.LogChunk{
+			RequestID: "req-ignore",
+			Content:   "ERROR: database connection refused during teardown",
+			LineStart: 1,
+			Metadata:  map[string]string{"pipeline_name": pipeline},
+		}
+	}
+
+	// Suppressed findings are kept, annotated with the rule
+	result := Reanalyze([]contracts.LogChunk{chunk("acme/api")}, rs, 0)
+	if len(result.Cards) != 1 {
+		t.Fatalf("Reanalyze() = %d cards, want 1", len(result.Cards))
+	}
+	card := result.Cards[0]
+	if !card.Suppressed() || card.Metadata[contracts.MetadataSuppressedBy] != "teardown" ||
+		card.Metadata[contracts.MetadataSuppressedReason] != "known noisy teardown" {
+		t.Errorf("Metadata = %v, want it suppressed by teardown", card.Metadata)
+	}
+
+	// Other pipelines aren't affected
+	if result = Reanalyze([]contracts.LogChunk{chunk("other/api")}, rs, 0); len(result.Cards) != 1 || result.Cards[0].Suppressed() {
+		t.Errorf("Reanalyze(other pipeline) = %+v, want an unsuppressed finding", result.Cards)
+	}
+}
+
 func TestAgent_EmptyChunk(t *testing.T) {
 	ctx := context.Background()
 	brk := broker.NewInMemoryBroker()
@@ -133,7 +268,7 @@ func TestAgent_EmptyChunk(t *testing.T) {
 	}
 
 	// Should not error on empty chunk
-	if err := agent.processChunk(ctx, msg); err != nil {
+	if _, err := agent.processChunk(ctx, msg); err != nil {
 		t.Errorf("Expected no error for empty chunk, got %v", err)
 	}
 }
@@ -165,7 +300,7 @@ func TestAgent_ChunkWithoutErrors(t *testing.T) {
 	}
 
 	// Process chunk
-	if err := agent.processChunk(ctx, msg); err != nil {
+	if _, err := agent.processChunk(ctx, msg); err != nil {
 		t.Fatalf("processChunk failed: %v", err)
 	}
 
@@ -222,7 +357,7 @@ func TestAgent_IntegrationFlow(t *testing.T) {
 			Value: chunkData,
 		}
 
-		if err := agent.processChunk(ctx, msg); err != nil {
+		if _, err := agent.processChunk(ctx, msg); err != nil {
 			t.Fatalf("processChunk failed: %v", err)
 		}
 	}
@@ -255,3 +390,138 @@ func TestAgent_IntegrationFlow(t *testing.T) {
 	t.Logf("Successfully processed %d chunks and received %d findings",
 		len(chunks), findingsReceived)
 }
+
+func TestAgent_Drain(t *testing.T) {
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	findingsChan, err := brk.Subscribe(ctx, contracts.TopicAnalysisFindings, "test-consumer")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// The first chunk is being analyzed when the drain starts
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	agent := NewAgent(brk, logger.NewSilentLogger())
+	agent.analyze = func(chunk contracts.LogChunk, rs *rules.RuleSet) ([]Finding, GuardStats) {
+		started <- struct{}{}
+		<-release
+		return AnalyzeChunkWithStats(chunk, rs)
+	}
+
+	msgChan := make(chan broker.Message, 2)
+	for i, job := range []string{"job-1", "job-2"} {
+		data, _ := json.Marshal(contracts.LogChunk{
+			RequestID: "req-drain", JobName: "unit", JobID: job, ChunkIndex: 0, TotalChunks: 1,
+			Content: "FATAL: System crash", LineStart: 1, LineEnd: 1,
+			Metadata: map[string]string{"build_url": "https://example.com"},
+		})
+		msgChan <- broker.Message{Topic: contracts.TopicLogsRaw, Key: "req-drain/" + job, Value: data, Offset: int64(i)}
+	}
+
+	var handled []int64
+	agent.handled = func(msg broker.Message) { handled = append(handled, msg.Offset) }
+
+	done := make(chan error, 1)
+	go func() { done <- agent.RunWithChannel(ctx, msgChan) }()
+
+	<-started
+	agent.Drain()
+	agent.Drain() // Safe to call twice
+	close(release)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("RunWithChannel() error = %v, want nil after a drain", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("agent didn't stop after draining")
+	}
+
+	// The chunk in flight was finished and committed; the next wasn't read
+	select {
+	case <-findingsChan:
+	default:
+		t.Error("expected the finding of the chunk in flight to be published")
+	}
+	if len(handled) != 1 || handled[0] != 0 {
+		t.Errorf("handled offsets = %v, want [0]", handled)
+	}
+	if len(msgChan) != 1 {
+		t.Errorf("%d chunks left unread, want 1", len(msgChan))
+	}
+}
+
+// flakyBroker fails to publish findings until fail is cleared.
+type flakyBroker struct {
+	*broker.InMemoryBroker
+	fail     atomic.Bool
+	attempts atomic.Int32
+}
+
+func (b *flakyBroker) Publish(ctx context.Context, topic, key string, value []byte) error {
+	if topic == contracts.TopicAnalysisFindings {
+		b.attempts.Add(1)
+		if b.fail.Load() {
+			return errors.New("broker unavailable")
+		}
+	}
+	return b.InMemoryBroker.Publish(ctx, topic, key, value)
+}
+
+func TestAgent_RetriesPublishBeforeCommitting(t *testing.T) {
+	defer func(delay time.Duration) { broker.RetryDelay = delay }(broker.RetryDelay)
+	broker.RetryDelay = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	brk := &flakyBroker{InMemoryBroker: broker.NewInMemoryBroker()}
+	defer brk.Close()
+	brk.fail.Store(true)
+	findingsChan, err := brk.Subscribe(ctx, contracts.TopicAnalysisFindings, "test-findings")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	agent := NewAgent(brk, logger.NewSilentLogger())
+	var committed atomic.Int32
+	agent.handled = func(broker.Message) { committed.Add(1) }
+
+	// A retried job's finding isn't held back to merge, so it's published
+	// while the chunk is processed
+	data, _ := json.Marshal(contracts.LogChunk{
+		RequestID: "req-flaky",
+		JobID:     "job-1",
+		JobName:   "test",
+		Content:   "ERROR: connection refused to db:5432",
+		LineStart: 1,
+		Metadata:  map[string]string{contracts.MetadataRetryGroup: "job-1"},
+	})
+	msgChan := make(chan broker.Message, 1)
+	msgChan <- broker.Message{Topic: contracts.TopicLogsRaw, Value: data}
+	go agent.RunWithChannel(ctx, msgChan)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for brk.attempts.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if committed.Load() != 0 {
+		t.Fatal("chunk committed while its finding failed to publish")
+	}
+
+	brk.fail.Store(false)
+	select {
+	case <-findingsChan:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for the finding to be published")
+	}
+	for committed.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if committed.Load() != 1 {
+		t.Errorf("chunk committed %d times, want once after its finding was published", committed.Load())
+	}
+}
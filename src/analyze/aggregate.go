@@ -0,0 +1,137 @@
+package analyze
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+const (
+	// aggregateIdle is how long the agent waits for another chunk before
+	// publishing the findings it's merging.
+	aggregateIdle = 250 * time.Millisecond
+
+	// aggregateWindow is the longest a request's findings are held back
+	// while its chunks keep coming.
+	aggregateWindow = 2 * time.Second
+)
+
+// aggregator merges the findings whose message recurs across a request's
+// jobs, as when a build's matrix fans one failure out to every leg, into
+// one card naming all the jobs (contracts.MetadataJobs), so it's published
+// and stored once rather than once per job. A request's findings are held
+// in a batch until its analysis completes, the agent goes idle, or the
+// batch is aggregateWindow old. Not safe for concurrent use; the agent's
+// processing loop owns it.
+type aggregator struct {
+	batches map[string]*batch // Request ID -> findings merged since the last flush
+	lastID  int               // ID of the newest batch
+	idle    time.Duration
+	window  time.Duration
+	now     func() time.Time
+}
+
+// batch is the findings of a request merged since it was last flushed.
+type batch struct {
+	id        int
+	requestID string
+	cards     []contracts.TriageCard
+	index     map[string]int // aggregateKey -> index in cards
+	started   time.Time
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{
+		batches: make(map[string]*batch),
+		idle:    aggregateIdle,
+		window:  aggregateWindow,
+		now:     time.Now,
+	}
+}
+
+// aggregates reports whether a card is merged with others of its request.
+// Findings of a retried job aren't: ranking compares attempts by their
+// cards.
+func aggregates(card contracts.TriageCard) bool {
+	return card.Metadata[contracts.MetadataRetryGroup] == ""
+}
+
+// aggregateKey is what merged findings have in common: the message, and
+// the job state and cascade ranking tiers it by, so a finding in a failed
+// job isn't merged with one in a passing job, nor a root cause with its
+// downstream echo.
+func aggregateKey(card contracts.TriageCard) string {
+	return strings.Join([]string{
+		card.MessageHash,
+		card.Metadata["job_state"],
+		card.Metadata[contracts.MetadataCascade],
+	}, "\x00")
+}
+
+// add merges a card into its request's batch.
+func (g *aggregator) add(card contracts.TriageCard) {
+	b := g.batches[card.RequestID]
+	if b == nil {
+		g.lastID++
+		b = &batch{id: g.lastID, requestID: card.RequestID, index: make(map[string]int), started: g.now()}
+		g.batches[card.RequestID] = b
+	}
+	key := aggregateKey(card)
+	if i, ok := b.index[key]; ok {
+		merge(&b.cards[i], card)
+		return
+	}
+	b.index[key] = len(b.cards)
+	b.cards = append(b.cards, card)
+}
+
+// merge folds another occurrence of a finding into its merged card. The
+// most confident occurrence stands for them all, listing its job first.
+func merge(merged *contracts.TriageCard, card contracts.TriageCard) {
+	count := merged.GetRecurrenceCount() + card.GetRecurrenceCount()
+	jobs := append(merged.Jobs(), card.Jobs()...)
+	if card.ConfidenceScore > merged.ConfidenceScore {
+		*merged = card
+		delete(merged.Metadata, contracts.MetadataJobs)
+	}
+	merged.AddJobs(jobs...)
+	merged.SetRecurrenceCount(count)
+}
+
+// pending reports whether any findings are held back.
+func (g *aggregator) pending() bool {
+	return len(g.batches) > 0
+}
+
+// take removes and returns the batch of requestID, or nil if it has none.
+func (g *aggregator) take(requestID string) *batch {
+	b := g.batches[requestID]
+	delete(g.batches, requestID)
+	return b
+}
+
+// due removes and returns the batches held back for the aggregation
+// window, or every batch when all is set.
+func (g *aggregator) due(all bool) []*batch {
+	var due []*batch
+	for requestID, b := range g.batches {
+		if all || g.now().Sub(b.started) >= g.window {
+			due = append(due, b)
+			delete(g.batches, requestID)
+		}
+	}
+	return due
+}
+
+// oldest returns the ID of the oldest batch held back, or math.MaxInt when
+// none is: every finding of a chunk handled before that batch was started
+// is published.
+func (g *aggregator) oldest() int {
+	oldest := math.MaxInt
+	for _, b := range g.batches {
+		oldest = min(oldest, b.id)
+	}
+	return oldest
+}
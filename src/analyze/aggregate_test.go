@@ -0,0 +1,163 @@
+package analyze
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/logger"
+)
+
+func TestAggregator_MergesAcrossJobs(t *testing.T) {
+	card := func(job, state string, confidence float64) contracts.TriageCard {
+		return contracts.TriageCard{
+			RequestID:       "req",
+			MessageHash:     "hash",
+			JobName:         job,
+			ConfidenceScore: confidence,
+			Metadata:        map[string]string{"job_state": state},
+		}
+	}
+
+	g := newAggregator()
+	g.add(card("test (linux)", "failed", 0.7))
+	g.add(card("test (macos)", "failed", 0.9))
+	g.add(card("test (windows)", "failed", 0.7))
+	g.add(card("lint", "passed", 0.7))
+	if !g.pending() {
+		t.Fatal("pending() = false after adding findings")
+	}
+
+	b := g.take("req")
+	if b == nil || len(b.cards) != 2 {
+		t.Fatalf("take() = %+v, want one card for the failed jobs and one for the passing job", b)
+	}
+	merged := b.cards[0]
+	if merged.JobName != "test (macos)" || merged.ConfidenceScore != 0.9 {
+		t.Errorf("merged card is %s at %.1f, want the most confident occurrence", merged.JobName, merged.ConfidenceScore)
+	}
+	if got := merged.Metadata[contracts.MetadataJobs]; got != "test (macos); test (linux); test (windows)" {
+		t.Errorf("jobs = %q, want all three, the merged card's first", got)
+	}
+	if merged.GetRecurrenceCount() != 3 {
+		t.Errorf("recurrence count = %d, want 3", merged.GetRecurrenceCount())
+	}
+	if _, ok := b.cards[1].Metadata[contracts.MetadataJobs]; ok {
+		t.Errorf("passing job's card lists jobs: %v", b.cards[1].Metadata)
+	}
+	if g.pending() || g.oldest() <= b.id {
+		t.Error("batch still held back after take()")
+	}
+
+	retried := card("test (linux)", "failed", 0.7)
+	retried.Metadata[contracts.MetadataRetryGroup] = "job-1"
+	if aggregates(retried) {
+		t.Error("aggregates() = true for a retried job's finding")
+	}
+}
+
+func TestAggregator_Due(t *testing.T) {
+	g := newAggregator()
+	now := time.Now()
+	g.now = func() time.Time { return now }
+	g.add(contracts.TriageCard{RequestID: "old", MessageHash: "a"})
+	now = now.Add(g.window)
+	g.add(contracts.TriageCard{RequestID: "new", MessageHash: "a"})
+
+	due := g.due(false)
+	if len(due) != 1 || due[0].requestID != "old" {
+		t.Fatalf("due(false) = %+v, want the batch held for the window", due)
+	}
+	if len(g.due(true)) != 1 || g.pending() {
+		t.Error("due(true) should take every remaining batch")
+	}
+}
+
+func TestAgent_MergesFindingsAcrossJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	findingsChan, err := brk.Subscribe(ctx, contracts.TopicAnalysisFindings, "test-findings")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	doneChan, err := brk.Subscribe(ctx, contracts.TopicControl, "test-control")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	agent := NewAgent(brk, logger.NewSilentLogger())
+	var mu sync.Mutex
+	committed := 0
+	agent.handled = func(broker.Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		committed++
+	}
+
+	msgChan := make(chan broker.Message, 3)
+	controlChan := make(chan broker.Message, 1)
+	jobs := []string{"test (linux)", "test (macos)", "test (windows)"}
+	for i, job := range jobs {
+		data, _ := json.Marshal(contracts.LogChunk{
+			RequestID:   "req-matrix",
+			JobName:     job,
+			JobID:       job,
+			TotalChunks: 1,
+			Content:     "INFO: running tests\nERROR: connection refused to db:5432",
+			LineStart:   1,
+			Metadata:    map[string]string{"job_state": "failed"},
+		})
+		msgChan <- broker.Message{Topic: contracts.TopicLogsRaw, Key: job, Value: data, Offset: int64(i)}
+	}
+	data, _ := json.Marshal(contracts.ControlMessage{Type: contracts.ControlIngestComplete, RequestID: "req-matrix", Chunks: len(jobs)})
+	controlChan <- broker.Message{Topic: contracts.TopicControl, Value: data}
+
+	go agent.RunWithChannels(ctx, msgChan, controlChan)
+
+	var cards []contracts.TriageCard
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case msg := <-findingsChan:
+			var card contracts.TriageCard
+			if err := json.Unmarshal(msg.Value, &card); err != nil {
+				t.Fatalf("Failed to unmarshal finding: %v", err)
+			}
+			cards = append(cards, card)
+			continue
+		case msg := <-doneChan:
+			var control contracts.ControlMessage
+			if err := json.Unmarshal(msg.Value, &control); err != nil || control.Type != contracts.ControlAnalysisComplete {
+				continue
+			}
+			if control.Findings != 1 {
+				t.Errorf("analysis complete with %d findings, want 1", control.Findings)
+			}
+		case <-timeout:
+			t.Fatal("Timeout waiting for the request's analysis to complete")
+		}
+		break
+	}
+
+	// The merged finding is published before the request's completion
+	if len(cards) != 1 {
+		t.Fatalf("got %d findings, want 1 merged across the jobs", len(cards))
+	}
+	if got := cards[0].Jobs(); len(got) != len(jobs) || cards[0].GetRecurrenceCount() != len(jobs) {
+		t.Errorf("finding in jobs %q, %d times; want all %d jobs", got, cards[0].GetRecurrenceCount(), len(jobs))
+	}
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if committed != len(jobs) {
+		t.Errorf("%d chunks committed, want all %d once their findings were published", committed, len(jobs))
+	}
+}
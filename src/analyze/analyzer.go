@@ -6,10 +6,13 @@ import (
 	"encoding/hex"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"destill-agent/src/contracts"
 	"destill-agent/src/patterns"
+	"destill-agent/src/provider"
+	"destill-agent/src/rules"
 )
 
 const (
@@ -18,6 +21,12 @@ const (
 
 	// PostContextLines is the number of lines to extract after an error (from chunk)
 	PostContextLines = 30
+
+	// MinConfidence is the minimum confidence for a line to be reported as a finding
+	MinConfidence = 0.5
+
+	// minLineLength skips empty or very short lines
+	minLineLength = 10
 )
 
 var (
@@ -115,15 +124,33 @@ type Finding struct {
 	PreContext      []string
 	PostContext     []string
 	ContextNote     string
+	Test            *TestFailure // Set for a failed test recognized in test runner output
+	Stack           *StackTrace  // Set for a finding assembled from a stack trace
+	ExitDistance    int          // Lines before the failed job's exit line, if within contracts.ExitWindowLines
+	ExitAnchor      bool         // The nearest finding before the exit line (see anchorToExit)
 }
 
 // AnalyzeChunk processes a single log chunk and returns findings.
 // This is stateless - it only looks within the provided chunk.
 func AnalyzeChunk(chunk contracts.LogChunk) []Finding {
-	// Split content into lines
-	lines := strings.Split(chunk.Content, "\n")
+	return AnalyzeChunkWithRules(chunk, nil)
+}
+
+// AnalyzeChunkWithRules is AnalyzeChunk with user patterns and suppressions
+// from rs applied. A nil rs uses only the built-in patterns.
+func AnalyzeChunkWithRules(chunk contracts.LogChunk, rs *rules.RuleSet) []Finding {
+	findings, _ := AnalyzeChunkWithStats(chunk, rs)
+	return findings
+}
+
+// AnalyzeChunkWithStats is AnalyzeChunkWithRules, also counting the lines
+// the guards truncated or skipped (see DefaultMaxLineLength).
+func AnalyzeChunkWithStats(chunk contracts.LogChunk, rs *rules.RuleSet) ([]Finding, GuardStats) {
+	// Split content into lines, cutting long lines and leaving binary and
+	// encoded ones out
+	lines, skip, stats := guardLines(strings.Split(chunk.Content, "\n"), maxLineLength)
 	if len(lines) == 0 {
-		return nil
+		return nil, stats
 	}
 
 	// Check job outcome based on exit status
@@ -138,46 +165,152 @@ func AnalyzeChunk(chunk contracts.LogChunk) []Finding {
 		}
 	}
 
+	// A timed out or canceled job was killed wherever it was; the lines
+	// printed as it was aren't errors of their own
+	interrupted := chunk.Metadata[contracts.MetadataInterrupted] != ""
+
+	// Per-request overrides from the AnalysisRequest options
+	minConfidence, preLines, postLines := MinConfidence, PreContextLines, PostContextLines
+	if opts := chunk.Options; opts != nil {
+		if opts.MinConfidence > 0 {
+			minConfidence = opts.MinConfidence
+		}
+		if opts.PreContextLines > 0 {
+			preLines = opts.PreContextLines
+		}
+		if opts.PostContextLines > 0 {
+			postLines = opts.PostContextLines
+		}
+	}
+
+	adjust := func(confidence float64) float64 {
+		// Adjust confidence based on job outcome:
+		// - Boost for failed jobs (errors more likely to be root cause)
+		// - Penalize for passed jobs (errors are likely noise/teardown)
+		if jobFailed {
+			return boostConfidenceForFailedJob(confidence)
+		} else if jobPassed {
+			return penalizeConfidenceForPassedJob(confidence)
+		}
+		return confidence
+	}
+
 	var findings []Finding
 
+	// Failed tests in test runner output become one finding each, keyed by
+	// test name; the lines of their blocks aren't scanned on their own
+	covered := make([]bool, len(lines))
+	for _, failure := range parseTestFailures(lines) {
+		for i := failure.start; i < failure.end; i++ {
+			covered[i] = true
+		}
+		line := lines[failure.Line]
+		if skip[failure.Line] {
+			continue
+		}
+		if _, suppressed := rs.Suppressed(strings.TrimSpace(line)); suppressed {
+			continue
+		}
+		confidence := adjust(TestFailureConfidence)
+		if confidence < minConfidence {
+			continue
+		}
+		preContext, postContext, contextNote := extractContextSized(lines, failure.Line, preLines, postLines)
+		failure := failure
+		findings = append(findings, Finding{
+			LineNumber:      chunk.LineStart + failure.Line,
+			RawMessage:      line,
+			NormalizedMsg:   TestFailureMessage(failure.Name),
+			Severity:        "ERROR",
+			ConfidenceScore: confidence,
+			PreContext:      preContext,
+			PostContext:     postContext,
+			ContextNote:     contextNote,
+			Test:            &failure,
+		})
+	}
+
+	// Stack traces become one finding each, with the exception header as
+	// the message and the frames in its context
+	for _, trace := range parseStackTraces(lines) {
+		if anyCovered(covered, trace.start, trace.end) {
+			continue
+		}
+		for i := trace.start; i < trace.end; i++ {
+			covered[i] = true
+		}
+		header := strings.TrimSpace(lines[trace.Header])
+		if skip[trace.Header] {
+			continue
+		}
+		if _, suppressed := rs.Suppressed(header); suppressed {
+			continue
+		}
+		severity, _ := lineSeverity(header, rs)
+		if severity != "FATAL" {
+			severity = "ERROR"
+		}
+		confidence := adjust(StackTraceConfidence)
+		if confidence < minConfidence {
+			continue
+		}
+		// The context always holds the whole trace
+		preContext, postContext, contextNote := extractContextSized(lines, trace.Header,
+			max(preLines, trace.Header-trace.start), max(postLines, trace.end-1-trace.Header))
+		trace := trace
+		findings = append(findings, Finding{
+			LineNumber:      chunk.LineStart + trace.Header,
+			RawMessage:      lines[trace.Header],
+			NormalizedMsg:   stackTraceMessage(normalizeLine(header, rs), trace.TopFrame),
+			Severity:        severity,
+			ConfidenceScore: confidence,
+			PreContext:      preContext,
+			PostContext:     postContext,
+			ContextNote:     contextNote,
+			Stack:           &trace,
+		})
+	}
+
 	// Process each line
 	for i, line := range lines {
-		// Skip empty or very short lines
+		// Skip empty or very short lines, unscannable content, and test
+		// failures and stack traces reported above
 		trimmed := strings.TrimSpace(line)
-		if len(trimmed) < 10 {
+		if skip[i] || covered[i] || len(trimmed) < minLineLength {
 			continue
 		}
 
-		// Detect severity
-		severity := detectSeverity(trimmed)
+		// Detect severity, honoring user severity overrides
+		severity, _ := lineSeverity(trimmed, rs)
 
 		// Only process ERROR and FATAL
 		if severity != "ERROR" && severity != "FATAL" {
 			continue
 		}
 
+		// Drop lines the user has suppressed
+		if _, suppressed := rs.Suppressed(trimmed); suppressed {
+			continue
+		}
+		if interrupted && killLine(trimmed) {
+			continue
+		}
+
 		// Calculate confidence
-		confidence := calculateConfidence(trimmed, severity)
+		confidence, _ := scoreLine(trimmed, severity, rs)
 
-		// Adjust confidence based on job outcome:
-		// - Boost for failed jobs (errors more likely to be root cause)
-		// - Penalize for passed jobs (errors are likely noise/teardown)
-		if jobFailed {
-			confidence = boostConfidenceForFailedJob(confidence)
-		} else if jobPassed {
-			confidence = penalizeConfidenceForPassedJob(confidence)
-		}
+		confidence = adjust(confidence)
 
 		// Skip low confidence findings
-		if confidence < 0.5 {
+		if confidence < minConfidence {
 			continue
 		}
 
 		// Normalize message
-		normalized := normalizeMessage(trimmed)
+		normalized := normalizeLine(trimmed, rs)
 
 		// Extract context from within this chunk only
-		preContext, postContext, contextNote := extractContext(lines, i)
+		preContext, postContext, contextNote := extractContextSized(lines, i, preLines, postLines)
 
 		finding := Finding{
 			LineNumber:      chunk.LineStart + i,
@@ -193,7 +326,18 @@ func AnalyzeChunk(chunk contracts.LogChunk) []Finding {
 		findings = append(findings, finding)
 	}
 
-	return findings
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].LineNumber < findings[j].LineNumber
+	})
+
+	// The errors right before a failed job's exit line usually caused it.
+	// Chunks overlap, so the window before an exit line is in its chunk.
+	if !jobPassed && !interrupted {
+		if exit := exitLine(lines); exit >= 0 {
+			anchorToExit(findings, chunk.LineStart+exit)
+		}
+	}
+	return findings, stats
 }
 
 // detectSeverity determines the severity level of a log line.
@@ -210,133 +354,135 @@ func detectSeverity(line string) string {
 	return "INFO"
 }
 
-// calculateConfidence calculates a confidence score for a finding.
-func calculateConfidence(line string, severity string) float64 {
-	score := 0.5 // Base score
-	lower := strings.ToLower(line)
+// lineSeverity returns the severity of a line: the first matching severity
+// rule in rs, or detectSeverity. override names the rule, if any.
+func lineSeverity(line string, rs *rules.RuleSet) (severity, override string) {
+	if severity, rule, ok := rs.Severity(line); ok {
+		return severity, rule
+	}
+	return detectSeverity(line), ""
+}
+
+// PatternMatch records a single confidence adjustment applied to a line.
+type PatternMatch struct {
+	Name  string  `json:"name"`           // Human-readable pattern name, e.g. "stack trace"
+	Delta float64 `json:"delta"`          // Score change applied (positive = boost, negative = penalty)
+	User  bool    `json:"user,omitempty"` // From the user pattern config rather than built in
+}
+
+// scoringRule is a named built-in confidence adjustment.
+type scoringRule struct {
+	name  string
+	delta float64
+	match func(line, lower, severity string) bool
+}
+
+// matchAny returns a rule matcher that fires if any pattern matches the line.
+func matchAny(patterns ...*regexp.Regexp) func(line, lower, severity string) bool {
+	return func(line, _, _ string) bool {
+		for _, p := range patterns {
+			if p.MatchString(line) {
+				return true
+			}
+		}
+		return false
+	}
+}
 
+// builtinRules are applied in order by scoreLine.
+var builtinRules = []scoringRule{
 	// === BOOSTS ===
 
 	// High confidence indicators (structured log prefix)
-	if highConfidencePattern.MatchString(line) {
-		score += 0.25
-	}
+	{"structured log prefix", 0.25, matchAny(highConfidencePattern)},
 
 	// Severity boost
-	if severity == "FATAL" {
-		score += 0.2
-	} else if severity == "ERROR" {
-		score += 0.1
-	}
+	{"FATAL severity", 0.2, func(_, _, severity string) bool { return severity == "FATAL" }},
+	{"ERROR severity", 0.1, func(_, _, severity string) bool { return severity == "ERROR" }},
 
 	// Stack traces (very high signal)
-	if stackTraceJava.MatchString(line) || stackTracePython.MatchString(line) ||
-		pythonFileLine.MatchString(line) || panicGo.MatchString(line) ||
-		stackTraceCpp.MatchString(line) || terminateCpp.MatchString(line) {
-		score += 0.30
-	}
+	{"stack trace", 0.30, matchAny(stackTraceJava, stackTracePython, pythonFileLine, panicGo, stackTraceCpp, terminateCpp)},
 
 	// Build tool errors (definitive)
-	if npmError.MatchString(line) || npmCodes.MatchString(line) ||
-		mavenFailure.MatchString(line) || gradleFailure.MatchString(line) {
-		score += 0.30
-	}
+	{"build tool error", 0.30, matchAny(npmError, npmCodes, mavenFailure, gradleFailure)},
 
 	// Docker/K8s errors
-	if dockerError.MatchString(line) || k8sErrors.MatchString(line) {
-		score += 0.30
-	}
+	{"docker/kubernetes error", 0.30, matchAny(dockerError, k8sErrors)},
 
 	// Crashes and resource issues (very high signal)
-	if oomPattern.MatchString(line) || segfaultPattern.MatchString(line) {
-		score += 0.35
-	}
+	{"crash or resource exhaustion", 0.35, matchAny(oomPattern, segfaultPattern)},
 
-	// Timeout errors
-	if timeoutPattern.MatchString(line) {
-		score += 0.20
-	}
-
-	// Exit code failures
-	if exitCodePattern.MatchString(line) || nonZeroExit.MatchString(line) {
-		score += 0.25
-	}
-
-	// Compilation/syntax/import errors
-	if compileError.MatchString(line) || syntaxError.MatchString(line) || importError.MatchString(line) {
-		score += 0.25
-	}
-
-	// Permission/auth errors
-	if permissionError.MatchString(line) {
-		score += 0.20
-	}
-
-	// Connection failures
-	if connectionError.MatchString(line) {
-		score += 0.20
-	}
-
-	// Assertion failures
-	if assertionError.MatchString(line) {
-		score += 0.25
-	}
+	{"timeout", 0.20, matchAny(timeoutPattern)},
+	{"non-zero exit code", 0.25, matchAny(exitCodePattern, nonZeroExit)},
+	{"compile/syntax/import error", 0.25, matchAny(compileError, syntaxError, importError)},
+	{"permission error", 0.20, matchAny(permissionError)},
+	{"connection failure", 0.20, matchAny(connectionError)},
+	{"assertion failure", 0.25, matchAny(assertionError)},
 
 	// === PENALTIES ===
 
 	// "0 errors" or "no errors" - success message (heavy penalty)
-	if zeroErrorsPattern.MatchString(line) {
-		score -= 0.50
-	}
+	{"zero errors message", -0.50, matchAny(zeroErrorsPattern)},
 
 	// Test expectations (testing for errors, not actual errors)
-	if testExpectPattern.MatchString(line) {
-		score -= 0.40
-	}
+	{"test expectation", -0.40, matchAny(testExpectPattern)},
 
-	// Caught/handled errors
-	if handledErrorPattern.MatchString(line) {
-		score -= 0.30
-	}
+	{"handled error", -0.30, matchAny(handledErrorPattern)},
+	{"error identifier", -0.25, matchAny(errorVarPattern)},
+	{"success after retry", -0.40, matchAny(retrySuccessPattern)},
+	{"comment", -0.30, matchAny(commentPattern)},
 
-	// Error in variable/function names
-	if errorVarPattern.MatchString(line) {
-		score -= 0.25
-	}
+	// Quoted log levels (format strings, not actual errors)
+	{"quoted log level", -0.30, matchAny(quotedLevelPattern)},
 
-	// Success after retry
-	if retrySuccessPattern.MatchString(line) {
-		score -= 0.40
-	}
+	{"help text", -0.25, matchAny(helpTextPattern)},
+	{"test passed message", -0.30, func(_, lower, _ string) bool {
+		return strings.Contains(lower, "test") && strings.Contains(lower, "passed")
+	}},
 
-	// Comments
-	if commentPattern.MatchString(line) {
-		score -= 0.30
-	}
+	// Deprecation warnings (usually not actionable)
+	{"deprecation", -0.20, func(_, lower, _ string) bool {
+		return strings.Contains(lower, "deprecated") || strings.Contains(lower, "deprecation")
+	}},
 
-	// Quoted log levels (format strings, not actual errors)
-	if quotedLevelPattern.MatchString(line) {
-		score -= 0.30
-	}
+	// Retry without failure context (might be transient)
+	{"retry without failure", -0.15, func(_, lower, _ string) bool {
+		return strings.Contains(lower, "retry") && !strings.Contains(lower, "failed") && !strings.Contains(lower, "error")
+	}},
+}
 
-	// Help/documentation text
-	if helpTextPattern.MatchString(line) {
-		score -= 0.25
+// BuiltinPatterns lists the built-in scoring patterns and their deltas.
+func BuiltinPatterns() []PatternMatch {
+	patterns := make([]PatternMatch, len(builtinRules))
+	for i, r := range builtinRules {
+		patterns[i] = PatternMatch{Name: r.name, Delta: r.delta}
 	}
+	return patterns
+}
 
-	// Test passed messages
-	if strings.Contains(lower, "test") && strings.Contains(lower, "passed") {
-		score -= 0.30
-	}
+// calculateConfidence calculates a confidence score for a finding.
+func calculateConfidence(line string, severity string) float64 {
+	score, _ := scoreLine(line, severity, nil)
+	return score
+}
 
-	// Deprecation warnings (usually not actionable)
-	if strings.Contains(lower, "deprecated") || strings.Contains(lower, "deprecation") {
-		score -= 0.20
-	}
+// scoreLine calculates a confidence score and records every pattern that
+// contributed to it, built-in rules first, then user patterns from rs.
+// The returned score is capped between 0 and 1.
+func scoreLine(line string, severity string, rs *rules.RuleSet) (float64, []PatternMatch) {
+	score := 0.5 // Base score
+	lower := strings.ToLower(line)
 
-	// Retry without failure context (might be transient)
-	if strings.Contains(lower, "retry") && !strings.Contains(lower, "failed") && !strings.Contains(lower, "error") {
-		score -= 0.15
+	var matches []PatternMatch
+	for _, r := range builtinRules {
+		if r.match(line, lower, severity) {
+			score += r.delta
+			matches = append(matches, PatternMatch{Name: r.name, Delta: r.delta})
+		}
+	}
+	for _, m := range rs.Match(line) {
+		score += m.Weight
+		matches = append(matches, PatternMatch{Name: m.Name, Delta: m.Weight, User: true})
 	}
 
 	// Cap between 0 and 1
@@ -347,7 +493,7 @@ func calculateConfidence(line string, severity string) float64 {
 		score = 0.0
 	}
 
-	return score
+	return score, matches
 }
 
 // boostConfidenceForFailedJob boosts confidence scores for findings from failed jobs.
@@ -401,15 +547,26 @@ func normalizeMessage(msg string) string {
 	return patterns.Normalize(msg, patterns.MaskRecurrence)
 }
 
+// normalizeLine applies the user normalization rules in rs to the raw line,
+// then the built-in normalization.
+func normalizeLine(line string, rs *rules.RuleSet) string {
+	return normalizeMessage(rs.Normalize(line))
+}
+
 // extractContext extracts surrounding lines from within the chunk.
 // Returns pre-context, post-context, and a note about truncation.
 func extractContext(lines []string, lineIndex int) ([]string, []string, string) {
+	return extractContextSized(lines, lineIndex, PreContextLines, PostContextLines)
+}
+
+// extractContextSized is extractContext with explicit pre/post line counts.
+func extractContextSized(lines []string, lineIndex, preLines, postLines int) ([]string, []string, string) {
 	var preContext []string
 	var postContext []string
 	var note string
 
 	// Extract pre-context
-	preStart := lineIndex - PreContextLines
+	preStart := lineIndex - preLines
 	if preStart < 0 {
 		note = "truncated at chunk start"
 		preStart = 0
@@ -422,7 +579,7 @@ func extractContext(lines []string, lineIndex int) ([]string, []string, string)
 	}
 
 	// Extract post-context
-	postEnd := lineIndex + PostContextLines + 1
+	postEnd := lineIndex + postLines + 1
 	if postEnd > len(lines) {
 		if note != "" {
 			note = "truncated at chunk boundaries"
@@ -462,6 +619,7 @@ func ConvertToTriageCard(finding Finding, chunk contracts.LogChunk, requestID st
 		RawMessage:      finding.RawMessage,
 		NormalizedMsg:   finding.NormalizedMsg,
 		ConfidenceScore: finding.ConfidenceScore,
+		Category:        categorize(finding),
 		PreContext:      finding.PreContext,
 		PostContext:     finding.PostContext,
 		ContextNote:     finding.ContextNote,
@@ -470,6 +628,19 @@ func ConvertToTriageCard(finding Finding, chunk contracts.LogChunk, requestID st
 		Metadata:        copyMetadata(chunk.Metadata),
 		Timestamp:       fmt.Sprintf("%d", 0), // Will be set by agent
 	}
+	// Artifact line numbers aren't lines of the job log
+	if chunk.Metadata[contracts.MetadataArtifact] == "" {
+		if link := provider.LineURL(chunk.Metadata, chunk.JobID, finding.LineNumber); link != "" {
+			card.Metadata[contracts.MetadataProviderLink] = link
+		}
+	}
+	if finding.Test != nil {
+		SetTestFailureMetadata(card.Metadata, *finding.Test)
+	}
+	if finding.Stack != nil {
+		setStackTraceMetadata(card.Metadata, *finding.Stack)
+	}
+	setExitMetadata(card.Metadata, finding)
 
 	return card
 }
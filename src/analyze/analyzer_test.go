@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"destill-agent/src/contracts"
+	"destill-agent/src/rules"
 )
 
 func TestDetectSeverity(t *testing.T) {
@@ -402,6 +403,30 @@ func TestConvertToTriageCard(t *testing.T) {
 	if card.MessageHash == "" {
 		t.Error("Expected message hash to be set")
 	}
+	if _, ok := card.Metadata[contracts.MetadataProviderLink]; ok {
+		t.Error("Expected no provider link without a known provider")
+	}
+}
+
+func TestConvertToTriageCard_ProviderLink(t *testing.T) {
+	finding := Finding{LineNumber: 5012, NormalizedMsg: "ERROR: boom"}
+	chunk := contracts.LogChunk{
+		JobID:     "0190a1b2-job",
+		LineStart: 5001,
+		Metadata: map[string]string{
+			"provider":  "buildkite",
+			"build_url": "https://buildkite.com/org/pipe/builds/42",
+		},
+	}
+
+	card := ConvertToTriageCard(finding, chunk, "req-123")
+	want := "https://buildkite.com/org/pipe/builds/42#0190a1b2-job/5012"
+	if got := card.Metadata[contracts.MetadataProviderLink]; got != want {
+		t.Errorf("provider link = %q, want %q", got, want)
+	}
+	if _, ok := chunk.Metadata[contracts.MetadataProviderLink]; ok {
+		t.Error("ConvertToTriageCard modified the chunk metadata")
+	}
 }
 
 func TestCalculateMessageHash(t *testing.T) {
@@ -428,3 +453,102 @@ func TestCalculateMessageHash(t *testing.T) {
 		t.Errorf("Expected hash length 64, got %d", len(hash1))
 	}
 }
+
+func TestAnalyzeChunkWithRules(t *testing.T) {
+	rs, err := rules.Compile(&rules.Config{
+		Patterns:     []rules.Pattern{{Name: "known noise", Regex: `cache miss`, Weight: -0.5}},
+		Suppressions: []rules.Suppression{{Name: "teardown", Regex: `during teardown`}},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	chunk := contracts.LogChunk{
+		Content: "ERROR: cache miss for key user:42\n" +
+			"ERROR: connection refused during teardown\n" +
+			"ERROR: connection refused to db:5432",
+		LineStart: 1,
+	}
+
+	if got := len(AnalyzeChunk(chunk)); got != 3 {
+		t.Fatalf("AnalyzeChunk() found %d, want 3 without rules", got)
+	}
+
+	findings := AnalyzeChunkWithRules(chunk, rs)
+	if len(findings) != 1 || findings[0].LineNumber != 3 {
+		t.Errorf("AnalyzeChunkWithRules() = %+v, want only line 3", findings)
+	}
+}
+
+func TestAnalyzeChunkWithRules_SeverityAndNormalization(t *testing.T) {
+	rs, err := rules.Compile(&rules.Config{
+		Severities: []rules.SeverityRule{
+			{Name: "acme failures", Regex: `^ACME-FAIL `, Severity: "error"},
+			{Name: "retried", Regex: `ERROR .*will retry`, Severity: "warn"},
+		},
+		Normalizations: []rules.Normalization{
+			{Name: "acme job ids", Regex: `acmejob-[a-z]+`, Replace: "acmejob-<id>"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	chunk := contracts.LogChunk{
+		Content: "ACME-FAIL widget assembly broke in acmejob-alpha\n" +
+			"ACME-FAIL widget assembly broke in acmejob-beta\n" +
+			"ERROR upload timed out, will retry",
+		LineStart: 1,
+	}
+
+	if got := len(AnalyzeChunk(chunk)); got != 1 {
+		t.Fatalf("AnalyzeChunk() found %d, want only the built-in ERROR line", got)
+	}
+
+	findings := AnalyzeChunkWithRules(chunk, rs)
+	if len(findings) != 2 {
+		t.Fatalf("AnalyzeChunkWithRules() found %d, want the two ACME-FAIL lines: %+v", len(findings), findings)
+	}
+	if findings[0].Severity != "ERROR" {
+		t.Errorf("Severity = %q, want ERROR from the override", findings[0].Severity)
+	}
+	if findings[0].NormalizedMsg != findings[1].NormalizedMsg {
+		t.Errorf("NormalizedMsg differs: %q vs %q, want user normalization to merge them",
+			findings[0].NormalizedMsg, findings[1].NormalizedMsg)
+	}
+}
+
+func TestAnalyzeChunk_Options(t *testing.T) {
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, fmt.Sprintf("INFO: step %d running", i))
+	}
+	lines[10] = "ERROR connection to cache lost" // scores 0.60
+	content := strings.Join(lines, "\n")
+
+	defaults := AnalyzeChunk(contracts.LogChunk{Content: content, LineStart: 1})
+	if len(defaults) != 1 {
+		t.Fatalf("AnalyzeChunk() found %d, want 1", len(defaults))
+	}
+	if len(defaults[0].PreContext) != 10 || len(defaults[0].PostContext) != 9 {
+		t.Errorf("default context = %d/%d lines, want 10/9", len(defaults[0].PreContext), len(defaults[0].PostContext))
+	}
+
+	sized := AnalyzeChunk(contracts.LogChunk{
+		Content:   content,
+		LineStart: 1,
+		Options:   &contracts.AnalysisOptions{PreContextLines: 2, PostContextLines: 3},
+	})
+	if len(sized) != 1 || len(sized[0].PreContext) != 2 || len(sized[0].PostContext) != 3 {
+		t.Errorf("sized context = %+v, want 2 pre and 3 post lines", sized)
+	}
+
+	strict := AnalyzeChunk(contracts.LogChunk{
+		Content:   content,
+		LineStart: 1,
+		Options:   &contracts.AnalysisOptions{MinConfidence: 0.7},
+	})
+	if len(strict) != 0 {
+		t.Errorf("AnalyzeChunk() with min_confidence 0.7 found %d, want 0", len(strict))
+	}
+}
@@ -0,0 +1,22 @@
+package analyze
+
+// withoutBaseline drops the findings whose message hash is in baseline,
+// the findings of the request's known-good build (see
+// contracts.LogChunk.BaselineHashes), and returns the rest with how many
+// it dropped.
+func withoutBaseline(findings []Finding, baseline []string) ([]Finding, int) {
+	if len(baseline) == 0 || len(findings) == 0 {
+		return findings, 0
+	}
+	known := make(map[string]bool, len(baseline))
+	for _, hash := range baseline {
+		known[hash] = true
+	}
+	kept := findings[:0:0]
+	for _, finding := range findings {
+		if !known[CalculateMessageHash(finding.NormalizedMsg)] {
+			kept = append(kept, finding)
+		}
+	}
+	return kept, len(findings) - len(kept)
+}
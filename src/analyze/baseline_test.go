@@ -0,0 +1,30 @@
+package analyze
+
+import "testing"
+
+func TestWithoutBaseline(t *testing.T) {
+	findings := []Finding{
+		{NormalizedMsg: "error: connection refused to <IP>"},
+		{NormalizedMsg: "panic: nil pointer dereference"},
+		{NormalizedMsg: "warning: deprecated flag --foo"},
+	}
+	baseline := []string{
+		CalculateMessageHash("error: connection refused to <IP>"),
+		CalculateMessageHash("warning: deprecated flag --foo"),
+	}
+
+	kept, dropped := withoutBaseline(findings, baseline)
+	if dropped != 2 {
+		t.Errorf("dropped = %d, want 2", dropped)
+	}
+	if len(kept) != 1 || kept[0].NormalizedMsg != "panic: nil pointer dereference" {
+		t.Errorf("kept = %+v, want only the panic", kept)
+	}
+	if len(findings) != 3 || findings[0].NormalizedMsg != "error: connection refused to <IP>" {
+		t.Errorf("findings were modified: %+v", findings)
+	}
+
+	if kept, dropped := withoutBaseline(findings, nil); dropped != 0 || len(kept) != 3 {
+		t.Errorf("without a baseline: kept %d, dropped %d; want 3, 0", len(kept), dropped)
+	}
+}
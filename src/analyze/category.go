@@ -0,0 +1,102 @@
+package analyze
+
+import (
+	"regexp"
+
+	"destill-agent/src/contracts"
+)
+
+// categoryRule tells a finding's category by the lines it matches.
+type categoryRule struct {
+	category string
+	patterns []*regexp.Regexp
+}
+
+// categoryRules are tried in order, the tools before the languages: a
+// Docker build or a Terraform plan reports the errors of what it runs.
+var categoryRules = []categoryRule{
+	{contracts.CategoryTerraform, []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bterraform\b|\.tf(?:vars)?\b|registry\.terraform\.io`),
+		regexp.MustCompile(`^\s*│\s*Error:`),
+	}},
+	{contracts.CategoryKubernetes, []*regexp.Regexp{
+		k8sErrors,
+		regexp.MustCompile(`(?i)\b(?:kubectl|kubernetes|helm|k8s)\b`),
+		regexp.MustCompile(`(?:^|\s)(?:pod|deployment(?:\.apps)?|statefulset(?:\.apps)?|daemonset(?:\.apps)?|namespace|job\.batch)/[\w.-]+`),
+	}},
+	{contracts.CategoryDocker, []*regexp.Regexp{
+		dockerError,
+		regexp.MustCompile(`(?i)\bdocker(?:file)?\b|\bbuildx\b|failed to solve`),
+	}},
+	{contracts.CategoryGo, []*regexp.Regexp{
+		panicGo,
+		regexp.MustCompile(`\.go:\d+`),
+		regexp.MustCompile(`\bgo (?:test|build|vet|mod|run)\b|^goroutine \d+ \[|^--- FAIL: \w+`),
+	}},
+	{contracts.CategoryJVM, []*regexp.Regexp{
+		stackTraceJava, gradleFailure,
+		regexp.MustCompile(`BUILD FAILURE|Failed to execute goal`),
+		regexp.MustCompile(`\b(?:java|javax|kotlin|scala|org\.junit)\.[\w.$]+`),
+		regexp.MustCompile(`\.(?:java|kt|scala):\d+`),
+		regexp.MustCompile(`(?i)\b(?:maven|gradle|sbt)\b`),
+	}},
+	{contracts.CategoryPython, []*regexp.Regexp{
+		stackTracePython, pythonFileLine,
+		regexp.MustCompile(`\.py\b|\b(?:pytest|pip3?|python3?)\b|ModuleNotFoundError|No module named`),
+	}},
+	{contracts.CategoryNode, []*regexp.Regexp{
+		npmError,
+		regexp.MustCompile(`(?i)\b(?:npm|yarn|pnpm|jest|webpack|tsc)\b|node_modules|\bnode:`),
+		regexp.MustCompile(`\.(?:[cm]?js|jsx|ts|tsx):\d+|Cannot find module`),
+	}},
+}
+
+// testFrameworkCategories and stackLanguageCategories are the categories of
+// test failures and stack traces, which say which language they're in.
+var (
+	testFrameworkCategories = map[string]string{
+		contracts.TestFrameworkGo:     contracts.CategoryGo,
+		contracts.TestFrameworkPytest: contracts.CategoryPython,
+	}
+	stackLanguageCategories = map[string]string{
+		contracts.StackLanguageJava:       contracts.CategoryJVM,
+		contracts.StackLanguageJavaScript: contracts.CategoryNode,
+		contracts.StackLanguagePython:     contracts.CategoryPython,
+		contracts.StackLanguageGo:         contracts.CategoryGo,
+	}
+)
+
+// categorize returns the category of a finding: from the test framework or
+// stack trace language it was recognized in, else from the patterns its
+// line matches, else those its context matches. "" when none do.
+func categorize(finding Finding) string {
+	if finding.Test != nil {
+		if category, ok := testFrameworkCategories[finding.Test.Framework]; ok {
+			return category
+		}
+	}
+	if finding.Stack != nil {
+		if category, ok := stackLanguageCategories[finding.Stack.Language]; ok {
+			return category
+		}
+	}
+	if category := Categorize(finding.RawMessage); category != "" {
+		return category
+	}
+	return Categorize(append(append([]string(nil), finding.PreContext...), finding.PostContext...)...)
+}
+
+// Categorize returns the category of the first rule any of lines matches,
+// one of the contracts.Category* values, or "".
+func Categorize(lines ...string) string {
+	for _, rule := range categoryRules {
+		for _, line := range lines {
+			for _, pattern := range rule.patterns {
+				if pattern.MatchString(line) {
+					return rule.category
+				}
+			}
+		}
+	}
+	return ""
+}
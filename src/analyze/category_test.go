@@ -0,0 +1,50 @@
+package analyze
+
+import (
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestCategorize(t *testing.T) {
+	tests := map[string]string{
+		"panic: runtime error: index out of range [3] with length 3":                        contracts.CategoryGo,
+		"    cart_test.go:42: got 3, want 4":                                                contracts.CategoryGo,
+		"[ERROR] Failed to execute goal org.apache.maven.plugins:maven-surefire-plugin:3.0": contracts.CategoryJVM,
+		"Exception in thread \"main\" java.lang.NullPointerException":                       contracts.CategoryJVM,
+		"ModuleNotFoundError: No module named 'requests'":                                   contracts.CategoryPython,
+		"npm ERR! code ERESOLVE":                                                            contracts.CategoryNode,
+		"ERROR: failed to solve: process \"/bin/sh -c go build ./...\" did not complete":    contracts.CategoryDocker,
+		"│ Error: Unsupported argument":                                                     contracts.CategoryTerraform,
+		"Warning: pod/web-7d9f restarted: CrashLoopBackOff":                                 contracts.CategoryKubernetes,
+		"ERROR: connection refused":                                                         "",
+	}
+	for line, want := range tests {
+		if got := Categorize(line); got != want {
+			t.Errorf("Categorize(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestAnalyzeChunk_Category(t *testing.T) {
+	chunk := contracts.LogChunk{
+		JobID:     "job-1",
+		Content:   "Traceback (most recent call last):\n  File \"/app/cart.py\", line 10, in total\n    return items[0]\nIndexError: list index out of range\nERROR: upload failed\n",
+		LineStart: 1,
+		Metadata:  map[string]string{"exit_status": "1"},
+	}
+
+	categories := map[string]string{}
+	for _, finding := range AnalyzeChunk(chunk) {
+		card := ConvertToTriageCard(finding, chunk, "req-1")
+		categories[card.NormalizedMsg] = card.Category
+	}
+	for msg, category := range categories {
+		if category != contracts.CategoryPython {
+			t.Errorf("category of %q = %q, want python (from the trace or its context)", msg, category)
+		}
+	}
+	if len(categories) == 0 {
+		t.Fatal("no findings")
+	}
+}
@@ -0,0 +1,273 @@
+package analyze
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+const (
+	// DefaultMaxFindings is the most findings the agent publishes for one
+	// request, so a log of endless errors can't flood the findings topic.
+	DefaultMaxFindings = 5000
+
+	// EnvMaxFindings overrides DefaultMaxFindings; 0 publishes every finding.
+	EnvMaxFindings = "DESTILL_MAX_FINDINGS"
+)
+
+// maxFindings is the configured finding limit, read once from the environment.
+var maxFindings = MaxFindingsFromEnv()
+
+// MaxFindingsFromEnv reads DESTILL_MAX_FINDINGS, falling back to
+// DefaultMaxFindings when it's unset or invalid.
+func MaxFindingsFromEnv() int {
+	value := strings.TrimSpace(os.Getenv(EnvMaxFindings))
+	if value == "" {
+		return DefaultMaxFindings
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: invalid %s %q, using %d\n", EnvMaxFindings, value, DefaultMaxFindings)
+		return DefaultMaxFindings
+	}
+	return n
+}
+
+// completionTTL is how long a request's progress is kept without a chunk or
+// control message arriving for it, and how old a control message can be and
+// still be acted on: a new agent reads destill.control from the start.
+const completionTTL = time.Hour
+
+// completion counts the chunks processed and findings published for each
+// request, so the agent can report its progress and say when a request's
+// analysis is done: once it has processed as many chunks as the ingest
+// agent reported publishing (contracts.ControlIngestComplete). Agents
+// sharing a request's chunks each count their own, and report them once
+// committed (contracts.ControlChunksAnalyzed); once the counts reported add
+// up to the chunks published, the reporting agent whose ID sorts first says
+// the analysis is done. It also enforces the finding limit, which an agent
+// sharing a request's chunks with others applies to its share. Not safe
+// for concurrent use; the agent's processing loop owns it.
+type completion struct {
+	requests    map[string]*requestProgress
+	maxFindings int // Per request; 0 = no limit
+	now         func() time.Time
+}
+
+type requestProgress struct {
+	chunks         map[string]bool                     // IDs of the chunks processed (see contracts.LogChunk.ID)
+	released       map[string]bool                     // IDs of the chunks processed and committed
+	findings       int                                 // Findings published by this agent
+	ingestFindings int                                 // Findings published by the ingest agent
+	allowed        int                                 // Findings let through the finding limit
+	dropped        int                                 // Findings over the finding limit
+	expected       int                                 // Chunks the ingest agent published
+	ingested       bool                                // Whether the ingest agent's ControlIngestComplete arrived
+	shares         map[string]contracts.ControlMessage // Latest ControlChunksAnalyzed of each agent
+	done           bool                                // Whether the request's analysis is complete
+	updated        time.Time
+}
+
+func newCompletion() *completion {
+	return &completion{requests: make(map[string]*requestProgress), maxFindings: maxFindings, now: time.Now}
+}
+
+// limit returns the findings of a chunk of requestID to publish: all of
+// them, or once the request nears the finding limit, the most confident
+// ones that fit under it.
+func (c *completion) limit(requestID string, findings []Finding) []Finding {
+	p := c.progress(requestID)
+	if c.maxFindings > 0 && p.allowed+len(findings) > c.maxFindings {
+		keep := max(c.maxFindings-p.allowed, 0)
+		findings = slices.Clone(findings)
+		slices.SortStableFunc(findings, func(a, b Finding) int {
+			return cmp.Compare(b.ConfidenceScore, a.ConfidenceScore)
+		})
+		p.dropped += len(findings) - keep
+		findings = findings[:keep]
+	}
+	p.allowed += len(findings)
+	return findings
+}
+
+// chunkDone records that chunk chunkID of requestID was processed,
+// publishing findings. A chunk delivered again, as brokers committing at
+// least once do, counts once. It returns the request's progress, and its
+// ControlAnalysisComplete if that was the last chunk.
+func (c *completion) chunkDone(requestID, chunkID string, findings int) (contracts.ProgressUpdate, contracts.ControlMessage, bool) {
+	p := c.progress(requestID)
+	if !p.chunks[chunkID] {
+		p.chunks[chunkID] = true
+		p.findings += findings
+	}
+	done, ok := c.check(requestID, p)
+	return p.update(requestID), done, ok
+}
+
+// published records findings of requestID published outside of its chunks'
+// processing: merged findings the agent held back (see aggregator).
+func (c *completion) published(requestID string, findings int) {
+	c.progress(requestID).findings += findings
+}
+
+// released records that processed chunk chunkID of requestID was
+// committed. It returns whether the agent has a new count to report: not
+// once the request is complete.
+func (c *completion) released(requestID, chunkID string) bool {
+	p := c.progress(requestID)
+	if p.done || p.released[chunkID] {
+		return false
+	}
+	p.released[chunkID] = true
+	return true
+}
+
+// report is agent's ControlChunksAnalyzed for requestID: the chunks it has
+// committed, and the findings it has published.
+func (c *completion) report(requestID, agent string) contracts.ControlMessage {
+	p := c.progress(requestID)
+	return contracts.ControlMessage{
+		Type:      contracts.ControlChunksAnalyzed,
+		RequestID: requestID,
+		Agent:     agent,
+		Chunks:    len(p.released),
+		Findings:  p.findings,
+		Truncated: c.truncated(p),
+	}
+}
+
+// ingestDone records the ingest agent's ControlIngestComplete, for the agent
+// self. It returns the request's progress, now that its total is known, and
+// its ControlAnalysisComplete if every chunk is already processed.
+func (c *completion) ingestDone(msg contracts.ControlMessage, self string) (contracts.ProgressUpdate, contracts.ControlMessage, bool) {
+	p := c.progress(msg.RequestID)
+	p.ingested = true
+	p.expected = msg.Chunks
+	p.ingestFindings = msg.Findings
+	if msg.Chunks == 0 {
+		// Every agent would say so at once, so the ingest agent does instead
+		p.done = true
+		return p.update(msg.RequestID), contracts.ControlMessage{}, false
+	}
+	done, ok := c.check(msg.RequestID, p)
+	if !ok {
+		done, ok = c.checkShares(msg.RequestID, p, self)
+	}
+	return p.update(msg.RequestID), done, ok
+}
+
+// shareReported records an agent's ControlChunksAnalyzed, the agent self's
+// own included. It returns the request's ControlAnalysisComplete if the
+// counts reported now add up and self is the agent to publish it.
+func (c *completion) shareReported(msg contracts.ControlMessage, self string) (contracts.ControlMessage, bool) {
+	p := c.progress(msg.RequestID)
+	p.shares[msg.Agent] = msg
+	return c.checkShares(msg.RequestID, p, self)
+}
+
+// analysisDone records that an agent published requestID's
+// ControlAnalysisComplete.
+func (c *completion) analysisDone(requestID string) {
+	if p, ok := c.requests[requestID]; ok {
+		p.done = true
+	}
+}
+
+// update is the request's progress: chunks analyzed, out of a total known
+// once ingest completes.
+func (p *requestProgress) update(requestID string) contracts.ProgressUpdate {
+	update := contracts.ProgressUpdate{
+		RequestID: requestID,
+		Stage:     contracts.StageAnalyzing,
+		Unit:      contracts.ProgressUnitChunks,
+		Current:   len(p.chunks),
+	}
+	if p.ingested {
+		update.Total = p.expected
+	}
+	return update
+}
+
+func (c *completion) progress(requestID string) *requestProgress {
+	now := c.now()
+	for id, p := range c.requests {
+		if now.Sub(p.updated) > completionTTL {
+			delete(c.requests, id)
+		}
+	}
+	p, ok := c.requests[requestID]
+	if !ok {
+		p = &requestProgress{
+			chunks:   make(map[string]bool),
+			released: make(map[string]bool),
+			shares:   make(map[string]contracts.ControlMessage),
+		}
+		c.requests[requestID] = p
+	}
+	p.updated = now
+	return p
+}
+
+// check is the request's ControlAnalysisComplete if this agent processed
+// every chunk itself.
+func (c *completion) check(requestID string, p *requestProgress) (contracts.ControlMessage, bool) {
+	if p.done || !p.ingested || len(p.chunks) < p.expected {
+		return contracts.ControlMessage{}, false
+	}
+	p.done = true
+	return contracts.ControlMessage{
+		Type:      contracts.ControlAnalysisComplete,
+		RequestID: requestID,
+		Chunks:    len(p.chunks),
+		Findings:  p.findings + p.ingestFindings,
+		Truncated: c.truncated(p),
+	}, true
+}
+
+// checkShares is the request's ControlAnalysisComplete if the chunks the
+// agents reported add up to those published, and self is the agent to
+// publish it: the reporting agent whose ID sorts first, so every agent
+// picks the same one whatever order the reports arrived in.
+func (c *completion) checkShares(requestID string, p *requestProgress, self string) (contracts.ControlMessage, bool) {
+	if p.done || !p.ingested {
+		return contracts.ControlMessage{}, false
+	}
+	done := contracts.ControlMessage{
+		Type:      contracts.ControlAnalysisComplete,
+		RequestID: requestID,
+		Findings:  p.ingestFindings,
+	}
+	publisher := ""
+	for _, agent := range slices.Sorted(maps.Keys(p.shares)) {
+		share := p.shares[agent]
+		if share.Chunks == 0 {
+			continue
+		}
+		if publisher == "" {
+			publisher = agent
+		}
+		done.Chunks += share.Chunks
+		done.Findings += share.Findings
+		done.Truncated = append(done.Truncated, share.Truncated...)
+	}
+	if done.Chunks < p.expected {
+		return contracts.ControlMessage{}, false
+	}
+	p.done = true
+	return done, publisher == self
+}
+
+// truncated notes the findings the agent dropped over the finding limit.
+func (c *completion) truncated(p *requestProgress) []string {
+	if p.dropped == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("dropped %d findings over the limit of %d (%s)", p.dropped, c.maxFindings, EnvMaxFindings)}
+}
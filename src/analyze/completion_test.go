@@ -0,0 +1,145 @@
+package analyze
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+func TestCompletion(t *testing.T) {
+	ingested := func(requestID string, chunks, findings int) contracts.ControlMessage {
+		return contracts.ControlMessage{Type: contracts.ControlIngestComplete, RequestID: requestID, Chunks: chunks, Findings: findings}
+	}
+
+	t.Run("chunks before ingest completes", func(t *testing.T) {
+		c := newCompletion()
+		for i := 0; i < 2; i++ {
+			if progress, _, ok := c.chunkDone("req", fmt.Sprint(i), 1); ok || progress.Current != i+1 || progress.Total != 0 {
+				t.Fatalf("chunk %d: progress %+v, complete %v; want counted, with the total unknown until ingest completes", i, progress, ok)
+			}
+		}
+		progress, done, ok := c.ingestDone(ingested("req", 2, 1), "agent")
+		if progress.Current != 2 || progress.Total != 2 || progress.Unit != contracts.ProgressUnitChunks {
+			t.Errorf("ingestDone() progress = %+v, want 2/2 chunks", progress)
+		}
+		if !ok || done.Type != contracts.ControlAnalysisComplete || done.Chunks != 2 || done.Findings != 3 {
+			t.Errorf("ingestDone() = %+v, %v; want complete with 2 chunks and 3 findings", done, ok)
+		}
+	})
+
+	t.Run("ingest completes first", func(t *testing.T) {
+		c := newCompletion()
+		if _, _, ok := c.ingestDone(ingested("req", 2, 0), "agent"); ok {
+			t.Fatal("complete before any chunk was processed")
+		}
+		c.chunkDone("req", "a", 0)
+		if _, done, ok := c.chunkDone("req", "b", 0); !ok || done.Findings != 0 {
+			t.Errorf("chunkDone() = %+v, %v; want complete with no findings", done, ok)
+		}
+		if _, _, ok := c.chunkDone("req", "c", 0); ok {
+			t.Error("request completed again by a later chunk")
+		}
+	})
+
+	t.Run("chunk delivered again", func(t *testing.T) {
+		c := newCompletion()
+		c.ingestDone(ingested("req", 2, 0), "agent")
+		c.chunkDone("req", "a", 1)
+		if progress, _, ok := c.chunkDone("req", "a", 1); ok || progress.Current != 1 {
+			t.Fatalf("chunk delivered again: progress %+v, complete %v; want it counted once", progress, ok)
+		}
+		if _, done, ok := c.chunkDone("req", "b", 0); !ok || done.Chunks != 2 || done.Findings != 1 {
+			t.Errorf("chunkDone() = %+v, %v; want complete with 2 chunks and 1 finding", done, ok)
+		}
+	})
+
+	t.Run("no chunks", func(t *testing.T) {
+		c := newCompletion()
+		if _, _, ok := c.ingestDone(ingested("req", 0, 0), "agent"); ok {
+			t.Error("request without chunks completed by an analyze agent; the ingest agent completes it")
+		}
+	})
+
+	t.Run("shared between agents", func(t *testing.T) {
+		// Agent a has chunks 1 and 2, agent b chunk 3; each sees both reports
+		reported := map[string]*completion{"a": newCompletion(), "b": newCompletion()}
+		reported["a"].chunkDone("req", "1", 1)
+		reported["a"].chunkDone("req", "2", 0)
+		reported["b"].chunkDone("req", "3", 2)
+		reported["a"].released("req", "1")
+		reported["a"].released("req", "2")
+		reported["b"].released("req", "3")
+		reports := []contracts.ControlMessage{reported["b"].report("req", "b"), reported["a"].report("req", "a")}
+
+		for self, c := range reported {
+			if _, _, ok := c.ingestDone(ingested("req", 3, 1), self); ok {
+				t.Fatalf("agent %s: complete with only its own chunks", self)
+			}
+			var completes []contracts.ControlMessage
+			for _, report := range reports {
+				if done, ok := c.shareReported(report, self); ok {
+					completes = append(completes, done)
+				}
+			}
+			if self == "b" {
+				if len(completes) != 0 {
+					t.Errorf("agent b published %+v; want agent a, sorting first, to", completes)
+				}
+				continue
+			}
+			if len(completes) != 1 || completes[0].Chunks != 3 || completes[0].Findings != 4 {
+				t.Errorf("agent a published %+v; want one completion, with 3 chunks and 4 findings", completes)
+			}
+		}
+	})
+
+	t.Run("shared, ingest completes last", func(t *testing.T) {
+		c := newCompletion()
+		c.chunkDone("req", "1", 0)
+		c.released("req", "1")
+		if _, ok := c.shareReported(c.report("req", "a"), "a"); ok {
+			t.Fatal("complete before ingest")
+		}
+		c.shareReported(contracts.ControlMessage{Type: contracts.ControlChunksAnalyzed, RequestID: "req", Agent: "b", Chunks: 1}, "a")
+		if _, done, ok := c.ingestDone(ingested("req", 2, 0), "a"); !ok || done.Chunks != 2 {
+			t.Errorf("ingestDone() = %+v, %v; want complete with both agents' chunks", done, ok)
+		}
+		if c.released("req", "2") {
+			t.Error("chunk count to report after the request completed")
+		}
+	})
+
+	t.Run("finding limit", func(t *testing.T) {
+		c := newCompletion()
+		c.maxFindings = 3
+		findings := []Finding{{RawMessage: "a", ConfidenceScore: 0.2}, {RawMessage: "b", ConfidenceScore: 0.9}}
+		if kept := c.limit("req", findings); len(kept) != 2 {
+			t.Fatalf("limit() kept %d findings under the limit, want 2", len(kept))
+		}
+		c.chunkDone("req", "a", 2)
+		// The most confident finding fills the last place
+		kept := c.limit("req", findings)
+		if len(kept) != 1 || kept[0].RawMessage != "b" {
+			t.Errorf("limit() = %+v, want b, the more confident", kept)
+		}
+		c.chunkDone("req", "b", 1)
+		_, done, ok := c.ingestDone(ingested("req", 2, 0), "agent")
+		if !ok || done.Findings != 3 || len(done.Truncated) != 1 || done.Truncated[0] != "dropped 1 findings over the limit of 3 (DESTILL_MAX_FINDINGS)" {
+			t.Errorf("ingestDone() = %+v, %v; want complete, noting the dropped finding", done, ok)
+		}
+	})
+
+	t.Run("stale requests forgotten", func(t *testing.T) {
+		c := newCompletion()
+		now := time.Now()
+		c.now = func() time.Time { return now }
+		c.chunkDone("partial", "a", 0)
+		now = now.Add(completionTTL + time.Minute)
+		c.chunkDone("other", "a", 0)
+		if _, ok := c.requests["partial"]; ok {
+			t.Error("request without news for longer than completionTTL still tracked")
+		}
+	})
+}
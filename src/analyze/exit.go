@@ -0,0 +1,59 @@
+package analyze
+
+import (
+	"regexp"
+	"strconv"
+
+	"destill-agent/src/contracts"
+)
+
+// runnerExit matches the line a CI runner prints when a job's command fails:
+// Buildkite's "The command exited with status 1", GitHub Actions'
+// "Process completed with exit code 1.", and GitLab's "Job failed: exit
+// code 1".
+var runnerExit = regexp.MustCompile(`(?i)(?:command exited with status|process completed with exit code|job failed: exit code)\s+[1-9]\d*`)
+
+// exitLine returns the index of the last runner exit line in a chunk's
+// lines, or -1 if there is none.
+func exitLine(lines []string) int {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if runnerExit.MatchString(lines[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// anchorToExit records how far the findings in the contracts.ExitWindowLines
+// before the exit line (a line number) are from it, and marks the nearest
+// as the exit anchor. findings are sorted by line; exit lines of earlier
+// commands aren't anchors.
+func anchorToExit(findings []Finding, exit int) {
+	anchored := false
+	for i := len(findings) - 1; i >= 0; i-- {
+		distance := exit - findings[i].LineNumber
+		if distance <= 0 {
+			continue
+		}
+		if distance > contracts.ExitWindowLines {
+			break
+		}
+		findings[i].ExitDistance = distance
+		if !anchored && !runnerExit.MatchString(findings[i].RawMessage) {
+			findings[i].ExitAnchor = true
+			anchored = true
+		}
+	}
+}
+
+// setExitMetadata records a finding's distance to its job's exit line in
+// finding metadata.
+func setExitMetadata(metadata map[string]string, finding Finding) {
+	if finding.ExitDistance == 0 {
+		return
+	}
+	metadata[contracts.MetadataExitDistance] = strconv.Itoa(finding.ExitDistance)
+	if finding.ExitAnchor {
+		metadata[contracts.MetadataExitAnchor] = "true"
+	}
+}
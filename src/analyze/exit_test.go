@@ -0,0 +1,68 @@
+package analyze
+
+import (
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestAnalyzeChunk_ExitAnchor(t *testing.T) {
+	lines := []string{
+		"ERROR: failed to resolve host cache.internal",
+		"Compiling module",
+	}
+	for i := 0; i < contracts.ExitWindowLines; i++ {
+		lines = append(lines, "building target")
+	}
+	lines = append(lines,
+		"ERROR: undefined reference to `parse_config`",
+		"FATAL: linker failed with 1 errors",
+		"🚨 Error: The command exited with status 2",
+	)
+	chunk := contracts.LogChunk{
+		JobID:     "job-1",
+		Content:   strings.Join(lines, "\n"),
+		LineStart: 1,
+		Metadata:  map[string]string{"exit_status": "2"},
+	}
+
+	distances := map[string]int{}
+	var anchor string
+	for _, finding := range AnalyzeChunk(chunk) {
+		distances[finding.RawMessage] = finding.ExitDistance
+		if finding.ExitAnchor {
+			anchor = finding.RawMessage
+		}
+	}
+	if anchor != "FATAL: linker failed with 1 errors" {
+		t.Errorf("anchor = %q, want the linker failure", anchor)
+	}
+	if got := distances["ERROR: undefined reference to `parse_config`"]; got != 2 {
+		t.Errorf("undefined reference ExitDistance = %d, want 2", got)
+	}
+	if got := distances["ERROR: failed to resolve host cache.internal"]; got != 0 {
+		t.Errorf("early error ExitDistance = %d, want 0 (outside the window)", got)
+	}
+
+	// A passed job's errors aren't anchored
+	chunk.Metadata["exit_status"] = "0"
+	for _, finding := range AnalyzeChunk(chunk) {
+		if finding.ExitDistance != 0 || finding.ExitAnchor {
+			t.Errorf("passed job finding %q anchored: %+v", finding.RawMessage, finding)
+		}
+	}
+}
+
+func TestConvertToTriageCard_ExitMetadata(t *testing.T) {
+	finding := Finding{LineNumber: 40, NormalizedMsg: "error", ExitDistance: 3, ExitAnchor: true}
+	card := ConvertToTriageCard(finding, contracts.LogChunk{LineStart: 1}, "req-1")
+	if card.Metadata[contracts.MetadataExitDistance] != "3" || card.Metadata[contracts.MetadataExitAnchor] != "true" {
+		t.Errorf("metadata = %v, want exit_distance 3 and exit_anchor true", card.Metadata)
+	}
+
+	card = ConvertToTriageCard(Finding{LineNumber: 40, NormalizedMsg: "error"}, contracts.LogChunk{LineStart: 1}, "req-1")
+	if _, ok := card.Metadata[contracts.MetadataExitDistance]; ok {
+		t.Errorf("metadata = %v, want no exit_distance", card.Metadata)
+	}
+}
@@ -0,0 +1,80 @@
+package analyze
+
+import (
+	"fmt"
+	"strings"
+
+	"destill-agent/src/rules"
+)
+
+// Explanation describes how the analyzer treats a single log line.
+// Used by `destill explain` to debug why a line was or wasn't flagged.
+type Explanation struct {
+	Line            string         `json:"line"`
+	Severity        string         `json:"severity"`
+	SeverityRule    string         `json:"severity_rule,omitempty"` // User severity rule that set Severity
+	NormalizedMsg   string         `json:"normalized_message"`
+	MessageHash     string         `json:"message_hash"`
+	Matches         []PatternMatch `json:"matches"`
+	PatternScore    float64        `json:"pattern_score"`            // Score after pattern matching
+	JobAdjustment   string         `json:"job_adjustment,omitempty"` // e.g. "failed job boost"
+	ConfidenceScore float64        `json:"confidence_score"`         // Final score
+	SuppressedBy    string         `json:"suppressed_by,omitempty"`  // User suppression that matched
+	Flagged         bool           `json:"flagged"`
+	Reason          string         `json:"reason"`
+}
+
+// ExplainLine runs severity detection, normalization, and confidence scoring
+// on a single line, mirroring AnalyzeChunk. exitStatus is the job's exit status
+// ("0" for passed, non-zero for failed, empty if unknown). rs may be nil.
+func ExplainLine(line string, exitStatus string, rs *rules.RuleSet) Explanation {
+	guarded, skip, _ := guardLines([]string{line}, maxLineLength)
+	trimmed := strings.TrimSpace(guarded[0])
+	severity, severityRule := lineSeverity(trimmed, rs)
+	normalized := normalizeLine(trimmed, rs)
+	score, matches := scoreLine(trimmed, severity, rs)
+	suppressedBy, suppressed := rs.Suppressed(trimmed)
+
+	exp := Explanation{
+		Line:          line,
+		Severity:      severity,
+		SeverityRule:  severityRule,
+		NormalizedMsg: normalized,
+		MessageHash:   CalculateMessageHash(normalized),
+		Matches:       matches,
+		PatternScore:  score,
+		SuppressedBy:  suppressedBy,
+	}
+
+	// Apply the same job outcome adjustment as AnalyzeChunk
+	confidence := score
+	switch {
+	case exitStatus == "":
+		// Unknown job outcome - no adjustment
+	case exitStatus != "0":
+		confidence = boostConfidenceForFailedJob(confidence)
+		exp.JobAdjustment = "failed job boost"
+	default:
+		confidence = penalizeConfidenceForPassedJob(confidence)
+		exp.JobAdjustment = "passed job penalty"
+	}
+	exp.ConfidenceScore = confidence
+
+	switch {
+	case skip[0]:
+		exp.Reason = "line looks like " + unscannable(line) + " and is not scanned"
+	case len(trimmed) < minLineLength:
+		exp.Reason = fmt.Sprintf("line is shorter than %d characters", minLineLength)
+	case severity != "ERROR" && severity != "FATAL":
+		exp.Reason = fmt.Sprintf("severity %s is not reported (only ERROR and FATAL)", severity)
+	case suppressed:
+		exp.Reason = fmt.Sprintf("suppressed by %q", suppressedBy)
+	case confidence < MinConfidence:
+		exp.Reason = fmt.Sprintf("confidence %.2f is below threshold %.2f", confidence, MinConfidence)
+	default:
+		exp.Flagged = true
+		exp.Reason = "reported as a finding"
+	}
+
+	return exp
+}
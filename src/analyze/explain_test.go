@@ -0,0 +1,101 @@
+package analyze
+
+import (
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestExplainLine(t *testing.T) {
+	tests := []struct {
+		name        string
+		line        string
+		exitStatus  string
+		wantFlagged bool
+		wantMatch   string
+	}{
+		{
+			name:        "connection error is flagged",
+			line:        "ERROR: connection refused to db:5432",
+			wantFlagged: true,
+			wantMatch:   "connection failure",
+		},
+		{
+			name:        "zero errors is penalized",
+			line:        "Build finished with 0 errors",
+			wantFlagged: false,
+			wantMatch:   "zero errors message",
+		},
+		{
+			name:        "info line is not flagged",
+			line:        "Downloading dependencies for project",
+			wantFlagged: false,
+		},
+		{
+			name:        "short line is not flagged",
+			line:        "ERROR",
+			wantFlagged: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := ExplainLine(tt.line, tt.exitStatus, nil)
+			if exp.Flagged != tt.wantFlagged {
+				t.Errorf("Flagged = %v, want %v (reason: %s)", exp.Flagged, tt.wantFlagged, exp.Reason)
+			}
+			if exp.Reason == "" {
+				t.Error("Reason is empty")
+			}
+			if tt.wantMatch == "" {
+				return
+			}
+			found := false
+			for _, m := range exp.Matches {
+				if m.Name == tt.wantMatch {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Matches = %v, want to contain %q", exp.Matches, tt.wantMatch)
+			}
+		})
+	}
+}
+
+// TestExplainLine_MatchesAnalyzeChunk ensures explain mode agrees with the
+// real analyzer so it can be trusted for debugging.
+func TestExplainLine_MatchesAnalyzeChunk(t *testing.T) {
+	lines := []string{
+		"ERROR: connection refused to db:5432",
+		"FATAL: out of memory",
+		"npm ERR! code ELIFECYCLE",
+		"Test passed: expect(fn).toThrow(error)",
+	}
+
+	for _, exitStatus := range []string{"", "0", "1"} {
+		for _, line := range lines {
+			chunk := contracts.LogChunk{
+				Content:   line,
+				LineStart: 1,
+				Metadata:  map[string]string{},
+			}
+			if exitStatus != "" {
+				chunk.Metadata["exit_status"] = exitStatus
+			}
+
+			findings := AnalyzeChunk(chunk)
+			exp := ExplainLine(line, exitStatus, nil)
+
+			if exp.Flagged != (len(findings) == 1) {
+				t.Errorf("ExplainLine(%q, %q).Flagged = %v, AnalyzeChunk found %d",
+					line, exitStatus, exp.Flagged, len(findings))
+				continue
+			}
+			if exp.Flagged && exp.ConfidenceScore != findings[0].ConfidenceScore {
+				t.Errorf("ExplainLine(%q, %q).ConfidenceScore = %.2f, AnalyzeChunk = %.2f",
+					line, exitStatus, exp.ConfidenceScore, findings[0].ConfidenceScore)
+			}
+		}
+	}
+}
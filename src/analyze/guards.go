@@ -0,0 +1,151 @@
+package analyze
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultMaxLineLength is how many bytes of a line are analyzed. Longer
+	// lines (minified JS, serialized payloads) are cut, since they make regex
+	// matching slow and can't be shown anyway.
+	DefaultMaxLineLength = 4096
+
+	// EnvMaxLineLength overrides DefaultMaxLineLength; 0 analyzes lines whole.
+	EnvMaxLineLength = "DESTILL_MAX_LINE_LENGTH"
+
+	// minEncodedRun is the shortest run of base64 characters taken as an
+	// encoded blob rather than text.
+	minEncodedRun = 256
+
+	// minBinaryLength is the shortest line checked for binary content, so a
+	// stray control character in a short line doesn't hide it.
+	minBinaryLength = 16
+)
+
+// maxLineLength is the configured line limit, read once from the environment.
+var maxLineLength = MaxLineLengthFromEnv()
+
+// MaxLineLengthFromEnv reads DESTILL_MAX_LINE_LENGTH, falling back to
+// DefaultMaxLineLength when it's unset or invalid.
+func MaxLineLengthFromEnv() int {
+	value := strings.TrimSpace(os.Getenv(EnvMaxLineLength))
+	if value == "" {
+		return DefaultMaxLineLength
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: invalid %s %q, using %d\n", EnvMaxLineLength, value, DefaultMaxLineLength)
+		return DefaultMaxLineLength
+	}
+	return n
+}
+
+// GuardStats counts the lines of a chunk the guards changed.
+type GuardStats struct {
+	Truncated int // Lines cut to the line length limit
+	Skipped   int // Binary or encoded lines, not scanned
+}
+
+// guardLines prepares lines for analysis: binary and encoded lines are
+// replaced by a marker and flagged to be skipped, and lines longer than
+// limit (if positive) are truncated with a marker.
+func guardLines(lines []string, limit int) ([]string, []bool, GuardStats) {
+	var stats GuardStats
+	guarded := make([]string, len(lines))
+	skip := make([]bool, len(lines))
+	for i, line := range lines {
+		if kind := unscannable(line); kind != "" {
+			guarded[i] = fmt.Sprintf("[destill: skipped %d bytes of %s]", len(line), kind)
+			skip[i] = true
+			stats.Skipped++
+			continue
+		}
+		if limit > 0 && len(line) > limit {
+			guarded[i] = truncateLine(line, limit)
+			stats.Truncated++
+			continue
+		}
+		guarded[i] = line
+	}
+	return guarded, skip, stats
+}
+
+// truncateLine cuts line to at most limit bytes, on a rune boundary, and
+// notes how much was cut.
+func truncateLine(line string, limit int) string {
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s [destill: truncated %d bytes]", line[:cut], len(line)-cut)
+}
+
+// unscannable returns "binary content" or "encoded data" for lines that
+// aren't worth scanning, or "" for text.
+func unscannable(line string) string {
+	if isBinary(line) {
+		return "binary content"
+	}
+	if hasEncodedRun(line) {
+		return "encoded data"
+	}
+	return ""
+}
+
+// isBinary reports whether line has a NUL byte, or more than a tenth of it
+// is control characters or invalid UTF-8. Tabs, carriage returns, and the
+// escape of ANSI color codes are text.
+func isBinary(line string) bool {
+	if len(line) < minBinaryLength {
+		return false
+	}
+	bad := 0
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		switch {
+		case r == 0:
+			return true
+		case r == utf8.RuneError && size == 1:
+			bad++
+		case r < 0x20 && r != '\t' && r != '\r' && r != 0x1b, r == 0x7f:
+			bad++
+		}
+		i += size
+	}
+	return bad*10 > len(line)
+}
+
+// hasEncodedRun reports whether line has a run of at least minEncodedRun
+// base64 characters mixing upper case, lower case, and digits, as base64
+// blobs do and words, paths, and hex hashes don't.
+func hasEncodedRun(line string) bool {
+	if len(line) < minEncodedRun {
+		return false
+	}
+	run := 0
+	var upper, lower, digit bool
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		case c == '+' || c == '/' || c == '=':
+		default:
+			run, upper, lower, digit = 0, false, false, false
+			continue
+		}
+		run++
+		if run >= minEncodedRun && upper && lower && digit {
+			return true
+		}
+	}
+	return false
+}
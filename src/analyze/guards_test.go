@@ -0,0 +1,104 @@
+package analyze
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestUnscannable(t *testing.T) {
+	blob := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("destill binary payload 0123456789 ", 20)))
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"text", "ERROR: connection refused while dialing 10.0.0.1:5432", ""},
+		{"ansi colors", "\x1b[31mERROR\x1b[0m: build failed\tafter 3 attempts\r", ""},
+		{"nul byte", "ELF\x00\x01\x02 some header bytes here", "binary content"},
+		{"control characters", strings.Repeat("\x01\x02\x03ab", 10), "binary content"},
+		{"invalid utf-8", strings.Repeat("\xff\xfe ok", 10), "binary content"},
+		{"base64 blob", "payload=" + blob, "encoded data"},
+		{"long word", strings.Repeat("a", 400), ""},
+		{"hex hash", strings.Repeat("deadbeef0123", 30), ""},
+		{"minified js", strings.Repeat("function(a){return a+1};", 20), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unscannable(tt.line); got != tt.want {
+				t.Errorf("unscannable() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuardLines(t *testing.T) {
+	lines := []string{
+		"short line",
+		"ERROR: " + strings.Repeat("x", 100),
+		"binary\x00\x00\x00 dump of some file",
+		"héllo wörld " + strings.Repeat("é", 50),
+	}
+
+	guarded, skip, stats := guardLines(lines, 40)
+
+	if stats != (GuardStats{Truncated: 2, Skipped: 1}) {
+		t.Errorf("guardLines() stats = %+v, want 2 truncated, 1 skipped", stats)
+	}
+	if guarded[0] != lines[0] || skip[0] {
+		t.Errorf("guardLines() changed a short line: %q", guarded[0])
+	}
+	if !strings.HasPrefix(guarded[1], "ERROR: xxx") || !strings.HasSuffix(guarded[1], "[destill: truncated 67 bytes]") {
+		t.Errorf("guardLines() truncated line = %q", guarded[1])
+	}
+	if !skip[2] || guarded[2] != "[destill: skipped 27 bytes of binary content]" {
+		t.Errorf("guardLines() binary line = %q, skip = %v", guarded[2], skip[2])
+	}
+	if text, _, _ := strings.Cut(guarded[3], " [destill:"); !strings.HasPrefix(lines[3], text) || !strings.HasSuffix(text, "é") {
+		t.Errorf("guardLines() cut a rune in half: %q", guarded[3])
+	}
+
+	if _, _, stats := guardLines(lines, 0); stats.Truncated != 0 {
+		t.Errorf("guardLines(limit 0) truncated %d lines, want none", stats.Truncated)
+	}
+}
+
+func TestAnalyzeChunkGuards(t *testing.T) {
+	blob := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("ERROR: fake error inside a blob 42 ", 20)))
+	chunk := contracts.LogChunk{
+		Content: strings.Join([]string{
+			"step 1",
+			"ERROR " + blob,
+			"ERROR: build failed: " + strings.Repeat("minified();", 1000),
+			"done",
+		}, "\n"),
+		Metadata: map[string]string{"exit_status": "1"},
+	}
+
+	findings, stats := AnalyzeChunkWithStats(chunk, nil)
+
+	if stats.Skipped != 1 || stats.Truncated != 1 {
+		t.Errorf("AnalyzeChunkWithStats() stats = %+v, want 1 skipped, 1 truncated", stats)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("AnalyzeChunkWithStats() = %d findings, want only the truncated line", len(findings))
+	}
+	if len(findings[0].RawMessage) > DefaultMaxLineLength+64 {
+		t.Errorf("finding message is %d bytes, want at most about %d", len(findings[0].RawMessage), DefaultMaxLineLength)
+	}
+	if got := findings[0].PreContext[len(findings[0].PreContext)-1]; !strings.HasPrefix(got, "[destill: skipped") {
+		t.Errorf("pre-context shows %.40q, want the skipped marker", got)
+	}
+}
+
+func TestExplainLineSkipsEncodedData(t *testing.T) {
+	blob := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("ERROR: 0123456789 ", 30)))
+	exp := ExplainLine("ERROR: "+blob, "1", nil)
+	if exp.Flagged || !strings.Contains(exp.Reason, "encoded data") {
+		t.Errorf("ExplainLine() = flagged %v, reason %q, want not flagged as encoded data", exp.Flagged, exp.Reason)
+	}
+}
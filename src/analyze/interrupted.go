@@ -0,0 +1,39 @@
+package analyze
+
+import (
+	"regexp"
+
+	"destill-agent/src/contracts"
+)
+
+var (
+	// Lines CI runners print when they stop a job at its time limit
+	timeoutMarker = regexp.MustCompile(`(?i)(?:exceeded the maximum execution time|execution took longer than|timeout has been exceeded|due to timeout|too long with no output|exceeded its timeout|job timed out)`)
+
+	// Lines CI runners print when a job is canceled
+	cancelMarker = regexp.MustCompile(`(?i)(?:the operation was canceled|received cancellation signal|aborted by \S+|job (?:was )?cancell?ed)`)
+
+	// Lines a job prints as it's killed: the runner's markers, and the
+	// signals and errors of the processes it stops
+	killedLine = regexp.MustCompile(`(?i)(?:signal: (?:killed|terminated|interrupt)|\bterminated\b|\bSIGTERM\b|\bSIGKILL\b|context canceled|exit (?:code|status) 1(?:30|37|43)\b)`)
+)
+
+// InterruptionMarker reports whether line is a runner's note that it timed
+// out or canceled the job: contracts.InterruptedTimeout,
+// contracts.InterruptedCanceled, or "".
+func InterruptionMarker(line string) string {
+	switch {
+	case timeoutMarker.MatchString(line):
+		return contracts.InterruptedTimeout
+	case cancelMarker.MatchString(line):
+		return contracts.InterruptedCanceled
+	}
+	return ""
+}
+
+// killLine reports whether line is a symptom of an interrupted job being
+// stopped rather than an error of its own; the interrupted job finding
+// reports it instead.
+func killLine(line string) bool {
+	return InterruptionMarker(line) != "" || killedLine.MatchString(line)
+}
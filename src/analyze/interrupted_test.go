@@ -0,0 +1,48 @@
+package analyze
+
+import (
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestAnalyzeChunk_InterruptedJob(t *testing.T) {
+	chunk := contracts.LogChunk{
+		JobID:     "job-1",
+		Content:   "ERROR: connection refused to db:5432\nrunning migrations\nERROR: migrate: context canceled\n",
+		LineStart: 1,
+		Metadata:  map[string]string{},
+	}
+	messages := func() []string {
+		var got []string
+		for _, finding := range AnalyzeChunk(chunk) {
+			got = append(got, finding.RawMessage)
+		}
+		return got
+	}
+
+	if got := messages(); len(got) != 2 {
+		t.Fatalf("findings = %q, want both errors of a job that ran to its end", got)
+	}
+
+	chunk.Metadata[contracts.MetadataInterrupted] = contracts.InterruptedTimeout
+	if got := messages(); len(got) != 1 || got[0] != "ERROR: connection refused to db:5432" {
+		t.Errorf("findings = %q, want only the error before the job was killed", got)
+	}
+}
+
+func TestInterruptionMarker(t *testing.T) {
+	tests := map[string]string{
+		"##[error]The job running on runner gh-7 has exceeded the maximum execution time of 60 minutes.": contracts.InterruptedTimeout,
+		"ERROR: Job failed: execution took longer than 1h0m0s seconds":                                   contracts.InterruptedTimeout,
+		"Too long with no output (exceeded 10m0s): context deadline exceeded":                            contracts.InterruptedTimeout,
+		"##[error]The operation was canceled.":                                                           contracts.InterruptedCanceled,
+		"Aborted by admin":                                                                               contracts.InterruptedCanceled,
+		"ERROR: request timed out after 30s":                                                             "",
+	}
+	for line, want := range tests {
+		if got := InterruptionMarker(line); got != want {
+			t.Errorf("InterruptionMarker(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/rules"
+)
+
+const (
+	// DefaultChunkTimeout is how long the agent analyzes one chunk before
+	// giving up on it, so a pathological chunk can't stall the agent.
+	DefaultChunkTimeout = 30 * time.Second
+
+	// EnvChunkTimeout overrides DefaultChunkTimeout with a duration such as
+	// "10s"; 0 disables the timeout.
+	EnvChunkTimeout = "DESTILL_CHUNK_TIMEOUT"
+)
+
+// chunkTimeout is the configured timeout, read once from the environment.
+var chunkTimeout = ChunkTimeoutFromEnv()
+
+// ChunkTimeoutFromEnv reads DESTILL_CHUNK_TIMEOUT, falling back to
+// DefaultChunkTimeout when it's unset or invalid.
+func ChunkTimeoutFromEnv() time.Duration {
+	value := strings.TrimSpace(os.Getenv(EnvChunkTimeout))
+	if value == "" {
+		return DefaultChunkTimeout
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: invalid %s %q, using %s\n", EnvChunkTimeout, value, DefaultChunkTimeout)
+		return DefaultChunkTimeout
+	}
+	return d
+}
+
+// chunkFailure is why a chunk was given up on.
+type chunkFailure struct {
+	reason string // contracts.SkipReasonTimeout, SkipReasonPanic, or SkipReasonInvalid
+	detail string
+}
+
+// chunkAnalyzer analyzes one chunk; AnalyzeChunkWithStats outside tests.
+type chunkAnalyzer func(chunk contracts.LogChunk, rs *rules.RuleSet) ([]Finding, GuardStats)
+
+// analyzeIsolated runs analyze, giving up after timeout (if positive) and
+// recovering from panics. Go can't stop the analysis once it times out, so
+// it runs on in the background and its result is dropped.
+func analyzeIsolated(analyze chunkAnalyzer, chunk contracts.LogChunk, rs *rules.RuleSet, timeout time.Duration) ([]Finding, GuardStats, *chunkFailure) {
+	type result struct {
+		findings []Finding
+		stats    GuardStats
+		failure  *chunkFailure
+	}
+	done := make(chan result, 1) // Buffered, so an abandoned analysis can finish
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{failure: &chunkFailure{
+					reason: contracts.SkipReasonPanic,
+					detail: fmt.Sprintf("%v\n%s", r, debug.Stack()),
+				}}
+			}
+		}()
+		findings, stats := analyze(chunk, rs)
+		done <- result{findings: findings, stats: stats}
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case r := <-done:
+		return r.findings, r.stats, r.failure
+	case <-expired:
+		return nil, GuardStats{}, &chunkFailure{
+			reason: contracts.SkipReasonTimeout,
+			detail: fmt.Sprintf("analysis took longer than %s", timeout),
+		}
+	}
+}
+
+// deadLetter wraps a message the agent gave up on for TopicLogsDLQ;
+// attempts counts this failure and those before replays.
+func deadLetter(msg broker.Message, failure *chunkFailure, attempts int) contracts.DeadLetter {
+	value := json.RawMessage(msg.Value)
+	if !json.Valid(value) {
+		value, _ = json.Marshal(string(msg.Value))
+	}
+	return contracts.DeadLetter{
+		Topic:    msg.Topic,
+		Key:      msg.Key,
+		Value:    value,
+		Agent:    "analyze-agent",
+		Reason:   failure.reason,
+		Error:    failure.detail,
+		FailedAt: time.Now().Format(time.RFC3339),
+		Attempts: attempts,
+	}
+}
+
+// skippedChunkCard is the finding recording that a chunk wasn't analyzed,
+// so the gap shows up in the results instead of passing silently. The
+// message hash leaves out the chunk index, so skips of one job group.
+func skippedChunkCard(chunk contracts.LogChunk, failure *chunkFailure) contracts.TriageCard {
+	normalized := fmt.Sprintf("Log chunk of job %q skipped: %s", chunk.JobName, failure.reason)
+	messageHash := CalculateMessageHash(normalized)
+
+	metadata := copyMetadata(chunk.Metadata)
+	metadata[contracts.MetadataFindingType] = contracts.FindingTypeChunkSkipped
+	metadata[contracts.MetadataSkipReason] = failure.reason
+
+	return contracts.TriageCard{
+		ID:          fmt.Sprintf("%s-%s-skipped-%d", chunk.JobID, messageHash[:8], chunk.ChunkIndex),
+		RequestID:   chunk.RequestID,
+		MessageHash: messageHash,
+		Source:      chunk.Metadata["provider"],
+		JobName:     chunk.JobName,
+		BuildURL:    chunk.Metadata["build_url"],
+		Severity:    "WARN",
+		RawMessage: fmt.Sprintf("chunk skipped: %s (chunk %d/%d, lines %d-%d); the chunk was sent to %s",
+			failure.reason, chunk.ChunkIndex+1, chunk.TotalChunks, chunk.LineStart, chunk.LineEnd, contracts.TopicLogsDLQ),
+		NormalizedMsg:   normalized,
+		ConfidenceScore: 0.5,
+		ChunkIndex:      chunk.ChunkIndex,
+		Metadata:        metadata,
+		Timestamp:       time.Now().Format(time.RFC3339),
+	}
+}
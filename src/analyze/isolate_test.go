@@ -0,0 +1,144 @@
+package analyze
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/logger"
+	"destill-agent/src/rules"
+)
+
+func TestAnalyzeIsolated(t *testing.T) {
+	chunk := contracts.LogChunk{Content: "ERROR: connection refused", LineStart: 1}
+
+	findings, _, failure := analyzeIsolated(AnalyzeChunkWithStats, chunk, nil, time.Second)
+	if failure != nil || len(findings) != 1 {
+		t.Errorf("analyzeIsolated() = %d findings, %+v, want 1 finding", len(findings), failure)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	stall := func(contracts.LogChunk, *rules.RuleSet) ([]Finding, GuardStats) {
+		<-release
+		return nil, GuardStats{}
+	}
+	if _, _, failure := analyzeIsolated(stall, chunk, nil, 10*time.Millisecond); failure == nil || failure.reason != contracts.SkipReasonTimeout {
+		t.Errorf("analyzeIsolated(stall) failure = %+v, want timeout", failure)
+	}
+
+	crash := func(contracts.LogChunk, *rules.RuleSet) ([]Finding, GuardStats) {
+		panic("index out of range")
+	}
+	_, _, failure = analyzeIsolated(crash, chunk, nil, 0)
+	if failure == nil || failure.reason != contracts.SkipReasonPanic || !strings.Contains(failure.detail, "index out of range") {
+		t.Errorf("analyzeIsolated(crash) failure = %+v, want panic", failure)
+	}
+}
+
+func TestAgent_SkipsStalledChunk(t *testing.T) {
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	findingsChan, err := brk.Subscribe(ctx, contracts.TopicAnalysisFindings, "test-consumer")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	dlqChan, err := brk.Subscribe(ctx, contracts.TopicLogsDLQ, "test-dlq")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	agent := NewAgent(brk, logger.NewSilentLogger())
+	agent.analyze = func(contracts.LogChunk, *rules.RuleSet) ([]Finding, GuardStats) {
+		<-release
+		return nil, GuardStats{}
+	}
+	agent.SetChunkTimeout(10 * time.Millisecond)
+
+	chunkData, _ := json.Marshal(contracts.LogChunk{
+		RequestID: "req-stall", JobName: "unit", JobID: "job-1", ChunkIndex: 2, TotalChunks: 5,
+		Content: "ERROR: never analyzed", LineStart: 1, LineEnd: 1,
+		Metadata:    map[string]string{"build_url": "https://example.com"},
+		DeadLetters: 1, // Replayed once before
+	})
+	msg := broker.Message{Topic: contracts.TopicLogsRaw, Key: "req-stall/job-1", Value: chunkData}
+	if _, err := agent.processChunk(ctx, msg); err != nil {
+		t.Fatalf("processChunk() error = %v", err)
+	}
+
+	select {
+	case m := <-findingsChan:
+		var card contracts.TriageCard
+		json.Unmarshal(m.Value, &card)
+		if card.Metadata[contracts.MetadataFindingType] != contracts.FindingTypeChunkSkipped ||
+			card.Metadata[contracts.MetadataSkipReason] != contracts.SkipReasonTimeout {
+			t.Errorf("finding metadata = %v, want chunk_skipped timeout", card.Metadata)
+		}
+		if !strings.HasPrefix(card.RawMessage, "chunk skipped: timeout") || card.ChunkIndex != 2 {
+			t.Errorf("finding = %q (chunk %d), want chunk skipped: timeout for chunk 2", card.RawMessage, card.ChunkIndex)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for skipped chunk finding")
+	}
+
+	select {
+	case m := <-dlqChan:
+		var letter contracts.DeadLetter
+		if err := json.Unmarshal(m.Value, &letter); err != nil {
+			t.Fatalf("Failed to unmarshal dead letter: %v", err)
+		}
+		if letter.Topic != contracts.TopicLogsRaw || letter.Reason != contracts.SkipReasonTimeout || string(letter.Value) != string(chunkData) {
+			t.Errorf("dead letter = %+v, want the original chunk with reason timeout", letter)
+		}
+		if letter.Attempts != 2 {
+			t.Errorf("dead letter attempts = %d, want 2", letter.Attempts)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for dead letter")
+	}
+}
+
+func TestAgent_DeadLettersInvalidChunk(t *testing.T) {
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	dlqChan, err := brk.Subscribe(ctx, contracts.TopicLogsDLQ, "test-dlq")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	agent := NewAgent(brk, logger.NewSilentLogger())
+	msg := broker.Message{Topic: contracts.TopicLogsRaw, Key: "req-bad/job-1", Value: []byte("not a chunk")}
+	if _, err := agent.processChunk(ctx, msg); err != nil {
+		t.Fatalf("processChunk() error = %v, want the chunk dead-lettered", err)
+	}
+
+	select {
+	case m := <-dlqChan:
+		var letter contracts.DeadLetter
+		if err := json.Unmarshal(m.Value, &letter); err != nil {
+			t.Fatalf("Failed to unmarshal dead letter: %v", err)
+		}
+		if letter.Reason != contracts.SkipReasonInvalid || letter.Key != "req-bad/job-1" || !letter.Poison() {
+			t.Errorf("dead letter = %+v, want poison with reason invalid", letter)
+		}
+		if m.Key != "req-bad" {
+			t.Errorf("dead letter key = %q, want the request req-bad", m.Key)
+		}
+		var value string
+		if err := json.Unmarshal(letter.Value, &value); err != nil || value != "not a chunk" {
+			t.Errorf("dead letter value = %s, want the original message as a string", letter.Value)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for dead letter")
+	}
+}
@@ -0,0 +1,34 @@
+package analyze
+
+import (
+	"time"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/rules"
+)
+
+// ReanalyzeResult is the outcome of analyzing stored chunks again.
+type ReanalyzeResult struct {
+	Cards   []contracts.TriageCard
+	Skipped int // Chunks that timed out or panicked, left out
+}
+
+// Reanalyze analyzes chunks again with rs, as the agent would: findings are
+// scored, tagged, and filtered by the chunk's baseline alike. Nothing is
+// published; chunks the agent would dead-letter are counted as skipped.
+func Reanalyze(chunks []contracts.LogChunk, rs *rules.RuleSet, timeout time.Duration) ReanalyzeResult {
+	var result ReanalyzeResult
+	for _, chunk := range chunks {
+		findings, _, failure := analyzeIsolated(AnalyzeChunkWithStats, chunk, rs, timeout)
+		if failure != nil {
+			result.Skipped++
+			continue
+		}
+		findings, _ = withoutBaseline(findings, chunk.BaselineHashes)
+		hints := rs.Hints(buildInfo(chunk))
+		for _, finding := range findings {
+			result.Cards = append(result.Cards, findingCard(finding, chunk, rs, hints))
+		}
+	}
+	return result
+}
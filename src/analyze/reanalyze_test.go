@@ -0,0 +1,41 @@
+package analyze
+
+import (
+	"testing"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/rules"
+)
+
+func TestReanalyze(t *testing.T) {
+	chunks := []contracts.LogChunk{
+		{RequestID: "req-2", JobID: "job-1", Content: "ERROR: database connection refused", LineStart: 1, Metadata: map[string]string{"job_id": "job-1"}},
+		{RequestID: "req-2", JobID: "job-2", Content: "FATAL: out of disk space", LineStart: 1, Metadata: map[string]string{"job_id": "job-2"}},
+	}
+
+	result := Reanalyze(chunks, nil, 0)
+	if len(result.Cards) != 2 || result.Skipped != 0 {
+		t.Fatalf("Reanalyze() = %d cards, %d skipped; want 2, 0", len(result.Cards), result.Skipped)
+	}
+	if card := result.Cards[0]; card.RequestID != "req-2" || card.Metadata["job_id"] != "job-1" || card.Timestamp == "" {
+		t.Errorf("card = %+v, want job-1's finding for req-2", card)
+	}
+
+	// New rules change the findings, tagged with their hash
+	rs, err := rules.Compile(&rules.Config{
+		Suppressions: []rules.Suppression{{Name: "disk", Regex: "out of disk space"}},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	result = Reanalyze(chunks, rs, 0)
+	if len(result.Cards) != 1 || result.Cards[0].Metadata[MetadataPatternConfigHash] != rs.Hash() {
+		t.Errorf("Reanalyze(suppressing disk) = %+v, want only the database finding, with the rules' hash", result.Cards)
+	}
+
+	// Findings of the chunk's baseline stay suppressed
+	chunks[0].BaselineHashes = []string{result.Cards[0].MessageHash}
+	if result = Reanalyze(chunks[:1], nil, 0); len(result.Cards) != 0 {
+		t.Errorf("Reanalyze(baseline) = %d cards, want 0", len(result.Cards))
+	}
+}
@@ -0,0 +1,268 @@
+package analyze
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"destill-agent/src/contracts"
+)
+
+// StackTraceConfidence is the base confidence of a stack trace finding,
+// before the job outcome adjustment: an uncaught exception or panic is
+// almost always a cause of the failure.
+const StackTraceConfidence = 0.9
+
+// StackTrace is a multi-line stack trace assembled from log lines: the
+// exception header and the frames printed with it.
+type StackTrace struct {
+	Language string   // One of the contracts.StackLanguage* values
+	Header   int      // Index of the exception header line
+	Frames   []string // Frame lines, trimmed, in log order
+	TopFrame string   // Innermost frame: where the exception was raised
+
+	start, end int // Lines of the trace, [start, end)
+}
+
+var (
+	// Java, Kotlin, and Scala ("\tat com.acme.Cart.total(Cart.java:42)"),
+	// and JavaScript ("    at Cart.total (/app/cart.js:10:5)")
+	atFrame      = regexp.MustCompile(`^\s+at\s+(?:async\s+|new\s+)?[^\s(]+(?:\s?\(.*\)|:\d+:\d+)`)
+	javaContinue = regexp.MustCompile(`^\s*(?:Caused by: |Suppressed: |\.\.\. \d+ (?:more|common frames omitted))`)
+
+	// Python
+	pythonFrame   = regexp.MustCompile(`File "(.*)", line \d+, in (\S+)`)
+	pythonChained = regexp.MustCompile(`^(?:During handling of the above exception|The above exception was the direct cause)`)
+
+	// Go
+	goPanicHeader = regexp.MustCompile(`^(?:panic: |fatal error: )`)
+	goGoroutine   = regexp.MustCompile(`^goroutine \d+ \[`)
+	goFrameFunc   = regexp.MustCompile(`^[\w./*()\[\]{}-]+\(.*\)$`)
+	goFrameFile   = regexp.MustCompile(`^\s+\S+\.go:\d+`)
+	goCreatedBy   = regexp.MustCompile(`^created by `)
+
+	// Frame reduction (see frameSymbol)
+	frameArgs     = regexp.MustCompile(`\([^()]*\)$`)
+	frameLocation = regexp.MustCompile(`(?::\d+){1,2}$`)
+)
+
+// goPanicLookahead bounds how far after a panic message the goroutine dump
+// may start ("[recovered]" panics and signal lines come between).
+const goPanicLookahead = 5
+
+// parseStackTraces assembles the Java, JavaScript, Python, and Go stack
+// traces in a chunk's lines.
+func parseStackTraces(lines []string) []StackTrace {
+	body := make([]string, len(lines))
+	for i, line := range lines {
+		body[i] = stripLogPrefix(line)
+	}
+	traces := parseAtTraces(body)
+	traces = append(traces, parsePythonTraces(body)...)
+	return append(traces, parseGoPanics(body)...)
+}
+
+// parseAtTraces finds runs of "at" frames and takes the line before each as
+// its exception header. "Caused by:" sections and "... 12 more" lines are
+// part of the trace; the top frame is that of the header's exception.
+func parseAtTraces(lines []string) []StackTrace {
+	var traces []StackTrace
+	for i := 1; i < len(lines); i++ {
+		if !atFrame.MatchString(lines[i]) || javaContinue.MatchString(lines[i-1]) || atFrame.MatchString(lines[i-1]) {
+			continue
+		}
+		trace := StackTrace{
+			Language: contracts.StackLanguageJavaScript,
+			Header:   i - 1,
+			start:    i - 1,
+		}
+		if stackTraceJava.MatchString(lines[i]) {
+			trace.Language = contracts.StackLanguageJava
+		}
+
+		j := i
+		for ; j < len(lines) && (atFrame.MatchString(lines[j]) || javaContinue.MatchString(lines[j])); j++ {
+			if atFrame.MatchString(lines[j]) {
+				trace.Frames = append(trace.Frames, strings.TrimSpace(lines[j]))
+			}
+		}
+		trace.end = j
+		i = j - 1
+
+		// Frames without a header are left to the line scan
+		if strings.TrimSpace(lines[trace.Header]) == "" {
+			continue
+		}
+		trace.TopFrame = trace.Frames[0]
+		traces = append(traces, trace)
+	}
+	return traces
+}
+
+// parsePythonTraces finds "Traceback (most recent call last):" blocks,
+// whose header is the exception line after the frames. Chained exceptions
+// ("During handling of the above exception, ...") fold into one trace
+// headed by the last exception, the one that propagated.
+func parsePythonTraces(lines []string) []StackTrace {
+	var traces []StackTrace
+	for i := 0; i < len(lines); i++ {
+		trace, ok := parsePythonTrace(lines, i)
+		if !ok {
+			continue
+		}
+		for {
+			next := pythonChainedTrace(lines, trace.end)
+			if next < 0 {
+				break
+			}
+			chained, ok := parsePythonTrace(lines, next)
+			if !ok {
+				break
+			}
+			chained.start = trace.start
+			trace = chained
+		}
+		traces = append(traces, trace)
+		i = trace.end - 1
+	}
+	return traces
+}
+
+// parsePythonTrace parses the traceback starting at line i, if there is
+// one and it isn't cut off before its exception line.
+func parsePythonTrace(lines []string, i int) (StackTrace, bool) {
+	if !stackTracePython.MatchString(strings.TrimSpace(lines[i])) {
+		return StackTrace{}, false
+	}
+	trace := StackTrace{Language: contracts.StackLanguagePython, start: i}
+
+	// Frames and their source lines are indented; the exception isn't
+	j := i + 1
+	for ; j < len(lines); j++ {
+		if strings.TrimSpace(lines[j]) == "" || leadingSpace(lines[j]) == 0 {
+			break
+		}
+		if pythonFileLine.MatchString(lines[j]) {
+			trace.Frames = append(trace.Frames, strings.TrimSpace(lines[j]))
+		}
+	}
+	if j == len(lines) || strings.TrimSpace(lines[j]) == "" || len(trace.Frames) == 0 {
+		return StackTrace{}, false
+	}
+	trace.Header = j
+	trace.end = j + 1
+	trace.TopFrame = trace.Frames[len(trace.Frames)-1]
+	return trace, true
+}
+
+// pythonChainedTrace returns the index of the traceback chained after the
+// exception line ending at end, or -1 if there is none.
+func pythonChainedTrace(lines []string, end int) int {
+	if end+3 >= len(lines) ||
+		strings.TrimSpace(lines[end]) != "" ||
+		!pythonChained.MatchString(strings.TrimSpace(lines[end+1])) ||
+		strings.TrimSpace(lines[end+2]) != "" ||
+		!stackTracePython.MatchString(strings.TrimSpace(lines[end+3])) {
+		return -1
+	}
+	return end + 3
+}
+
+// parseGoPanics finds "panic:" and "fatal error:" messages followed by a
+// goroutine dump. The dump is read up to the end of the panicking
+// goroutine; its top frame is the first outside the runtime below the
+// last panic() call, where the code panicked.
+func parseGoPanics(lines []string) []StackTrace {
+	var traces []StackTrace
+	for i := 0; i < len(lines); i++ {
+		if !goPanicHeader.MatchString(lines[i]) {
+			continue
+		}
+		j := i + 1
+		for j < len(lines) && j <= i+goPanicLookahead && !goGoroutine.MatchString(lines[j]) {
+			j++
+		}
+		if j >= len(lines) || !goGoroutine.MatchString(lines[j]) {
+			continue
+		}
+
+		trace := StackTrace{Language: contracts.StackLanguageGo, Header: i, start: i}
+		for j++; j < len(lines); j++ {
+			line := lines[j]
+			if goFrameFunc.MatchString(line) {
+				trace.Frames = append(trace.Frames, strings.TrimSpace(line))
+			} else if !goFrameFile.MatchString(line) && !goCreatedBy.MatchString(line) {
+				break
+			}
+		}
+		if len(trace.Frames) == 0 {
+			continue
+		}
+		trace.end = j
+		trace.TopFrame = goTopFrame(trace.Frames)
+		traces = append(traces, trace)
+		i = j - 1
+	}
+	return traces
+}
+
+// anyCovered reports whether any of the lines [start, end) is covered.
+func anyCovered(covered []bool, start, end int) bool {
+	for i := start; i < end; i++ {
+		if covered[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func goTopFrame(frames []string) string {
+	from := 0
+	for k, frame := range frames {
+		if strings.HasPrefix(frame, "panic(") {
+			from = k + 1
+		}
+	}
+	for _, frame := range frames[from:] {
+		if !strings.HasPrefix(frame, "runtime.") {
+			return frame
+		}
+	}
+	return frames[0]
+}
+
+// frameSymbol reduces a frame to the function it names, leaving out the
+// arguments, line numbers, and directories that change between builds:
+// "at com.acme.Cart.total(Cart.java:42)" -> "com.acme.Cart.total",
+// `File "/app/cart.py", line 14, in total` -> "cart.py in total".
+func frameSymbol(frame string) string {
+	if matches := pythonFrame.FindStringSubmatch(frame); matches != nil {
+		return path.Base(matches[1]) + " in " + matches[2]
+	}
+	frame = strings.TrimPrefix(frame, "at ")
+	frame = strings.TrimPrefix(frame, "async ")
+	if name, _, ok := strings.Cut(frame, " ("); ok {
+		return name // JavaScript: "Cart.total (/app/cart.js:10:5)"
+	}
+	if frameArgs.MatchString(frame) {
+		return frameArgs.ReplaceAllString(frame, "") // Java, Go
+	}
+	return path.Base(frameLocation.ReplaceAllString(frame, "")) // Anonymous JavaScript: "/app/cart.js:10:5"
+}
+
+// stackTraceMessage is the normalized message of a stack trace finding:
+// the normalized exception header at the top frame's function, so a trace
+// recurs across builds when the same exception is raised in the same place.
+func stackTraceMessage(normalizedHeader, topFrame string) string {
+	return normalizedHeader + " at " + frameSymbol(topFrame)
+}
+
+// setStackTraceMetadata records a stack trace's language, top frame, and
+// frame count in finding metadata.
+func setStackTraceMetadata(metadata map[string]string, trace StackTrace) {
+	metadata[contracts.MetadataFindingType] = contracts.FindingTypeStackTrace
+	metadata[contracts.MetadataStackLanguage] = trace.Language
+	metadata[contracts.MetadataTopFrame] = trace.TopFrame
+	metadata[contracts.MetadataStackFrames] = strconv.Itoa(len(trace.Frames))
+}
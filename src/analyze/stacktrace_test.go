@@ -0,0 +1,171 @@
+package analyze
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestParseStackTraces_Java(t *testing.T) {
+	log := `Running CartTest
+java.lang.IllegalStateException: cart 42 is empty
+	at com.acme.Cart.total(Cart.java:42)
+	at com.acme.CartTest.testTotal(CartTest.java:17)
+Caused by: java.io.IOException: read failed
+	at com.acme.Store.read(Store.java:88)
+	... 2 more
+Tests run: 3, Failures: 1`
+
+	traces := parseStackTraces(strings.Split(log, "\n"))
+	if len(traces) != 1 {
+		t.Fatalf("got %d traces, want 1: %+v", len(traces), traces)
+	}
+	trace := traces[0]
+	if trace.Language != contracts.StackLanguageJava || trace.Header != 1 || trace.start != 1 || trace.end != 7 {
+		t.Errorf("trace = %s header %d [%d, %d), want java header 1 [1, 7)", trace.Language, trace.Header, trace.start, trace.end)
+	}
+	if len(trace.Frames) != 3 || trace.TopFrame != "at com.acme.Cart.total(Cart.java:42)" {
+		t.Errorf("Frames = %q, TopFrame = %q", trace.Frames, trace.TopFrame)
+	}
+}
+
+func TestParseStackTraces_JavaScript(t *testing.T) {
+	log := `TypeError: Cannot read properties of undefined (reading 'id')
+    at Cart.total (/app/src/cart.js:10:5)
+    at async Promise.all (index 0)
+    at /app/src/index.js:3:1`
+
+	traces := parseStackTraces(strings.Split(log, "\n"))
+	if len(traces) != 1 || traces[0].Language != contracts.StackLanguageJavaScript || len(traces[0].Frames) != 3 {
+		t.Fatalf("traces = %+v, want one javascript trace of 3 frames", traces)
+	}
+}
+
+func TestParseStackTraces_IgnoresProse(t *testing.T) {
+	log := "Retrying the upload\n  at least 3 attempts remain"
+	if traces := parseStackTraces(strings.Split(log, "\n")); len(traces) != 0 {
+		t.Errorf("got traces %+v from prose, want none", traces)
+	}
+}
+
+func TestParseStackTraces_PythonChained(t *testing.T) {
+	log := `2025-01-15T09:30:00Z Traceback (most recent call last):
+2025-01-15T09:30:00Z   File "/home/runner/app/config.py", line 12, in load
+2025-01-15T09:30:00Z     return data["key"]
+2025-01-15T09:30:00Z KeyError: 'key'
+2025-01-15T09:30:00Z
+2025-01-15T09:30:00Z During handling of the above exception, another exception occurred:
+2025-01-15T09:30:00Z
+2025-01-15T09:30:00Z Traceback (most recent call last):
+2025-01-15T09:30:00Z   File "/home/runner/app/main.py", line 4, in <module>
+2025-01-15T09:30:00Z     start()
+2025-01-15T09:30:00Z   File "/home/runner/app/main.py", line 9, in start
+2025-01-15T09:30:00Z     raise RuntimeError("bad config")
+2025-01-15T09:30:00Z RuntimeError: bad config`
+
+	traces := parseStackTraces(strings.Split(log, "\n"))
+	if len(traces) != 1 {
+		t.Fatalf("got %d traces, want the chain folded into 1: %+v", len(traces), traces)
+	}
+	trace := traces[0]
+	if trace.start != 0 || trace.Header != 12 || trace.end != 13 {
+		t.Errorf("trace header %d [%d, %d), want header 12 [0, 13)", trace.Header, trace.start, trace.end)
+	}
+	if got := frameSymbol(trace.TopFrame); got != "main.py in start" {
+		t.Errorf("frameSymbol(TopFrame) = %q, want the innermost frame of the last exception", got)
+	}
+}
+
+func TestParseStackTraces_GoPanic(t *testing.T) {
+	log := `--- FAIL: TestTotal (0.00s)
+panic: runtime error: invalid memory address or nil pointer dereference [recovered]
+	panic: runtime error: invalid memory address or nil pointer dereference
+[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x4f2a1c]
+
+goroutine 7 [running]:
+testing.tRunner.func1.2({0x52e0a0, 0x6a1c50})
+	/usr/local/go/src/testing/testing.go:1632 +0x230
+panic({0x52e0a0?, 0x6a1c50?})
+	/usr/local/go/src/runtime/panic.go:785 +0x132
+github.com/acme/cart.(*Cart).Total(0x0)
+	/home/runner/work/cart/cart.go:40 +0x1c
+created by testing.(*T).Run in goroutine 1
+	/usr/local/go/src/testing/testing.go:1743 +0x390
+FAIL	github.com/acme/cart	0.012s`
+
+	traces := parseStackTraces(strings.Split(log, "\n"))
+	if len(traces) != 1 {
+		t.Fatalf("got %d traces, want 1: %+v", len(traces), traces)
+	}
+	trace := traces[0]
+	if trace.Header != 1 || trace.end != 14 || len(trace.Frames) != 3 {
+		t.Errorf("trace header %d, end %d, %d frames; want header 1, end 14, 3 frames", trace.Header, trace.end, len(trace.Frames))
+	}
+	if got := frameSymbol(trace.TopFrame); got != "github.com/acme/cart.(*Cart).Total" {
+		t.Errorf("frameSymbol(TopFrame) = %q, want the frame below panic()", got)
+	}
+}
+
+func TestFrameSymbol(t *testing.T) {
+	tests := []struct {
+		frame string
+		want  string
+	}{
+		{"at com.acme.Cart.total(Cart.java:42)", "com.acme.Cart.total"},
+		{"at java.base/java.lang.Thread.run(Thread.java:833)", "java.base/java.lang.Thread.run"},
+		{"at Cart.total (/app/src/cart.js:10:5)", "Cart.total"},
+		{"at async Cart.load (/app/src/cart.js:20:3)", "Cart.load"},
+		{"at /app/src/index.js:3:1", "index.js"},
+		{`File "/home/runner/app/cart.py", line 14, in total`, "cart.py in total"},
+		{"main.(*Cart).Total(0xc000010000, {0x4b2a10, 0x3})", "main.(*Cart).Total"},
+	}
+
+	for _, tt := range tests {
+		if got := frameSymbol(tt.frame); got != tt.want {
+			t.Errorf("frameSymbol(%q) = %q, want %q", tt.frame, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeChunk_StackTrace(t *testing.T) {
+	trace := func(id int) string {
+		return strings.Join([]string{
+			"INFO: loading cart",
+			fmt.Sprintf("java.lang.IllegalStateException: cart %d is empty", id),
+			fmt.Sprintf("\tat com.acme.Cart.total(Cart.java:%d)", 40+id),
+			"\tat com.acme.Main.main(Main.java:9)",
+			"ERROR: build step failed with exit code 1",
+		}, "\n")
+	}
+	chunk := contracts.LogChunk{Content: trace(1), LineStart: 1, Metadata: map[string]string{"exit_status": "1"}}
+
+	findings := AnalyzeChunk(chunk)
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want the trace folded into 1 plus the step error: %+v", len(findings), findings)
+	}
+	stack := findings[0]
+	if stack.Stack == nil || stack.LineNumber != 2 || !strings.HasPrefix(stack.RawMessage, "java.lang.IllegalStateException") {
+		t.Fatalf("finding 0 = %+v, want the trace headed at line 2", stack)
+	}
+	if !strings.HasSuffix(stack.NormalizedMsg, " at com.acme.Cart.total") {
+		t.Errorf("NormalizedMsg = %q, want it keyed on the top frame", stack.NormalizedMsg)
+	}
+	if len(stack.PostContext) < 2 || stack.PostContext[0] != "\tat com.acme.Cart.total(Cart.java:41)" {
+		t.Errorf("PostContext = %q, want the frames", stack.PostContext)
+	}
+
+	card := ConvertToTriageCard(stack, chunk, "req-1")
+	if card.Metadata[contracts.MetadataFindingType] != contracts.FindingTypeStackTrace ||
+		card.Metadata[contracts.MetadataStackFrames] != "2" ||
+		card.Metadata[contracts.MetadataTopFrame] != "at com.acme.Cart.total(Cart.java:41)" {
+		t.Errorf("card metadata = %v", card.Metadata)
+	}
+
+	// The same exception at the same place recurs despite changed line numbers
+	again := AnalyzeChunk(contracts.LogChunk{Content: trace(2), LineStart: 1})
+	if len(again) == 0 || again[0].NormalizedMsg != stack.NormalizedMsg {
+		t.Errorf("recurring trace NormalizedMsg = %+v, want %q", again, stack.NormalizedMsg)
+	}
+}
@@ -0,0 +1,312 @@
+package analyze
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"destill-agent/src/contracts"
+)
+
+// TestFailureConfidence is the base confidence of a test failure finding,
+// before the job outcome adjustment: a test the runner reported as failed
+// is a cause of the failure, whatever its output looks like.
+const TestFailureConfidence = 0.85
+
+// TestFailure is a failed test recognized in test runner output or a JUnit
+// XML report.
+type TestFailure struct {
+	Framework string   // One of the contracts.TestFramework* values
+	Name      string   // Test name, e.g. "TestParse/empty" or "TestCart.test_total"
+	Line      int      // Index of the line reporting the failure; -1 in a JUnit report
+	Message   string   // First line of the assertion or error
+	Output    []string // Assertion output: the test's log lines, or pytest's E lines
+
+	start, end int // Lines of the failure's block, [start, end)
+}
+
+var (
+	// Leading timestamp added by CI log viewers, e.g. "2025-01-15T09:30:00.123Z "
+	logTimestampPrefix = regexp.MustCompile(`^\s*\[?\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?\]?\s?`)
+
+	// go test
+	goFailHeader = regexp.MustCompile(`^(\s*)--- FAIL: (\S+) \([\d.]+s\)`)
+	goTestHeader = regexp.MustCompile(`^\s*--- (?:FAIL|PASS|SKIP): `)
+	goRunLine    = regexp.MustCompile(`^\s*=== (?:RUN|CONT|PAUSE|NAME)\s+(\S+)`)
+
+	// pytest
+	pytestSection     = regexp.MustCompile(`^_{3,} (.+?) _{3,}$`)
+	pytestSession     = regexp.MustCompile(`^={3,}.*={3,}$`)
+	pytestSummary     = regexp.MustCompile(`^(?:FAILED|ERROR) (\S+?::\S+)(?: - (.*))?$`)
+	pytestErrorLine   = regexp.MustCompile(`^E\s`)
+	pytestLocation    = regexp.MustCompile(`^\S+\.py:\d+: \S`)
+	pytestPhasePrefix = regexp.MustCompile(`^ERROR at (?:setup|teardown) of `)
+)
+
+// goRunLookback bounds how far back a go test failure without output after
+// its header looks for the test's output (go test -v prints it as it runs).
+const goRunLookback = 200
+
+// stripLogPrefix removes a leading timestamp from a log line, keeping the
+// indentation after it.
+func stripLogPrefix(line string) string {
+	return strings.TrimRight(logTimestampPrefix.ReplaceAllString(line, ""), "\r")
+}
+
+// parseTestFailures finds the failed tests in go test and pytest output.
+func parseTestFailures(lines []string) []TestFailure {
+	body := make([]string, len(lines))
+	for i, line := range lines {
+		body[i] = stripLogPrefix(line)
+	}
+	return append(parseGoTestFailures(body), parsePytestFailures(body)...)
+}
+
+// parseGoTestFailures finds "--- FAIL: TestName (0.01s)" blocks. The test's
+// output follows the header, indented, or (with -v) precedes it after the
+// test's "=== RUN" line. A test whose subtests failed is left out for them.
+func parseGoTestFailures(lines []string) []TestFailure {
+	var failures []TestFailure
+	for i, line := range lines {
+		matches := goFailHeader.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		failure := TestFailure{
+			Framework: contracts.TestFrameworkGo,
+			Name:      matches[2],
+			Line:      i,
+			start:     i,
+			end:       i + 1,
+		}
+
+		indent := len(matches[1])
+		for j := i + 1; j < len(lines); j++ {
+			next := lines[j]
+			if strings.TrimSpace(next) == "" || goTestHeader.MatchString(next) || leadingSpace(next) <= indent {
+				break
+			}
+			failure.Output = append(failure.Output, strings.TrimSpace(next))
+			failure.end = j + 1
+		}
+
+		if len(failure.Output) == 0 {
+			for j := i - 1; j >= 0 && j >= i-goRunLookback; j-- {
+				if run := goRunLine.FindStringSubmatch(lines[j]); run != nil && run[1] == failure.Name {
+					failure.start = j
+					break
+				}
+			}
+			for j := failure.start + 1; failure.start < i && j < i; j++ {
+				if goRunLine.MatchString(lines[j]) || goTestHeader.MatchString(lines[j]) || leadingSpace(lines[j]) == 0 {
+					continue
+				}
+				failure.Output = append(failure.Output, strings.TrimSpace(lines[j]))
+			}
+		}
+		if len(failure.Output) > 0 {
+			failure.Message = failure.Output[0]
+		}
+		failures = append(failures, failure)
+	}
+
+	// Leave out parents of failed subtests: the subtests say what failed
+	kept := failures[:0]
+	for _, failure := range failures {
+		parent := false
+		for _, other := range failures {
+			if strings.HasPrefix(other.Name, failure.Name+"/") {
+				parent = true
+				break
+			}
+		}
+		if !parent {
+			kept = append(kept, failure)
+		}
+	}
+	return kept
+}
+
+// parsePytestFailures finds the "____ test_name ____" sections of pytest's
+// FAILURES and ERRORS reports, keeping the E lines as the assertion output.
+// Failures only in the short test summary ("FAILED path::test - message"),
+// as with --tb=no, are taken from there.
+func parsePytestFailures(lines []string) []TestFailure {
+	var failures []TestFailure
+	sections := make(map[string]bool)
+	inReport := false
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if pytestSession.MatchString(trimmed) {
+			inReport = strings.Contains(trimmed, " FAILURES ") || strings.Contains(trimmed, " ERRORS ")
+			continue
+		}
+		matches := pytestSection.FindStringSubmatch(trimmed)
+		if !inReport || matches == nil {
+			continue
+		}
+		failure := TestFailure{
+			Framework: contracts.TestFrameworkPytest,
+			Name:      pytestPhasePrefix.ReplaceAllString(matches[1], ""),
+			Line:      i,
+			start:     i,
+		}
+
+		var location string
+		j := i + 1
+		for ; j < len(lines); j++ {
+			trimmed := strings.TrimSpace(lines[j])
+			if pytestSection.MatchString(trimmed) || pytestSession.MatchString(trimmed) {
+				break
+			}
+			switch {
+			case pytestErrorLine.MatchString(lines[j]):
+				if failure.Output == nil {
+					failure.Line = j
+					failure.Message = strings.TrimSpace(lines[j][1:])
+				}
+				failure.Output = append(failure.Output, strings.TrimRight(lines[j], " "))
+			case pytestLocation.MatchString(trimmed):
+				location = trimmed
+			}
+		}
+		failure.end = j
+		if location != "" {
+			failure.Output = append(failure.Output, location)
+			if failure.Message == "" {
+				failure.Message = location
+			}
+		}
+
+		sections[failure.Name] = true
+		failures = append(failures, failure)
+		i = j - 1
+	}
+
+	for i, line := range lines {
+		matches := pytestSummary.FindStringSubmatch(strings.TrimSpace(line))
+		if matches == nil {
+			continue
+		}
+		name := pytestTestName(matches[1])
+		if sections[name] {
+			continue
+		}
+		failure := TestFailure{
+			Framework: contracts.TestFrameworkPytest,
+			Name:      name,
+			Line:      i,
+			Message:   matches[2],
+			start:     i,
+			end:       i + 1,
+		}
+		if matches[2] != "" {
+			failure.Output = []string{matches[2]}
+		}
+		sections[name] = true
+		failures = append(failures, failure)
+	}
+	return failures
+}
+
+// pytestTestName turns a pytest node ID into the name its failure section
+// uses: "tests/test_cart.py::TestCart::test_total" -> "TestCart.test_total".
+func pytestTestName(nodeID string) string {
+	if _, name, ok := strings.Cut(nodeID, "::"); ok {
+		return strings.ReplaceAll(name, "::", ".")
+	}
+	return nodeID
+}
+
+func leadingSpace(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// JUnit XML report elements read by ParseJUnitXML. The root is <testsuites>
+// or a single <testsuite>, and suites may nest.
+type junitSuite struct {
+	XMLName xml.Name
+	Name    string       `xml:"name,attr"`
+	Suites  []junitSuite `xml:"testsuite"`
+	Cases   []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure"`
+	Error     *junitProblem `xml:"error"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ParseJUnitXML returns the failed and errored test cases of a JUnit XML
+// report, as written by most test runners (go-junit-report, pytest
+// --junitxml, Maven Surefire, Jest). Names are the test case names, as the
+// runners print them in logs, so a failure matches its log finding.
+func ParseJUnitXML(data []byte) ([]TestFailure, error) {
+	var root junitSuite
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse JUnit XML: %w", err)
+	}
+	if name := root.XMLName.Local; name != "testsuites" && name != "testsuite" {
+		return nil, fmt.Errorf("not a JUnit XML report: root element is <%s>", name)
+	}
+
+	var failures []TestFailure
+	var walk func(suite junitSuite)
+	walk = func(suite junitSuite) {
+		for _, tc := range suite.Cases {
+			problem := tc.Failure
+			if problem == nil {
+				problem = tc.Error
+			}
+			if problem == nil {
+				continue
+			}
+			failure := TestFailure{
+				Framework: contracts.TestFrameworkJUnit,
+				Name:      tc.Name,
+				Line:      -1,
+				Message:   strings.TrimSpace(problem.Message),
+			}
+			for _, line := range strings.Split(strings.TrimSpace(problem.Text), "\n") {
+				if line = strings.TrimRight(line, " \r"); line != "" {
+					failure.Output = append(failure.Output, line)
+				}
+			}
+			if failure.Message == "" && len(failure.Output) > 0 {
+				failure.Message = strings.TrimSpace(failure.Output[0])
+			}
+			failures = append(failures, failure)
+		}
+		for _, nested := range suite.Suites {
+			walk(nested)
+		}
+	}
+	walk(root)
+	return failures, nil
+}
+
+// TestFailureMessage is the normalized message of a test failure finding.
+// It names only the test, so a test's failure matches across builds and
+// between a run's log and its JUnit report.
+func TestFailureMessage(name string) string {
+	return "Test failed: " + name
+}
+
+// SetTestFailureMetadata records a test failure's framework, name, and
+// assertion output in finding metadata.
+func SetTestFailureMetadata(metadata map[string]string, failure TestFailure) {
+	metadata[contracts.MetadataFindingType] = contracts.FindingTypeTestFailure
+	metadata[contracts.MetadataTestFramework] = failure.Framework
+	metadata[contracts.MetadataTestName] = failure.Name
+	if len(failure.Output) > 0 {
+		metadata[contracts.MetadataAssertionDiff] = strings.Join(failure.Output, "\n")
+	}
+}
@@ -0,0 +1,153 @@
+package analyze
+
+import (
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestParseGoTestFailures(t *testing.T) {
+	log := `=== RUN   TestParse
+=== RUN   TestParse/empty
+    parse_test.go:21: Parse("") = "x", want ""
+=== RUN   TestParse/ok
+--- FAIL: TestParse (0.00s)
+    --- FAIL: TestParse/empty (0.00s)
+    --- PASS: TestParse/ok (0.00s)
+--- FAIL: TestTotal (0.01s)
+    cart_test.go:40: total = 12, want 10
+        extra detail
+FAIL
+FAIL	github.com/acme/cart	0.012s`
+
+	failures := parseTestFailures(strings.Split(log, "\n"))
+	if len(failures) != 2 {
+		t.Fatalf("got %d failures, want 2: %+v", len(failures), failures)
+	}
+
+	empty := failures[0]
+	if empty.Name != "TestParse/empty" || empty.Framework != contracts.TestFrameworkGo {
+		t.Errorf("failure 0 = %s %s, want go TestParse/empty", empty.Framework, empty.Name)
+	}
+	if empty.Message != `parse_test.go:21: Parse("") = "x", want ""` {
+		t.Errorf("Message = %q, want the -v output before the header", empty.Message)
+	}
+
+	total := failures[1]
+	if total.Name != "TestTotal" || total.Line != 7 {
+		t.Errorf("failure 1 = %s at line %d, want TestTotal at line 7", total.Name, total.Line)
+	}
+	if want := []string{"cart_test.go:40: total = 12, want 10", "extra detail"}; strings.Join(total.Output, "|") != strings.Join(want, "|") {
+		t.Errorf("Output = %q, want %q", total.Output, want)
+	}
+}
+
+func TestParsePytestFailures(t *testing.T) {
+	log := `2025-01-15T09:30:00Z ============================= FAILURES =============================
+2025-01-15T09:30:00Z _____________________ TestCart.test_total _____________________
+2025-01-15T09:30:00Z
+2025-01-15T09:30:00Z     def test_total(self):
+2025-01-15T09:30:00Z >       assert cart.total() == 10
+2025-01-15T09:30:00Z E       assert 12 == 10
+2025-01-15T09:30:00Z E        +  where 12 = total()
+2025-01-15T09:30:00Z
+2025-01-15T09:30:00Z tests/test_cart.py:14: AssertionError
+2025-01-15T09:30:00Z =========================== short test summary info ============================
+2025-01-15T09:30:00Z FAILED tests/test_cart.py::TestCart::test_total - assert 12 == 10
+2025-01-15T09:30:00Z FAILED tests/test_login.py::test_login - KeyError: 'user'
+2025-01-15T09:30:00Z ========================= 2 failed, 8 passed in 0.41s =========================`
+
+	failures := parseTestFailures(strings.Split(log, "\n"))
+	if len(failures) != 2 {
+		t.Fatalf("got %d failures, want 2: %+v", len(failures), failures)
+	}
+
+	total := failures[0]
+	if total.Name != "TestCart.test_total" || total.Line != 5 || total.Message != "assert 12 == 10" {
+		t.Errorf("failure 0 = %q at line %d (%q), want TestCart.test_total at line 5", total.Name, total.Line, total.Message)
+	}
+	if len(total.Output) != 3 || total.Output[2] != "tests/test_cart.py:14: AssertionError" {
+		t.Errorf("Output = %q, want the E lines and the location", total.Output)
+	}
+
+	login := failures[1]
+	if login.Name != "test_login" || login.Message != "KeyError: 'user'" {
+		t.Errorf("failure 1 = %q (%q), want test_login from the summary", login.Name, login.Message)
+	}
+}
+
+func TestParsePytestFailures_IgnoresSectionsOutsideReport(t *testing.T) {
+	log := "______________ setup ______________\nrunning setup"
+	if failures := parseTestFailures(strings.Split(log, "\n")); len(failures) != 0 {
+		t.Errorf("got failures %+v outside a pytest report, want none", failures)
+	}
+}
+
+func TestParseJUnitXML(t *testing.T) {
+	report := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="cart" tests="3">
+    <testcase classname="cart" name="TestTotal">
+      <failure message="total = 12, want 10" type="assertion">cart_test.go:40: total = 12, want 10
+    extra detail</failure>
+    </testcase>
+    <testcase classname="cart" name="TestEmpty"/>
+    <testsuite name="nested">
+      <testcase classname="nested" name="test_login"><error message="" type="KeyError">KeyError: 'user'</error></testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>`
+
+	failures, err := ParseJUnitXML([]byte(report))
+	if err != nil {
+		t.Fatalf("ParseJUnitXML() error = %v", err)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("got %d failures, want 2: %+v", len(failures), failures)
+	}
+	if failures[0].Name != "TestTotal" || failures[0].Message != "total = 12, want 10" || len(failures[0].Output) != 2 {
+		t.Errorf("failure 0 = %+v", failures[0])
+	}
+	if failures[1].Name != "test_login" || failures[1].Message != "KeyError: 'user'" {
+		t.Errorf("failure 1 = %+v", failures[1])
+	}
+
+	if _, err := ParseJUnitXML([]byte(`<project><target/></project>`)); err == nil {
+		t.Error("ParseJUnitXML() of a non-JUnit document succeeded, want an error")
+	}
+}
+
+func TestAnalyzeChunk_TestFailures(t *testing.T) {
+	chunk := contracts.LogChunk{
+		Content: strings.Join([]string{
+			"=== RUN   TestTotal",
+			"--- FAIL: TestTotal (0.01s)",
+			"    cart_test.go:40: Error: total = 12, want 10",
+			"FAIL",
+			"ERROR: database connection refused",
+		}, "\n"),
+		LineStart: 1,
+		Metadata:  map[string]string{"exit_status": "1"},
+	}
+
+	findings := AnalyzeChunk(chunk)
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want the test failure and the database error: %+v", len(findings), findings)
+	}
+
+	test := findings[0]
+	if test.Test == nil || test.NormalizedMsg != TestFailureMessage("TestTotal") || test.LineNumber != 2 {
+		t.Fatalf("finding 0 = %+v, want the TestTotal failure at line 2", test)
+	}
+	card := ConvertToTriageCard(test, chunk, "req-1")
+	if card.Metadata[contracts.MetadataFindingType] != contracts.FindingTypeTestFailure ||
+		card.Metadata[contracts.MetadataTestName] != "TestTotal" ||
+		card.Metadata[contracts.MetadataAssertionDiff] != "cart_test.go:40: Error: total = 12, want 10" {
+		t.Errorf("card metadata = %v", card.Metadata)
+	}
+
+	if findings[1].Test != nil || !strings.Contains(findings[1].RawMessage, "database") {
+		t.Errorf("finding 1 = %+v, want the free-form database error", findings[1])
+	}
+}
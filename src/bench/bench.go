@@ -0,0 +1,224 @@
+// Package bench replays a corpus of saved build logs through the analyzer
+// and scores its findings against the ones each log is expected to produce,
+// so pattern and scoring changes can be checked for precision and recall
+// regressions before they ship.
+//
+// A corpus is a directory of cases. Each case is a log, <name>.log, next to
+// its expectations, <name>.expected.yaml:
+//
+//	exit_status: "1"        # The job's exit status; "0" scores it as passed
+//	findings:
+//	  - message: "panic: runtime error"  # Substring of the finding's line
+//	    line: 42                          # Optional line in the log
+//
+// A case without findings expects none, which catches noise in passing logs.
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/contracts"
+	"destill-agent/src/ingest"
+	"destill-agent/src/rules"
+)
+
+// ExpectedSuffix names a case's expectations, next to <name>.log.
+const ExpectedSuffix = ".expected.yaml"
+
+// Expected is a finding a case's log should produce.
+type Expected struct {
+	Message string `yaml:"message" json:"message"`     // Substring of the finding's raw message
+	Line    int    `yaml:"line,omitempty" json:"line"` // Line in the log; 0 matches any line
+}
+
+// Case is a log of the corpus and the findings it should produce.
+type Case struct {
+	Name       string     `yaml:"-"`
+	LogPath    string     `yaml:"-"`
+	ExitStatus string     `yaml:"exit_status,omitempty"`
+	Findings   []Expected `yaml:"findings"`
+}
+
+// LoadCorpus reads the cases of dir, sorted by name. Every log must have
+// its expectations, so a case isn't silently scored as expecting nothing.
+func LoadCorpus(dir string) ([]Case, error) {
+	logs, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
+		return nil, fmt.Errorf("no *.log cases in %s", dir)
+	}
+	sort.Strings(logs)
+
+	cases := make([]Case, 0, len(logs))
+	for _, logPath := range logs {
+		name := strings.TrimSuffix(filepath.Base(logPath), ".log")
+		data, err := os.ReadFile(filepath.Join(dir, name+ExpectedSuffix))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("case %s has no %s%s", name, name, ExpectedSuffix)
+		}
+		if err != nil {
+			return nil, err
+		}
+		c := Case{Name: name, LogPath: logPath}
+		if err := yaml.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("case %s: %w", name, err)
+		}
+		for i, e := range c.Findings {
+			if e.Message == "" {
+				return nil, fmt.Errorf("case %s: finding %d has no message", name, i+1)
+			}
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// Finding is a finding of a case's log, at its line in the log.
+type Finding struct {
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+}
+
+// CaseResult scores the findings of one case.
+type CaseResult struct {
+	Name       string        `json:"name"`
+	Lines      int           `json:"lines"`
+	Bytes      int           `json:"bytes"`
+	Findings   int           `json:"findings"`
+	Expected   int           `json:"expected"`
+	Correct    int           `json:"correct"` // Findings matching an expected one
+	Found      int           `json:"found"`   // Expected findings matched by a finding
+	Missed     []Expected    `json:"missed,omitempty"`
+	Unexpected []Finding     `json:"unexpected,omitempty"`
+	Skipped    int           `json:"skipped_chunks,omitempty"` // Chunks that timed out or failed
+	Duration   time.Duration `json:"duration_ns"`
+}
+
+// Precision is the share of findings that were expected, 1 without findings.
+func (r CaseResult) Precision() float64 { return ratio(r.Correct, r.Findings) }
+
+// Recall is the share of expected findings that were found, 1 when none are.
+func (r CaseResult) Recall() float64 { return ratio(r.Found, r.Expected) }
+
+// Report scores a corpus: its cases and their totals.
+type Report struct {
+	Cases     []CaseResult `json:"cases"`
+	Total     CaseResult   `json:"total"`
+	RulesHash string       `json:"pattern_config_hash,omitempty"`
+}
+
+// Run analyzes each case's log with rs as the agents would, chunk by chunk
+// with the given timeout, and scores the findings.
+func Run(cases []Case, rs *rules.RuleSet, timeout time.Duration) (Report, error) {
+	report := Report{Total: CaseResult{Name: "total"}, RulesHash: rs.Hash()}
+	for _, c := range cases {
+		content, err := os.ReadFile(c.LogPath)
+		if err != nil {
+			return Report{}, err
+		}
+		result := runCase(c, string(content), rs, timeout)
+		report.Cases = append(report.Cases, result)
+
+		total := &report.Total
+		total.Lines += result.Lines
+		total.Bytes += result.Bytes
+		total.Findings += result.Findings
+		total.Expected += result.Expected
+		total.Correct += result.Correct
+		total.Found += result.Found
+		total.Skipped += result.Skipped
+		total.Duration += result.Duration
+	}
+	return report, nil
+}
+
+// runCase analyzes and scores one case's log.
+func runCase(c Case, content string, rs *rules.RuleSet, timeout time.Duration) CaseResult {
+	var metadata map[string]string
+	if c.ExitStatus != "" {
+		metadata = map[string]string{"exit_status": c.ExitStatus}
+	}
+
+	start := time.Now()
+	chunks := ingest.ChunkLog(content, "bench", c.Name, c.Name, c.Name, metadata)
+	analyzed := analyze.Reanalyze(chunks, rs, timeout)
+	duration := time.Since(start)
+
+	// Chunks overlap, so a line can be found twice
+	var findings []Finding
+	seen := make(map[Finding]bool)
+	for _, card := range analyzed.Cards {
+		f := Finding{Message: card.RawMessage, Line: chunkStart(chunks, card.ChunkIndex) + card.LineInChunk}
+		if !seen[f] {
+			seen[f] = true
+			findings = append(findings, f)
+		}
+	}
+
+	result := score(c.Findings, findings)
+	result.Name = c.Name
+	result.Lines = strings.Count(content, "\n")
+	result.Bytes = len(content)
+	result.Skipped = analyzed.Skipped
+	result.Duration = duration
+	return result
+}
+
+// score matches findings against the expected ones.
+func score(expected []Expected, findings []Finding) CaseResult {
+	result := CaseResult{Findings: len(findings), Expected: len(expected)}
+	found := make([]bool, len(expected))
+	for _, f := range findings {
+		correct := false
+		for i, e := range expected {
+			if e.matches(f) {
+				correct, found[i] = true, true
+			}
+		}
+		if correct {
+			result.Correct++
+		} else {
+			result.Unexpected = append(result.Unexpected, f)
+		}
+	}
+	for i, e := range expected {
+		if found[i] {
+			result.Found++
+		} else {
+			result.Missed = append(result.Missed, e)
+		}
+	}
+	return result
+}
+
+func (e Expected) matches(f Finding) bool {
+	return strings.Contains(f.Message, e.Message) && (e.Line == 0 || e.Line == f.Line)
+}
+
+// chunkStart is the first line of the chunk at index, 1 if it's unknown.
+func chunkStart(chunks []contracts.LogChunk, index int) int {
+	for _, chunk := range chunks {
+		if chunk.ChunkIndex == index {
+			return chunk.LineStart
+		}
+	}
+	return 1
+}
+
+func ratio(n, of int) float64 {
+	if of == 0 {
+		return 1
+	}
+	return float64(n) / float64(of)
+}
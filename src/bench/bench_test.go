@@ -0,0 +1,86 @@
+package bench
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/rules"
+)
+
+func TestScore(t *testing.T) {
+	expected := []Expected{{Message: "panic:", Line: 5}, {Message: "connection refused"}, {Message: "out of memory"}}
+	findings := []Finding{
+		{Message: "panic: nil map", Line: 5},
+		{Message: "dial tcp: connection refused", Line: 9},
+		{Message: "dial tcp: connection refused", Line: 20},
+		{Message: "panic: again", Line: 30}, // Not at the expected line
+		{Message: "deprecated flag"},
+	}
+
+	result := score(expected, findings)
+	if result.Correct != 3 || result.Found != 2 || result.Precision() != 0.6 {
+		t.Errorf("score() = %d correct, %d found, precision %v; want 3, 2, 0.6", result.Correct, result.Found, result.Precision())
+	}
+	if len(result.Missed) != 1 || result.Missed[0].Message != "out of memory" {
+		t.Errorf("missed = %+v, want out of memory", result.Missed)
+	}
+	if len(result.Unexpected) != 2 || result.Unexpected[0].Line != 30 {
+		t.Errorf("unexpected = %+v, want the panic at line 30 and the flag", result.Unexpected)
+	}
+
+	// Nothing expected and nothing found is a perfect score
+	if empty := score(nil, nil); empty.Precision() != 1 || empty.Recall() != 1 {
+		t.Errorf("score(nil, nil) = %v precision, %v recall; want 1, 1", empty.Precision(), empty.Recall())
+	}
+}
+
+func TestRun_Corpus(t *testing.T) {
+	cases, err := LoadCorpus("testdata/corpus")
+	if err != nil {
+		t.Fatalf("LoadCorpus() error = %v", err)
+	}
+	if len(cases) != 3 || cases[0].Name != "go-test-panic" || cases[0].ExitStatus != "1" {
+		t.Fatalf("LoadCorpus() = %+v, want 3 cases starting with go-test-panic", cases)
+	}
+
+	report, err := Run(cases, nil, time.Second)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// The sample corpus is fully found; npm's echoes of the error are noise
+	if recall := report.Total.Recall(); recall != 1 {
+		t.Errorf("recall = %v, want 1; missed: %+v", recall, report.Cases)
+	}
+	if report.Total.Precision() >= 1 || report.Total.Expected != 4 {
+		t.Errorf("total = %+v, want npm's noise to lower precision", report.Total)
+	}
+
+	// Suppressing a line the corpus expects is a recall regression
+	rs, err := rules.Compile(&rules.Config{Suppressions: []rules.Suppression{{Name: "ts", Regex: "error TS2322"}}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if report, _ = Run(cases, rs, time.Second); report.Total.Found != 3 || report.RulesHash != rs.Hash() {
+		t.Errorf("Run(suppressing TS2322) = %+v, want 3 of 4 found", report.Total)
+	}
+}
+
+func TestLoadCorpus_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadCorpus(dir); err == nil {
+		t.Error("LoadCorpus(empty) succeeded")
+	}
+
+	os.WriteFile(filepath.Join(dir, "build.log"), []byte("ERROR: boom\n"), 0o644)
+	if _, err := LoadCorpus(dir); err == nil || !strings.Contains(err.Error(), "build.expected.yaml") {
+		t.Errorf("LoadCorpus(no expectations) error = %v, want it named", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "build.expected.yaml"), []byte("findings:\n  - line: 1\n"), 0o644)
+	if _, err := LoadCorpus(dir); err == nil || !strings.Contains(err.Error(), "no message") {
+		t.Errorf("LoadCorpus(no message) error = %v", err)
+	}
+}
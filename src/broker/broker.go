@@ -1,7 +1,10 @@
 // Package broker defines the interface for message brokers and provides implementations.
 package broker
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Broker abstracts message publishing and consumption.
 // This interface supports both in-memory (legacy) and distributed (Redpanda/Kafka) implementations.
@@ -16,10 +19,31 @@ type Broker interface {
 	// For in-memory broker, groupID is ignored.
 	Subscribe(ctx context.Context, topic string, groupID string) (<-chan Message, error)
 
+	// SubscribePattern returns a single channel for consuming messages from every
+	// topic matching pattern, including topics created after the call.
+	// See MatchTopic for pattern syntax.
+	SubscribePattern(ctx context.Context, pattern string, groupID string) (<-chan Message, error)
+
+	// Topics lists the topics known to the broker, sorted by name.
+	Topics(ctx context.Context) ([]string, error)
+
+	// Stats returns per-topic message counters for this broker instance.
+	Stats() map[string]TopicStats
+
 	// Close shuts down the broker connection gracefully.
 	Close() error
 }
 
+// TopicStats counts messages that flowed through one topic on this broker
+// instance. Counters start at zero when the broker is created.
+type TopicStats struct {
+	Topic       string
+	Published   int64 // Messages published to the topic
+	Delivered   int64 // Messages handed to subscribers (one per subscriber)
+	Dropped     int64 // Messages dropped because a subscriber's buffer was full
+	Subscribers int   // Active subscriptions, including matching patterns
+}
+
 // Message represents a consumed message from a broker.
 type Message struct {
 	Topic     string
@@ -28,4 +52,68 @@ type Message struct {
 	Offset    int64
 	Partition int32
 	Timestamp int64
+
+	commit func() // Marks the message handled, for SubscribeUncommitted
+}
+
+// Committer is implemented by brokers that can hold back committing a
+// consumer group's position until the subscriber has handled a message, so
+// messages a crashed subscriber hadn't handled are delivered again
+// (at-least-once). Other brokers acknowledge a message once it's handed to
+// the channel.
+type Committer interface {
+	// SubscribeUncommitted is Subscribe without committing delivered
+	// messages: the group's position only moves past messages passed to
+	// Commit.
+	SubscribeUncommitted(ctx context.Context, topic string, groupID string) (<-chan Message, error)
+
+	// Commit marks a message from SubscribeUncommitted handled, along with
+	// the messages before it in its partition. Marked positions are
+	// committed in the background, and before a partition moves to another
+	// member of the group.
+	Commit(msg Message)
+}
+
+// SubscribeAtLeastOnce subscribes to topic, without committing delivered
+// messages when brk is a Committer. Call done with each message once it's
+// handled; for other brokers it does nothing.
+func SubscribeAtLeastOnce(ctx context.Context, brk Broker, topic, groupID string) (msgs <-chan Message, done func(Message), err error) {
+	if committer, ok := brk.(Committer); ok {
+		msgs, err = committer.SubscribeUncommitted(ctx, topic, groupID)
+		return msgs, committer.Commit, err
+	}
+	msgs, err = brk.Subscribe(ctx, topic, groupID)
+	return msgs, func(Message) {}, err
+}
+
+// Delays between the attempts of RetryUntilDone, doubled after each failure.
+var (
+	RetryDelay    = 100 * time.Millisecond
+	RetryMaxDelay = 30 * time.Second
+)
+
+// RetryUntilDone calls f until it succeeds, backing off between attempts
+// and passing each failure to failed with the delay before the next. It
+// returns nil once f succeeds, or ctx's error if ctx is done first. For
+// consumers committing at least once (see SubscribeAtLeastOnce): offsets
+// are committed per partition, so a message whose handling failed can't be
+// skipped, or committing any later message would lose it.
+func RetryUntilDone(ctx context.Context, f func() error, failed func(err error, retryIn time.Duration)) error {
+	delay := RetryDelay
+	for {
+		err := f()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		failed(err, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, RetryMaxDelay)
+	}
 }
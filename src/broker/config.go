@@ -0,0 +1,33 @@
+package broker
+
+import (
+	"fmt"
+
+	"destill-agent/src/config"
+)
+
+// NewFromConfig connects to the broker of cfg's transport: the gRPC server
+// in grpc mode, NATS in nats mode, Redpanda otherwise.
+func NewFromConfig(cfg *config.Config) (Broker, error) {
+	switch cfg.Transport {
+	case config.TransportGRPC:
+		brk, err := NewGRPCBroker(cfg.GRPCAddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to gRPC server: %w", err)
+		}
+		return brk, nil
+	case config.TransportNATS:
+		return NewNATSBroker(cfg.NATSURL)
+	case config.TransportSQS:
+		return NewSQSBroker(cfg.SQSPrefix)
+	}
+
+	brk, err := NewRedpandaBrokerWithOptions(cfg.RedpandaBrokers, RedpandaOptions{
+		Balancer:       cfg.RedpandaBalancer,
+		SessionTimeout: cfg.RedpandaSessionTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redpanda: %w", err)
+	}
+	return brk, nil
+}
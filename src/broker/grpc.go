@@ -0,0 +1,216 @@
+// Package broker provides a gRPC client broker implementation.
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"destill-agent/src/grpcapi/destillpb"
+)
+
+// grpcResubscribeDelay is how long a subscription waits before reopening a
+// stream the server dropped.
+const grpcResubscribeDelay = time.Second
+
+// GRPCBroker relays messages through a destill gRPC server ('destill
+// grpc-server'), for deployments without a Kafka-compatible broker. The
+// server keeps no log: messages published while a subscriber is
+// disconnected are not redelivered to it.
+type GRPCBroker struct {
+	conn      *grpc.ClientConn
+	client    destillpb.DestillClient
+	done      chan struct{} // Closed by Close
+	closeOnce sync.Once
+
+	statsMu sync.Mutex
+	stats   map[string]*TopicStats // topic -> counters
+	subs    map[string]int         // topic or pattern -> active subscriptions
+}
+
+// NewGRPCBroker connects to the gRPC server at addr (host:port). The
+// connection is plaintext; use it on a private network.
+func NewGRPCBroker(addr string) (*GRPCBroker, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+	return &GRPCBroker{
+		conn:   conn,
+		client: destillpb.NewDestillClient(conn),
+		done:   make(chan struct{}),
+		stats:  make(map[string]*TopicStats),
+		subs:   make(map[string]int),
+	}, nil
+}
+
+// Publish sends a message to a topic through the server.
+// Implements the Broker interface.
+func (b *GRPCBroker) Publish(ctx context.Context, topic string, key string, value []byte) error {
+	_, err := b.client.Publish(ctx, &destillpb.Message{Topic: topic, Key: key, Value: value})
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	b.count(topic, func(st *TopicStats) { st.Published++ })
+	return nil
+}
+
+// Subscribe opens a stream of the messages published to topic. Subscribers
+// sharing a groupID split the messages between them, as in a Kafka
+// consumer group. Implements the Broker interface.
+func (b *GRPCBroker) Subscribe(ctx context.Context, topic string, groupID string) (<-chan Message, error) {
+	return b.subscribe(ctx, &destillpb.SubscribeRequest{Topic: topic, GroupId: groupID})
+}
+
+// SubscribePattern opens a stream of the messages published to every topic
+// matching pattern. Implements the Broker interface.
+func (b *GRPCBroker) SubscribePattern(ctx context.Context, pattern string, groupID string) (<-chan Message, error) {
+	return b.subscribe(ctx, &destillpb.SubscribeRequest{Topic: pattern, GroupId: groupID, Pattern: true})
+}
+
+// subscribe opens the first stream before returning, so messages published
+// after Subscribe returns are delivered, and reopens it if the server drops
+// it until ctx is done or the broker is closed.
+func (b *GRPCBroker) subscribe(ctx context.Context, req *destillpb.SubscribeRequest) (<-chan Message, error) {
+	stream, err := b.openStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	b.statsMu.Lock()
+	b.subs[req.Topic]++
+	b.statsMu.Unlock()
+
+	msgChan := make(chan Message, 100)
+	go func() {
+		defer close(msgChan)
+		defer func() {
+			b.statsMu.Lock()
+			b.subs[req.Topic]--
+			b.statsMu.Unlock()
+		}()
+
+		for {
+			b.receive(ctx, stream, msgChan)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-b.done:
+					return
+				case <-time.After(grpcResubscribeDelay):
+				}
+				if stream, err = b.openStream(ctx, req); err == nil {
+					break
+				}
+			}
+		}
+	}()
+
+	return msgChan, nil
+}
+
+// openStream starts a Subscribe call and waits for the server to confirm
+// the subscription with its response header.
+func (b *GRPCBroker) openStream(ctx context.Context, req *destillpb.SubscribeRequest) (grpc.ServerStreamingClient[destillpb.Message], error) {
+	stream, err := b.client.Subscribe(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", req.Topic, err)
+	}
+	if _, err := stream.Header(); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", req.Topic, err)
+	}
+	return stream, nil
+}
+
+// receive forwards messages from stream until it ends.
+func (b *GRPCBroker) receive(ctx context.Context, stream grpc.ServerStreamingClient[destillpb.Message], msgChan chan<- Message) {
+	for {
+		m, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() == nil && status.Code(err) != codes.Canceled && !b.isClosed() {
+				fmt.Printf("[GRPCBroker] Subscription stream ended: %v\n", err)
+			}
+			return
+		}
+		msg := Message{Topic: m.Topic, Key: m.Key, Value: m.Value, Timestamp: m.Timestamp}
+		select {
+		case msgChan <- msg:
+			b.count(m.Topic, func(st *TopicStats) { st.Delivered++ })
+		case <-ctx.Done():
+			return
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// isClosed reports whether Close was called.
+func (b *GRPCBroker) isClosed() bool {
+	select {
+	case <-b.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Topics lists the topics known to the server.
+// Implements the Broker interface.
+func (b *GRPCBroker) Topics(ctx context.Context) ([]string, error) {
+	resp, err := b.client.ListTopics(ctx, &destillpb.ListTopicsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+	return resp.Topics, nil
+}
+
+// Stats returns counters for messages this instance published and received.
+// Implements the Broker interface.
+func (b *GRPCBroker) Stats() map[string]TopicStats {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	result := make(map[string]TopicStats, len(b.stats))
+	for topic, st := range b.stats {
+		snapshot := *st
+		for name, n := range b.subs {
+			if MatchTopic(name, topic) {
+				snapshot.Subscribers += n
+			}
+		}
+		result[topic] = snapshot
+	}
+	return result
+}
+
+// count updates the counters for topic.
+func (b *GRPCBroker) count(topic string, update func(*TopicStats)) {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	st, ok := b.stats[topic]
+	if !ok {
+		st = &TopicStats{Topic: topic}
+		b.stats[topic] = st
+	}
+	update(st)
+}
+
+// Close closes the connection, ending all subscriptions.
+func (b *GRPCBroker) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.done)
+		if closeErr := b.conn.Close(); closeErr != nil {
+			err = fmt.Errorf("failed to close gRPC connection: %w", closeErr)
+		}
+	})
+	return err
+}
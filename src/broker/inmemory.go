@@ -4,6 +4,9 @@ package broker
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 )
@@ -13,8 +16,19 @@ import (
 type InMemoryBroker struct {
 	mu          sync.RWMutex
 	subscribers map[string][]chan Message
+	patterns    []patternSubscriber
 	verbose     bool
 	closed      bool
+
+	statsMu sync.Mutex
+	stats   map[string]*TopicStats // topic -> counters
+}
+
+// patternSubscriber is a wildcard subscription.
+type patternSubscriber struct {
+	pattern string
+	re      *regexp.Regexp
+	ch      chan Message
 }
 
 // NewInMemoryBroker creates a new InMemoryBroker instance.
@@ -23,6 +37,7 @@ func NewInMemoryBroker() *InMemoryBroker {
 		subscribers: make(map[string][]chan Message),
 		verbose:     false,
 		closed:      false,
+		stats:       make(map[string]*TopicStats),
 	}
 }
 
@@ -56,18 +71,30 @@ func (b *InMemoryBroker) Publish(ctx context.Context, topic string, key string,
 		Timestamp: time.Now().UnixMilli(),
 	}
 
-	if channels, ok := b.subscribers[topic]; ok {
-		for _, ch := range channels {
-			select {
-			case ch <- msg:
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-				// Channel buffer full - log warning but continue
-				// This is acceptable for local development; production would use backpressure
-				if b.verbose {
-					fmt.Printf("[InMemoryBroker] Warning: channel buffer full for topic '%s', message dropped\n", topic)
-				}
+	// Exact subscribers plus matching pattern subscribers. Copy so appending
+	// never writes into the subscribers map's backing array.
+	channels := append([]chan Message(nil), b.subscribers[topic]...)
+	for _, sub := range b.patterns {
+		if sub.re.MatchString(topic) {
+			channels = append(channels, sub.ch)
+		}
+	}
+
+	var delivered, dropped int64
+	defer func() { b.recordPublish(topic, delivered, dropped) }()
+
+	for _, ch := range channels {
+		select {
+		case ch <- msg:
+			delivered++
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			// Channel buffer full - log warning but continue
+			// This is acceptable for local development; production would use backpressure
+			dropped++
+			if b.verbose {
+				fmt.Printf("[InMemoryBroker] Warning: channel buffer full for topic '%s', message dropped\n", topic)
 			}
 		}
 	}
@@ -75,6 +102,28 @@ func (b *InMemoryBroker) Publish(ctx context.Context, topic string, key string,
 	return nil
 }
 
+// recordPublish updates the counters for topic after a publish.
+func (b *InMemoryBroker) recordPublish(topic string, delivered, dropped int64) {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	st := b.topicStats(topic)
+	st.Published++
+	st.Delivered += delivered
+	st.Dropped += dropped
+}
+
+// topicStats returns the counters for topic, creating them if needed.
+// Caller must hold statsMu.
+func (b *InMemoryBroker) topicStats(topic string) *TopicStats {
+	st, ok := b.stats[topic]
+	if !ok {
+		st = &TopicStats{Topic: topic}
+		b.stats[topic] = st
+	}
+	return st
+}
+
 // Subscribe creates and returns a channel for receiving messages from the specified topic.
 // Implements the Broker interface. groupID is ignored for in-memory broker.
 func (b *InMemoryBroker) Subscribe(ctx context.Context, topic string, groupID string) (<-chan Message, error) {
@@ -89,13 +138,97 @@ func (b *InMemoryBroker) Subscribe(ctx context.Context, topic string, groupID st
 	ch := make(chan Message, 100)
 	b.subscribers[topic] = append(b.subscribers[topic], ch)
 
+	// Stop delivering once the subscriber's context is done, so short-lived
+	// subscriptions (such as gRPC streams) don't pile up
+	context.AfterFunc(ctx, func() { b.unsubscribe(ch) })
+
 	if b.verbose {
 		fmt.Printf("[InMemoryBroker] New subscriber for topic '%s' (group: %s)\n", topic, groupID)
 	}
 
+	// Make the topic visible to Topics() before anything is published
+	b.statsMu.Lock()
+	b.topicStats(topic)
+	b.statsMu.Unlock()
+
+	return ch, nil
+}
+
+// SubscribePattern creates a channel receiving messages from every topic that
+// matches pattern. Implements the Broker interface. groupID is ignored.
+func (b *InMemoryBroker) SubscribePattern(ctx context.Context, pattern string, groupID string) (<-chan Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, fmt.Errorf("broker is closed")
+	}
+
+	ch := make(chan Message, 100)
+	b.patterns = append(b.patterns, patternSubscriber{
+		pattern: pattern,
+		re:      patternRegexp(pattern),
+		ch:      ch,
+	})
+	context.AfterFunc(ctx, func() { b.unsubscribe(ch) })
+
+	if b.verbose {
+		fmt.Printf("[InMemoryBroker] New pattern subscriber for '%s' (group: %s)\n", pattern, groupID)
+	}
+
 	return ch, nil
 }
 
+// unsubscribe stops delivering to ch. The channel is left open, as its
+// reader may still be draining it.
+func (b *InMemoryBroker) unsubscribe(ch chan Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	for topic, channels := range b.subscribers {
+		b.subscribers[topic] = slices.DeleteFunc(channels, func(c chan Message) bool { return c == ch })
+	}
+	b.patterns = slices.DeleteFunc(b.patterns, func(sub patternSubscriber) bool { return sub.ch == ch })
+}
+
+// Topics lists every topic that has been published to or subscribed to.
+// Implements the Broker interface.
+func (b *InMemoryBroker) Topics(ctx context.Context) ([]string, error) {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	topics := make([]string, 0, len(b.stats))
+	for topic := range b.stats {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+// Stats returns a snapshot of per-topic counters. Implements the Broker interface.
+func (b *InMemoryBroker) Stats() map[string]TopicStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	result := make(map[string]TopicStats, len(b.stats))
+	for topic, st := range b.stats {
+		snapshot := *st
+		snapshot.Subscribers = len(b.subscribers[topic])
+		for _, sub := range b.patterns {
+			if sub.re.MatchString(topic) {
+				snapshot.Subscribers++
+			}
+		}
+		result[topic] = snapshot
+	}
+	return result
+}
+
 // Close shuts down the broker and closes all subscriber channels.
 func (b *InMemoryBroker) Close() error {
 	b.mu.Lock()
@@ -114,6 +247,10 @@ func (b *InMemoryBroker) Close() error {
 		}
 		delete(b.subscribers, topic)
 	}
+	for _, sub := range b.patterns {
+		close(sub.ch)
+	}
+	b.patterns = nil
 
 	return nil
 }
@@ -231,3 +231,131 @@ func TestMultipleSubscribersSameTopic(t *testing.T) {
 		}
 	}
 }
+
+// TestSubscribePattern verifies wildcard subscribers receive messages from every matching topic.
+func TestSubscribePattern(t *testing.T) {
+	broker := NewInMemoryBroker()
+	defer broker.Close()
+
+	ctx := context.Background()
+
+	ch, err := broker.SubscribePattern(ctx, "destill.analysis.*", "test-group")
+	if err != nil {
+		t.Fatalf("SubscribePattern failed: %v", err)
+	}
+
+	broker.Publish(ctx, "destill.analysis.findings", "k", []byte("finding"))
+	broker.Publish(ctx, "destill.logs.raw", "k", []byte("chunk"))
+	broker.Publish(ctx, "destill.analysis.summary", "k", []byte("summary"))
+
+	var got []string
+	for len(got) < 2 {
+		select {
+		case msg := <-ch:
+			got = append(got, msg.Topic)
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Timeout waiting for messages, got %v", got)
+		}
+	}
+
+	if got[0] != "destill.analysis.findings" || got[1] != "destill.analysis.summary" {
+		t.Errorf("Received topics %v, want findings then summary", got)
+	}
+
+	select {
+	case msg := <-ch:
+		t.Errorf("Unexpected message from topic %s", msg.Topic)
+	default:
+	}
+}
+
+// TestTopicsAndStats verifies introspection reflects subscriptions and message flow.
+func TestTopicsAndStats(t *testing.T) {
+	broker := NewInMemoryBroker()
+	defer broker.Close()
+
+	ctx := context.Background()
+
+	if _, err := broker.Subscribe(ctx, "topic-a", "group"); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if _, err := broker.SubscribePattern(ctx, "topic-*", "group"); err != nil {
+		t.Fatalf("SubscribePattern failed: %v", err)
+	}
+
+	broker.Publish(ctx, "topic-a", "k", []byte("1"))
+	broker.Publish(ctx, "topic-a", "k", []byte("2"))
+	broker.Publish(ctx, "other", "k", []byte("3"))
+
+	topics, err := broker.Topics(ctx)
+	if err != nil {
+		t.Fatalf("Topics failed: %v", err)
+	}
+	if len(topics) != 2 || topics[0] != "other" || topics[1] != "topic-a" {
+		t.Errorf("Topics() = %v, want [other topic-a]", topics)
+	}
+
+	stats := broker.Stats()
+	a := stats["topic-a"]
+	if a.Published != 2 || a.Delivered != 4 || a.Subscribers != 2 {
+		t.Errorf("Stats()[topic-a] = %+v, want Published=2 Delivered=4 Subscribers=2", a)
+	}
+	other := stats["other"]
+	if other.Published != 1 || other.Delivered != 0 || other.Subscribers != 0 {
+		t.Errorf("Stats()[other] = %+v, want Published=1 Delivered=0 Subscribers=0", other)
+	}
+}
+
+// TestStatsCountsDropped verifies messages dropped on a full buffer are counted.
+func TestStatsCountsDropped(t *testing.T) {
+	broker := NewInMemoryBroker()
+	defer broker.Close()
+
+	ctx := context.Background()
+
+	if _, err := broker.Subscribe(ctx, "slow", "group"); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	// Buffer holds 100 messages and nobody is reading
+	for i := 0; i < 105; i++ {
+		broker.Publish(ctx, "slow", "k", []byte("x"))
+	}
+
+	if st := broker.Stats()["slow"]; st.Delivered != 100 || st.Dropped != 5 {
+		t.Errorf("Stats()[slow] = %+v, want Delivered=100 Dropped=5", st)
+	}
+}
+
+// TestUnsubscribeOnContextDone verifies a subscription stops receiving once its context is done.
+func TestUnsubscribeOnContextDone(t *testing.T) {
+	broker := NewInMemoryBroker()
+	defer broker.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := broker.Subscribe(ctx, "test-topic", "test-group")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	patternCh, err := broker.SubscribePattern(ctx, "test-*", "test-group")
+	if err != nil {
+		t.Fatalf("SubscribePattern failed: %v", err)
+	}
+	cancel()
+
+	// AfterFunc runs asynchronously; wait for the subscriptions to go
+	deadline := time.Now().Add(time.Second)
+	for broker.Stats()["test-topic"].Subscribers > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Subscriptions still active after context was canceled")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := broker.Publish(context.Background(), "test-topic", "key", []byte("late")); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if len(ch) != 0 || len(patternCh) != 0 {
+		t.Errorf("Canceled subscriptions received %d and %d messages, want none", len(ch), len(patternCh))
+	}
+}
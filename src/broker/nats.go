@@ -0,0 +1,308 @@
+// Package broker provides NATS JetStream broker implementation.
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// NATSStream is the JetStream stream holding every destill topic. Topics
+	// are used as subjects as is, so they must start with "destill.".
+	NATSStream = "DESTILL"
+
+	// natsKeyHeader carries the message key, which JetStream has no field for.
+	natsKeyHeader = "Destill-Key"
+
+	// natsEphemeralTTL is how long the server keeps the consumer of a
+	// subscription without a group after its subscriber goes away.
+	natsEphemeralTTL = time.Minute
+)
+
+// NATSBroker is a broker implementation on NATS JetStream. Subscribers with
+// the same groupID share a durable consumer, so they split the messages and
+// resume where the group left off, as in a Kafka consumer group.
+type NATSBroker struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	stream jetstream.Stream
+	mu     sync.RWMutex
+	subs   map[string]jetstream.ConsumeContext // topic+groupID -> active consumer
+	closed bool
+
+	statsMu sync.Mutex
+	stats   map[string]*topicCounters // topic -> counters
+	names   map[string]int            // topic or pattern -> active subscriptions
+}
+
+// NewNATSBroker connects to the NATS server at url (e.g. nats://localhost:4222)
+// and creates the destill stream if it doesn't exist yet.
+func NewNATSBroker(url string) (*NATSBroker, error) {
+	conn, err := nats.Connect(url, nats.Name("destill"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     NATSStream,
+		Subjects: []string{"destill.>"},
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create stream %s: %w", NATSStream, err)
+	}
+
+	return &NATSBroker{
+		conn:   conn,
+		js:     js,
+		stream: stream,
+		subs:   make(map[string]jetstream.ConsumeContext),
+		stats:  make(map[string]*topicCounters),
+		names:  make(map[string]int),
+	}, nil
+}
+
+// Publish stores a message on the topic's subject, waiting for the
+// server's acknowledgement. Implements the Broker interface.
+func (b *NATSBroker) Publish(ctx context.Context, topic string, key string, value []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return fmt.Errorf("broker is closed")
+	}
+
+	msg := nats.NewMsg(topic)
+	msg.Data = value
+	if key != "" {
+		msg.Header.Set(natsKeyHeader, key)
+	}
+	if _, err := b.js.PublishMsg(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	b.counters(topic).published.Add(1)
+	return nil
+}
+
+// counters returns the counters for topic, creating them if needed.
+func (b *NATSBroker) counters(topic string) *topicCounters {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	c, ok := b.stats[topic]
+	if !ok {
+		c = &topicCounters{}
+		b.stats[topic] = c
+	}
+	return c
+}
+
+// Subscribe consumes the topic from the start of the stream, or from where
+// groupID left off. Implements the Broker interface.
+func (b *NATSBroker) Subscribe(ctx context.Context, topic string, groupID string) (<-chan Message, error) {
+	return b.subscribe(ctx, topic, groupID, topic)
+}
+
+// SubscribePattern consumes every topic matching pattern, including topics
+// created later. Implements the Broker interface.
+func (b *NATSBroker) SubscribePattern(ctx context.Context, pattern string, groupID string) (<-chan Message, error) {
+	return b.subscribe(ctx, pattern, groupID, natsFilterSubject(pattern))
+}
+
+// subscribe starts a consumer of the subjects matching filter for name (a
+// topic or pattern) and groupID. Messages are acknowledged once handed to
+// the channel.
+func (b *NATSBroker) subscribe(ctx context.Context, name string, groupID string, filter string) (<-chan Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, fmt.Errorf("broker is closed")
+	}
+
+	cfg := jetstream.ConsumerConfig{
+		FilterSubject: filter,
+		DeliverPolicy: jetstream.DeliverAllPolicy, // Start from beginning
+		AckPolicy:     jetstream.AckExplicitPolicy,
+	}
+	if groupID != "" {
+		cfg.Durable = natsConsumerName(name, groupID)
+	} else {
+		cfg.InactiveThreshold = natsEphemeralTTL
+	}
+	consumer, err := b.stream.CreateOrUpdateConsumer(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer: %w", err)
+	}
+
+	msgChan := make(chan Message, 100)
+	done := make(chan struct{}) // Closed to unblock a handler waiting to send
+	var sendMu sync.Mutex       // Held by the handler while sending
+	stopped := false            // Set under sendMu before msgChan is closed
+	cc, err := consumer.Consume(func(m jetstream.Msg) {
+		if name != filter && !MatchTopic(name, m.Subject()) {
+			m.Ack() // Outside the pattern, but within the subject filter
+			return
+		}
+		msg := Message{
+			Topic: m.Subject(),
+			Key:   m.Headers().Get(natsKeyHeader),
+			Value: m.Data(),
+		}
+		if meta, err := m.Metadata(); err == nil {
+			msg.Offset = int64(meta.Sequence.Stream)
+			msg.Timestamp = meta.Timestamp.UnixMilli()
+		}
+
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		if stopped {
+			m.Nak() // Redeliver to another member of the group
+			return
+		}
+		select {
+		case msgChan <- msg:
+			m.Ack()
+			b.counters(msg.Topic).delivered.Add(1)
+		case <-done:
+			m.Nak()
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start consumer: %w", err)
+	}
+
+	key := fmt.Sprintf("%s:%s:%p", name, groupID, msgChan)
+	b.subs[key] = cc
+
+	b.statsMu.Lock()
+	b.names[name]++
+	b.statsMu.Unlock()
+
+	// Stop consuming when the subscriber goes away or the broker closes
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-cc.Closed():
+		}
+		close(done)
+		cc.Stop()
+		sendMu.Lock()
+		stopped = true
+		close(msgChan)
+		sendMu.Unlock()
+
+		b.mu.Lock()
+		delete(b.subs, key)
+		b.mu.Unlock()
+
+		b.statsMu.Lock()
+		b.names[name]--
+		b.statsMu.Unlock()
+	}()
+
+	return msgChan, nil
+}
+
+// natsConsumerName derives a durable consumer name from a group and the
+// topic or pattern it consumes. Names may not contain '.', '*', '>', or
+// whitespace.
+func natsConsumerName(name, groupID string) string {
+	replacer := strings.NewReplacer(".", "_", "*", "_", ">", "_", "?", "_", " ", "_", "\t", "_")
+	return replacer.Replace(groupID + "-" + name)
+}
+
+// natsFilterSubject returns the narrowest subject filter covering every
+// topic a pattern can match: the pattern's leading literal tokens followed
+// by '>'. The handler applies the pattern itself.
+func natsFilterSubject(pattern string) string {
+	var literal []string
+	for _, token := range strings.Split(pattern, ".") {
+		if strings.ContainsAny(token, "*?") {
+			break
+		}
+		literal = append(literal, token)
+	}
+	if len(literal) == len(strings.Split(pattern, ".")) {
+		return pattern // No wildcards
+	}
+	if len(literal) == 0 {
+		return ">"
+	}
+	return strings.Join(literal, ".") + ".>"
+}
+
+// Topics lists the subjects holding messages in the destill stream.
+// Implements the Broker interface.
+func (b *NATSBroker) Topics(ctx context.Context) ([]string, error) {
+	info, err := b.stream.Info(ctx, jetstream.WithSubjectFilter(">"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stream info: %w", err)
+	}
+
+	topics := make([]string, 0, len(info.State.Subjects))
+	for subject := range info.State.Subjects {
+		topics = append(topics, subject)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+// Stats returns counters for messages this instance published and consumed.
+// Other clients of the server are not reflected.
+// Implements the Broker interface.
+func (b *NATSBroker) Stats() map[string]TopicStats {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	result := make(map[string]TopicStats, len(b.stats))
+	for topic, c := range b.stats {
+		st := TopicStats{
+			Topic:     topic,
+			Published: c.published.Load(),
+			Delivered: c.delivered.Load(),
+		}
+		for name, n := range b.names {
+			if MatchTopic(name, topic) {
+				st.Subscribers += n
+			}
+		}
+		result[topic] = st
+	}
+	return result
+}
+
+// Close stops all consumers and closes the connection. Durable consumers
+// stay on the server for the next run.
+func (b *NATSBroker) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	subs := b.subs
+	b.subs = make(map[string]jetstream.ConsumeContext)
+	b.mu.Unlock()
+
+	for _, cc := range subs {
+		cc.Stop()
+	}
+	b.conn.Close()
+	return nil
+}
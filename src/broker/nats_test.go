@@ -0,0 +1,42 @@
+package broker
+
+import "testing"
+
+func TestNATSFilterSubject(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"destill.logs.raw", "destill.logs.raw"},
+		{"destill.*", "destill.>"},
+		{"destill.analysis.*", "destill.analysis.>"},
+		{"destill.log?.raw", "destill.>"},
+		{"destill.*.findings", "destill.>"},
+		{"*", ">"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := natsFilterSubject(tt.pattern); got != tt.want {
+				t.Errorf("natsFilterSubject(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNATSConsumerName(t *testing.T) {
+	tests := []struct {
+		name    string
+		groupID string
+		want    string
+	}{
+		{"destill.requests", "destill-ingest", "destill-ingest-destill_requests"},
+		{"destill.*", "self triage", "self_triage-destill__"},
+	}
+
+	for _, tt := range tests {
+		if got := natsConsumerName(tt.name, tt.groupID); got != tt.want {
+			t.Errorf("natsConsumerName(%q, %q) = %q, want %q", tt.name, tt.groupID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package broker
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MatchTopic reports whether topic matches a subscription pattern.
+// '*' matches any run of characters (including dots) and '?' matches exactly
+// one character, so "destill.*" matches every destill topic and
+// "destill.analysis.*" only the analysis topics. All other characters match
+// literally.
+func MatchTopic(pattern, topic string) bool {
+	return patternRegexp(pattern).MatchString(topic)
+}
+
+// patternRegexp compiles a subscription pattern into an anchored regexp.
+func patternRegexp(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(patternExpr(pattern))
+}
+
+// patternExpr translates a subscription pattern into an anchored regexp string.
+func patternExpr(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
@@ -0,0 +1,27 @@
+package broker
+
+import "testing"
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		pattern string
+		topic   string
+		want    bool
+	}{
+		{"destill.*", "destill.logs.raw", true},
+		{"destill.*", "destill.requests", true},
+		{"destill.analysis.*", "destill.analysis.findings", true},
+		{"destill.analysis.*", "destill.logs.raw", false},
+		{"destill.requests", "destill.requests", true},
+		{"destill.requests", "destillXrequests", false}, // dots are literal
+		{"destill.logs.ra?", "destill.logs.raw", true},
+		{"*", "anything", true},
+		{"logs", "destill.logs.raw", false}, // patterns are anchored
+	}
+
+	for _, tt := range tests {
+		if got := MatchTopic(tt.pattern, tt.topic); got != tt.want {
+			t.Errorf("MatchTopic(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
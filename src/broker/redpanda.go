@@ -4,27 +4,83 @@ package broker
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 )
 
 // RedpandaBroker is a Kafka-compatible broker implementation using franz-go.
 type RedpandaBroker struct {
 	client    *kgo.Client
 	brokers   []string
+	groupOpts []kgo.Opt // Consumer group options, from RedpandaOptions
 	mu        sync.RWMutex
 	consumers map[string]*kgo.Client // topic+groupID -> consumer client
 	closed    bool
+
+	statsMu sync.Mutex
+	stats   map[string]*topicCounters // topic -> counters
+	subs    map[string]int            // topic or pattern -> active subscriptions
+}
+
+// topicCounters are updated from Publish and consume loops concurrently.
+type topicCounters struct {
+	published atomic.Int64
+	delivered atomic.Int64
+}
+
+// RedpandaOptions configure how a RedpandaBroker's consumer groups
+// rebalance partitions between their members.
+type RedpandaOptions struct {
+	// Balancer assigns partitions to the members of a group:
+	// "cooperative-sticky" (default), "sticky", "range", or "roundrobin".
+	// Members of one group must agree on it.
+	Balancer string
+
+	// SessionTimeout is how long a member may go without heartbeats before
+	// its partitions are moved to the other members (default 45s).
+	SessionTimeout time.Duration
+}
+
+// redpandaBalancers are the RedpandaOptions.Balancer names.
+var redpandaBalancers = map[string]func() kgo.GroupBalancer{
+	"cooperative-sticky": kgo.CooperativeStickyBalancer,
+	"sticky":             kgo.StickyBalancer,
+	"range":              kgo.RangeBalancer,
+	"roundrobin":         kgo.RoundRobinBalancer,
 }
 
 // NewRedpandaBroker creates a new RedpandaBroker instance.
 // brokers is a slice of broker addresses (e.g., ["localhost:19092"]).
 func NewRedpandaBroker(brokers []string) (*RedpandaBroker, error) {
+	return NewRedpandaBrokerWithOptions(brokers, RedpandaOptions{})
+}
+
+// NewRedpandaBrokerWithOptions is NewRedpandaBroker with consumer group
+// options.
+func NewRedpandaBrokerWithOptions(brokers []string, opts RedpandaOptions) (*RedpandaBroker, error) {
 	if len(brokers) == 0 {
 		return nil, fmt.Errorf("at least one broker address is required")
 	}
 
+	var groupOpts []kgo.Opt
+	if opts.Balancer != "" {
+		balancer, ok := redpandaBalancers[opts.Balancer]
+		if !ok {
+			return nil, fmt.Errorf("unknown consumer group balancer %q (expected cooperative-sticky, sticky, range, or roundrobin)", opts.Balancer)
+		}
+		groupOpts = append(groupOpts, kgo.Balancers(balancer()))
+	}
+	if opts.SessionTimeout > 0 {
+		groupOpts = append(groupOpts, kgo.SessionTimeout(opts.SessionTimeout))
+	}
+
 	// Create producer client
 	client, err := kgo.NewClient(
 		kgo.SeedBrokers(brokers...),
@@ -37,8 +93,11 @@ func NewRedpandaBroker(brokers []string) (*RedpandaBroker, error) {
 	return &RedpandaBroker{
 		client:    client,
 		brokers:   brokers,
+		groupOpts: groupOpts,
 		consumers: make(map[string]*kgo.Client),
 		closed:    false,
+		stats:     make(map[string]*topicCounters),
+		subs:      make(map[string]int),
 	}, nil
 }
 
@@ -64,9 +123,23 @@ func (b *RedpandaBroker) Publish(ctx context.Context, topic string, key string,
 		return fmt.Errorf("failed to produce message: %w", err)
 	}
 
+	b.counters(topic).published.Add(1)
 	return nil
 }
 
+// counters returns the counters for topic, creating them if needed.
+func (b *RedpandaBroker) counters(topic string) *topicCounters {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	c, ok := b.stats[topic]
+	if !ok {
+		c = &topicCounters{}
+		b.stats[topic] = c
+	}
+	return c
+}
+
 // Subscribe creates a consumer for the specified topic and consumer group.
 // Returns a channel that will receive messages.
 // Implements the Broker interface.
@@ -78,37 +151,139 @@ func (b *RedpandaBroker) Subscribe(ctx context.Context, topic string, groupID st
 		return nil, fmt.Errorf("broker is closed")
 	}
 
-	consumerKey := fmt.Sprintf("%s:%s", topic, groupID)
+	return b.subscribe(ctx, topic, groupID, false, kgo.ConsumeTopics(topic))
+}
+
+// SubscribeUncommitted is Subscribe, except that the group's position is
+// only committed for messages passed to Commit: a chunk in flight when an
+// agent crashes is delivered again, to it or another member of the group.
+// Implements the Committer interface.
+func (b *RedpandaBroker) SubscribeUncommitted(ctx context.Context, topic string, groupID string) (<-chan Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, fmt.Errorf("broker is closed")
+	}
+
+	return b.subscribe(ctx, topic, groupID, true,
+		kgo.ConsumeTopics(topic),
+		kgo.AutoCommitMarks(),
+		// Commit what's handled before a partition moves to another member
+		kgo.OnPartitionsRevoked(func(ctx context.Context, cl *kgo.Client, _ map[string][]int32) {
+			if err := cl.CommitMarkedOffsets(ctx); err != nil {
+				fmt.Printf("[RedpandaBroker] Commit error: %v\n", err)
+			}
+		}),
+	)
+}
+
+// Commit marks a message from SubscribeUncommitted handled. Implements the
+// Committer interface.
+func (b *RedpandaBroker) Commit(msg Message) {
+	if msg.commit != nil {
+		msg.commit()
+	}
+}
+
+// SubscribePattern creates a regex consumer for every topic matching pattern.
+// Topics created later are picked up on the client's next metadata refresh.
+// Implements the Broker interface.
+func (b *RedpandaBroker) SubscribePattern(ctx context.Context, pattern string, groupID string) (<-chan Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, fmt.Errorf("broker is closed")
+	}
+
+	return b.subscribe(ctx, pattern, groupID, false, kgo.ConsumeTopics(patternExpr(pattern)), kgo.ConsumeRegex())
+}
+
+// subscribe starts a consumer for name (a topic or pattern) and groupID.
+// With marks, messages carry a commit function marking them for commit.
+// Caller must hold b.mu.
+func (b *RedpandaBroker) subscribe(ctx context.Context, name string, groupID string, marks bool, opts ...kgo.Opt) (<-chan Message, error) {
+	consumerKey := fmt.Sprintf("%s:%s", name, groupID)
 
 	// Check if consumer already exists
 	if _, exists := b.consumers[consumerKey]; exists {
-		return nil, fmt.Errorf("consumer already exists for topic %s and group %s", topic, groupID)
+		return nil, fmt.Errorf("consumer already exists for topic %s and group %s", name, groupID)
 	}
 
 	// Create consumer client
-	consumer, err := kgo.NewClient(
+	opts = slices.Concat([]kgo.Opt{
 		kgo.SeedBrokers(b.brokers...),
 		kgo.ConsumerGroup(groupID),
-		kgo.ConsumeTopics(topic),
 		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()), // Start from beginning
-	)
+	}, b.groupOpts, opts)
+	consumer, err := kgo.NewClient(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create consumer: %w", err)
 	}
 
 	b.consumers[consumerKey] = consumer
 
+	b.statsMu.Lock()
+	b.subs[name]++
+	b.statsMu.Unlock()
+
 	// Create message channel
 	msgChan := make(chan Message, 100)
 
 	// Start consuming in a goroutine
-	go b.consumeLoop(ctx, consumer, msgChan)
+	go b.consumeLoop(ctx, consumer, msgChan, marks)
 
 	return msgChan, nil
 }
 
+// Topics lists the non-internal topics in the cluster.
+// Implements the Broker interface.
+func (b *RedpandaBroker) Topics(ctx context.Context) ([]string, error) {
+	req := kmsg.NewPtrMetadataRequest() // nil Topics requests all topics
+	resp, err := req.RequestWith(ctx, b.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch topic metadata: %w", err)
+	}
+
+	var topics []string
+	for _, t := range resp.Topics {
+		if t.Topic == nil || t.IsInternal || strings.HasPrefix(*t.Topic, "_") {
+			continue
+		}
+		topics = append(topics, *t.Topic)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+// Stats returns counters for messages this instance published and consumed.
+// Other producers and consumers in the cluster are not reflected.
+// Implements the Broker interface.
+func (b *RedpandaBroker) Stats() map[string]TopicStats {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	result := make(map[string]TopicStats, len(b.stats))
+	for topic, c := range b.stats {
+		st := TopicStats{
+			Topic:     topic,
+			Published: c.published.Load(),
+			Delivered: c.delivered.Load(),
+		}
+		for name, n := range b.subs {
+			if MatchTopic(name, topic) {
+				st.Subscribers += n
+			}
+		}
+		result[topic] = st
+	}
+	return result
+}
+
 // consumeLoop continuously polls for messages and sends them to the channel.
-func (b *RedpandaBroker) consumeLoop(ctx context.Context, consumer *kgo.Client, msgChan chan<- Message) {
+// With marks, each message can mark its record for commit (see Commit).
+func (b *RedpandaBroker) consumeLoop(ctx context.Context, consumer *kgo.Client, msgChan chan<- Message, marks bool) {
 	defer close(msgChan)
 
 	for {
@@ -140,9 +315,13 @@ func (b *RedpandaBroker) consumeLoop(ctx context.Context, consumer *kgo.Client,
 					Partition: record.Partition,
 					Timestamp: record.Timestamp.UnixMilli(),
 				}
+				if marks {
+					msg.commit = func() { consumer.MarkCommitRecords(record) }
+				}
 
 				select {
 				case msgChan <- msg:
+					b.counters(record.Topic).delivered.Add(1)
 				case <-ctx.Done():
 					return
 				}
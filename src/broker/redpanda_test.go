@@ -0,0 +1,37 @@
+package broker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewRedpandaBrokerWithOptions_Balancer(t *testing.T) {
+	_, err := NewRedpandaBrokerWithOptions([]string{"localhost:19092"}, RedpandaOptions{Balancer: "fastest"})
+	if err == nil || !strings.Contains(err.Error(), `"fastest"`) {
+		t.Errorf("NewRedpandaBrokerWithOptions(fastest) error = %v, want unknown balancer", err)
+	}
+
+	// Creating a client doesn't dial, so known balancers succeed offline
+	for name := range redpandaBalancers {
+		b, err := NewRedpandaBrokerWithOptions([]string{"localhost:19092"}, RedpandaOptions{Balancer: name})
+		if err != nil {
+			t.Errorf("NewRedpandaBrokerWithOptions(%s) error = %v", name, err)
+			continue
+		}
+		b.Close()
+	}
+}
+
+func TestSubscribeAtLeastOnce_InMemory(t *testing.T) {
+	brk := NewInMemoryBroker()
+	defer brk.Close()
+
+	msgs, done, err := SubscribeAtLeastOnce(t.Context(), brk, "destill.logs.raw", "test")
+	if err != nil {
+		t.Fatalf("SubscribeAtLeastOnce() error = %v", err)
+	}
+	if err := brk.Publish(t.Context(), "destill.logs.raw", "key", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	done(<-msgs) // A no-op without a Committer
+}
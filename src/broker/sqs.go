@@ -0,0 +1,516 @@
+// Package broker provides an AWS SNS/SQS broker implementation.
+package broker
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	// Message attributes and queue tags. SNS filters on the topic attribute,
+	// so each queue only receives the topics it subscribed to.
+	sqsTopicAttribute    = "destill-topic"
+	sqsKeyAttribute      = "destill-key"
+	sqsEncodingAttribute = "destill-encoding" // "base64" when the value isn't UTF-8
+	sqsGroupTag          = "destill-group"
+
+	sqsWaitSeconds       = 20  // Long polling
+	sqsVisibilitySeconds = 300 // How long a received message stays hidden before redelivery
+	sqsMaxQueueName      = 80
+	sqsRetryDelay        = 5 * time.Second
+)
+
+var sqsInvalidQueueChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// SQSBroker is a broker implementation on AWS SNS and SQS. Every message is
+// published to one SNS topic named after the prefix, with the destill topic
+// as a message attribute. Each subscription reads its own SQS queue,
+// subscribed to the SNS topic with a filter on that attribute: one queue per
+// consumer group and topic, so subscribers with the same groupID split the
+// messages, and every group gets a copy, as in a Kafka consumer group.
+//
+// Queues are created on the first Subscribe; messages published before a
+// group's queue exists are not delivered to it. SQS standard queues don't
+// preserve order, and SNS limits messages to 256 KB.
+type SQSBroker struct {
+	sns      *sns.Client
+	sqs      *sqs.Client
+	prefix   string
+	topicARN string
+	mu       sync.RWMutex
+	subs     map[string]context.CancelFunc // topic+groupID -> stops the receive loop
+	wg       sync.WaitGroup                // Receive loops, which clean up temporary queues
+	closed   bool
+
+	statsMu sync.Mutex
+	stats   map[string]*topicCounters // topic -> counters
+	names   map[string]int            // topic or pattern -> active subscriptions
+}
+
+// NewSQSBroker connects to SNS and SQS with the default AWS configuration
+// (AWS_REGION, AWS_PROFILE, access keys, or an instance role; AWS_ENDPOINT_URL
+// for LocalStack) and creates the SNS topic named prefix if it doesn't exist.
+func NewSQSBroker(prefix string) (*SQSBroker, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	snsClient := sns.NewFromConfig(awsCfg)
+	topic, err := snsClient.CreateTopic(ctx, &sns.CreateTopicInput{Name: aws.String(prefix)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SNS topic %s: %w", prefix, err)
+	}
+
+	return &SQSBroker{
+		sns:      snsClient,
+		sqs:      sqs.NewFromConfig(awsCfg),
+		prefix:   prefix,
+		topicARN: aws.ToString(topic.TopicArn),
+		subs:     make(map[string]context.CancelFunc),
+		stats:    make(map[string]*topicCounters),
+		names:    make(map[string]int),
+	}, nil
+}
+
+// Publish sends a message to the SNS topic, tagged with topic and key.
+// Implements the Broker interface.
+func (b *SQSBroker) Publish(ctx context.Context, topic string, key string, value []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return fmt.Errorf("broker is closed")
+	}
+
+	body, encoding := sqsEncode(value)
+	attrs := map[string]snstypes.MessageAttributeValue{
+		sqsTopicAttribute: snsString(topic),
+	}
+	if key != "" {
+		attrs[sqsKeyAttribute] = snsString(key)
+	}
+	if encoding != "" {
+		attrs[sqsEncodingAttribute] = snsString(encoding)
+	}
+	_, err := b.sns.Publish(ctx, &sns.PublishInput{
+		TopicArn:          aws.String(b.topicARN),
+		Message:           aws.String(body),
+		MessageAttributes: attrs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	b.counters(topic).published.Add(1)
+	return nil
+}
+
+// snsString builds a string message attribute.
+func snsString(value string) snstypes.MessageAttributeValue {
+	return snstypes.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+}
+
+// counters returns the counters for topic, creating them if needed.
+func (b *SQSBroker) counters(topic string) *topicCounters {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	c, ok := b.stats[topic]
+	if !ok {
+		c = &topicCounters{}
+		b.stats[topic] = c
+	}
+	return c
+}
+
+// Subscribe reads the topic from groupID's queue. Without a groupID the
+// subscription gets a temporary queue, deleted when it ends.
+// Implements the Broker interface.
+func (b *SQSBroker) Subscribe(ctx context.Context, topic string, groupID string) (<-chan Message, error) {
+	return b.subscribe(ctx, topic, groupID)
+}
+
+// SubscribePattern reads every topic matching pattern, including topics
+// created later, from one queue. Implements the Broker interface.
+func (b *SQSBroker) SubscribePattern(ctx context.Context, pattern string, groupID string) (<-chan Message, error) {
+	return b.subscribe(ctx, pattern, groupID)
+}
+
+// subscribe creates the queue for name (a topic or pattern) and groupID,
+// subscribes it to the SNS topic, and starts receiving. Messages are deleted
+// from the queue once handed to the channel.
+func (b *SQSBroker) subscribe(ctx context.Context, name string, groupID string) (<-chan Message, error) {
+	b.mu.RLock()
+	closed := b.closed
+	b.mu.RUnlock()
+	if closed {
+		return nil, fmt.Errorf("broker is closed")
+	}
+
+	temporary := groupID == ""
+	if temporary {
+		groupID = "tmp-" + randomSuffix()
+	}
+	queueURL, subscriptionARN, err := b.createQueue(ctx, name, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	msgChan := make(chan Message, 100)
+	key := fmt.Sprintf("%s:%s:%p", name, groupID, msgChan)
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("broker is closed")
+	}
+	b.subs[key] = cancel
+	b.wg.Add(1)
+	b.mu.Unlock()
+
+	b.statsMu.Lock()
+	b.names[name]++
+	b.statsMu.Unlock()
+
+	go func() {
+		defer b.wg.Done()
+		b.receive(subCtx, name, queueURL, msgChan)
+		close(msgChan)
+		cancel()
+
+		b.mu.Lock()
+		delete(b.subs, key)
+		b.mu.Unlock()
+
+		b.statsMu.Lock()
+		b.names[name]--
+		b.statsMu.Unlock()
+
+		if temporary {
+			b.deleteQueue(queueURL, subscriptionARN)
+		}
+	}()
+
+	return msgChan, nil
+}
+
+// createQueue creates the queue of name and groupID, allows the SNS topic
+// to send to it, and subscribes it with a filter for name. Both calls are
+// idempotent, so group members share the queue.
+func (b *SQSBroker) createQueue(ctx context.Context, name, groupID string) (queueURL, subscriptionARN string, err error) {
+	queueName := sqsQueueName(b.prefix, groupID, name)
+	created, err := b.sqs.CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName: aws.String(queueName),
+		Attributes: map[string]string{
+			string(sqstypes.QueueAttributeNameVisibilityTimeout):             strconv.Itoa(sqsVisibilitySeconds),
+			string(sqstypes.QueueAttributeNameReceiveMessageWaitTimeSeconds): strconv.Itoa(sqsWaitSeconds),
+		},
+		Tags: map[string]string{sqsTopicAttribute: name, sqsGroupTag: groupID},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create queue %s: %w", queueName, err)
+	}
+	queueURL = aws.ToString(created.QueueUrl)
+
+	attrs, err := b.sqs.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read queue %s: %w", queueName, err)
+	}
+	queueARN := attrs.Attributes[string(sqstypes.QueueAttributeNameQueueArn)]
+
+	_, err = b.sqs.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(queueURL),
+		Attributes: map[string]string{string(sqstypes.QueueAttributeNamePolicy): sqsQueuePolicy(queueARN, b.topicARN)},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to set policy of queue %s: %w", queueName, err)
+	}
+
+	subAttrs := map[string]string{"RawMessageDelivery": "true"}
+	if policy := sqsFilterPolicy(name); policy != "" {
+		subAttrs["FilterPolicy"] = policy
+	}
+	sub, err := b.sns.Subscribe(ctx, &sns.SubscribeInput{
+		TopicArn:              aws.String(b.topicARN),
+		Protocol:              aws.String("sqs"),
+		Endpoint:              aws.String(queueARN),
+		Attributes:            subAttrs,
+		ReturnSubscriptionArn: true,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to subscribe queue %s: %w", queueName, err)
+	}
+	return queueURL, aws.ToString(sub.SubscriptionArn), nil
+}
+
+// receive long-polls the queue until ctx is done, sending messages of
+// topics matching name to msgChan.
+func (b *SQSBroker) receive(ctx context.Context, name, queueURL string, msgChan chan<- Message) {
+	for ctx.Err() == nil {
+		out, err := b.sqs.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:                    aws.String(queueURL),
+			MaxNumberOfMessages:         10,
+			WaitTimeSeconds:             sqsWaitSeconds,
+			MessageAttributeNames:       []string{"All"},
+			MessageSystemAttributeNames: []sqstypes.MessageSystemAttributeName{sqstypes.MessageSystemAttributeNameSentTimestamp},
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Printf("[SQSBroker] Receive error: %v\n", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(sqsRetryDelay):
+			}
+			continue
+		}
+
+		for i, m := range out.Messages {
+			msg, err := sqsMessage(m)
+			if err != nil || !MatchTopic(name, msg.Topic) {
+				b.deleteMessage(queueURL, m) // Malformed, or outside the pattern
+				continue
+			}
+			select {
+			case msgChan <- msg:
+				b.deleteMessage(queueURL, m)
+				b.counters(msg.Topic).delivered.Add(1)
+			case <-ctx.Done():
+				b.release(queueURL, out.Messages[i:])
+				return
+			}
+		}
+	}
+}
+
+// deleteMessage removes a handled message from the queue. Deletion uses its
+// own context so it still happens while the subscription stops.
+func (b *SQSBroker) deleteMessage(queueURL string, m sqstypes.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := b.sqs.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(queueURL),
+		ReceiptHandle: m.ReceiptHandle,
+	}); err != nil {
+		fmt.Printf("[SQSBroker] Delete error: %v\n", err)
+	}
+}
+
+// release makes received but undelivered messages visible again, so another
+// member of the group picks them up right away.
+func (b *SQSBroker) release(queueURL string, messages []sqstypes.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, m := range messages {
+		b.sqs.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          aws.String(queueURL),
+			ReceiptHandle:     m.ReceiptHandle,
+			VisibilityTimeout: 0,
+		})
+	}
+}
+
+// deleteQueue removes a temporary queue and its SNS subscription.
+func (b *SQSBroker) deleteQueue(queueURL, subscriptionARN string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if subscriptionARN != "" {
+		b.sns.Unsubscribe(ctx, &sns.UnsubscribeInput{SubscriptionArn: aws.String(subscriptionARN)})
+	}
+	if _, err := b.sqs.DeleteQueue(ctx, &sqs.DeleteQueueInput{QueueUrl: aws.String(queueURL)}); err != nil {
+		fmt.Printf("[SQSBroker] Failed to delete queue %s: %v\n", queueURL, err)
+	}
+}
+
+// sqsMessage converts a received SQS message back into a Message.
+func sqsMessage(m sqstypes.Message) (Message, error) {
+	attr := func(name string) string {
+		return aws.ToString(m.MessageAttributes[name].StringValue)
+	}
+	value, err := sqsDecode(aws.ToString(m.Body), attr(sqsEncodingAttribute))
+	if err != nil {
+		return Message{}, err
+	}
+	msg := Message{
+		Topic: attr(sqsTopicAttribute),
+		Key:   attr(sqsKeyAttribute),
+		Value: value,
+	}
+	if sent, err := strconv.ParseInt(m.Attributes[string(sqstypes.MessageSystemAttributeNameSentTimestamp)], 10, 64); err == nil {
+		msg.Timestamp = sent
+	}
+	return msg, nil
+}
+
+// sqsEncode returns value as an SNS message body. SNS only carries UTF-8
+// text, so other values are base64-encoded and the encoding returned.
+func sqsEncode(value []byte) (body, encoding string) {
+	if utf8.Valid(value) {
+		return string(value), ""
+	}
+	return base64.StdEncoding.EncodeToString(value), "base64"
+}
+
+// sqsDecode reverses sqsEncode.
+func sqsDecode(body, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(body), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return nil, fmt.Errorf("unknown message encoding %q", encoding)
+}
+
+// sqsQueueName derives the queue name of a group and the topic or pattern
+// it consumes. Queue names allow only letters, digits, '-', and '_', up to
+// 80 characters; longer names are cut and made unique with a hash.
+func sqsQueueName(prefix, groupID, name string) string {
+	queueName := sqsInvalidQueueChars.ReplaceAllString(prefix+"-"+groupID+"-"+name, "_")
+	if len(queueName) <= sqsMaxQueueName {
+		return queueName
+	}
+	sum := sha256.Sum256([]byte(queueName))
+	suffix := "-" + hex.EncodeToString(sum[:4])
+	return queueName[:sqsMaxQueueName-len(suffix)] + suffix
+}
+
+// sqsFilterPolicy returns the SNS filter policy for a topic or pattern: an
+// exact match on the topic attribute, or a match on a pattern's literal
+// prefix. The receive loop applies the pattern itself. Empty means no
+// filter, for patterns starting with a wildcard.
+func sqsFilterPolicy(name string) string {
+	var match any = name
+	if i := strings.IndexAny(name, "*?"); i >= 0 {
+		if i == 0 {
+			return ""
+		}
+		match = map[string]string{"prefix": name[:i]}
+	}
+	policy, _ := json.Marshal(map[string][]any{sqsTopicAttribute: {match}})
+	return string(policy)
+}
+
+// sqsQueuePolicy allows the SNS topic to send messages to the queue.
+func sqsQueuePolicy(queueARN, topicARN string) string {
+	policy, _ := json.Marshal(map[string]any{
+		"Version": "2012-10-17",
+		"Statement": []map[string]any{{
+			"Effect":    "Allow",
+			"Principal": map[string]string{"Service": "sns.amazonaws.com"},
+			"Action":    "sqs:SendMessage",
+			"Resource":  queueARN,
+			"Condition": map[string]any{"ArnEquals": map[string]string{"aws:SourceArn": topicARN}},
+		}},
+	})
+	return string(policy)
+}
+
+// randomSuffix returns 8 random hex characters for temporary queue names.
+func randomSuffix() string {
+	var b [4]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Topics lists the topics that have queues, read from the queue tags.
+// Topics nobody subscribed to aren't known to SQS. Implements the Broker
+// interface.
+func (b *SQSBroker) Topics(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	paginator := sqs.NewListQueuesPaginator(b.sqs, &sqs.ListQueuesInput{QueueNamePrefix: aws.String(b.prefix + "-")})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list queues: %w", err)
+		}
+		for _, queueURL := range page.QueueUrls {
+			tags, err := b.sqs.ListQueueTags(ctx, &sqs.ListQueueTagsInput{QueueUrl: aws.String(queueURL)})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read tags of queue %s: %w", queueURL, err)
+			}
+			if topic := tags.Tags[sqsTopicAttribute]; topic != "" && !strings.ContainsAny(topic, "*?") {
+				seen[topic] = true
+			}
+		}
+	}
+
+	topics := make([]string, 0, len(seen))
+	for topic := range seen {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+// Stats returns counters for messages this instance published and consumed.
+// Other clients are not reflected. Implements the Broker interface.
+func (b *SQSBroker) Stats() map[string]TopicStats {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	result := make(map[string]TopicStats, len(b.stats))
+	for topic, c := range b.stats {
+		st := TopicStats{
+			Topic:     topic,
+			Published: c.published.Load(),
+			Delivered: c.delivered.Load(),
+		}
+		for name, n := range b.names {
+			if MatchTopic(name, topic) {
+				st.Subscribers += n
+			}
+		}
+		result[topic] = st
+	}
+	return result
+}
+
+// Close stops all subscriptions and waits for temporary queues to be
+// deleted. Group queues stay for the next run.
+func (b *SQSBroker) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	subs := b.subs
+	b.subs = make(map[string]context.CancelFunc)
+	b.mu.Unlock()
+
+	for _, cancel := range subs {
+		cancel()
+	}
+	b.wg.Wait()
+	return nil
+}
@@ -0,0 +1,91 @@
+package broker
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+func TestSQSQueueName(t *testing.T) {
+	tests := []struct {
+		groupID string
+		name    string
+		want    string
+	}{
+		{"destill-ingest", "destill.requests", "destill-destill-ingest-destill_requests"},
+		{"self triage", "destill.*", "destill-self_triage-destill__"},
+	}
+
+	for _, tt := range tests {
+		if got := sqsQueueName("destill", tt.groupID, tt.name); got != tt.want {
+			t.Errorf("sqsQueueName(%q, %q) = %q, want %q", tt.groupID, tt.name, got, tt.want)
+		}
+	}
+
+	long := sqsQueueName("destill", strings.Repeat("g", 60), "destill.analysis.findings")
+	if len(long) != sqsMaxQueueName {
+		t.Errorf("long queue name has %d characters, want %d", len(long), sqsMaxQueueName)
+	}
+	if other := sqsQueueName("destill", strings.Repeat("g", 60), "destill.analysis.other"); other == long {
+		t.Errorf("long queue names collide: %q", long)
+	}
+}
+
+func TestSQSFilterPolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"destill.logs.raw", `{"destill-topic":["destill.logs.raw"]}`},
+		{"destill.analysis.*", `{"destill-topic":[{"prefix":"destill.analysis."}]}`},
+		{"destill.log?.raw", `{"destill-topic":[{"prefix":"destill.log"}]}`},
+		{"*", ""},
+	}
+
+	for _, tt := range tests {
+		if got := sqsFilterPolicy(tt.name); got != tt.want {
+			t.Errorf("sqsFilterPolicy(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSQSMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		value []byte
+	}{
+		{"text", []byte(`{"request_id":"req-1"}`)},
+		{"binary", []byte{0xff, 0x00, 0xfe}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, encoding := sqsEncode(tt.value)
+			attrs := map[string]sqstypes.MessageAttributeValue{
+				sqsTopicAttribute: {DataType: aws.String("String"), StringValue: aws.String("destill.requests")},
+				sqsKeyAttribute:   {DataType: aws.String("String"), StringValue: aws.String("req-1")},
+			}
+			if encoding != "" {
+				attrs[sqsEncodingAttribute] = sqstypes.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(encoding)}
+			}
+
+			msg, err := sqsMessage(sqstypes.Message{
+				Body:              aws.String(body),
+				MessageAttributes: attrs,
+				Attributes:        map[string]string{"SentTimestamp": "1700000000000"},
+			})
+			if err != nil {
+				t.Fatalf("sqsMessage() error = %v", err)
+			}
+			if msg.Topic != "destill.requests" || msg.Key != "req-1" || msg.Timestamp != 1700000000000 {
+				t.Errorf("sqsMessage() = %+v", msg)
+			}
+			if !bytes.Equal(msg.Value, tt.value) {
+				t.Errorf("sqsMessage() value = %q, want %q", msg.Value, tt.value)
+			}
+		})
+	}
+}
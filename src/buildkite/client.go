@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"time"
+
+	"destill-agent/src/provider"
 )
 
 const (
@@ -29,20 +32,73 @@ type Build struct {
 	Number    int       `json:"number"`
 	State     string    `json:"state"`
 	WebURL    string    `json:"web_url"`
+	Branch    string    `json:"branch"`
+	Commit    string    `json:"commit"` // A SHA, or "HEAD" until the agent resolves it
+	Source    string    `json:"source"` // webhook, api, ui, trigger_job, schedule
 	CreatedAt time.Time `json:"created_at"`
 	Jobs      []Job     `json:"jobs"`
+
+	// PullRequest is set when the build was triggered for a pull request
+	PullRequest *struct {
+		ID string `json:"id"`
+	} `json:"pull_request"`
 }
 
 // Job represents a Buildkite job within a build.
 type Job struct {
-	ID         string    `json:"id"`
-	Name       string    `json:"name"`
-	Type       string    `json:"type"`
-	State      string    `json:"state"`
-	ExitStatus int       `json:"exit_status"`
-	CreatedAt  time.Time `json:"created_at"`
-	LogURL     string    `json:"log_url"`
-	RawLogURL  string    `json:"raw_log_url"`
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	State      string     `json:"state"`
+	ExitStatus int        `json:"exit_status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	LogURL     string     `json:"log_url"`
+	RawLogURL  string     `json:"raw_log_url"`
+
+	// Automatic and manual retries: the retried attempt points at its retry
+	StepKey        string `json:"step_key"`
+	Retried        bool   `json:"retried"`
+	RetriedInJobID string `json:"retried_in_job_id"`
+
+	// DependsOn lists the step keys of the step's depends_on
+	DependsOn StepKeys `json:"depends_on"`
+}
+
+// StepKeys decodes a depends_on: a step key, or a list of step keys and
+// {"step": key, "allow_failure": bool} objects.
+type StepKeys []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (k *StepKeys) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		*k = nil
+		if key != "" {
+			*k = StepKeys{key}
+		}
+		return nil
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	*k = nil
+	for _, item := range items {
+		var dep struct {
+			Step string `json:"step"`
+		}
+		if err := json.Unmarshal(item, &key); err == nil {
+			dep.Step = key
+		} else if err := json.Unmarshal(item, &dep); err != nil {
+			return err
+		}
+		if dep.Step != "" {
+			*k = append(*k, dep.Step)
+		}
+	}
+	return nil
 }
 
 // Artifact represents a build artifact.
@@ -60,7 +116,8 @@ func NewClient(apiToken string) *Client {
 	return &Client{
 		apiToken: apiToken,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: provider.NewAPITransport(),
 		},
 	}
 }
@@ -118,6 +175,47 @@ func (c *Client) GetBuild(ctx context.Context, org, pipeline, buildNumber string
 	return &build, nil
 }
 
+// ListBuilds fetches the most recent builds for a pipeline, newest first.
+// branch and state are optional filters (empty means no filter).
+func (c *Client) ListBuilds(ctx context.Context, org, pipeline, branch, state string, perPage int) ([]Build, error) {
+	query := url.Values{}
+	if branch != "" {
+		query.Set("branch", branch)
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	query.Set("per_page", strconv.Itoa(perPage))
+
+	endpoint := fmt.Sprintf("%s/organizations/%s/pipelines/%s/builds?%s", APIBaseURL, org, pipeline, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiToken))
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var builds []Build
+	if err := json.NewDecoder(resp.Body).Decode(&builds); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return builds, nil
+}
+
 // GetJobLog fetches the raw log content for a specific job.
 // Deprecated: Use GetJobLogByURL instead with the raw_log_url from the job metadata.
 func (c *Client) GetJobLog(ctx context.Context, jobID string) (string, error) {
@@ -154,31 +252,49 @@ func (c *Client) GetJobLog(ctx context.Context, jobID string) (string, error) {
 // GetJobLogByURL fetches the raw log content using the provided raw_log_url.
 // This is the preferred method as it uses the URL provided by the Buildkite API.
 func (c *Client) GetJobLogByURL(ctx context.Context, rawLogURL string) (string, error) {
+	body, err := c.openJobLog(ctx, c.httpClient, rawLogURL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	logBytes, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read log content: %w", err)
+	}
+
+	return string(logBytes), nil
+}
+
+// StreamJobLogByURL opens the raw log at raw_log_url for reading as it
+// downloads. The client's timeout is not applied, since reading a large log
+// can take longer; ctx bounds the download instead. The caller must close
+// the returned body.
+func (c *Client) StreamJobLogByURL(ctx context.Context, rawLogURL string) (io.ReadCloser, error) {
+	return c.openJobLog(ctx, &http.Client{Transport: c.httpClient.Transport}, rawLogURL)
+}
+
+func (c *Client) openJobLog(ctx context.Context, client *http.Client, rawLogURL string) (io.ReadCloser, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", rawLogURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiToken))
 	req.Header.Set("Accept", "text/plain")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute request: %w", err)
+		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	logBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read log content: %w", err)
+		resp.Body.Close()
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
-	return string(logBytes), nil
+	return resp.Body, nil
 }
 
 // GetJobArtifacts fetches the list of artifacts for a specific job.
@@ -1,9 +1,23 @@
 package buildkite
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+
+	"destill-agent/src/provider"
 )
 
+func TestMain(m *testing.M) {
+	// Keep test responses out of the user's API response cache
+	os.Setenv(provider.EnvHTTPCache, "off")
+	os.Exit(m.Run())
+}
+
 func TestParseBuildURL(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -86,3 +100,35 @@ func TestNewClient(t *testing.T) {
 		t.Error("NewClient() httpClient is nil")
 	}
 }
+
+func TestClient_StreamJobLogByURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("unexpected Authorization header: %s", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path == "/missing/log" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("line 1\nline 2\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	body, err := client.StreamJobLogByURL(context.Background(), server.URL+"/job/log")
+	if err != nil {
+		t.Fatalf("StreamJobLogByURL() error = %v", err)
+	}
+	defer body.Close()
+	content, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("reading log: %v", err)
+	}
+	if string(content) != "line 1\nline 2\n" {
+		t.Errorf("log = %q, want the response body", content)
+	}
+
+	if _, err := client.StreamJobLogByURL(context.Background(), server.URL+"/missing/log"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("StreamJobLogByURL() error = %v, want the 404 status", err)
+	}
+}
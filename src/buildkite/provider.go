@@ -3,6 +3,8 @@ package buildkite
 import (
 	"context"
 	"fmt"
+	"io"
+	"slices"
 
 	"destill-agent/src/provider"
 )
@@ -54,15 +56,20 @@ func (p *Provider) FetchBuild(ctx context.Context, ref *provider.BuildRef) (*pro
 		Number:    fmt.Sprintf("%d", bkBuild.Number),
 		URL:       bkBuild.WebURL,
 		State:     bkBuild.State,
+		Branch:    bkBuild.Branch,
+		Trigger:   buildTrigger(bkBuild),
+		Commit:    buildCommit(bkBuild),
 		Timestamp: bkBuild.CreatedAt,
 		Jobs:      make([]provider.Job, 0, len(bkBuild.Jobs)),
 	}
 
+	retries := retryChains(bkBuild.Jobs)
+	dependsOn := dependencies(bkBuild.Jobs)
 	for _, bkJob := range bkBuild.Jobs {
 		// Cache the raw log URL for later retrieval
 		p.jobLogURLs[bkJob.ID] = bkJob.RawLogURL
 
-		build.Jobs = append(build.Jobs, provider.Job{
+		job := provider.Job{
 			ID:        bkJob.ID,
 			Name:      bkJob.Name,
 			Type:      bkJob.Type,
@@ -70,12 +77,161 @@ func (p *Provider) FetchBuild(ctx context.Context, ref *provider.BuildRef) (*pro
 			ExitCode:  bkJob.ExitStatus,
 			BuildID:   bkBuild.ID,
 			Timestamp: bkJob.CreatedAt,
-		})
+			Duration:  provider.Elapsed(bkJob.StartedAt, bkJob.FinishedAt),
+			DependsOn: dependsOn[bkJob.ID],
+		}
+		if r, ok := retries[bkJob.ID]; ok {
+			job.RetryGroup, job.Attempt, job.Attempts = r.group, r.attempt, r.attempts
+		}
+		build.Jobs = append(build.Jobs, job)
 	}
 
 	return build, nil
 }
 
+// retryAttempt places a job in its chain of retries.
+type retryAttempt struct {
+	group    string // Job ID of the first attempt
+	attempt  int
+	attempts int
+}
+
+// retryChains follows retried_in_job_id links to number the attempts of
+// every retried job. Jobs that were never retried are left out.
+func retryChains(jobs []Job) map[string]retryAttempt {
+	next := make(map[string]string)     // Job ID -> ID of its retry
+	previous := make(map[string]string) // Job ID -> ID of the attempt it retried
+	for _, job := range jobs {
+		if job.Retried && job.RetriedInJobID != "" {
+			next[job.ID] = job.RetriedInJobID
+			previous[job.RetriedInJobID] = job.ID
+		}
+	}
+
+	chains := make(map[string]retryAttempt)
+	for _, job := range jobs {
+		if _, retry := previous[job.ID]; retry || next[job.ID] == "" {
+			continue // Not the first attempt of a chain
+		}
+		var chain []string
+		seen := make(map[string]bool)
+		for id := job.ID; id != "" && !seen[id]; id = next[id] {
+			seen[id] = true
+			chain = append(chain, id)
+		}
+		for i, id := range chain {
+			chains[id] = retryAttempt{group: job.ID, attempt: i + 1, attempts: len(chain)}
+		}
+	}
+	return chains
+}
+
+// dependencies returns the IDs of the jobs each job waited on: the jobs
+// between the wait step before it and the one before that, and the jobs of
+// the steps named in its depends_on. Earlier jobs are reached through those.
+// Wait steps are jobs of type "waiter"; jobs without dependencies are left
+// out.
+func dependencies(jobs []Job) map[string][]string {
+	byStep := make(map[string][]string) // Step key -> IDs of its jobs
+	for _, job := range jobs {
+		if job.StepKey != "" && job.Type != "waiter" {
+			byStep[job.StepKey] = append(byStep[job.StepKey], job.ID)
+		}
+	}
+
+	deps := make(map[string][]string)
+	var waitedOn, current []string // Jobs before the last wait step, and since
+	for _, job := range jobs {
+		if job.Type == "waiter" {
+			if len(current) > 0 {
+				waitedOn, current = current, nil
+			}
+			continue
+		}
+		current = append(current, job.ID)
+
+		ids := slices.Clone(waitedOn)
+		for _, key := range job.DependsOn {
+			for _, id := range byStep[key] {
+				if id != job.ID && !slices.Contains(ids, id) {
+					ids = append(ids, id)
+				}
+			}
+		}
+		if len(ids) > 0 {
+			deps[job.ID] = ids
+		}
+	}
+	return deps
+}
+
+// FetchLatestFailedBuild finds the most recent failed build on a branch
+func (p *Provider) FetchLatestFailedBuild(ctx context.Context, ref *provider.BuildRef, branch string) (*provider.Build, error) {
+	org := ref.Metadata["org"]
+	pipeline := ref.Metadata["pipeline"]
+
+	bkBuilds, err := p.client.ListBuilds(ctx, org, pipeline, branch, "failed", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(bkBuilds) == 0 {
+		return nil, fmt.Errorf("%w: no failed builds for %s/%s on branch %s",
+			provider.ErrBuildNotFound, org, pipeline, branch)
+	}
+
+	bkBuild := bkBuilds[0]
+	return &provider.Build{
+		ID:        bkBuild.ID,
+		Number:    fmt.Sprintf("%d", bkBuild.Number),
+		URL:       bkBuild.WebURL,
+		State:     bkBuild.State,
+		Branch:    bkBuild.Branch,
+		Trigger:   buildTrigger(&bkBuild),
+		Commit:    buildCommit(&bkBuild),
+		Timestamp: bkBuild.CreatedAt,
+	}, nil
+}
+
+// lastPassedBuildWindow is how many recent passed builds of a pipeline
+// FetchLastPassedBuild looks through for one like the failed build.
+const lastPassedBuildWindow = 50
+
+// FetchLastPassedBuild finds the most recent passed build before build on
+// its branch, with its trigger (see provider.CommitHistory).
+func (p *Provider) FetchLastPassedBuild(ctx context.Context, ref *provider.BuildRef, build *provider.Build) (*provider.Build, error) {
+	org := ref.Metadata["org"]
+	pipeline := ref.Metadata["pipeline"]
+
+	bkBuilds, err := p.client.ListBuilds(ctx, org, pipeline, build.Branch, "passed", lastPassedBuildWindow)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bkBuilds {
+		bkBuild := &bkBuilds[i]
+		if !bkBuild.CreatedAt.Before(build.Timestamp) || buildTrigger(bkBuild) != build.Trigger {
+			continue
+		}
+		return &provider.Build{
+			ID:        bkBuild.ID,
+			Number:    fmt.Sprintf("%d", bkBuild.Number),
+			URL:       bkBuild.WebURL,
+			State:     bkBuild.State,
+			Branch:    bkBuild.Branch,
+			Trigger:   buildTrigger(bkBuild),
+			Commit:    buildCommit(bkBuild),
+			Timestamp: bkBuild.CreatedAt,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: no passed builds of %s/%s on branch %s before build %s",
+		provider.ErrBuildNotFound, org, pipeline, build.Branch, build.Number)
+}
+
+// CountCommits returns 0: Buildkite doesn't know the repository's history
+// (see provider.CommitHistory).
+func (p *Provider) CountCommits(ctx context.Context, ref *provider.BuildRef, base, head string) (int, error) {
+	return 0, nil
+}
+
 // FetchJobLog retrieves raw log content
 func (p *Provider) FetchJobLog(ctx context.Context, jobID string) (string, error) {
 	// Look up the raw log URL from our cache (populated by FetchBuild)
@@ -88,6 +244,16 @@ func (p *Provider) FetchJobLog(ctx context.Context, jobID string) (string, error
 	return p.client.GetJobLogByURL(ctx, rawLogURL)
 }
 
+// StreamJobLog opens a job's log for reading as it downloads, so large logs
+// are chunked without being held in memory. The caller closes the reader.
+func (p *Provider) StreamJobLog(ctx context.Context, jobID string) (io.ReadCloser, error) {
+	rawLogURL, ok := p.jobLogURLs[jobID]
+	if !ok {
+		return nil, fmt.Errorf("no log URL found for job %s (FetchBuild must be called first)", jobID)
+	}
+	return p.client.StreamJobLogByURL(ctx, rawLogURL)
+}
+
 // FetchArtifacts retrieves artifacts for a job
 func (p *Provider) FetchArtifacts(ctx context.Context, jobID string) ([]provider.Artifact, error) {
 	bkArtifacts, err := p.client.GetJobArtifacts(ctx, jobID)
@@ -113,3 +279,33 @@ func (p *Provider) FetchArtifacts(ctx context.Context, jobID string) ([]provider
 func (p *Provider) DownloadArtifact(ctx context.Context, artifact provider.Artifact) ([]byte, error) {
 	return p.client.DownloadArtifact(ctx, artifact.DownloadURL)
 }
+
+// buildCommit returns the build's commit SHA, or "" while it's unresolved.
+func buildCommit(b *Build) string {
+	if b.Commit == "HEAD" {
+		return ""
+	}
+	return b.Commit
+}
+
+// buildTrigger maps a Buildkite build source to a normalized trigger.
+// Webhook builds for a pull request are reported as pull_request.
+func buildTrigger(b *Build) string {
+	switch b.Source {
+	case "schedule":
+		return provider.TriggerSchedule
+	case "webhook":
+		if b.PullRequest != nil && b.PullRequest.ID != "" {
+			return provider.TriggerPullRequest
+		}
+		return provider.TriggerPush
+	case "ui":
+		return provider.TriggerManual
+	case "api":
+		return provider.TriggerAPI
+	case "trigger_job":
+		return provider.TriggerUpstream
+	default:
+		return ""
+	}
+}
@@ -2,6 +2,7 @@ package buildkite
 
 import (
 	"encoding/json"
+	"slices"
 	"testing"
 )
 
@@ -55,3 +56,93 @@ func TestBuildJSONUnmarshal_NumberAsInt(t *testing.T) {
 		t.Errorf("Number = %d, want 77825", build.Number)
 	}
 }
+
+func TestBuildTrigger(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"schedule", `{"source": "schedule"}`, "schedule"},
+		{"push", `{"source": "webhook", "pull_request": null}`, "push"},
+		{"pull request", `{"source": "webhook", "pull_request": {"id": "42"}}`, "pull_request"},
+		{"ui", `{"source": "ui"}`, "manual"},
+		{"trigger step", `{"source": "trigger_job"}`, "upstream"},
+		{"unknown", `{}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var build Build
+			if err := json.Unmarshal([]byte(tt.json), &build); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got := buildTrigger(&build); got != tt.want {
+				t.Errorf("buildTrigger() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryChains(t *testing.T) {
+	jsonData := `[
+		{"id": "a1", "step_key": "test", "retried": true, "retried_in_job_id": "a2"},
+		{"id": "a2", "step_key": "test", "retried": true, "retried_in_job_id": "a3"},
+		{"id": "a3", "step_key": "test"},
+		{"id": "b1", "step_key": "lint"}
+	]`
+	var jobs []Job
+	if err := json.Unmarshal([]byte(jsonData), &jobs); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	chains := retryChains(jobs)
+
+	want := map[string]retryAttempt{
+		"a1": {group: "a1", attempt: 1, attempts: 3},
+		"a2": {group: "a1", attempt: 2, attempts: 3},
+		"a3": {group: "a1", attempt: 3, attempts: 3},
+	}
+	if len(chains) != len(want) {
+		t.Fatalf("retryChains() returned %d jobs, want %d: %+v", len(chains), len(want), chains)
+	}
+	for id, w := range want {
+		if got := chains[id]; got != w {
+			t.Errorf("retryChains()[%s] = %+v, want %+v", id, got, w)
+		}
+	}
+	if _, ok := chains["b1"]; ok {
+		t.Error("job that wasn't retried should not be in a chain")
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	jsonData := `[
+		{"id": "build", "type": "script", "step_key": "build"},
+		{"id": "lint", "type": "script", "step_key": "lint"},
+		{"id": "wait", "type": "waiter"},
+		{"id": "test", "type": "script", "step_key": "test"},
+		{"id": "deploy", "type": "script", "depends_on": [{"step": "test", "allow_failure": true}, "lint"]},
+		{"id": "notify", "type": "script", "depends_on": "deploy-missing"}
+	]`
+	var jobs []Job
+	if err := json.Unmarshal([]byte(jsonData), &jobs); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	deps := dependencies(jobs)
+
+	want := map[string][]string{
+		"test":   {"build", "lint"},
+		"deploy": {"build", "lint", "test"},
+		"notify": {"build", "lint"},
+	}
+	if len(deps) != len(want) {
+		t.Fatalf("dependencies() = %v, want %v", deps, want)
+	}
+	for id, w := range want {
+		if got := deps[id]; !slices.Equal(got, w) {
+			t.Errorf("dependencies()[%s] = %v, want %v", id, got, w)
+		}
+	}
+}
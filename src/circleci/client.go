@@ -0,0 +1,257 @@
+// Package circleci provides a CircleCI provider.
+package circleci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"destill-agent/src/provider"
+)
+
+var (
+	ErrInvalidURL = errors.New("invalid CircleCI workflow URL")
+)
+
+// workflowURLPattern matches https://app.circleci.com/pipelines/gh/org/repo/123/workflows/<uuid>
+var workflowURLPattern = regexp.MustCompile(`^https://app\.circleci\.com/pipelines/([^/]+/[^/]+/[^/]+)/(\d+)/workflows/([0-9a-f-]+)`)
+
+// Client is a CircleCI API client. Workflow and job listings use API v2;
+// step output is only available from API v1.1.
+type Client struct {
+	token      string
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient creates a new CircleCI client
+func NewClient(token string) *Client {
+	return &Client{
+		token: token,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		baseURL: "https://circleci.com/api",
+	}
+}
+
+// ParseWorkflowURL extracts the project slug, pipeline number, and workflow ID from URL
+func ParseWorkflowURL(workflowURL string) (projectSlug, pipelineNumber, workflowID string, err error) {
+	matches := workflowURLPattern.FindStringSubmatch(workflowURL)
+	if matches == nil {
+		return "", "", "", fmt.Errorf("%w: %s", ErrInvalidURL, workflowURL)
+	}
+	return matches[1], matches[2], matches[3], nil
+}
+
+// WorkflowURL returns the web URL of a workflow
+func WorkflowURL(projectSlug string, pipelineNumber int64, workflowID string) string {
+	return fmt.Sprintf("https://app.circleci.com/pipelines/%s/%d/workflows/%s", projectSlug, pipelineNumber, workflowID)
+}
+
+// GetWorkflow fetches workflow metadata
+func (c *Client) GetWorkflow(ctx context.Context, workflowID string) (*Workflow, error) {
+	var workflow Workflow
+	if err := c.getJSON(ctx, c.baseURL+"/v2/workflow/"+url.PathEscape(workflowID), &workflow, true); err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// GetPipeline fetches pipeline metadata (trigger and branch)
+func (c *Client) GetPipeline(ctx context.Context, pipelineID string) (*Pipeline, error) {
+	var pipeline Pipeline
+	if err := c.getJSON(ctx, c.baseURL+"/v2/pipeline/"+url.PathEscape(pipelineID), &pipeline, true); err != nil {
+		return nil, err
+	}
+	return &pipeline, nil
+}
+
+// ListPipelines fetches the most recent pipelines for a project, newest first.
+// branch is an optional filter. Only the first page is returned.
+func (c *Client) ListPipelines(ctx context.Context, projectSlug, branch string) ([]Pipeline, error) {
+	endpoint := fmt.Sprintf("%s/v2/project/%s/pipeline", c.baseURL, projectSlug)
+	if branch != "" {
+		endpoint += "?branch=" + url.QueryEscape(branch)
+	}
+
+	var resp page[Pipeline]
+	if err := c.getJSON(ctx, endpoint, &resp, true); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// GetPipelineWorkflows fetches the workflows of a pipeline
+func (c *Client) GetPipelineWorkflows(ctx context.Context, pipelineID string) ([]Workflow, error) {
+	var resp page[Workflow]
+	if err := c.getJSON(ctx, c.baseURL+"/v2/pipeline/"+url.PathEscape(pipelineID)+"/workflow", &resp, true); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// GetWorkflowJobs fetches all jobs in a workflow (handles pagination)
+func (c *Client) GetWorkflowJobs(ctx context.Context, workflowID string) ([]WorkflowJob, error) {
+	var allJobs []WorkflowJob
+	pageToken := ""
+
+	for {
+		endpoint := c.baseURL + "/v2/workflow/" + url.PathEscape(workflowID) + "/job"
+		if pageToken != "" {
+			endpoint += "?page-token=" + url.QueryEscape(pageToken)
+		}
+
+		var resp page[WorkflowJob]
+		if err := c.getJSON(ctx, endpoint, &resp, true); err != nil {
+			return nil, err
+		}
+		allJobs = append(allJobs, resp.Items...)
+
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+
+	return allJobs, nil
+}
+
+// GetJobDetails fetches a job's steps from API v1.1
+func (c *Client) GetJobDetails(ctx context.Context, projectSlug string, jobNumber int64) (*JobDetails, error) {
+	endpoint := fmt.Sprintf("%s/v1.1/project/%s/%d", c.baseURL, v1ProjectSlug(projectSlug), jobNumber)
+
+	var details JobDetails
+	if err := c.getJSON(ctx, endpoint, &details, true); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// GetStepOutput downloads a step's output. Output URLs are presigned and
+// hosted outside CircleCI, so the API token is not sent. The messages are
+// decoded one at a time, stopping past the log size limit of ctx (see
+// provider.WithMaxLogBytes).
+func (c *Client) GetStepOutput(ctx context.Context, outputURL string) (string, error) {
+	resp, err := c.do(ctx, outputURL, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	max, limited := provider.MaxLogBytes(ctx)
+	dec := json.NewDecoder(resp.Body)
+	if _, err := dec.Token(); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	var sb strings.Builder
+	for dec.More() {
+		var m OutputMessage
+		if err := dec.Decode(&m); err != nil {
+			return "", fmt.Errorf("failed to decode response: %w", err)
+		}
+		if limited && int64(sb.Len()+len(m.Message)) > max {
+			// Cut past the limit at a rune boundary: after the rune holding
+			// the byte past it, so the cut still shows
+			cut := int(max+1) - sb.Len()
+			for cut < len(m.Message) && !utf8.RuneStart(m.Message[cut]) {
+				cut++
+			}
+			sb.WriteString(m.Message[:cut])
+			return sb.String(), nil
+		}
+		sb.WriteString(m.Message)
+	}
+	return sb.String(), nil
+}
+
+// GetJobArtifacts lists the artifacts stored by a job
+func (c *Client) GetJobArtifacts(ctx context.Context, projectSlug string, jobNumber int64) ([]Artifact, error) {
+	endpoint := fmt.Sprintf("%s/v2/project/%s/%d/artifacts", c.baseURL, projectSlug, jobNumber)
+
+	var resp page[Artifact]
+	if err := c.getJSON(ctx, endpoint, &resp, true); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// DownloadArtifact downloads raw artifact content
+func (c *Client) DownloadArtifact(ctx context.Context, downloadURL string) ([]byte, error) {
+	resp, err := c.do(ctx, downloadURL, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, nil
+}
+
+// getJSON performs a GET and decodes the JSON body into v
+func (c *Client) getJSON(ctx context.Context, endpoint string, v any, authenticated bool) error {
+	resp, err := c.do(ctx, endpoint, authenticated)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// do performs a GET, sending the API token when authenticated is set.
+// Non-200 responses are returned as errors.
+func (c *Client) do(ctx context.Context, endpoint string, authenticated bool) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if authenticated {
+		req.Header.Set("Circle-Token", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("CircleCI API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	return resp, nil
+}
+
+// v1ProjectSlug converts a v2 project slug ("gh/org/repo") to the v1.1
+// form, which spells out the VCS ("github/org/repo").
+func v1ProjectSlug(projectSlug string) string {
+	vcs, rest, ok := strings.Cut(projectSlug, "/")
+	if !ok {
+		return projectSlug
+	}
+	switch vcs {
+	case "gh":
+		vcs = "github"
+	case "bb":
+		vcs = "bitbucket"
+	}
+	return vcs + "/" + rest
+}
@@ -0,0 +1,157 @@
+package circleci
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"unicode/utf8"
+
+	"destill-agent/src/provider"
+)
+
+func TestClient_ParseWorkflowURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		wantSlug     string
+		wantPipeline string
+		wantWorkflow string
+		wantErr      bool
+	}{
+		{
+			name:         "workflow URL",
+			url:          "https://app.circleci.com/pipelines/gh/acme/api/42/workflows/5f1c2d3e-0000-4a4b-9c9d-0123456789ab",
+			wantSlug:     "gh/acme/api",
+			wantPipeline: "42",
+			wantWorkflow: "5f1c2d3e-0000-4a4b-9c9d-0123456789ab",
+		},
+		{
+			name:         "job URL within workflow",
+			url:          "https://app.circleci.com/pipelines/github/acme/api/42/workflows/5f1c2d3e-0000-4a4b-9c9d-0123456789ab/jobs/1234",
+			wantSlug:     "github/acme/api",
+			wantPipeline: "42",
+			wantWorkflow: "5f1c2d3e-0000-4a4b-9c9d-0123456789ab",
+		},
+		{
+			name:    "pipeline URL without workflow",
+			url:     "https://app.circleci.com/pipelines/gh/acme/api/42",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slug, pipeline, workflow, err := ParseWorkflowURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWorkflowURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if slug != tt.wantSlug || pipeline != tt.wantPipeline || workflow != tt.wantWorkflow {
+				t.Errorf("ParseWorkflowURL() = %v, %v, %v, want %v, %v, %v",
+					slug, pipeline, workflow, tt.wantSlug, tt.wantPipeline, tt.wantWorkflow)
+			}
+		})
+	}
+}
+
+func TestClient_GetWorkflowJobs_Pagination(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Circle-Token") != "test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Query().Get("page-token") == "" {
+			json.NewEncoder(w).Encode(page[WorkflowJob]{
+				Items:         []WorkflowJob{{Name: "build", JobNumber: 1}},
+				NextPageToken: "next",
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(page[WorkflowJob]{
+			Items: []WorkflowJob{{Name: "test", JobNumber: 2}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+
+	jobs, err := client.GetWorkflowJobs(context.Background(), "wf-1")
+	if err != nil {
+		t.Fatalf("GetWorkflowJobs() error = %v", err)
+	}
+	if len(jobs) != 2 || requests != 2 {
+		t.Errorf("got %d jobs in %d requests, want 2 jobs in 2 requests", len(jobs), requests)
+	}
+}
+
+func TestClient_GetStepOutput_NoToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Circle-Token") != "" {
+			t.Error("API token sent to step output URL")
+		}
+		json.NewEncoder(w).Encode([]OutputMessage{
+			{Message: "go test ./...\r\n", Type: "out"},
+			{Message: "FAIL\tpkg\r\n", Type: "err"},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	output, err := client.GetStepOutput(context.Background(), server.URL+"/output")
+	if err != nil {
+		t.Fatalf("GetStepOutput() error = %v", err)
+	}
+	if output != "go test ./...\r\nFAIL\tpkg\r\n" {
+		t.Errorf("GetStepOutput() = %q", output)
+	}
+}
+
+func TestClient_GetStepOutput_Limit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]OutputMessage{{Message: "ok\n"}, {Message: "résumé\n"}})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	tests := []struct {
+		max  int64
+		want string
+	}{
+		{2, "ok\n"},   // Cut at the end of a message
+		{4, "ok\nré"}, // The byte past the limit is the first of é's two
+		{5, "ok\nré"}, // It's the second: the cut keeps the whole rune
+		{20, "ok\nrésumé\n"},
+	}
+	for _, tt := range tests {
+		output, err := client.GetStepOutput(provider.WithMaxLogBytes(context.Background(), tt.max), server.URL+"/output")
+		if err != nil {
+			t.Fatalf("GetStepOutput() error = %v", err)
+		}
+		if output != tt.want || !utf8.ValidString(output) {
+			t.Errorf("GetStepOutput() with a %d byte limit = %q, want %q", tt.max, output, tt.want)
+		}
+	}
+}
+
+func TestV1ProjectSlug(t *testing.T) {
+	tests := map[string]string{
+		"gh/acme/api":       "github/acme/api",
+		"bb/acme/api":       "bitbucket/acme/api",
+		"github/acme/api":   "github/acme/api",
+		"circleci/org/proj": "circleci/org/proj",
+	}
+
+	for slug, want := range tests {
+		if got := v1ProjectSlug(slug); got != want {
+			t.Errorf("v1ProjectSlug(%v) = %v, want %v", slug, got, want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package circleci
+
+import (
+	"context"
+	"destill-agent/src/provider"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	// Register the CircleCI provider factory
+	provider.RegisterProvider("circleci", func(token string) provider.Provider {
+		return NewProvider(token)
+	})
+}
+
+// Provider implements provider.Provider and provider.StepProvider for CircleCI
+type Provider struct {
+	client *Client
+}
+
+// NewProvider creates a CircleCI provider with API token
+func NewProvider(token string) *Provider {
+	return &Provider{
+		client: NewClient(token),
+	}
+}
+
+// Name returns "circleci"
+func (p *Provider) Name() string {
+	return "circleci"
+}
+
+// ParseURL delegates to provider.ParseURL
+func (p *Provider) ParseURL(url string) (*provider.BuildRef, error) {
+	return provider.ParseURL(url)
+}
+
+// FetchBuild retrieves a workflow and its jobs. The workflow is the build;
+// its pipeline supplies the branch and trigger.
+func (p *Provider) FetchBuild(ctx context.Context, ref *provider.BuildRef) (*provider.Build, error) {
+	workflow, err := p.client.GetWorkflow(ctx, ref.BuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline, err := p.client.GetPipeline(ctx, workflow.PipelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := p.client.GetWorkflowJobs(ctx, workflow.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	build := toBuild(workflow, pipeline)
+	build.Jobs = make([]provider.Job, 0, len(jobs))
+
+	for _, ccJob := range jobs {
+		exitCode := 0
+		state := mapCircleCIStatus(ccJob.Status)
+		if state == "failed" {
+			exitCode = 1
+		}
+
+		jobType := "script"
+		if ccJob.Type == "approval" {
+			jobType = "approval"
+		}
+
+		job := provider.Job{
+			ID:       fmt.Sprintf("%s/%d", workflow.ProjectSlug, ccJob.JobNumber),
+			Name:     ccJob.Name,
+			Type:     jobType,
+			State:    state,
+			ExitCode: exitCode,
+			BuildID:  build.ID,
+			Duration: provider.Elapsed(ccJob.StartedAt, ccJob.StoppedAt),
+		}
+		if ccJob.StartedAt != nil {
+			job.Timestamp = *ccJob.StartedAt
+		}
+		build.Jobs = append(build.Jobs, job)
+	}
+
+	return build, nil
+}
+
+// FetchLatestFailedBuild finds the most recent failed workflow on a branch.
+// Only the most recent page of pipelines is searched.
+func (p *Provider) FetchLatestFailedBuild(ctx context.Context, ref *provider.BuildRef, branch string) (*provider.Build, error) {
+	projectSlug := ref.Metadata["project_slug"]
+
+	pipelines, err := p.client.ListPipelines(ctx, projectSlug, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pipelines {
+		workflows, err := p.client.GetPipelineWorkflows(ctx, pipelines[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for j := range workflows {
+			if mapCircleCIStatus(workflows[j].Status) == "failed" {
+				return toBuild(&workflows[j], &pipelines[i]), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no failed workflows for %s on branch %s",
+		provider.ErrBuildNotFound, projectSlug, branch)
+}
+
+// FetchJobLog retrieves a job's output with every step concatenated.
+// jobID has the form "gh/org/repo/123".
+func (p *Provider) FetchJobLog(ctx context.Context, jobID string) (string, error) {
+	steps, err := p.FetchJobSteps(ctx, jobID)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, step := range steps {
+		sb.WriteString(step.Log)
+		if step.Log != "" && !strings.HasSuffix(step.Log, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// FetchJobSteps retrieves a job's output split by step. Parallel jobs
+// return one step per node, named "step (node N)".
+func (p *Provider) FetchJobSteps(ctx context.Context, jobID string) ([]provider.Step, error) {
+	projectSlug, jobNumber, err := splitJobID(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := p.client.GetJobDetails(ctx, projectSlug, jobNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// Past the log size limit, the steps after are listed without output
+	max, limited := provider.MaxLogBytes(ctx)
+	var size int64
+	var steps []provider.Step
+	for _, ccStep := range details.Steps {
+		for _, action := range ccStep.Actions {
+			name := ccStep.Name
+			if len(ccStep.Actions) > 1 {
+				name = fmt.Sprintf("%s (node %d)", name, action.Index)
+			}
+
+			step := provider.Step{
+				Name:     name,
+				Index:    len(steps),
+				State:    mapCircleCIStatus(action.Status),
+				Duration: time.Duration(action.RunTimeMs) * time.Millisecond,
+			}
+			if action.ExitCode != nil {
+				step.ExitCode = *action.ExitCode
+			}
+
+			if action.HasOutput && action.OutputURL != "" && (!limited || size <= max) {
+				stepCtx := ctx
+				if limited {
+					stepCtx = provider.WithMaxLogBytes(ctx, max-size)
+				}
+				step.Log, err = p.client.GetStepOutput(stepCtx, action.OutputURL)
+				if err != nil {
+					return nil, fmt.Errorf("failed to fetch output of step %q: %w", name, err)
+				}
+				size += int64(len(step.Log))
+			}
+			steps = append(steps, step)
+		}
+	}
+	return steps, nil
+}
+
+// FetchArtifacts lists the artifacts stored by a job
+func (p *Provider) FetchArtifacts(ctx context.Context, jobID string) ([]provider.Artifact, error) {
+	projectSlug, jobNumber, err := splitJobID(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	ccArtifacts, err := p.client.GetJobArtifacts(ctx, projectSlug, jobNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make([]provider.Artifact, 0, len(ccArtifacts))
+	for _, a := range ccArtifacts {
+		artifacts = append(artifacts, provider.Artifact{
+			ID:          fmt.Sprintf("%d-%s", a.NodeIndex, a.Path),
+			JobID:       jobID,
+			Path:        a.Path,
+			DownloadURL: a.URL,
+		})
+	}
+	return artifacts, nil
+}
+
+// DownloadArtifact downloads artifact content
+func (p *Provider) DownloadArtifact(ctx context.Context, artifact provider.Artifact) ([]byte, error) {
+	return p.client.DownloadArtifact(ctx, artifact.DownloadURL)
+}
+
+// toBuild converts a workflow and its pipeline to a provider.Build without jobs
+func toBuild(workflow *Workflow, pipeline *Pipeline) *provider.Build {
+	return &provider.Build{
+		ID:        workflow.ID,
+		Number:    strconv.FormatInt(pipeline.Number, 10),
+		URL:       WorkflowURL(workflow.ProjectSlug, pipeline.Number, workflow.ID),
+		State:     mapCircleCIStatus(workflow.Status),
+		Branch:    pipeline.VCS.Branch,
+		Trigger:   mapTriggerType(pipeline.Trigger.Type),
+		Timestamp: workflow.CreatedAt,
+	}
+}
+
+// splitJobID splits "gh/org/repo/123" into the project slug and job number
+func splitJobID(jobID string) (string, int64, error) {
+	idx := strings.LastIndex(jobID, "/")
+	if idx <= 0 {
+		return "", 0, fmt.Errorf("invalid job ID format: %s", jobID)
+	}
+
+	number, err := strconv.ParseInt(jobID[idx+1:], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid job number: %s", jobID[idx+1:])
+	}
+	return jobID[:idx], number, nil
+}
+
+// mapCircleCIStatus maps CircleCI workflow/job/step status to Buildkite-like state
+func mapCircleCIStatus(status string) string {
+	switch status {
+	case "success":
+		return "passed"
+	case "failed", "failing", "error", "infrastructure_fail", "timedout":
+		return "failed"
+	case "canceled", "cancelled":
+		return "canceled"
+	case "not_run", "blocked", "queued", "on_hold":
+		return "queued"
+	default:
+		// running, unauthorized
+		return status
+	}
+}
+
+// mapTriggerType maps a CircleCI pipeline trigger type to a normalized trigger
+func mapTriggerType(triggerType string) string {
+	switch triggerType {
+	case "schedule", "scheduled_pipeline":
+		return provider.TriggerSchedule
+	case "webhook":
+		return provider.TriggerPush
+	case "api", "explicit":
+		return provider.TriggerAPI
+	default:
+		return ""
+	}
+}
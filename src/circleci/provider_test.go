@@ -0,0 +1,227 @@
+package circleci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"destill-agent/src/provider"
+)
+
+func TestCircleCIProvider_Name(t *testing.T) {
+	p := NewProvider("fake-token")
+	if p.Name() != "circleci" {
+		t.Errorf("Name() = %v, want circleci", p.Name())
+	}
+}
+
+func TestCircleCIProvider_FetchBuild(t *testing.T) {
+	started := time.Date(2024, 1, 1, 12, 5, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/workflow/wf-1":
+			json.NewEncoder(w).Encode(Workflow{
+				ID:          "wf-1",
+				Status:      "failed",
+				PipelineID:  "pl-1",
+				ProjectSlug: "gh/acme/api",
+				CreatedAt:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			})
+		case "/v2/pipeline/pl-1":
+			w.Write([]byte(`{"id": "pl-1", "number": 42, "trigger": {"type": "schedule"}, "vcs": {"branch": "main"}}`))
+		case "/v2/workflow/wf-1/job":
+			json.NewEncoder(w).Encode(page[WorkflowJob]{Items: []WorkflowJob{
+				{Name: "build", Type: "build", Status: "success", JobNumber: 101, StartedAt: &started},
+				{Name: "test", Type: "build", Status: "failed", JobNumber: 102},
+				{Name: "hold", Type: "approval", Status: "on_hold"},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+
+	ref := &provider.BuildRef{
+		Provider: "circleci",
+		BuildID:  "wf-1",
+		Metadata: map[string]string{"project_slug": "gh/acme/api", "pipeline_number": "42"},
+	}
+
+	build, err := p.FetchBuild(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("FetchBuild() error = %v", err)
+	}
+
+	if build.ID != "wf-1" || build.Number != "42" || build.State != "failed" {
+		t.Errorf("Build = %+v, want ID wf-1, Number 42, State failed", build)
+	}
+	if build.Branch != "main" || build.Trigger != provider.TriggerSchedule {
+		t.Errorf("Branch, Trigger = %q, %q, want main, schedule", build.Branch, build.Trigger)
+	}
+	if build.URL != "https://app.circleci.com/pipelines/gh/acme/api/42/workflows/wf-1" {
+		t.Errorf("URL = %v", build.URL)
+	}
+	if len(build.Jobs) != 3 {
+		t.Fatalf("len(Build.Jobs) = %v, want 3", len(build.Jobs))
+	}
+
+	if job := build.Jobs[0]; job.ID != "gh/acme/api/101" || job.State != "passed" || !job.Timestamp.Equal(started) {
+		t.Errorf("Job[0] = %+v, want gh/acme/api/101 passed", job)
+	}
+	if job := build.Jobs[1]; job.State != "failed" || job.ExitCode != 1 || job.Type != "script" {
+		t.Errorf("Job[1] = %+v, want failed script exit 1", job)
+	}
+	if job := build.Jobs[2]; job.Type != "approval" {
+		t.Errorf("Job[2].Type = %v, want approval", job.Type)
+	}
+}
+
+func TestCircleCIProvider_FetchJobSteps(t *testing.T) {
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.1/project/github/acme/api/102":
+			exitOK, exitFail := 0, 1
+			json.NewEncoder(w).Encode(JobDetails{
+				BuildNum: 102,
+				Steps: []JobStep{
+					{Name: "Spin up environment", Actions: []JobAction{
+						{Index: 0, Status: "success", HasOutput: true, OutputURL: serverURL + "/out/setup", ExitCode: &exitOK},
+					}},
+					{Name: "go test", Actions: []JobAction{
+						{Index: 0, Status: "success", HasOutput: true, OutputURL: serverURL + "/out/test0", ExitCode: &exitOK},
+						{Index: 1, Status: "failed", HasOutput: true, OutputURL: serverURL + "/out/test1", ExitCode: &exitFail},
+					}},
+					{Name: "Save cache", Actions: []JobAction{{Index: 0, Status: "success"}}},
+				},
+			})
+		case "/out/setup":
+			json.NewEncoder(w).Encode([]OutputMessage{{Message: "Starting container\n"}})
+		case "/out/test0":
+			json.NewEncoder(w).Encode([]OutputMessage{{Message: "ok  \tpkg/a\n"}})
+		case "/out/test1":
+			json.NewEncoder(w).Encode([]OutputMessage{{Message: "--- FAIL: TestB\n"}, {Message: "FAIL\tpkg/b\n"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+
+	steps, err := p.FetchJobSteps(context.Background(), "gh/acme/api/102")
+	if err != nil {
+		t.Fatalf("FetchJobSteps() error = %v", err)
+	}
+
+	want := []provider.Step{
+		{Name: "Spin up environment", Index: 0, State: "passed", Log: "Starting container\n"},
+		{Name: "go test (node 0)", Index: 1, State: "passed", Log: "ok  \tpkg/a\n"},
+		{Name: "go test (node 1)", Index: 2, State: "failed", ExitCode: 1, Log: "--- FAIL: TestB\nFAIL\tpkg/b\n"},
+		{Name: "Save cache", Index: 3, State: "passed"},
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("len(steps) = %d, want %d", len(steps), len(want))
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Errorf("steps[%d] = %+v, want %+v", i, steps[i], want[i])
+		}
+	}
+
+	log, err := p.FetchJobLog(context.Background(), "gh/acme/api/102")
+	if err != nil {
+		t.Fatalf("FetchJobLog() error = %v", err)
+	}
+	if log != "Starting container\nok  \tpkg/a\n--- FAIL: TestB\nFAIL\tpkg/b\n" {
+		t.Errorf("FetchJobLog() = %q", log)
+	}
+
+	// Reading stops one byte past the limit, across steps
+	steps, err = p.FetchJobSteps(provider.WithMaxLogBytes(context.Background(), 20), "gh/acme/api/102")
+	if err != nil {
+		t.Fatalf("FetchJobSteps() with a limit error = %v", err)
+	}
+	var logs []string
+	for _, step := range steps {
+		logs = append(logs, step.Log)
+	}
+	if !slices.Equal(logs, []string{"Starting container\n", "ok", "", ""}) {
+		t.Errorf("FetchJobSteps() with a limit = %q, want 21 bytes of output", logs)
+	}
+}
+
+func TestCircleCIProvider_FetchLatestFailedBuild(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/project/gh/acme/api/pipeline":
+			if r.URL.Query().Get("branch") != "main" {
+				t.Errorf("branch = %q, want main", r.URL.Query().Get("branch"))
+			}
+			w.Write([]byte(`{"items": [{"id": "pl-2", "number": 8}, {"id": "pl-1", "number": 7}]}`))
+		case "/v2/pipeline/pl-2/workflow":
+			w.Write([]byte(`{"items": [{"id": "wf-2", "status": "success", "project_slug": "gh/acme/api"}]}`))
+		case "/v2/pipeline/pl-1/workflow":
+			w.Write([]byte(`{"items": [{"id": "wf-1", "status": "failed", "project_slug": "gh/acme/api"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+
+	ref := &provider.BuildRef{Provider: "circleci", Metadata: map[string]string{"project_slug": "gh/acme/api"}}
+	build, err := p.FetchLatestFailedBuild(context.Background(), ref, "main")
+	if err != nil {
+		t.Fatalf("FetchLatestFailedBuild() error = %v", err)
+	}
+	if build.ID != "wf-1" || build.Number != "7" {
+		t.Errorf("Build = %+v, want wf-1 from pipeline 7", build)
+	}
+}
+
+func TestCircleCIProvider_FetchLatestFailedBuild_None(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items": []}`))
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+
+	ref := &provider.BuildRef{Provider: "circleci", Metadata: map[string]string{"project_slug": "gh/acme/api"}}
+	_, err := p.FetchLatestFailedBuild(context.Background(), ref, "main")
+	if !errors.Is(err, provider.ErrBuildNotFound) {
+		t.Errorf("error = %v, want ErrBuildNotFound", err)
+	}
+}
+
+func TestMapCircleCIStatus(t *testing.T) {
+	tests := map[string]string{
+		"success":  "passed",
+		"failed":   "failed",
+		"timedout": "failed",
+		"canceled": "canceled",
+		"on_hold":  "queued",
+		"running":  "running",
+	}
+
+	for status, want := range tests {
+		if got := mapCircleCIStatus(status); got != want {
+			t.Errorf("mapCircleCIStatus(%v) = %v, want %v", status, got, want)
+		}
+	}
+}
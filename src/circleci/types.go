@@ -0,0 +1,84 @@
+package circleci
+
+import "time"
+
+// Workflow is a CircleCI v2 workflow
+type Workflow struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Status         string    `json:"status"` // success, running, not_run, failed, error, failing, on_hold, canceled, unauthorized
+	PipelineID     string    `json:"pipeline_id"`
+	PipelineNumber int64     `json:"pipeline_number"`
+	ProjectSlug    string    `json:"project_slug"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Pipeline is a CircleCI v2 pipeline
+type Pipeline struct {
+	ID        string    `json:"id"`
+	Number    int64     `json:"number"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	Trigger   struct {
+		Type string `json:"type"` // webhook, explicit, api, schedule, scheduled_pipeline
+	} `json:"trigger"`
+	VCS struct {
+		Branch string `json:"branch"`
+	} `json:"vcs"`
+}
+
+// WorkflowJob is a job within a workflow, as listed by the v2 API.
+// Approval jobs have no job number.
+type WorkflowJob struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Type      string     `json:"type"` // build, approval
+	Status    string     `json:"status"`
+	JobNumber int64      `json:"job_number"`
+	StartedAt *time.Time `json:"started_at"`
+	StoppedAt *time.Time `json:"stopped_at"`
+}
+
+// JobDetails is the v1.1 job response, the only API exposing step output
+type JobDetails struct {
+	BuildNum int64     `json:"build_num"`
+	Status   string    `json:"status"`
+	Steps    []JobStep `json:"steps"`
+}
+
+// JobStep is a step of a job. Parallel jobs have one action per node.
+type JobStep struct {
+	Name    string      `json:"name"`
+	Actions []JobAction `json:"actions"`
+}
+
+// JobAction is a step's execution on one parallel node
+type JobAction struct {
+	Index     int    `json:"index"` // Parallel node index
+	Step      int    `json:"step"`  // Step number within the job
+	Name      string `json:"name"`
+	Status    string `json:"status"` // success, failed, canceled, running, timedout
+	ExitCode  *int   `json:"exit_code"`
+	HasOutput bool   `json:"has_output"`
+	OutputURL string `json:"output_url"`
+	RunTimeMs int64  `json:"run_time_millis"`
+}
+
+// OutputMessage is an entry of a step's output
+type OutputMessage struct {
+	Message string `json:"message"`
+	Type    string `json:"type"` // out, err
+}
+
+// Artifact is a file stored by a job
+type Artifact struct {
+	Path      string `json:"path"`
+	NodeIndex int    `json:"node_index"`
+	URL       string `json:"url"`
+}
+
+// page is a paginated v2 list response
+type page[T any] struct {
+	Items         []T    `json:"items"`
+	NextPageToken string `json:"next_page_token"`
+}
@@ -6,16 +6,27 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"destill-agent/src/analyze"
 	"destill-agent/src/broker"
 	"destill-agent/src/config"
 	"destill-agent/src/logger"
+	"destill-agent/src/pipeline"
+	"destill-agent/src/rules"
+	"destill-agent/src/selftriage"
+	"destill-agent/src/store"
+	"destill-agent/src/summarize"
 )
 
 func main() {
-	// Load configuration
+	// Load configuration: ~/.destill.yaml fills in unset environment variables
+	if _, _, err := config.Setup(); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
 	cfg, err := config.LoadFromEnv()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
@@ -23,43 +34,141 @@ func main() {
 	}
 
 	// Verify we're in distributed mode
-	if len(cfg.RedpandaBrokers) == 0 {
-		fmt.Fprintln(os.Stderr, "ERROR: REDPANDA_BROKERS environment variable is required for analyze agent")
+	if !cfg.Distributed() {
+		fmt.Fprintln(os.Stderr, "ERROR: REDPANDA_BROKERS, NATS_URL, or DESTILL_TRANSPORT=grpc|sqs is required for analyze agent")
 		fmt.Fprintln(os.Stderr, "Example: export REDPANDA_BROKERS=localhost:19092")
 		os.Exit(1)
 	}
 
 	// Create logger
-	log := logger.NewConsoleLogger()
+	var log logger.Logger = logger.NewConsoleLogger()
 
 	log.Info("Starting Destill Analyze Agent")
-	log.Info("Redpanda brokers: %v", cfg.RedpandaBrokers)
+	log.Info("Connecting to %s", cfg.Endpoint())
 
-	// Create Redpanda broker
-	brk, err := broker.NewRedpandaBroker(cfg.RedpandaBrokers)
+	// Create broker for the configured transport
+	brk, err := broker.NewFromConfig(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to create broker: %v\n", err)
 		os.Exit(1)
 	}
 	defer brk.Close()
 
+	// Optionally publish our own logs for 'destill self-triage --topic'
+	if publish, _ := strconv.ParseBool(os.Getenv(selftriage.EnvPublishLogs)); publish {
+		log = selftriage.PublishLogs(context.Background(), log, brk, "analyze-agent")
+	}
+
+	// Load user patterns and suppressions
+	ruleSet, err := rules.LoadRuleSet()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Pattern config error: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Run 'destill config lint' for details")
+		os.Exit(1)
+	}
+
+	// Optional LLM summaries of top findings
+	summarizer, err := summarize.StageFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "LLM summaries: %v\n", err)
+		os.Exit(1)
+	}
+	if summarizer != nil {
+		log.Info("LLM summaries enabled")
+	}
+
+	// Optional pruning of old findings from the database
+	retention, err := store.RetentionFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+	if retention > 0 && cfg.PostgresDSN == "" && cfg.SQLitePath == "" {
+		fmt.Fprintf(os.Stderr, "Configuration error: %s requires POSTGRES_DSN or DESTILL_SQLITE_PATH\n", store.EnvRetention)
+		os.Exit(1)
+	}
+
 	// Create analyze agent
 	agent := analyze.NewAgent(brk, log)
+	agent.SetRules(ruleSet)
+	agent.SetSummarizer(summarizer)
 
 	// Setup context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Handle shutdown signals
+	// Handle shutdown signals: drain first, so work in flight isn't lost,
+	// and stop anyway after DESTILL_DRAIN_TIMEOUT or on a second signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		<-sigChan
-		log.Info("Shutdown signal received, stopping agent...")
+		if cfg.DrainTimeout <= 0 {
+			log.Info("Shutdown signal received, stopping agent...")
+			cancel()
+			return
+		}
+		log.Info("Shutdown signal received, draining agent (up to %s)...", cfg.DrainTimeout)
+		agent.Drain()
+		select {
+		case <-sigChan:
+			log.Info("Second shutdown signal received, stopping agent...")
+		case <-time.After(cfg.DrainTimeout):
+			log.Error("Agent didn't drain within %s, stopping...", cfg.DrainTimeout)
+		}
 		cancel()
 	}()
 
+	var db store.Persistent
+	if cfg.PostgresDSN != "" || cfg.SQLitePath != "" {
+		db, err = store.OpenPersistent(ctx, cfg.PostgresDSN, cfg.SQLitePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+	}
+
+	// NATS and SQS have no Redpanda Connect sink, and it doesn't write to
+	// SQLite, so the analyze agents store findings and log chunks themselves,
+	// splitting them as a group
+	if cfg.StoresFindings() {
+		if err := pipeline.StoreFindings(brk, ctx, db); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := pipeline.StoreChunks(brk, ctx, db); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// The requests table records each request's status and progress, for
+	// 'destill status' and 'destill view <build-url>'
+	if tracker, ok := db.(store.RequestTracker); ok {
+		if err := pipeline.TrackRequests(brk, ctx, tracker); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Every agent prunes; the deletes don't conflict
+	if retention > 0 {
+		pruner, ok := db.(store.Pruner)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Error: the database doesn't support pruning")
+			os.Exit(1)
+		}
+		log.Info("Pruning data older than %s every %s", retention, store.DefaultPruneInterval)
+		pipeline.PruneOld(ctx, pruner, retention, store.DefaultPruneInterval, log)
+	}
+
+	// Apply pattern config edits without a restart
+	if path := rules.DefaultPath(); path != "" {
+		go agent.WatchRules(ctx, path, rules.DefaultWatchInterval)
+	}
+
 	// Run agent
 	log.Info("Analyze agent started, processing log chunks...")
 	if err := agent.Run(ctx); err != nil && err != context.Canceled {
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/notify"
+	"destill-agent/src/permalink"
+	"destill-agent/src/store"
+)
+
+// assignCmd records who is triaging a stored finding
+var assignCmd = &cobra.Command{
+	Use:   "assign <permalink> [assignee]",
+	Short: "Assign a stored finding to someone to triage",
+	Long: `Records an assignee on a finding in Postgres (or SQLite), naming the finding
+by its permalink (copied with p in the TUI, or listed in Slack summaries).
+The assignee shows in the TUI and plain output, 'destill view --assignee'
+lists someone's findings, and in the TUI of 'destill view' the a key assigns
+the selected finding.
+
+With --notify, the assignee is told on Slack (SLACK_WEBHOOK_URL). Give a Slack
+user ID, such as U024BE7LH, to mention them.
+
+Examples:
+  destill assign 'destill://finding/3f2a...?request=req-1733769623456789' alex
+  destill assign 'destill://finding/3f2a...?request=req-1733769623456789' U024BE7LH --notify
+  destill assign 'destill://finding/3f2a...?request=req-1733769623456789' --clear
+
+Environment variables:
+  POSTGRES_DSN        - Postgres connection string
+  DESTILL_SQLITE_PATH - SQLite database of the agents, without POSTGRES_DSN
+  SLACK_WEBHOOK_URL   - Slack incoming webhook, for --notify`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		unassign, _ := cmd.Flags().GetBool("clear")
+		if unassign == (len(args) == 2) {
+			fmt.Fprintln(os.Stderr, "Error: give an assignee, or --clear to unassign")
+			os.Exit(1)
+		}
+		var assignee string
+		if len(args) == 2 {
+			assignee = strings.TrimSpace(args[1])
+		}
+
+		link, err := permalink.Parse(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if link.RequestID == "" {
+			fmt.Fprintln(os.Stderr, "Error: the permalink names no stored request; assign findings of builds analyzed with 'destill submit'")
+			os.Exit(1)
+		}
+
+		ctx := cmd.Context()
+		db, err := store.OpenPersistent(ctx, os.Getenv("POSTGRES_DSN"), os.Getenv("DESTILL_SQLITE_PATH"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		assigner, err := newAssigner(cmd, db)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		card, err := assigner.AssignFinding(ctx, link.RequestID, link.MessageHash, assignee)
+		if err != nil && card.MessageHash == "" {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err != nil {
+			// Assigned, but the notification failed
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if card.Assignee == "" {
+			fmt.Printf("Unassigned %s finding in %s\n", card.Severity, card.JobName)
+			return
+		}
+		fmt.Printf("Assigned %s finding in %s to %s\n", card.Severity, card.JobName, card.Assignee)
+	},
+}
+
+// newAssigner returns db as a store.Assigner that, with --notify, also tells
+// assignees about their findings on Slack.
+func newAssigner(cmd *cobra.Command, db store.Persistent) (store.Assigner, error) {
+	assigner, ok := db.(store.Assigner)
+	if !ok {
+		return nil, fmt.Errorf("the database doesn't support assigning findings")
+	}
+	if notifyOn, _ := cmd.Flags().GetBool("notify"); !notifyOn {
+		return assigner, nil
+	}
+	webhook := os.Getenv(notify.EnvSlackWebhook)
+	if webhook == "" {
+		return nil, fmt.Errorf("%s is required for --notify", notify.EnvSlackWebhook)
+	}
+	return notifyingAssigner{Assigner: assigner, notifier: notify.NewSlackNotifier(webhook)}, nil
+}
+
+// notifyingAssigner tells assignees about the findings assigned to them.
+// When only the notification fails, AssignFinding returns the assigned
+// finding with the error.
+type notifyingAssigner struct {
+	store.Assigner
+	notifier notify.AssignmentNotifier
+}
+
+func (a notifyingAssigner) AssignFinding(ctx context.Context, requestID, messageHash, assignee string) (contracts.TriageCard, error) {
+	card, err := a.Assigner.AssignFinding(ctx, requestID, messageHash, assignee)
+	if err != nil || assignee == "" {
+		return card, err
+	}
+	if err := a.notifier.NotifyAssignment(ctx, notify.Assignment{Card: card, Assignee: assignee}); err != nil {
+		return card, fmt.Errorf("failed to notify %s: %w", assignee, err)
+	}
+	return card, nil
+}
+
+// assigningStore is a store whose findings the TUI can assign through
+// Assigner.
+type assigningStore struct {
+	store.Persistent
+	store.Assigner
+}
+
+// PreviousOccurrence looks up the finding's previous occurrence in the
+// store, for the TUI's c key.
+func (s assigningStore) PreviousOccurrence(ctx context.Context, card contracts.TriageCard) (contracts.TriageCard, error) {
+	finder, ok := s.Persistent.(store.OccurrenceFinder)
+	if !ok {
+		return contracts.TriageCard{}, errors.New("the database doesn't support comparing builds")
+	}
+	return finder.PreviousOccurrence(ctx, card)
+}
+
+// filterByAssignee keeps the findings assigned to assignee, ignoring case.
+func filterByAssignee(cards []contracts.TriageCard, assignee string) []contracts.TriageCard {
+	var assigned []contracts.TriageCard
+	for _, card := range cards {
+		if strings.EqualFold(card.Assignee, assignee) {
+			assigned = append(assigned, card)
+		}
+	}
+	return assigned
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/bench"
+	"destill-agent/src/rules"
+	"destill-agent/src/tui"
+)
+
+// benchCmd scores the analyzer against a corpus of saved logs
+var benchCmd = &cobra.Command{
+	Use:   "bench <corpus-dir>",
+	Short: "Score the analyzer's precision and recall on a corpus of saved logs",
+	Long: `Analyzes each log of a corpus with the current pattern config, as the agents
+would, and compares the findings with the ones the log is expected to produce.
+Precision is the share of findings that were expected; recall is the share of
+expected findings that were found. Run it before and after changing patterns
+or suppressions to catch regressions.
+
+A corpus is a directory of <name>.log files, each with a <name>.expected.yaml:
+
+  exit_status: "1"   # The job's exit status; "0" scores it as passed
+  findings:
+    - message: "panic: runtime error"   # Substring of the finding's line
+      line: 42                           # Optional line in the log
+
+A log that should produce no findings has "findings: []". See
+src/bench/testdata/corpus for examples.
+
+--min-precision and --min-recall exit with status 1 when the totals fall
+below them, for CI.
+
+Examples:
+  destill bench ./corpus
+  destill bench ./corpus --file ./patterns.yaml --verbose
+  destill bench ./corpus --min-precision 0.8 --min-recall 0.95 --json
+
+Environment variables:
+  DESTILL_PATTERNS_FILE - Pattern config to analyze with, without --file`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, _ := cmd.Flags().GetString("file")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		minPrecision, _ := cmd.Flags().GetFloat64("min-precision")
+		minRecall, _ := cmd.Flags().GetFloat64("min-recall")
+
+		ruleSet, err := loadBenchRules(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cases, err := bench.LoadCorpus(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		report, err := bench.Run(cases, ruleSet, analyze.ChunkTimeoutFromEnv())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			writeBenchReport(os.Stdout, report, verbose)
+		}
+
+		if failures := benchFailures(report.Total, minPrecision, minRecall); len(failures) > 0 {
+			for _, failure := range failures {
+				fmt.Fprintf(os.Stderr, "❌ %s\n", failure)
+			}
+			os.Exit(1)
+		}
+	},
+}
+
+// loadBenchRules compiles the pattern config at path, or the usual one
+// without a path.
+func loadBenchRules(path string) (*rules.RuleSet, error) {
+	if path == "" {
+		return rules.LoadRuleSet()
+	}
+	cfg, err := rules.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return rules.Compile(cfg)
+}
+
+// benchFailures describes the totals below the minimum precision or recall.
+func benchFailures(total bench.CaseResult, minPrecision, minRecall float64) []string {
+	var failures []string
+	if p := total.Precision(); p < minPrecision {
+		failures = append(failures, fmt.Sprintf("precision %s is below the minimum %s", percent(p), percent(minPrecision)))
+	}
+	if r := total.Recall(); r < minRecall {
+		failures = append(failures, fmt.Sprintf("recall %s is below the minimum %s", percent(r), percent(minRecall)))
+	}
+	return failures
+}
+
+// writeBenchReport prints a table of the cases and their totals and, when
+// verbose, the expected findings each missed and the unexpected ones.
+func writeBenchReport(w io.Writer, report bench.Report, verbose bool) {
+	fmt.Fprintf(w, "%-30s  %8s  %8s  %9s  %7s  %8s\n", "CASE", "FINDINGS", "EXPECTED", "PRECISION", "RECALL", "TIME")
+	row := func(r bench.CaseResult) {
+		fmt.Fprintf(w, "%-30s  %8d  %8d  %9s  %7s  %8s\n",
+			r.Name, r.Findings, r.Expected, percent(r.Precision()), percent(r.Recall()), r.Duration.Round(time.Microsecond))
+	}
+	for _, r := range report.Cases {
+		row(r)
+		if !verbose {
+			continue
+		}
+		for _, e := range r.Missed {
+			if e.Line > 0 {
+				fmt.Fprintf(w, "    missed     line %d: %s\n", e.Line, e.Message)
+			} else {
+				fmt.Fprintf(w, "    missed     %s\n", e.Message)
+			}
+		}
+		for _, f := range r.Unexpected {
+			fmt.Fprintf(w, "    unexpected line %d: %s\n", f.Line, tui.Truncate(f.Message, 100, true))
+		}
+	}
+	row(report.Total)
+
+	total := report.Total
+	fmt.Fprintf(w, "\n%d cases, %d lines", len(report.Cases), total.Lines)
+	if seconds := total.Duration.Seconds(); seconds > 0 {
+		fmt.Fprintf(w, " at %.0f lines/s", float64(total.Lines)/seconds)
+	}
+	if total.Skipped > 0 {
+		fmt.Fprintf(w, "; %d chunks timed out or failed and were skipped", total.Skipped)
+	}
+	fmt.Fprintln(w)
+}
+
+func percent(ratio float64) string {
+	return fmt.Sprintf("%.1f%%", ratio*100)
+}
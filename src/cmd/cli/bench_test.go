@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/bench"
+)
+
+func TestWriteBenchReport(t *testing.T) {
+	report := bench.Report{
+		Cases: []bench.CaseResult{{
+			Name: "npm-build", Lines: 100, Findings: 4, Expected: 2, Correct: 3, Found: 1,
+			Missed:     []bench.Expected{{Message: "error TS2554", Line: 8}},
+			Unexpected: []bench.Finding{{Message: "npm ERR! code 1", Line: 11}},
+			Duration:   time.Millisecond,
+		}},
+		Total: bench.CaseResult{Name: "total", Lines: 100, Findings: 4, Expected: 2, Correct: 3, Found: 1, Duration: time.Millisecond},
+	}
+
+	var buf bytes.Buffer
+	writeBenchReport(&buf, report, true)
+	for _, want := range []string{"npm-build", "75.0%", "50.0%", "missed     line 8: error TS2554", "unexpected line 11: npm ERR! code 1", "1 cases, 100 lines at 100000 lines/s"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	writeBenchReport(&buf, report, false)
+	if strings.Contains(buf.String(), "missed") {
+		t.Errorf("report lists misses without verbose:\n%s", buf.String())
+	}
+
+	if failures := benchFailures(report.Total, 0.7, 0.9); len(failures) != 1 || !strings.Contains(failures[0], "recall 50.0%") {
+		t.Errorf("benchFailures() = %q, want recall below the minimum", failures)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/provider"
+)
+
+// bisectPlanCmd prints how to bisect the failures of a build
+var bisectPlanCmd = &cobra.Command{
+	Use:   "bisect-plan <build-url>",
+	Short: "Print a git bisect plan for a failed build",
+	Long: `Looks up the last passed build of the same pipeline, on the same branch and
+with the same trigger (for a nightly build, the last clean nightly), and prints
+the commit range the failures were introduced in with the git bisect commands
+to narrow it down.
+
+Findings of failed nightly builds carry the same range (bisect_good_commit,
+bisect_bad_commit, bisect_commits), shown in the TUI detail panel as
+"Failure introduced somewhere in 14 commits between ...".
+
+Supported on Buildkite and GitHub Actions; only GitHub counts the commits.
+
+Examples:
+  destill bisect-plan https://buildkite.com/org/nightly/builds/123
+  destill bisect-plan nightly#123`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		buildURL, err := resolveBuildArg(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		ref, err := provider.ParseURL(buildURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", provider.WrapError(err))
+			os.Exit(1)
+		}
+		prov, err := provider.GetProvider(ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx := cmd.Context()
+		build, err := prov.FetchBuild(ctx, ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to fetch build: %v\n", provider.WrapError(err))
+			os.Exit(1)
+		}
+		r, err := provider.FindCommitRange(ctx, prov, ref, build)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", provider.WrapError(err))
+			os.Exit(1)
+		}
+		if r == nil {
+			fmt.Fprintln(os.Stderr, "No commit range to bisect: the provider can't look up earlier builds, no earlier build passed, or it ran the same commit")
+			os.Exit(1)
+		}
+		writeBisectPlan(os.Stdout, build, r)
+	},
+}
+
+// writeBisectPlan prints the commit range of build's failures and the git
+// bisect commands to search it.
+func writeBisectPlan(w io.Writer, build *provider.Build, r *provider.CommitRange) {
+	fmt.Fprintf(w, "Build %s failed at %s; %s passed at %s.\n",
+		build.Number, provider.ShortCommit(r.Bad), r.GoodBuildURL, provider.ShortCommit(r.Good))
+	fmt.Fprintf(w, "The failures were %s", r)
+	if r.Commits > 1 {
+		// Each step halves the remaining commits
+		fmt.Fprintf(w, " (about %d bisect steps)", bits.Len(uint(r.Commits-1)))
+	}
+	fmt.Fprintln(w, ".")
+
+	var failed []string
+	for _, job := range build.Jobs {
+		if job.State == "failed" || job.ExitCode != 0 {
+			failed = append(failed, job.Name)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Fprintln(w, "\nFailed jobs:")
+		for _, name := range failed {
+			fmt.Fprintf(w, "  - %s\n", name)
+		}
+	}
+
+	fmt.Fprintln(w, "\nBisect plan:")
+	fmt.Fprintf(w, "  git fetch origin %s %s\n", r.Good, r.Bad)
+	fmt.Fprintf(w, "  git bisect start %s %s\n", r.Bad, r.Good)
+	fmt.Fprintln(w, "  git bisect run <command reproducing a failed job>")
+	fmt.Fprintln(w, "  git bisect reset")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"destill-agent/src/provider"
+)
+
+func TestWriteBisectPlan(t *testing.T) {
+	build := &provider.Build{
+		Number: "123",
+		Jobs: []provider.Job{
+			{Name: "unit-tests", State: "failed", ExitCode: 1},
+			{Name: "lint", State: "passed"},
+		},
+	}
+	r := &provider.CommitRange{
+		Good:         "1a2b3c4d5e6f",
+		Bad:          "5d6e7f8a9b0c",
+		GoodBuildURL: "https://buildkite.com/org/nightly/builds/122",
+		Commits:      14,
+	}
+
+	var b strings.Builder
+	writeBisectPlan(&b, build, r)
+	out := b.String()
+	for _, want := range []string{
+		"introduced somewhere in 14 commits between 1a2b3c4 and 5d6e7f8 (about 4 bisect steps)",
+		"  - unit-tests\n",
+		"git bisect start 5d6e7f8a9b0c 1a2b3c4d5e6f",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("writeBisectPlan() = %q, want it to contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "lint") {
+		t.Errorf("writeBisectPlan() = %q, want only the failed jobs", out)
+	}
+}
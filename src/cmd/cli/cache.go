@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/provider"
+)
+
+// cacheCmd groups the commands managing the on-disk API response cache
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the on-disk cache of provider API responses",
+	Long: `Build and job log responses from the Buildkite and GitHub APIs are cached
+on disk, keyed by URL, and revalidated by ETag on every request: analyzing
+the same build again only downloads what changed, and the provider still
+checks access each time.
+
+The cache is in "http" under destill's cache directory (~/.cache/destill on
+Linux, ~/Library/Caches/destill on macOS, %LocalAppData%\destill on Windows).
+
+Environment variables:
+  DESTILL_HTTP_CACHE - Cache directory to use instead, or "off" to disable`,
+}
+
+// cacheClearCmd deletes the cached API responses
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete the cached API responses",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cleared, err := provider.ClearHTTPCache()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Cleared %d cached responses\n", cleared)
+	},
+}
+
+// addNoCacheFlag registers --no-cache on commands that fetch builds.
+func addNoCacheFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("no-cache", false, "Download build and job log API responses again instead of reusing the on-disk cache")
+}
+
+// applyNoCacheFlag turns the API response cache off for this process if
+// --no-cache is set. Provider clients read the setting from the
+// environment when they're created, so call it before any are.
+func applyNoCacheFlag(cmd *cobra.Command) {
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); noCache {
+		os.Setenv(provider.EnvHTTPCache, "off")
+	}
+}
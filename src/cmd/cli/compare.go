@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/provider"
+	"destill-agent/src/ranking"
+	"destill-agent/src/store"
+	"destill-agent/src/tui"
+)
+
+// viewComparison shows the findings of two requests together (view
+// --compare), labeled by which of them has each: only the first, only the
+// second, or both. Each argument is a request ID or a build, for its latest
+// request.
+func viewComparison(cmd *cobra.Command, args []string) error {
+	postgresDSN, sqlitePath := os.Getenv("POSTGRES_DSN"), os.Getenv("DESTILL_SQLITE_PATH")
+	if postgresDSN == "" && sqlitePath == "" {
+		return fmt.Errorf("POSTGRES_DSN or DESTILL_SQLITE_PATH environment variable is required")
+	}
+
+	ctx := cmd.Context()
+	db, err := store.OpenPersistent(ctx, postgresDSN, sqlitePath)
+	if err != nil {
+		return fmt.Errorf("failed to open the database: %w", err)
+	}
+	defer db.Close()
+
+	var requestIDs [2]string
+	for i, arg := range args {
+		if requestIDs[i], err = lookupRequest(ctx, db, arg); err != nil {
+			return err
+		}
+	}
+	if requestIDs[0] == requestIDs[1] {
+		return fmt.Errorf("both arguments are request %s; compare two different requests", requestIDs[0])
+	}
+
+	first, err := db.GetFindings(ctx, requestIDs[0])
+	if err != nil {
+		return fmt.Errorf("failed to query findings of %s: %w", requestIDs[0], err)
+	}
+	second, err := db.GetFindings(ctx, requestIDs[1])
+	if err != nil {
+		return fmt.Errorf("failed to query findings of %s: %w", requestIDs[1], err)
+	}
+	findings, comparison := ranking.CompareRequests(first, second, requestIDs[0], requestIDs[1])
+	fmt.Printf("⚖️  %s\n", comparison)
+	if len(findings) == 0 {
+		fmt.Println("\nNeither request has findings")
+		return nil
+	}
+
+	if team, _ := cmd.Flags().GetString("team"); team != "" {
+		findings = filterByTeam(findings, team)
+	}
+	if assignee, _ := cmd.Flags().GetString("assignee"); assignee != "" {
+		findings = filterByAssignee(findings, assignee)
+	}
+	if category, _ := cmd.Flags().GetString("category"); category != "" {
+		findings = filterByCategory(findings, category)
+	}
+	if len(findings) == 0 {
+		fmt.Println("\nNo findings match the filters")
+		return nil
+	}
+
+	if plain, _ := cmd.Flags().GetBool("plain"); plain {
+		return tui.StartPlain(findings)
+	}
+	var source tui.ContextSource = db
+	if assigner, err := newAssigner(cmd, db); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else {
+		source = assigningStore{Persistent: db, Assigner: assigner}
+	}
+	return tui.StartWithContext(findings, source)
+}
+
+// lookupRequest returns the request ID arg names: arg itself, or the latest
+// request of the build arg names (a URL, shorthand, or alias).
+func lookupRequest(ctx context.Context, db store.Persistent, arg string) (string, error) {
+	buildURL, err := resolveBuildArg(arg)
+	if err != nil {
+		return "", err
+	}
+	if !provider.IsURL(buildURL) {
+		return arg, nil
+	}
+	requestID, err := db.GetLatestRequestByBuildURL(ctx, buildURL)
+	if err != nil {
+		return "", fmt.Errorf("no request found for build %s: %w", buildURL, err)
+	}
+	return requestID, nil
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/config"
+	"destill-agent/src/rules"
+)
+
+// configCmd groups commands for inspecting user configuration
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and validate destill configuration",
+}
+
+// configLintCmd validates the user pattern/suppression config
+var configLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Validate the pattern config and print the effective configuration",
+	Long: `Parses the user pattern/suppression config, checks that every regex compiles,
+that pattern weights are within [-1.0, 1.0], that severity overrides name a
+known severity, and warns about rules that match every line, and that pipeline
+rules use valid priorities, notify modes, and deadlines. Pattern packs listed
+under 'packs:' and the repo's .destill-ignore.yaml are loaded and checked too. Then prints the effective configuration: built-in patterns merged
+with user patterns, suppressions, and pipeline rules.
+
+The config is read from ~/.destill/patterns.yaml, or DESTILL_PATTERNS_FILE if set.
+
+Exits non-zero if the config has errors.
+
+Examples:
+  destill config lint
+  destill config lint --file ./patterns.yaml
+  destill config lint --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, _ := cmd.Flags().GetString("file")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		var cfg *rules.Config
+		var err error
+		if path != "" {
+			cfg, err = rules.LoadFile(path)
+		} else {
+			cfg, path, err = rules.Load()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		ignores, ignorePath, err := rules.LoadIgnores()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.Ignores = ignores
+
+		issues := rules.Lint(cfg)
+
+		if jsonOutput {
+			output, err := json.MarshalIndent(map[string]any{
+				"path":              path,
+				"issues":            issues,
+				"builtin_patterns":  analyze.BuiltinPatterns(),
+				"user_patterns":     cfg.Patterns,
+				"user_suppressions": cfg.Suppressions,
+				"severities":        cfg.Severities,
+				"normalizations":    cfg.Normalizations,
+				"remediations":      cfg.Remediations,
+				"packs":             cfg.Packs,
+				"pipeline_rules":    cfg.Pipelines,
+				"ignore_path":       ignorePath,
+				"ignore":            cfg.Ignores,
+			}, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to marshal lint result: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(output))
+		} else {
+			printLintResult(path, ignorePath, cfg, issues)
+		}
+
+		if rules.HasErrors(issues) {
+			os.Exit(1)
+		}
+	},
+}
+
+// configShowCmd prints the merged config file settings
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the merged ~/.destill.yaml and per-repo config",
+	Long: `Prints which config files were loaded and the merged settings, with tokens
+and the Postgres password masked.
+
+The global config is ~/.destill.yaml, or DESTILL_CONFIG_FILE if set. A
+.destill.yaml in the current repository (searched from the working directory
+up to the directory containing .git) overrides it, except that it may not
+contain tokens. Environment variables override both.
+
+Examples:
+  destill config show
+  destill config show --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		redacted := fileConfig.Redacted()
+
+		if jsonOutput {
+			output, err := json.MarshalIndent(map[string]any{
+				"files":  fileConfigSources,
+				"config": redacted,
+			}, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to marshal config: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(output))
+			return
+		}
+
+		if len(fileConfigSources) == 0 {
+			fmt.Printf("No config files found (looked for %s and ./%s)\n", config.GlobalPath(), config.FileName)
+			return
+		}
+		fmt.Println("Config files (later files override earlier ones):")
+		for _, path := range fileConfigSources {
+			fmt.Printf("  %s\n", path)
+		}
+		output, err := yaml.Marshal(redacted)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to marshal config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\n%s", output)
+	},
+}
+
+// printLintResult writes lint issues and the effective configuration to stdout.
+func printLintResult(path, ignorePath string, cfg *rules.Config, issues []rules.Issue) {
+	fmt.Printf("Pattern config: %s\n", path)
+	if ignorePath != "" {
+		fmt.Printf("Ignore file: %s\n", ignorePath)
+	}
+	fmt.Println()
+
+	if len(issues) == 0 {
+		fmt.Println("✅ No problems found")
+	}
+	for _, issue := range issues {
+		icon := "⚠️ "
+		if issue.Level == rules.LevelError {
+			icon = "❌"
+		}
+		fmt.Printf("%s %s: %s\n", icon, issue.Level, issue)
+	}
+
+	builtins := analyze.BuiltinPatterns()
+	fmt.Printf("\nBuilt-in patterns (%d):\n", len(builtins))
+	for _, p := range builtins {
+		fmt.Printf("  %+.2f  %s\n", p.Delta, p.Name)
+	}
+
+	if len(cfg.Packs) > 0 {
+		fmt.Printf("\nPacks: %s\n", strings.Join(cfg.Packs, ", "))
+	}
+
+	fmt.Printf("\nUser patterns (%d):\n", len(cfg.Patterns))
+	for _, p := range cfg.Patterns {
+		fmt.Printf("  %+.2f  %s  /%s/%s\n", p.Weight, p.Name, p.Regex, fromPack(p.Pack))
+	}
+
+	fmt.Printf("\nSuppressions (%d):\n", len(cfg.Suppressions))
+	for _, s := range cfg.Suppressions {
+		if s.Reason != "" {
+			fmt.Printf("  %s  /%s/  (%s)%s\n", s.Name, s.Regex, s.Reason, fromPack(s.Pack))
+		} else {
+			fmt.Printf("  %s  /%s/%s\n", s.Name, s.Regex, fromPack(s.Pack))
+		}
+	}
+
+	fmt.Printf("\nSeverity overrides (%d):\n", len(cfg.Severities))
+	for _, r := range cfg.Severities {
+		fmt.Printf("  %-5s  %s  /%s/%s\n", strings.ToUpper(r.Severity), r.Name, r.Regex, fromPack(r.Pack))
+	}
+
+	fmt.Printf("\nNormalizations (%d):\n", len(cfg.Normalizations))
+	for _, n := range cfg.Normalizations {
+		fmt.Printf("  %s  /%s/ → %q%s\n", n.Name, n.Regex, n.Replace, fromPack(n.Pack))
+	}
+
+	fmt.Printf("\nRemediations (%d, plus %d built-in):\n", len(cfg.Remediations), len(rules.BuiltinRemediations()))
+	for _, r := range cfg.Remediations {
+		fmt.Printf("  %s  /%s/ → %s%s\n", r.Name, r.Regex, r.Fix, fromPack(r.Pack))
+	}
+
+	fmt.Printf("\nPipeline rules (%d):\n", len(cfg.Pipelines))
+	for _, r := range cfg.Pipelines {
+		fmt.Printf("  %s  %s\n", r.Name, formatPipelineRule(r))
+	}
+
+	if ignorePath != "" {
+		fmt.Printf("\nIgnore rules (%d):\n", len(cfg.Ignores))
+		for _, r := range cfg.Ignores {
+			fmt.Printf("  %s  %s\n", r.Name, formatIgnoreRule(r))
+		}
+	}
+}
+
+// formatIgnoreRule renders an ignore rule's pipeline, matches, and reason on one line.
+func formatIgnoreRule(r rules.IgnoreRule) string {
+	pipeline := r.Pipeline
+	if pipeline == "" {
+		pipeline = "*"
+	}
+	parts := []string{"pipeline=" + pipeline}
+	if r.Regex != "" {
+		parts = append(parts, "/"+r.Regex+"/")
+	}
+	if len(r.Hashes) > 0 {
+		parts = append(parts, "hashes="+strings.Join(r.Hashes, ","))
+	}
+	if r.Reason != "" {
+		parts = append(parts, "("+r.Reason+")")
+	}
+	return strings.Join(parts, "  ")
+}
+
+// fromPack labels a rule loaded from a pattern pack.
+func fromPack(pack string) string {
+	if pack == "" {
+		return ""
+	}
+	return "  [pack " + pack + "]"
+}
+
+// formatPipelineRule renders a pipeline rule's conditions and effects on one line.
+func formatPipelineRule(r rules.PipelineRule) string {
+	var parts []string
+	for _, field := range []struct{ key, value string }{
+		{"pipeline", r.Pipeline},
+		{"branch", r.Branch},
+		{"trigger", r.Trigger},
+		{"→ priority", r.Priority},
+		{"notify", r.Notify},
+		{"deadline", r.Deadline},
+	} {
+		if field.value != "" {
+			parts = append(parts, field.key+"="+field.value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
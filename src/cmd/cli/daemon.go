@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/config"
+	"destill-agent/src/daemon"
+	"destill-agent/src/logger"
+	"destill-agent/src/pipeline"
+	"destill-agent/src/provider"
+)
+
+// daemonCmd runs scheduled sweeps for failed builds
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Periodically analyze the latest failed builds of configured pipelines",
+	Long: `Runs until interrupted. On every tick of --schedule, looks up the most recent
+failed build of each --pipeline via the provider API, submits builds it hasn't
+analyzed yet, and sends notifications. No webhooks from the CI system needed.
+
+Pipelines are given as a pipeline URL, org/pipeline slug, or alias, optionally
+followed by @branch (default: --branch).
+
+Notifications follow the notify hint of the pipeline rules in
+~/.destill/patterns.yaml: 'immediate' notifies as soon as a build is analyzed,
+'digest' batches reports until the next tick of --digest-schedule, and 'none'
+only logs. Reports are printed to stdout and, with --webhook, POSTed as JSON.
+
+Schedules are "@every <duration>", "@hourly", "@daily", "@weekly", or a
+five-field cron expression (minute hour day-of-month month day-of-week).
+
+Without REDPANDA_BROKERS, NATS_URL, or DESTILL_TRANSPORT=grpc|sqs, analysis runs
+in-process. With any of them, requests go to the distributed agents and findings are also
+stored in Postgres.
+
+Examples:
+  destill daemon --pipeline org/api --pipeline org/web@release
+  destill daemon --pipeline backend --schedule "*/10 8-18 * * 1-5"
+  destill daemon --pipeline https://github.com/owner/repo --webhook https://hooks.slack.com/services/...
+  destill daemon --pipeline org/api --once
+
+Environment variables:
+  DESTILL_NOTIFY_WEBHOOK   - Optional. Default for --webhook
+  REDPANDA_BROKERS         - Optional. Use the distributed agents
+  NATS_URL                 - Optional. Use the distributed agents over NATS JetStream
+  DESTILL_TRANSPORT        - Optional. grpc to reach the agents via DESTILL_GRPC_ADDR, sqs via AWS SNS/SQS
+  DESTILL_PIPELINE_ALIASES - Optional. Comma-separated alias=org/pipeline pairs`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		pipelines, _ := cmd.Flags().GetStringSlice("pipeline")
+		branch, _ := cmd.Flags().GetString("branch")
+		scheduleSpec, _ := cmd.Flags().GetString("schedule")
+		digestSpec, _ := cmd.Flags().GetString("digest-schedule")
+		maxAge, _ := cmd.Flags().GetDuration("max-age")
+		idle, _ := cmd.Flags().GetDuration("idle")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		webhook, _ := cmd.Flags().GetString("webhook")
+		once, _ := cmd.Flags().GetBool("once")
+		if webhook == "" {
+			webhook = os.Getenv("DESTILL_NOTIFY_WEBHOOK")
+		}
+
+		if len(pipelines) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: at least one --pipeline is required")
+			os.Exit(1)
+		}
+
+		sweep, err := daemon.ParseSchedule(scheduleSpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --schedule: %v\n", err)
+			os.Exit(1)
+		}
+		digest, err := daemon.ParseSchedule(digestSpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --digest-schedule: %v\n", err)
+			os.Exit(1)
+		}
+
+		aliases, err := config.LoadAliasesFromEnv()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Validate every pipeline and token up front so typos fail fast
+		targets := make([]daemon.Target, 0, len(pipelines))
+		for _, spec := range pipelines {
+			target := daemon.ParseTarget(spec, branch)
+			ref, err := provider.ParsePipeline(target.Pipeline, aliases)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", provider.WrapError(err))
+				os.Exit(1)
+			}
+			if err := provider.ValidateToken(ref); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", target, provider.WrapError(err))
+				os.Exit(1)
+			}
+			targets = append(targets, target)
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		msgBroker, err := newDaemonBroker(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer msgBroker.Close()
+
+		notifier := daemon.MultiNotifier{daemon.NewWriterNotifier(os.Stdout)}
+		if webhook != "" {
+			notifier = append(notifier, daemon.NewWebhookNotifier(webhook))
+		}
+
+		d := daemon.New(msgBroker, logger.NewConsoleLogger(), notifier, daemon.Options{
+			Targets: targets,
+			Aliases: aliases,
+			MaxAge:  maxAge,
+			Idle:    idle,
+			Timeout: timeout,
+		})
+
+		if once {
+			if err := d.Start(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			d.Sweep(ctx)
+			d.FlushDigest(ctx)
+			return
+		}
+
+		fmt.Printf("🕑 Sweeping %d pipelines on schedule %q (digest %q)\n", len(targets), scheduleSpec, digestSpec)
+		if err := d.Run(ctx, sweep, digest); err != nil && err != context.Canceled {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// newDaemonBroker connects to the shared broker when one is configured (see
+// connectBroker), and otherwise starts the agents in-process on an
+// in-memory broker.
+func newDaemonBroker(ctx context.Context) (broker.Broker, error) {
+	if msgBroker, err := connectBroker(); msgBroker != nil || err != nil {
+		return msgBroker, err
+	}
+
+	msgBroker := broker.NewInMemoryBroker()
+	if err := pipeline.Start(msgBroker, ctx); err != nil {
+		msgBroker.Close()
+		return nil, fmt.Errorf("failed to start pipeline: %w", err)
+	}
+	return msgBroker, nil
+}
+
+// connectBroker connects to the gRPC server when DESTILL_TRANSPORT=grpc, to
+// SNS/SQS when DESTILL_TRANSPORT=sqs, to NATS when NATS_URL is set, or to
+// Redpanda when REDPANDA_BROKERS is set. It returns nil when none is
+// configured.
+func connectBroker() (broker.Broker, error) {
+	transport, grpcAddr, err := config.TransportFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &config.Config{Transport: transport, GRPCAddr: grpcAddr}
+	if transport == config.TransportNATS {
+		cfg.NATSURL = strings.TrimSpace(os.Getenv("NATS_URL"))
+	}
+	if transport == config.TransportSQS {
+		cfg.SQSPrefix = config.SQSPrefixFromEnv()
+	}
+	if transport == config.TransportRedpanda {
+		redpandaBrokersStr := os.Getenv("REDPANDA_BROKERS")
+		if redpandaBrokersStr == "" {
+			return nil, nil
+		}
+		for _, addr := range strings.Split(redpandaBrokersStr, ",") {
+			cfg.RedpandaBrokers = append(cfg.RedpandaBrokers, strings.TrimSpace(addr))
+		}
+	}
+	return broker.NewFromConfig(cfg)
+}
+
+// requireBroker is connectBroker for commands that only work with the
+// shared broker the agents use.
+func requireBroker() (broker.Broker, error) {
+	msgBroker, err := connectBroker()
+	if err != nil {
+		return nil, err
+	}
+	if msgBroker == nil {
+		return nil, fmt.Errorf("no broker configured: set REDPANDA_BROKERS, NATS_URL, or DESTILL_TRANSPORT")
+	}
+	return msgBroker, nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+)
+
+// dlqCmd groups the commands inspecting the dead-letter topic
+var dlqCmd = &cobra.Command{
+	Use:   "dlq",
+	Short: "Inspect and replay messages the agents gave up on (distributed mode)",
+	Long: `The analyze agent sends the log chunks it gives up on to the destill.logs.dlq
+topic: chunks whose analysis timed out or crashed, and messages it couldn't
+decode. Each dead letter keeps the original message, why it failed, and how
+many times it has failed, counting replays.
+
+A message that failed 3 times, or can't be decoded, is poison: replay leaves
+it on the topic unless --force is given (undecodable messages are never
+replayed).
+
+Environment variables:
+  DESTILL_TRANSPORT - Broker transport (redpanda, nats, sqs, grpc)
+  REDPANDA_BROKERS  - Comma-separated list of Redpanda brokers
+  NATS_URL          - NATS server, with DESTILL_TRANSPORT=nats`,
+}
+
+// dlqListCmd prints the dead letters
+var dlqListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the dead letters",
+	Long: `Reads the dead-letter topic from the beginning and prints each entry.
+
+Examples:
+  destill dlq list
+  destill dlq list --request req-1733769623456789
+  destill dlq list --since 24h --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		msgBroker, err := requireBroker()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer msgBroker.Close()
+
+		letters, err := readDeadLettersFrom(cmd, msgBroker)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(letters); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		writeDeadLetters(os.Stdout, letters)
+	},
+}
+
+// dlqReplayCmd publishes dead letters to their original topic again
+var dlqReplayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Send dead letters back to the agents",
+	Long: `Publishes the dead letters to the topic they came from, so the agents
+process them again. A replayed chunk that fails again comes back to the
+dead-letter topic with one more attempt.
+
+Entries stay on the dead-letter topic after a replay, so narrow repeated
+replays with --request or --since.
+
+Examples:
+  destill dlq replay --request req-1733769623456789
+  destill dlq replay --since 1h --dry-run`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		force, _ := cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		msgBroker, err := requireBroker()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer msgBroker.Close()
+
+		letters, err := readDeadLettersFrom(cmd, msgBroker)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var publish broker.Broker
+		if !dryRun {
+			publish = msgBroker
+		}
+		result, err := replayDeadLetters(cmd.Context(), publish, letters, force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		verb := "Replayed"
+		if dryRun {
+			verb = "Would replay"
+		}
+		fmt.Printf("%s %d of %d dead letters", verb, result.Replayed, len(letters))
+		if result.Poison > 0 {
+			fmt.Printf("; left %d poison messages (see 'destill dlq list')", result.Poison)
+		}
+		fmt.Println()
+	},
+}
+
+// readDeadLettersFrom reads the dead letters matching the command's
+// --request and --since filters.
+func readDeadLettersFrom(cmd *cobra.Command, msgBroker broker.Broker) ([]contracts.DeadLetter, error) {
+	idle, _ := cmd.Flags().GetDuration("idle")
+	requestID, _ := cmd.Flags().GetString("request")
+	since, _ := cmd.Flags().GetDuration("since")
+
+	// A fresh consumer group per run so the topic is read from the beginning
+	groupID := fmt.Sprintf("destill-dlq-%d", time.Now().UnixNano())
+	letters, err := readDeadLetters(cmd.Context(), msgBroker, groupID, idle)
+	if err != nil {
+		return nil, err
+	}
+	var after time.Time
+	if since > 0 {
+		after = time.Now().Add(-since)
+	}
+	return filterDeadLetters(letters, requestID, after), nil
+}
+
+// readDeadLetters reads everything currently on the dead-letter topic,
+// until no message arrives for idle. Entries that aren't dead letters are
+// skipped.
+func readDeadLetters(ctx context.Context, brk broker.Broker, groupID string, idle time.Duration) ([]contracts.DeadLetter, error) {
+	msgs, err := brk.Subscribe(ctx, contracts.TopicLogsDLQ, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicLogsDLQ, err)
+	}
+
+	var letters []contracts.DeadLetter
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+
+	for {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				return letters, nil
+			}
+			var letter contracts.DeadLetter
+			if err := json.Unmarshal(msg.Value, &letter); err == nil {
+				letters = append(letters, letter)
+			}
+			timer.Reset(idle)
+		case <-timer.C:
+			return letters, nil
+		case <-ctx.Done():
+			return letters, nil
+		}
+	}
+}
+
+// filterDeadLetters keeps the dead letters of requestID (any when empty)
+// that failed after after (any when zero). Entries without a valid
+// failed_at are kept.
+func filterDeadLetters(letters []contracts.DeadLetter, requestID string, after time.Time) []contracts.DeadLetter {
+	var kept []contracts.DeadLetter
+	for _, letter := range letters {
+		if requestID != "" && letter.RequestID() != requestID {
+			continue
+		}
+		if failedAt, err := time.Parse(time.RFC3339, letter.FailedAt); err == nil && !after.IsZero() && failedAt.Before(after) {
+			continue
+		}
+		kept = append(kept, letter)
+	}
+	return kept
+}
+
+// writeDeadLetters prints one entry per dead letter, with the failure on
+// an indented second line.
+func writeDeadLetters(w io.Writer, letters []contracts.DeadLetter) {
+	if len(letters) == 0 {
+		fmt.Fprintln(w, "No dead letters")
+		return
+	}
+	for _, letter := range letters {
+		fmt.Fprintf(w, "%s  %s  %s, attempt %d of %d", letter.FailedAt, letter.RequestID(), letter.Reason, letter.Attempts, contracts.MaxDeadLetterAttempts)
+		if letter.Poison() {
+			fmt.Fprint(w, " (poison)")
+		}
+		fmt.Fprintln(w)
+
+		var chunk contracts.LogChunk
+		if letter.Topic == contracts.TopicLogsRaw && json.Unmarshal(letter.Value, &chunk) == nil {
+			fmt.Fprintf(w, "    job %q chunk %d, lines %d-%d\n", chunk.JobName, chunk.ChunkIndex+1, chunk.LineStart, chunk.LineEnd)
+		} else {
+			fmt.Fprintf(w, "    from %s\n", letter.Topic)
+		}
+		if letter.Error != "" {
+			fmt.Fprintf(w, "    %s\n", letter.Error)
+		}
+	}
+	fmt.Fprintf(w, "\n%d dead letters\n", len(letters))
+}
+
+// replayResult counts what replayDeadLetters did.
+type replayResult struct {
+	Replayed int
+	Poison   int // Left on the dead-letter topic
+}
+
+// replayDeadLetters publishes letters to the topics they came from,
+// skipping poison messages unless force is set; messages that can't be
+// decoded are never replayed. Replayed log chunks carry their attempts so
+// far, which the analyze agent counts on if they fail again. A nil brk
+// only counts what would be replayed.
+func replayDeadLetters(ctx context.Context, brk broker.Broker, letters []contracts.DeadLetter, force bool) (replayResult, error) {
+	var result replayResult
+	for _, letter := range letters {
+		if letter.Reason == contracts.SkipReasonInvalid || (letter.Poison() && !force) {
+			result.Poison++
+			continue
+		}
+
+		value := []byte(letter.Value)
+		if letter.Topic == contracts.TopicLogsRaw {
+			var chunk contracts.LogChunk
+			if err := json.Unmarshal(letter.Value, &chunk); err != nil {
+				result.Poison++
+				continue
+			}
+			chunk.DeadLetters = letter.Attempts
+			data, err := json.Marshal(chunk)
+			if err != nil {
+				return result, fmt.Errorf("failed to marshal chunk: %w", err)
+			}
+			value = data
+		}
+
+		if brk != nil {
+			if err := brk.Publish(ctx, letter.Topic, letter.Key, value); err != nil {
+				return result, fmt.Errorf("failed to replay %s to %s: %w", letter.Key, letter.Topic, err)
+			}
+		}
+		result.Replayed++
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+)
+
+func TestReplayDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	chunks, err := brk.Subscribe(ctx, contracts.TopicLogsRaw, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chunkData, _ := json.Marshal(contracts.LogChunk{RequestID: "req-1", JobName: "unit", Content: "ERROR: boom"})
+	letters := []contracts.DeadLetter{
+		{Topic: contracts.TopicLogsRaw, Key: "req-1", Value: chunkData, Reason: contracts.SkipReasonTimeout, Attempts: 1},
+		{Topic: contracts.TopicLogsRaw, Key: "req-1", Value: chunkData, Reason: contracts.SkipReasonPanic, Attempts: contracts.MaxDeadLetterAttempts},
+		{Topic: contracts.TopicLogsRaw, Key: "req-2", Value: json.RawMessage(`"not a chunk"`), Reason: contracts.SkipReasonInvalid, Attempts: 1},
+	}
+
+	result, err := replayDeadLetters(ctx, brk, letters, false)
+	if err != nil {
+		t.Fatalf("replayDeadLetters() error = %v", err)
+	}
+	if result.Replayed != 1 || result.Poison != 2 {
+		t.Errorf("replayDeadLetters() = %+v, want 1 replayed, 2 poison", result)
+	}
+
+	select {
+	case msg := <-chunks:
+		var chunk contracts.LogChunk
+		if err := json.Unmarshal(msg.Value, &chunk); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Key != "req-1" || chunk.Content != "ERROR: boom" || chunk.DeadLetters != 1 {
+			t.Errorf("replayed chunk = %+v (key %s), want req-1's chunk with 1 dead letter", chunk, msg.Key)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for replayed chunk")
+	}
+
+	// --force replays poison, but never what can't be decoded
+	result, err = replayDeadLetters(ctx, nil, letters, true)
+	if err != nil || result.Replayed != 2 || result.Poison != 1 {
+		t.Errorf("replayDeadLetters(force) = %+v, %v, want 2 replayed, 1 poison", result, err)
+	}
+}
+
+func TestReadDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	done := make(chan []contracts.DeadLetter)
+	go func() {
+		letters, _ := readDeadLetters(ctx, brk, "test", 200*time.Millisecond)
+		done <- letters
+	}()
+	// Let the reader subscribe; the in-memory broker doesn't keep history
+	time.Sleep(50 * time.Millisecond)
+
+	old, _ := json.Marshal(contracts.DeadLetter{Key: "req-1", FailedAt: time.Now().Add(-2 * time.Hour).Format(time.RFC3339)})
+	recent, _ := json.Marshal(contracts.DeadLetter{Key: "req-1", FailedAt: time.Now().Format(time.RFC3339)})
+	other, _ := json.Marshal(contracts.DeadLetter{Key: "req-2", FailedAt: time.Now().Format(time.RFC3339)})
+	for _, data := range [][]byte{old, []byte("garbage"), recent, other} {
+		if err := brk.Publish(ctx, contracts.TopicLogsDLQ, "key", data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	letters := <-done
+	if len(letters) != 3 {
+		t.Fatalf("readDeadLetters() = %d entries, want 3 (garbage skipped)", len(letters))
+	}
+	if got := filterDeadLetters(letters, "req-1", time.Now().Add(-time.Hour)); len(got) != 1 || got[0].FailedAt != letters[1].FailedAt {
+		t.Errorf("filterDeadLetters(req-1, 1h) = %+v, want the recent req-1 entry", got)
+	}
+}
+
+func TestWriteDeadLetters(t *testing.T) {
+	chunkData, _ := json.Marshal(contracts.LogChunk{JobName: "unit", ChunkIndex: 2, LineStart: 1001, LineEnd: 1500})
+	var buf bytes.Buffer
+	writeDeadLetters(&buf, []contracts.DeadLetter{{
+		Topic: contracts.TopicLogsRaw, Key: "req-1", Value: chunkData, FailedAt: "2026-01-02T03:04:05Z",
+		Reason: contracts.SkipReasonPanic, Error: "index out of range", Attempts: 3,
+	}})
+	out := buf.String()
+	for _, want := range []string{
+		"2026-01-02T03:04:05Z  req-1  panic, attempt 3 of 3 (poison)",
+		`job "unit" chunk 3, lines 1001-1500`,
+		"    index out of range",
+		"1 dead letters",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("writeDeadLetters() output missing %q:\n%s", want, out)
+		}
+	}
+}
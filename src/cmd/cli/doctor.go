@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/config"
+	"destill-agent/src/contracts"
+)
+
+// doctorCmd checks the settings in effect and prints what to fix
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check tokens, the broker, its topics, and the database",
+	Long: `Checks the settings in effect, from the config files and the environment:
+makes a cheap authenticated request to each CI provider with a token set,
+checks that the Redpanda brokers (or NATS or gRPC server) and the database are
+reachable, and that the topics of distributed mode exist. Prints a checklist
+with a hint for each check that failed.
+
+Exits with status 1 when any check fails. Warnings, such as missing topics the
+broker would create on first use, don't fail it.
+
+Examples:
+  destill doctor
+  destill doctor --json`,
+	Args: cobra.NoArgs,
+	// A broken config file is reported as a failed check instead
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		_, sources, configErr := config.Setup()
+		checks := runDoctor(cmd.Context(), sources, configErr)
+
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		if jsonOutput {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(checks); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			printDoctor(os.Stdout, checks)
+		}
+		for _, c := range checks {
+			if c.Status == doctorFail {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// Statuses of a doctor check
+const (
+	doctorPass = "pass"
+	doctorFail = "fail"
+	doctorWarn = "warn"
+	doctorSkip = "skip"
+)
+
+// doctorCheck is one line of the doctor's checklist
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Hint   string `json:"hint,omitempty"` // How to fix a failure or warning
+}
+
+// doctorTopics are the topics distributed mode needs (see docker/README.md)
+var doctorTopics = []string{
+	contracts.TopicRequests,
+	contracts.TopicLogsRaw,
+	contracts.TopicAnalysisFindings,
+	contracts.TopicLogsDLQ,
+	contracts.TopicControl,
+}
+
+// listTopics lists the topics on the Redpanda brokers, replaced in tests
+var listTopics = func(ctx context.Context, brokers []string) ([]string, error) {
+	brk, err := broker.NewRedpandaBroker(brokers)
+	if err != nil {
+		return nil, err
+	}
+	defer brk.Close()
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return brk.Topics(ctx)
+}
+
+// runDoctor runs the checks against the environment, which config.Setup
+// has filled in from sources unless it failed with configErr
+func runDoctor(ctx context.Context, sources []string, configErr error) []doctorCheck {
+	check := doctorCheck{Name: "Config file", Status: doctorPass, Detail: strings.Join(sources, ", ")}
+	switch {
+	case configErr != nil:
+		check.Status, check.Detail, check.Hint = doctorFail, configErr.Error(), "Fix the file, or run destill config lint to see what's wrong"
+	case len(sources) == 0:
+		check.Status, check.Detail = doctorSkip, "none, using the environment only"
+	}
+
+	checks := []doctorCheck{check}
+	checks = append(checks, doctorProviders(ctx)...)
+	checks = append(checks, doctorBroker(ctx)...)
+	return append(checks, doctorDatabase(ctx))
+}
+
+// doctorProviders checks the token of each provider that has one
+func doctorProviders(ctx context.Context) []doctorCheck {
+	cfg := &config.File{JenkinsURL: strings.TrimSpace(os.Getenv("JENKINS_URL"))}
+	var checks []doctorCheck
+	for _, p := range initProviders {
+		token := p.token(&cfg.Tokens)
+		if *token = os.Getenv(p.env); *token == "" {
+			continue
+		}
+		check := doctorCheck{Name: p.label, Status: doctorPass, Detail: maskSecret(*token)}
+		if err := checkProvider(ctx, p.name, cfg); err != nil {
+			check.Status, check.Detail, check.Hint = doctorFail, err.Error(), p.hint+", then export "+p.env
+		}
+		checks = append(checks, check)
+	}
+
+	cfg.Tokens.JenkinsUser, cfg.Tokens.JenkinsToken = os.Getenv("JENKINS_USER"), os.Getenv("JENKINS_TOKEN")
+	switch {
+	case cfg.Tokens.JenkinsToken == "" && cfg.JenkinsURL == "":
+	case cfg.JenkinsURL == "":
+		checks = append(checks, doctorCheck{Name: "Jenkins", Status: doctorWarn,
+			Detail: "JENKINS_TOKEN is set but JENKINS_URL isn't", Hint: "Export JENKINS_URL to check the token"})
+	default:
+		check := doctorCheck{Name: "Jenkins", Status: doctorPass, Detail: cfg.JenkinsURL}
+		if err := checkProvider(ctx, "jenkins", cfg); err != nil {
+			check.Status, check.Detail = doctorFail, err.Error()
+			check.Hint = "Create an API token on your Jenkins user's Configure page, then export JENKINS_USER and JENKINS_TOKEN"
+		}
+		checks = append(checks, check)
+	}
+
+	if len(checks) == 0 {
+		var envs []string
+		for _, p := range initProviders {
+			envs = append(envs, p.env)
+		}
+		checks = append(checks, doctorCheck{Name: "CI provider tokens", Status: doctorFail, Detail: "no token is set",
+			Hint: "Run destill init, or export " + strings.Join(envs, ", ") + " or JENKINS_TOKEN"})
+	}
+	return checks
+}
+
+// doctorBroker checks the broker or server of the configured transport and,
+// for Redpanda, its topics
+func doctorBroker(ctx context.Context) []doctorCheck {
+	transport, grpcAddr, err := config.TransportFromEnv()
+	if err != nil {
+		return []doctorCheck{{Name: "Transport", Status: doctorFail, Detail: err.Error(),
+			Hint: "Set DESTILL_TRANSPORT to redpanda, nats, grpc, or sqs, with its address"}}
+	}
+
+	switch transport {
+	case config.TransportNATS:
+		natsURL := strings.TrimSpace(os.Getenv("NATS_URL"))
+		check := doctorCheck{Name: "NATS server", Status: doctorPass, Detail: natsURL}
+		if err := checkNATS(ctx, natsURL); err != nil {
+			check.Status, check.Detail, check.Hint = doctorFail, err.Error(), "Start NATS (docker compose up nats), or fix NATS_URL"
+		}
+		return []doctorCheck{check}
+	case config.TransportGRPC:
+		check := doctorCheck{Name: "gRPC server", Status: doctorPass, Detail: grpcAddr}
+		if err := checkAddress(ctx, grpcAddr); err != nil {
+			check.Status, check.Detail, check.Hint = doctorFail, err.Error(), "Start destill grpc-server, or fix DESTILL_GRPC_ADDR"
+		}
+		return []doctorCheck{check}
+	case config.TransportSQS:
+		return []doctorCheck{{Name: "SQS", Status: doctorSkip, Detail: "uses your AWS credentials and AWS_REGION, which aren't checked"}}
+	}
+
+	var brokers []string
+	for _, b := range strings.Split(os.Getenv("REDPANDA_BROKERS"), ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			brokers = append(brokers, b)
+		}
+	}
+	if len(brokers) == 0 {
+		return []doctorCheck{{Name: "Redpanda", Status: doctorSkip, Detail: "REDPANDA_BROKERS isn't set; only destill analyze works"}}
+	}
+
+	var checks []doctorCheck
+	reachable := false
+	for _, b := range brokers {
+		check := doctorCheck{Name: "Redpanda broker " + b, Status: doctorPass}
+		if err := checkAddress(ctx, b); err != nil {
+			check.Status, check.Detail, check.Hint = doctorFail, err.Error(), "Start Redpanda (docker compose up redpanda), or fix REDPANDA_BROKERS"
+		} else {
+			reachable = true
+		}
+		checks = append(checks, check)
+	}
+	if !reachable {
+		return checks
+	}
+
+	topics, err := listTopics(ctx, brokers)
+	if err != nil {
+		return append(checks, doctorCheck{Name: "Topics", Status: doctorFail, Detail: err.Error(),
+			Hint: "Check that the brokers are Kafka API addresses (port 9092 by default)"})
+	}
+	var missing []string
+	for _, t := range doctorTopics {
+		if !slices.Contains(topics, t) {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) > 0 {
+		return append(checks, doctorCheck{Name: "Topics", Status: doctorWarn,
+			Detail: "missing " + strings.Join(missing, ", ") + " (created on first use if the broker auto-creates topics)",
+			Hint:   "Create them with rpk topic create " + strings.Join(missing, " ") + ", with the partition counts in docker/README.md"})
+	}
+	return append(checks, doctorCheck{Name: "Topics", Status: doctorPass, Detail: fmt.Sprintf("all %d present", len(doctorTopics))})
+}
+
+// doctorDatabase checks the Postgres or SQLite database
+func doctorDatabase(ctx context.Context) doctorCheck {
+	if dsn := os.Getenv("POSTGRES_DSN"); dsn != "" {
+		check := doctorCheck{Name: "Postgres", Status: doctorPass}
+		if err := checkDatabase(ctx, dsn, ""); err != nil {
+			check.Status, check.Detail, check.Hint = doctorFail, err.Error(), "Start Postgres (docker compose up postgres), or fix POSTGRES_DSN"
+		}
+		return check
+	}
+	if path := strings.TrimSpace(os.Getenv("DESTILL_SQLITE_PATH")); path != "" {
+		check := doctorCheck{Name: "SQLite", Status: doctorPass, Detail: path}
+		if err := checkDatabase(ctx, "", path); err != nil {
+			check.Status, check.Detail, check.Hint = doctorFail, err.Error(), "Check that DESTILL_SQLITE_PATH's directory exists and is writable"
+		}
+		return check
+	}
+	return doctorCheck{Name: "Database", Status: doctorSkip, Detail: "neither POSTGRES_DSN nor DESTILL_SQLITE_PATH is set; distributed mode needs one"}
+}
+
+// printDoctor prints the checklist and a count of its results
+func printDoctor(w io.Writer, checks []doctorCheck) {
+	marks := map[string]string{doctorPass: "✅", doctorFail: "❌", doctorWarn: "⚠️ ", doctorSkip: "➖"}
+	counts := make(map[string]int)
+	for _, c := range checks {
+		counts[c.Status]++
+		line := fmt.Sprintf("%s %s", marks[c.Status], c.Name)
+		if c.Detail != "" {
+			line += ": " + c.Detail
+		}
+		fmt.Fprintln(w, line)
+		if c.Hint != "" {
+			fmt.Fprintf(w, "   → %s\n", c.Hint)
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d failed, %d warnings, %d skipped\n",
+		counts[doctorPass], counts[doctorFail], counts[doctorWarn], counts[doctorSkip])
+}
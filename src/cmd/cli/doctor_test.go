@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"destill-agent/src/config"
+	"destill-agent/src/contracts"
+)
+
+func TestRunDoctor(t *testing.T) {
+	for _, env := range []string{"BUILDKITE_API_TOKEN", "GITHUB_TOKEN", "GITLAB_TOKEN", "CIRCLECI_TOKEN", "JENKINS_URL", "JENKINS_USER", "JENKINS_TOKEN", "DESTILL_TRANSPORT", "NATS_URL", "REDPANDA_BROKERS", "POSTGRES_DSN", "DESTILL_SQLITE_PATH"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("BUILDKITE_API_TOKEN", "bk-token-rejected")
+	t.Setenv("GITHUB_TOKEN", "ghp_works")
+	t.Setenv("REDPANDA_BROKERS", "localhost:9092, localhost:9093")
+	t.Setenv("POSTGRES_DSN", "postgres://destill@localhost/destill")
+
+	defer func(orig func(context.Context, string, *config.File) error) { checkProvider = orig }(checkProvider)
+	defer func(orig func(context.Context, string) error) { checkAddress = orig }(checkAddress)
+	defer func(orig func(context.Context, string, string) error) { checkDatabase = orig }(checkDatabase)
+	defer func(orig func(context.Context, []string) ([]string, error)) { listTopics = orig }(listTopics)
+	checkProvider = func(_ context.Context, name string, cfg *config.File) error {
+		if name == "buildkite" {
+			return errors.New("the token was rejected (HTTP 401)")
+		}
+		return nil
+	}
+	checkAddress = func(_ context.Context, address string) error {
+		if address == "localhost:9093" {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	checkDatabase = func(context.Context, string, string) error { return nil }
+	listTopics = func(context.Context, []string) ([]string, error) {
+		return []string{contracts.TopicRequests, contracts.TopicLogsRaw, contracts.TopicAnalysisFindings}, nil
+	}
+
+	checks := runDoctor(context.Background(), nil, nil)
+	statuses := make(map[string]string)
+	for _, c := range checks {
+		statuses[c.Name] = c.Status
+	}
+	want := map[string]string{
+		"Config file":                    doctorSkip,
+		"Buildkite API token":            doctorFail,
+		"GitHub token":                   doctorPass,
+		"Redpanda broker localhost:9092": doctorPass,
+		"Redpanda broker localhost:9093": doctorFail,
+		"Topics":                         doctorWarn,
+		"Postgres":                       doctorPass,
+	}
+	for name, status := range want {
+		if statuses[name] != status {
+			t.Errorf("%s = %q, want %q", name, statuses[name], status)
+		}
+	}
+	if len(checks) != len(want) {
+		t.Errorf("got %d checks, want %d: %+v", len(checks), len(want), checks)
+	}
+
+	var out bytes.Buffer
+	printDoctor(&out, checks)
+	for _, want := range []string{
+		"❌ Buildkite API token: the token was rejected",
+		"→ Create an API access token with the read_builds and read_build_logs scopes",
+		"missing destill.logs.dlq, destill.control",
+		"rpk topic create destill.logs.dlq destill.control",
+		"3 passed, 2 failed, 1 warnings, 1 skipped",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunDoctor_NothingSet(t *testing.T) {
+	for _, env := range []string{"BUILDKITE_API_TOKEN", "GITHUB_TOKEN", "GITLAB_TOKEN", "CIRCLECI_TOKEN", "JENKINS_URL", "JENKINS_USER", "JENKINS_TOKEN", "DESTILL_TRANSPORT", "NATS_URL", "REDPANDA_BROKERS", "POSTGRES_DSN", "DESTILL_SQLITE_PATH"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("JENKINS_TOKEN", "jenkins-token")
+
+	checks := runDoctor(context.Background(), nil, errors.New("~/.destill.yaml: unknown key \"tokenz\""))
+	got := make([]string, len(checks))
+	for i, c := range checks {
+		got[i] = c.Name + "=" + c.Status
+	}
+	if want := "Config file=fail,Jenkins=warn,Redpanda=skip,Database=skip"; strings.Join(got, ",") != want {
+		t.Errorf("checks = %s, want %s", strings.Join(got, ","), want)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/rules"
+)
+
+// explainCmd runs the analyzer on a single pasted log line
+var explainCmd = &cobra.Command{
+	Use:   "explain <log-line>",
+	Short: "Explain how the analyzer scores a single log line",
+	Long: `Runs severity detection, normalization, and confidence scoring on a single
+log line and prints which patterns matched and how each changed the score.
+
+This is the fastest way to debug why a line was or wasn't flagged as a finding.
+
+Use --exit-status to simulate the job outcome adjustment:
+  0        - passed job (findings are penalized)
+  non-zero - failed job (findings are boosted)
+
+Examples:
+  destill explain "ERROR: connection refused to db:5432"
+  destill explain "Build finished with 0 errors" --exit-status 0
+  destill explain "npm ERR! code ELIFECYCLE" --json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exitStatus, _ := cmd.Flags().GetString("exit-status")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		ruleSet, err := rules.LoadRuleSet()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		exp := analyze.ExplainLine(args[0], exitStatus, ruleSet)
+
+		if jsonOutput {
+			output, err := json.MarshalIndent(exp, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to marshal explanation: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(output))
+			return
+		}
+
+		printExplanation(exp)
+	},
+}
+
+// printExplanation writes a human-readable explanation to stdout.
+func printExplanation(exp analyze.Explanation) {
+	shortHash := exp.MessageHash
+	if len(shortHash) > 12 {
+		shortHash = shortHash[:12]
+	}
+
+	fmt.Printf("Line:       %s\n", exp.Line)
+	if exp.SeverityRule != "" {
+		fmt.Printf("Severity:   %s (set by %q)\n", exp.Severity, exp.SeverityRule)
+	} else {
+		fmt.Printf("Severity:   %s\n", exp.Severity)
+	}
+	fmt.Printf("Normalized: %s\n", exp.NormalizedMsg)
+	fmt.Printf("Hash:       %s\n", shortHash)
+
+	fmt.Println("\nPattern matches:")
+	fmt.Printf("  %+.2f  base score\n", 0.5)
+	if len(exp.Matches) == 0 {
+		fmt.Println("         (no patterns matched)")
+	}
+	for _, m := range exp.Matches {
+		if m.User {
+			fmt.Printf("  %+.2f  %s (user)\n", m.Delta, m.Name)
+		} else {
+			fmt.Printf("  %+.2f  %s\n", m.Delta, m.Name)
+		}
+	}
+	fmt.Printf("  = %.2f (capped to 0.00-1.00)\n", exp.PatternScore)
+
+	if exp.JobAdjustment != "" {
+		fmt.Printf("\nJob outcome: %s → %.2f\n", exp.JobAdjustment, exp.ConfidenceScore)
+	}
+
+	if exp.SuppressedBy != "" {
+		fmt.Printf("\nSuppressed by: %s\n", exp.SuppressedBy)
+	}
+
+	fmt.Printf("\nConfidence: %.2f (threshold %.2f)\n", exp.ConfidenceScore, analyze.MinConfidence)
+	if exp.Flagged {
+		fmt.Printf("✅ Flagged: %s\n", exp.Reason)
+	} else {
+		fmt.Printf("❌ Not flagged: %s\n", exp.Reason)
+	}
+}
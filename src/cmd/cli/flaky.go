@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/daemon"
+	"destill-agent/src/flaky"
+	"destill-agent/src/githubactions"
+)
+
+// flakyCmd suggests flaky tests to quarantine
+var flakyCmd = &cobra.Command{
+	Use:   "flaky",
+	Short: "Suggest flaky tests to quarantine, with links to the builds where they flaked",
+	Long: `Finds the failing test of each finding (go test, pytest, Gradle, Maven,
+RSpec, Jest, and dotnet test output) and reports tests that fail intermittently
+across the builds of a pipeline branch:
+
+  - the test failed but its job still passed (retried within the job)
+  - the test failed, then didn't fail in another analyzed build, then failed again
+
+Tests failing in every build since some point are broken rather than flaky and
+are not reported.
+
+With --pr owner/repo, opens a GitHub pull request adding the suspects to a
+quarantine file (--quarantine-file, one test per line) for the test runner to
+skip. Tests already in the file are left out, so re-runs don't open duplicates.
+
+With --schedule, runs until interrupted and reports on every tick. Schedules
+are "@every <duration>", "@hourly", "@daily", "@weekly", or a five-field cron
+expression.
+
+Findings are read from Postgres (POSTGRES_DSN), or with --input from files
+written by 'destill analyze --json'.
+
+Examples:
+  destill flaky
+  destill flaky --since 336h --min-builds 3
+  destill flaky --input build1.json --input build2.json
+  destill flaky --schedule @weekly --webhook https://hooks.slack.com/services/...
+  destill flaky --schedule "0 9 * * 1" --pr owner/repo
+
+Environment variables:
+  POSTGRES_DSN           - Required without --input. Postgres connection string
+  GITHUB_TOKEN           - Required with --pr. Needs contents and pull request write access
+  DESTILL_NOTIFY_WEBHOOK - Optional. Default for --webhook`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, _ := cmd.Flags().GetDuration("since")
+		inputs, _ := cmd.Flags().GetStringSlice("input")
+		minBuilds, _ := cmd.Flags().GetInt("min-builds")
+		top, _ := cmd.Flags().GetInt("top")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		scheduleSpec, _ := cmd.Flags().GetString("schedule")
+		webhook, _ := cmd.Flags().GetString("webhook")
+		prRepo, _ := cmd.Flags().GetString("pr")
+		quarantineFile, _ := cmd.Flags().GetString("quarantine-file")
+		base, _ := cmd.Flags().GetString("base")
+		if webhook == "" {
+			webhook = os.Getenv("DESTILL_NOTIFY_WEBHOOK")
+		}
+
+		run := flakyRun{
+			since:   since,
+			inputs:  inputs,
+			opts:    flaky.Options{MinBuilds: minBuilds},
+			top:     top,
+			json:    jsonOutput,
+			webhook: webhook,
+		}
+		if prRepo != "" {
+			owner, repo, ok := strings.Cut(prRepo, "/")
+			if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+				fmt.Fprintf(os.Stderr, "Error: --pr must be owner/repo, got %q\n", prRepo)
+				os.Exit(1)
+			}
+			token := os.Getenv("GITHUB_TOKEN")
+			if token == "" {
+				fmt.Fprintln(os.Stderr, "Error: GITHUB_TOKEN environment variable is required with --pr")
+				os.Exit(1)
+			}
+			run.github = githubactions.NewClient(token)
+			run.pr = &flaky.PROptions{Owner: owner, Repo: repo, Path: quarantineFile, Base: base}
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if scheduleSpec == "" {
+			if err := run.report(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		schedule, err := daemon.ParseSchedule(scheduleSpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --schedule: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("🕑 Reporting flaky tests on schedule %q\n", scheduleSpec)
+		for {
+			// A failed run is retried on the next tick
+			if err := run.report(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			next := schedule.Next(time.Now())
+			if next.IsZero() {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(next)):
+			}
+		}
+	},
+}
+
+// flakyRun is one configured 'destill flaky' report.
+type flakyRun struct {
+	since   time.Duration
+	inputs  []string
+	opts    flaky.Options
+	top     int
+	json    bool
+	webhook string
+
+	github *githubactions.Client
+	pr     *flaky.PROptions
+}
+
+// report loads findings, detects flaky tests, and prints, posts, and opens a
+// pull request as configured.
+func (r flakyRun) report(ctx context.Context) error {
+	var cards []contracts.TriageCard
+	var err error
+	if len(r.inputs) > 0 {
+		cards, err = readFindingFiles(r.inputs)
+	} else {
+		cards, err = readStoredFindings(ctx, time.Now().Add(-r.since))
+	}
+	if err != nil {
+		return err
+	}
+
+	suspects := flaky.Detect(cards, r.opts)
+	if r.top > 0 && len(suspects) > r.top {
+		suspects = suspects[:r.top]
+	}
+
+	if r.json {
+		output, err := json.MarshalIndent(suspects, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		fmt.Print(flaky.FormatReport(suspects))
+	}
+
+	if len(suspects) == 0 {
+		return nil
+	}
+	if r.webhook != "" {
+		if err := flaky.PostWebhook(ctx, r.webhook, suspects); err != nil {
+			return err
+		}
+	}
+	if r.pr != nil {
+		pr, err := flaky.OpenPR(ctx, r.github, *r.pr, suspects)
+		if err != nil {
+			return err
+		}
+		if pr == nil {
+			fmt.Fprintln(os.Stderr, "All suspects are already quarantined, no pull request opened")
+		} else {
+			fmt.Fprintf(os.Stderr, "🔀 Opened %s\n", pr.HTMLURL)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/grpcapi"
+	"destill-agent/src/pipeline"
+	"destill-agent/src/store"
+)
+
+// grpcServerCmd serves the gRPC API, the transport of grpc mode
+var grpcServerCmd = &cobra.Command{
+	Use:   "grpc-server",
+	Short: "Serve the gRPC API for agents and clients",
+	Long: `Serves the destill gRPC API (src/grpcapi/destill.proto), an alternative to
+Redpanda for deployments without a Kafka-compatible broker.
+
+Clients submit analysis requests with SubmitAnalysis and follow the results
+with StreamFindings. Agents started with DESTILL_TRANSPORT=grpc and
+DESTILL_GRPC_ADDR pointing here exchange their messages through the server;
+agents sharing a consumer group split the work between them. 'destill submit'
+and 'destill daemon' honor the same variables.
+
+Messages are relayed in memory and not retained: a request published while
+no ingest agent is connected is lost. With POSTGRES_DSN set, the server stores
+findings and log chunks in Postgres, as Redpanda Connect does in the
+Redpanda deployment, so 'destill view' works unchanged.
+
+Examples:
+  destill grpc-server
+  destill grpc-server --addr :50051
+
+Environment variables:
+  DESTILL_GRPC_ADDR - Optional. Default for --addr
+  POSTGRES_DSN      - Optional. Store findings and log chunks in Postgres`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		addr, _ := cmd.Flags().GetString("addr")
+		if !cmd.Flags().Changed("addr") && os.Getenv("DESTILL_GRPC_ADDR") != "" {
+			addr = os.Getenv("DESTILL_GRPC_ADDR")
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		msgBroker := broker.NewInMemoryBroker()
+		defer msgBroker.Close()
+
+		if dsn := os.Getenv("POSTGRES_DSN"); dsn != "" {
+			postgresStore, err := store.NewPostgresStore(ctx, dsn)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to connect to Postgres: %v\n", err)
+				os.Exit(1)
+			}
+			defer postgresStore.Close()
+
+			if err := pipeline.StoreFindings(msgBroker, ctx, postgresStore); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := pipeline.StoreChunks(msgBroker, ctx, postgresStore); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		fmt.Fprintf(os.Stderr, "destill gRPC server listening on %s\n", addr)
+		if err := grpcapi.Serve(ctx, addr, msgBroker); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"destill-agent/src/config"
+	"destill-agent/src/store"
+)
+
+// initCmd walks through first-time setup and writes the config file
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up tokens, the broker, and the database interactively",
+	Long: `Walks through first-time setup: asks for the CI provider tokens, offering
+the ones already set in the environment, and for the broker and database of
+distributed mode, checks that each one works, and writes them to the global
+config file (~/.destill.yaml, or DESTILL_CONFIG_FILE if set).
+
+Run it again to check or change the settings: the saved values are offered
+as defaults. Press Enter to keep the value in brackets, or type - to clear it.
+
+Examples:
+  destill init
+  destill init --file ./destill.yaml`,
+	Args: cobra.NoArgs,
+	// The config file may not exist or parse yet; init is what fixes it
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		path, _ := cmd.Flags().GetString("file")
+		if path == "" {
+			path = config.GlobalPath()
+		}
+		if path == "" {
+			fmt.Fprintf(os.Stderr, "Error: no home directory for ~/%s; pass --file\n", config.FileName)
+			os.Exit(1)
+		}
+		if err := runInit(cmd.Context(), os.Stdin, os.Stdout, path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// initProvider is a CI provider init asks a token for, and doctor checks
+type initProvider struct {
+	name  string // Provider name, as in provider.BuildRef
+	label string
+	env   string
+	token func(*config.Tokens) *string
+	hint  string // What a working token needs
+}
+
+var initProviders = []initProvider{
+	{"buildkite", "Buildkite API token", "BUILDKITE_API_TOKEN", func(t *config.Tokens) *string { return &t.Buildkite },
+		"Create an API access token with the read_builds and read_build_logs scopes at https://buildkite.com/user/api-access-tokens"},
+	{"github", "GitHub token", "GITHUB_TOKEN", func(t *config.Tokens) *string { return &t.GitHub },
+		"Create a personal access token with the repo scope at https://github.com/settings/tokens"},
+	{"gitlab", "GitLab token", "GITLAB_TOKEN", func(t *config.Tokens) *string { return &t.GitLab },
+		"Create a personal access token with the read_api scope in GitLab's user settings"},
+	{"circleci", "CircleCI token", "CIRCLECI_TOKEN", func(t *config.Tokens) *string { return &t.CircleCI },
+		"Create a personal API token at https://app.circleci.com/settings/user/tokens"},
+}
+
+// Connectivity checks, replaced in tests
+var (
+	// checkProvider makes an authenticated request to the provider's API
+	// with the token in cfg
+	checkProvider = func(ctx context.Context, name string, cfg *config.File) error {
+		req, err := providerCheckRequest(ctx, name, cfg)
+		if err != nil {
+			return err
+		}
+		resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			return fmt.Errorf("the token was rejected (HTTP %d)", resp.StatusCode)
+		case resp.StatusCode >= 300:
+			return fmt.Errorf("unexpected HTTP status %d from %s", resp.StatusCode, req.URL.Host)
+		}
+		return nil
+	}
+
+	// checkAddress opens a TCP connection to a broker or server
+	checkAddress = func(ctx context.Context, address string) error {
+		dialer := net.Dialer{Timeout: 5 * time.Second}
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	// checkDatabase opens the Postgres or SQLite database
+	checkDatabase = func(ctx context.Context, postgresDSN, sqlitePath string) error {
+		db, err := store.OpenPersistent(ctx, postgresDSN, sqlitePath)
+		if err != nil {
+			return err
+		}
+		return db.Close()
+	}
+)
+
+// providerCheckRequest returns a request for the provider's endpoint that
+// describes the token's own user, which any valid token may read
+func providerCheckRequest(ctx context.Context, name string, cfg *config.File) (*http.Request, error) {
+	endpoints := map[string]string{
+		"buildkite": "https://api.buildkite.com/v2/access-token",
+		"github":    "https://api.github.com/user",
+		"gitlab":    "https://gitlab.com/api/v4/user",
+		"circleci":  "https://circleci.com/api/v2/me",
+		"jenkins":   strings.TrimSuffix(cfg.JenkinsURL, "/") + "/me/api/json",
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoints[name], nil)
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "buildkite":
+		req.Header.Set("Authorization", "Bearer "+cfg.Tokens.Buildkite)
+	case "github":
+		req.Header.Set("Authorization", "Bearer "+cfg.Tokens.GitHub)
+	case "gitlab":
+		req.Header.Set("PRIVATE-TOKEN", cfg.Tokens.GitLab)
+	case "circleci":
+		req.Header.Set("Circle-Token", cfg.Tokens.CircleCI)
+	case "jenkins":
+		req.SetBasicAuth(cfg.Tokens.JenkinsUser, cfg.Tokens.JenkinsToken)
+	}
+	return req, nil
+}
+
+// initWizard asks the setup questions on in and reports on out
+type initWizard struct {
+	in     *bufio.Reader
+	out    io.Writer
+	failed int // Checks that failed
+}
+
+// runInit asks for the settings, starting from the config at path if there
+// is one, checks them, and writes them to path once confirmed
+func runInit(ctx context.Context, in io.Reader, out io.Writer, path string) error {
+	w := &initWizard{in: bufio.NewReader(in), out: out}
+
+	cfg, err := config.LoadFile(path)
+	switch {
+	case err == nil:
+		fmt.Fprintf(out, "Updating %s. Press Enter to keep the value in brackets, or type - to clear it.\n", path)
+	case errors.Is(err, os.ErrNotExist):
+		cfg = &config.File{}
+		fmt.Fprintf(out, "Setting up destill in %s. Press Enter to skip a question or use the value in brackets.\n", path)
+	default:
+		fmt.Fprintf(out, "⚠️  %v\n", err)
+		if !w.confirm("Replace it with a new config?", false) {
+			return errors.New("setup canceled; fix the config file or remove it")
+		}
+		cfg = &config.File{}
+	}
+
+	fmt.Fprintln(out, "\nCI providers: a token for each provider whose builds you analyze.")
+	for _, p := range initProviders {
+		token := p.token(&cfg.Tokens)
+		*token = w.ask(p.label, *token, p.env, true)
+		if *token != "" {
+			w.report(p.label, checkProvider(ctx, p.name, cfg))
+		}
+	}
+	cfg.JenkinsURL = w.ask("Jenkins URL", cfg.JenkinsURL, "JENKINS_URL", false)
+	if cfg.JenkinsURL != "" {
+		cfg.Tokens.JenkinsUser = w.ask("Jenkins user", cfg.Tokens.JenkinsUser, "JENKINS_USER", false)
+		cfg.Tokens.JenkinsToken = w.ask("Jenkins API token", cfg.Tokens.JenkinsToken, "JENKINS_TOKEN", true)
+		w.report("Jenkins", checkProvider(ctx, "jenkins", cfg))
+	}
+
+	fmt.Fprintln(out, "\nDistributed mode (destill submit and view, with separate agents) needs a")
+	fmt.Fprintln(out, "broker and a database. Skip both to analyze builds locally only.")
+	w.askBroker(ctx, cfg)
+	w.askDatabase(ctx, cfg)
+
+	if w.failed > 0 {
+		fmt.Fprintf(out, "\n%d check(s) failed. You can save anyway and run destill init again once fixed.\n", w.failed)
+	}
+	if !w.confirm(fmt.Sprintf("\nSave to %s?", path), true) {
+		return errors.New("setup canceled; nothing was written")
+	}
+	if err := writeInitConfig(path, cfg); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "✅ Saved %s\n", path)
+	fmt.Fprintln(out, "Next: destill analyze <build-url>, or destill config show to review the settings")
+	return nil
+}
+
+// askBroker asks for the transport and its broker or server address
+func (w *initWizard) askBroker(ctx context.Context, cfg *config.File) {
+	transports := []string{config.TransportRedpanda, config.TransportNATS, config.TransportGRPC, config.TransportSQS}
+	transport := w.ask("Transport ("+strings.Join(transports, ", ")+")", cfg.Transport, "DESTILL_TRANSPORT", false)
+	switch transport {
+	case "", config.TransportRedpanda:
+		cfg.Transport = transport
+		brokers := w.ask("Redpanda brokers, comma-separated", strings.Join(cfg.Brokers, ","), "REDPANDA_BROKERS", false)
+		cfg.Brokers = nil
+		for _, broker := range strings.Split(brokers, ",") {
+			if broker = strings.TrimSpace(broker); broker != "" {
+				cfg.Brokers = append(cfg.Brokers, broker)
+				w.report("Redpanda broker "+broker, checkAddress(ctx, broker))
+			}
+		}
+	case config.TransportNATS:
+		cfg.Transport = transport
+		cfg.NATSURL = w.ask("NATS URL", cfg.NATSURL, "NATS_URL", false)
+		if cfg.NATSURL != "" {
+			w.report("NATS server", checkNATS(ctx, cfg.NATSURL))
+		}
+	case config.TransportGRPC:
+		cfg.Transport = transport
+		cfg.GRPCAddr = w.ask("destill grpc-server address (host:port)", cfg.GRPCAddr, "DESTILL_GRPC_ADDR", false)
+		if cfg.GRPCAddr != "" {
+			w.report("gRPC server", checkAddress(ctx, cfg.GRPCAddr))
+		}
+	case config.TransportSQS:
+		cfg.Transport = transport
+		cfg.SQSPrefix = w.ask("SQS topic prefix", cfg.SQSPrefix, "DESTILL_SQS_PREFIX", false)
+		fmt.Fprintln(w.out, "  SQS uses your AWS credentials and AWS_REGION, which aren't checked here")
+	default:
+		fmt.Fprintf(w.out, "  Unknown transport %q, skipped (expected %s)\n", transport, strings.Join(transports, ", "))
+	}
+}
+
+// checkNATS checks the server of a NATS URL, on the default port if the URL
+// has none
+func checkNATS(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid NATS URL %q (expected e.g. nats://localhost:4222)", rawURL)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	return checkAddress(ctx, host)
+}
+
+// askDatabase asks for the Postgres DSN, or a SQLite path without one
+func (w *initWizard) askDatabase(ctx context.Context, cfg *config.File) {
+	cfg.PostgresDSN = w.ask("Postgres DSN", cfg.PostgresDSN, "POSTGRES_DSN", true)
+	if cfg.PostgresDSN != "" {
+		cfg.SQLitePath = ""
+		w.report("Postgres", checkDatabase(ctx, cfg.PostgresDSN, ""))
+		return
+	}
+	cfg.SQLitePath = w.ask("SQLite path, for agents all on this machine", cfg.SQLitePath, "DESTILL_SQLITE_PATH", false)
+	if cfg.SQLitePath != "" {
+		w.report("SQLite", checkDatabase(ctx, "", cfg.SQLitePath))
+	}
+}
+
+// ask prompts for a value, offering current, or else the value of the
+// environment variable env, as the default. Secrets are shown masked.
+func (w *initWizard) ask(label, current, env string, secret bool) string {
+	value, source := current, "saved"
+	if value == "" {
+		value, source = os.Getenv(env), "from "+env
+	}
+	prompt := "  " + label
+	switch {
+	case value != "" && secret:
+		prompt += fmt.Sprintf(" [%s, %s]", maskSecret(value), source)
+	case value != "":
+		prompt += fmt.Sprintf(" [%s]", value)
+	}
+	fmt.Fprint(w.out, prompt+": ")
+
+	answer := w.readLine()
+	switch answer {
+	case "":
+		return value
+	case "-":
+		return ""
+	}
+	return answer
+}
+
+// confirm asks a yes/no question, returning def when the answer is empty
+func (w *initWizard) confirm(question string, def bool) bool {
+	choices := "[y/N]"
+	if def {
+		choices = "[Y/n]"
+	}
+	fmt.Fprintf(w.out, "%s %s ", question, choices)
+	switch strings.ToLower(w.readLine()) {
+	case "":
+		return def
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// readLine reads one answer, "" at the end of the input
+func (w *initWizard) readLine() string {
+	line, err := w.in.ReadString('\n')
+	if err != nil && line == "" {
+		// Out of answers: end the prompt's line
+		fmt.Fprintln(w.out)
+	}
+	return strings.TrimSpace(line)
+}
+
+// report prints the result of a check
+func (w *initWizard) report(what string, err error) {
+	if err != nil {
+		w.failed++
+		fmt.Fprintf(w.out, "  ❌ %s: %v\n", what, err)
+		return
+	}
+	fmt.Fprintf(w.out, "  ✅ %s works\n", what)
+}
+
+// maskSecret shows only the last 4 characters of a token
+func maskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// writeInitConfig writes cfg to path, readable only by the user since it
+// holds tokens
+func writeInitConfig(path string, cfg *config.File) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	data = append([]byte("# Written by destill init; see destill config show\n"), data...)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"destill-agent/src/config"
+)
+
+func TestRunInit(t *testing.T) {
+	for _, env := range []string{"BUILDKITE_API_TOKEN", "GITHUB_TOKEN", "GITLAB_TOKEN", "CIRCLECI_TOKEN", "JENKINS_URL", "DESTILL_TRANSPORT", "REDPANDA_BROKERS", "POSTGRES_DSN", "DESTILL_SQLITE_PATH"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("GITHUB_TOKEN", "ghp_fromtheenvironment")
+
+	defer func(orig func(context.Context, string, *config.File) error) { checkProvider = orig }(checkProvider)
+	defer func(orig func(context.Context, string) error) { checkAddress = orig }(checkAddress)
+	defer func(orig func(context.Context, string, string) error) { checkDatabase = orig }(checkDatabase)
+	var checked []string
+	checkProvider = func(_ context.Context, name string, cfg *config.File) error {
+		checked = append(checked, name)
+		if name == "buildkite" {
+			return errors.New("the token was rejected (HTTP 401)")
+		}
+		return nil
+	}
+	checkAddress = func(_ context.Context, address string) error {
+		checked = append(checked, address)
+		return nil
+	}
+	checkDatabase = func(_ context.Context, dsn, sqlitePath string) error {
+		checked = append(checked, "db:"+dsn+sqlitePath)
+		return nil
+	}
+
+	path := filepath.Join(t.TempDir(), "destill.yaml")
+	// Buildkite token, GitHub from the environment, skip GitLab, CircleCI,
+	// and Jenkins, Redpanda with two brokers, no Postgres, SQLite, save
+	answers := strings.Join([]string{"bk-token", "", "", "", "", "", "localhost:9092, localhost:9093", "", "/tmp/destill.db", ""}, "\n") + "\n"
+	var out bytes.Buffer
+	if err := runInit(context.Background(), strings.NewReader(answers), &out, path); err != nil {
+		t.Fatalf("runInit() error = %v\n%s", err, out.String())
+	}
+
+	for _, want := range []string{"❌ Buildkite API token: the token was rejected", "✅ GitHub token works", "[****ment, from GITHUB_TOKEN]", "1 check(s) failed", "✅ Saved"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if got, want := strings.Join(checked, ","), "buildkite,github,localhost:9092,localhost:9093,db:/tmp/destill.db"; got != want {
+		t.Errorf("checked %s, want %s", got, want)
+	}
+
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatalf("written config doesn't load: %v", err)
+	}
+	if cfg.Tokens.Buildkite != "bk-token" || cfg.Tokens.GitHub != "ghp_fromtheenvironment" || len(cfg.Brokers) != 2 || cfg.SQLitePath != "/tmp/destill.db" {
+		t.Errorf("written config = %+v", cfg)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0o600 {
+		t.Errorf("config mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// Run again: the saved values are the defaults, and - clears one
+	checked = nil
+	answers = strings.Join([]string{"-", "", "", "", "", "", "", "", "", ""}, "\n") + "\n"
+	out.Reset()
+	if err := runInit(context.Background(), strings.NewReader(answers), &out, path); err != nil {
+		t.Fatalf("second runInit() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Updating "+path) {
+		t.Errorf("second run doesn't say it updates the config:\n%s", out.String())
+	}
+	cfg, _ = config.LoadFile(path)
+	if cfg.Tokens.Buildkite != "" || cfg.Tokens.GitHub != "ghp_fromtheenvironment" || len(cfg.Brokers) != 2 {
+		t.Errorf("updated config = %+v", cfg)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/config"
+	"destill-agent/src/provider"
+	"destill-agent/src/store"
+)
+
+// listCmd prints the recent requests in the database
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recent analysis requests (distributed mode)",
+	Long: `Lists the requests in Postgres (or SQLite), newest first: each one's status,
+findings, when it was submitted and how long it took, and its build.
+
+--pipeline takes a pipeline URL, an org/pipeline slug, or an alias from
+DESTILL_PIPELINE_ALIASES, and keeps the requests for that pipeline's builds.
+
+Examples:
+  destill list
+  destill list --since 24h --failed-only
+  destill list --pipeline myorg/api --limit 50
+  destill list --json
+
+Environment variables:
+  POSTGRES_DSN        - Postgres connection string
+  DESTILL_SQLITE_PATH - SQLite database of the agents, without POSTGRES_DSN`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		filter := store.RequestFilter{}
+		filter.Limit, _ = cmd.Flags().GetInt("limit")
+		if since, _ := cmd.Flags().GetDuration("since"); since > 0 {
+			filter.Since = time.Now().Add(-since)
+		}
+		if failedOnly, _ := cmd.Flags().GetBool("failed-only"); failedOnly {
+			filter.Statuses = []string{store.RequestFailed}
+		}
+
+		pipeline, _ := cmd.Flags().GetString("pipeline")
+		if pipeline != "" {
+			aliases, err := config.LoadAliasesFromEnv()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			ref, err := provider.ParsePipeline(pipeline, aliases)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", provider.WrapError(err))
+				os.Exit(1)
+			}
+			pipeline = provider.PipelineName(ref)
+		}
+
+		ctx := cmd.Context()
+		db, err := store.OpenPersistent(ctx, os.Getenv("POSTGRES_DSN"), os.Getenv("DESTILL_SQLITE_PATH"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		lister, ok := db.(store.RequestLister)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Error: the database doesn't support listing requests")
+			os.Exit(1)
+		}
+		requests, err := listRequests(ctx, lister, filter, pipeline)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			out := make([]statusJSON, 0, len(requests))
+			for _, status := range requests {
+				out = append(out, newStatusJSON(status))
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(out); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		writeRequestList(os.Stdout, requests)
+	},
+}
+
+// listRequests returns the requests matching filter and, unless it's empty,
+// for builds of pipeline. The requests table doesn't keep the pipeline, so
+// it's read from each build URL, and the limit applied after.
+func listRequests(ctx context.Context, lister store.RequestLister, filter store.RequestFilter, pipeline string) ([]store.RequestStatus, error) {
+	if pipeline == "" {
+		return lister.ListRequests(ctx, filter)
+	}
+	limit := filter.Limit
+	filter.Limit = 0
+	requests, err := lister.ListRequests(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var matching []store.RequestStatus
+	for _, status := range requests {
+		ref, err := provider.ParseURL(status.BuildURL)
+		if err != nil || provider.PipelineName(ref) != pipeline {
+			continue
+		}
+		matching = append(matching, status)
+		if len(matching) == limit {
+			break
+		}
+	}
+	return matching, nil
+}
+
+// writeRequestList prints one line per request
+func writeRequestList(w io.Writer, requests []store.RequestStatus) {
+	if len(requests) == 0 {
+		fmt.Fprintln(w, "No requests found")
+		return
+	}
+	fmt.Fprintf(w, "%-26s  %-10s  %8s  %-16s  %8s  %s\n", "REQUEST", "STATUS", "FINDINGS", "SUBMITTED", "TOOK", "BUILD")
+	for _, status := range requests {
+		submitted, took := "-", "-"
+		if !status.CreatedAt.IsZero() {
+			submitted = status.CreatedAt.Local().Format("2006-01-02 15:04")
+			if !status.CompletedAt.IsZero() {
+				took = status.CompletedAt.Sub(status.CreatedAt).Round(time.Second).String()
+			}
+		}
+		fmt.Fprintf(w, "%-26s  %-10s  %8d  %-16s  %8s  %s\n",
+			status.RequestID, status.Status, status.FindingsCount, submitted, took, status.BuildURL)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/store"
+)
+
+// fakeLister returns its requests, cut to the filter's limit
+type fakeLister []store.RequestStatus
+
+func (f fakeLister) ListRequests(_ context.Context, filter store.RequestFilter) ([]store.RequestStatus, error) {
+	if filter.Limit > 0 && filter.Limit < len(f) {
+		return f[:filter.Limit], nil
+	}
+	return f, nil
+}
+
+func TestListRequests_Pipeline(t *testing.T) {
+	lister := fakeLister{
+		{RequestID: "req-4", BuildURL: "https://buildkite.com/org/web/builds/9"},
+		{RequestID: "req-3", BuildURL: "https://buildkite.com/org/api/builds/8"},
+		{RequestID: "req-2", BuildURL: "https://buildkite.com/org/web/builds/8"},
+		{RequestID: "req-1", BuildURL: "https://buildkite.com/org/api/builds/7"},
+	}
+
+	ids := func(requests []store.RequestStatus) string {
+		var ids []string
+		for _, r := range requests {
+			ids = append(ids, r.RequestID)
+		}
+		return strings.Join(ids, ",")
+	}
+	requests, err := listRequests(context.Background(), lister, store.RequestFilter{Limit: 2}, "")
+	if err != nil || ids(requests) != "req-4,req-3" {
+		t.Errorf("listRequests(limit 2) = %s, %v, want req-4,req-3", ids(requests), err)
+	}
+	// The limit counts the pipeline's requests, not all of them
+	requests, err = listRequests(context.Background(), lister, store.RequestFilter{Limit: 2}, "org/api")
+	if err != nil || ids(requests) != "req-3,req-1" {
+		t.Errorf("listRequests(org/api, limit 2) = %s, %v, want req-3,req-1", ids(requests), err)
+	}
+}
+
+func TestWriteRequestList(t *testing.T) {
+	created := time.Date(2026, 3, 2, 12, 0, 0, 0, time.Local)
+	requests := []store.RequestStatus{
+		{RequestID: "req-2", BuildURL: "https://buildkite.com/org/api/builds/8", Status: store.RequestProcessing, CreatedAt: created},
+		{RequestID: "req-1", BuildURL: "https://buildkite.com/org/api/builds/7", Status: store.RequestCompleted, FindingsCount: 12,
+			CreatedAt: created, CompletedAt: created.Add(92 * time.Second)},
+	}
+
+	var buf bytes.Buffer
+	writeRequestList(&buf, requests)
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasPrefix(lines[0], "REQUEST") {
+		t.Errorf("header = %q", lines[0])
+	}
+	for _, want := range []string{"req-1", "completed", "12", "2026-03-02 12:00", "1m32s", "org/api/builds/7"} {
+		if !strings.Contains(lines[2], want) {
+			t.Errorf("line %q missing %q", lines[2], want)
+		}
+	}
+
+	buf.Reset()
+	writeRequestList(&buf, nil)
+	if got := buf.String(); got != "No requests found\n" {
+		t.Errorf("empty list = %q", got)
+	}
+}
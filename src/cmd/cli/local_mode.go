@@ -1,19 +1,23 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
-	"sort"
 	"time"
 
 	"destill-agent/src/broker"
+	"destill-agent/src/config"
 	"destill-agent/src/contracts"
+	"destill-agent/src/events"
 	"destill-agent/src/pipeline"
+	"destill-agent/src/platform"
 	"destill-agent/src/provider"
+	"destill-agent/src/ranking"
 	"destill-agent/src/tui"
 )
 
@@ -31,29 +35,35 @@ const (
 // LocalMode encapsulates the in-memory broker and agent lifecycle for local execution.
 // It provides a clean interface for starting agents, submitting analysis requests,
 // and managing the complete lifecycle of the local mode infrastructure.
+// Results are read from its event bus (see Events) rather than broker topics.
 type LocalMode struct {
 	broker broker.Broker
+	bus    *events.Bus
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
 // NewLocalMode creates and initializes a new local mode instance.
 // Agents are started immediately and begin listening for requests.
+// The agents stop when ctx is cancelled or Close is called.
 // Returns error if pipeline initialization fails.
-func NewLocalMode() (*LocalMode, error) {
+func NewLocalMode(ctx context.Context) (*LocalMode, error) {
 	msgBroker := broker.NewInMemoryBroker()
-	ctx, cancel := context.WithCancel(context.Background())
+	bus := events.NewBus()
+	ctx, cancel := context.WithCancel(ctx)
 
 	// Start ingest and analyze agents as goroutines.
 	// Subscriptions happen synchronously to avoid race conditions.
-	if err := pipeline.Start(msgBroker, ctx); err != nil {
+	if err := pipeline.StartLocal(msgBroker, ctx, bus); err != nil {
 		cancel()
+		bus.Close()
 		msgBroker.Close()
 		return nil, fmt.Errorf("failed to start pipeline: %w", err)
 	}
 
 	return &LocalMode{
 		broker: msgBroker,
+		bus:    bus,
 		ctx:    ctx,
 		cancel: cancel,
 	}, nil
@@ -61,8 +71,9 @@ func NewLocalMode() (*LocalMode, error) {
 
 // SubmitAnalysis publishes an analysis request to the broker.
 // The ingest and analyze agents will process this request asynchronously.
-func (lm *LocalMode) SubmitAnalysis(buildURL string) (string, error) {
-	requestID, data, err := buildAnalysisRequest(buildURL)
+// opts may be nil to use the agent defaults.
+func (lm *LocalMode) SubmitAnalysis(buildURL string, opts *contracts.AnalysisOptions) (string, error) {
+	requestID, data, err := buildAnalysisRequest(buildURL, opts)
 	if err != nil {
 		return "", err
 	}
@@ -74,14 +85,28 @@ func (lm *LocalMode) SubmitAnalysis(buildURL string) (string, error) {
 	return requestID, nil
 }
 
-// Broker returns the underlying message broker for subscription purposes.
+// Broker returns the underlying message broker, for consumers of topics
+// the event bus doesn't carry, such as raw log chunks.
 func (lm *LocalMode) Broker() broker.Broker {
 	return lm.broker
 }
 
-// Close gracefully shuts down the agents and closes the broker.
+// Events returns the bus carrying findings, progress, and errors.
+func (lm *LocalMode) Events() *events.Bus {
+	return lm.bus
+}
+
+// Subscribe returns a channel of the events published from now on, until
+// the local mode is closed. Subscribe before submitting, so no finding is
+// missed.
+func (lm *LocalMode) Subscribe() (<-chan events.Event, error) {
+	return lm.bus.Subscribe(lm.ctx)
+}
+
+// Close gracefully shuts down the agents and closes the bus and broker.
 func (lm *LocalMode) Close() {
 	lm.cancel()
+	lm.bus.Close()
 	lm.broker.Close()
 }
 
@@ -91,49 +116,75 @@ func (lm *LocalMode) Close() {
 
 // displayTUI launches the interactive terminal UI.
 // If initialCards is provided (from cache), displays them immediately without streaming.
-// If initialCards is empty, streams live updates from the broker as analysis progresses.
-func displayTUI(msgBroker broker.Broker, initialCards []contracts.TriageCard) error {
-	// Show appropriate startup message
-	if len(initialCards) == 0 {
-		fmt.Println("🚀 Launching TUI (cards will stream in as they're analyzed)...")
-	} else {
+// If initialCards is empty, streams live updates from the bus as analysis progresses,
+// calling submit to publish the request once the TUI is listening.
+func displayTUI(bus *events.Bus, initialCards []contracts.TriageCard, submit func() error) error {
+	if len(initialCards) > 0 {
 		fmt.Println("🚀 Launching TUI with cached data...")
+		if err := submit(); err != nil {
+			return fmt.Errorf("failed to submit analysis: %w", err)
+		}
+		return tui.Start(initialCards)
 	}
 
-	// Brief pause to ensure log output completes before TUI starts
-	time.Sleep(100 * time.Millisecond)
-
-	// Determine if broker should stream (only if no cache)
-	var brokerForTUI broker.Broker
-	if len(initialCards) == 0 {
-		brokerForTUI = msgBroker
-	}
-
-	return tui.StartWithBroker(brokerForTUI, initialCards)
+	return tui.StartStreaming(bus, submit)
 }
 
-// displayJSON collects findings from the broker and outputs them as JSON.
-// The analysis request must already be submitted before calling this function.
-func displayJSON(msgBroker broker.Broker) error {
-	ctx := context.Background()
-	return collectAndOutputJSON(ctx, msgBroker)
+// displayJSON collects findings from the events and outputs them as JSON.
+// The events must be subscribed to before the request is submitted.
+func displayJSON(ctx context.Context, evs <-chan events.Event, timeouts pipeline.CollectTimeouts) error {
+	return collectAndOutputJSON(ctx, evs, timeouts)
 }
 
 // ========================================
 // Helper Functions
 // ========================================
 
-// validateBuildURL validates the build URL format and required API token
-func validateBuildURL(buildURL string) error {
-	ref, err := provider.ParseURL(buildURL)
+// resolveBuildArg expands build shorthands (alias#123, org/pipeline/123) into
+// full build URLs using aliases from DESTILL_PIPELINE_ALIASES. An existing
+// directory becomes a file:// URL for the local log directory provider.
+// Full URLs and request IDs are returned unchanged.
+func resolveBuildArg(arg string) (string, error) {
+	if info, err := os.Stat(arg); err == nil && info.IsDir() {
+		return provider.LocalDirURL(arg)
+	}
+
+	aliases, err := config.LoadAliasesFromEnv()
 	if err != nil {
-		return provider.WrapError(err)
+		return "", err
 	}
-	// Validate token upfront to fail fast before starting the pipeline
-	if err := provider.ValidateToken(ref); err != nil {
-		return provider.WrapError(err)
+	expanded, err := provider.ExpandShorthand(arg, aliases)
+	if err != nil {
+		return "", provider.WrapError(err)
 	}
-	return nil
+	return expanded, nil
+}
+
+// resolveLatestFailedBuild finds the most recent failed build for a pipeline
+// on branch and returns its build URL. spec is a pipeline URL, org/pipeline
+// slug, or alias from DESTILL_PIPELINE_ALIASES.
+func resolveLatestFailedBuild(ctx context.Context, spec, branch string) (string, error) {
+	aliases, err := config.LoadAliasesFromEnv()
+	if err != nil {
+		return "", err
+	}
+
+	ref, err := provider.ParsePipeline(spec, aliases)
+	if err != nil {
+		return "", provider.WrapError(err)
+	}
+
+	prov, err := provider.GetProvider(ref)
+	if err != nil {
+		return "", provider.WrapError(err)
+	}
+
+	build, err := prov.FetchLatestFailedBuild(ctx, ref, branch)
+	if err != nil {
+		return "", provider.WrapError(err)
+	}
+
+	return build.URL, nil
 }
 
 // generateRequestID creates a unique request identifier
@@ -156,12 +207,18 @@ func generateRequestID() string {
 
 // buildAnalysisRequest creates a new analysis request with a unique ID.
 // Returns the request ID and marshaled JSON data ready for publishing.
-func buildAnalysisRequest(buildURL string) (requestID string, data []byte, err error) {
+func buildAnalysisRequest(buildURL string, opts *contracts.AnalysisOptions) (requestID string, data []byte, err error) {
+	if err := opts.Validate(); err != nil {
+		return "", nil, fmt.Errorf("invalid analysis options: %w", err)
+	}
+
 	requestID = generateRequestID()
 
 	payload := contracts.AnalysisRequest{
+		Version:   contracts.AnalysisRequestVersion,
 		RequestID: requestID,
 		BuildURL:  buildURL,
+		Options:   opts,
 	}
 
 	data, err = json.Marshal(payload)
@@ -172,7 +229,16 @@ func buildAnalysisRequest(buildURL string) (requestID string, data []byte, err e
 	return requestID, data, nil
 }
 
-// loadCachedCards reads, unmarshals, and sorts cached triage cards from a file.
+// analysisSession is the cache file --save-cache writes: the findings of a
+// local analysis and the build they came from.
+type analysisSession struct {
+	BuildURL string                 `json:"build_url"`
+	SavedAt  time.Time              `json:"saved_at"`
+	Cards    []contracts.TriageCard `json:"cards"`
+}
+
+// loadCachedCards reads, unmarshals, and sorts cached triage cards from a
+// file: an analysisSession, or the card array of 'destill analyze --json'.
 // Returns an empty slice if cacheFile is empty (not an error).
 func loadCachedCards(cacheFile string) ([]contracts.TriageCard, error) {
 	if cacheFile == "" {
@@ -185,7 +251,13 @@ func loadCachedCards(cacheFile string) ([]contracts.TriageCard, error) {
 	}
 
 	var cards []contracts.TriageCard
-	if err := json.Unmarshal(data, &cards); err != nil {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var session analysisSession
+		if err := json.Unmarshal(data, &session); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cache: %w", err)
+		}
+		cards = session.Cards
+	} else if err := json.Unmarshal(data, &cards); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal cache: %w", err)
 	}
 
@@ -195,12 +267,63 @@ func loadCachedCards(cacheFile string) ([]contracts.TriageCard, error) {
 	return cards, nil
 }
 
-// sortCardsByPriority sorts cards by confidence score (desc) and recurrence count (desc)
-func sortCardsByPriority(cards []contracts.TriageCard) {
-	sort.Slice(cards, func(i, j int) bool {
-		if cards[i].ConfidenceScore != cards[j].ConfidenceScore {
-			return cards[i].ConfidenceScore > cards[j].ConfidenceScore
+// saveSession writes the findings of buildURL to a cache file that --cache
+// loads, resolving name like --cache does (see platform.CachePath). Returns
+// the path written.
+func saveSession(name, buildURL string, cards []contracts.TriageCard) (string, error) {
+	path, err := platform.CachePath(name)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(analysisSession{BuildURL: buildURL, SavedAt: time.Now().UTC(), Cards: cards}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal findings: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return path, nil
+}
+
+// sessionRecorder collects the findings published on a local mode's event
+// bus, for --save-cache.
+type sessionRecorder struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	cards  []contracts.TriageCard
+}
+
+// recordSession starts collecting the findings published on bus from now
+// on. Start it before submitting.
+func recordSession(ctx context.Context, bus *events.Bus) (*sessionRecorder, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	evs, err := bus.Subscribe(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	r := &sessionRecorder{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(r.done)
+		for event := range evs {
+			if finding, ok := event.(events.Finding); ok {
+				r.cards = append(r.cards, finding.Card)
+			}
 		}
-		return cards[i].GetRecurrenceCount() > cards[j].GetRecurrenceCount()
-	})
+	}()
+	return r, nil
+}
+
+// Stop stops recording and returns the findings, deduplicated and ranked.
+func (r *sessionRecorder) Stop() []contracts.TriageCard {
+	r.cancel()
+	<-r.done
+	cards := contracts.DeduplicateCards(r.cards)
+	sortCardsByPriority(cards)
+	return cards
+}
+
+// sortCardsByPriority sorts cards by composite score (desc)
+func sortCardsByPriority(cards []contracts.TriageCard) {
+	ranking.SortCards(cards)
 }
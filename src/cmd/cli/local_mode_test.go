@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -9,49 +10,22 @@ import (
 	"time"
 
 	"destill-agent/src/contracts"
+	"destill-agent/src/pipeline"
+	"destill-agent/src/provider"
 )
 
-// TestValidateBuildURL tests URL validation
-func TestValidateBuildURL(t *testing.T) {
-	tests := []struct {
-		name    string
-		url     string
-		wantErr bool
-	}{
-		{
-			name:    "valid buildkite URL",
-			url:     "https://buildkite.com/myorg/pipeline/builds/123",
-			wantErr: false,
-		},
-		{
-			name:    "valid github actions URL",
-			url:     "https://github.com/owner/repo/actions/runs/123456",
-			wantErr: false,
-		},
-		{
-			name:    "invalid URL - not a URL",
-			url:     "not-a-url",
-			wantErr: true,
-		},
-		{
-			name:    "invalid URL - wrong domain",
-			url:     "https://example.com/builds/123",
-			wantErr: true,
-		},
-		{
-			name:    "empty URL",
-			url:     "",
-			wantErr: true,
-		},
-	}
+func TestResolveBuildArg_Directory(t *testing.T) {
+	dir := t.TempDir()
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := validateBuildURL(tt.url)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("validateBuildURL() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
+	got, err := resolveBuildArg(dir)
+	if err != nil {
+		t.Fatalf("resolveBuildArg(%q) error = %v", dir, err)
+	}
+	if !strings.HasPrefix(got, "file:///") {
+		t.Fatalf("resolveBuildArg(%q) = %q, want a file:// URL", dir, got)
+	}
+	if _, err := provider.ValidateBuildURL(got); err != nil {
+		t.Errorf("ValidateBuildURL(%q) error = %v, want nil (no token needed)", got, err)
 	}
 }
 
@@ -110,7 +84,7 @@ func TestGenerateRequestID(t *testing.T) {
 func TestBuildAnalysisRequest(t *testing.T) {
 	buildURL := "https://buildkite.com/myorg/pipeline/builds/123"
 
-	requestID, data, err := buildAnalysisRequest(buildURL)
+	requestID, data, err := buildAnalysisRequest(buildURL, nil)
 	if err != nil {
 		t.Fatalf("buildAnalysisRequest() unexpected error: %v", err)
 	}
@@ -143,6 +117,32 @@ func TestBuildAnalysisRequest(t *testing.T) {
 	}
 }
 
+// TestBuildAnalysisRequest_Options tests that options are versioned and validated
+func TestBuildAnalysisRequest_Options(t *testing.T) {
+	buildURL := "https://buildkite.com/myorg/pipeline/builds/123"
+	opts := &contracts.AnalysisOptions{FailedOnly: true, MinConfidence: 0.7}
+
+	_, data, err := buildAnalysisRequest(buildURL, opts)
+	if err != nil {
+		t.Fatalf("buildAnalysisRequest() unexpected error: %v", err)
+	}
+
+	var payload contracts.AnalysisRequest
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("buildAnalysisRequest() Data is not valid JSON: %v", err)
+	}
+	if payload.Version != contracts.AnalysisRequestVersion {
+		t.Errorf("Version = %d, want %d", payload.Version, contracts.AnalysisRequestVersion)
+	}
+	if payload.Options == nil || !payload.Options.FailedOnly || payload.Options.MinConfidence != 0.7 {
+		t.Errorf("Options = %+v, want failed_only and min_confidence 0.7", payload.Options)
+	}
+
+	if _, _, err := buildAnalysisRequest(buildURL, &contracts.AnalysisOptions{PreContextLines: -1}); err == nil {
+		t.Error("buildAnalysisRequest() expected error for negative context, got nil")
+	}
+}
+
 // TestLoadCachedCards tests cache loading
 func TestLoadCachedCards(t *testing.T) {
 	t.Run("empty cache file path", func(t *testing.T) {
@@ -199,6 +199,30 @@ func TestLoadCachedCards(t *testing.T) {
 		}
 	})
 
+	t.Run("saved session round-trips", func(t *testing.T) {
+		cacheFile := filepath.Join(t.TempDir(), "session.json")
+		buildURL := "https://buildkite.com/org/pipeline/builds/4091"
+		saved := []contracts.TriageCard{{ID: "card-1", MessageHash: "abc", ContextNote: "truncated at chunk start"}}
+
+		path, err := saveSession(cacheFile, buildURL, saved)
+		if err != nil || path != cacheFile {
+			t.Fatalf("saveSession() = %q, %v, want %q", path, err, cacheFile)
+		}
+		data, _ := os.ReadFile(cacheFile)
+		var session analysisSession
+		if err := json.Unmarshal(data, &session); err != nil || session.BuildURL != buildURL || session.SavedAt.IsZero() {
+			t.Errorf("saved session = %+v, %v, want the build URL and save time", session, err)
+		}
+
+		cards, err := loadCachedCards(cacheFile)
+		if err != nil {
+			t.Fatalf("loadCachedCards() unexpected error: %v", err)
+		}
+		if len(cards) != 1 || cards[0].ID != "card-1" || cards[0].ContextNote != "truncated at chunk start" {
+			t.Errorf("loadCachedCards() = %+v, want the saved card", cards)
+		}
+	})
+
 	t.Run("non-existent cache file", func(t *testing.T) {
 		_, err := loadCachedCards("/path/does/not/exist.json")
 		if err == nil {
@@ -297,7 +321,7 @@ func TestSortCardsByPriority(t *testing.T) {
 // TestLocalMode tests the LocalMode type
 func TestLocalMode(t *testing.T) {
 	t.Run("NewLocalMode creates valid instance", func(t *testing.T) {
-		mode, err := NewLocalMode()
+		mode, err := NewLocalMode(context.Background())
 		if err != nil {
 			t.Fatalf("NewLocalMode() unexpected error: %v", err)
 		}
@@ -310,14 +334,14 @@ func TestLocalMode(t *testing.T) {
 	})
 
 	t.Run("SubmitAnalysis returns valid request ID", func(t *testing.T) {
-		mode, err := NewLocalMode()
+		mode, err := NewLocalMode(context.Background())
 		if err != nil {
 			t.Fatalf("NewLocalMode() unexpected error: %v", err)
 		}
 		defer mode.Close()
 
 		buildURL := "https://buildkite.com/myorg/pipeline/builds/123"
-		requestID, err := mode.SubmitAnalysis(buildURL)
+		requestID, err := mode.SubmitAnalysis(buildURL, nil)
 		if err != nil {
 			t.Fatalf("SubmitAnalysis() unexpected error: %v", err)
 		}
@@ -331,8 +355,61 @@ func TestLocalMode(t *testing.T) {
 		}
 	})
 
+	t.Run("Subscribe receives findings and errors", func(t *testing.T) {
+		mode, err := NewLocalMode(context.Background())
+		if err != nil {
+			t.Fatalf("NewLocalMode() unexpected error: %v", err)
+		}
+		defer mode.Close()
+
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "unit-tests.log"), []byte("ERROR: test failed\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		buildURL, err := provider.LocalDirURL(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recorder, err := recordSession(context.Background(), mode.Events())
+		if err != nil {
+			t.Fatalf("recordSession() unexpected error: %v", err)
+		}
+		evs, err := mode.Subscribe()
+		if err != nil {
+			t.Fatalf("Subscribe() unexpected error: %v", err)
+		}
+		if _, err := mode.SubmitAnalysis(buildURL, nil); err != nil {
+			t.Fatalf("SubmitAnalysis() unexpected error: %v", err)
+		}
+		// Without an idle timeout, only the end of the analysis stops it early
+		start := time.Now()
+		cards, err := collectFindings(context.Background(), evs, pipeline.CollectTimeouts{Hard: 5 * time.Second})
+		if err != nil || len(cards) == 0 {
+			t.Fatalf("collectFindings() = %d cards, %v; want the log's error", len(cards), err)
+		}
+		if waited := time.Since(start); waited > 4*time.Second {
+			t.Errorf("collectFindings() took %v, want it to stop when the analysis completes", waited)
+		}
+		if recorded := recorder.Stop(); len(recorded) != len(contracts.DeduplicateCards(cards)) {
+			t.Errorf("recorder.Stop() = %d cards, want the %d unique findings collected", len(recorded), len(contracts.DeduplicateCards(cards)))
+		}
+
+		// A request that fails ends collection with its error
+		evs, err = mode.Subscribe()
+		if err != nil {
+			t.Fatalf("Subscribe() unexpected error: %v", err)
+		}
+		if _, err := mode.SubmitAnalysis("file://"+filepath.Join(dir, "missing"), nil); err != nil {
+			t.Fatalf("SubmitAnalysis() unexpected error: %v", err)
+		}
+		if _, err := collectFindings(context.Background(), evs, pipeline.CollectTimeouts{Hard: 5 * time.Second}); err == nil {
+			t.Error("collectFindings() of a failed request: expected error, got nil")
+		}
+	})
+
 	t.Run("Close does not panic", func(t *testing.T) {
-		mode, err := NewLocalMode()
+		mode, err := NewLocalMode(context.Background())
 		if err != nil {
 			t.Fatalf("NewLocalMode() unexpected error: %v", err)
 		}
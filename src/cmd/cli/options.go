@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/pipeline"
+	"destill-agent/src/provider"
+)
+
+// addAnalysisOptionFlags registers the per-request analysis knobs shared by
+// analyze (local mode) and submit (distributed mode).
+func addAnalysisOptionFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("failed-only", false, "Only analyze jobs that failed")
+	cmd.Flags().Float64("min-confidence", 0, "Drop findings below this confidence, 0-1 (default 0.5)")
+	cmd.Flags().Int("pre-context", 0, "Lines of context before each finding (default 15)")
+	cmd.Flags().Int("post-context", 0, "Lines of context after each finding (default 30)")
+	cmd.Flags().String("baseline", "", "Known-good build URL to compare against")
+	cmd.Flags().Bool("artifacts", false, "Also analyze the jobs' text artifacts (*.log, *.txt, crash logs)")
+}
+
+// analysisOptionsFromFlags builds request options from the shared flags,
+// falling back to the config file's analysis section for flags not given.
+// Returns nil when no option was set so the request uses agent defaults.
+func analysisOptionsFromFlags(cmd *cobra.Command, formats ...string) *contracts.AnalysisOptions {
+	defaults := fileConfig.Analysis
+	opts := &contracts.AnalysisOptions{
+		Formats:          formats,
+		FailedOnly:       defaults.FailedOnly,
+		MinConfidence:    defaults.MinConfidence,
+		PreContextLines:  defaults.PreContext,
+		PostContextLines: defaults.PostContext,
+		Artifacts:        defaults.Artifacts,
+	}
+	if cmd.Flags().Changed("failed-only") {
+		opts.FailedOnly, _ = cmd.Flags().GetBool("failed-only")
+	}
+	if cmd.Flags().Changed("min-confidence") {
+		opts.MinConfidence, _ = cmd.Flags().GetFloat64("min-confidence")
+	}
+	if cmd.Flags().Changed("pre-context") {
+		opts.PreContextLines, _ = cmd.Flags().GetInt("pre-context")
+	}
+	if cmd.Flags().Changed("post-context") {
+		opts.PostContextLines, _ = cmd.Flags().GetInt("post-context")
+	}
+	if cmd.Flags().Changed("artifacts") {
+		opts.Artifacts, _ = cmd.Flags().GetBool("artifacts")
+	}
+	opts.BaselineURL, _ = cmd.Flags().GetString("baseline")
+	opts.Notify, _ = cmd.Flags().GetBool("notify") // submit only
+
+	if !opts.FailedOnly && opts.MinConfidence == 0 && opts.PreContextLines == 0 &&
+		opts.PostContextLines == 0 && opts.BaselineURL == "" && len(opts.Formats) == 0 && !opts.Notify && !opts.Artifacts {
+		return nil
+	}
+	return opts
+}
+
+// defaultCollectTimeouts bound how long local mode waits for findings when
+// neither flags nor the environment say otherwise.
+var defaultCollectTimeouts = pipeline.CollectTimeouts{Idle: 10 * time.Second}
+
+// addCollectTimeoutFlags registers the flags bounding how long local mode
+// commands wait for findings, documenting the command's defaults.
+func addCollectTimeoutFlags(cmd *cobra.Command, defaults pipeline.CollectTimeouts) {
+	cmd.Flags().Duration("idle-timeout", 0, "Stop waiting for findings after this long without a new one, 0 to disable "+timeoutDefault(defaults.Idle))
+	cmd.Flags().Duration("timeout", 0, "Stop waiting for findings after this long in all, 0 to disable "+timeoutDefault(defaults.Hard))
+}
+
+func timeoutDefault(d time.Duration) string {
+	if d <= 0 {
+		return "(default: no limit)"
+	}
+	return fmt.Sprintf("(default %v)", d)
+}
+
+// collectTimeoutsFromFlags returns the collector timeouts from the flags,
+// falling back to DESTILL_IDLE_TIMEOUT and DESTILL_HARD_TIMEOUT (which the
+// config file's analysis section sets) and then defaults.
+func collectTimeoutsFromFlags(cmd *cobra.Command, defaults pipeline.CollectTimeouts) pipeline.CollectTimeouts {
+	timeouts := pipeline.CollectTimeoutsFromEnv(defaults)
+	if cmd.Flags().Changed("idle-timeout") {
+		timeouts.Idle, _ = cmd.Flags().GetDuration("idle-timeout")
+	}
+	if cmd.Flags().Changed("timeout") {
+		timeouts.Hard, _ = cmd.Flags().GetDuration("timeout")
+	}
+	return timeouts
+}
+
+// resolveBaseline expands a --baseline shorthand (alias#123, a log
+// directory) the way the build argument is, and checks the URL parses.
+func resolveBaseline(opts *contracts.AnalysisOptions) error {
+	if opts == nil || opts.BaselineURL == "" {
+		return nil
+	}
+	baselineURL, err := resolveBuildArg(opts.BaselineURL)
+	if err != nil {
+		return fmt.Errorf("--baseline: %w", err)
+	}
+	if _, err := provider.ParseURL(baselineURL); err != nil {
+		return fmt.Errorf("--baseline: %w", provider.WrapError(err))
+	}
+	opts.BaselineURL = baselineURL
+	return nil
+}
+
+// Output formats of 'destill analyze'.
+const (
+	formatTUI   = "tui"
+	formatJSON  = "json"
+	formatJUnit = "junit"
+	formatPlain = "plain"
+)
+
+// outputFormat resolves analyze's --format and its --json and --plain
+// shorthands, falling back to the config file's output setting.
+func outputFormat(cmd *cobra.Command) (string, error) {
+	format, _ := cmd.Flags().GetString("format")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	plainOutput, _ := cmd.Flags().GetBool("plain")
+	switch {
+	case jsonOutput && plainOutput:
+		return "", fmt.Errorf("--json conflicts with --plain")
+	case jsonOutput && cmd.Flags().Changed("format") && format != formatJSON:
+		return "", fmt.Errorf("--json conflicts with --format %s", format)
+	case plainOutput && cmd.Flags().Changed("format") && format != formatPlain:
+		return "", fmt.Errorf("--plain conflicts with --format %s", format)
+	case jsonOutput:
+		return formatJSON, nil
+	case plainOutput:
+		return formatPlain, nil
+	case !cmd.Flags().Changed("format") && fileConfig.Output != "":
+		format = fileConfig.Output
+	}
+
+	switch format {
+	case formatTUI, formatJSON, formatJUnit, formatPlain:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown format %q (expected tui, json, junit, or plain)", format)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/config"
+	"destill-agent/src/pipeline"
+)
+
+func TestAnalysisOptionsFromFlags_ConfigDefaults(t *testing.T) {
+	saved := fileConfig
+	defer func() { fileConfig = saved }()
+	fileConfig = &config.File{Analysis: config.Analysis{MinConfidence: 0.7, PreContext: 5}}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantConf   float64
+		wantPre    int
+		wantFailed bool
+	}{
+		{name: "config defaults", args: nil, wantConf: 0.7, wantPre: 5},
+		{name: "flag overrides config", args: []string{"--min-confidence", "0.9"}, wantConf: 0.9, wantPre: 5},
+		{name: "flags combine with config", args: []string{"--failed-only"}, wantConf: 0.7, wantPre: 5, wantFailed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addAnalysisOptionFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+
+			opts := analysisOptionsFromFlags(cmd)
+			if opts == nil {
+				t.Fatal("analysisOptionsFromFlags() = nil, want config defaults")
+			}
+			if opts.MinConfidence != tt.wantConf || opts.PreContextLines != tt.wantPre || opts.FailedOnly != tt.wantFailed {
+				t.Errorf("analysisOptionsFromFlags() = %+v", opts)
+			}
+		})
+	}
+}
+
+func TestCollectTimeoutsFromFlags(t *testing.T) {
+	defaults := pipeline.CollectTimeouts{Idle: 10 * time.Second, Hard: 2 * time.Minute}
+
+	tests := []struct {
+		name string
+		args []string
+		env  string
+		want pipeline.CollectTimeouts
+	}{
+		{name: "defaults", want: defaults},
+		{name: "environment", env: "30s", want: pipeline.CollectTimeouts{Idle: 30 * time.Second, Hard: 2 * time.Minute}},
+		{name: "flags override environment", args: []string{"--idle-timeout", "1m", "--timeout", "0"}, env: "30s", want: pipeline.CollectTimeouts{Idle: time.Minute}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(pipeline.EnvIdleTimeout, tt.env)
+			cmd := &cobra.Command{}
+			addCollectTimeoutFlags(cmd, defaults)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+			if got := collectTimeoutsFromFlags(cmd, defaults); got != tt.want {
+				t.Errorf("collectTimeoutsFromFlags() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	saved := fileConfig
+	defer func() { fileConfig = saved }()
+
+	tests := []struct {
+		name    string
+		args    []string
+		config  string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "tui"},
+		{name: "json shorthand", args: []string{"--json"}, want: "json"},
+		{name: "junit", args: []string{"--format", "junit"}, want: "junit"},
+		{name: "config default", config: "junit", want: "junit"},
+		{name: "flag overrides config", args: []string{"--format", "tui"}, config: "json", want: "tui"},
+		{name: "plain shorthand", args: []string{"--plain"}, config: "json", want: "plain"},
+		{name: "conflict", args: []string{"--json", "--format", "junit"}, wantErr: true},
+		{name: "plain conflict", args: []string{"--plain", "--format", "junit"}, wantErr: true},
+		{name: "json and plain", args: []string{"--json", "--plain"}, wantErr: true},
+		{name: "unknown", args: []string{"--format", "html"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileConfig = &config.File{Output: tt.config}
+			cmd := &cobra.Command{}
+			cmd.Flags().Bool("json", false, "")
+			cmd.Flags().Bool("plain", false, "")
+			cmd.Flags().String("format", formatTUI, "")
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+
+			got, err := outputFormat(cmd)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("outputFormat() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("outputFormat() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/permalink"
+	"destill-agent/src/pipeline"
+	"destill-agent/src/ranking"
+	"destill-agent/src/store"
+	"destill-agent/src/tui"
+)
+
+// viewPermalink opens the finding a destill:// permalink points to: in the
+// TUI with its detail shown, or on its own with --plain.
+func viewPermalink(cmd *cobra.Command, arg string) error {
+	link, err := permalink.Parse(arg)
+	if err != nil {
+		return err
+	}
+
+	cards, err := permalinkFindings(cmd.Context(), link)
+	if err != nil {
+		return err
+	}
+	card, ok := permalink.Find(cards, link)
+	if !ok {
+		return fmt.Errorf("finding %s not found (the build may have been re-run since the link was made)", link.MessageHash)
+	}
+
+	if plain, _ := cmd.Flags().GetBool("plain"); plain {
+		return tui.StartPlain([]contracts.TriageCard{card})
+	}
+	return tui.StartAt(cards, link.MessageHash)
+}
+
+// permalinkFindings loads the findings of the request a permalink names
+// from Postgres (or SQLite), falling back to analyzing its build locally
+// when there's no database or the request isn't stored (as for 'analyze
+// --json' output).
+func permalinkFindings(ctx context.Context, link permalink.Link) ([]contracts.TriageCard, error) {
+	postgresDSN, sqlitePath := os.Getenv("POSTGRES_DSN"), os.Getenv("DESTILL_SQLITE_PATH")
+	if (postgresDSN != "" || sqlitePath != "") && link.RequestID != "" {
+		db, err := store.OpenPersistent(ctx, postgresDSN, sqlitePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the database: %w", err)
+		}
+		defer db.Close()
+
+		cards, err := db.GetFindings(ctx, link.RequestID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query findings: %w", err)
+		}
+		if len(cards) > 0 {
+			return ranking.MarkTransients(cards), nil
+		}
+	}
+
+	if link.BuildURL == "" {
+		return nil, fmt.Errorf("request %s not found; set POSTGRES_DSN or DESTILL_SQLITE_PATH to the database it was stored in", link.RequestID)
+	}
+	fmt.Fprintf(os.Stderr, "Analyzing %s to find the linked finding...\n", link.BuildURL)
+	cards, _, err := analyzeBuild(ctx, link.BuildURL, nil, nil, pipeline.CollectTimeoutsFromEnv(defaultCollectTimeouts))
+	return cards, err
+}
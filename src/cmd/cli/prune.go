@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/store"
+)
+
+// pruneCmd deletes old findings from the database
+var pruneCmd = &cobra.Command{
+	Use:   "prune --older-than <age>",
+	Short: "Delete old findings, log chunks, and requests from the database",
+	Long: `Deletes the findings, log chunks, and requests stored more than --older-than
+ago, so the database doesn't grow without bound. Ages are a number of days
+("30d") or weeks ("2w"), or a duration ("36h").
+
+To prune continuously instead, set DESTILL_RETENTION on the analyze agents:
+they then prune hourly.
+
+Examples:
+  destill prune --older-than 30d
+  destill prune --older-than 2w
+
+Environment variables:
+  POSTGRES_DSN        - Postgres connection string
+  DESTILL_SQLITE_PATH - SQLite database of the agents, without POSTGRES_DSN`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		maxAge, err := store.ParseRetention(olderThan)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --older-than: %v\n", err)
+			os.Exit(1)
+		}
+
+		db, err := store.OpenPersistent(cmd.Context(), os.Getenv("POSTGRES_DSN"), os.Getenv("DESTILL_SQLITE_PATH"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		pruner, ok := db.(store.Pruner)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Error: the database doesn't support pruning")
+			os.Exit(1)
+		}
+
+		before := time.Now().Add(-maxAge)
+		res, err := pruner.Prune(cmd.Context(), before)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Pruned %d findings, %d log chunks, %d requests, and %d message history rows stored before %s\n",
+			res.Findings, res.LogChunks, res.Requests, res.HashHistory, before.Format(time.RFC3339))
+	},
+}
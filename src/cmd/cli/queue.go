@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/provider"
+	"destill-agent/src/store"
+)
+
+// queueCmd groups the commands managing the requests waiting for the agents
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Inspect and reorder the requests waiting for the agents (distributed mode)",
+	Long: `When the agents are backed up, for example during an incident, 'destill queue
+list' shows the requests they haven't finished and 'destill queue bump' gets
+one ingested next.
+
+Environment variables:
+  POSTGRES_DSN        - Postgres connection string
+  DESTILL_SQLITE_PATH - SQLite database of the agents, without POSTGRES_DSN
+  DESTILL_TRANSPORT   - Broker transport (redpanda, nats, sqs, grpc), for bump
+  REDPANDA_BROKERS    - Comma-separated list of Redpanda brokers, for bump
+  NATS_URL            - NATS server, with DESTILL_TRANSPORT=nats`,
+}
+
+// queueListCmd prints the pending and processing requests
+var queueListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the requests the agents haven't finished",
+	Long: `Lists the pending and processing requests in Postgres (or SQLite), oldest
+first: how long each has waited, and for those being processed, the jobs
+fetched and chunks analyzed so far.
+
+Pending requests are taken by the ingest agents round-robin by pipeline, so
+the list shows what's waiting rather than the exact order it will run in.
+
+Examples:
+  destill queue list
+  destill queue list --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		db, err := store.OpenPersistent(ctx, os.Getenv("POSTGRES_DSN"), os.Getenv("DESTILL_SQLITE_PATH"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		lister, ok := db.(store.QueueLister)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Error: the database doesn't support listing requests")
+			os.Exit(1)
+		}
+		requests, err := lister.QueuedRequests(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			out := make([]statusJSON, 0, len(requests))
+			for _, status := range requests {
+				out = append(out, newStatusJSON(status))
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(out); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		writeQueue(os.Stdout, requests, time.Now())
+	},
+}
+
+// queueBumpCmd asks the ingest agents to take a request next
+var queueBumpCmd = &cobra.Command{
+	Use:   "bump <request-id-or-url>",
+	Short: "Have the ingest agents take a pending request next",
+	Long: `Publishes a prioritize message on destill.control. The ingest agent holding
+the request in its queue moves it to the front, ahead of every request not
+bumped and of its pipeline's in-flight limit (DESTILL_INGEST_PIPELINE_LIMIT).
+Requests bumped earlier keep their place ahead of it.
+
+With POSTGRES_DSN or DESTILL_SQLITE_PATH set, the request is checked first:
+requests already being processed or finished can't be bumped, and a build URL
+is resolved to its latest request.
+
+Examples:
+  destill queue bump req-1733769623456789
+  destill queue bump https://buildkite.com/org/pipeline/builds/123`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		arg, err := resolveBuildArg(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx := cmd.Context()
+		requestID := arg
+		postgresDSN, sqlitePath := os.Getenv("POSTGRES_DSN"), os.Getenv("DESTILL_SQLITE_PATH")
+		switch {
+		case postgresDSN != "" || sqlitePath != "":
+			db, err := store.OpenPersistent(ctx, postgresDSN, sqlitePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open the database: %v\n", err)
+				os.Exit(1)
+			}
+			defer db.Close()
+
+			if provider.IsURL(arg) {
+				requestID, err = db.GetLatestRequestByBuildURL(ctx, arg)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to find request for build URL: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			status, err := db.GetRequestStatus(ctx, requestID)
+			if err := checkBumpable(requestID, status, err); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		case provider.IsURL(arg):
+			fmt.Fprintln(os.Stderr, "Error: finding the request of a build URL needs POSTGRES_DSN or DESTILL_SQLITE_PATH; give the request ID instead")
+			os.Exit(1)
+		}
+
+		msgBroker, err := requireBroker()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer msgBroker.Close()
+
+		if err := bumpRequest(ctx, msgBroker, requestID); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Asked the ingest agents to take %s next\n", requestID)
+	},
+}
+
+// checkBumpable returns an error unless the request, whose status lookup
+// returned status and err, is still waiting to be ingested. A request the
+// database doesn't know yet may still be on its way to it, so that's only
+// warned about.
+func checkBumpable(requestID string, status store.RequestStatus, err error) error {
+	var notFound store.ErrNotFound
+	switch {
+	case errors.As(err, &notFound):
+		fmt.Fprintf(os.Stderr, "Warning: request %s isn't in the database; bumping it anyway\n", requestID)
+		return nil
+	case err != nil:
+		return err
+	case status.Status != store.RequestPending:
+		return fmt.Errorf("request %s is already %s; only pending requests can be bumped", requestID, status.Status)
+	}
+	return nil
+}
+
+// bumpRequest publishes a ControlPrioritize message for requestID.
+func bumpRequest(ctx context.Context, brk broker.Broker, requestID string) error {
+	data, err := json.Marshal(contracts.ControlMessage{
+		Type:      contracts.ControlPrioritize,
+		RequestID: requestID,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal control message: %w", err)
+	}
+	if err := brk.Publish(ctx, contracts.TopicControl, requestID, data); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", contracts.TopicControl, err)
+	}
+	return nil
+}
+
+// writeQueue prints one line per request: its ID, status, how long ago it
+// was submitted, its build, and its progress once processing.
+func writeQueue(w io.Writer, requests []store.RequestStatus, now time.Time) {
+	if len(requests) == 0 {
+		fmt.Fprintln(w, "No requests waiting")
+		return
+	}
+	pending := 0
+	for _, status := range requests {
+		age := "?"
+		if !status.CreatedAt.IsZero() {
+			age = now.Sub(status.CreatedAt).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%s  %-10s  %8s  %s", status.RequestID, status.Status, age, status.BuildURL)
+		if status.Status == store.RequestPending {
+			pending++
+		} else {
+			fmt.Fprintf(w, "  (jobs %s, chunks %s)", progressCount(status.JobsFetched, status.JobsTotal),
+				progressCount(status.ChunksProcessed, status.ChunksTotal))
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "\n%d requests: %d pending, %d processing\n", len(requests), pending, len(requests)-pending)
+}
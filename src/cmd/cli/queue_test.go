@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/store"
+)
+
+func TestWriteQueue(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	requests := []store.RequestStatus{
+		{RequestID: "req-1", BuildURL: "https://buildkite.com/org/api/builds/1", Status: store.RequestProcessing,
+			JobsTotal: 4, JobsFetched: 2, ChunksProcessed: 3, CreatedAt: now.Add(-10 * time.Minute)},
+		{RequestID: "req-2", BuildURL: "https://buildkite.com/org/web/builds/7", Status: store.RequestPending,
+			CreatedAt: now.Add(-90 * time.Second)},
+	}
+
+	var buf bytes.Buffer
+	writeQueue(&buf, requests, now)
+	lines := strings.Split(buf.String(), "\n")
+
+	for _, want := range []string{"req-1", "processing", "10m0s", "org/api/builds/1", "jobs 2/4"} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("line %q missing %q", lines[0], want)
+		}
+	}
+	if !strings.Contains(lines[1], "1m30s") || strings.Contains(lines[1], "jobs") {
+		t.Errorf("pending line = %q, want its age and no progress", lines[1])
+	}
+	if !strings.Contains(buf.String(), "2 requests: 1 pending, 1 processing") {
+		t.Errorf("missing summary in:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	writeQueue(&buf, nil, now)
+	if got := buf.String(); got != "No requests waiting\n" {
+		t.Errorf("empty queue = %q", got)
+	}
+}
+
+func TestCheckBumpable(t *testing.T) {
+	if err := checkBumpable("req-1", store.RequestStatus{Status: store.RequestPending}, nil); err != nil {
+		t.Errorf("pending: error = %v", err)
+	}
+	if err := checkBumpable("req-1", store.RequestStatus{}, store.ErrNotFound{RequestID: "req-1"}); err != nil {
+		t.Errorf("not found: error = %v, want a warning only", err)
+	}
+	for _, status := range []string{store.RequestProcessing, store.RequestCompleted, store.RequestFailed} {
+		if err := checkBumpable("req-1", store.RequestStatus{Status: status}, nil); err == nil {
+			t.Errorf("%s: want an error", status)
+		}
+	}
+}
+
+func TestBumpRequest(t *testing.T) {
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	control, err := brk.Subscribe(ctx, contracts.TopicControl, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bumpRequest(ctx, brk, "req-9"); err != nil {
+		t.Fatalf("bumpRequest() error = %v", err)
+	}
+
+	select {
+	case msg := <-control:
+		var ctrl contracts.ControlMessage
+		if err := json.Unmarshal(msg.Value, &ctrl); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Key != "req-9" || ctrl.Type != contracts.ControlPrioritize || ctrl.RequestID != "req-9" || ctrl.Timestamp == "" {
+			t.Errorf("published %s %+v", msg.Key, ctrl)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no control message published")
+	}
+}
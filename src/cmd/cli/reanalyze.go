@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/ranking"
+	"destill-agent/src/rules"
+	"destill-agent/src/store"
+)
+
+// reanalyzeCmd analyzes a request's stored log chunks again
+var reanalyzeCmd = &cobra.Command{
+	Use:   "reanalyze <request-id-or-url>",
+	Short: "Analyze a request's stored logs again with the current patterns",
+	Long: `Analyzes the log chunks stored for a request again with the current pattern
+config (~/.destill/patterns.yaml or DESTILL_PATTERNS_FILE), without fetching
+the logs from the CI provider again. Use it to tune patterns, suppressions,
+and severity rules against builds already analyzed.
+
+The findings are stored as a new request for the same build, with a copy of
+the chunks, so 'destill view' shows them with their context and marks what
+changed since the original request. --dry-run only prints the comparison.
+
+Chunks are stored in distributed mode by the Redpanda Connect sink, or by
+the analyze agents when they write to the database themselves. Chunks stored
+before this version lack their job's name and metadata, so findings read
+from them have no job name or links.
+
+Examples:
+  destill reanalyze req-1733769623456789
+  destill reanalyze https://buildkite.com/org/pipeline/builds/123 --dry-run
+
+Environment variables:
+  POSTGRES_DSN          - Postgres connection string
+  DESTILL_SQLITE_PATH   - SQLite database of the agents, without POSTGRES_DSN
+  DESTILL_PATTERNS_FILE - Pattern config to analyze with`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		ruleSet, err := rules.LoadRuleSet()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		db, err := store.OpenPersistent(ctx, os.Getenv("POSTGRES_DSN"), os.Getenv("DESTILL_SQLITE_PATH"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		requestID, err := lookupRequest(ctx, db, args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if err := reanalyze(ctx, db, requestID, ruleSet, dryRun, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// reanalyze analyzes the stored chunks of requestID again with ruleSet and,
+// unless dryRun, stores the chunks and findings as a new request
+func reanalyze(ctx context.Context, db store.Persistent, requestID string, ruleSet *rules.RuleSet, dryRun bool, out io.Writer) error {
+	reader, ok := db.(store.ChunkReader)
+	if !ok {
+		return errors.New("the database doesn't support reading log chunks")
+	}
+	chunks, err := reader.GetChunks(ctx, requestID)
+	if errors.Is(err, store.ErrNoChunks) {
+		return fmt.Errorf("no log chunks are stored for %s; they're stored by the Redpanda Connect sink or analyze agents with a database", requestID)
+	}
+	if err != nil {
+		return err
+	}
+	previous, err := db.GetFindings(ctx, requestID)
+	if err != nil {
+		return fmt.Errorf("failed to query findings of %s: %w", requestID, err)
+	}
+
+	newID := generateRequestID()
+	buildURL, withoutHeader := "", 0
+	for i := range chunks {
+		chunks[i].RequestID = newID
+		if chunks[i].JobName == "" {
+			withoutHeader++
+		}
+		if buildURL == "" {
+			buildURL = chunks[i].Metadata["build_url"]
+		}
+	}
+	if status, err := db.GetRequestStatus(ctx, requestID); err == nil && status.BuildURL != "" {
+		buildURL = status.BuildURL
+	}
+
+	result := analyze.Reanalyze(chunks, ruleSet, analyze.ChunkTimeoutFromEnv())
+	for i := range result.Cards {
+		if result.Cards[i].BuildURL == "" {
+			result.Cards[i].BuildURL = buildURL
+		}
+	}
+	_, comparison := ranking.CompareRequests(previous, result.Cards, requestID, newID)
+
+	fmt.Fprintf(out, "🔁 Reanalyzed %d log chunks of %s with the current patterns\n", len(chunks), requestID)
+	if withoutHeader > 0 {
+		fmt.Fprintf(out, "   %d chunks were stored without their job's details; their findings have no job name or links\n", withoutHeader)
+	}
+	if result.Skipped > 0 {
+		fmt.Fprintf(out, "   %d chunks timed out or failed and were skipped\n", result.Skipped)
+	}
+	fmt.Fprintf(out, "Findings: %d (was %d)\n", len(result.Cards), len(previous))
+	fmt.Fprintf(out, "⚖️  %s\n", comparison)
+	if dryRun {
+		fmt.Fprintln(out, "Dry run: nothing was stored")
+		return nil
+	}
+
+	if err := db.StoreChunks(ctx, chunks); err != nil {
+		return fmt.Errorf("failed to store chunks: %w", err)
+	}
+	if err := db.Store(ctx, newID, result.Cards); err != nil {
+		return fmt.Errorf("failed to store findings: %w", err)
+	}
+	if tracker, ok := db.(store.RequestTracker); ok {
+		if err := tracker.SetRequestStatus(ctx, newID, buildURL, store.RequestCompleted); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "✅ Stored as %s\n", newID)
+	fmt.Fprintf(out, "View it with destill view %s, or compare with destill view --compare %s %s\n", newID, requestID, newID)
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/rules"
+	"destill-agent/src/store"
+)
+
+// trackingStore is an in-memory store.Persistent recording request statuses
+type trackingStore struct {
+	*store.InMemoryStore
+	statuses []store.RequestStatus // Newest last
+}
+
+func (s *trackingStore) GetFindingsSince(context.Context, time.Time) ([]contracts.TriageCard, error) {
+	return nil, nil
+}
+
+func (s *trackingStore) GetLatestRequestByBuildURL(_ context.Context, buildURL string) (string, error) {
+	for i := len(s.statuses) - 1; i >= 0; i-- {
+		if s.statuses[i].BuildURL == buildURL {
+			return s.statuses[i].RequestID, nil
+		}
+	}
+	return "", store.ErrNotFound{}
+}
+
+func (s *trackingStore) GetRequestStatus(_ context.Context, requestID string) (store.RequestStatus, error) {
+	for _, status := range s.statuses {
+		if status.RequestID == requestID {
+			return status, nil
+		}
+	}
+	return store.RequestStatus{}, store.ErrNotFound{RequestID: requestID}
+}
+
+func (s *trackingStore) SetRequestStatus(_ context.Context, requestID, buildURL, status string) error {
+	s.statuses = append(s.statuses, store.RequestStatus{RequestID: requestID, BuildURL: buildURL, Status: status})
+	return nil
+}
+
+func (s *trackingStore) SetRequestProgress(context.Context, contracts.ProgressUpdate) error {
+	return nil
+}
+
+func (s *trackingStore) SetRequestTruncated(context.Context, string, []string) error {
+	return nil
+}
+
+func TestReanalyze(t *testing.T) {
+	ctx := context.Background()
+	db := &trackingStore{InMemoryStore: store.NewInMemoryStore()}
+
+	build := "https://buildkite.com/org/api/builds/1"
+	db.SetRequestStatus(ctx, "req-1", build, store.RequestCompleted)
+	db.StoreChunks(ctx, []contracts.LogChunk{{
+		RequestID: "req-1", JobID: "job-1", JobName: "tests", LineStart: 1, LineEnd: 2,
+		Content:  "ERROR: database connection refused\nFATAL: out of disk space",
+		Metadata: map[string]string{"job_id": "job-1", "build_url": build},
+	}})
+	db.Store(ctx, "req-1", []contracts.TriageCard{{RequestID: "req-1", MessageHash: "old-hash", RawMessage: "a finding the old patterns made"}})
+
+	rs, err := rules.Compile(&rules.Config{Suppressions: []rules.Suppression{{Name: "disk", Regex: "out of disk space"}}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	// A dry run stores nothing
+	var out bytes.Buffer
+	if err := reanalyze(ctx, db, "req-1", rs, true, &out); err != nil {
+		t.Fatalf("reanalyze(dry run) error = %v", err)
+	}
+	for _, want := range []string{"Reanalyzed 1 log chunks of req-1", "Findings: 1 (was 1)", "0 shared, 1 only in req-1, 1 only in req-", "nothing was stored"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("dry run output missing %q:\n%s", want, out.String())
+		}
+	}
+	if latest, _ := db.GetLatestRequestByBuildURL(ctx, build); latest != "req-1" {
+		t.Errorf("latest request after a dry run = %s, want req-1", latest)
+	}
+
+	out.Reset()
+	if err := reanalyze(ctx, db, "req-1", rs, false, &out); err != nil {
+		t.Fatalf("reanalyze() error = %v", err)
+	}
+	newID, _ := db.GetLatestRequestByBuildURL(ctx, build)
+	if newID == "req-1" || !strings.Contains(out.String(), "Stored as "+newID) {
+		t.Fatalf("latest request = %s, want the reanalysis:\n%s", newID, out.String())
+	}
+	findings, _ := db.GetFindings(ctx, newID)
+	if len(findings) != 1 || !strings.Contains(findings[0].RawMessage, "database connection refused") || findings[0].BuildURL != build {
+		t.Errorf("stored findings = %+v, want the database error for %s", findings, build)
+	}
+	if chunks, err := db.GetChunks(ctx, newID); err != nil || len(chunks) != 1 {
+		t.Errorf("GetChunks(%s) = %d chunks, %v; want a copy of the chunk", newID, len(chunks), err)
+	}
+
+	if err := reanalyze(ctx, db, "req-unknown", rs, false, &out); err == nil || !strings.Contains(err.Error(), "no log chunks") {
+		t.Errorf("reanalyze(unknown) error = %v, want no log chunks", err)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/pipeline"
+	"destill-agent/src/provider"
+	"destill-agent/src/ranking"
+	"destill-agent/src/report"
+	"destill-agent/src/store"
+)
+
+// Formats of 'destill report'.
+const (
+	reportFormatHTML     = "html"
+	reportFormatMarkdown = "markdown"
+)
+
+// reportCmd renders findings as a shareable document
+var reportCmd = &cobra.Command{
+	Use:   "report <request-id | build-url>",
+	Short: "Render findings as a standalone HTML or Markdown report",
+	Long: `Renders the findings of a build as a standalone HTML page or Markdown document,
+for attaching to a pull request or sharing with teammates who don't run the TUI.
+The report has one section per job; each finding shows a confidence bar and
+its log context in a collapsible block.
+
+With POSTGRES_DSN set, findings are read from Postgres: the argument is a
+request ID, or a build URL whose latest request is used (as with 'view').
+Otherwise the build is analyzed locally first (as with 'analyze'), and the
+analysis flags --failed-only, --min-confidence, --pre-context, and
+--post-context apply.
+
+--context widens each finding's context with lines read from the full log,
+across chunk boundaries, up to the store's size caps. In Postgres mode this
+needs the log_chunks table filled by the Connect sink.
+
+The format defaults to Markdown when --output ends in .md, and HTML otherwise.
+
+Examples:
+  destill report https://buildkite.com/org/pipeline/builds/4091 -o report.html
+  destill report backend#4091 -o findings.md
+  destill report backend#4091 --context 80 -o report.html
+  destill report req-20240115T143022-a3f8c91d --format markdown | gh pr comment 42 --body-file -
+
+Environment variables:
+  POSTGRES_DSN - Optional. Read stored findings instead of analyzing the build`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		outputPath, _ := cmd.Flags().GetString("output")
+		title, _ := cmd.Flags().GetString("title")
+		applyNoCacheFlag(cmd)
+
+		format, err := reportFormat(format, outputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		contextLines, _ := cmd.Flags().GetInt("context")
+		cards, source, err := reportFindings(cmd.Context(), cmd, args[0], contextLines)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		opts := report.Options{Title: title, Source: source, Generated: time.Now()}
+		var output []byte
+		if format == reportFormatMarkdown {
+			output = report.Markdown(cards, opts)
+		} else if output, err = report.HTML(cards, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputPath == "" {
+			os.Stdout.Write(output)
+			return
+		}
+		if err := os.WriteFile(outputPath, output, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to write report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "📝 Wrote %s report to %s\n", format, outputPath)
+	},
+}
+
+// reportFormat resolves report's --format, defaulting to Markdown for .md
+// output files and HTML otherwise.
+func reportFormat(format, outputPath string) (string, error) {
+	switch strings.ToLower(format) {
+	case "":
+		switch strings.ToLower(filepath.Ext(outputPath)) {
+		case ".md", ".markdown":
+			return reportFormatMarkdown, nil
+		}
+		return reportFormatHTML, nil
+	case "html", "htm":
+		return reportFormatHTML, nil
+	case "markdown", "md":
+		return reportFormatMarkdown, nil
+	}
+	return "", fmt.Errorf("unknown report format %q (expected html or markdown)", format)
+}
+
+// reportFindings loads the findings to report and a description of where
+// they came from: stored findings when POSTGRES_DSN is set, otherwise a
+// fresh local analysis of the build. With contextLines, each finding's
+// context is widened from the log chunks.
+func reportFindings(ctx context.Context, cmd *cobra.Command, arg string, contextLines int) ([]contracts.TriageCard, string, error) {
+	if postgresDSN := os.Getenv("POSTGRES_DSN"); postgresDSN != "" {
+		postgresStore, err := store.NewPostgresStore(ctx, postgresDSN)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to connect to Postgres: %w", err)
+		}
+		defer postgresStore.Close()
+
+		requestID := arg
+		if provider.IsURL(arg) {
+			requestID, err = postgresStore.GetLatestRequestByBuildURL(ctx, arg)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to find request for build URL: %w", err)
+			}
+		}
+		cards, err := postgresStore.GetFindings(ctx, requestID)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to query findings: %w", err)
+		}
+		if err := expandContext(ctx, postgresStore, cards, contextLines); err != nil {
+			return nil, "", err
+		}
+		// Mark findings new since the build's previous analysis
+		cards, _, err = store.MarkRerun(ctx, postgresStore, cards)
+		if err != nil {
+			return nil, "", err
+		}
+		return ranking.MarkTransients(cards), arg, nil
+	}
+
+	if strings.HasPrefix(arg, "req-") {
+		return nil, "", fmt.Errorf("POSTGRES_DSN environment variable is required to report on a request ID")
+	}
+	if contextLines <= 0 {
+		return analyzeBuild(ctx, arg, analysisOptionsFromFlags(cmd), nil, collectTimeoutsFromFlags(cmd, defaultCollectTimeouts))
+	}
+
+	chunks := store.NewInMemoryStore()
+	cards, buildURL, err := analyzeBuild(ctx, arg, analysisOptionsFromFlags(cmd), chunks, collectTimeoutsFromFlags(cmd, defaultCollectTimeouts))
+	if err != nil {
+		return nil, "", err
+	}
+	if err := chunks.Store(ctx, buildURL, cards); err != nil {
+		return nil, "", err
+	}
+	if err := expandContext(ctx, chunks, cards, contextLines); err != nil {
+		return nil, "", err
+	}
+	return cards, buildURL, nil
+}
+
+// expandContext replaces the context of cards with up to lines lines on
+// each side read from st. Cards whose chunks aren't stored keep their own.
+func expandContext(ctx context.Context, st store.Store, cards []contracts.TriageCard, lines int) error {
+	if lines <= 0 {
+		return nil
+	}
+	for i, card := range cards {
+		fc, err := st.GetFindingContext(ctx, card.ID, lines, lines)
+		if errors.Is(err, store.ErrNoChunks) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read context of finding %s: %w", card.MessageHash, err)
+		}
+		cards[i].PreContext, cards[i].PostContext, cards[i].ContextNote = fc.Before, fc.After, ""
+		if fc.Truncated {
+			cards[i].ContextNote = "cut at size limit"
+		}
+	}
+	return nil
+}
+
+// analyzeBuild analyzes a build locally and returns its findings and the
+// resolved build URL. If chunks is set, the build's log chunks are saved
+// to it as they're analyzed. Findings are collected until timeouts expire.
+func analyzeBuild(ctx context.Context, arg string, opts *contracts.AnalysisOptions, chunks store.Store, timeouts pipeline.CollectTimeouts) ([]contracts.TriageCard, string, error) {
+	buildURL, err := resolveBuildArg(arg)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := provider.ValidateBuildURL(buildURL); err != nil {
+		return nil, "", err
+	}
+
+	mode, err := NewLocalMode(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer mode.Close()
+
+	if chunks != nil {
+		if err := pipeline.StoreChunks(mode.Broker(), ctx, chunks); err != nil {
+			return nil, "", err
+		}
+	}
+	evs, err := mode.Subscribe()
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := mode.SubmitAnalysis(buildURL, opts); err != nil {
+		return nil, "", fmt.Errorf("failed to submit analysis: %w", err)
+	}
+	cards, err := collectFindings(ctx, evs, timeouts)
+	if err != nil {
+		return nil, "", err
+	}
+	return cards, buildURL, nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestReportFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{format: "", output: "", want: reportFormatHTML},
+		{format: "", output: "report.html", want: reportFormatHTML},
+		{format: "", output: "findings.md", want: reportFormatMarkdown},
+		{format: "", output: "FINDINGS.Markdown", want: reportFormatMarkdown},
+		{format: "md", output: "", want: reportFormatMarkdown},
+		{format: "HTML", output: "findings.md", want: reportFormatHTML},
+		{format: "pdf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := reportFormat(tt.format, tt.output)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("reportFormat(%q, %q) error = %v, wantErr %v", tt.format, tt.output, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("reportFormat(%q, %q) = %q, want %q", tt.format, tt.output, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/rules"
+	"destill-agent/src/selftriage"
+)
+
+// selfTriageCmd runs the analyzer over destill's own agent logs
+var selfTriageCmd = &cobra.Command{
+	Use:   "self-triage [log-file...]",
+	Short: "Diagnose destill pipeline problems from the agents' own logs",
+	Long: `Analyzes the ingest and analyze agents' logs the same way build logs are
+analyzed, ranking operational failures such as broker disconnects, publish
+failures, and store errors.
+
+Logs are read from the given files, from stdin (no files, or "-"), or with
+--topic from the destill.agent.logs topic. Agents publish to that topic when
+started with DESTILL_PUBLISH_LOGS=true.
+
+User patterns and suppressions from ~/.destill/patterns.yaml also apply.
+
+Examples:
+  destill self-triage ingest-agent.log analyze-agent.log
+  docker logs destill-ingest 2>&1 | destill self-triage
+  destill self-triage --topic --since 1h`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fromTopic, _ := cmd.Flags().GetBool("topic")
+		since, _ := cmd.Flags().GetDuration("since")
+		idle, _ := cmd.Flags().GetDuration("idle")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		userConfig, _, err := rules.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		ruleSet, err := selftriage.OperationalRules(userConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var entries []contracts.AgentLogEntry
+		if fromTopic {
+			entries, err = readAgentLogTopic(cmd.Context(), idle)
+		} else {
+			entries, err = readAgentLogFiles(args)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if since > 0 {
+			entries = selftriage.Since(entries, time.Now().Add(-since))
+		}
+
+		cards := selftriage.Analyze(entries, ruleSet)
+		if limit > 0 && len(cards) > limit {
+			cards = cards[:limit]
+		}
+
+		if jsonOutput {
+			output, err := json.MarshalIndent(cards, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to marshal findings: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(output))
+			return
+		}
+
+		fmt.Printf("Analyzed %d log entries\n", len(entries))
+		if len(cards) == 0 {
+			fmt.Println("✅ No operational problems found")
+			return
+		}
+		fmt.Println()
+		for _, card := range cards {
+			fmt.Printf("[%.2f] %s: %s\n", card.ConfidenceScore, card.JobName, card.RawMessage)
+		}
+	},
+}
+
+// readAgentLogFiles reads agent logs from files, or stdin when none (or "-") are given.
+// Each file's base name is used as the agent name for lines that don't carry one.
+func readAgentLogFiles(paths []string) ([]contracts.AgentLogEntry, error) {
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	var entries []contracts.AgentLogEntry
+	for _, path := range paths {
+		if path == "-" {
+			fileEntries, err := selftriage.ReadEntries(os.Stdin, "stdin")
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, fileEntries...)
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		agent := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		fileEntries, err := selftriage.ReadEntries(f, agent)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		entries = append(entries, fileEntries...)
+	}
+	return entries, nil
+}
+
+// readAgentLogTopic reads everything currently on the agent log topic.
+func readAgentLogTopic(ctx context.Context, idle time.Duration) ([]contracts.AgentLogEntry, error) {
+	redpandaBrokersStr := os.Getenv("REDPANDA_BROKERS")
+	if redpandaBrokersStr == "" {
+		return nil, fmt.Errorf("REDPANDA_BROKERS environment variable is required for --topic")
+	}
+
+	redpandaBrokers := strings.Split(redpandaBrokersStr, ",")
+	for i := range redpandaBrokers {
+		redpandaBrokers[i] = strings.TrimSpace(redpandaBrokers[i])
+	}
+
+	msgBroker, err := broker.NewRedpandaBroker(redpandaBrokers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redpanda: %w", err)
+	}
+	defer msgBroker.Close()
+
+	// A fresh consumer group per run so the topic is read from the beginning
+	groupID := fmt.Sprintf("destill-self-triage-%d", time.Now().UnixNano())
+	return selftriage.ReadTopic(ctx, msgBroker, groupID, idle)
+}
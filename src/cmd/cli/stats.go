@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/stats"
+	"destill-agent/src/store"
+)
+
+// statsCmd reports failure clusters by infrastructure dimension
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Find infrastructure patterns in failures across many builds",
+	Long: `Pivots the findings of a time window by runner, queue, labels, OS, and image,
+and highlights error signatures concentrated on one value far beyond that
+value's share of all failing jobs, e.g.
+
+  80% of "java.lang.OutOfMemoryError" (12/15 jobs) ran on queue=large-gpu, vs 20% of all failing jobs
+
+Runner dimensions come from the environment dump at the start of each job log
+(see metadata.runner_* on findings). Any other finding metadata key, such as
+pipeline_name or branch, can be given with --by too.
+
+Findings are read from Postgres (POSTGRES_DSN), or with --input from files
+written by 'destill analyze --json'.
+
+Examples:
+  destill stats
+  destill stats --since 72h --by queue,container_image
+  destill stats --input build1.json --input build2.json --min-jobs 2
+  destill stats --json
+
+Environment variables:
+  POSTGRES_DSN - Required without --input. Postgres connection string`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, _ := cmd.Flags().GetDuration("since")
+		by, _ := cmd.Flags().GetStringSlice("by")
+		inputs, _ := cmd.Flags().GetStringSlice("input")
+		minJobs, _ := cmd.Flags().GetInt("min-jobs")
+		minShare, _ := cmd.Flags().GetFloat64("min-share")
+		minLift, _ := cmd.Flags().GetFloat64("min-lift")
+		top, _ := cmd.Flags().GetInt("top")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		dims, err := stats.ParseDimensions(by)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var cards []contracts.TriageCard
+		if len(inputs) > 0 {
+			cards, err = readFindingFiles(inputs)
+		} else {
+			cards, err = readStoredFindings(cmd.Context(), time.Now().Add(-since))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		report := stats.Cluster(cards, stats.Options{
+			Dimensions: dims,
+			MinJobs:    minJobs,
+			MinShare:   minShare,
+			MinLift:    minLift,
+		})
+
+		if jsonOutput {
+			output, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to marshal report: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(output))
+			return
+		}
+		printStatsReport(report, top)
+	},
+}
+
+// readFindingFiles reads findings from 'destill analyze --json' output files.
+func readFindingFiles(paths []string) ([]contracts.TriageCard, error) {
+	var cards []contracts.TriageCard
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read findings: %w", err)
+		}
+		var fileCards []contracts.TriageCard
+		if err := json.Unmarshal(data, &fileCards); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse findings: %w", path, err)
+		}
+		cards = append(cards, fileCards...)
+	}
+	return cards, nil
+}
+
+// readStoredFindings reads the findings stored in Postgres since a time.
+func readStoredFindings(ctx context.Context, since time.Time) ([]contracts.TriageCard, error) {
+	postgresDSN := os.Getenv("POSTGRES_DSN")
+	if postgresDSN == "" {
+		return nil, fmt.Errorf("POSTGRES_DSN environment variable is required (or use --input)")
+	}
+
+	postgresStore, err := store.NewPostgresStore(ctx, postgresDSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Postgres: %w", err)
+	}
+	defer postgresStore.Close()
+
+	return postgresStore.GetFindingsSince(ctx, since)
+}
+
+// printStatsReport writes the outliers and per-dimension breakdowns to stdout.
+func printStatsReport(report stats.Report, top int) {
+	fmt.Printf("Analyzed %d findings from %d failing jobs\n\n", report.Findings, report.Jobs)
+
+	if len(report.Outliers) == 0 {
+		fmt.Println("✅ No error is concentrated on one runner, queue, or image")
+	} else {
+		fmt.Printf("⚠️  Outliers (%d):\n", len(report.Outliers))
+		for _, o := range report.Outliers {
+			fmt.Printf("  %s\n", o)
+		}
+	}
+
+	for _, pivot := range report.Dimensions {
+		if len(pivot.Values) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s:\n", strings.TrimPrefix(pivot.Dimension, "runner_"))
+		for i, v := range pivot.Values {
+			if top > 0 && i == top {
+				fmt.Printf("  ... %d more\n", len(pivot.Values)-top)
+				break
+			}
+			fmt.Printf("  %5d jobs  %3.0f%%  %s\n", v.Jobs, v.Share*100, v.Value)
+		}
+		if pivot.Unknown > 0 {
+			fmt.Printf("  %5d jobs        (not reported)\n", pivot.Unknown)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/provider"
+	"destill-agent/src/store"
+)
+
+// statusCmd shows how far the agents are with a request
+var statusCmd = &cobra.Command{
+	Use:   "status <request-id-or-url>",
+	Short: "Show the progress of a submitted analysis (distributed mode)",
+	Long: `Shows the status of a request submitted with 'destill submit': the jobs
+whose logs the ingest agent has fetched and the log chunks the analyze agent
+has analyzed, with percentages once the totals are known, and the findings
+stored so far.
+
+The analyze agents record progress when they have a database, so POSTGRES_DSN
+or DESTILL_SQLITE_PATH must be set for them as well.
+
+Examples:
+  destill status req-1733769623456789
+  destill status https://buildkite.com/org/pipeline/builds/123
+  destill status req-1733769623456789 --json
+
+Environment variables:
+  POSTGRES_DSN        - Postgres connection string
+  DESTILL_SQLITE_PATH - SQLite database of the agents, without POSTGRES_DSN`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		arg, err := resolveBuildArg(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx := cmd.Context()
+		db, err := store.OpenPersistent(ctx, os.Getenv("POSTGRES_DSN"), os.Getenv("DESTILL_SQLITE_PATH"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		requestID := arg
+		if provider.IsURL(arg) {
+			requestID, err = db.GetLatestRequestByBuildURL(ctx, arg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to find request for build URL: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		status, err := db.GetRequestStatus(ctx, requestID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			if err := writeStatusJSON(os.Stdout, status); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		writeStatus(os.Stdout, status)
+	},
+}
+
+// writeStatus prints status for people, with the progress as percentages
+// where the totals are known.
+func writeStatus(w io.Writer, status store.RequestStatus) {
+	fmt.Fprintf(w, "Request:  %s\n", status.RequestID)
+	if status.BuildURL != "" {
+		fmt.Fprintf(w, "Build:    %s\n", status.BuildURL)
+	}
+	fmt.Fprintf(w, "Status:   %s\n", status.Status)
+	if status.Stage != "" && status.Status != store.RequestCompleted {
+		fmt.Fprintf(w, "Stage:    %s\n", status.Stage)
+	}
+	fmt.Fprintf(w, "Jobs:     %s fetched\n", progressCount(status.JobsFetched, status.JobsTotal))
+	fmt.Fprintf(w, "Chunks:   %s analyzed\n", progressCount(status.ChunksProcessed, status.ChunksTotal))
+	fmt.Fprintf(w, "Findings: %d\n", status.FindingsCount)
+	for _, note := range status.Truncated {
+		fmt.Fprintf(w, "Truncated: %s\n", note)
+	}
+	if !status.CreatedAt.IsZero() {
+		fmt.Fprintf(w, "Started:  %s\n", status.CreatedAt.Local().Format(time.RFC3339))
+	}
+	if !status.CompletedAt.IsZero() {
+		fmt.Fprintf(w, "Finished: %s (took %s)\n", status.CompletedAt.Local().Format(time.RFC3339),
+			status.CompletedAt.Sub(status.CreatedAt).Round(time.Second))
+	}
+}
+
+// progressCount formats done out of total, "3/5 (60%)", or just done
+// while the total isn't known.
+func progressCount(done, total int) string {
+	if total <= 0 {
+		return fmt.Sprintf("%d", done)
+	}
+	return fmt.Sprintf("%d/%d (%.0f%%)", done, total, float64(done)/float64(total)*100)
+}
+
+// statusJSON is the --json form of a store.RequestStatus.
+type statusJSON struct {
+	RequestID       string     `json:"request_id"`
+	BuildURL        string     `json:"build_url,omitempty"`
+	Status          string     `json:"status"`
+	Stage           string     `json:"stage,omitempty"`
+	JobsFetched     int        `json:"jobs_fetched"`
+	JobsTotal       int        `json:"jobs_total"`
+	ChunksProcessed int        `json:"chunks_processed"`
+	ChunksTotal     int        `json:"chunks_total"`
+	Findings        int        `json:"findings"`
+	Truncated       []string   `json:"truncated,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+}
+
+func writeStatusJSON(w io.Writer, status store.RequestStatus) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(newStatusJSON(status))
+}
+
+// newStatusJSON converts status to its --json form.
+func newStatusJSON(status store.RequestStatus) statusJSON {
+	out := statusJSON{
+		RequestID:       status.RequestID,
+		BuildURL:        status.BuildURL,
+		Status:          status.Status,
+		Stage:           status.Stage,
+		JobsFetched:     status.JobsFetched,
+		JobsTotal:       status.JobsTotal,
+		ChunksProcessed: status.ChunksProcessed,
+		ChunksTotal:     status.ChunksTotal,
+		Findings:        status.FindingsCount,
+		Truncated:       status.Truncated,
+		CreatedAt:       status.CreatedAt,
+	}
+	if !status.CompletedAt.IsZero() {
+		out.CompletedAt = &status.CompletedAt
+	}
+	return out
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"destill-agent/src/store"
+)
+
+func TestWriteStatus(t *testing.T) {
+	var b strings.Builder
+	writeStatus(&b, store.RequestStatus{
+		RequestID:       "req-1",
+		Status:          store.RequestProcessing,
+		Stage:           "complete",
+		JobsFetched:     5,
+		JobsTotal:       5,
+		ChunksProcessed: 12,
+		ChunksTotal:     40,
+		FindingsCount:   3,
+		Truncated:       []string{"log of job tests cut at 256 MB (DESTILL_MAX_LOG_MB)"},
+	})
+	for _, want := range []string{"Jobs:     5/5 (100%) fetched", "Chunks:   12/40 (30%) analyzed", "Findings: 3", "Truncated: log of job tests cut at 256 MB"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("writeStatus() = %q, want it to contain %q", b.String(), want)
+		}
+	}
+
+	// The chunk total isn't known until ingest completes
+	b.Reset()
+	writeStatus(&b, store.RequestStatus{RequestID: "req-1", Status: store.RequestProcessing, ChunksProcessed: 7})
+	if !strings.Contains(b.String(), "Chunks:   7 analyzed") {
+		t.Errorf("writeStatus() = %q, want the chunks without a total", b.String())
+	}
+}
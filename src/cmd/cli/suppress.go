@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"destill-agent/src/rules"
+)
+
+// suppressCmd groups commands for sharing suppression rules
+var suppressCmd = &cobra.Command{
+	Use:   "suppress",
+	Short: "Share suppression rules between pattern configs",
+}
+
+// suppressExportCmd writes the effective suppressions as a pattern pack
+var suppressExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export suppressions as a YAML file",
+	Long: `Writes the suppressions of the pattern config, including those from its
+packs, as a YAML file with a single suppressions section. Commit it to share a
+curated noise list: other repos can list it under packs: or merge it into their
+own config with 'destill suppress import'.
+
+The config is read from ~/.destill/patterns.yaml, or DESTILL_PATTERNS_FILE if set.
+
+Examples:
+  destill suppress export > suppressions.yaml
+  destill suppress export -o ci/destill-suppressions.yaml
+  destill suppress export --pack team-noise`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, _ := cmd.Flags().GetString("file")
+		outputPath, _ := cmd.Flags().GetString("output")
+		pack, _ := cmd.Flags().GetString("pack")
+
+		cfg, err := loadPatternConfig(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		suppressions := cfg.Suppressions
+		if cmd.Flags().Changed("pack") {
+			suppressions = nil
+			for _, s := range cfg.Suppressions {
+				if s.Pack == pack {
+					suppressions = append(suppressions, s)
+				}
+			}
+		}
+
+		output, err := rules.ExportSuppressions(suppressions)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if outputPath == "" {
+			os.Stdout.Write(output)
+			return
+		}
+		if err := os.WriteFile(outputPath, output, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to write suppressions: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d suppressions to %s\n", len(suppressions), outputPath)
+	},
+}
+
+// suppressImportCmd merges suppressions from a file into the pattern config
+var suppressImportCmd = &cobra.Command{
+	Use:   "import <file | ->",
+	Short: "Merge suppressions from a YAML file into the pattern config",
+	Long: `Adds the suppressions of an exported file or pattern pack to the pattern
+config. Suppressions already in the config (same name and rule, or the same
+regex under another name) are skipped. A suppression with a name that is
+already used for a different rule is reported as a conflict and left alone,
+unless --overwrite is set. The rest of the config, including comments, is kept.
+
+Every regex is checked before anything is written. Use - to read from stdin.
+
+The config is ~/.destill/patterns.yaml, or DESTILL_PATTERNS_FILE if set; it is
+created if missing.
+
+Examples:
+  destill suppress import suppressions.yaml --dry-run
+  destill suppress import ../platform/ci/destill-suppressions.yaml --overwrite
+  curl -s https://example.com/noise.yaml | destill suppress import -`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, _ := cmd.Flags().GetString("file")
+		overwrite, _ := cmd.Flags().GetBool("overwrite")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		data, err := readInput(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		suppressions, err := rules.ParseSuppressions(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", args[0], err)
+			os.Exit(1)
+		}
+
+		if path == "" {
+			path = rules.DefaultPath()
+		}
+		result, err := rules.ImportSuppressions(path, suppressions, overwrite, dryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if jsonOutput {
+			output, err := json.MarshalIndent(map[string]any{
+				"path":    path,
+				"dry_run": dryRun,
+				"result":  result,
+			}, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to marshal import result: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(output))
+			return
+		}
+		printImportResult(path, result, dryRun)
+	},
+}
+
+// loadPatternConfig reads the pattern config at path, or the default one.
+func loadPatternConfig(path string) (*rules.Config, error) {
+	if path != "" {
+		return rules.LoadFile(path)
+	}
+	cfg, _, err := rules.Load()
+	return cfg, err
+}
+
+// readInput reads a file, or stdin for "-".
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return data, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// printImportResult summarizes an import for the terminal.
+func printImportResult(path string, result rules.ImportResult, dryRun bool) {
+	verb := "Imported into"
+	if dryRun {
+		verb = "Would import into"
+	}
+	fmt.Printf("%s %s:\n", verb, path)
+	for _, group := range []struct {
+		label string
+		names []string
+	}{
+		{"added", result.Added},
+		{"updated", result.Updated},
+		{"unchanged", result.Unchanged},
+		{"conflicts (use --overwrite to replace)", result.Conflicts},
+	} {
+		if len(group.names) > 0 {
+			fmt.Printf("  %d %s: %s\n", len(group.names), group.label, strings.Join(group.names, ", "))
+		}
+	}
+	if !result.Changed() && len(result.Conflicts) == 0 {
+		fmt.Println("  nothing to import")
+	}
+}
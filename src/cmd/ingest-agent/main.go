@@ -6,18 +6,29 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"destill-agent/src/broker"
 	_ "destill-agent/src/buildkite" // Import for provider registration
+	_ "destill-agent/src/circleci"  // Import for provider registration
 	"destill-agent/src/config"
 	_ "destill-agent/src/githubactions" // Import for provider registration
+	_ "destill-agent/src/gitlab"        // Import for provider registration
 	"destill-agent/src/ingest"
+	_ "destill-agent/src/jenkins"  // Import for provider registration
+	_ "destill-agent/src/localdir" // Import for provider registration
 	"destill-agent/src/logger"
+	"destill-agent/src/selftriage"
 )
 
 func main() {
-	// Load configuration
+	// Load configuration: ~/.destill.yaml fills in unset environment variables
+	if _, _, err := config.Setup(); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
 	cfg, err := config.LoadFromEnv()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
@@ -25,40 +36,63 @@ func main() {
 	}
 
 	// Verify we're in distributed mode
-	if len(cfg.RedpandaBrokers) == 0 {
-		fmt.Fprintln(os.Stderr, "ERROR: REDPANDA_BROKERS environment variable is required for ingest agent")
+	if !cfg.Distributed() {
+		fmt.Fprintln(os.Stderr, "ERROR: REDPANDA_BROKERS, NATS_URL, or DESTILL_TRANSPORT=grpc|sqs is required for ingest agent")
 		fmt.Fprintln(os.Stderr, "Example: export REDPANDA_BROKERS=localhost:19092")
 		os.Exit(1)
 	}
 
 	// Create logger
-	log := logger.NewConsoleLogger()
+	var log logger.Logger = logger.NewConsoleLogger()
 
 	log.Info("Starting Destill Ingest Agent")
-	log.Info("Redpanda brokers: %v", cfg.RedpandaBrokers)
+	log.Info("Connecting to %s", cfg.Endpoint())
 
-	// Create Redpanda broker
-	brk, err := broker.NewRedpandaBroker(cfg.RedpandaBrokers)
+	// Create broker for the configured transport
+	brk, err := broker.NewFromConfig(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to create broker: %v\n", err)
 		os.Exit(1)
 	}
 	defer brk.Close()
 
+	// Optionally publish our own logs for 'destill self-triage --topic'
+	if publish, _ := strconv.ParseBool(os.Getenv(selftriage.EnvPublishLogs)); publish {
+		log = selftriage.PublishLogs(context.Background(), log, brk, "ingest-agent")
+	}
+
 	// Create ingest agent (no longer needs token - providers get it from env)
-	agent := ingest.NewAgent(brk, log)
+	opts, err := ingestOptionsFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+	agent := ingest.NewAgentWithOptions(brk, log, opts)
 
 	// Setup context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Handle shutdown signals
+	// Handle shutdown signals: drain first, so work in flight isn't lost,
+	// and stop anyway after DESTILL_DRAIN_TIMEOUT or on a second signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		<-sigChan
-		log.Info("Shutdown signal received, stopping agent...")
+		if cfg.DrainTimeout <= 0 {
+			log.Info("Shutdown signal received, stopping agent...")
+			cancel()
+			return
+		}
+		log.Info("Shutdown signal received, draining agent (up to %s)...", cfg.DrainTimeout)
+		agent.Drain()
+		select {
+		case <-sigChan:
+			log.Info("Second shutdown signal received, stopping agent...")
+		case <-time.After(cfg.DrainTimeout):
+			log.Error("Agent didn't drain within %s, stopping...", cfg.DrainTimeout)
+		}
 		cancel()
 	}()
 
@@ -71,3 +105,27 @@ func main() {
 
 	log.Info("Ingest agent stopped")
 }
+
+// ingestOptionsFromEnv reads the scheduling options from
+// DESTILL_INGEST_WORKERS and DESTILL_INGEST_PIPELINE_LIMIT.
+func ingestOptionsFromEnv() (ingest.Options, error) {
+	var opts ingest.Options
+	for _, setting := range []struct {
+		name string
+		dst  *int
+	}{
+		{"DESTILL_INGEST_WORKERS", &opts.Workers},
+		{"DESTILL_INGEST_PIPELINE_LIMIT", &opts.PerPipeline},
+	} {
+		value := os.Getenv(setting.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("%s must be a non-negative integer, got %q", setting.name, value)
+		}
+		*setting.dst = n
+	}
+	return opts, nil
+}
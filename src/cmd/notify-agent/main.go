@@ -0,0 +1,96 @@
+// Package main provides the standalone notify agent binary.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/config"
+	"destill-agent/src/logger"
+	"destill-agent/src/notify"
+)
+
+func main() {
+	// Load configuration: ~/.destill.yaml fills in unset environment variables
+	if _, _, err := config.Setup(); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Verify we're in distributed mode
+	if !cfg.Distributed() {
+		fmt.Fprintln(os.Stderr, "ERROR: REDPANDA_BROKERS, NATS_URL, or DESTILL_TRANSPORT=grpc|sqs is required for notify agent")
+		fmt.Fprintln(os.Stderr, "Example: export REDPANDA_BROKERS=localhost:19092")
+		os.Exit(1)
+	}
+	webhookURL := os.Getenv(notify.EnvSlackWebhook)
+	if webhookURL == "" {
+		fmt.Fprintf(os.Stderr, "ERROR: %s environment variable is required for notify agent\n", notify.EnvSlackWebhook)
+		os.Exit(1)
+	}
+
+	// Create logger
+	var log logger.Logger = logger.NewConsoleLogger()
+
+	log.Info("Starting Destill Notify Agent")
+	log.Info("Connecting to %s", cfg.Endpoint())
+
+	// Create broker for the configured transport
+	brk, err := broker.NewFromConfig(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create broker: %v\n", err)
+		os.Exit(1)
+	}
+	defer brk.Close()
+
+	// Create notify agent
+	agent := notify.NewAgent(brk, log, notify.NewSlackNotifier(webhookURL), notify.Options{
+		Idle:    10 * time.Second,
+		Timeout: 10 * time.Minute,
+	})
+
+	// Setup context with cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handle shutdown signals: drain first, so work in flight isn't lost,
+	// and stop anyway after DESTILL_DRAIN_TIMEOUT or on a second signal
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-sigChan
+		if cfg.DrainTimeout <= 0 {
+			log.Info("Shutdown signal received, stopping agent...")
+			cancel()
+			return
+		}
+		log.Info("Shutdown signal received, draining agent (up to %s)...", cfg.DrainTimeout)
+		agent.Drain()
+		select {
+		case <-sigChan:
+			log.Info("Second shutdown signal received, stopping agent...")
+		case <-time.After(cfg.DrainTimeout):
+			log.Error("Agent didn't drain within %s, stopping...", cfg.DrainTimeout)
+		}
+		cancel()
+	}()
+
+	// Run agent
+	if err := agent.Run(ctx); err != nil && err != context.Canceled {
+		fmt.Fprintf(os.Stderr, "Agent error: %v\n", err)
+		os.Exit(1)
+	}
+
+	log.Info("Notify agent stopped")
+}
@@ -1,5 +1,9 @@
-// Package main provides the main entry point for the Destill CLI.
+// Package main provides the original entry point for the Destill CLI.
 // This orchestrates all subcommands and provides mode detection (Local vs Distributed).
+//
+// Deprecated: the destill binary is built from src/cmd/cli, which has every
+// command; this root command has none and is kept only so existing builds of
+// it point users there.
 package main
 
 import (
@@ -21,6 +25,7 @@ It supports two modes:
 - Distributed Mode: Redpanda + Postgres, distributed processing
 
 Mode is auto-detected based on REDPANDA_BROKERS environment variable.`,
+	Deprecated: "build the destill CLI from ./src/cmd/cli instead (make build)",
 }
 
 func main() {
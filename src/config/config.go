@@ -5,8 +5,26 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
+// Transports the agents exchange messages over, selected with
+// DESTILL_TRANSPORT.
+const (
+	TransportRedpanda = "redpanda" // Default; REDPANDA_BROKERS
+	TransportGRPC     = "grpc"     // 'destill grpc-server' at DESTILL_GRPC_ADDR
+	TransportNATS     = "nats"     // NATS JetStream at NATS_URL; default when it's set
+	TransportSQS      = "sqs"      // AWS SNS/SQS; AWS_REGION and DESTILL_SQS_PREFIX
+)
+
+// DefaultSQSPrefix names the SNS topic and prefixes the SQS queues in sqs
+// mode.
+const DefaultSQSPrefix = "destill"
+
+// DefaultDrainTimeout is how long the agents finish their work in flight
+// after SIGTERM before stopping anyway.
+const DefaultDrainTimeout = 30 * time.Second
+
 // Config holds the application configuration.
 type Config struct {
 	// BuildkiteAPIToken is the API token for authenticating with Buildkite.
@@ -16,9 +34,66 @@ type Config struct {
 	// Empty means use in-memory broker (legacy mode).
 	RedpandaBrokers []string
 
+	// RedpandaBalancer is how consumer groups assign partitions to their
+	// members (REDPANDA_BALANCER); empty means cooperative-sticky.
+	RedpandaBalancer string
+
+	// RedpandaSessionTimeout is how long a group member may go silent
+	// before its partitions move to the others (REDPANDA_SESSION_TIMEOUT);
+	// zero means 45s.
+	RedpandaSessionTimeout time.Duration
+
 	// PostgresDSN is the Postgres connection string.
-	// Required for distributed mode.
+	// Required for distributed mode, unless SQLitePath is set.
 	PostgresDSN string
+
+	// SQLitePath is the SQLite database the agents store findings in
+	// instead of Postgres, for running them all on one machine.
+	SQLitePath string
+
+	// Transport is TransportRedpanda, TransportGRPC, TransportNATS, or
+	// TransportSQS.
+	Transport string
+
+	// GRPCAddr is the address of the gRPC server in grpc mode.
+	GRPCAddr string
+
+	// NATSURL is the NATS server URL in nats mode.
+	NATSURL string
+
+	// SQSPrefix names the SNS topic and SQS queues in sqs mode.
+	SQSPrefix string
+
+	// DrainTimeout is how long an agent finishes the work it has taken
+	// after SIGTERM before stopping anyway (DESTILL_DRAIN_TIMEOUT); zero
+	// means stop right away.
+	DrainTimeout time.Duration
+}
+
+// Distributed reports whether the agents run against a shared broker,
+// over any transport.
+func (c *Config) Distributed() bool {
+	return c.Transport == TransportGRPC || c.Transport == TransportNATS || c.Transport == TransportSQS || len(c.RedpandaBrokers) > 0
+}
+
+// StoresFindings reports whether the analyze agents must write findings to
+// the database themselves, because the transport has no Redpanda Connect
+// sink and no server doing it, or because the database is SQLite.
+func (c *Config) StoresFindings() bool {
+	return c.Transport == TransportNATS || c.Transport == TransportSQS || c.SQLitePath != ""
+}
+
+// Endpoint describes where the agents connect, for logging.
+func (c *Config) Endpoint() string {
+	switch c.Transport {
+	case TransportGRPC:
+		return "gRPC server " + c.GRPCAddr
+	case TransportNATS:
+		return "NATS server " + c.NATSURL
+	case TransportSQS:
+		return "AWS SNS/SQS with prefix " + c.SQSPrefix
+	}
+	return "Redpanda brokers " + strings.Join(c.RedpandaBrokers, ",")
 }
 
 // LoadFromEnv loads configuration from environment variables.
@@ -31,6 +106,7 @@ func LoadFromEnv() (*Config, error) {
 	cfg := &Config{
 		BuildkiteAPIToken: token,
 		PostgresDSN:       os.Getenv("POSTGRES_DSN"),
+		SQLitePath:        strings.TrimSpace(os.Getenv("DESTILL_SQLITE_PATH")),
 	}
 
 	// Parse Redpanda brokers (comma-separated)
@@ -42,11 +118,118 @@ func LoadFromEnv() (*Config, error) {
 		}
 		cfg.RedpandaBrokers = brokers
 	}
+	cfg.RedpandaBalancer = strings.ToLower(strings.TrimSpace(os.Getenv("REDPANDA_BALANCER")))
+	if v := strings.TrimSpace(os.Getenv("REDPANDA_SESSION_TIMEOUT")); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid REDPANDA_SESSION_TIMEOUT %q: expected a duration such as 30s", v)
+		}
+		cfg.RedpandaSessionTimeout = timeout
+	}
+	cfg.DrainTimeout = DefaultDrainTimeout
+	if v := strings.TrimSpace(os.Getenv("DESTILL_DRAIN_TIMEOUT")); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid DESTILL_DRAIN_TIMEOUT %q: expected a duration such as 30s", v)
+		}
+		cfg.DrainTimeout = timeout
+	}
 
-	// Validate distributed mode configuration
-	if len(cfg.RedpandaBrokers) > 0 && cfg.PostgresDSN == "" {
-		return nil, fmt.Errorf("POSTGRES_DSN is required when REDPANDA_BROKERS is set (distributed mode)")
+	transport, grpcAddr, err := TransportFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Transport, cfg.GRPCAddr = transport, grpcAddr
+	if transport == TransportNATS {
+		cfg.NATSURL = strings.TrimSpace(os.Getenv("NATS_URL"))
+	}
+	if transport == TransportSQS {
+		cfg.SQSPrefix = SQSPrefixFromEnv()
+	}
+
+	// Validate distributed mode configuration. In grpc mode the server
+	// stores findings, so agents don't need a database.
+	hasDB := cfg.PostgresDSN != "" || cfg.SQLitePath != ""
+	if cfg.Transport == TransportRedpanda && len(cfg.RedpandaBrokers) > 0 && !hasDB {
+		return nil, fmt.Errorf("POSTGRES_DSN or DESTILL_SQLITE_PATH is required when REDPANDA_BROKERS is set (distributed mode)")
+	}
+	if cfg.Transport == TransportNATS && !hasDB {
+		return nil, fmt.Errorf("POSTGRES_DSN or DESTILL_SQLITE_PATH is required when NATS_URL is set (distributed mode)")
+	}
+	if cfg.Transport == TransportSQS && !hasDB {
+		return nil, fmt.Errorf("POSTGRES_DSN or DESTILL_SQLITE_PATH is required when DESTILL_TRANSPORT=sqs (distributed mode)")
 	}
 
 	return cfg, nil
 }
+
+// TransportFromEnv reads DESTILL_TRANSPORT and, for grpc, the server
+// address from DESTILL_GRPC_ADDR. Without DESTILL_TRANSPORT the transport is
+// nats when NATS_URL is set and redpanda otherwise.
+func TransportFromEnv() (transport, grpcAddr string, err error) {
+	natsURL := strings.TrimSpace(os.Getenv("NATS_URL"))
+	transport = strings.ToLower(strings.TrimSpace(os.Getenv("DESTILL_TRANSPORT")))
+	switch transport {
+	case "":
+		if natsURL != "" {
+			return TransportNATS, "", nil
+		}
+		return TransportRedpanda, "", nil
+	case TransportRedpanda:
+		return TransportRedpanda, "", nil
+	case TransportNATS:
+		if natsURL == "" {
+			return "", "", fmt.Errorf("NATS_URL is required when DESTILL_TRANSPORT=nats")
+		}
+		return transport, "", nil
+	case TransportGRPC:
+		grpcAddr = strings.TrimSpace(os.Getenv("DESTILL_GRPC_ADDR"))
+		if grpcAddr == "" {
+			return "", "", fmt.Errorf("DESTILL_GRPC_ADDR is required when DESTILL_TRANSPORT=grpc")
+		}
+		return transport, grpcAddr, nil
+	case TransportSQS:
+		return transport, "", nil
+	}
+	return "", "", fmt.Errorf("unknown DESTILL_TRANSPORT %q (expected redpanda, grpc, nats, or sqs)", transport)
+}
+
+// SQSPrefixFromEnv reads DESTILL_SQS_PREFIX, defaulting to DefaultSQSPrefix.
+func SQSPrefixFromEnv() string {
+	if prefix := strings.TrimSpace(os.Getenv("DESTILL_SQS_PREFIX")); prefix != "" {
+		return prefix
+	}
+	return DefaultSQSPrefix
+}
+
+// LoadAliasesFromEnv loads pipeline aliases from DESTILL_PIPELINE_ALIASES.
+// Returns an empty map if the variable is not set.
+func LoadAliasesFromEnv() (map[string]string, error) {
+	return ParseAliases(os.Getenv("DESTILL_PIPELINE_ALIASES"))
+}
+
+// ParseAliases parses a comma-separated list of alias=org/pipeline pairs.
+// Example: "backend=myorg/backend,web=myorg/web-app"
+func ParseAliases(spec string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	if strings.TrimSpace(spec) == "" {
+		return aliases, nil
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, slug, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		slug = strings.Trim(strings.TrimSpace(slug), "/")
+		if !ok || name == "" || strings.Count(slug, "/") != 1 {
+			return nil, fmt.Errorf("invalid pipeline alias %q (expected alias=org/pipeline)", entry)
+		}
+		aliases[name] = slug
+	}
+
+	return aliases, nil
+}
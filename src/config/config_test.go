@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestLoadFromEnv(t *testing.T) {
@@ -47,4 +48,142 @@ func TestLoadFromEnv(t *testing.T) {
 			t.Error("LoadFromEnv() expected error for empty token, got nil")
 		}
 	})
+
+	t.Run("sqlite instead of postgres", func(t *testing.T) {
+		t.Setenv("BUILDKITE_API_TOKEN", "test-token")
+		t.Setenv("NATS_URL", "nats://localhost:4222")
+		t.Setenv("DESTILL_TRANSPORT", "")
+		t.Setenv("POSTGRES_DSN", "")
+
+		t.Setenv("DESTILL_SQLITE_PATH", "")
+		if _, err := LoadFromEnv(); err == nil {
+			t.Error("LoadFromEnv() expected error without a database, got nil")
+		}
+
+		t.Setenv("DESTILL_SQLITE_PATH", "/var/lib/destill.db")
+		cfg, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("LoadFromEnv() unexpected error: %v", err)
+		}
+		if cfg.SQLitePath != "/var/lib/destill.db" || !cfg.StoresFindings() {
+			t.Errorf("LoadFromEnv() = %+v, want the SQLite path and agents storing findings", cfg)
+		}
+	})
+
+	t.Run("consumer group rebalancing", func(t *testing.T) {
+		t.Setenv("BUILDKITE_API_TOKEN", "test-token")
+		t.Setenv("REDPANDA_BALANCER", " Range ")
+		t.Setenv("REDPANDA_SESSION_TIMEOUT", "20s")
+
+		cfg, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("LoadFromEnv() unexpected error: %v", err)
+		}
+		if cfg.RedpandaBalancer != "range" || cfg.RedpandaSessionTimeout != 20*time.Second {
+			t.Errorf("LoadFromEnv() balancer %q, session timeout %s, want range and 20s", cfg.RedpandaBalancer, cfg.RedpandaSessionTimeout)
+		}
+
+		t.Setenv("REDPANDA_SESSION_TIMEOUT", "soon")
+		if _, err := LoadFromEnv(); err == nil {
+			t.Error("LoadFromEnv() expected error for an invalid session timeout, got nil")
+		}
+	})
+
+	t.Run("drain timeout", func(t *testing.T) {
+		t.Setenv("BUILDKITE_API_TOKEN", "test-token")
+
+		cfg, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("LoadFromEnv() unexpected error: %v", err)
+		}
+		if cfg.DrainTimeout != DefaultDrainTimeout {
+			t.Errorf("DrainTimeout = %s, want default %s", cfg.DrainTimeout, DefaultDrainTimeout)
+		}
+
+		t.Setenv("DESTILL_DRAIN_TIMEOUT", "0s")
+		cfg, err = LoadFromEnv()
+		if err != nil {
+			t.Fatalf("LoadFromEnv() unexpected error: %v", err)
+		}
+		if cfg.DrainTimeout != 0 {
+			t.Errorf("DrainTimeout = %s, want 0", cfg.DrainTimeout)
+		}
+
+		t.Setenv("DESTILL_DRAIN_TIMEOUT", "-1m")
+		if _, err := LoadFromEnv(); err == nil {
+			t.Error("LoadFromEnv() expected error for a negative drain timeout, got nil")
+		}
+	})
+}
+
+func TestParseAliases(t *testing.T) {
+	t.Run("valid aliases", func(t *testing.T) {
+		aliases, err := ParseAliases("backend=myorg/backend, web=myorg/web-app/")
+		if err != nil {
+			t.Fatalf("ParseAliases() unexpected error: %v", err)
+		}
+		if aliases["backend"] != "myorg/backend" {
+			t.Errorf("backend = %q, want %q", aliases["backend"], "myorg/backend")
+		}
+		if aliases["web"] != "myorg/web-app" {
+			t.Errorf("web = %q, want %q", aliases["web"], "myorg/web-app")
+		}
+	})
+
+	t.Run("empty spec", func(t *testing.T) {
+		aliases, err := ParseAliases("")
+		if err != nil {
+			t.Fatalf("ParseAliases() unexpected error: %v", err)
+		}
+		if len(aliases) != 0 {
+			t.Errorf("ParseAliases() = %v, want empty map", aliases)
+		}
+	})
+
+	t.Run("invalid entry", func(t *testing.T) {
+		if _, err := ParseAliases("backend"); err == nil {
+			t.Error("ParseAliases() expected error for missing slug, got nil")
+		}
+		if _, err := ParseAliases("backend=just-a-pipeline"); err == nil {
+			t.Error("ParseAliases() expected error for slug without org, got nil")
+		}
+	})
+}
+
+func TestTransportFromEnv(t *testing.T) {
+	tests := []struct {
+		name          string
+		transport     string
+		grpcAddr      string
+		natsURL       string
+		wantTransport string
+		wantAddr      string
+		wantErr       bool
+	}{
+		{name: "default", wantTransport: TransportRedpanda},
+		{name: "redpanda", transport: "redpanda", wantTransport: TransportRedpanda},
+		{name: "grpc", transport: "GRPC", grpcAddr: " localhost:50051 ", wantTransport: TransportGRPC, wantAddr: "localhost:50051"},
+		{name: "grpc without address", transport: "grpc", wantErr: true},
+		{name: "nats from URL", natsURL: "nats://localhost:4222", wantTransport: TransportNATS},
+		{name: "explicit transport wins over NATS URL", transport: "redpanda", natsURL: "nats://localhost:4222", wantTransport: TransportRedpanda},
+		{name: "nats without URL", transport: "nats", wantErr: true},
+		{name: "sqs", transport: "sqs", natsURL: "nats://localhost:4222", wantTransport: TransportSQS},
+		{name: "unknown", transport: "kafka", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DESTILL_TRANSPORT", tt.transport)
+			t.Setenv("DESTILL_GRPC_ADDR", tt.grpcAddr)
+			t.Setenv("NATS_URL", tt.natsURL)
+
+			transport, addr, err := TransportFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TransportFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if transport != tt.wantTransport || addr != tt.wantAddr {
+				t.Errorf("TransportFromEnv() = %q, %q, want %q, %q", transport, addr, tt.wantTransport, tt.wantAddr)
+			}
+		})
+	}
 }
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Themes the TUI ships with (DESTILL_THEME).
+const (
+	ThemeDark       = "dark"
+	ThemeLight      = "light"
+	ThemeAccessible = "accessible"
+)
+
+// Environment variables for the TUI's look (see Theme).
+const (
+	EnvTheme       = "DESTILL_THEME"
+	EnvThemeColors = "DESTILL_THEME_COLORS"
+	EnvASCII       = "DESTILL_ASCII"
+)
+
+// Theme configures the TUI's look: a built-in theme, colors replacing some
+// of its own, and ASCII-only output for terminals that can't draw more.
+type Theme struct {
+	// Name is dark (default), light, or accessible (DESTILL_THEME).
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// Colors replace the theme's colors by role (see ThemeColorRoles),
+	// e.g. error: "#d70000" (DESTILL_THEME_COLORS).
+	Colors map[string]string `yaml:"colors,omitempty" json:"colors,omitempty"`
+
+	// ASCII draws with ASCII only, without emoji or spinners (DESTILL_ASCII).
+	ASCII bool `yaml:"ascii,omitempty" json:"ascii,omitempty"`
+}
+
+// ThemeColorRoles are the colors a theme's Colors can replace.
+var ThemeColorRoles = []string{
+	"primary", "accent", "warning", "success", "text", "muted", "border",
+	"selected", "error", "error_background", "unique", "noise",
+}
+
+// ValidateThemeName returns an error unless name is a built-in theme or
+// empty, for the default.
+func ValidateThemeName(name string) error {
+	switch name {
+	case "", ThemeDark, ThemeLight, ThemeAccessible:
+		return nil
+	}
+	return fmt.Errorf("unknown theme %q (expected %s, %s, or %s)", name, ThemeDark, ThemeLight, ThemeAccessible)
+}
+
+// ParseThemeColors parses comma-separated role=color pairs, e.g.
+// "error=#d70000,muted=244". Colors are hex (#rgb or #rrggbb) or ANSI
+// color numbers from 0 to 255.
+func ParseThemeColors(spec string) (map[string]string, error) {
+	colors := make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		role, color, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid theme color %q (expected role=color)", pair)
+		}
+		role, color = strings.TrimSpace(role), strings.TrimSpace(color)
+		if !slices.Contains(ThemeColorRoles, role) {
+			return nil, fmt.Errorf("unknown theme color %q (expected %s)", role, strings.Join(ThemeColorRoles, ", "))
+		}
+		if !isColor(color) {
+			return nil, fmt.Errorf("invalid theme color %s=%s (expected #rgb, #rrggbb, or 0-255)", role, color)
+		}
+		colors[role] = color
+	}
+	return colors, nil
+}
+
+// colorsSpec formats Colors as DESTILL_THEME_COLORS does.
+func (t Theme) colorsSpec() string {
+	pairs := make([]string, 0, len(t.Colors))
+	for role, color := range t.Colors {
+		pairs = append(pairs, role+"="+color)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// isColor reports whether color is a hex color or an ANSI color number.
+func isColor(color string) bool {
+	if hex, ok := strings.CutPrefix(color, "#"); ok {
+		if len(hex) != 3 && len(hex) != 6 {
+			return false
+		}
+		_, err := strconv.ParseUint(hex, 16, 32)
+		return err == nil
+	}
+	n, err := strconv.Atoi(color)
+	return err == nil && n >= 0 && n <= 255
+}
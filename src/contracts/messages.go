@@ -1,25 +1,62 @@
 // Package contracts defines message types for the Distributed Data Plane architecture.
 package contracts
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
 
 // LogChunk represents a chunk of log data for the distributed architecture.
 // Published to: destill.logs.raw
-// Key: {build_id}
+// Key: {request_id}/{job_id} (see Key)
 type LogChunk struct {
 	RequestID   string            `json:"request_id"`
 	BuildID     string            `json:"build_id"`
 	JobName     string            `json:"job_name"`
 	JobID       string            `json:"job_id"`
 	ChunkIndex  int               `json:"chunk_index"`
-	TotalChunks int               `json:"total_chunks"`
+	TotalChunks int               `json:"total_chunks"` // 0 for a streamed log, whose total is unknown
 	Content     string            `json:"content"`
 	LineStart   int               `json:"line_start"` // First line number in this chunk
 	LineEnd     int               `json:"line_end"`   // Last line number in this chunk
 	Metadata    map[string]string `json:"metadata"`
+
+	// Options from the originating AnalysisRequest (nil = defaults)
+	Options *AnalysisOptions `json:"options,omitempty"`
+
+	// Message hashes of the findings in the request's baseline build
+	// (AnalysisOptions.BaselineURL); findings with them are suppressed
+	BaselineHashes []string `json:"baseline_hashes,omitempty"`
+
+	// How many times the chunk was dead-lettered before; set when it's
+	// replayed from destill.logs.dlq (see DeadLetter.Attempts)
+	DeadLetters int `json:"dead_letters,omitempty"`
+}
+
+// Key is the chunk's message key on destill.logs.raw. Brokers partition
+// by key, so a job's chunks stay in order on one partition while the jobs
+// of a build spread across partitions, and so across the analyze agents
+// sharing a consumer group.
+func (c *LogChunk) Key() string {
+	return c.RequestID + "/" + c.JobID
+}
+
+// ID identifies the chunk within its request, so a chunk delivered again
+// is recognized: a job's log is chunked per step, and each of its text
+// artifacts on its own (see MetadataArtifact), numbering the chunks from 0.
+func (c *LogChunk) ID() string {
+	return fmt.Sprintf("%s/%s/%s/%d", c.JobID, c.Metadata["step_index"], c.Metadata[MetadataArtifact], c.ChunkIndex)
 }
 
 // TriageCard represents an analysis finding with chunk-aware context.
+// It's the one schema of findings: the analyzer, stores, TUI, reports, and
+// MCP server all pass it as is, converting it only where it's serialized
+// (store columns, MCP's Finding).
 // Published to: destill.analysis.findings
 // Key: {request_id}
 type TriageCard struct {
@@ -39,6 +76,10 @@ type TriageCard struct {
 	NormalizedMsg   string  `json:"normalized_message"`
 	ConfidenceScore float64 `json:"confidence_score"`
 
+	// Category is the language or tool ecosystem of the finding, one of the
+	// Category* values, or "" when none is recognized
+	Category string `json:"category,omitempty"`
+
 	// Context (from chunk only - may be truncated)
 	PreContext  []string `json:"pre_context"`  // Up to 15 lines before
 	PostContext []string `json:"post_context"` // Up to 30 lines after
@@ -51,15 +92,318 @@ type TriageCard struct {
 	// Metadata
 	Metadata  map[string]string `json:"metadata"`
 	Timestamp string            `json:"timestamp"`
+
+	// Summary is set by the optional LLM summarization stage
+	Summary *Summary `json:"summary,omitempty"`
+
+	// Remediation is a known fix for the failure, from the remediation rules
+	Remediation *Remediation `json:"remediation,omitempty"`
+
+	// Assignee is who is triaging the finding, set with 'destill assign' or
+	// the TUI and kept in the store
+	Assignee string `json:"assignee,omitempty"`
+
+	// Permalink is the finding's destill:// link, set on JSON output only
+	Permalink string `json:"permalink,omitempty"`
+}
+
+// Finding categories: the language or tool ecosystem a finding belongs
+// to, told by the analyzer from the patterns that matched it.
+const (
+	CategoryGo         = "go"
+	CategoryJVM        = "jvm"
+	CategoryPython     = "python"
+	CategoryNode       = "node"
+	CategoryDocker     = "docker"
+	CategoryTerraform  = "terraform"
+	CategoryKubernetes = "k8s"
+)
+
+// Summary is a short LLM-written explanation of a finding.
+type Summary struct {
+	RootCause    string `json:"root_cause"`
+	SuggestedFix string `json:"suggested_fix,omitempty"`
+	Model        string `json:"model,omitempty"`
 }
 
+// Remediation is a suggested fix for a well-known failure: a command or
+// config snippet to try, and why.
+type Remediation struct {
+	Name        string `json:"name"`
+	Fix         string `json:"fix"`
+	Description string `json:"description,omitempty"`
+}
+
+// Runner environment metadata keys. The ingest agent fills them in from the
+// environment dump many CI systems print at the start of a job log, so
+// findings can be correlated to agent pools and images.
+const (
+	MetadataRunnerName     = "runner_name"
+	MetadataRunnerLabels   = "runner_labels"
+	MetadataRunnerQueue    = "runner_queue"
+	MetadataRunnerOS       = "runner_os"
+	MetadataRunnerImage    = "runner_image"
+	MetadataContainerImage = "container_image"
+)
+
+// RunnerMetadataKeys lists the runner environment metadata keys in display order.
+var RunnerMetadataKeys = []string{
+	MetadataRunnerName,
+	MetadataRunnerQueue,
+	MetadataRunnerLabels,
+	MetadataRunnerOS,
+	MetadataRunnerImage,
+	MetadataContainerImage,
+}
+
+// MetadataProviderLink is the provider's web link to the finding's log line
+// (or its job, where the provider has no line anchors).
+const MetadataProviderLink = "provider_link"
+
+// Retry metadata keys, set on findings from jobs that were retried within the
+// build. Attempts of one job share a retry group (the first attempt's job ID).
+const (
+	MetadataRetryGroup = "retry_group"
+	MetadataAttempt    = "attempt"  // 1-based
+	MetadataAttempts   = "attempts" // Total attempts of the job
+
+	// MetadataRetryOutcome is set at read time (see ranking.MarkTransients).
+	// RetryTransient marks a finding that disappeared after a retry.
+	MetadataRetryOutcome = "retry_outcome"
+	RetryTransient       = "transient"
+)
+
+// Job dependency metadata keys, set by the ingest agent on the log chunks
+// of builds whose provider reports which jobs wait on which (Buildkite wait
+// steps and depends_on, GitHub Actions needs:), so findings carry them too.
+// A downstream job ran after a job it depends on, directly or not, failed:
+// its failure is likely an echo of that one, the root cause.
+const (
+	MetadataCascade        = "cascade"         // CascadeRoot or CascadeDownstream
+	MetadataFailedUpstream = "failed_upstream" // Names of the failed jobs upstream, separated by FailedUpstreamSeparator
+
+	// FailedUpstreamSeparator separates job names, which may contain
+	// commas, e.g. GitHub Actions matrix jobs
+	FailedUpstreamSeparator = "; "
+
+	CascadeRoot       = "root"
+	CascadeDownstream = "downstream"
+)
+
+// MetadataJobs is set by the analyze agent on a finding whose message
+// appeared in several jobs of a build, e.g. every leg of a test matrix: it
+// publishes one card for them all, naming the jobs separated by
+// FailedUpstreamSeparator. The card's JobName is one of them, and its
+// recurrence count counts every occurrence.
+const MetadataJobs = "jobs"
+
+// Exit metadata keys, set by the analyzer on findings of a failed job in
+// the ExitWindowLines before the runner's final "command exited with status
+// N" line. The error region right before the exit is usually the root cause;
+// the nearest finding to it is the job's exit anchor.
+const (
+	MetadataExitDistance = "exit_distance" // Lines from the finding to the exit line
+	MetadataExitAnchor   = "exit_anchor"   // "true" on the nearest finding before the exit line
+
+	// ExitWindowLines is how far before the exit line findings count as near it
+	ExitWindowLines = 50
+)
+
+// Timing metadata keys, making up a build's timing profile. The ingest
+// agent sets the job's duration (and the step's, where the provider reports
+// it) on every log chunk, so findings carry them too. Durations are in
+// milliseconds.
+const (
+	MetadataJobDuration       = "job_duration_ms"
+	MetadataStepDuration      = "step_duration_ms"
+	MetadataMedianJobDuration = "median_job_duration_ms" // Median job of the build; slow-job findings only
+)
+
+// MetadataFindingType marks findings that don't come from a log line.
+// FindingTypeSlowJob is an informational finding for a job that ran much
+// longer than the build's median job, a hint of a performance regression.
+// FindingTypeChunkSkipped marks a chunk the analyze agent gave up on (see
+// DeadLetter); MetadataSkipReason says why.
+const (
+	MetadataFindingType     = "finding_type"
+	FindingTypeSlowJob      = "slow_job"
+	FindingTypeChunkSkipped = "chunk_skipped"
+	MetadataSkipReason      = "skip_reason"
+)
+
+// MetadataInterrupted is set on the log chunks of a job CI stopped before
+// it finished, by its state or the marker its runner printed, so findings
+// carry it too: the errors at the end of its log are likely those of the
+// processes being killed. Each such job also gets a finding of its own,
+// FindingTypeJobTimeout or FindingTypeJobCanceled, with guidance.
+const (
+	MetadataInterrupted    = "interrupted" // InterruptedTimeout or InterruptedCanceled
+	InterruptedTimeout     = "timeout"
+	InterruptedCanceled    = "canceled"
+	FindingTypeJobTimeout  = "job_timeout"
+	FindingTypeJobCanceled = "job_canceled"
+)
+
+// Test failure metadata keys, set on findings for a failed test recognized
+// in test runner output (go test, pytest) or a JUnit XML report rather than
+// taken from a single error line. MetadataAssertionDiff holds the test's
+// assertion output, one line per line; MetadataTestReport is the path of the
+// JUnit report artifact the failure came from, if any.
+const (
+	FindingTypeTestFailure = "test_failure"
+	MetadataTestFramework  = "test_framework" // One of the TestFramework* values
+	MetadataTestName       = "test_name"
+	MetadataAssertionDiff  = "assertion_diff"
+	MetadataTestReport     = "test_report"
+	TestFrameworkGo        = "go"
+	TestFrameworkPytest    = "pytest"
+	TestFrameworkJUnit     = "junit"
+)
+
+// Stack trace metadata keys, set on findings assembled from a multi-line
+// stack trace rather than taken from a single error line. The finding's
+// message is the exception header and its context holds the frames;
+// MetadataTopFrame is the innermost frame, which the message hash is keyed
+// on, and MetadataStackFrames the number of frames.
+const (
+	FindingTypeStackTrace   = "stack_trace"
+	MetadataStackLanguage   = "stack_language" // One of the StackLanguage* values
+	MetadataTopFrame        = "top_frame"
+	MetadataStackFrames     = "stack_frames"
+	StackLanguageJava       = "java"
+	StackLanguageJavaScript = "javascript"
+	StackLanguagePython     = "python"
+	StackLanguageGo         = "go"
+)
+
+// MetadataArtifact is the path of the build artifact a log chunk, and the
+// findings in it, came from, for text artifacts (crash logs, *.log files)
+// analyzed alongside the console log. Unset for the console log itself.
+const MetadataArtifact = "artifact_path"
+
+// Cross-build recurrence metadata keys, set at read time from the message
+// hash history (see store.MarkBuildRecurrence): the finding's message
+// appeared in MetadataBuildsSeen of the last MetadataBuildsWindow analyzed
+// builds of its pipeline.
+const (
+	MetadataBuildsSeen   = "builds_seen"
+	MetadataBuildsWindow = "builds_window"
+)
+
+// Bisect metadata keys, set on findings from the failed jobs of a
+// scheduled (nightly) build when the last passed scheduled build on its
+// branch ran an earlier commit: the failure was introduced after
+// MetadataBisectGood, up to MetadataBisectBad, in MetadataBisectCommits
+// commits (unset when the provider can't count them).
+const (
+	MetadataBisectGood      = "bisect_good_commit"
+	MetadataBisectBad       = "bisect_bad_commit"
+	MetadataBisectCommits   = "bisect_commits"
+	MetadataBisectGoodBuild = "bisect_good_build_url"
+)
+
+// MetadataOwnerTeam is the team owning a finding, from ownership
+// attribution. Findings without one are unowned and match no team filter.
+const MetadataOwnerTeam = "owner_team"
+
+// Flakiness metadata key, set at read time from the findings of earlier
+// builds of the pipeline (see flaky.Classify): the finding failed
+// intermittently before (FlakinessLikelyFlaky), or not at all
+// (FlakinessNewRegression).
+const (
+	MetadataFlakiness      = "flakiness"
+	FlakinessLikelyFlaky   = "likely_flaky"
+	FlakinessNewRegression = "new_regression"
+)
+
+// Suppression metadata keys, set by the analyze agent on findings matching
+// a rule of the repo's .destill-ignore.yaml (see rules.IgnoreRule): the
+// rule's name and its reason. Suppressed findings are kept so they can be
+// reviewed, but the TUI and notifications leave them out.
+const (
+	MetadataSuppressedBy     = "suppressed_by"
+	MetadataSuppressedReason = "suppressed_reason"
+)
+
+// Reasons a chunk is skipped and dead-lettered.
+const (
+	SkipReasonTimeout = "timeout"
+	SkipReasonPanic   = "panic"
+	SkipReasonInvalid = "invalid" // The message couldn't be decoded
+)
+
+// MaxDeadLetterAttempts is how many times a message may fail before it's
+// treated as poison: 'destill dlq replay' leaves it on the dead-letter topic.
+const MaxDeadLetterAttempts = 3
+
+// Re-run metadata keys, set at read time on findings of a build analyzed
+// before (see ranking.MarkRerun). MetadataPreviousRequest is the request the
+// findings were compared with; MetadataRerunStatus says whether a finding
+// was already there (RerunCarriedOver) or appeared in this run (RerunNew).
+const (
+	MetadataPreviousRequest = "previous_request_id"
+	MetadataRerunStatus     = "rerun_status"
+	RerunCarriedOver        = "carried_over"
+	RerunNew                = "new"
+)
+
+// Comparison metadata keys, set at read time on the findings of two requests
+// compared with 'destill view --compare' (see ranking.CompareRequests).
+// MetadataCompareFirst and MetadataCompareSecond are the requests compared;
+// MetadataComparison says whether a finding is in both of them
+// (ComparisonShared) or only in one (ComparisonOnlyFirst,
+// ComparisonOnlySecond).
+const (
+	MetadataCompareFirst  = "compare_first_request_id"
+	MetadataCompareSecond = "compare_second_request_id"
+	MetadataComparison    = "comparison"
+	ComparisonOnlyFirst   = "only_first"
+	ComparisonOnlySecond  = "only_second"
+	ComparisonShared      = "shared"
+)
+
+// AnalysisRequestVersion is the current AnalysisRequest schema version.
+//
+//	1 - request_id, build_url, timestamp (messages without a version field)
+//	2 - adds options
+const AnalysisRequestVersion = 2
+
 // AnalysisRequest represents a request to analyze a build.
 // Published to: destill.requests
 // Key: {request_id}
 type AnalysisRequest struct {
-	RequestID string `json:"request_id"`
-	BuildURL  string `json:"build_url"`
-	Timestamp string `json:"timestamp"`
+	Version   int              `json:"version,omitempty"` // 0 is treated as 1
+	RequestID string           `json:"request_id"`
+	BuildURL  string           `json:"build_url"`
+	Timestamp string           `json:"timestamp"`
+	Options   *AnalysisOptions `json:"options,omitempty"` // nil = defaults
+}
+
+// AnalysisOptions are the per-request analysis knobs. Zero values mean
+// "use the agent default", so a v1 request behaves exactly as before.
+type AnalysisOptions struct {
+	FailedOnly       bool     `json:"failed_only,omitempty"`        // Only ingest jobs that failed
+	MinConfidence    float64  `json:"min_confidence,omitempty"`     // Drop findings below this (default 0.5)
+	PreContextLines  int      `json:"pre_context_lines,omitempty"`  // Lines before a finding (default 15)
+	PostContextLines int      `json:"post_context_lines,omitempty"` // Lines after a finding (default 30)
+	BaselineURL      string   `json:"baseline_url,omitempty"`       // Known-good build; findings it also has are suppressed
+	Formats          []string `json:"formats,omitempty"`            // Requested output formats, e.g. "json"
+	Notify           bool     `json:"notify,omitempty"`             // Post a summary when analysis completes (see notify)
+	Artifacts        bool     `json:"artifacts,omitempty"`          // Also analyze the jobs' text artifacts (see MetadataArtifact)
+}
+
+// Validate checks that option values are in range. A nil receiver is valid.
+func (o *AnalysisOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.MinConfidence < 0 || o.MinConfidence > 1 {
+		return fmt.Errorf("min_confidence %.2f must be between 0 and 1", o.MinConfidence)
+	}
+	if o.PreContextLines < 0 || o.PostContextLines < 0 {
+		return fmt.Errorf("context line counts must not be negative")
+	}
+	return nil
 }
 
 // RequestStatus represents the status of an analysis request.
@@ -77,12 +421,52 @@ type RequestStatus struct {
 // Key: {request_id}
 type ProgressUpdate struct {
 	RequestID string `json:"request_id"`
-	Stage     string `json:"stage"`   // e.g., "Downloading build metadata", "Fetching logs"
-	Current   int    `json:"current"` // Current item number (0 if not applicable)
-	Total     int    `json:"total"`   // Total items (0 if not applicable)
+	Stage     string `json:"stage"`          // e.g., "Downloading build metadata", "Fetching logs"
+	Unit      string `json:"unit,omitempty"` // What Current and Total count: ProgressUnitJobs or ProgressUnitChunks
+	Current   int    `json:"current"`        // Current item number (0 if not applicable)
+	Total     int    `json:"total"`          // Total items (0 if not applicable or not yet known)
 	Timestamp string `json:"timestamp"`
 }
 
+// Progress units. The ingest agent counts the jobs whose logs it has
+// fetched, the analyze agent the log chunks it has analyzed.
+const (
+	ProgressUnitJobs   = "jobs"
+	ProgressUnitChunks = "chunks"
+)
+
+// StageAnalyzing is the stage of the analyze agent's progress updates.
+const StageAnalyzing = "Analyzing logs"
+
+// OwnedBy reports whether the finding's owner team (MetadataOwnerTeam) is
+// team, ignoring case.
+func (c *TriageCard) OwnedBy(team string) bool {
+	owner := c.Metadata[MetadataOwnerTeam]
+	return owner != "" && strings.EqualFold(owner, team)
+}
+
+// Suppressed reports whether an ignore rule suppressed the finding
+// (MetadataSuppressedBy).
+func (c *TriageCard) Suppressed() bool {
+	return c.Metadata[MetadataSuppressedBy] != ""
+}
+
+// OwnerTeams returns the distinct owner teams of cards, sorted. Teams
+// differing only in case are one, spelled as first seen.
+func OwnerTeams(cards []TriageCard) []string {
+	seen := make(map[string]bool)
+	var teams []string
+	for _, card := range cards {
+		team := card.Metadata[MetadataOwnerTeam]
+		if team != "" && !seen[strings.ToLower(team)] {
+			seen[strings.ToLower(team)] = true
+			teams = append(teams, team)
+		}
+	}
+	sort.Strings(teams)
+	return teams
+}
+
 // GetRecurrenceCount returns the recurrence count from metadata, defaulting to 1.
 func (c *TriageCard) GetRecurrenceCount() int {
 	if c.Metadata == nil {
@@ -105,9 +489,150 @@ func (c *TriageCard) SetRecurrenceCount(count int) {
 	c.Metadata["recurrence_count"] = fmt.Sprintf("%d", count)
 }
 
+// Jobs returns the names of the jobs the finding appeared in: those in
+// MetadataJobs for a finding merged across jobs, else its JobName.
+func (c *TriageCard) Jobs() []string {
+	if jobs := c.Metadata[MetadataJobs]; jobs != "" {
+		return strings.Split(jobs, FailedUpstreamSeparator)
+	}
+	return []string{c.JobName}
+}
+
+// AddJobs records more jobs the finding appeared in, in MetadataJobs.
+func (c *TriageCard) AddJobs(jobs ...string) {
+	all := c.Jobs()
+	for _, job := range jobs {
+		if !slices.Contains(all, job) {
+			all = append(all, job)
+		}
+	}
+	if len(all) < 2 {
+		return
+	}
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string)
+	}
+	c.Metadata[MetadataJobs] = strings.Join(all, FailedUpstreamSeparator)
+}
+
+// PerJob splits a finding merged across jobs into a card for each job
+// (see MetadataJobs), which can't say how often it recurred in its job.
+// Other findings are returned as they are.
+func (c TriageCard) PerJob() []TriageCard {
+	jobs := c.Jobs()
+	if len(jobs) < 2 {
+		return []TriageCard{c}
+	}
+	cards := make([]TriageCard, len(jobs))
+	for i, job := range jobs {
+		cards[i] = c
+		cards[i].JobName = job
+		cards[i].Metadata = maps.Clone(c.Metadata)
+		delete(cards[i].Metadata, MetadataJobs)
+		delete(cards[i].Metadata, "recurrence_count")
+	}
+	return cards
+}
+
+// GetBuildRecurrence returns how many of the recent builds of its pipeline
+// the finding's message appeared in, and how many builds that is out of.
+// Both are 0 when the history wasn't looked up.
+func (c *TriageCard) GetBuildRecurrence() (seen, builds int) {
+	seen, _ = strconv.Atoi(c.Metadata[MetadataBuildsSeen])
+	builds, _ = strconv.Atoi(c.Metadata[MetadataBuildsWindow])
+	return seen, builds
+}
+
+// Control message types (see ControlMessage).
+const (
+	// ControlIngestComplete: the ingest agent has published every log chunk
+	// of the request (Chunks), and the findings it makes itself (Findings),
+	// such as failed tests from JUnit reports and slow jobs.
+	ControlIngestComplete = "ingest_complete"
+
+	// ControlAnalysisComplete: the analyze agents have processed every chunk
+	// of the request. Findings counts all the request's findings, the
+	// ingest agent's included, so a collector can tell when it has them
+	// all; 0 means the build has none. The ingest agent publishes it
+	// itself for a request without log chunks.
+	ControlAnalysisComplete = "analysis_complete"
+
+	// ControlChunksAnalyzed: an analyze agent (Agent) sharing the request's
+	// chunks with others has processed Chunks of them, publishing Findings,
+	// so far. The agents sum each other's counts to tell when the request's
+	// analysis is complete.
+	ControlChunksAnalyzed = "chunks_analyzed"
+
+	// ControlPrioritize: a build cop wants the request ingested next (see
+	// 'destill queue bump'). Ingest agents holding it in their queue move it
+	// to the front.
+	ControlPrioritize = "prioritize"
+)
+
+// ControlMessage marks a point in a request's processing, so collectors
+// can stop when the analysis is done rather than after an idle timeout, or
+// asks the agents to act on a request.
+// Published to: destill.control
+// Key: {request_id}
+type ControlMessage struct {
+	Type      string `json:"type"` // One of the Control* types above
+	RequestID string `json:"request_id"`
+	Chunks    int    `json:"chunks"`
+	Findings  int    `json:"findings"`
+	Agent     string `json:"agent,omitempty"` // The reporting agent (chunks analyzed)
+	Timestamp string `json:"timestamp"`       // RFC3339
+
+	// Truncated says what the agent's limits left out of the analysis,
+	// such as a log cut at its size limit (ingest and analysis complete,
+	// chunks analyzed)
+	Truncated []string `json:"truncated,omitempty"`
+}
+
+// AgentLogEntry is a single log message from a destill agent.
+// Published to: destill.agent.logs
+// Key: {agent}
+type AgentLogEntry struct {
+	Timestamp string `json:"timestamp"` // RFC3339
+	Agent     string `json:"agent"`     // e.g. "ingest-agent", or a component like "IngestAgent"
+	Level     string `json:"level"`     // info, error, debug
+	Message   string `json:"message"`
+}
+
+// DeadLetter is a message an agent gave up on, kept for offline
+// inspection. Value is the original message.
+// Published to: destill.logs.dlq
+// Key: {request_id}
+type DeadLetter struct {
+	Topic    string          `json:"topic"` // Where the message came from
+	Key      string          `json:"key"`
+	Value    json.RawMessage `json:"value"`
+	Agent    string          `json:"agent"`
+	Reason   string          `json:"reason"` // SkipReasonTimeout, SkipReasonPanic, or SkipReasonInvalid
+	Error    string          `json:"error"`
+	FailedAt string          `json:"failed_at"` // RFC3339
+	Attempts int             `json:"attempts"`  // Times the message failed, counting replays
+}
+
+// Poison reports whether the message failed too often to be replayed
+// again, or can't be decoded at all.
+func (d *DeadLetter) Poison() bool {
+	return d.Reason == SkipReasonInvalid || d.Attempts >= MaxDeadLetterAttempts
+}
+
+// RequestID returns the request of the message: a log chunk's own, or else
+// the request its key starts with (see LogChunk.Key).
+func (d *DeadLetter) RequestID() string {
+	var chunk LogChunk
+	if d.Topic == TopicLogsRaw && json.Unmarshal(d.Value, &chunk) == nil && chunk.RequestID != "" {
+		return chunk.RequestID
+	}
+	requestID, _, _ := strings.Cut(d.Key, "/")
+	return requestID
+}
+
 // DeduplicateCards removes duplicate findings by MessageHash.
-// When duplicates are found, the first occurrence is kept and its recurrence count
-// is incremented.
+// When duplicates are found, the first occurrence is kept, its recurrence count
+// is incremented by theirs, and their jobs are added to it (see MetadataJobs).
 func DeduplicateCards(cards []TriageCard) []TriageCard {
 	seen := make(map[string]int) // MessageHash -> index in result slice
 	result := []TriageCard{}
@@ -115,7 +640,8 @@ func DeduplicateCards(cards []TriageCard) []TriageCard {
 	for _, card := range cards {
 		if idx, exists := seen[card.MessageHash]; exists {
 			// Duplicate found - increment recurrence count on existing card
-			result[idx].SetRecurrenceCount(result[idx].GetRecurrenceCount() + 1)
+			result[idx].SetRecurrenceCount(result[idx].GetRecurrenceCount() + card.GetRecurrenceCount())
+			result[idx].AddJobs(card.Jobs()...)
 		} else {
 			// First occurrence - add to result
 			seen[card.MessageHash] = len(result)
@@ -142,4 +668,14 @@ const (
 
 	// TopicProgress contains progress updates during analysis
 	TopicProgress = "destill.progress"
+
+	// TopicAgentLogs contains the agents' own log entries (opt-in, for self-triage)
+	TopicAgentLogs = "destill.agent.logs"
+
+	// TopicLogsDLQ contains log chunks the analyze agent gave up on (dead letters)
+	TopicLogsDLQ = "destill.logs.dlq"
+
+	// TopicControl contains control messages marking the end of a request's
+	// ingestion and analysis, and prioritizing queued requests
+	TopicControl = "destill.control"
 )
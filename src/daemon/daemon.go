@@ -0,0 +1,432 @@
+// Package daemon runs scheduled pipeline sweeps: each sweep finds the most
+// recent failed build of every configured pipeline, submits it for analysis,
+// and notifies according to the notify hint from the pipeline rules.
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/logger"
+	"destill-agent/src/provider"
+	"destill-agent/src/ranking"
+	"destill-agent/src/rules"
+)
+
+// Target is a pipeline branch to sweep.
+type Target struct {
+	Pipeline string // Pipeline URL, org/pipeline slug, or alias
+	Branch   string
+}
+
+// ParseTarget parses "pipeline" or "pipeline@branch".
+func ParseTarget(spec, defaultBranch string) Target {
+	spec = strings.TrimSpace(spec)
+	if i := strings.LastIndex(spec, "@"); i > 0 {
+		return Target{Pipeline: spec[:i], Branch: spec[i+1:]}
+	}
+	return Target{Pipeline: spec, Branch: defaultBranch}
+}
+
+func (t Target) String() string {
+	return t.Pipeline + "@" + t.Branch
+}
+
+// Report is the result of analyzing one failed build.
+type Report struct {
+	Pipeline  string                 `json:"pipeline"`
+	Branch    string                 `json:"branch"`
+	BuildURL  string                 `json:"build_url"`
+	RequestID string                 `json:"request_id"`
+	Priority  string                 `json:"priority,omitempty"`
+	Findings  []contracts.TriageCard `json:"findings"`
+}
+
+// Options configure a Daemon.
+type Options struct {
+	Targets []Target
+	Aliases map[string]string // Pipeline aliases from DESTILL_PIPELINE_ALIASES
+
+	MaxAge  time.Duration // Skip failed builds older than this (0 = no limit)
+	Idle    time.Duration // Once the analysis completes, stop waiting this long after the findings still missing
+	Timeout time.Duration // Stop collecting findings for a build after this long
+}
+
+// event is a finding or the analysis completing for a submitted request.
+type event struct {
+	card     *contracts.TriageCard
+	complete bool
+	findings int // Findings the analysis counted, when complete
+}
+
+// waiter receives the events of one submitted request.
+type waiter struct {
+	events chan event
+	done   chan struct{} // Closed when the collector stops reading
+}
+
+// Daemon sweeps pipelines for failed builds and submits them for analysis.
+// Findings are read back from the broker, so the agents may run in-process
+// (pipeline.Start) or as separate services.
+type Daemon struct {
+	broker   broker.Broker
+	logger   logger.Logger
+	notifier Notifier
+	opts     Options
+
+	// latestFailed finds a target's most recent failed build; replaced in tests
+	latestFailed func(ctx context.Context, target Target) (*provider.Build, error)
+	now          func() time.Time
+
+	seen   map[string]bool // Build URLs already submitted or skipped as too old
+	digest []Report        // Reports waiting for the next digest
+	next   int             // Target that goes first in the next sweep
+
+	mu      sync.Mutex
+	waiters map[string]*waiter // Request ID -> collector
+}
+
+// New creates a daemon. Call Start before Sweep, or use Run.
+func New(brk broker.Broker, log logger.Logger, notifier Notifier, opts Options) *Daemon {
+	if opts.Idle <= 0 {
+		opts.Idle = 10 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Minute
+	}
+
+	d := &Daemon{
+		broker:   brk,
+		logger:   log,
+		notifier: notifier,
+		opts:     opts,
+		now:      time.Now,
+		seen:     make(map[string]bool),
+		waiters:  make(map[string]*waiter),
+	}
+	d.latestFailed = d.fetchLatestFailed
+	return d
+}
+
+// Start subscribes to findings and control messages and routes them to
+// pending requests.
+func (d *Daemon) Start(ctx context.Context) error {
+	findings, err := d.broker.Subscribe(ctx, contracts.TopicAnalysisFindings, "destill-daemon")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicAnalysisFindings, err)
+	}
+	control, err := d.broker.Subscribe(ctx, contracts.TopicControl, "destill-daemon-control")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicControl, err)
+	}
+
+	go func() {
+		for {
+			select {
+			case msg, ok := <-findings:
+				if !ok {
+					return
+				}
+				var card contracts.TriageCard
+				if err := json.Unmarshal(msg.Value, &card); err != nil {
+					d.logger.Error("[Daemon] Failed to unmarshal finding: %v", err)
+					continue
+				}
+				d.deliver(ctx, card.RequestID, event{card: &card})
+			case msg, ok := <-control:
+				if !ok {
+					return
+				}
+				var done contracts.ControlMessage
+				if err := json.Unmarshal(msg.Value, &done); err != nil || done.Type != contracts.ControlAnalysisComplete {
+					continue
+				}
+				d.deliver(ctx, done.RequestID, event{complete: true, findings: done.Findings})
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Run sweeps immediately and then on every tick of sweep, and sends the
+// digest on every tick of digest. It returns when ctx is done.
+func (d *Daemon) Run(ctx context.Context, sweep, digest Schedule) error {
+	if err := d.Start(ctx); err != nil {
+		return err
+	}
+
+	d.Sweep(ctx)
+
+	nextSweep := sweep.Next(d.now())
+	nextDigest := digest.Next(d.now())
+	for {
+		next := nextSweep
+		if !nextDigest.IsZero() && (next.IsZero() || nextDigest.Before(next)) {
+			next = nextDigest
+		}
+		if next.IsZero() {
+			d.logger.Info("[Daemon] Schedule has no future runs, stopping")
+			return nil
+		}
+
+		timer := time.NewTimer(next.Sub(d.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if !nextDigest.IsZero() && !d.now().Before(nextDigest) {
+			d.FlushDigest(ctx)
+			nextDigest = digest.Next(d.now())
+		}
+		if !nextSweep.IsZero() && !d.now().Before(nextSweep) {
+			d.Sweep(ctx)
+			nextSweep = sweep.Next(d.now())
+		}
+	}
+}
+
+// Sweep checks every target once. Failures are logged per target so one
+// broken pipeline doesn't stop the others. The starting target rotates
+// between sweeps so that, when builds are slow to analyze, the pipelines
+// listed last aren't always the ones waiting.
+func (d *Daemon) Sweep(ctx context.Context) {
+	d.logger.Info("[Daemon] Sweeping %d pipelines", len(d.opts.Targets))
+	n := len(d.opts.Targets)
+	if n == 0 {
+		return
+	}
+	start := d.next % n
+	d.next = start + 1
+	for i := range n {
+		target := d.opts.Targets[(start+i)%n]
+		if ctx.Err() != nil {
+			return
+		}
+		if err := d.sweepTarget(ctx, target); err != nil {
+			d.logger.Error("[Daemon] %s: %v", target, err)
+		}
+	}
+}
+
+// FlushDigest sends the reports collected since the last digest.
+func (d *Daemon) FlushDigest(ctx context.Context) {
+	if len(d.digest) == 0 {
+		return
+	}
+	reports := d.digest
+	d.digest = nil
+	if err := d.notifier.Notify(ctx, reports); err != nil {
+		d.logger.Error("[Daemon] Failed to send digest: %v", err)
+	}
+}
+
+// sweepTarget submits the target's latest failed build if it hasn't been seen.
+func (d *Daemon) sweepTarget(ctx context.Context, target Target) error {
+	build, err := d.latestFailed(ctx, target)
+	if errors.Is(err, provider.ErrBuildNotFound) {
+		d.logger.Debug("[Daemon] %s: no failed builds", target)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if d.seen[build.URL] {
+		d.logger.Debug("[Daemon] %s: %s already analyzed", target, build.URL)
+		return nil
+	}
+
+	if d.opts.MaxAge > 0 && !build.Timestamp.IsZero() && d.now().Sub(build.Timestamp) > d.opts.MaxAge {
+		d.logger.Info("[Daemon] %s: skipping %s, older than %v", target, build.URL, d.opts.MaxAge)
+		d.seen[build.URL] = true
+		return nil
+	}
+
+	requestID := generateRequestID()
+	w := d.register(requestID)
+
+	data, err := json.Marshal(contracts.AnalysisRequest{
+		Version:   contracts.AnalysisRequestVersion,
+		RequestID: requestID,
+		BuildURL:  build.URL,
+		Timestamp: d.now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		d.unregister(requestID)
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	if err := d.broker.Publish(ctx, contracts.TopicRequests, requestID, data); err != nil {
+		d.unregister(requestID)
+		return fmt.Errorf("failed to submit %s: %w", build.URL, err)
+	}
+	d.seen[build.URL] = true // Failed submissions are retried on the next sweep
+	d.logger.Info("[Daemon] %s: submitted %s as %s", target, build.URL, requestID)
+
+	cards := d.collect(ctx, requestID, w.events)
+	d.unregister(requestID)
+	d.dispatch(ctx, Report{
+		Pipeline:  target.Pipeline,
+		Branch:    target.Branch,
+		BuildURL:  build.URL,
+		RequestID: requestID,
+		Findings:  cards,
+	})
+	return nil
+}
+
+// collect gathers a request's findings until the analysis has completed
+// and the findings it counted arrived, or none arrived for opts.Idle since,
+// or opts.Timeout passes.
+func (d *Daemon) collect(ctx context.Context, requestID string, events <-chan event) []contracts.TriageCard {
+	var cards []contracts.TriageCard
+	complete, expected := false, 0
+
+	var idle <-chan time.Time // Started once the analysis completes
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	deadline := time.NewTimer(d.opts.Timeout)
+	defer deadline.Stop()
+
+collectLoop:
+	for {
+		select {
+		case ev := <-events:
+			if ev.card != nil {
+				cards = append(cards, *ev.card)
+			}
+			if ev.complete {
+				complete, expected = true, ev.findings
+			}
+			if !complete {
+				continue
+			}
+			if len(cards) >= expected {
+				break collectLoop
+			}
+			if timer == nil {
+				timer = time.NewTimer(d.opts.Idle)
+				idle = timer.C
+				continue
+			}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(d.opts.Idle)
+		case <-idle:
+			d.logger.Error("[Daemon] %d of the %d findings of %s never arrived; reporting without them", expected-len(cards), expected, requestID)
+			break collectLoop
+		case <-deadline.C:
+			d.logger.Error("[Daemon] Timed out waiting for %s after %v; reporting %d findings", requestID, d.opts.Timeout, len(cards))
+			break collectLoop
+		case <-ctx.Done():
+			break collectLoop
+		}
+	}
+
+	cards = contracts.DeduplicateCards(ranking.MarkTransients(cards))
+	ranking.SortCards(cards)
+	return cards
+}
+
+// dispatch notifies now, adds to the digest, or only logs, per the notify hint
+// the analyzer attached to the findings.
+func (d *Daemon) dispatch(ctx context.Context, report Report) {
+	if len(report.Findings) == 0 {
+		d.logger.Info("[Daemon] %s: no findings", report.BuildURL)
+		return
+	}
+
+	notify := report.Findings[0].Metadata[analyze.MetadataNotify]
+	report.Priority = report.Findings[0].Metadata[analyze.MetadataPriority]
+
+	switch notify {
+	case rules.NotifyNone:
+		d.logger.Info("[Daemon] %s: %d findings (notifications off)", report.BuildURL, len(report.Findings))
+	case rules.NotifyDigest:
+		d.logger.Info("[Daemon] %s: %d findings (queued for digest)", report.BuildURL, len(report.Findings))
+		d.digest = append(d.digest, report)
+	default:
+		if err := d.notifier.Notify(ctx, []Report{report}); err != nil {
+			d.logger.Error("[Daemon] Failed to notify for %s: %v", report.BuildURL, err)
+		}
+	}
+}
+
+// register creates the waiter for a submitted request. It is registered
+// before the request is published so no event can be missed.
+func (d *Daemon) register(requestID string) *waiter {
+	w := &waiter{events: make(chan event, 100), done: make(chan struct{})}
+	d.mu.Lock()
+	d.waiters[requestID] = w
+	d.mu.Unlock()
+	return w
+}
+
+// unregister drops the waiter and releases any delivery blocked on it.
+func (d *Daemon) unregister(requestID string) {
+	d.mu.Lock()
+	if w, ok := d.waiters[requestID]; ok {
+		close(w.done)
+		delete(d.waiters, requestID)
+	}
+	d.mu.Unlock()
+}
+
+// deliver forwards an event to its request's collector. Events for other
+// requests (e.g. from 'destill submit') are dropped.
+func (d *Daemon) deliver(ctx context.Context, requestID string, ev event) {
+	d.mu.Lock()
+	w, ok := d.waiters[requestID]
+	d.mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case w.events <- ev:
+	case <-w.done:
+	case <-ctx.Done():
+	}
+}
+
+// fetchLatestFailed looks up a target's most recent failed build via its provider.
+func (d *Daemon) fetchLatestFailed(ctx context.Context, target Target) (*provider.Build, error) {
+	ref, err := provider.ParsePipeline(target.Pipeline, d.opts.Aliases)
+	if err != nil {
+		return nil, err
+	}
+	prov, err := provider.GetProvider(ref)
+	if err != nil {
+		return nil, err
+	}
+	return prov.FetchLatestFailedBuild(ctx, ref, target.Branch)
+}
+
+// generateRequestID creates a unique request identifier.
+func generateRequestID() string {
+	timestamp := time.Now().UTC().Format("20060102T150405")
+	randomBytes := make([]byte, 4)
+	rand.Read(randomBytes)
+	return fmt.Sprintf("req-%s-%s", timestamp, hex.EncodeToString(randomBytes))
+}
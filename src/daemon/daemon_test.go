@@ -0,0 +1,243 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/logger"
+	"destill-agent/src/provider"
+	"destill-agent/src/rules"
+)
+
+// recordingNotifier records every notification
+type recordingNotifier struct {
+	calls [][]Report
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, reports []Report) error {
+	n.calls = append(n.calls, reports)
+	return nil
+}
+
+// fakeAgents answers each request on the broker with one finding carrying
+// the notify hint for its build URL, then signals completion.
+func fakeAgents(t *testing.T, ctx context.Context, brk broker.Broker, notify map[string]string) {
+	t.Helper()
+	requests, err := brk.Subscribe(ctx, contracts.TopicRequests, "fake-agents")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	go func() {
+		for {
+			select {
+			case msg := <-requests:
+				var req contracts.AnalysisRequest
+				json.Unmarshal(msg.Value, &req)
+
+				card, _ := json.Marshal(contracts.TriageCard{
+					RequestID:       req.RequestID,
+					BuildURL:        req.BuildURL,
+					JobName:         "tests",
+					RawMessage:      "FAIL: " + req.BuildURL,
+					MessageHash:     req.BuildURL,
+					ConfidenceScore: 0.9,
+					Metadata:        map[string]string{analyze.MetadataNotify: notify[req.BuildURL]},
+				})
+				brk.Publish(ctx, contracts.TopicAnalysisFindings, req.RequestID, card)
+
+				done, _ := json.Marshal(contracts.ControlMessage{Type: contracts.ControlAnalysisComplete, RequestID: req.RequestID, Findings: 1})
+				brk.Publish(ctx, contracts.TopicControl, req.RequestID, done)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func newTestDaemon(t *testing.T, ctx context.Context, targets []Target, builds map[string]*provider.Build, notify map[string]string) (*Daemon, *recordingNotifier, *int) {
+	t.Helper()
+	brk := broker.NewInMemoryBroker()
+	t.Cleanup(func() { brk.Close() })
+	fakeAgents(t, ctx, brk, notify)
+
+	notifier := &recordingNotifier{}
+	d := New(brk, logger.NewSilentLogger(), notifier, Options{
+		Targets: targets,
+		MaxAge:  24 * time.Hour,
+		Idle:    50 * time.Millisecond,
+		Timeout: 5 * time.Second,
+	})
+
+	lookups := 0
+	d.latestFailed = func(ctx context.Context, target Target) (*provider.Build, error) {
+		lookups++
+		build, ok := builds[target.Pipeline]
+		if !ok {
+			return nil, fmt.Errorf("%w: none", provider.ErrBuildNotFound)
+		}
+		return build, nil
+	}
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	return d, notifier, &lookups
+}
+
+func TestDaemon_SweepNotifiesOncePerBuild(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	build := &provider.Build{URL: "https://buildkite.com/org/api/builds/7", Timestamp: time.Now()}
+	targets := []Target{{Pipeline: "org/api", Branch: "main"}, {Pipeline: "org/quiet", Branch: "main"}}
+	d, notifier, lookups := newTestDaemon(t, ctx, targets,
+		map[string]*provider.Build{"org/api": build},
+		map[string]string{build.URL: rules.NotifyImmediate})
+
+	d.Sweep(ctx)
+	if *lookups != 2 {
+		t.Errorf("lookups = %d, want 2", *lookups)
+	}
+	if len(notifier.calls) != 1 || len(notifier.calls[0]) != 1 {
+		t.Fatalf("notifications = %+v, want one report", notifier.calls)
+	}
+	report := notifier.calls[0][0]
+	if report.BuildURL != build.URL || report.Pipeline != "org/api" || report.Branch != "main" || len(report.Findings) != 1 {
+		t.Errorf("report = %+v", report)
+	}
+
+	// The same failed build is not analyzed twice
+	d.Sweep(ctx)
+	if len(notifier.calls) != 1 {
+		t.Errorf("notifications after second sweep = %d, want 1", len(notifier.calls))
+	}
+}
+
+func TestDaemon_NotifyHints(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	digestBuild := &provider.Build{URL: "https://buildkite.com/org/nightly/builds/1"}
+	silentBuild := &provider.Build{URL: "https://buildkite.com/org/docs/builds/2"}
+	d, notifier, _ := newTestDaemon(t, ctx,
+		[]Target{{Pipeline: "org/nightly", Branch: "main"}, {Pipeline: "org/docs", Branch: "main"}},
+		map[string]*provider.Build{"org/nightly": digestBuild, "org/docs": silentBuild},
+		map[string]string{digestBuild.URL: rules.NotifyDigest, silentBuild.URL: rules.NotifyNone})
+
+	d.Sweep(ctx)
+	if len(notifier.calls) != 0 {
+		t.Fatalf("notifications before digest = %+v, want none", notifier.calls)
+	}
+
+	d.FlushDigest(ctx)
+	if len(notifier.calls) != 1 || len(notifier.calls[0]) != 1 || notifier.calls[0][0].BuildURL != digestBuild.URL {
+		t.Fatalf("digest = %+v, want the nightly build only", notifier.calls)
+	}
+
+	// An empty digest sends nothing
+	d.FlushDigest(ctx)
+	if len(notifier.calls) != 1 {
+		t.Errorf("notifications after empty digest = %d, want 1", len(notifier.calls))
+	}
+}
+
+func TestDaemon_SkipsOldBuilds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	old := &provider.Build{URL: "https://buildkite.com/org/api/builds/1", Timestamp: time.Now().Add(-48 * time.Hour)}
+	d, notifier, _ := newTestDaemon(t, ctx, []Target{{Pipeline: "org/api", Branch: "main"}},
+		map[string]*provider.Build{"org/api": old}, nil)
+
+	d.Sweep(ctx)
+	if len(notifier.calls) != 0 {
+		t.Errorf("notifications = %+v, want none for a build older than MaxAge", notifier.calls)
+	}
+}
+
+func TestDaemon_LookupErrorsDoNotStopSweep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	build := &provider.Build{URL: "https://buildkite.com/org/api/builds/3"}
+	d, notifier, _ := newTestDaemon(t, ctx,
+		[]Target{{Pipeline: "org/broken", Branch: "main"}, {Pipeline: "org/api", Branch: "main"}},
+		map[string]*provider.Build{"org/api": build},
+		map[string]string{build.URL: rules.NotifyImmediate})
+
+	inner := d.latestFailed
+	d.latestFailed = func(ctx context.Context, target Target) (*provider.Build, error) {
+		if target.Pipeline == "org/broken" {
+			return nil, errors.New("401 Unauthorized")
+		}
+		return inner(ctx, target)
+	}
+
+	d.Sweep(ctx)
+	if len(notifier.calls) != 1 {
+		t.Errorf("notifications = %d, want 1", len(notifier.calls))
+	}
+}
+
+func TestDaemon_SweepRotatesTargets(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	targets := []Target{{Pipeline: "org/a", Branch: "main"}, {Pipeline: "org/b", Branch: "main"}, {Pipeline: "org/c", Branch: "main"}}
+	d, _, _ := newTestDaemon(t, ctx, targets, nil, nil)
+
+	var order []string
+	d.latestFailed = func(ctx context.Context, target Target) (*provider.Build, error) {
+		order = append(order, target.Pipeline)
+		return nil, provider.ErrBuildNotFound
+	}
+
+	d.Sweep(ctx)
+	d.Sweep(ctx)
+	want := "org/a org/b org/c org/b org/c org/a"
+	if got := strings.Join(order, " "); got != want {
+		t.Errorf("sweep order = %q, want %q", got, want)
+	}
+}
+
+func TestDaemon_CollectUntilComplete(t *testing.T) {
+	d := New(nil, logger.NewSilentLogger(), &recordingNotifier{}, Options{Idle: time.Minute, Timeout: time.Minute})
+	card := func(msg string) *contracts.TriageCard {
+		return &contracts.TriageCard{RequestID: "req", MessageHash: msg, RawMessage: msg}
+	}
+
+	// The completion arrives before the last finding it counted
+	events := make(chan event, 3)
+	events <- event{card: card("a")}
+	events <- event{complete: true, findings: 2}
+	events <- event{card: card("b")}
+	start := time.Now()
+	if cards := d.collect(context.Background(), "req", events); len(cards) != 2 || time.Since(start) > 10*time.Second {
+		t.Errorf("collect() = %d cards after %v; want both, without waiting out a timeout", len(cards), time.Since(start))
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Target
+	}{
+		{"org/api", Target{Pipeline: "org/api", Branch: "main"}},
+		{"org/api@release/1.2", Target{Pipeline: "org/api", Branch: "release/1.2"}},
+		{"https://github.com/owner/repo@develop", Target{Pipeline: "https://github.com/owner/repo", Branch: "develop"}},
+		{" backend ", Target{Pipeline: "backend", Branch: "main"}},
+	}
+	for _, tt := range tests {
+		if got := ParseTarget(tt.spec, "main"); got != tt.want {
+			t.Errorf("ParseTarget(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Notifier delivers reports: one for an immediate notification, several for a digest.
+type Notifier interface {
+	Notify(ctx context.Context, reports []Report) error
+}
+
+// maxFindingsPerReport limits how many findings a notification lists per build.
+const maxFindingsPerReport = 3
+
+// FormatReports renders reports as plain text, listing the top findings of each.
+func FormatReports(reports []Report) string {
+	var sb strings.Builder
+	if len(reports) > 1 {
+		fmt.Fprintf(&sb, "Destill digest: %d failed builds\n", len(reports))
+	}
+	for _, r := range reports {
+		fmt.Fprintf(&sb, "%s (%s): %d findings", r.Pipeline, r.Branch, len(r.Findings))
+		if r.Priority != "" {
+			fmt.Fprintf(&sb, " [%s]", r.Priority)
+		}
+		fmt.Fprintf(&sb, "\n%s\n", r.BuildURL)
+		for i, card := range r.Findings {
+			if i == maxFindingsPerReport {
+				fmt.Fprintf(&sb, "  ... and %d more (destill view %s)\n", len(r.Findings)-i, r.RequestID)
+				break
+			}
+			fmt.Fprintf(&sb, "  [%.2f] %s: %s\n", card.ConfidenceScore, card.JobName, card.RawMessage)
+		}
+	}
+	return sb.String()
+}
+
+// WriterNotifier writes reports as text, e.g. to stdout.
+type WriterNotifier struct {
+	w io.Writer
+}
+
+// NewWriterNotifier creates a notifier that writes to w.
+func NewWriterNotifier(w io.Writer) *WriterNotifier {
+	return &WriterNotifier{w: w}
+}
+
+func (n *WriterNotifier) Notify(ctx context.Context, reports []Report) error {
+	_, err := fmt.Fprintln(n.w, FormatReports(reports))
+	return err
+}
+
+// WebhookNotifier POSTs reports as JSON. The body has a "text" field with the
+// formatted reports, so chat incoming webhooks (e.g. Slack) can use it as is,
+// and a "reports" field with the full data.
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier creates a notifier that posts to url.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:        url,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, reports []Report) error {
+	body, err := json.Marshal(struct {
+		Text    string   `json:"text"`
+		Reports []Report `json:"reports"`
+	}{
+		Text:    FormatReports(reports),
+		Reports: reports,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// MultiNotifier sends to every notifier, returning the first error.
+type MultiNotifier []Notifier
+
+func (m MultiNotifier) Notify(ctx context.Context, reports []Report) error {
+	var firstErr error
+	for _, n := range m {
+		if err := n.Notify(ctx, reports); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,89 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func testReport() Report {
+	return Report{
+		Pipeline:  "org/api",
+		Branch:    "main",
+		BuildURL:  "https://buildkite.com/org/api/builds/7",
+		RequestID: "req-1",
+		Priority:  "high",
+		Findings: []contracts.TriageCard{
+			{JobName: "tests", RawMessage: "FAIL: TestLogin", ConfidenceScore: 0.95},
+			{JobName: "tests", RawMessage: "panic: nil map", ConfidenceScore: 0.9},
+			{JobName: "lint", RawMessage: "error: unused var", ConfidenceScore: 0.8},
+			{JobName: "lint", RawMessage: "warning: shadow", ConfidenceScore: 0.6},
+		},
+	}
+}
+
+func TestFormatReports(t *testing.T) {
+	text := FormatReports([]Report{testReport()})
+
+	for _, want := range []string{
+		"org/api (main): 4 findings [high]",
+		"https://buildkite.com/org/api/builds/7",
+		"[0.95] tests: FAIL: TestLogin",
+		"... and 1 more (destill view req-1)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("FormatReports() missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "warning: shadow") {
+		t.Errorf("FormatReports() listed more than %d findings:\n%s", maxFindingsPerReport, text)
+	}
+
+	digest := FormatReports([]Report{testReport(), testReport()})
+	if !strings.HasPrefix(digest, "Destill digest: 2 failed builds") {
+		t.Errorf("digest header missing:\n%s", digest)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var payload struct {
+		Text    string   `json:"text"`
+		Reports []Report `json:"reports"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	if err := NewWebhookNotifier(server.URL).Notify(context.Background(), []Report{testReport()}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(payload.Reports) != 1 || payload.Reports[0].BuildURL != testReport().BuildURL {
+		t.Errorf("reports = %+v", payload.Reports)
+	}
+	if !strings.Contains(payload.Text, "org/api") {
+		t.Errorf("text = %q", payload.Text)
+	}
+}
+
+func TestWebhookNotifier_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL).Notify(context.Background(), []Report{testReport()})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Notify() error = %v, want 403", err)
+	}
+}
@@ -0,0 +1,75 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	// Wednesday 2025-01-15 10:07:30 UTC
+	from := time.Date(2025, 1, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"@every 15m", from.Add(15 * time.Minute)},
+		{"*/15 * * * *", time.Date(2025, 1, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2025, 1, 16, 2, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 6,7", time.Date(2025, 1, 18, 9, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (the 20th or a Friday)
+		{"0 0 20 * 5", time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"10-20/5 10 * * *", time.Date(2025, 1, 15, 10, 10, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) error = %v", tt.spec, err)
+			}
+			if got := s.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSchedule_NeverFires(t *testing.T) {
+	s, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseSchedule() error = %v", err)
+	}
+	if got := s.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next() = %v, want zero time", got)
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"@every",
+		"@every 10s",
+		"@every soon",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) expected error, got nil", spec)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package events provides the typed event bus of local mode. The TUI and
+// CLI collectors receive findings, progress, and errors as Go values from a
+// Bus instead of decoding broker topics; the broker remains the transport
+// between agents in distributed mode.
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"destill-agent/src/contracts"
+)
+
+// Event is one of Finding, Progress, Complete, or Error.
+type Event interface {
+	event()
+}
+
+// Finding is a triage card produced by the analysis.
+type Finding struct {
+	Card contracts.TriageCard
+}
+
+// Progress reports how far a request's ingestion has got.
+type Progress struct {
+	Update contracts.ProgressUpdate
+}
+
+// Complete reports that every chunk of a request has been analyzed.
+// Findings is how many findings the request has in all: they may still be
+// on their way, so a collector has them all once it's received that many.
+type Complete struct {
+	RequestID string
+	Findings  int
+}
+
+// Error reports that a request failed, such as a build that could not be
+// fetched. RequestID is empty when the request couldn't be read.
+type Error struct {
+	RequestID string
+	Err       error
+}
+
+func (Finding) event()  {}
+func (Progress) event() {}
+func (Complete) event() {}
+func (Error) event()    {}
+
+// SubscriberBuffer is how many events a subscriber can fall behind before
+// Publish waits for it.
+const SubscriberBuffer = 256
+
+// ErrClosed is returned by Publish and Subscribe once the bus is closed.
+var ErrClosed = errors.New("event bus is closed")
+
+// Bus delivers every published event to every subscriber, in order. Unlike
+// the in-memory broker it never drops an event: Publish waits for a
+// subscriber whose buffer is full, until the subscriber goes away or the
+// publisher's context is done.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[*subscriber]struct{}
+	closed bool
+}
+
+type subscriber struct {
+	ch   chan Event
+	done chan struct{} // Closed when the subscriber goes away
+	once sync.Once
+}
+
+// NewBus creates an empty Bus.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*subscriber]struct{})}
+}
+
+// Subscribe returns a channel receiving the events published from now on.
+// The channel is closed when ctx is done or the bus is closed.
+func (b *Bus) Subscribe(ctx context.Context) (<-chan Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	sub := &subscriber{
+		ch:   make(chan Event, SubscriberBuffer),
+		done: make(chan struct{}),
+	}
+	b.subs[sub] = struct{}{}
+	context.AfterFunc(ctx, func() { b.unsubscribe(sub) })
+	return sub.ch, nil
+}
+
+// Publish delivers e to every subscriber, waiting for those that are
+// behind. It returns ctx's error if ctx is done first.
+func (b *Bus) Publish(ctx context.Context, e Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrClosed
+	}
+
+	for sub := range b.subs {
+		select {
+		case sub.ch <- e:
+		case <-sub.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Close closes every subscriber's channel. Later calls to Publish and
+// Subscribe return ErrClosed.
+func (b *Bus) Close() {
+	b.mu.RLock()
+	for sub := range b.subs {
+		sub.stop()
+	}
+	b.mu.RUnlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subs {
+		close(sub.ch)
+	}
+	b.subs = nil
+}
+
+// unsubscribe removes sub and closes its channel. Publishers waiting on sub
+// give up first, so the lock is free to take.
+func (b *Bus) unsubscribe(sub *subscriber) {
+	sub.stop()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+}
+
+func (s *subscriber) stop() {
+	s.once.Do(func() { close(s.done) })
+}
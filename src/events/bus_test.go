@@ -0,0 +1,130 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+func TestBus_DeliversInOrderToEverySubscriber(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	ctx := context.Background()
+
+	first, err := bus.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := bus.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := []Event{
+		Progress{Update: contracts.ProgressUpdate{Stage: "Fetching logs", Current: 1, Total: 2}},
+		Finding{Card: contracts.TriageCard{MessageHash: "abc"}},
+		Error{RequestID: "req-1", Err: errors.New("build not found")},
+	}
+	for _, e := range sent {
+		if err := bus.Publish(ctx, e); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	for _, ch := range []<-chan Event{first, second} {
+		if e, ok := (<-ch).(Progress); !ok || e.Update.Stage != "Fetching logs" {
+			t.Errorf("first event = %+v, want the progress update", e)
+		}
+		if e, ok := (<-ch).(Finding); !ok || e.Card.MessageHash != "abc" {
+			t.Errorf("second event = %+v, want the finding", e)
+		}
+		if e, ok := (<-ch).(Error); !ok || e.RequestID != "req-1" {
+			t.Errorf("third event = %+v, want the error", e)
+		}
+	}
+}
+
+func TestBus_PublishWaitsForSlowSubscriber(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	ch, err := bus.Subscribe(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// More events than the buffer holds: none may be dropped
+	n := SubscriberBuffer * 3
+	go func() {
+		for i := 0; i < n; i++ {
+			bus.Publish(context.Background(), Finding{})
+		}
+	}()
+	for i := 0; i < n; i++ {
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			t.Fatalf("received %d events, want %d", i, n)
+		}
+	}
+}
+
+func TestBus_PublishContextDone(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	if _, err := bus.Subscribe(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < SubscriberBuffer; i++ {
+		bus.Publish(context.Background(), Finding{})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bus.Publish(ctx, Finding{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Publish() to a full subscriber error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := bus.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A full subscriber that goes away doesn't hold up publishers
+	for i := 0; i < SubscriberBuffer; i++ {
+		bus.Publish(context.Background(), Finding{})
+	}
+
+	cancel()
+	if err := bus.Publish(context.Background(), Finding{}); err != nil {
+		t.Errorf("Publish() after unsubscribe error = %v", err)
+	}
+	for range ch {
+	}
+}
+
+func TestBus_Close(t *testing.T) {
+	bus := NewBus()
+	ch, err := bus.Subscribe(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bus.Close()
+	bus.Close()
+	if _, ok := <-ch; ok {
+		t.Error("subscriber channel open after Close")
+	}
+	if err := bus.Publish(context.Background(), Finding{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish() after Close error = %v, want ErrClosed", err)
+	}
+	if _, err := bus.Subscribe(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe() after Close error = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Package experiments gates analysis features that ship dark: each is off
+// unless named in DESTILL_EXPERIMENTS (or the config file's experiments
+// list), so risky passes can be tried per team before they become default.
+package experiments
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EnvExperiments is a comma-separated list of experiments to enable, e.g.
+// "stacktrace_stitching".
+const EnvExperiments = "DESTILL_EXPERIMENTS"
+
+// The experiments, by the name DESTILL_EXPERIMENTS enables them with.
+const (
+	// StackTraceStitching carries a stack trace cut at the end of a log
+	// chunk whole into the next chunk, instead of the usual line overlap,
+	// so it's analyzed as one trace (see ingest.ChunkStream).
+	StackTraceStitching = "stacktrace_stitching"
+)
+
+// known describes each experiment.
+var known = map[string]string{
+	StackTraceStitching: "Carry stack traces cut at a log chunk's end whole into the next chunk",
+}
+
+// Parse splits a DESTILL_EXPERIMENTS value into experiment names, trimmed
+// and lowercased.
+func Parse(spec string) []string {
+	var names []string
+	for _, name := range strings.Split(spec, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Validate returns an error naming any experiments that aren't known.
+func Validate(names []string) error {
+	var unknown []string
+	for _, name := range names {
+		if _, ok := known[strings.ToLower(strings.TrimSpace(name))]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown experiment(s) %s (known: %s)", strings.Join(unknown, ", "), strings.Join(knownNames(), ", "))
+	}
+	return nil
+}
+
+// warnOnce keeps Enabled from repeating the warning about unknown names.
+var warnOnce sync.Once
+
+// Enabled reports whether DESTILL_EXPERIMENTS enables the experiment name.
+// Unknown names in it are ignored, with a warning the first time.
+func Enabled(name string) bool {
+	names := Parse(os.Getenv(EnvExperiments))
+	if err := Validate(names); err != nil {
+		warnOnce.Do(func() {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", EnvExperiments, err)
+		})
+	}
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func knownNames() []string {
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,38 @@
+package experiments
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	got := Parse(" Stacktrace_Stitching, ,other ")
+	if len(got) != 2 || got[0] != "stacktrace_stitching" || got[1] != "other" {
+		t.Errorf("Parse() = %q, want [stacktrace_stitching other]", got)
+	}
+	if got := Parse(""); len(got) != 0 {
+		t.Errorf("Parse(\"\") = %q, want none", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate([]string{StackTraceStitching}); err != nil {
+		t.Errorf("Validate(known) error = %v", err)
+	}
+	err := Validate([]string{StackTraceStitching, "time_travel"})
+	if err == nil || !strings.Contains(err.Error(), "time_travel") || !strings.Contains(err.Error(), StackTraceStitching) {
+		t.Errorf("Validate(unknown) error = %v, want the unknown and known names", err)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	t.Setenv(EnvExperiments, "")
+	if Enabled(StackTraceStitching) {
+		t.Error("Enabled() with DESTILL_EXPERIMENTS unset = true")
+	}
+
+	t.Setenv(EnvExperiments, "time_travel, stacktrace_stitching")
+	if !Enabled(StackTraceStitching) {
+		t.Error("Enabled() = false, want true when listed")
+	}
+}
@@ -0,0 +1,139 @@
+package flaky
+
+import (
+	"context"
+	"time"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/store"
+)
+
+// DefaultHistoryWindow is how far back MarkFlakiness looks for earlier
+// builds of a pipeline.
+const DefaultHistoryWindow = 30 * 24 * time.Hour
+
+// Classify labels each card with how its failure showed up in earlier
+// builds of its pipeline (metadata flakiness), correlating findings by job
+// name and normalized message. A failure that appeared in an earlier build,
+// then was missing from a later analyzed build, or that appeared in a job
+// that still passed, is likely flaky; one missing from every earlier build
+// is a new regression. A failure present in every build since it first
+// appeared is ongoing, and is left unlabeled like the cards of pipelines
+// without earlier builds in history. Findings of the cards' own builds in
+// history are ignored. Returns a new slice; the input cards are not
+// modified.
+func Classify(cards, history []contracts.TriageCard) []contracts.TriageCard {
+	type findingKey struct{ pipeline, job, hash string }
+
+	current := make(map[string]bool)
+	for _, card := range cards {
+		current[card.BuildURL] = true
+	}
+
+	builds := make(map[string]map[string]*build)   // Pipeline -> build URL -> build
+	failed := make(map[findingKey]map[string]bool) // -> build URL -> job passed
+	for _, card := range history {
+		pipeline := card.Metadata["pipeline_name"]
+		if pipeline == "" || current[card.BuildURL] {
+			continue
+		}
+		if builds[pipeline] == nil {
+			builds[pipeline] = make(map[string]*build)
+		}
+		if builds[pipeline][card.BuildURL] == nil {
+			b := &build{url: card.BuildURL, order: len(builds[pipeline])}
+			b.created, _ = time.Parse(time.RFC3339, card.Metadata["build_created_at"])
+			builds[pipeline][card.BuildURL] = b
+		}
+		key := findingKey{pipeline, card.JobName, card.MessageHash}
+		if failed[key] == nil {
+			failed[key] = make(map[string]bool)
+		}
+		failed[key][card.BuildURL] = failed[key][card.BuildURL] || card.Metadata["exit_status"] == "0"
+	}
+
+	ordered := make(map[string][]*build, len(builds))
+	for pipeline, m := range builds {
+		ordered[pipeline] = sortedBuilds(m)
+	}
+
+	result := make([]contracts.TriageCard, len(cards))
+	for i, card := range cards {
+		result[i] = card
+		pipeline := card.Metadata["pipeline_name"]
+		if len(ordered[pipeline]) == 0 {
+			continue
+		}
+
+		before := failed[findingKey{pipeline, card.JobName, card.MessageHash}]
+		label := contracts.FlakinessNewRegression
+		if len(before) > 0 {
+			if !intermittent(ordered[pipeline], before) && card.Metadata["exit_status"] != "0" {
+				continue // Ongoing
+			}
+			label = contracts.FlakinessLikelyFlaky
+		}
+
+		metadata := make(map[string]string, len(card.Metadata)+1)
+		for k, v := range card.Metadata {
+			metadata[k] = v
+		}
+		metadata[contracts.MetadataFlakiness] = label
+		result[i].Metadata = metadata
+	}
+	return result
+}
+
+// intermittent reports whether a failure seen in failed (build URL -> job
+// passed) of builds, in build order, passed in its job or was missing from
+// a build after it first appeared. The failure is in the current build, so
+// a gap means it went away and came back.
+func intermittent(builds []*build, failed map[string]bool) bool {
+	seenFailure := false
+	for _, b := range builds {
+		passed, ok := failed[b.url]
+		if !ok {
+			if seenFailure {
+				return true
+			}
+			continue
+		}
+		if passed {
+			return true
+		}
+		seenFailure = true
+	}
+	return false
+}
+
+// IsLikelyFlaky reports whether Classify labeled the card as likely flaky.
+func IsLikelyFlaky(card contracts.TriageCard) bool {
+	return card.Metadata[contracts.MetadataFlakiness] == contracts.FlakinessLikelyFlaky
+}
+
+// IsNewRegression reports whether Classify labeled the card as a new
+// regression.
+func IsNewRegression(card contracts.TriageCard) bool {
+	return card.Metadata[contracts.MetadataFlakiness] == contracts.FlakinessNewRegression
+}
+
+// historyReader is implemented by stores that read findings by time, such
+// as store.Persistent.
+type historyReader interface {
+	GetFindingsSince(ctx context.Context, since time.Time) ([]contracts.TriageCard, error)
+}
+
+// MarkFlakiness classifies cards (see Classify) against the findings st
+// stored in the last window, when st reads findings by time. Otherwise the
+// cards are returned as is.
+func MarkFlakiness(ctx context.Context, st store.Store, cards []contracts.TriageCard, window time.Duration) ([]contracts.TriageCard, error) {
+	reader, ok := st.(historyReader)
+	if !ok || len(cards) == 0 {
+		return cards, nil
+	}
+	history, err := reader.GetFindingsSince(ctx, time.Now().Add(-window))
+	if err != nil {
+		return cards, err
+	}
+	return Classify(cards, history), nil
+}
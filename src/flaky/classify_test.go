@@ -0,0 +1,63 @@
+package flaky
+
+import (
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+// finding builds a finding with a message hash for build n of the api
+// pipeline.
+func finding(n int, hash string, jobPassed bool) contracts.TriageCard {
+	card := failure(n, hash, jobPassed)
+	card.MessageHash = hash
+	return card
+}
+
+func TestClassify(t *testing.T) {
+	history := []contracts.TriageCard{
+		// intermittent: fails, is missing from build 2, fails again now
+		finding(1, "intermittent", false),
+		finding(2, "other", false),
+		// retried: failed in a job that passed
+		finding(2, "retried", true),
+		// ongoing: fails in every build since build 1
+		finding(1, "ongoing", false),
+		finding(2, "ongoing", false),
+		// The current build's own findings don't count
+		finding(3, "regression", false),
+	}
+	cards := []contracts.TriageCard{
+		finding(3, "intermittent", false),
+		finding(3, "retried", false),
+		finding(3, "ongoing", false),
+		finding(3, "regression", false),
+	}
+	otherJob := finding(3, "ongoing", false)
+	otherJob.JobName = "lint"
+	cards = append(cards, otherJob)
+
+	want := []string{
+		contracts.FlakinessLikelyFlaky,
+		contracts.FlakinessLikelyFlaky,
+		"",
+		contracts.FlakinessNewRegression,
+		contracts.FlakinessNewRegression, // Same message, another job
+	}
+	marked := Classify(cards, history)
+	for i, card := range marked {
+		if got := card.Metadata[contracts.MetadataFlakiness]; got != want[i] {
+			t.Errorf("Classify()[%d] (%s in %s) = %q, want %q", i, card.MessageHash, card.JobName, got, want[i])
+		}
+	}
+	if _, ok := cards[0].Metadata[contracts.MetadataFlakiness]; ok {
+		t.Error("Classify() modified the input cards")
+	}
+}
+
+func TestClassify_NoHistory(t *testing.T) {
+	cards := []contracts.TriageCard{finding(1, "first", false)}
+	if got := Classify(cards, cards)[0].Metadata[contracts.MetadataFlakiness]; got != "" {
+		t.Errorf("Classify() without earlier builds = %q, want unlabeled", got)
+	}
+}
@@ -0,0 +1,197 @@
+package flaky
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+// Options tunes Detect. Zero values use the defaults.
+type Options struct {
+	// MinBuilds skips tests that failed in fewer builds (default 2).
+	MinBuilds int
+
+	// MaxEvidence caps the evidence links kept per test (default 5).
+	MaxEvidence int
+}
+
+// Suspect is a test that looks flaky, with the builds that show it.
+type Suspect struct {
+	Test     string `json:"test"`
+	Pipeline string `json:"pipeline"`
+	Branch   string `json:"branch,omitempty"`
+
+	FailedBuilds   int `json:"failed_builds"`   // Analyzed builds the test failed in
+	AnalyzedBuilds int `json:"analyzed_builds"` // Analyzed builds of the pipeline and branch
+	PassedOnRetry  int `json:"passed_on_retry"` // Failures in jobs that still passed
+	Recoveries     int `json:"recoveries"`      // Times it stopped failing, then failed again
+
+	Reasons  []string   `json:"reasons"`
+	Evidence []Evidence `json:"evidence"`
+}
+
+// Score ranks suspects: in-job retries are the strongest flakiness signal.
+func (s Suspect) Score() int {
+	return 2*s.PassedOnRetry + s.Recoveries
+}
+
+// Evidence is one build where the test failed.
+type Evidence struct {
+	BuildURL   string `json:"build_url"`
+	JobName    string `json:"job_name"`
+	Message    string `json:"message"`
+	JobPassed  bool   `json:"job_passed,omitempty"`
+	BuildStart string `json:"build_created_at,omitempty"`
+}
+
+// build is one analyzed build of a pipeline branch.
+type build struct {
+	url     string
+	created time.Time
+	order   int // First seen, for builds without a creation time
+}
+
+// Detect finds tests that failed intermittently across builds. A test is
+// suspect when it failed in a job that still passed (retried within the
+// job), or when, in build order, it failed, then didn't fail in another
+// analyzed build of the same pipeline branch, then failed again. A test
+// that fails in every build since some point is broken, not flaky.
+func Detect(cards []contracts.TriageCard, opts Options) []Suspect {
+	if opts.MinBuilds <= 0 {
+		opts.MinBuilds = 2
+	}
+	if opts.MaxEvidence <= 0 {
+		opts.MaxEvidence = 5
+	}
+
+	type failure struct {
+		evidence Evidence
+		passed   bool
+	}
+	type testKey struct{ pipeline, branch, test string }
+
+	builds := make(map[string]map[string]*build) // Pipeline\x00branch -> build URL -> build
+	failures := make(map[testKey]map[string]*failure)
+	var keys []testKey
+
+	for _, card := range cards {
+		pipeline, branch := card.Metadata["pipeline_name"], card.Metadata["branch"]
+		if pipeline == "" {
+			pipeline = card.BuildURL
+		}
+		scope := pipeline + "\x00" + branch
+		if builds[scope] == nil {
+			builds[scope] = make(map[string]*build)
+		}
+		if builds[scope][card.BuildURL] == nil {
+			b := &build{url: card.BuildURL, order: len(builds[scope])}
+			b.created, _ = time.Parse(time.RFC3339, card.Metadata["build_created_at"])
+			builds[scope][card.BuildURL] = b
+		}
+
+		test, ok := CardTestName(card)
+		if !ok {
+			continue
+		}
+		key := testKey{pipeline, branch, test}
+		if failures[key] == nil {
+			failures[key] = make(map[string]*failure)
+			keys = append(keys, key)
+		}
+		passed := card.Metadata["exit_status"] == "0"
+		if f := failures[key][card.BuildURL]; f != nil {
+			f.passed = f.passed || passed
+			continue
+		}
+		failures[key][card.BuildURL] = &failure{
+			passed: passed,
+			evidence: Evidence{
+				BuildURL:   card.BuildURL,
+				JobName:    card.JobName,
+				Message:    card.RawMessage,
+				JobPassed:  passed,
+				BuildStart: card.Metadata["build_created_at"],
+			},
+		}
+	}
+
+	var suspects []Suspect
+	for _, key := range keys {
+		failed := failures[key]
+		if len(failed) < opts.MinBuilds {
+			continue
+		}
+		ordered := sortedBuilds(builds[key.pipeline+"\x00"+key.branch])
+
+		s := Suspect{
+			Test:           key.test,
+			Pipeline:       key.pipeline,
+			Branch:         key.branch,
+			FailedBuilds:   len(failed),
+			AnalyzedBuilds: len(ordered),
+		}
+		seenFailure, gap := false, false
+		for _, b := range ordered {
+			f, ok := failed[b.url]
+			if !ok {
+				gap = seenFailure
+				continue
+			}
+			if gap {
+				s.Recoveries++
+				gap = false
+			}
+			seenFailure = true
+			if f.passed {
+				s.PassedOnRetry++
+			}
+		}
+		if s.Score() == 0 {
+			continue
+		}
+
+		if s.PassedOnRetry > 0 {
+			s.Reasons = append(s.Reasons, fmt.Sprintf("failed but its job passed in %d builds", s.PassedOnRetry))
+		}
+		if s.Recoveries > 0 {
+			s.Reasons = append(s.Reasons, fmt.Sprintf("failed in %d of %d builds, not consecutively", s.FailedBuilds, s.AnalyzedBuilds))
+		}
+		// Most recent builds first
+		for i := len(ordered) - 1; i >= 0 && len(s.Evidence) < opts.MaxEvidence; i-- {
+			if f, ok := failed[ordered[i].url]; ok {
+				s.Evidence = append(s.Evidence, f.evidence)
+			}
+		}
+		suspects = append(suspects, s)
+	}
+
+	sort.SliceStable(suspects, func(i, j int) bool {
+		if suspects[i].Score() != suspects[j].Score() {
+			return suspects[i].Score() > suspects[j].Score()
+		}
+		if suspects[i].FailedBuilds != suspects[j].FailedBuilds {
+			return suspects[i].FailedBuilds > suspects[j].FailedBuilds
+		}
+		return suspects[i].Test < suspects[j].Test
+	})
+	return suspects
+}
+
+// sortedBuilds orders builds by creation time, falling back to the order
+// they were first seen in.
+func sortedBuilds(m map[string]*build) []*build {
+	builds := make([]*build, 0, len(m))
+	for _, b := range m {
+		builds = append(builds, b)
+	}
+	sort.Slice(builds, func(i, j int) bool {
+		a, b := builds[i], builds[j]
+		if !a.created.IsZero() && !b.created.IsZero() && !a.created.Equal(b.created) {
+			return a.created.Before(b.created)
+		}
+		return a.order < b.order
+	})
+	return builds
+}
@@ -0,0 +1,85 @@
+package flaky
+
+import (
+	"fmt"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+// failure builds a finding for a failed test in build n of the api pipeline.
+func failure(n int, test string, jobPassed bool) contracts.TriageCard {
+	exitStatus := "1"
+	if jobPassed {
+		exitStatus = "0"
+	}
+	return contracts.TriageCard{
+		BuildURL:   fmt.Sprintf("https://buildkite.com/org/api/builds/%d", n),
+		JobName:    "tests",
+		RawMessage: "--- FAIL: " + test + " (0.10s)",
+		Metadata: map[string]string{
+			"pipeline_name":    "org/api",
+			"branch":           "main",
+			"exit_status":      exitStatus,
+			"build_created_at": fmt.Sprintf("2024-01-%02dT00:00:00Z", n),
+		},
+	}
+}
+
+// otherFailure is a finding without a test name, so the build counts as
+// analyzed without any test failing.
+func otherFailure(n int) contracts.TriageCard {
+	card := failure(n, "", false)
+	card.RawMessage = "npm ERR! network timeout"
+	return card
+}
+
+func TestDetect(t *testing.T) {
+	cards := []contracts.TriageCard{
+		// TestFlaky: fails, recovers, fails again
+		failure(1, "TestFlaky", false),
+		otherFailure(2),
+		failure(3, "TestFlaky", false),
+
+		// TestRetried: failed in jobs that passed
+		failure(4, "TestRetried", true),
+		failure(5, "TestRetried", true),
+
+		// TestBroken: fails in every build since build 3
+		failure(3, "TestBroken", false),
+		failure(4, "TestBroken", false),
+		failure(5, "TestBroken", false),
+
+		// TestOnce: below --min-builds
+		failure(5, "TestOnce", true),
+	}
+
+	suspects := Detect(cards, Options{})
+	if len(suspects) != 2 {
+		t.Fatalf("Detect() = %+v, want TestRetried and TestFlaky", suspects)
+	}
+
+	retried := suspects[0]
+	if retried.Test != "TestRetried" || retried.PassedOnRetry != 2 || retried.Score() != 4 {
+		t.Errorf("suspects[0] = %+v, want TestRetried with 2 retried passes first", retried)
+	}
+
+	flaky := suspects[1]
+	if flaky.Test != "TestFlaky" || flaky.Recoveries != 1 || flaky.FailedBuilds != 2 || flaky.AnalyzedBuilds != 5 {
+		t.Errorf("suspects[1] = %+v", flaky)
+	}
+	if len(flaky.Evidence) != 2 || flaky.Evidence[0].BuildURL != "https://buildkite.com/org/api/builds/3" {
+		t.Errorf("evidence = %+v, want builds 3 and 1, newest first", flaky.Evidence)
+	}
+}
+
+func TestDetect_SeparatesBranches(t *testing.T) {
+	release := otherFailure(2)
+	release.Metadata["branch"] = "release"
+
+	// The release build doesn't count as a gap in main's history
+	cards := []contracts.TriageCard{failure(1, "TestX", false), release, failure(3, "TestX", false)}
+	if suspects := Detect(cards, Options{}); len(suspects) != 0 {
+		t.Errorf("Detect() = %+v, want none", suspects)
+	}
+}
@@ -0,0 +1,176 @@
+package flaky
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"destill-agent/src/githubactions"
+)
+
+// DefaultQuarantineFile is where quarantine PRs list tests, one per line.
+// Test runners or CI scripts read it to skip or retry the listed tests.
+const DefaultQuarantineFile = ".destill/quarantine.txt"
+
+// FormatReport renders suspects as Markdown with evidence links, for
+// notifications and pull request descriptions.
+func FormatReport(suspects []Suspect) string {
+	if len(suspects) == 0 {
+		return "No flaky tests found.\n"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Suggested for quarantine (%d):\n", len(suspects))
+	for _, s := range suspects {
+		scope := s.Pipeline
+		if s.Branch != "" {
+			scope += "@" + s.Branch
+		}
+		fmt.Fprintf(&b, "\n- `%s` (%s): %s\n", s.Test, scope, strings.Join(s.Reasons, "; "))
+		for _, e := range s.Evidence {
+			note := ""
+			if e.JobPassed {
+				note = ", job passed"
+			}
+			fmt.Fprintf(&b, "  - [%s](%s)%s\n", e.JobName, e.BuildURL, note)
+		}
+	}
+	return b.String()
+}
+
+// MergeQuarantine adds the suspects missing from a quarantine file (one test
+// per line; blank lines and # comments are kept). Returns the new content
+// and the tests that were added.
+func MergeQuarantine(existing []byte, suspects []Suspect) ([]byte, []string) {
+	listed := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(string(existing)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			listed[line] = true
+		}
+	}
+
+	content := string(existing)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	var added []string
+	for _, s := range suspects {
+		if listed[s.Test] {
+			continue
+		}
+		listed[s.Test] = true
+		added = append(added, s.Test)
+		content += fmt.Sprintf("# %s: %s\n%s\n", s.Pipeline, strings.Join(s.Reasons, "; "), s.Test)
+	}
+	return []byte(content), added
+}
+
+// PROptions configure OpenPR.
+type PROptions struct {
+	Owner, Repo string
+	Path        string // Quarantine file (default DefaultQuarantineFile)
+	Base        string // Target branch (default: the repository's default branch)
+}
+
+// OpenPR opens a pull request adding the suspects to the quarantine file.
+// Returns nil without error if every suspect is already listed.
+func OpenPR(ctx context.Context, gh *githubactions.Client, opts PROptions, suspects []Suspect) (*githubactions.PullRequest, error) {
+	if opts.Path == "" {
+		opts.Path = DefaultQuarantineFile
+	}
+	base := opts.Base
+	if base == "" {
+		var err error
+		if base, err = gh.GetDefaultBranch(ctx, opts.Owner, opts.Repo); err != nil {
+			return nil, fmt.Errorf("failed to get default branch: %w", err)
+		}
+	}
+
+	var existing []byte
+	var fileSHA string
+	file, err := gh.GetFile(ctx, opts.Owner, opts.Repo, opts.Path, base)
+	switch {
+	case err == nil:
+		existing, fileSHA = file.Content, file.SHA
+	case !errors.Is(err, githubactions.ErrNotFound):
+		return nil, fmt.Errorf("failed to read %s: %w", opts.Path, err)
+	}
+
+	content, added := MergeQuarantine(existing, suspects)
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	sha, err := gh.GetBranchSHA(ctx, opts.Owner, opts.Repo, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", base, err)
+	}
+	branch := "destill/quarantine-" + time.Now().UTC().Format("20060102-150405")
+	if err := gh.CreateBranch(ctx, opts.Owner, opts.Repo, branch, sha); err != nil {
+		return nil, fmt.Errorf("failed to create branch: %w", err)
+	}
+
+	title := fmt.Sprintf("Quarantine %d flaky tests", len(added))
+	if len(added) == 1 {
+		title = "Quarantine flaky test " + added[0]
+	}
+	if err := gh.PutFile(ctx, opts.Owner, opts.Repo, opts.Path, branch, title, content, fileSHA); err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", opts.Path, err)
+	}
+
+	var quarantined []Suspect
+	for _, s := range suspects {
+		for _, test := range added {
+			if s.Test == test {
+				quarantined = append(quarantined, s)
+				break
+			}
+		}
+	}
+	body := FormatReport(quarantined) + fmt.Sprintf("\nAdds the tests to `%s`. Generated by `destill flaky`.\n", opts.Path)
+	pr, err := gh.CreatePullRequest(ctx, opts.Owner, opts.Repo, title, body, branch, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pull request: %w", err)
+	}
+	return pr, nil
+}
+
+// PostWebhook POSTs the report as JSON, with the Markdown report in "text"
+// for chat incoming webhooks and the full data in "suspects".
+func PostWebhook(ctx context.Context, url string, suspects []Suspect) error {
+	body, err := json.Marshal(struct {
+		Text     string    `json:"text"`
+		Suspects []Suspect `json:"suspects"`
+	}{
+		Text:     FormatReport(suspects),
+		Suspects: suspects,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package flaky
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeQuarantine(t *testing.T) {
+	existing := []byte("# Quarantined tests\nTestOld\n")
+	suspects := []Suspect{
+		{Test: "TestOld", Pipeline: "org/api", Reasons: []string{"r"}},
+		{Test: "TestNew", Pipeline: "org/api", Reasons: []string{"failed but its job passed in 2 builds"}},
+	}
+
+	content, added := MergeQuarantine(existing, suspects)
+	if len(added) != 1 || added[0] != "TestNew" {
+		t.Errorf("added = %v, want [TestNew]", added)
+	}
+	want := "# Quarantined tests\nTestOld\n# org/api: failed but its job passed in 2 builds\nTestNew\n"
+	if string(content) != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+
+	if _, added := MergeQuarantine(content, suspects); len(added) != 0 {
+		t.Errorf("second merge added %v, want nothing", added)
+	}
+}
+
+func TestFormatReport(t *testing.T) {
+	report := FormatReport([]Suspect{{
+		Test:     "TestFlaky",
+		Pipeline: "org/api",
+		Branch:   "main",
+		Reasons:  []string{"failed in 2 of 5 builds, not consecutively"},
+		Evidence: []Evidence{{BuildURL: "https://buildkite.com/org/api/builds/3", JobName: "tests", JobPassed: true}},
+	}})
+	for _, want := range []string{"`TestFlaky` (org/api@main)", "[tests](https://buildkite.com/org/api/builds/3), job passed"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Package flaky finds tests that fail intermittently across analyzed builds
+// and suggests them for quarantine.
+package flaky
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/sanitize"
+)
+
+// testNameContextLines is how many context lines on each side of a finding
+// are searched for the failing test's name.
+const testNameContextLines = 5
+
+var (
+	// logTimestampPrefix matches the ISO timestamps GitHub Actions prefixes lines with
+	logTimestampPrefix = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z\s+`)
+
+	// testNamePatterns match the failure lines of common test runners. The
+	// submatches are joined with "." to form the name, in reverse order when
+	// reversed is set.
+	testNamePatterns = []struct {
+		re       *regexp.Regexp
+		reversed bool
+	}{
+		{re: regexp.MustCompile(`^--- FAIL: (\S+)`)},                                       // go test
+		{re: regexp.MustCompile(`^FAILED (\S+::\S+)`)},                                     // pytest
+		{re: regexp.MustCompile(`^([\w.$]+) > (.+?)(?:\(\))? FAILED$`)},                    // Gradle
+		{re: regexp.MustCompile(`^\[ERROR\]\s+([\w.$]+)\.(\w+):\d+`)},                      // Maven Surefire summary
+		{re: regexp.MustCompile(`^\[ERROR\]\s+(\w+)\(([\w.$]+)\)\s+Time`), reversed: true}, // Maven Surefire, older format
+		{re: regexp.MustCompile(`^rspec (\./\S+:\d+)`)},                                    // RSpec
+		{re: regexp.MustCompile(`^● (.+ › .+)$`)},                                          // Jest
+		{re: regexp.MustCompile(`^Failed (\S+) \[`)},                                       // dotnet test
+	}
+)
+
+// TestName extracts the name of a failed test from one log line.
+func TestName(line string) (string, bool) {
+	line = logTimestampPrefix.ReplaceAllString(sanitize.Clean(line), "")
+	line = strings.TrimSpace(line)
+	for _, p := range testNamePatterns {
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		parts := m[1:]
+		if p.reversed {
+			slices.Reverse(parts)
+		}
+		return strings.Join(parts, "."), true
+	}
+	return "", false
+}
+
+// CardTestName finds the failing test of a finding: in its message, or else
+// in the nearest context lines, looking above the error first.
+func CardTestName(card contracts.TriageCard) (string, bool) {
+	if name, ok := TestName(card.RawMessage); ok {
+		return name, true
+	}
+	for i := len(card.PreContext) - 1; i >= 0 && i >= len(card.PreContext)-testNameContextLines; i-- {
+		if name, ok := TestName(card.PreContext[i]); ok {
+			return name, true
+		}
+	}
+	for i := 0; i < len(card.PostContext) && i < testNameContextLines; i++ {
+		if name, ok := TestName(card.PostContext[i]); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
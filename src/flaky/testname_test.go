@@ -0,0 +1,50 @@
+package flaky
+
+import (
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestTestName(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"go", "--- FAIL: TestCheckout/expired_card (0.31s)", "TestCheckout/expired_card"},
+		{"go with timestamp", "2024-01-01T12:00:00.1234567Z --- FAIL: TestLogin (1.00s)", "TestLogin"},
+		{"pytest", "FAILED tests/test_api.py::test_retry - AssertionError: 500 != 200", "tests/test_api.py::test_retry"},
+		{"gradle", "com.example.CartTest > addsItem() FAILED", "com.example.CartTest.addsItem"},
+		{"maven summary", "[ERROR]   CartTest.addsItem:42 expected:<1> but was:<0>", "CartTest.addsItem"},
+		{"maven old", "[ERROR] addsItem(com.example.CartTest)  Time elapsed: 0.01 sec  <<< FAILURE!", "com.example.CartTest.addsItem"},
+		{"rspec", "rspec ./spec/models/user_spec.rb:12 # User validates email", "./spec/models/user_spec.rb:12"},
+		{"jest", "● Cart › adds an item", "Cart › adds an item"},
+		{"dotnet", "  Failed Shop.Tests.CartTests.AddsItem [12 ms]", "Shop.Tests.CartTests.AddsItem"},
+		{"not a test", "Error: connection refused", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := TestName(tt.line)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("TestName(%q) = %q, %v, want %q", tt.line, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestCardTestName_Context(t *testing.T) {
+	card := contracts.TriageCard{
+		RawMessage: "    checkout_test.go:42: expected 200, got 500",
+		PreContext: []string{"--- FAIL: TestOther (0.01s)", "=== RUN TestCheckout", "--- FAIL: TestCheckout (0.20s)"},
+	}
+	if got, _ := CardTestName(card); got != "TestCheckout" {
+		t.Errorf("CardTestName() = %q, want the nearest test above the error", got)
+	}
+
+	card.PreContext = nil
+	if _, ok := CardTestName(card); ok {
+		t.Error("CardTestName() found a test without one in the finding")
+	}
+}
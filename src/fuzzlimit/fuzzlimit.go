@@ -0,0 +1,24 @@
+// Package fuzzlimit holds the fuzz targets' guard against pathological
+// slowdowns, shared by the packages that fuzz log handling.
+package fuzzlimit
+
+import (
+	"testing"
+	"time"
+)
+
+// PerInput is how long a fuzz target may take on one input. No single input
+// should take anywhere near this long; one that does has hit a pathological
+// slowdown, such as regexp backtracking or quadratic splitting.
+const PerInput = time.Second
+
+// Check runs f, failing t if it takes longer than limit. what describes the
+// call in the failure, e.g. "Normalize(120 bytes)".
+func Check(t testing.TB, limit time.Duration, what string, f func()) {
+	t.Helper()
+	start := time.Now()
+	f()
+	if elapsed := time.Since(start); elapsed > limit {
+		t.Errorf("%s took %s", what, elapsed)
+	}
+}
@@ -9,8 +9,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"time"
+
+	"destill-agent/src/provider"
 )
 
 var (
@@ -31,7 +34,8 @@ func NewClient(token string) *Client {
 	return &Client{
 		token: token,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: provider.NewAPITransport(),
 		},
 		baseURL: "https://api.github.com",
 	}
@@ -77,6 +81,47 @@ func (c *Client) GetWorkflowRun(ctx context.Context, owner, repo, runID string)
 	return &run, nil
 }
 
+// ListWorkflowRuns fetches the most recent workflow runs for a repository, newest first.
+// branch and status are optional filters (empty means no filter).
+func (c *Client) ListWorkflowRuns(ctx context.Context, owner, repo, branch, status string, perPage int) ([]WorkflowRun, error) {
+	query := url.Values{}
+	if branch != "" {
+		query.Set("branch", branch)
+	}
+	if status != "" {
+		query.Set("status", status)
+	}
+	query.Set("per_page", fmt.Sprintf("%d", perPage))
+
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/actions/runs?%s", c.baseURL, owner, repo, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var runsResp WorkflowRunsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&runsResp); err != nil {
+		return nil, err
+	}
+
+	return runsResp.WorkflowRuns, nil
+}
+
 // GetWorkflowJobs fetches jobs for a workflow run (handles pagination)
 func (c *Client) GetWorkflowJobs(ctx context.Context, owner, repo, runID string) ([]WorkflowJob, error) {
 	var allJobs []WorkflowJob
@@ -126,13 +171,67 @@ func (c *Client) GetWorkflowJobs(ctx context.Context, owner, repo, runID string)
 	return allJobs, nil
 }
 
+// maxWorkflowFileSize caps how much of a workflow file is read, far above
+// any real workflow.
+const maxWorkflowFileSize = 1 << 20
+
+// GetWorkflowFile fetches the workflow file at path as of ref, a commit SHA
+func (c *Client) GetWorkflowFile(ctx context.Context, owner, repo, path, ref string) ([]byte, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", c.baseURL, owner, repo, path, url.QueryEscape(ref))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github.raw+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, string(body))
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxWorkflowFileSize))
+}
+
 // GetJobLogs fetches raw logs for a job (returns zip archive URL redirect)
 func (c *Client) GetJobLogs(ctx context.Context, owner, repo string, jobID int64) (string, error) {
+	logs, err := c.openJobLogs(ctx, c.httpClient, owner, repo, jobID)
+	if err != nil {
+		return "", err
+	}
+	defer logs.Close()
+
+	body, err := io.ReadAll(logs)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// StreamJobLogs opens a job's raw logs for reading as they download. The
+// client's timeout is not applied to the download, since reading a large log
+// can take longer; ctx bounds it instead. The caller must close the returned
+// body.
+func (c *Client) StreamJobLogs(ctx context.Context, owner, repo string, jobID int64) (io.ReadCloser, error) {
+	return c.openJobLogs(ctx, &http.Client{Transport: c.httpClient.Transport}, owner, repo, jobID)
+}
+
+// openJobLogs resolves the redirect to a job's logs and opens them with
+// logClient.
+func (c *Client) openJobLogs(ctx context.Context, logClient *http.Client, owner, repo string, jobID int64) (io.ReadCloser, error) {
 	url := fmt.Sprintf("%s/repos/%s/%s/actions/jobs/%d/logs", c.baseURL, owner, repo, jobID)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.token)
@@ -150,38 +249,39 @@ func (c *Client) GetJobLogs(ctx context.Context, owner, repo string, jobID int64
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusFound {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, string(body))
 	}
 
 	// Follow redirect to download logs
 	logURL := resp.Header.Get("Location")
 	if logURL == "" {
-		return "", errors.New("no redirect location for logs")
+		return nil, errors.New("no redirect location for logs")
 	}
 
-	logReq, err := http.NewRequestWithContext(ctx, "GET", logURL, nil)
+	// The redirect is signed anew each time: cache the logs under the API URL
+	logReq, err := http.NewRequestWithContext(provider.WithCacheKey(ctx, url), "GET", logURL, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	logResp, err := c.httpClient.Do(logReq)
+	logResp, err := logClient.Do(logReq)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer logResp.Body.Close()
 
-	body, err := io.ReadAll(logResp.Body)
-	if err != nil {
-		return "", err
+	if logResp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(logResp.Body)
+		logResp.Body.Close()
+		return nil, fmt.Errorf("GitHub log download error %d: %s", logResp.StatusCode, string(body))
 	}
 
-	return string(body), nil
+	return logResp.Body, nil
 }
 
 // GetArtifacts fetches artifacts for a workflow run
@@ -2,11 +2,21 @@ package githubactions
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+
+	"destill-agent/src/provider"
 )
 
+func TestMain(m *testing.M) {
+	// Keep test responses out of the user's API response cache
+	os.Setenv(provider.EnvHTTPCache, "off")
+	os.Exit(m.Run())
+}
+
 func TestClient_NewClient(t *testing.T) {
 	client := NewClient("fake-token")
 	if client == nil {
@@ -149,3 +159,41 @@ func TestClient_GetWorkflowRun_Unauthorized(t *testing.T) {
 		t.Errorf("error = %v, want to start with %s", err, expectedErr)
 	}
 }
+
+func TestClient_StreamJobLogs(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/actions/jobs/42/logs":
+			http.Redirect(w, r, server.URL+"/download/42", http.StatusFound)
+		case "/repos/owner/repo/actions/jobs/43/logs":
+			http.Redirect(w, r, server.URL+"/download/expired", http.StatusFound)
+		case "/download/42":
+			w.Write([]byte("##[group]Run tests\nFAIL\n"))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+
+	body, err := client.StreamJobLogs(context.Background(), "owner", "repo", 42)
+	if err != nil {
+		t.Fatalf("StreamJobLogs() error = %v", err)
+	}
+	defer body.Close()
+	content, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("reading log: %v", err)
+	}
+	if string(content) != "##[group]Run tests\nFAIL\n" {
+		t.Errorf("log = %q, want the redirected download", content)
+	}
+
+	// A failed download is an error rather than a log of the error page
+	if _, err := client.StreamJobLogs(context.Background(), "owner", "repo", 43); err == nil {
+		t.Error("StreamJobLogs() of a forbidden download: expected error, got nil")
+	}
+}
@@ -0,0 +1,107 @@
+package githubactions
+
+import (
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// workflowFile is the part of a workflow file describing job dependencies.
+type workflowFile struct {
+	Jobs map[string]struct {
+		Name  string     `yaml:"name"`
+		Needs stringList `yaml:"needs"`
+	} `yaml:"jobs"`
+}
+
+// stringList decodes a string or a list of strings, as needs: takes.
+type stringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *stringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = stringList{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// dependencies returns the IDs of the jobs each job of a run needed,
+// from the needs: of its workflow file. The run's jobs are matched to the
+// workflow's by name: the job's name:, or its key without one, followed by
+// the matrix values for matrix jobs, e.g. "test (ubuntu, 1.22)". A name: with
+// an expression matches on the text before it. id is the provider.Job ID of
+// a run's job; jobs without dependencies are left out.
+func dependencies(workflow []byte, jobs []WorkflowJob, id func(WorkflowJob) string) (map[string][]string, error) {
+	var file workflowFile
+	if err := yaml.Unmarshal(workflow, &file); err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string][]string) // Workflow job key -> IDs of its run jobs
+	keyOf := make(map[string]string)   // Run job ID -> workflow job key
+	for _, job := range jobs {
+		if key := matchWorkflowJob(file, job.Name); key != "" {
+			byKey[key] = append(byKey[key], id(job))
+			keyOf[id(job)] = key
+		}
+	}
+
+	deps := make(map[string][]string)
+	for _, job := range jobs {
+		key, ok := keyOf[id(job)]
+		if !ok {
+			continue
+		}
+		var ids []string
+		for _, need := range file.Jobs[key].Needs {
+			for _, needed := range byKey[need] {
+				if !slices.Contains(ids, needed) {
+					ids = append(ids, needed)
+				}
+			}
+		}
+		if len(ids) > 0 {
+			deps[id(job)] = ids
+		}
+	}
+	return deps, nil
+}
+
+// matchWorkflowJob returns the key of the workflow job a run job is named
+// after, preferring an exact match, or "" if there is none.
+func matchWorkflowJob(file workflowFile, name string) string {
+	keys := make([]string, 0, len(file.Jobs))
+	for key := range file.Jobs {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	match := ""
+	for _, key := range keys {
+		display := file.Jobs[key].Name
+		if display == "" {
+			display = key
+		}
+		if name == display {
+			return key
+		}
+		if match != "" {
+			continue
+		}
+		if prefix, _, expression := strings.Cut(display, "${{"); expression {
+			if prefix = strings.TrimSpace(prefix); prefix != "" && strings.HasPrefix(name, prefix) {
+				match = key
+			}
+		} else if strings.HasPrefix(name, display+" (") {
+			match = key
+		}
+	}
+	return match
+}
@@ -0,0 +1,97 @@
+package githubactions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+
+	"destill-agent/src/provider"
+)
+
+const testWorkflow = `
+name: CI
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+  test:
+    needs: build
+    strategy:
+      matrix:
+        os: [ubuntu, macos]
+  deploy:
+    name: Deploy ${{ inputs.env }}
+    needs: [test, lint]
+  lint:
+    name: Lint
+`
+
+func TestDependencies(t *testing.T) {
+	jobs := []WorkflowJob{
+		{ID: 1, Name: "build"},
+		{ID: 2, Name: "test (ubuntu)"},
+		{ID: 3, Name: "test (macos)"},
+		{ID: 4, Name: "Lint"},
+		{ID: 5, Name: "Deploy staging"},
+		{ID: 6, Name: "not in the workflow"},
+	}
+	id := func(job WorkflowJob) string { return strconv.FormatInt(job.ID, 10) }
+
+	deps, err := dependencies([]byte(testWorkflow), jobs, id)
+	if err != nil {
+		t.Fatalf("dependencies() error = %v", err)
+	}
+	want := map[string][]string{
+		"2": {"1"},
+		"3": {"1"},
+		"5": {"2", "3", "4"},
+	}
+	if len(deps) != len(want) {
+		t.Fatalf("dependencies() = %v, want %v", deps, want)
+	}
+	for job, w := range want {
+		if got := deps[job]; !slices.Equal(got, w) {
+			t.Errorf("dependencies()[%s] = %v, want %v", job, got, w)
+		}
+	}
+
+	if _, err := dependencies([]byte("jobs: ["), jobs, id); err == nil {
+		t.Error("dependencies(invalid YAML) error = nil")
+	}
+}
+
+func TestGitHubProvider_FetchBuild_Dependencies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/o/r/actions/runs/1":
+			json.NewEncoder(w).Encode(WorkflowRun{ID: 1, HeadSHA: "abc123", Path: ".github/workflows/ci.yml"})
+		case "/repos/o/r/actions/runs/1/jobs":
+			json.NewEncoder(w).Encode(WorkflowJobsResponse{TotalCount: 2, Jobs: []WorkflowJob{{ID: 10, Name: "build"}, {ID: 11, Name: "test (ubuntu)"}}})
+		case "/repos/o/r/contents/.github/workflows/ci.yml":
+			if r.URL.Query().Get("ref") != "abc123" {
+				t.Errorf("workflow ref = %q, want the run's commit", r.URL.Query().Get("ref"))
+			}
+			w.Write([]byte(testWorkflow))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+	build, err := p.FetchBuild(context.Background(), &provider.BuildRef{BuildID: "1", Metadata: map[string]string{"owner": "o", "repo": "r"}})
+	if err != nil {
+		t.Fatalf("FetchBuild() error = %v", err)
+	}
+	if deps := build.Jobs[1].DependsOn; !slices.Equal(deps, []string{"o/r/10"}) {
+		t.Errorf("test job DependsOn = %v, want the build job", deps)
+	}
+	if build.Jobs[0].DependsOn != nil {
+		t.Errorf("build job DependsOn = %v, want none", build.Jobs[0].DependsOn)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"destill-agent/src/provider"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 func init() {
@@ -18,12 +20,16 @@ func init() {
 // Provider implements provider.Provider for GitHub Actions
 type Provider struct {
 	client *Client
+
+	mu   sync.Mutex
+	runs map[string]string // Job ID -> workflow run ID, recorded by FetchBuild
 }
 
 // NewProvider creates a GitHub Actions provider with API token
 func NewProvider(token string) *Provider {
 	return &Provider{
 		client: NewClient(token),
+		runs:   make(map[string]string),
 	}
 }
 
@@ -58,30 +64,121 @@ func (p *Provider) FetchBuild(ctx context.Context, ref *provider.BuildRef) (*pro
 		Number:    fmt.Sprintf("%d", run.RunNumber),
 		URL:       run.HTMLURL,
 		State:     mapGitHubStatus(run.Status, run.Conclusion),
+		Branch:    run.HeadBranch,
+		Trigger:   mapGitHubEvent(run.Event),
+		Commit:    run.HeadSHA,
 		Timestamp: run.CreatedAt,
 		Jobs:      make([]provider.Job, 0, len(jobs)),
 	}
 
+	// Job dependencies, from the needs: of the workflow file. Without it
+	// the build is analyzed as before, so a failure is ignored.
+	jobID := func(ghJob WorkflowJob) string { return fmt.Sprintf("%s/%s/%d", owner, repo, ghJob.ID) }
+	var dependsOn map[string][]string
+	if run.Path != "" && run.HeadSHA != "" {
+		if workflow, err := p.client.GetWorkflowFile(ctx, owner, repo, run.Path, run.HeadSHA); err == nil {
+			dependsOn, _ = dependencies(workflow, jobs, jobID)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	for _, ghJob := range jobs {
 		exitCode := 0
 		if ghJob.Conclusion == "failure" {
 			exitCode = 1
 		}
 
+		id := jobID(ghJob)
+		p.runs[id] = runID
 		build.Jobs = append(build.Jobs, provider.Job{
-			ID:        fmt.Sprintf("%s/%s/%d", owner, repo, ghJob.ID),
+			ID:        id,
 			Name:      ghJob.Name,
 			Type:      "script", // GitHub Actions doesn't distinguish types
 			State:     mapGitHubStatus(ghJob.Status, ghJob.Conclusion),
 			ExitCode:  exitCode,
 			BuildID:   fmt.Sprintf("%d", run.ID),
 			Timestamp: ghJob.StartedAt,
+			Duration:  provider.Elapsed(&ghJob.StartedAt, ghJob.CompletedAt),
+			DependsOn: dependsOn[id],
 		})
 	}
 
 	return build, nil
 }
 
+// FetchLatestFailedBuild finds the most recent failed workflow run on a branch
+func (p *Provider) FetchLatestFailedBuild(ctx context.Context, ref *provider.BuildRef, branch string) (*provider.Build, error) {
+	owner := ref.Metadata["owner"]
+	repo := ref.Metadata["repo"]
+
+	runs, err := p.client.ListWorkflowRuns(ctx, owner, repo, branch, "failure", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("%w: no failed runs for %s/%s on branch %s",
+			provider.ErrBuildNotFound, owner, repo, branch)
+	}
+
+	run := runs[0]
+	return &provider.Build{
+		ID:        fmt.Sprintf("%d", run.ID),
+		Number:    fmt.Sprintf("%d", run.RunNumber),
+		URL:       run.HTMLURL,
+		State:     mapGitHubStatus(run.Status, run.Conclusion),
+		Branch:    run.HeadBranch,
+		Trigger:   mapGitHubEvent(run.Event),
+		Commit:    run.HeadSHA,
+		Timestamp: run.CreatedAt,
+	}, nil
+}
+
+// lastPassedRunWindow is how many recent successful runs of a repository
+// FetchLastPassedBuild looks through for one like the failed run.
+const lastPassedRunWindow = 50
+
+// FetchLastPassedBuild finds the most recent successful run of build's
+// workflow before it, on its branch and with its trigger (see
+// provider.CommitHistory).
+func (p *Provider) FetchLastPassedBuild(ctx context.Context, ref *provider.BuildRef, build *provider.Build) (*provider.Build, error) {
+	owner := ref.Metadata["owner"]
+	repo := ref.Metadata["repo"]
+
+	// Runs of every workflow of the repository are listed together
+	failed, err := p.client.GetWorkflowRun(ctx, owner, repo, build.ID)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := p.client.ListWorkflowRuns(ctx, owner, repo, build.Branch, "success", lastPassedRunWindow)
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		if run.WorkflowID != failed.WorkflowID || !run.CreatedAt.Before(build.Timestamp) || mapGitHubEvent(run.Event) != build.Trigger {
+			continue
+		}
+		return &provider.Build{
+			ID:        fmt.Sprintf("%d", run.ID),
+			Number:    fmt.Sprintf("%d", run.RunNumber),
+			URL:       run.HTMLURL,
+			State:     mapGitHubStatus(run.Status, run.Conclusion),
+			Branch:    run.HeadBranch,
+			Trigger:   mapGitHubEvent(run.Event),
+			Commit:    run.HeadSHA,
+			Timestamp: run.CreatedAt,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: no successful runs of %s in %s/%s on branch %s before run %s",
+		provider.ErrBuildNotFound, failed.Name, owner, repo, build.Branch, build.Number)
+}
+
+// CountCommits counts the commits after base up to head with the compare
+// API (see provider.CommitHistory).
+func (p *Provider) CountCommits(ctx context.Context, ref *provider.BuildRef, base, head string) (int, error) {
+	return p.client.CompareCommits(ctx, ref.Metadata["owner"], ref.Metadata["repo"], base, head)
+}
+
 // FetchJobLog retrieves raw log content for a job
 func (p *Provider) FetchJobLog(ctx context.Context, jobID string) (string, error) {
 	// Extract owner/repo from stored metadata (we'll need to pass this differently)
@@ -101,13 +198,55 @@ func (p *Provider) FetchJobLog(ctx context.Context, jobID string) (string, error
 	return p.client.GetJobLogs(ctx, owner, repo, id)
 }
 
-// FetchArtifacts retrieves artifacts for the workflow run
+// StreamJobLog opens a job's log for reading as it downloads, so large logs
+// are chunked without being held in memory. The caller closes the reader.
+func (p *Provider) StreamJobLog(ctx context.Context, jobID string) (io.ReadCloser, error) {
+	parts := strings.Split(jobID, "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid job ID format: %s", jobID)
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job ID number: %s", parts[2])
+	}
+	return p.client.StreamJobLogs(ctx, parts[0], parts[1], id)
+}
+
+// FetchArtifacts retrieves the artifacts of the job's workflow run. GitHub
+// artifacts belong to the run, not a job, so every job of a run lists the
+// same ones; their IDs tell them apart. The job's build must have been
+// fetched with FetchBuild first, which records the run of each job.
 func (p *Provider) FetchArtifacts(ctx context.Context, jobID string) ([]provider.Artifact, error) {
-	// Known limitation: GitHub artifacts are per-run, not per-job.
-	// The current provider interface passes jobID, but GitHub's API requires runID.
-	// This will be addressed when the ingest agent is updated to support per-run artifacts.
-	// For now, return empty list as a placeholder implementation.
-	return []provider.Artifact{}, nil
+	parts := strings.Split(jobID, "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid job ID format: %s", jobID)
+	}
+	p.mu.Lock()
+	runID, ok := p.runs[jobID]
+	p.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown workflow run for job %s", jobID)
+	}
+
+	ghArtifacts, err := p.client.GetArtifacts(ctx, parts[0], parts[1], runID)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make([]provider.Artifact, 0, len(ghArtifacts))
+	for _, ghArt := range ghArtifacts {
+		if ghArt.Expired {
+			continue
+		}
+		artifacts = append(artifacts, provider.Artifact{
+			ID:          strconv.FormatInt(ghArt.ID, 10),
+			JobID:       jobID,
+			Path:        ghArt.Name,
+			DownloadURL: ghArt.ArchiveDownloadURL,
+			FileSize:    ghArt.SizeInBytes,
+		})
+	}
+	return artifacts, nil
 }
 
 // DownloadArtifact downloads artifact content (returns first file from zip)
@@ -141,3 +280,23 @@ func mapGitHubStatus(status, conclusion string) string {
 	}
 	return status
 }
+
+// mapGitHubEvent maps a workflow run event to a normalized trigger
+func mapGitHubEvent(event string) string {
+	switch event {
+	case "schedule":
+		return provider.TriggerSchedule
+	case "pull_request", "pull_request_target", "merge_group":
+		return provider.TriggerPullRequest
+	case "push":
+		return provider.TriggerPush
+	case "workflow_dispatch":
+		return provider.TriggerManual
+	case "repository_dispatch":
+		return provider.TriggerAPI
+	case "workflow_run", "workflow_call":
+		return provider.TriggerUpstream
+	default:
+		return ""
+	}
+}
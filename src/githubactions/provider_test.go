@@ -3,6 +3,7 @@ package githubactions
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -169,6 +170,82 @@ func TestGitHubProvider_FetchBuild(t *testing.T) {
 	}
 }
 
+func TestGitHubProvider_FetchLatestFailedBuild(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/testowner/testrepo/actions/runs" {
+			http.NotFound(w, r)
+			return
+		}
+
+		// Verify filters are passed through
+		if got := r.URL.Query().Get("branch"); got != "main" {
+			t.Errorf("branch = %v, want main", got)
+		}
+		if got := r.URL.Query().Get("status"); got != "failure" {
+			t.Errorf("status = %v, want failure", got)
+		}
+
+		resp := WorkflowRunsResponse{
+			TotalCount: 1,
+			WorkflowRuns: []WorkflowRun{
+				{
+					ID:         999,
+					RunNumber:  77,
+					Status:     "completed",
+					Conclusion: "failure",
+					HTMLURL:    "https://github.com/testowner/testrepo/actions/runs/999",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+
+	ref := &provider.BuildRef{
+		Provider: "github",
+		Metadata: map[string]string{"owner": "testowner", "repo": "testrepo"},
+	}
+
+	build, err := p.FetchLatestFailedBuild(context.Background(), ref, "main")
+	if err != nil {
+		t.Fatalf("FetchLatestFailedBuild() error = %v", err)
+	}
+	if build.ID != "999" {
+		t.Errorf("Build.ID = %v, want 999", build.ID)
+	}
+	if build.State != "failed" {
+		t.Errorf("Build.State = %v, want failed", build.State)
+	}
+	if build.URL != "https://github.com/testowner/testrepo/actions/runs/999" {
+		t.Errorf("Build.URL = %v, want https://github.com/testowner/testrepo/actions/runs/999", build.URL)
+	}
+}
+
+func TestGitHubProvider_FetchLatestFailedBuild_NoRuns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"total_count": 0, "workflow_runs": []}`))
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+
+	ref := &provider.BuildRef{
+		Provider: "github",
+		Metadata: map[string]string{"owner": "testowner", "repo": "testrepo"},
+	}
+
+	_, err := p.FetchLatestFailedBuild(context.Background(), ref, "main")
+	if !errors.Is(err, provider.ErrBuildNotFound) {
+		t.Errorf("FetchLatestFailedBuild() error = %v, want ErrBuildNotFound", err)
+	}
+}
+
 func TestGitHubProvider_FetchJobLog(t *testing.T) {
 	// Create mock server
 	var serverURL string
@@ -213,6 +290,50 @@ func TestGitHubProvider_FetchJobLog(t *testing.T) {
 	}
 }
 
+func TestGitHubProvider_FetchArtifacts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/repos/testowner/testrepo/actions/runs/12345":
+			json.NewEncoder(w).Encode(WorkflowRun{ID: 12345, Status: "completed", Conclusion: "failure"})
+		case "/repos/testowner/testrepo/actions/runs/12345/jobs":
+			json.NewEncoder(w).Encode(WorkflowJobsResponse{TotalCount: 1, Jobs: []WorkflowJob{{ID: 67890, Name: "test"}}})
+		case "/repos/testowner/testrepo/actions/runs/12345/artifacts":
+			json.NewEncoder(w).Encode(ArtifactsResponse{TotalCount: 2, Artifacts: []Artifact{
+				{ID: 1, Name: "crash-logs", SizeInBytes: 2048, ArchiveDownloadURL: "https://api.github.com/artifacts/1/zip"},
+				{ID: 2, Name: "old-logs", Expired: true},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+
+	// The job's run is only known once its build is fetched
+	if _, err := p.FetchArtifacts(context.Background(), "testowner/testrepo/67890"); err == nil {
+		t.Error("FetchArtifacts() before FetchBuild() succeeded, want an error")
+	}
+
+	ref := &provider.BuildRef{Provider: "github", BuildID: "12345", Metadata: map[string]string{"owner": "testowner", "repo": "testrepo"}}
+	if _, err := p.FetchBuild(context.Background(), ref); err != nil {
+		t.Fatalf("FetchBuild() error = %v", err)
+	}
+	artifacts, err := p.FetchArtifacts(context.Background(), "testowner/testrepo/67890")
+	if err != nil {
+		t.Fatalf("FetchArtifacts() error = %v", err)
+	}
+	if len(artifacts) != 1 {
+		t.Fatalf("got %d artifacts, want the unexpired one: %+v", len(artifacts), artifacts)
+	}
+	want := provider.Artifact{ID: "1", JobID: "testowner/testrepo/67890", Path: "crash-logs", DownloadURL: "https://api.github.com/artifacts/1/zip", FileSize: 2048}
+	if artifacts[0] != want {
+		t.Errorf("artifact = %+v, want %+v", artifacts[0], want)
+	}
+}
+
 func TestGitHubProvider_FetchJobLog_InvalidFormat(t *testing.T) {
 	p := NewProvider("test-token")
 
@@ -277,3 +398,20 @@ func TestMapGitHubStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestMapGitHubEvent(t *testing.T) {
+	tests := map[string]string{
+		"schedule":          "schedule",
+		"pull_request":      "pull_request",
+		"push":              "push",
+		"workflow_dispatch": "manual",
+		"workflow_run":      "upstream",
+		"release":           "",
+	}
+
+	for event, want := range tests {
+		if got := mapGitHubEvent(event); got != want {
+			t.Errorf("mapGitHubEvent(%v) = %q, want %q", event, got, want)
+		}
+	}
+}
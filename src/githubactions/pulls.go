@@ -0,0 +1,156 @@
+package githubactions
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrNotFound is returned when a repository object does not exist.
+var ErrNotFound = errors.New("not found")
+
+// RepoFile is a file's content at a ref.
+type RepoFile struct {
+	Content []byte
+	SHA     string // Blob SHA, needed to update the file
+}
+
+// PullRequest is a created pull request.
+type PullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+// GetDefaultBranch returns the repository's default branch.
+func (c *Client) GetDefaultBranch(ctx context.Context, owner, repo string) (string, error) {
+	var r struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.do(ctx, "GET", fmt.Sprintf("/repos/%s/%s", owner, repo), nil, &r); err != nil {
+		return "", err
+	}
+	return r.DefaultBranch, nil
+}
+
+// GetBranchSHA returns the commit SHA a branch points at.
+func (c *Client) GetBranchSHA(ctx context.Context, owner, repo, branch string) (string, error) {
+	var ref struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	if err := c.do(ctx, "GET", fmt.Sprintf("/repos/%s/%s/git/ref/heads/%s", owner, repo, branch), nil, &ref); err != nil {
+		return "", err
+	}
+	return ref.Object.SHA, nil
+}
+
+// CompareCommits returns how many commits head is ahead of base.
+func (c *Client) CompareCommits(ctx context.Context, owner, repo, base, head string) (int, error) {
+	var cmp struct {
+		AheadBy int `json:"ahead_by"`
+	}
+	if err := c.do(ctx, "GET", fmt.Sprintf("/repos/%s/%s/compare/%s...%s", owner, repo, base, head), nil, &cmp); err != nil {
+		return 0, err
+	}
+	return cmp.AheadBy, nil
+}
+
+// CreateBranch creates branch at commit sha.
+func (c *Client) CreateBranch(ctx context.Context, owner, repo, branch, sha string) error {
+	body := map[string]string{"ref": "refs/heads/" + branch, "sha": sha}
+	return c.do(ctx, "POST", fmt.Sprintf("/repos/%s/%s/git/refs", owner, repo), body, nil)
+}
+
+// GetFile reads a file at ref. Returns ErrNotFound if it doesn't exist.
+func (c *Client) GetFile(ctx context.Context, owner, repo, path, ref string) (*RepoFile, error) {
+	var f struct {
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+		SHA      string `json:"sha"`
+	}
+	endpoint := fmt.Sprintf("/repos/%s/%s/contents/%s?ref=%s", owner, repo, path, url.QueryEscape(ref))
+	if err := c.do(ctx, "GET", endpoint, nil, &f); err != nil {
+		return nil, err
+	}
+	if f.Encoding != "base64" {
+		return nil, fmt.Errorf("unsupported content encoding %q for %s", f.Encoding, path)
+	}
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(f.Content, "\n", ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return &RepoFile{Content: content, SHA: f.SHA}, nil
+}
+
+// PutFile creates or updates a file on branch with one commit. sha is the
+// blob SHA of the file being replaced, or "" to create it.
+func (c *Client) PutFile(ctx context.Context, owner, repo, path, branch, message string, content []byte, sha string) error {
+	body := map[string]string{
+		"message": message,
+		"content": base64.StdEncoding.EncodeToString(content),
+		"branch":  branch,
+	}
+	if sha != "" {
+		body["sha"] = sha
+	}
+	return c.do(ctx, "PUT", fmt.Sprintf("/repos/%s/%s/contents/%s", owner, repo, path), body, nil)
+}
+
+// CreatePullRequest opens a pull request from head into base.
+func (c *Client) CreatePullRequest(ctx context.Context, owner, repo, title, body, head, base string) (*PullRequest, error) {
+	req := map[string]string{"title": title, "body": body, "head": head, "base": base}
+	var pr PullRequest
+	if err := c.do(ctx, "POST", fmt.Sprintf("/repos/%s/%s/pulls", owner, repo), req, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// do sends a JSON request to the API path and decodes the response into out
+// (if not nil).
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", method, path, ErrNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, string(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,95 @@
+package githubactions
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_GetFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/contents/.destill/quarantine.txt":
+			if r.URL.Query().Get("ref") != "main" {
+				t.Errorf("ref = %q, want main", r.URL.Query().Get("ref"))
+			}
+			// The API wraps base64 content at 60 characters
+			encoded := base64.StdEncoding.EncodeToString([]byte("TestA\nTestB\n"))
+			json.NewEncoder(w).Encode(map[string]string{
+				"content":  encoded[:8] + "\n" + encoded[8:],
+				"encoding": "base64",
+				"sha":      "blob-sha",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+
+	file, err := client.GetFile(context.Background(), "owner", "repo", ".destill/quarantine.txt", "main")
+	if err != nil {
+		t.Fatalf("GetFile() error = %v", err)
+	}
+	if string(file.Content) != "TestA\nTestB\n" || file.SHA != "blob-sha" {
+		t.Errorf("GetFile() = %q, %q", file.Content, file.SHA)
+	}
+
+	if _, err := client.GetFile(context.Background(), "owner", "repo", "missing.txt", "main"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetFile(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_PutFileAndCreatePullRequest(t *testing.T) {
+	var put, pull map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/repos/owner/repo/contents/q.txt":
+			json.NewDecoder(r.Body).Decode(&put)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/repo/pulls":
+			json.NewDecoder(r.Body).Decode(&pull)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"number": 7, "html_url": "https://github.com/owner/repo/pull/7"}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+	ctx := context.Background()
+
+	if err := client.PutFile(ctx, "owner", "repo", "q.txt", "fix", "Quarantine", []byte("TestA\n"), ""); err != nil {
+		t.Fatalf("PutFile() error = %v", err)
+	}
+	if put["branch"] != "fix" || put["content"] != base64.StdEncoding.EncodeToString([]byte("TestA\n")) {
+		t.Errorf("PutFile() body = %v", put)
+	}
+	if _, ok := put["sha"]; ok {
+		t.Error("PutFile() sent a sha when creating a file")
+	}
+
+	pr, err := client.CreatePullRequest(ctx, "owner", "repo", "Quarantine", "body", "fix", "main")
+	if err != nil {
+		t.Fatalf("CreatePullRequest() error = %v", err)
+	}
+	if pr.Number != 7 || pr.HTMLURL != "https://github.com/owner/repo/pull/7" {
+		t.Errorf("CreatePullRequest() = %+v", pr)
+	}
+	if pull["head"] != "fix" || pull["base"] != "main" {
+		t.Errorf("CreatePullRequest() body = %v", pull)
+	}
+}
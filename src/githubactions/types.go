@@ -10,18 +10,24 @@ type WorkflowRun struct {
 	Status     string    `json:"status"`
 	Conclusion string    `json:"conclusion"`
 	HTMLURL    string    `json:"html_url"`
+	HeadBranch string    `json:"head_branch"`
+	HeadSHA    string    `json:"head_sha"`
+	WorkflowID int64     `json:"workflow_id"`
+	Path       string    `json:"path"`  // Workflow file, e.g. ".github/workflows/ci.yml"
+	Event      string    `json:"event"` // push, pull_request, schedule, workflow_dispatch, ...
 	CreatedAt  time.Time `json:"created_at"`
 }
 
 // WorkflowJob represents a job within a workflow run
 type WorkflowJob struct {
-	ID         int64     `json:"id"`
-	RunID      int64     `json:"run_id"`
-	Name       string    `json:"name"`
-	Status     string    `json:"status"`
-	Conclusion string    `json:"conclusion"`
-	StartedAt  time.Time `json:"started_at"`
-	Steps      []Step    `json:"steps"`
+	ID          int64      `json:"id"`
+	RunID       int64      `json:"run_id"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Steps       []Step     `json:"steps"`
 }
 
 // Step represents a step within a job
@@ -48,6 +54,12 @@ type WorkflowJobsResponse struct {
 	Jobs       []WorkflowJob `json:"jobs"`
 }
 
+// WorkflowRunsResponse is the API response for listing workflow runs
+type WorkflowRunsResponse struct {
+	TotalCount   int           `json:"total_count"`
+	WorkflowRuns []WorkflowRun `json:"workflow_runs"`
+}
+
 // ArtifactsResponse is the API response for listing artifacts
 type ArtifactsResponse struct {
 	TotalCount int        `json:"total_count"`
@@ -0,0 +1,201 @@
+// Package gitlab provides a GitLab CI provider.
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"destill-agent/src/provider"
+)
+
+var (
+	ErrInvalidURL = errors.New("invalid GitLab pipeline URL")
+)
+
+// pipelineURLPattern matches https://gitlab.com/group[/subgroup...]/project/-/pipelines/123
+var pipelineURLPattern = regexp.MustCompile(`^https://gitlab\.com/(.+?)/-/pipelines/(\d+)`)
+
+// Client is a GitLab REST API (v4) client
+type Client struct {
+	token      string
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient creates a new GitLab client
+func NewClient(token string) *Client {
+	return &Client{
+		token: token,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		baseURL: "https://gitlab.com/api/v4",
+	}
+}
+
+// ParsePipelineURL extracts the project path and pipeline ID from URL
+func ParsePipelineURL(pipelineURL string) (project, pipelineID string, err error) {
+	matches := pipelineURLPattern.FindStringSubmatch(pipelineURL)
+	if matches == nil {
+		return "", "", fmt.Errorf("%w: %s", ErrInvalidURL, pipelineURL)
+	}
+	return matches[1], matches[2], nil
+}
+
+// GetPipeline fetches pipeline metadata
+func (c *Client) GetPipeline(ctx context.Context, project, pipelineID string) (*Pipeline, error) {
+	endpoint := fmt.Sprintf("%s/projects/%s/pipelines/%s", c.baseURL, url.PathEscape(project), pipelineID)
+
+	var pipeline Pipeline
+	if _, err := c.getJSON(ctx, endpoint, &pipeline); err != nil {
+		return nil, err
+	}
+	return &pipeline, nil
+}
+
+// ListPipelines fetches the most recent pipelines for a project, newest first.
+// ref and status are optional filters (empty means no filter).
+func (c *Client) ListPipelines(ctx context.Context, project, ref, status string, perPage int) ([]Pipeline, error) {
+	query := url.Values{}
+	if ref != "" {
+		query.Set("ref", ref)
+	}
+	if status != "" {
+		query.Set("status", status)
+	}
+	query.Set("per_page", strconv.Itoa(perPage))
+
+	endpoint := fmt.Sprintf("%s/projects/%s/pipelines?%s", c.baseURL, url.PathEscape(project), query.Encode())
+
+	var pipelines []Pipeline
+	if _, err := c.getJSON(ctx, endpoint, &pipelines); err != nil {
+		return nil, err
+	}
+	return pipelines, nil
+}
+
+// GetPipelineJobs fetches all jobs for a pipeline (handles pagination)
+func (c *Client) GetPipelineJobs(ctx context.Context, project, pipelineID string) ([]Job, error) {
+	var allJobs []Job
+	page := 1
+	perPage := 100 // GitLab's max per page
+
+	for {
+		endpoint := fmt.Sprintf("%s/projects/%s/pipelines/%s/jobs?per_page=%d&page=%d",
+			c.baseURL, url.PathEscape(project), pipelineID, perPage, page)
+
+		var jobs []Job
+		header, err := c.getJSON(ctx, endpoint, &jobs)
+		if err != nil {
+			return nil, err
+		}
+		allJobs = append(allJobs, jobs...)
+
+		// X-Next-Page is empty on the last page
+		if header.Get("X-Next-Page") == "" || len(jobs) < perPage {
+			break
+		}
+		page++
+	}
+
+	return allJobs, nil
+}
+
+// GetJob fetches a single job
+func (c *Client) GetJob(ctx context.Context, project string, jobID int64) (*Job, error) {
+	endpoint := fmt.Sprintf("%s/projects/%s/jobs/%d", c.baseURL, url.PathEscape(project), jobID)
+
+	var job Job
+	if _, err := c.getJSON(ctx, endpoint, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJobTrace fetches the raw log (trace) for a job
+func (c *Client) GetJobTrace(ctx context.Context, project string, jobID int64) (string, error) {
+	endpoint := fmt.Sprintf("%s/projects/%s/jobs/%d/trace", c.baseURL, url.PathEscape(project), jobID)
+
+	resp, err := c.do(ctx, endpoint)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := provider.ReadLog(ctx, resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	return string(body), nil
+}
+
+// JobArtifactsURL returns the download URL for a job's artifacts archive
+func (c *Client) JobArtifactsURL(project string, jobID int64) string {
+	return fmt.Sprintf("%s/projects/%s/jobs/%d/artifacts", c.baseURL, url.PathEscape(project), jobID)
+}
+
+// DownloadArtifact downloads raw artifact content
+func (c *Client) DownloadArtifact(ctx context.Context, downloadURL string) ([]byte, error) {
+	return c.get(ctx, downloadURL)
+}
+
+// getJSON performs an authenticated GET and decodes the JSON body into v.
+// Returns the response headers for pagination.
+func (c *Client) getJSON(ctx context.Context, endpoint string, v any) (http.Header, error) {
+	resp, err := c.do(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// get performs an authenticated GET and returns the raw body.
+func (c *Client) get(ctx context.Context, endpoint string) ([]byte, error) {
+	resp, err := c.do(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, nil
+}
+
+// do performs an authenticated GET. Non-200 responses are returned as errors.
+func (c *Client) do(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("GitLab API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	return resp, nil
+}
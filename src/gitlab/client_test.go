@@ -0,0 +1,126 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"destill-agent/src/provider"
+)
+
+func TestClient_ParsePipelineURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		wantErr      bool
+		wantProject  string
+		wantPipeline string
+	}{
+		{
+			name:         "project URL",
+			url:          "https://gitlab.com/group/project/-/pipelines/123",
+			wantProject:  "group/project",
+			wantPipeline: "123",
+		},
+		{
+			name:         "subgroup URL",
+			url:          "https://gitlab.com/group/sub/project/-/pipelines/456",
+			wantProject:  "group/sub/project",
+			wantPipeline: "456",
+		},
+		{
+			name:    "merge request URL",
+			url:     "https://gitlab.com/group/project/-/merge_requests/7",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, pipelineID, err := ParsePipelineURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePipelineURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if project != tt.wantProject {
+				t.Errorf("project = %v, want %v", project, tt.wantProject)
+			}
+			if pipelineID != tt.wantPipeline {
+				t.Errorf("pipelineID = %v, want %v", pipelineID, tt.wantPipeline)
+			}
+		})
+	}
+}
+
+func TestClient_GetPipelineJobs_Pagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Project paths are URL-encoded into a single path segment
+		if r.URL.EscapedPath() != "/projects/group%2Fproject/pipelines/1/jobs" {
+			t.Errorf("path = %v, want encoded project path", r.URL.EscapedPath())
+		}
+		if r.Header.Get("PRIVATE-TOKEN") != "test-token" {
+			t.Errorf("PRIVATE-TOKEN = %v, want test-token", r.Header.Get("PRIVATE-TOKEN"))
+		}
+
+		var jobs []Job
+		switch r.URL.Query().Get("page") {
+		case "1":
+			for i := 0; i < 100; i++ {
+				jobs = append(jobs, Job{ID: int64(i), Name: "job"})
+			}
+			w.Header().Set("X-Next-Page", "2")
+		case "2":
+			jobs = []Job{{ID: 100, Name: "last"}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jobs)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+
+	jobs, err := client.GetPipelineJobs(context.Background(), "group/project", "1")
+	if err != nil {
+		t.Fatalf("GetPipelineJobs() error = %v", err)
+	}
+	if len(jobs) != 101 {
+		t.Errorf("len(jobs) = %d, want 101", len(jobs))
+	}
+}
+
+func TestClient_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient("bad-token")
+	client.baseURL = server.URL
+
+	if _, err := client.GetPipeline(context.Background(), "group/project", "1"); err == nil {
+		t.Error("GetPipeline() expected error for 401, got nil")
+	}
+}
+
+func TestClient_GetJobTrace_Limit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("$ make test\nFAIL\tpkg\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+
+	trace, err := client.GetJobTrace(provider.WithMaxLogBytes(context.Background(), 11), "group/project", 1)
+	if err != nil {
+		t.Fatalf("GetJobTrace() error = %v", err)
+	}
+	if trace != "$ make test\n" {
+		t.Errorf("GetJobTrace() with an 11 byte limit = %q, want 12 bytes", trace)
+	}
+}
@@ -0,0 +1,205 @@
+package gitlab
+
+import (
+	"context"
+	"destill-agent/src/provider"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	// Register the GitLab CI provider factory
+	provider.RegisterProvider("gitlab", func(token string) provider.Provider {
+		return NewProvider(token)
+	})
+}
+
+// Provider implements provider.Provider for GitLab CI
+type Provider struct {
+	client *Client
+}
+
+// NewProvider creates a GitLab CI provider with API token
+func NewProvider(token string) *Provider {
+	return &Provider{
+		client: NewClient(token),
+	}
+}
+
+// Name returns "gitlab"
+func (p *Provider) Name() string {
+	return "gitlab"
+}
+
+// ParseURL delegates to provider.ParseURL
+func (p *Provider) ParseURL(url string) (*provider.BuildRef, error) {
+	return provider.ParseURL(url)
+}
+
+// FetchBuild retrieves pipeline metadata and jobs using the GitLab API
+func (p *Provider) FetchBuild(ctx context.Context, ref *provider.BuildRef) (*provider.Build, error) {
+	project := ref.Metadata["project"]
+	pipelineID := ref.BuildID
+
+	pipeline, err := p.client.GetPipeline(ctx, project, pipelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := p.client.GetPipelineJobs(ctx, project, pipelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	build := toBuild(pipeline)
+	build.Jobs = make([]provider.Job, 0, len(jobs))
+
+	for _, glJob := range jobs {
+		exitCode := 0
+		if glJob.Status == "failed" {
+			exitCode = 1
+		}
+
+		timestamp := glJob.CreatedAt
+		if glJob.StartedAt != nil {
+			timestamp = *glJob.StartedAt
+		}
+
+		build.Jobs = append(build.Jobs, provider.Job{
+			ID:        fmt.Sprintf("%s/%d", project, glJob.ID),
+			Name:      glJob.Name,
+			Type:      "script", // Trigger (bridge) jobs are not returned by the jobs endpoint
+			State:     mapGitLabStatus(glJob.Status),
+			ExitCode:  exitCode,
+			BuildID:   build.ID,
+			Timestamp: timestamp,
+			Duration:  provider.Elapsed(glJob.StartedAt, glJob.FinishedAt),
+		})
+	}
+
+	return build, nil
+}
+
+// FetchLatestFailedBuild finds the most recent failed pipeline on a branch
+func (p *Provider) FetchLatestFailedBuild(ctx context.Context, ref *provider.BuildRef, branch string) (*provider.Build, error) {
+	project := ref.Metadata["project"]
+
+	pipelines, err := p.client.ListPipelines(ctx, project, branch, "failed", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(pipelines) == 0 {
+		return nil, fmt.Errorf("%w: no failed pipelines for %s on branch %s",
+			provider.ErrBuildNotFound, project, branch)
+	}
+
+	return toBuild(&pipelines[0]), nil
+}
+
+// FetchJobLog retrieves the raw trace for a job.
+// jobID has the form "group/project/123"; the project path may contain slashes.
+func (p *Provider) FetchJobLog(ctx context.Context, jobID string) (string, error) {
+	project, id, err := splitJobID(jobID)
+	if err != nil {
+		return "", err
+	}
+	return p.client.GetJobTrace(ctx, project, id)
+}
+
+// FetchArtifacts returns the job's artifacts archive, if it has one
+func (p *Provider) FetchArtifacts(ctx context.Context, jobID string) ([]provider.Artifact, error) {
+	project, id, err := splitJobID(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := p.client.GetJob(ctx, project, id)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := []provider.Artifact{}
+	for _, a := range job.Artifacts {
+		if a.FileType != "archive" {
+			continue
+		}
+		artifacts = append(artifacts, provider.Artifact{
+			ID:          fmt.Sprintf("%d-%s", job.ID, a.FileType),
+			JobID:       jobID,
+			Path:        a.Filename,
+			DownloadURL: p.client.JobArtifactsURL(project, id),
+			FileSize:    a.Size,
+		})
+	}
+	return artifacts, nil
+}
+
+// DownloadArtifact downloads artifact content (the zip archive as-is)
+func (p *Provider) DownloadArtifact(ctx context.Context, artifact provider.Artifact) ([]byte, error) {
+	return p.client.DownloadArtifact(ctx, artifact.DownloadURL)
+}
+
+// toBuild converts a pipeline to a provider.Build without jobs
+func toBuild(pipeline *Pipeline) *provider.Build {
+	return &provider.Build{
+		ID:        strconv.FormatInt(pipeline.ID, 10),
+		Number:    strconv.FormatInt(pipeline.IID, 10),
+		URL:       pipeline.WebURL,
+		State:     mapGitLabStatus(pipeline.Status),
+		Branch:    pipeline.Ref,
+		Trigger:   mapGitLabSource(pipeline.Source),
+		Timestamp: pipeline.CreatedAt,
+	}
+}
+
+// splitJobID splits "group/project/123" into the project path and job ID
+func splitJobID(jobID string) (string, int64, error) {
+	idx := strings.LastIndex(jobID, "/")
+	if idx <= 0 {
+		return "", 0, fmt.Errorf("invalid job ID format: %s", jobID)
+	}
+
+	id, err := strconv.ParseInt(jobID[idx+1:], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid job ID number: %s", jobID[idx+1:])
+	}
+	return jobID[:idx], id, nil
+}
+
+// mapGitLabStatus maps GitLab pipeline/job status to Buildkite-like state
+func mapGitLabStatus(status string) string {
+	switch status {
+	case "success":
+		return "passed"
+	case "failed":
+		return "failed"
+	case "canceled", "canceling":
+		return "canceled"
+	case "created", "pending", "waiting_for_resource", "preparing", "scheduled":
+		return "queued"
+	default:
+		// running, skipped, manual
+		return status
+	}
+}
+
+// mapGitLabSource maps a pipeline source to a normalized trigger
+func mapGitLabSource(source string) string {
+	switch source {
+	case "schedule":
+		return provider.TriggerSchedule
+	case "merge_request_event", "external_pull_request_event":
+		return provider.TriggerPullRequest
+	case "push":
+		return provider.TriggerPush
+	case "web":
+		return provider.TriggerManual
+	case "api", "trigger", "chat":
+		return provider.TriggerAPI
+	case "pipeline", "parent_pipeline":
+		return provider.TriggerUpstream
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,239 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"destill-agent/src/provider"
+)
+
+func TestGitLabProvider_Name(t *testing.T) {
+	p := NewProvider("fake-token")
+	if p.Name() != "gitlab" {
+		t.Errorf("Name() = %v, want gitlab", p.Name())
+	}
+}
+
+func TestGitLabProvider_FetchBuild(t *testing.T) {
+	started := time.Date(2024, 1, 1, 12, 5, 0, 0, time.UTC)
+	finished := started.Add(90 * time.Second)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.EscapedPath() {
+		case "/projects/group%2Fproject/pipelines/555":
+			json.NewEncoder(w).Encode(Pipeline{
+				ID:        555,
+				IID:       42,
+				Status:    "failed",
+				WebURL:    "https://gitlab.com/group/project/-/pipelines/555",
+				CreatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			})
+		case "/projects/group%2Fproject/pipelines/555/jobs":
+			json.NewEncoder(w).Encode([]Job{
+				{ID: 1001, Name: "lint", Status: "success", StartedAt: &started, FinishedAt: &finished},
+				{ID: 1002, Name: "test", Status: "failed"},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+
+	ref := &provider.BuildRef{
+		Provider: "gitlab",
+		BuildID:  "555",
+		Metadata: map[string]string{"project": "group/project"},
+	}
+
+	build, err := p.FetchBuild(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("FetchBuild() error = %v", err)
+	}
+
+	if build.ID != "555" || build.Number != "42" || build.State != "failed" {
+		t.Errorf("Build = %+v, want ID 555, Number 42, State failed", build)
+	}
+	if len(build.Jobs) != 2 {
+		t.Fatalf("len(Build.Jobs) = %v, want 2", len(build.Jobs))
+	}
+
+	lint := build.Jobs[0]
+	if lint.ID != "group/project/1001" || lint.State != "passed" || lint.ExitCode != 0 {
+		t.Errorf("Job[0] = %+v, want group/project/1001 passed exit 0", lint)
+	}
+	if !lint.Timestamp.Equal(started) {
+		t.Errorf("Job[0].Timestamp = %v, want %v", lint.Timestamp, started)
+	}
+	if lint.Duration != 90*time.Second {
+		t.Errorf("Job[0].Duration = %v, want 1m30s", lint.Duration)
+	}
+
+	test := build.Jobs[1]
+	if test.State != "failed" || test.ExitCode != 1 || test.Duration != 0 {
+		t.Errorf("Job[1] = %+v, want failed exit 1 without duration", test)
+	}
+}
+
+func TestGitLabProvider_FetchLatestFailedBuild(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("ref"); got != "main" {
+			t.Errorf("ref = %v, want main", got)
+		}
+		if got := r.URL.Query().Get("status"); got != "failed" {
+			t.Errorf("status = %v, want failed", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]Pipeline{
+			{ID: 777, IID: 9, Status: "failed", WebURL: "https://gitlab.com/group/project/-/pipelines/777"},
+		})
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+
+	ref := &provider.BuildRef{Provider: "gitlab", Metadata: map[string]string{"project": "group/project"}}
+
+	build, err := p.FetchLatestFailedBuild(context.Background(), ref, "main")
+	if err != nil {
+		t.Fatalf("FetchLatestFailedBuild() error = %v", err)
+	}
+	if build.URL != "https://gitlab.com/group/project/-/pipelines/777" {
+		t.Errorf("Build.URL = %v, want pipeline 777", build.URL)
+	}
+}
+
+func TestGitLabProvider_FetchLatestFailedBuild_None(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+
+	ref := &provider.BuildRef{Provider: "gitlab", Metadata: map[string]string{"project": "group/project"}}
+
+	_, err := p.FetchLatestFailedBuild(context.Background(), ref, "main")
+	if !errors.Is(err, provider.ErrBuildNotFound) {
+		t.Errorf("FetchLatestFailedBuild() error = %v, want ErrBuildNotFound", err)
+	}
+}
+
+func TestGitLabProvider_FetchJobLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/projects/group%2Fsub%2Fproject/jobs/1002/trace" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("Running tests\nERROR: assertion failed\n"))
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+
+	logs, err := p.FetchJobLog(context.Background(), "group/sub/project/1002")
+	if err != nil {
+		t.Fatalf("FetchJobLog() error = %v", err)
+	}
+	if logs != "Running tests\nERROR: assertion failed\n" {
+		t.Errorf("FetchJobLog() = %q", logs)
+	}
+}
+
+func TestGitLabProvider_FetchArtifacts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Job{
+			ID: 1002,
+			Artifacts: []JobArtifact{
+				{FileType: "trace", Filename: "job.log", Size: 10},
+				{FileType: "archive", Filename: "artifacts.zip", Size: 2048},
+			},
+		})
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-token")
+	p.client.baseURL = server.URL
+
+	artifacts, err := p.FetchArtifacts(context.Background(), "group/project/1002")
+	if err != nil {
+		t.Fatalf("FetchArtifacts() error = %v", err)
+	}
+	if len(artifacts) != 1 || artifacts[0].Path != "artifacts.zip" || artifacts[0].FileSize != 2048 {
+		t.Errorf("FetchArtifacts() = %+v, want only the archive", artifacts)
+	}
+}
+
+func TestSplitJobID(t *testing.T) {
+	tests := []struct {
+		jobID       string
+		wantProject string
+		wantID      int64
+		wantErr     bool
+	}{
+		{"group/project/123", "group/project", 123, false},
+		{"group/sub/project/456", "group/sub/project", 456, false},
+		{"123", "", 0, true},
+		{"group/project/abc", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		project, id, err := splitJobID(tt.jobID)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitJobID(%q) error = %v, wantErr %v", tt.jobID, err, tt.wantErr)
+			continue
+		}
+		if project != tt.wantProject || id != tt.wantID {
+			t.Errorf("splitJobID(%q) = %q, %d, want %q, %d", tt.jobID, project, id, tt.wantProject, tt.wantID)
+		}
+	}
+}
+
+func TestMapGitLabStatus(t *testing.T) {
+	tests := map[string]string{
+		"success":  "passed",
+		"failed":   "failed",
+		"canceled": "canceled",
+		"pending":  "queued",
+		"running":  "running",
+		"skipped":  "skipped",
+		"manual":   "manual",
+	}
+
+	for status, want := range tests {
+		if got := mapGitLabStatus(status); got != want {
+			t.Errorf("mapGitLabStatus(%v) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestMapGitLabSource(t *testing.T) {
+	tests := map[string]string{
+		"schedule":            "schedule",
+		"merge_request_event": "pull_request",
+		"push":                "push",
+		"web":                 "manual",
+		"parent_pipeline":     "upstream",
+		"ondemand_dast_scan":  "",
+	}
+
+	for source, want := range tests {
+		if got := mapGitLabSource(source); got != want {
+			t.Errorf("mapGitLabSource(%v) = %q, want %q", source, got, want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package gitlab
+
+import "time"
+
+// Pipeline represents a GitLab CI pipeline
+type Pipeline struct {
+	ID        int64     `json:"id"`
+	IID       int64     `json:"iid"`
+	ProjectID int64     `json:"project_id"`
+	Status    string    `json:"status"`
+	Ref       string    `json:"ref"`
+	SHA       string    `json:"sha"`
+	Source    string    `json:"source"` // push, merge_request_event, schedule, web, api, trigger, ...
+	WebURL    string    `json:"web_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Job represents a job within a pipeline
+type Job struct {
+	ID           int64         `json:"id"`
+	Name         string        `json:"name"`
+	Stage        string        `json:"stage"`
+	Status       string        `json:"status"`
+	AllowFailure bool          `json:"allow_failure"`
+	WebURL       string        `json:"web_url"`
+	CreatedAt    time.Time     `json:"created_at"`
+	StartedAt    *time.Time    `json:"started_at"`
+	FinishedAt   *time.Time    `json:"finished_at"`
+	Artifacts    []JobArtifact `json:"artifacts"`
+}
+
+// JobArtifact describes a file attached to a job. The "archive" file type is
+// the user-declared artifacts zip; others are reports and traces.
+type JobArtifact struct {
+	FileType string `json:"file_type"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+}
@@ -0,0 +1,554 @@
+// gRPC transport for destill: an alternative to Redpanda where running a
+// Kafka-compatible broker isn't feasible. Regenerate with `make proto`.
+//
+// Payloads are the JSON messages of package contracts, carried as bytes, so
+// both transports share one versioned schema.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: destill.proto
+
+package destillpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SubmitAnalysisRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// contracts.AnalysisRequest as JSON. A missing request_id is generated.
+	Request       []byte `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitAnalysisRequest) Reset() {
+	*x = SubmitAnalysisRequest{}
+	mi := &file_destill_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitAnalysisRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitAnalysisRequest) ProtoMessage() {}
+
+func (x *SubmitAnalysisRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_destill_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitAnalysisRequest.ProtoReflect.Descriptor instead.
+func (*SubmitAnalysisRequest) Descriptor() ([]byte, []int) {
+	return file_destill_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubmitAnalysisRequest) GetRequest() []byte {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+type SubmitAnalysisResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitAnalysisResponse) Reset() {
+	*x = SubmitAnalysisResponse{}
+	mi := &file_destill_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitAnalysisResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitAnalysisResponse) ProtoMessage() {}
+
+func (x *SubmitAnalysisResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_destill_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitAnalysisResponse.ProtoReflect.Descriptor instead.
+func (*SubmitAnalysisResponse) Descriptor() ([]byte, []int) {
+	return file_destill_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubmitAnalysisResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type StreamFindingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamFindingsRequest) Reset() {
+	*x = StreamFindingsRequest{}
+	mi := &file_destill_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamFindingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamFindingsRequest) ProtoMessage() {}
+
+func (x *StreamFindingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_destill_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamFindingsRequest.ProtoReflect.Descriptor instead.
+func (*StreamFindingsRequest) Descriptor() ([]byte, []int) {
+	return file_destill_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamFindingsRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type Finding struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// contracts.TriageCard as JSON
+	Card          []byte `protobuf:"bytes,1,opt,name=card,proto3" json:"card,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Finding) Reset() {
+	*x = Finding{}
+	mi := &file_destill_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Finding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Finding) ProtoMessage() {}
+
+func (x *Finding) ProtoReflect() protoreflect.Message {
+	mi := &file_destill_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Finding.ProtoReflect.Descriptor instead.
+func (*Finding) Descriptor() ([]byte, []int) {
+	return file_destill_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Finding) GetCard() []byte {
+	if x != nil {
+		return x.Card
+	}
+	return nil
+}
+
+type Message struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Unix milliseconds, set by the server
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_destill_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_destill_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_destill_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Message) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *Message) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Message) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Message) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type PublishResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishResponse) Reset() {
+	*x = PublishResponse{}
+	mi := &file_destill_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishResponse) ProtoMessage() {}
+
+func (x *PublishResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_destill_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishResponse.ProtoReflect.Descriptor instead.
+func (*PublishResponse) Descriptor() ([]byte, []int) {
+	return file_destill_proto_rawDescGZIP(), []int{5}
+}
+
+type SubscribeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// A topic name, or a pattern when pattern is set (see broker.MatchTopic)
+	Topic         string `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	GroupId       string `protobuf:"bytes,2,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	Pattern       bool   `protobuf:"varint,3,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_destill_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_destill_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_destill_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SubscribeRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *SubscribeRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *SubscribeRequest) GetPattern() bool {
+	if x != nil {
+		return x.Pattern
+	}
+	return false
+}
+
+type ListTopicsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTopicsRequest) Reset() {
+	*x = ListTopicsRequest{}
+	mi := &file_destill_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTopicsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTopicsRequest) ProtoMessage() {}
+
+func (x *ListTopicsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_destill_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTopicsRequest.ProtoReflect.Descriptor instead.
+func (*ListTopicsRequest) Descriptor() ([]byte, []int) {
+	return file_destill_proto_rawDescGZIP(), []int{7}
+}
+
+type ListTopicsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topics        []string               `protobuf:"bytes,1,rep,name=topics,proto3" json:"topics,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTopicsResponse) Reset() {
+	*x = ListTopicsResponse{}
+	mi := &file_destill_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTopicsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTopicsResponse) ProtoMessage() {}
+
+func (x *ListTopicsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_destill_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTopicsResponse.ProtoReflect.Descriptor instead.
+func (*ListTopicsResponse) Descriptor() ([]byte, []int) {
+	return file_destill_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListTopicsResponse) GetTopics() []string {
+	if x != nil {
+		return x.Topics
+	}
+	return nil
+}
+
+var File_destill_proto protoreflect.FileDescriptor
+
+const file_destill_proto_rawDesc = "" +
+	"\n" +
+	"\rdestill.proto\x12\n" +
+	"destill.v1\"1\n" +
+	"\x15SubmitAnalysisRequest\x12\x18\n" +
+	"\arequest\x18\x01 \x01(\fR\arequest\"7\n" +
+	"\x16SubmitAnalysisResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\"6\n" +
+	"\x15StreamFindingsRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\"\x1d\n" +
+	"\aFinding\x12\x12\n" +
+	"\x04card\x18\x01 \x01(\fR\x04card\"e\n" +
+	"\aMessage\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\"\x11\n" +
+	"\x0fPublishResponse\"]\n" +
+	"\x10SubscribeRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x19\n" +
+	"\bgroup_id\x18\x02 \x01(\tR\agroupId\x12\x18\n" +
+	"\apattern\x18\x03 \x01(\bR\apattern\"\x13\n" +
+	"\x11ListTopicsRequest\",\n" +
+	"\x12ListTopicsResponse\x12\x16\n" +
+	"\x06topics\x18\x01 \x03(\tR\x06topics2\xfa\x02\n" +
+	"\aDestill\x12W\n" +
+	"\x0eSubmitAnalysis\x12!.destill.v1.SubmitAnalysisRequest\x1a\".destill.v1.SubmitAnalysisResponse\x12J\n" +
+	"\x0eStreamFindings\x12!.destill.v1.StreamFindingsRequest\x1a\x13.destill.v1.Finding0\x01\x12;\n" +
+	"\aPublish\x12\x13.destill.v1.Message\x1a\x1b.destill.v1.PublishResponse\x12@\n" +
+	"\tSubscribe\x12\x1c.destill.v1.SubscribeRequest\x1a\x13.destill.v1.Message0\x01\x12K\n" +
+	"\n" +
+	"ListTopics\x12\x1d.destill.v1.ListTopicsRequest\x1a\x1e.destill.v1.ListTopicsResponseB%Z#destill-agent/src/grpcapi/destillpbb\x06proto3"
+
+var (
+	file_destill_proto_rawDescOnce sync.Once
+	file_destill_proto_rawDescData []byte
+)
+
+func file_destill_proto_rawDescGZIP() []byte {
+	file_destill_proto_rawDescOnce.Do(func() {
+		file_destill_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_destill_proto_rawDesc), len(file_destill_proto_rawDesc)))
+	})
+	return file_destill_proto_rawDescData
+}
+
+var file_destill_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_destill_proto_goTypes = []any{
+	(*SubmitAnalysisRequest)(nil),  // 0: destill.v1.SubmitAnalysisRequest
+	(*SubmitAnalysisResponse)(nil), // 1: destill.v1.SubmitAnalysisResponse
+	(*StreamFindingsRequest)(nil),  // 2: destill.v1.StreamFindingsRequest
+	(*Finding)(nil),                // 3: destill.v1.Finding
+	(*Message)(nil),                // 4: destill.v1.Message
+	(*PublishResponse)(nil),        // 5: destill.v1.PublishResponse
+	(*SubscribeRequest)(nil),       // 6: destill.v1.SubscribeRequest
+	(*ListTopicsRequest)(nil),      // 7: destill.v1.ListTopicsRequest
+	(*ListTopicsResponse)(nil),     // 8: destill.v1.ListTopicsResponse
+}
+var file_destill_proto_depIdxs = []int32{
+	0, // 0: destill.v1.Destill.SubmitAnalysis:input_type -> destill.v1.SubmitAnalysisRequest
+	2, // 1: destill.v1.Destill.StreamFindings:input_type -> destill.v1.StreamFindingsRequest
+	4, // 2: destill.v1.Destill.Publish:input_type -> destill.v1.Message
+	6, // 3: destill.v1.Destill.Subscribe:input_type -> destill.v1.SubscribeRequest
+	7, // 4: destill.v1.Destill.ListTopics:input_type -> destill.v1.ListTopicsRequest
+	1, // 5: destill.v1.Destill.SubmitAnalysis:output_type -> destill.v1.SubmitAnalysisResponse
+	3, // 6: destill.v1.Destill.StreamFindings:output_type -> destill.v1.Finding
+	5, // 7: destill.v1.Destill.Publish:output_type -> destill.v1.PublishResponse
+	4, // 8: destill.v1.Destill.Subscribe:output_type -> destill.v1.Message
+	8, // 9: destill.v1.Destill.ListTopics:output_type -> destill.v1.ListTopicsResponse
+	5, // [5:10] is the sub-list for method output_type
+	0, // [0:5] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_destill_proto_init() }
+func file_destill_proto_init() {
+	if File_destill_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_destill_proto_rawDesc), len(file_destill_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_destill_proto_goTypes,
+		DependencyIndexes: file_destill_proto_depIdxs,
+		MessageInfos:      file_destill_proto_msgTypes,
+	}.Build()
+	File_destill_proto = out.File
+	file_destill_proto_goTypes = nil
+	file_destill_proto_depIdxs = nil
+}
@@ -0,0 +1,298 @@
+// gRPC transport for destill: an alternative to Redpanda where running a
+// Kafka-compatible broker isn't feasible. Regenerate with `make proto`.
+//
+// Payloads are the JSON messages of package contracts, carried as bytes, so
+// both transports share one versioned schema.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: destill.proto
+
+package destillpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Destill_SubmitAnalysis_FullMethodName = "/destill.v1.Destill/SubmitAnalysis"
+	Destill_StreamFindings_FullMethodName = "/destill.v1.Destill/StreamFindings"
+	Destill_Publish_FullMethodName        = "/destill.v1.Destill/Publish"
+	Destill_Subscribe_FullMethodName      = "/destill.v1.Destill/Subscribe"
+	Destill_ListTopics_FullMethodName     = "/destill.v1.Destill/ListTopics"
+)
+
+// DestillClient is the client API for Destill service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DestillClient interface {
+	// SubmitAnalysis queues a build for analysis, like publishing to
+	// destill.requests.
+	SubmitAnalysis(ctx context.Context, in *SubmitAnalysisRequest, opts ...grpc.CallOption) (*SubmitAnalysisResponse, error)
+	// StreamFindings streams the findings of a request as the analyze agents
+	// produce them, until the client cancels.
+	StreamFindings(ctx context.Context, in *StreamFindingsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Finding], error)
+	// Publish, Subscribe, and ListTopics are the broker transport the agents
+	// use in grpc mode.
+	Publish(ctx context.Context, in *Message, opts ...grpc.CallOption) (*PublishResponse, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Message], error)
+	ListTopics(ctx context.Context, in *ListTopicsRequest, opts ...grpc.CallOption) (*ListTopicsResponse, error)
+}
+
+type destillClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDestillClient(cc grpc.ClientConnInterface) DestillClient {
+	return &destillClient{cc}
+}
+
+func (c *destillClient) SubmitAnalysis(ctx context.Context, in *SubmitAnalysisRequest, opts ...grpc.CallOption) (*SubmitAnalysisResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitAnalysisResponse)
+	err := c.cc.Invoke(ctx, Destill_SubmitAnalysis_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *destillClient) StreamFindings(ctx context.Context, in *StreamFindingsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Finding], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Destill_ServiceDesc.Streams[0], Destill_StreamFindings_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamFindingsRequest, Finding]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Destill_StreamFindingsClient = grpc.ServerStreamingClient[Finding]
+
+func (c *destillClient) Publish(ctx context.Context, in *Message, opts ...grpc.CallOption) (*PublishResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublishResponse)
+	err := c.cc.Invoke(ctx, Destill_Publish_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *destillClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Message], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Destill_ServiceDesc.Streams[1], Destill_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, Message]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Destill_SubscribeClient = grpc.ServerStreamingClient[Message]
+
+func (c *destillClient) ListTopics(ctx context.Context, in *ListTopicsRequest, opts ...grpc.CallOption) (*ListTopicsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTopicsResponse)
+	err := c.cc.Invoke(ctx, Destill_ListTopics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DestillServer is the server API for Destill service.
+// All implementations must embed UnimplementedDestillServer
+// for forward compatibility.
+type DestillServer interface {
+	// SubmitAnalysis queues a build for analysis, like publishing to
+	// destill.requests.
+	SubmitAnalysis(context.Context, *SubmitAnalysisRequest) (*SubmitAnalysisResponse, error)
+	// StreamFindings streams the findings of a request as the analyze agents
+	// produce them, until the client cancels.
+	StreamFindings(*StreamFindingsRequest, grpc.ServerStreamingServer[Finding]) error
+	// Publish, Subscribe, and ListTopics are the broker transport the agents
+	// use in grpc mode.
+	Publish(context.Context, *Message) (*PublishResponse, error)
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[Message]) error
+	ListTopics(context.Context, *ListTopicsRequest) (*ListTopicsResponse, error)
+	mustEmbedUnimplementedDestillServer()
+}
+
+// UnimplementedDestillServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDestillServer struct{}
+
+func (UnimplementedDestillServer) SubmitAnalysis(context.Context, *SubmitAnalysisRequest) (*SubmitAnalysisResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SubmitAnalysis not implemented")
+}
+func (UnimplementedDestillServer) StreamFindings(*StreamFindingsRequest, grpc.ServerStreamingServer[Finding]) error {
+	return status.Error(codes.Unimplemented, "method StreamFindings not implemented")
+}
+func (UnimplementedDestillServer) Publish(context.Context, *Message) (*PublishResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Publish not implemented")
+}
+func (UnimplementedDestillServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[Message]) error {
+	return status.Error(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedDestillServer) ListTopics(context.Context, *ListTopicsRequest) (*ListTopicsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListTopics not implemented")
+}
+func (UnimplementedDestillServer) mustEmbedUnimplementedDestillServer() {}
+func (UnimplementedDestillServer) testEmbeddedByValue()                 {}
+
+// UnsafeDestillServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DestillServer will
+// result in compilation errors.
+type UnsafeDestillServer interface {
+	mustEmbedUnimplementedDestillServer()
+}
+
+func RegisterDestillServer(s grpc.ServiceRegistrar, srv DestillServer) {
+	// If the following call panics, it indicates UnimplementedDestillServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Destill_ServiceDesc, srv)
+}
+
+func _Destill_SubmitAnalysis_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitAnalysisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DestillServer).SubmitAnalysis(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Destill_SubmitAnalysis_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DestillServer).SubmitAnalysis(ctx, req.(*SubmitAnalysisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Destill_StreamFindings_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamFindingsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DestillServer).StreamFindings(m, &grpc.GenericServerStream[StreamFindingsRequest, Finding]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Destill_StreamFindingsServer = grpc.ServerStreamingServer[Finding]
+
+func _Destill_Publish_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Message)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DestillServer).Publish(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Destill_Publish_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DestillServer).Publish(ctx, req.(*Message))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Destill_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DestillServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, Message]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Destill_SubscribeServer = grpc.ServerStreamingServer[Message]
+
+func _Destill_ListTopics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTopicsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DestillServer).ListTopics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Destill_ListTopics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DestillServer).ListTopics(ctx, req.(*ListTopicsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Destill_ServiceDesc is the grpc.ServiceDesc for Destill service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Destill_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "destill.v1.Destill",
+	HandlerType: (*DestillServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitAnalysis",
+			Handler:    _Destill_SubmitAnalysis_Handler,
+		},
+		{
+			MethodName: "Publish",
+			Handler:    _Destill_Publish_Handler,
+		},
+		{
+			MethodName: "ListTopics",
+			Handler:    _Destill_ListTopics_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamFindings",
+			Handler:       _Destill_StreamFindings_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Destill_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "destill.proto",
+}
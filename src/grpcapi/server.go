@@ -0,0 +1,311 @@
+// Package grpcapi implements destill's gRPC API (destill.proto): submitting
+// analysis requests, streaming findings, and the broker transport the agents
+// use in grpc mode. The server relays messages through a broker.Broker,
+// usually an in-memory one, taking Redpanda's place in a deployment.
+package grpcapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/grpcapi/destillpb"
+)
+
+// Server implements the Destill gRPC service on top of a broker.
+type Server struct {
+	destillpb.UnimplementedDestillServer
+	broker broker.Broker
+
+	mu     sync.Mutex
+	groups map[string]*group // subscription + group ID -> consumer group
+}
+
+// group is a consumer group: one broker subscription whose messages are
+// handed to its members in turn, so agents sharing a group ID split the
+// work as they do on Redpanda.
+type group struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	members []chan broker.Message
+	next    int
+}
+
+// NewServer creates a server relaying through brk.
+func NewServer(brk broker.Broker) *Server {
+	return &Server{
+		broker: brk,
+		groups: make(map[string]*group),
+	}
+}
+
+// Serve listens on addr and serves the API for brk until ctx is done.
+func Serve(ctx context.Context, addr string, brk broker.Broker) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	gs := grpc.NewServer()
+	destillpb.RegisterDestillServer(gs, NewServer(brk))
+
+	stop := context.AfterFunc(ctx, gs.Stop)
+	defer stop()
+	if err := gs.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("gRPC server failed: %w", err)
+	}
+	return nil
+}
+
+// SubmitAnalysis publishes an analysis request to destill.requests.
+func (s *Server) SubmitAnalysis(ctx context.Context, req *destillpb.SubmitAnalysisRequest) (*destillpb.SubmitAnalysisResponse, error) {
+	var request contracts.AnalysisRequest
+	if err := json.Unmarshal(req.Request, &request); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid analysis request: %v", err)
+	}
+	if request.BuildURL == "" {
+		return nil, status.Error(codes.InvalidArgument, "build_url is required")
+	}
+	if err := request.Options.Validate(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid analysis options: %v", err)
+	}
+	if request.RequestID == "" {
+		request.RequestID = newRequestID()
+	}
+	if request.Version == 0 {
+		request.Version = contracts.AnalysisRequestVersion
+	}
+	if request.Timestamp == "" {
+		request.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to marshal request: %v", err)
+	}
+	if err := s.broker.Publish(ctx, contracts.TopicRequests, request.RequestID, data); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to publish request: %v", err)
+	}
+	return &destillpb.SubmitAnalysisResponse{RequestId: request.RequestID}, nil
+}
+
+// StreamFindings streams the findings of one request until the client
+// cancels. Findings published before the call are not replayed.
+func (s *Server) StreamFindings(req *destillpb.StreamFindingsRequest, stream grpc.ServerStreamingServer[destillpb.Finding]) error {
+	if req.RequestId == "" {
+		return status.Error(codes.InvalidArgument, "request_id is required")
+	}
+	ctx := stream.Context()
+	ch, err := s.broker.Subscribe(ctx, contracts.TopicAnalysisFindings, "")
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to subscribe: %v", err)
+	}
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return status.Error(codes.Unavailable, "broker closed")
+			}
+			var card struct {
+				RequestID string `json:"request_id"`
+			}
+			if err := json.Unmarshal(msg.Value, &card); err != nil || card.RequestID != req.RequestId {
+				continue
+			}
+			if err := stream.Send(&destillpb.Finding{Card: msg.Value}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Publish publishes a message for an agent.
+func (s *Server) Publish(ctx context.Context, msg *destillpb.Message) (*destillpb.PublishResponse, error) {
+	if msg.Topic == "" {
+		return nil, status.Error(codes.InvalidArgument, "topic is required")
+	}
+	if err := s.broker.Publish(ctx, msg.Topic, msg.Key, msg.Value); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to publish: %v", err)
+	}
+	return &destillpb.PublishResponse{}, nil
+}
+
+// Subscribe streams messages to an agent. The response header is sent once
+// the subscription is in place, so clients can wait for it before
+// publishing.
+func (s *Server) Subscribe(req *destillpb.SubscribeRequest, stream grpc.ServerStreamingServer[destillpb.Message]) error {
+	if req.Topic == "" {
+		return status.Error(codes.InvalidArgument, "topic is required")
+	}
+	ctx := stream.Context()
+	ch, leave, err := s.join(ctx, req)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to subscribe: %v", err)
+	}
+	defer leave()
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return status.Error(codes.Unavailable, "broker closed")
+			}
+			err := stream.Send(&destillpb.Message{
+				Topic:     msg.Topic,
+				Key:       msg.Key,
+				Value:     msg.Value,
+				Timestamp: msg.Timestamp,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ListTopics lists the broker's topics.
+func (s *Server) ListTopics(ctx context.Context, _ *destillpb.ListTopicsRequest) (*destillpb.ListTopicsResponse, error) {
+	topics, err := s.broker.Topics(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to list topics: %v", err)
+	}
+	return &destillpb.ListTopicsResponse{Topics: topics}, nil
+}
+
+// join adds a subscriber to the consumer group of req, creating it if
+// needed, and returns its channel and a function to leave the group.
+// Without a group ID the subscriber gets every message.
+func (s *Server) join(ctx context.Context, req *destillpb.SubscribeRequest) (<-chan broker.Message, func(), error) {
+	if req.GroupId == "" {
+		ch, err := s.subscribe(ctx, req)
+		return ch, func() {}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := fmt.Sprintf("%t/%s/%s", req.Pattern, req.Topic, req.GroupId)
+	g, ok := s.groups[key]
+	if !ok {
+		groupCtx, cancel := context.WithCancel(context.Background())
+		ch, err := s.subscribe(groupCtx, req)
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		g = &group{ctx: groupCtx, cancel: cancel}
+		s.groups[key] = g
+		go s.dispatch(key, g, ch)
+	}
+
+	member := make(chan broker.Message, 100)
+	g.members = append(g.members, member)
+	return member, func() { s.leave(key, g, member) }, nil
+}
+
+// subscribe subscribes to the topic or pattern of req.
+func (s *Server) subscribe(ctx context.Context, req *destillpb.SubscribeRequest) (<-chan broker.Message, error) {
+	if req.Pattern {
+		return s.broker.SubscribePattern(ctx, req.Topic, req.GroupId)
+	}
+	return s.broker.Subscribe(ctx, req.Topic, req.GroupId)
+}
+
+// leave removes a member from its group, ending the group's subscription
+// when it was the last one.
+func (s *Server) leave(key string, g *group, member chan broker.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, m := range g.members {
+		if m == member {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			break
+		}
+	}
+	if len(g.members) == 0 {
+		g.cancel()
+		if s.groups[key] == g {
+			delete(s.groups, key)
+		}
+	}
+}
+
+// dispatch hands each message of a group's subscription to its members in
+// turn, skipping members whose buffer is full. A message no member has room
+// for is dropped, as the in-memory broker does.
+func (s *Server) dispatch(key string, g *group, ch <-chan broker.Message) {
+	for {
+		select {
+		case <-g.ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				s.closeGroup(key, g)
+				return
+			}
+			s.deliver(g, msg)
+		}
+	}
+}
+
+// deliver sends msg to the next member of g with room for it.
+func (s *Server) deliver(g *group, msg broker.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range g.members {
+		index := (g.next + i) % len(g.members)
+		select {
+		case g.members[index] <- msg:
+			g.next = (index + 1) % len(g.members)
+			return
+		default:
+		}
+	}
+}
+
+// closeGroup ends a group whose subscription closed, closing its members.
+func (s *Server) closeGroup(key string, g *group) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, member := range g.members {
+		close(member)
+	}
+	g.members = nil
+	if s.groups[key] == g {
+		delete(s.groups, key)
+	}
+}
+
+// newRequestID generates a request ID in the format the CLI uses.
+func newRequestID() string {
+	randomBytes := make([]byte, 4)
+	rand.Read(randomBytes)
+	return fmt.Sprintf("req-%s-%s", time.Now().UTC().Format("20060102T150405"), hex.EncodeToString(randomBytes))
+}
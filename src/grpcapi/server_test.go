@@ -0,0 +1,206 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/grpcapi/destillpb"
+)
+
+// startServer serves the API for a fresh in-memory hub on a local port.
+func startServer(t *testing.T) (*broker.InMemoryBroker, string) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	hub := broker.NewInMemoryBroker()
+	gs := grpc.NewServer()
+	destillpb.RegisterDestillServer(gs, NewServer(hub))
+	go gs.Serve(lis)
+	t.Cleanup(func() {
+		gs.Stop()
+		hub.Close()
+	})
+	return hub, lis.Addr().String()
+}
+
+func newClient(t *testing.T, addr string) destillpb.DestillClient {
+	t.Helper()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return destillpb.NewDestillClient(conn)
+}
+
+func TestSubmitAndStreamFindings(t *testing.T) {
+	hub, addr := startServer(t)
+	client := newClient(t, addr)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	requests, err := hub.Subscribe(ctx, contracts.TopicRequests, "destill-ingest")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	data, _ := json.Marshal(contracts.AnalysisRequest{BuildURL: "https://buildkite.com/org/pipeline/builds/1"})
+	resp, err := client.SubmitAnalysis(ctx, &destillpb.SubmitAnalysisRequest{Request: data})
+	if err != nil {
+		t.Fatalf("SubmitAnalysis() error = %v", err)
+	}
+	if resp.RequestId == "" {
+		t.Fatal("SubmitAnalysis() returned no request ID")
+	}
+
+	var published contracts.AnalysisRequest
+	select {
+	case msg := <-requests:
+		if err := json.Unmarshal(msg.Value, &published); err != nil {
+			t.Fatalf("published request: %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("request was not published")
+	}
+	if published.RequestID != resp.RequestId || published.Version != contracts.AnalysisRequestVersion || published.Timestamp == "" {
+		t.Errorf("published request = %+v, want ID %s with version and timestamp", published, resp.RequestId)
+	}
+
+	stream, err := client.StreamFindings(ctx, &destillpb.StreamFindingsRequest{RequestId: resp.RequestId})
+	if err != nil {
+		t.Fatalf("StreamFindings() error = %v", err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("StreamFindings() header: %v", err)
+	}
+
+	for _, card := range []contracts.TriageCard{
+		{ID: "other", RequestID: "req-other"},
+		{ID: "mine", RequestID: resp.RequestId},
+	} {
+		data, _ := json.Marshal(card)
+		if err := hub.Publish(ctx, contracts.TopicAnalysisFindings, card.RequestID, data); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	finding, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	var card contracts.TriageCard
+	if err := json.Unmarshal(finding.Card, &card); err != nil {
+		t.Fatalf("finding card: %v", err)
+	}
+	if card.ID != "mine" {
+		t.Errorf("streamed finding %q, want only the request's finding", card.ID)
+	}
+}
+
+func TestSubmitAnalysisInvalid(t *testing.T) {
+	_, addr := startServer(t)
+	client := newClient(t, addr)
+
+	tests := []struct {
+		name    string
+		request string
+	}{
+		{name: "malformed", request: `{`},
+		{name: "missing build URL", request: `{"request_id": "req-1"}`},
+		{name: "invalid options", request: `{"build_url": "https://buildkite.com/org/p/builds/1", "options": {"min_confidence": 2}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.SubmitAnalysis(context.Background(), &destillpb.SubmitAnalysisRequest{Request: []byte(tt.request)})
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("SubmitAnalysis() error = %v, want InvalidArgument", err)
+			}
+		})
+	}
+}
+
+func TestGRPCBrokerConsumerGroups(t *testing.T) {
+	_, addr := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	newBroker := func() *broker.GRPCBroker {
+		brk, err := broker.NewGRPCBroker(addr)
+		if err != nil {
+			t.Fatalf("NewGRPCBroker() error = %v", err)
+		}
+		t.Cleanup(func() { brk.Close() })
+		return brk
+	}
+
+	// Two agents in one group split the messages; a subscriber without a
+	// group sees them all
+	var members []<-chan broker.Message
+	for range 2 {
+		ch, err := newBroker().Subscribe(ctx, contracts.TopicLogsRaw, "destill-analyze")
+		if err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+		members = append(members, ch)
+	}
+	all, err := newBroker().Subscribe(ctx, contracts.TopicLogsRaw, "")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	publisher := newBroker()
+	const messages = 4
+	for i := range messages {
+		if err := publisher.Publish(ctx, contracts.TopicLogsRaw, "key", []byte{byte(i)}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	receive := func(ch <-chan broker.Message, n int) {
+		t.Helper()
+		for range n {
+			select {
+			case <-ch:
+			case <-ctx.Done():
+				t.Fatalf("timed out waiting for messages")
+			}
+		}
+	}
+	receive(all, messages)
+	for _, ch := range members {
+		receive(ch, messages/2)
+	}
+	for i, ch := range members {
+		select {
+		case msg := <-ch:
+			t.Errorf("member %d got extra message %v", i, msg.Value)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	topics, err := publisher.Topics(ctx)
+	if err != nil {
+		t.Fatalf("Topics() error = %v", err)
+	}
+	if len(topics) != 1 || topics[0] != contracts.TopicLogsRaw {
+		t.Errorf("Topics() = %v, want [%s]", topics, contracts.TopicLogsRaw)
+	}
+	if stats := publisher.Stats()[contracts.TopicLogsRaw]; stats.Published != messages {
+		t.Errorf("Stats().Published = %d, want %d", stats.Published, messages)
+	}
+}
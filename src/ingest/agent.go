@@ -5,33 +5,101 @@ package ingest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"destill-agent/src/broker"
 	_ "destill-agent/src/buildkite" // Import for provider registration
+	_ "destill-agent/src/circleci"  // Import for provider registration
 	"destill-agent/src/contracts"
 	_ "destill-agent/src/githubactions" // Import for provider registration
+	_ "destill-agent/src/gitlab"        // Import for provider registration
+	_ "destill-agent/src/jenkins"       // Import for provider registration
+	_ "destill-agent/src/localdir"      // Import for provider registration
 	"destill-agent/src/logger"
 	"destill-agent/src/provider"
 )
 
 // Agent consumes analysis requests and publishes log chunks.
 type Agent struct {
-	broker broker.Broker
-	logger logger.Logger
+	broker  broker.Broker
+	logger  logger.Logger
+	opts    Options
+	limits  Limits
+	onError func(requestID string, err error)
+
+	drain     chan struct{} // Closed by Drain
+	drainOnce sync.Once
 }
 
-// NewAgent creates a new ingest agent.
+// Options controls how the agent schedules requests.
+type Options struct {
+	// Workers is the number of requests processed concurrently (default 1).
+	Workers int
+
+	// PerPipeline caps the requests of one pipeline in flight at once, so a
+	// pipeline can't occupy every worker (0 = no limit).
+	PerPipeline int
+
+	// MaxPending is the number of received requests buffered for scheduling
+	// before the agent stops reading from the broker (default 1000).
+	MaxPending int
+}
+
+// NewAgent creates a new ingest agent with default options.
 func NewAgent(brk broker.Broker, log logger.Logger) *Agent {
+	return NewAgentWithOptions(brk, log, Options{})
+}
+
+// NewAgentWithOptions creates a new ingest agent.
+func NewAgentWithOptions(brk broker.Broker, log logger.Logger, opts Options) *Agent {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = 1000
+	}
 	return &Agent{
 		broker: brk,
 		logger: log,
+		opts:   opts,
+		limits: limits,
+		drain:  make(chan struct{}),
 	}
 }
 
+// SetLimits sets how much of each build is ingested (default
+// DESTILL_MAX_LOG_MB and DESTILL_MAX_CHUNKS). Call before Run.
+func (a *Agent) SetLimits(l Limits) {
+	a.limits = l
+}
+
+// SetErrorHandler sets a function called with each request that fails, such
+// as one whose build can't be fetched, in addition to logging it. requestID
+// is the message key, empty if the request had none. Call before Run.
+func (a *Agent) SetErrorHandler(handle func(requestID string, err error)) {
+	a.onError = handle
+}
+
+// Drain stops the agent reading new requests, for a graceful shutdown: Run
+// returns nil once the requests it has already received, in flight or
+// queued, are processed and their chunks published. Requests are
+// acknowledged when they're received, so the queued ones would otherwise
+// be lost. Cancel Run's context to stop without waiting.
+func (a *Agent) Drain() {
+	a.drainOnce.Do(func() { close(a.drain) })
+}
+
 // Run starts the agent's main loop.
-// It subscribes to destill.requests and processes incoming build analysis requests.
+// It subscribes to destill.requests and processes incoming build analysis
+// requests, and to destill.control for requests to prioritize.
 func (a *Agent) Run(ctx context.Context) error {
 	a.logger.Info("[IngestAgent] Starting...")
 
@@ -41,30 +109,123 @@ func (a *Agent) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicRequests, err)
 	}
 
-	return a.RunWithChannel(ctx, msgChan)
+	// Every agent holds its own queue, so each gets every control message
+	hostname, _ := os.Hostname()
+	controlGroup := fmt.Sprintf("destill-ingest-control-%s-%d", hostname, os.Getpid())
+	controlChan, err := a.broker.Subscribe(ctx, contracts.TopicControl, controlGroup)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicControl, err)
+	}
+
+	return a.RunWithChannels(ctx, msgChan, controlChan)
 }
 
 // RunWithChannel runs the agent's processing loop using a pre-subscribed channel.
 // This allows the caller to control subscription timing to avoid race conditions.
+//
+// Received requests are queued per pipeline and processed round-robin, so a
+// burst of builds from one pipeline doesn't starve the others.
 func (a *Agent) RunWithChannel(ctx context.Context, msgChan <-chan broker.Message) error {
+	return a.RunWithChannels(ctx, msgChan, nil)
+}
+
+// RunWithChannels is RunWithChannel with a pre-subscribed channel of
+// destill.control messages as well: a contracts.ControlPrioritize message
+// moves its request, if queued here, ahead of the others. Messages sent
+// before the agent started are ignored, so replaying the topic to a new
+// consumer group doesn't reorder the queue.
+func (a *Agent) RunWithChannels(ctx context.Context, msgChan, controlChan <-chan broker.Message) error {
 	a.logger.Info("[IngestAgent] Listening for requests on '%s' topic...", contracts.TopicRequests)
 
+	queue := newFairQueue(a.opts.MaxPending, a.opts.PerPipeline)
+	stop := context.AfterFunc(ctx, queue.abort)
+	defer stop()
+
+	if controlChan != nil {
+		go a.prioritizeFrom(ctx, controlChan, queue, time.Now())
+	}
+
+	// Feed the queue from the broker
+	go func() {
+		defer queue.close()
+		for {
+			select {
+			case msg, ok := <-msgChan:
+				if !ok {
+					return
+				}
+				if !queue.push(requestPipeline(msg), msg) {
+					return
+				}
+			case <-a.drain:
+				a.logger.Info("[IngestAgent] Draining, finishing the requests received")
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	// Process messages
+	var wg sync.WaitGroup
+	for i := 0; i < a.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				key, msg, ok := queue.pop()
+				if !ok {
+					return
+				}
+				if err := a.processRequest(ctx, msg); err != nil {
+					a.logger.Error("[IngestAgent] Error processing request: %v", err)
+					if a.onError != nil {
+						a.onError(msg.Key, err)
+					}
+				}
+				queue.done(key)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		a.logger.Info("[IngestAgent] Context cancelled, shutting down")
+		return ctx.Err()
+	}
+	select {
+	case <-a.drain:
+		a.logger.Info("[IngestAgent] Drained, shutting down")
+	default:
+		a.logger.Info("[IngestAgent] Message channel closed, shutting down")
+	}
+	return nil
+}
+
+// prioritizeFrom moves the requests named by ControlPrioritize messages sent
+// after started to the front of queue, until controlChan closes or ctx is
+// done.
+func (a *Agent) prioritizeFrom(ctx context.Context, controlChan <-chan broker.Message, queue *fairQueue, started time.Time) {
 	for {
 		select {
-		case msg, ok := <-msgChan:
+		case msg, ok := <-controlChan:
 			if !ok {
-				a.logger.Info("[IngestAgent] Message channel closed, shutting down")
-				return nil
+				return
 			}
-
-			if err := a.processRequest(ctx, msg); err != nil {
-				a.logger.Error("[IngestAgent] Error processing request: %v", err)
+			var control contracts.ControlMessage
+			if err := json.Unmarshal(msg.Value, &control); err != nil || control.Type != contracts.ControlPrioritize {
+				continue
+			}
+			if sent, err := time.Parse(time.RFC3339, control.Timestamp); err == nil && sent.Before(started.Truncate(time.Second)) {
+				continue
+			}
+			if queue.prioritize(control.RequestID) {
+				a.logger.Info("[IngestAgent] Prioritized request %s", control.RequestID)
+			} else {
+				a.logger.Debug("[IngestAgent] Request %s to prioritize isn't queued here", control.RequestID)
 			}
-
 		case <-ctx.Done():
-			a.logger.Info("[IngestAgent] Context cancelled, shutting down")
-			return ctx.Err()
+			return
 		}
 	}
 }
@@ -80,6 +241,14 @@ func (a *Agent) processRequest(ctx context.Context, msg broker.Message) error {
 	a.logger.Info("[IngestAgent] Processing request %s", request.RequestID)
 	a.logger.Info("[IngestAgent] Build URL: %s", request.BuildURL)
 
+	if request.Version > contracts.AnalysisRequestVersion {
+		a.logger.Info("[IngestAgent] Request %s uses schema v%d (this agent understands v%d); unknown fields are ignored",
+			request.RequestID, request.Version, contracts.AnalysisRequestVersion)
+	}
+	if err := request.Options.Validate(); err != nil {
+		return fmt.Errorf("invalid options for request %s: %w", request.RequestID, err)
+	}
+
 	// Parse URL to detect provider
 	ref, err := provider.ParseURL(request.BuildURL)
 	if err != nil {
@@ -110,6 +279,30 @@ func (a *Agent) processRequest(ctx context.Context, msg broker.Message) error {
 	a.logger.Info("[IngestAgent] Fetching build metadata for %s", buildID)
 	a.logger.Info("[IngestAgent] Found %d jobs in build (state: %s)", len(build.Jobs), build.State)
 
+	// Findings the known-good baseline build also has are suppressed
+	var baseline []string
+	if request.Options != nil && request.Options.BaselineURL != "" {
+		a.publishProgress(ctx, request.RequestID, "Analyzing baseline build", 0, 0)
+		baseline, err = a.baselineHashes(ctx, request.Options.BaselineURL)
+		if err != nil {
+			a.logger.Error("[IngestAgent] Not suppressing baseline findings: %v", err)
+		} else {
+			a.logger.Info("[IngestAgent] Baseline %s has %d distinct findings", request.Options.BaselineURL, len(baseline))
+		}
+	}
+
+	// Nightly builds: the commits since the last clean one, for findings of
+	// failed jobs
+	var commits *provider.CommitRange
+	if build.Trigger == provider.TriggerSchedule && slices.ContainsFunc(build.Jobs, jobFailed) {
+		commits, err = provider.FindCommitRange(ctx, prov, ref, build)
+		if err != nil {
+			a.logger.Error("[IngestAgent] No bisect hint: %v", err)
+		} else if commits != nil {
+			a.logger.Info("[IngestAgent] Failures %s", commits)
+		}
+	}
+
 	// Count script jobs for progress tracking
 	scriptJobs := 0
 	for _, job := range build.Jobs {
@@ -118,16 +311,41 @@ func (a *Agent) processRequest(ctx context.Context, msg broker.Message) error {
 		}
 	}
 
-	// Process each job
+	cascades := cascadeMetadata(build.Jobs)
+
+	// Process each job, recording how long each ran for the timing profile
+	analyzeArtifacts := request.Options != nil && request.Options.Artifacts
+	seenArtifacts := make(map[string]bool)
 	totalChunks := 0
+	ownFindings := 0 // Published here rather than by the analyze agent
 	processedJobs := 0
-	for _, job := range build.Jobs {
+	var timings []jobTiming
+	var truncated []string // What the limits cut, for the request status
+	cutJob, skippedJobs := "", 0
+	for _, job := range failedFirst(build.Jobs) {
 		// Skip non-script jobs (GitHub doesn't have this distinction, so Type may be empty)
 		if job.Type != "script" && job.Type != "" {
 			a.logger.Debug("[IngestAgent] Skipping non-script job: %s (type: %s)", job.Name, job.Type)
 			continue
 		}
 
+		// Jobs with an unknown outcome (exported logs) can't be ruled out,
+		// and timed out jobs failed whatever their exit code
+		if request.Options != nil && request.Options.FailedOnly && !jobFailed(job) && job.State != provider.JobStateUnknown &&
+			!jobTimedOut(job) {
+			a.logger.Debug("[IngestAgent] Skipping job that did not fail: %s (state: %s)", job.Name, job.State)
+			timings = append(timings, jobTiming{duration: job.Duration, name: job.Name})
+			continue
+		}
+
+		// Past the chunk limit, later jobs aren't fetched at all
+		if a.limits.MaxChunks > 0 && totalChunks >= a.limits.MaxChunks {
+			a.logger.Debug("[IngestAgent] Skipping job past the chunk limit: %s", job.Name)
+			timings = append(timings, jobTiming{duration: job.Duration, name: job.Name})
+			skippedJobs++
+			continue
+		}
+
 		a.logger.Info("[IngestAgent] Fetching logs for job: %s (id: %s, state: %s)",
 			job.Name, job.ID, job.State)
 
@@ -135,11 +353,32 @@ func (a *Agent) processRequest(ctx context.Context, msg broker.Message) error {
 		processedJobs++
 		a.publishProgress(ctx, request.RequestID, "Fetching logs", processedJobs, scriptJobs)
 
-		// Fetch job log using provider
-		logContent, err := prov.FetchJobLog(ctx, job.ID)
-		if err != nil {
-			a.logger.Error("[IngestAgent] Failed to fetch log for job %s: %v", job.Name, err)
-			continue
+		// Fetch job log using provider, split by step when supported.
+		// Providers that stream logs are read below, as they're chunked.
+		streamer, streaming := prov.(provider.LogStreamer)
+		var sections []logSection
+		if !streaming {
+			// Providers stop reading one byte past the cap, so capSections
+			// sees the cut without the whole log in memory
+			fetchCtx := ctx
+			if a.limits.MaxLogBytes > 0 {
+				fetchCtx = provider.WithMaxLogBytes(ctx, a.limits.MaxLogBytes)
+			}
+			sections, err = fetchJobLog(fetchCtx, prov, job.ID)
+			if errors.Is(err, provider.ErrRateLimited) {
+				// Every later job would fail the same way
+				return provider.WrapError(fmt.Errorf("failed to fetch log for job %s: %w", job.Name, err))
+			}
+			if err != nil {
+				a.logger.Error("[IngestAgent] Failed to fetch log for job %s: %v", job.Name, err)
+				timings = append(timings, jobTiming{duration: job.Duration, name: job.Name})
+				continue
+			}
+			var cut bool
+			if sections, cut = capSections(sections, a.limits.MaxLogBytes); cut {
+				a.logger.Info("[IngestAgent] Cut the log of job '%s' at %s", job.Name, formatSize(a.limits.MaxLogBytes))
+				truncated = append(truncated, logCutNote(job.Name, a.limits.MaxLogBytes))
+			}
 		}
 
 		// Prepare metadata
@@ -147,38 +386,186 @@ func (a *Agent) processRequest(ctx context.Context, msg broker.Message) error {
 			"build_url":    request.BuildURL,
 			"build_id":     build.ID,
 			"build_number": build.Number,
+			"job_id":       job.ID,
 			"job_state":    job.State,
 			"job_type":     job.Type,
 			"exit_status":  fmt.Sprintf("%d", job.ExitCode),
 			"provider":     prov.Name(),
 		}
 
+		// Retried jobs: every attempt is analyzed, and readers compare them
+		if job.Attempts > 1 {
+			metadata[contracts.MetadataRetryGroup] = job.RetryGroup
+			metadata[contracts.MetadataAttempt] = strconv.Itoa(job.Attempt)
+			metadata[contracts.MetadataAttempts] = strconv.Itoa(job.Attempts)
+		}
+
+		// Without a known outcome, leave exit_status unset so the analyzer
+		// neither boosts nor penalizes findings
+		if job.State == provider.JobStateUnknown {
+			delete(metadata, "exit_status")
+		}
+
+		// A job CI timed out or canceled was killed wherever it was, so its
+		// exit status says nothing about its errors
+		interrupted, marker := interruption(job, sections)
+		if interrupted != "" {
+			metadata[contracts.MetadataInterrupted] = interrupted
+			delete(metadata, "exit_status")
+		}
+
 		// Add provider-specific metadata
 		for k, v := range ref.Metadata {
 			metadata[k] = v
 		}
 
-		// Chunk the log
-		chunks := ChunkLog(logContent, request.RequestID, buildID, job.Name, job.ID, metadata)
-		a.logger.Info("[IngestAgent] Split job '%s' into %d chunks", job.Name, len(chunks))
+		// Runner details from the environment dump at the start of the log
+		for _, section := range sections {
+			for k, v := range parseEnvMetadata(section.content) {
+				if _, ok := metadata[k]; !ok {
+					metadata[k] = v
+				}
+			}
+		}
+
+		// Whether the job ran downstream of a failed job it depends on
+		for k, v := range cascades[job.ID] {
+			metadata[k] = v
+		}
+
+		// Build metadata used by pipeline rules to assign priority hints
+		addBuildMetadata(metadata, ref, build)
+		if commits != nil && jobFailed(job) {
+			addCommitRange(metadata, commits)
+		}
+
+		// Job duration from the provider, or else from log timestamps
+		duration := jobDuration(job, sections)
+		if duration > 0 {
+			metadata[contracts.MetadataJobDuration] = strconv.FormatInt(duration.Milliseconds(), 10)
+		}
+
+		// Publish each chunk, up to the request's chunk limit
+		publish := func(chunk contracts.LogChunk) error {
+			if a.limits.MaxChunks > 0 && totalChunks >= a.limits.MaxChunks {
+				cutJob = job.Name
+				return errChunkLimit
+			}
+			chunk.Options = request.Options
+			chunk.BaselineHashes = baseline
 
-		// Publish each chunk
-		for _, chunk := range chunks {
 			data, err := json.Marshal(chunk)
 			if err != nil {
 				a.logger.Error("[IngestAgent] Failed to marshal chunk: %v", err)
-				continue
+				return nil
 			}
 
-			// Publish to destill.logs.raw with buildID as key for ordering
-			if err := a.broker.Publish(ctx, contracts.TopicLogsRaw, buildID, data); err != nil {
+			// Keyed by request and job: the job's chunks stay in order while
+			// the build's jobs are shared between analyze agents
+			if err := a.broker.Publish(ctx, contracts.TopicLogsRaw, chunk.Key(), data); err != nil {
 				a.logger.Error("[IngestAgent] Failed to publish chunk: %v", err)
-				continue
+				return nil
 			}
 
 			a.logger.Debug("[IngestAgent] Published %s", FormatChunkInfo(chunk))
 			totalChunks++
+			return nil
+		}
+
+		// A streamed log is published chunk by chunk as it's read
+		if streaming {
+			streamed, logSpan, cut, err := a.streamJobLog(ctx, streamer, request.RequestID, buildID, job, metadata, publish)
+			if errors.Is(err, provider.ErrRateLimited) {
+				return provider.WrapError(fmt.Errorf("failed to stream log for job %s: %w", job.Name, err))
+			}
+			if cut {
+				a.logger.Info("[IngestAgent] Cut the log of job '%s' at %s", job.Name, formatSize(a.limits.MaxLogBytes))
+				truncated = append(truncated, logCutNote(job.Name, a.limits.MaxLogBytes))
+			}
+			if err != nil && !errors.Is(err, errChunkLimit) {
+				a.logger.Error("[IngestAgent] Failed to stream log for job %s after %d chunks: %v", job.Name, streamed, err)
+			}
+			a.logger.Info("[IngestAgent] Streamed job '%s' in %d chunks", job.Name, streamed)
+			if duration == 0 && logSpan > 0 {
+				duration = logSpan
+				metadata[contracts.MetadataJobDuration] = strconv.FormatInt(duration.Milliseconds(), 10)
+			}
+		}
+		timings = append(timings, jobTiming{duration: duration, analyzed: true, name: job.Name, metadata: metadata})
+
+		// Chunk the log. Each step is chunked separately so every chunk,
+		// and every finding in it, belongs to exactly one step.
+		var chunks []contracts.LogChunk
+		for _, section := range sections {
+			sectionMetadata := metadata
+			if section.metadata != nil {
+				sectionMetadata = make(map[string]string, len(metadata)+len(section.metadata))
+				for k, v := range metadata {
+					sectionMetadata[k] = v
+				}
+				for k, v := range section.metadata {
+					sectionMetadata[k] = v
+				}
+			}
+			chunks = append(chunks, ChunkLog(section.content, request.RequestID, buildID, job.Name, job.ID, sectionMetadata)...)
+		}
+		if !streaming {
+			a.logger.Info("[IngestAgent] Split job '%s' into %d chunks (%d sections)", job.Name, len(chunks), len(sections))
+		}
+
+		// Artifacts: JUnit reports of failed jobs, and text artifacts
+		// analyzed alongside the log when requested
+		var artifacts []provider.Artifact
+		if jobFailed(job) || analyzeArtifacts {
+			artifacts = a.jobArtifacts(ctx, prov, job, seenArtifacts)
+		}
+		if analyzeArtifacts {
+			artifactChunks := a.artifactChunks(ctx, prov, request.RequestID, buildID, job, artifacts, metadata)
+			if len(artifactChunks) > 0 {
+				a.logger.Info("[IngestAgent] Added %d chunks from the artifacts of job '%s'", len(artifactChunks), job.Name)
+			}
+			chunks = append(chunks, artifactChunks...)
+		}
+
+		for _, chunk := range chunks {
+			if publish(chunk) != nil {
+				break
+			}
+		}
+
+		// Failed tests from the job's JUnit report artifacts
+		if jobFailed(job) {
+			ownFindings += a.publishJUnitFailures(ctx, prov, request, job, artifacts, metadata, baseline)
 		}
+
+		// The timeout or cancellation itself, with guidance
+		if interrupted != "" {
+			ownFindings += a.publishInterruption(ctx, request, interruptedCard(request.RequestID, job, interrupted, marker, metadata))
+		}
+	}
+
+	// Jobs much slower than the rest of the build, as informational findings
+	for _, card := range slowJobCards(request.RequestID, timings) {
+		if request.Options != nil && card.ConfidenceScore < request.Options.MinConfidence {
+			continue
+		}
+		a.logger.Info("[IngestAgent] %s", card.RawMessage)
+		data, err := json.Marshal(card)
+		if err != nil {
+			a.logger.Error("[IngestAgent] Failed to marshal slow job finding: %v", err)
+			continue
+		}
+		if err := a.broker.Publish(ctx, contracts.TopicAnalysisFindings, request.RequestID, data); err != nil {
+			a.logger.Error("[IngestAgent] Failed to publish slow job finding: %v", err)
+			continue
+		}
+		ownFindings++
+	}
+
+	if cutJob != "" || skippedJobs > 0 {
+		note := chunkLimitNote(a.limits.MaxChunks, cutJob, skippedJobs)
+		a.logger.Info("[IngestAgent] Request %s %s", request.RequestID, note)
+		truncated = append(truncated, note)
 	}
 
 	a.logger.Info("[IngestAgent] Completed processing request %s (%d log chunks)",
@@ -187,14 +574,182 @@ func (a *Agent) processRequest(ctx context.Context, msg broker.Message) error {
 	// Signal completion to progress subscribers
 	a.publishProgress(ctx, request.RequestID, "complete", scriptJobs, scriptJobs)
 
+	// Tell the analyze agent how many chunks to expect
+	a.publishControl(ctx, contracts.ControlMessage{
+		Type:      contracts.ControlIngestComplete,
+		RequestID: request.RequestID,
+		Chunks:    totalChunks,
+		Findings:  ownFindings,
+		Truncated: truncated,
+	})
+	if totalChunks == 0 {
+		// No analyze agent gets a chunk of it, to say when it's done
+		a.publishControl(ctx, contracts.ControlMessage{
+			Type:      contracts.ControlAnalysisComplete,
+			RequestID: request.RequestID,
+			Findings:  ownFindings,
+		})
+	}
+
 	return nil
 }
 
-// publishProgress publishes a progress update to the broker.
+// logSection is part of a job's log with the metadata describing it.
+type logSection struct {
+	content  string
+	metadata map[string]string // Step metadata; nil for a whole-job log
+	duration time.Duration     // Step duration reported by the provider; zero if unknown
+}
+
+// fetchJobLog fetches a job's log. Providers implementing provider.StepProvider
+// return one section per step, tagged with step_name, step_index, step_state,
+// and step_exit_code; others return the whole log as a single section.
+func fetchJobLog(ctx context.Context, prov provider.Provider, jobID string) ([]logSection, error) {
+	sp, ok := prov.(provider.StepProvider)
+	if !ok {
+		content, err := prov.FetchJobLog(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		return []logSection{{content: content}}, nil
+	}
+
+	steps, err := sp.FetchJobSteps(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make([]logSection, 0, len(steps))
+	for _, step := range steps {
+		section := logSection{
+			content: step.Log,
+			metadata: map[string]string{
+				"step_name":      step.Name,
+				"step_index":     strconv.Itoa(step.Index),
+				"step_state":     step.State,
+				"step_exit_code": strconv.Itoa(step.ExitCode),
+			},
+			duration: step.Duration,
+		}
+		if step.Duration > 0 {
+			section.metadata[contracts.MetadataStepDuration] = strconv.FormatInt(step.Duration.Milliseconds(), 10)
+		}
+		sections = append(sections, section)
+	}
+	return sections, nil
+}
+
+// streamJobLog reads a job's log from a streaming provider, chunking it and
+// passing each chunk to publish as soon as it's complete, so memory use is
+// bounded by the chunk size however large the log. Runner details from the
+// environment dump are read from the first chunk and added to metadata.
+// Reading stops at Limits.MaxLogBytes, or when publish fails. Returns the
+// number of chunks, how long the log's timestamps span, and whether the
+// log was cut at the limit.
+func (a *Agent) streamJobLog(ctx context.Context, streamer provider.LogStreamer, requestID, buildID string, job provider.Job, metadata map[string]string, publish func(contracts.LogChunk) error) (int, time.Duration, bool, error) {
+	body, err := streamer.StreamJobLog(ctx, job.ID)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	defer body.Close()
+
+	var r io.Reader = body
+	var capped *cappedReader
+	if a.limits.MaxLogBytes > 0 {
+		capped = &cappedReader{r: body, max: a.limits.MaxLogBytes}
+		r = capped
+	}
+
+	var first, last string
+	n, err := ChunkStream(r, requestID, buildID, job.Name, job.ID, metadata, func(chunk contracts.LogChunk) error {
+		if chunk.ChunkIndex == 0 {
+			first = chunk.Content
+			for k, v := range parseEnvMetadata(chunk.Content) {
+				if _, ok := metadata[k]; !ok {
+					metadata[k] = v
+					chunk.Metadata[k] = v
+				}
+			}
+		}
+		last = chunk.Content
+		return publish(chunk)
+	})
+	return n, logDuration(first + "\n" + last), capped != nil && capped.cut, err
+}
+
+// jobDuration returns how long a job ran: as reported by the provider, or
+// else the sum of its steps' durations, each as reported or estimated from
+// log timestamps (see logDuration). Zero when unknown.
+func jobDuration(job provider.Job, sections []logSection) time.Duration {
+	if job.Duration > 0 {
+		return job.Duration
+	}
+	var total time.Duration
+	for _, section := range sections {
+		if section.duration > 0 {
+			total += section.duration
+		} else {
+			total += logDuration(section.content)
+		}
+	}
+	return total
+}
+
+// addBuildMetadata records the pipeline, branch, trigger, and creation time
+// of a build. Values the provider doesn't report are omitted.
+func addBuildMetadata(metadata map[string]string, ref *provider.BuildRef, build *provider.Build) {
+	set := func(key, value string) {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	set("pipeline_name", provider.PipelineName(ref))
+	set("branch", build.Branch)
+	set("trigger", build.Trigger)
+	if !build.Timestamp.IsZero() {
+		metadata["build_created_at"] = build.Timestamp.Format(time.RFC3339)
+	}
+}
+
+// addCommitRange records where the failures of a job were introduced (see
+// contracts.MetadataBisectGood).
+func addCommitRange(metadata map[string]string, r *provider.CommitRange) {
+	metadata[contracts.MetadataBisectGood] = r.Good
+	metadata[contracts.MetadataBisectBad] = r.Bad
+	metadata[contracts.MetadataBisectGoodBuild] = r.GoodBuildURL
+	if r.Commits > 0 {
+		metadata[contracts.MetadataBisectCommits] = strconv.Itoa(r.Commits)
+	}
+}
+
+// jobFailed reports whether a job failed, by state or exit code.
+func jobFailed(job provider.Job) bool {
+	return job.State == "failed" || job.ExitCode != 0
+}
+
+// jobTimedOut reports whether CI stopped a job at its time limit, by state.
+func jobTimedOut(job provider.Job) bool {
+	kind, _ := interruption(job, nil)
+	return kind == contracts.InterruptedTimeout
+}
+
+// failedFirst returns jobs with the failed ones first, otherwise in build
+// order, so the findings that matter most are fetched and published first.
+func failedFirst(jobs []provider.Job) []provider.Job {
+	ordered := make([]provider.Job, len(jobs))
+	copy(ordered, jobs)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return jobFailed(ordered[i]) && !jobFailed(ordered[j])
+	})
+	return ordered
+}
+
+// publishProgress publishes a progress update to the broker, counting jobs.
 func (a *Agent) publishProgress(ctx context.Context, requestID, stage string, current, total int) {
 	update := contracts.ProgressUpdate{
 		RequestID: requestID,
 		Stage:     stage,
+		Unit:      contracts.ProgressUnitJobs,
 		Current:   current,
 		Total:     total,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
@@ -210,3 +765,17 @@ func (a *Agent) publishProgress(ctx context.Context, requestID, stage string, cu
 		a.logger.Error("[IngestAgent] Failed to publish progress update: %v", err)
 	}
 }
+
+// publishControl publishes a control message to the broker.
+func (a *Agent) publishControl(ctx context.Context, msg contracts.ControlMessage) {
+	msg.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		a.logger.Error("[IngestAgent] Failed to marshal control message: %v", err)
+		return
+	}
+
+	if err := a.broker.Publish(ctx, contracts.TopicControl, msg.RequestID, data); err != nil {
+		a.logger.Error("[IngestAgent] Failed to publish control message: %v", err)
+	}
+}
@@ -3,12 +3,17 @@ package ingest
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"destill-agent/src/broker"
 	"destill-agent/src/contracts"
 	"destill-agent/src/logger"
+	"destill-agent/src/provider"
 )
 
 func TestAgent_ProcessRequest(t *testing.T) {
@@ -178,3 +183,295 @@ func TestAgent_TopicNames(t *testing.T) {
 		t.Errorf("Expected TopicRequests to be 'destill.requests', got %s", contracts.TopicRequests)
 	}
 }
+
+func TestAgent_RejectsInvalidOptions(t *testing.T) {
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	agent := NewAgent(brk, logger.NewSilentLogger())
+
+	data, _ := json.Marshal(contracts.AnalysisRequest{
+		Version:   contracts.AnalysisRequestVersion,
+		RequestID: "req-bad-opts",
+		BuildURL:  "https://buildkite.com/org/pipeline/builds/1",
+		Options:   &contracts.AnalysisOptions{MinConfidence: 1.5},
+	})
+
+	err := agent.processRequest(context.Background(), broker.Message{Topic: contracts.TopicRequests, Value: data})
+	if err == nil {
+		t.Fatal("processRequest() expected error for out-of-range min_confidence, got nil")
+	}
+}
+
+func TestJobFailed(t *testing.T) {
+	tests := []struct {
+		job  provider.Job
+		want bool
+	}{
+		{provider.Job{State: "failed", ExitCode: 1}, true},
+		{provider.Job{State: "failed"}, true},
+		{provider.Job{State: "broken", ExitCode: 2}, true},
+		{provider.Job{State: "passed"}, false},
+		{provider.Job{State: "skipped"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := jobFailed(tt.job); got != tt.want {
+			t.Errorf("jobFailed(%+v) = %v, want %v", tt.job, got, tt.want)
+		}
+	}
+}
+
+func TestFailedFirst(t *testing.T) {
+	jobs := []provider.Job{
+		{Name: "lint", State: "passed"},
+		{Name: "unit", State: "failed"},
+		{Name: "docs", State: "passed"},
+		{Name: "e2e", State: "broken", ExitCode: 2},
+	}
+
+	var names []string
+	for _, job := range failedFirst(jobs) {
+		names = append(names, job.Name)
+	}
+	if got, want := strings.Join(names, ","), "unit,e2e,lint,docs"; got != want {
+		t.Errorf("failedFirst() order = %s, want %s", got, want)
+	}
+	if jobs[0].Name != "lint" {
+		t.Error("failedFirst() reordered the build's jobs")
+	}
+}
+
+func TestAddBuildMetadata(t *testing.T) {
+	ref := &provider.BuildRef{Provider: "github", Metadata: map[string]string{"owner": "acme", "repo": "web"}}
+	build := &provider.Build{
+		Branch:    "main",
+		Trigger:   provider.TriggerSchedule,
+		Timestamp: time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC),
+	}
+
+	metadata := map[string]string{}
+	addBuildMetadata(metadata, ref, build)
+
+	want := map[string]string{
+		"pipeline_name":    "acme/web",
+		"branch":           "main",
+		"trigger":          "schedule",
+		"build_created_at": "2024-01-01T02:00:00Z",
+	}
+	for key, value := range want {
+		if metadata[key] != value {
+			t.Errorf("metadata[%s] = %q, want %q", key, metadata[key], value)
+		}
+	}
+
+	empty := map[string]string{}
+	addBuildMetadata(empty, &provider.BuildRef{Provider: "other"}, &provider.Build{})
+	if len(empty) != 0 {
+		t.Errorf("metadata = %v, want empty for unknown build info", empty)
+	}
+}
+
+// stubProvider serves a fixed log, optionally split by step.
+type stubProvider struct {
+	provider.Provider
+	log   string
+	steps []provider.Step
+}
+
+func (s *stubProvider) FetchJobLog(ctx context.Context, jobID string) (string, error) {
+	return s.log, nil
+}
+
+type stubStepProvider struct {
+	stubProvider
+}
+
+func (s *stubStepProvider) FetchJobSteps(ctx context.Context, jobID string) ([]provider.Step, error) {
+	return s.steps, nil
+}
+
+// stubLogStreamer streams a fixed log.
+type stubLogStreamer struct {
+	stubProvider
+}
+
+func (s *stubLogStreamer) StreamJobLog(ctx context.Context, jobID string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(s.log)), nil
+}
+
+func TestAgent_StreamJobLog(t *testing.T) {
+	agent := NewAgent(nil, logger.NewSilentLogger())
+	log := "2024-01-15T10:00:00Z Image: ubuntu-22.04\n" + strings.Repeat(strings.Repeat("v", 600)+"\n", 2000) + "2024-01-15T10:05:00Z ERROR: build failed"
+	metadata := map[string]string{"job_id": "job-1"}
+
+	var chunks []contracts.LogChunk
+	n, span, cut, err := agent.streamJobLog(context.Background(), &stubLogStreamer{stubProvider{log: log}}, "req-1", "build-1", provider.Job{ID: "job-1", Name: "tests"}, metadata, func(chunk contracts.LogChunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil || cut {
+		t.Fatalf("streamJobLog() cut = %v, error = %v; want the whole log", cut, err)
+	}
+	if n < 2 || n != len(chunks) {
+		t.Fatalf("streamJobLog() = %d chunks (%d published), want several", n, len(chunks))
+	}
+	if span != 5*time.Minute {
+		t.Errorf("span = %v, want 5m0s from the log's timestamps", span)
+	}
+	for _, chunk := range chunks {
+		if chunk.Metadata[contracts.MetadataRunnerImage] != "ubuntu-22.04" {
+			t.Errorf("chunk %d runner image = %q, want ubuntu-22.04 from the first chunk", chunk.ChunkIndex, chunk.Metadata[contracts.MetadataRunnerImage])
+		}
+	}
+}
+
+func TestFetchJobLog(t *testing.T) {
+	ctx := context.Background()
+
+	sections, err := fetchJobLog(ctx, &stubProvider{log: "whole log"}, "job-1")
+	if err != nil {
+		t.Fatalf("fetchJobLog() error = %v", err)
+	}
+	if len(sections) != 1 || sections[0].content != "whole log" || sections[0].metadata != nil {
+		t.Errorf("sections = %+v, want the whole log without step metadata", sections)
+	}
+
+	sp := &stubStepProvider{stubProvider{steps: []provider.Step{
+		{Name: "checkout", Index: 0, State: "passed", Log: "cloning"},
+		{Name: "test", Index: 1, State: "failed", ExitCode: 2, Log: "FAIL"},
+	}}}
+	sections, err = fetchJobLog(ctx, sp, "job-1")
+	if err != nil {
+		t.Fatalf("fetchJobLog() error = %v", err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("len(sections) = %d, want 2", len(sections))
+	}
+	want := map[string]string{
+		"step_name":      "test",
+		"step_index":     "1",
+		"step_state":     "failed",
+		"step_exit_code": "2",
+	}
+	for key, value := range want {
+		if got := sections[1].metadata[key]; got != value {
+			t.Errorf("metadata[%s] = %q, want %q", key, got, value)
+		}
+	}
+	if sections[1].content != "FAIL" {
+		t.Errorf("content = %q, want FAIL", sections[1].content)
+	}
+}
+
+func TestAgent_ProcessLocalDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "unit-tests.log"), []byte("ERROR: test failed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	buildURL, err := provider.LocalDirURL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	msgChan, err := brk.Subscribe(ctx, contracts.TopicLogsRaw, "test-consumer")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	data, _ := json.Marshal(contracts.AnalysisRequest{
+		Version:   contracts.AnalysisRequestVersion,
+		RequestID: "req-local",
+		BuildURL:  buildURL,
+		Options:   &contracts.AnalysisOptions{FailedOnly: true},
+	})
+	agent := NewAgent(brk, logger.NewSilentLogger())
+	if err := agent.processRequest(ctx, broker.Message{Topic: contracts.TopicRequests, Value: data}); err != nil {
+		t.Fatalf("processRequest() error = %v", err)
+	}
+
+	select {
+	case msg := <-msgChan:
+		var chunk contracts.LogChunk
+		if err := json.Unmarshal(msg.Value, &chunk); err != nil {
+			t.Fatalf("Failed to unmarshal chunk: %v", err)
+		}
+		if chunk.JobName != "unit-tests" {
+			t.Errorf("JobName = %q, want unit-tests", chunk.JobName)
+		}
+		if _, ok := chunk.Metadata["exit_status"]; ok {
+			t.Errorf("exit_status = %q, want unset for a job with unknown outcome", chunk.Metadata["exit_status"])
+		}
+		if chunk.Metadata["provider"] != "local" {
+			t.Errorf("provider = %q, want local", chunk.Metadata["provider"])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for chunk")
+	}
+}
+
+func TestAgent_Drain(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "unit-tests.log"), []byte("ERROR: test failed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	buildURL, err := provider.LocalDirURL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	chunks, err := brk.Subscribe(ctx, contracts.TopicLogsRaw, "test-consumer")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	agent := NewAgent(brk, logger.NewSilentLogger())
+	requests := make(chan broker.Message)
+	done := make(chan error, 1)
+	go func() { done <- agent.RunWithChannel(ctx, requests) }()
+
+	// A request received before the drain is still processed
+	data, _ := json.Marshal(contracts.AnalysisRequest{
+		Version:   contracts.AnalysisRequestVersion,
+		RequestID: "req-drain",
+		BuildURL:  buildURL,
+	})
+	requests <- broker.Message{Topic: contracts.TopicRequests, Key: "req-drain", Value: data}
+	agent.Drain()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("RunWithChannel() error = %v, want nil after a drain", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("agent didn't stop after draining")
+	}
+
+	select {
+	case msg := <-chunks:
+		var chunk contracts.LogChunk
+		if err := json.Unmarshal(msg.Value, &chunk); err != nil {
+			t.Fatal(err)
+		}
+		if chunk.RequestID != "req-drain" {
+			t.Errorf("RequestID = %q, want req-drain", chunk.RequestID)
+		}
+	default:
+		t.Error("expected the request received before the drain to be processed")
+	}
+
+	select {
+	case requests <- broker.Message{Topic: contracts.TopicRequests, Key: "req-late", Value: data}:
+		t.Error("agent read a request after draining")
+	default:
+	}
+}
@@ -0,0 +1,93 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/provider"
+)
+
+// MaxTextArtifactSize is the largest text artifact downloaded for analysis.
+const MaxTextArtifactSize = 10 << 20
+
+// textArtifactExts are the extensions of artifacts analyzed like the
+// console log. Artifacts without an extension are sniffed after download:
+// GitHub artifacts are named archives, e.g. "crash-logs".
+var textArtifactExts = map[string]bool{
+	".log": true,
+	".txt": true,
+	".out": true,
+	".err": true,
+	"":     true,
+}
+
+// isTextArtifact reports whether an artifact path looks like a text log:
+// "logs/server.log", "hs_err_pid1234.log", "crash-logs". JUnit reports are
+// read by publishJUnitFailures instead.
+func isTextArtifact(artifactPath string) bool {
+	return textArtifactExts[strings.ToLower(path.Ext(artifactPath))]
+}
+
+// looksLikeText reports whether downloaded artifact content is text that
+// can be analyzed: valid UTF-8 without NUL bytes.
+func looksLikeText(data []byte) bool {
+	return utf8.Valid(data) && !bytes.Contains(data, []byte{0})
+}
+
+// jobArtifacts lists a job's artifacts, leaving out those already read for
+// another job of the request (GitHub lists a run's artifacts for each of
+// its jobs). Providers without artifacts yield none.
+func (a *Agent) jobArtifacts(ctx context.Context, prov provider.Provider, job provider.Job, seen map[string]bool) []provider.Artifact {
+	artifacts, err := prov.FetchArtifacts(ctx, job.ID)
+	if err != nil {
+		a.logger.Debug("[IngestAgent] No artifacts for job %s: %v", job.Name, err)
+		return nil
+	}
+
+	var unseen []provider.Artifact
+	for _, artifact := range artifacts {
+		key := artifact.ID
+		if key == "" {
+			key = artifact.JobID + "/" + artifact.Path
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unseen = append(unseen, artifact)
+	}
+	return unseen
+}
+
+// artifactChunks downloads a job's text artifacts and chunks them like its
+// log, with MetadataArtifact set so findings name the file they came from.
+// Binary content is skipped.
+func (a *Agent) artifactChunks(ctx context.Context, prov provider.Provider, requestID, buildID string, job provider.Job, artifacts []provider.Artifact, metadata map[string]string) []contracts.LogChunk {
+	var chunks []contracts.LogChunk
+	for _, artifact := range artifacts {
+		if !isTextArtifact(artifact.Path) || artifact.FileSize > MaxTextArtifactSize {
+			continue
+		}
+		data, err := prov.DownloadArtifact(ctx, artifact)
+		if err != nil {
+			a.logger.Error("[IngestAgent] Failed to download artifact %s of job %s: %v", artifact.Path, job.Name, err)
+			continue
+		}
+		if len(data) > MaxTextArtifactSize || !looksLikeText(data) {
+			a.logger.Debug("[IngestAgent] Skipping artifact %s of job %s: not a text log", artifact.Path, job.Name)
+			continue
+		}
+
+		artifactMetadata := make(map[string]string, len(metadata)+1)
+		for k, v := range metadata {
+			artifactMetadata[k] = v
+		}
+		artifactMetadata[contracts.MetadataArtifact] = artifact.Path
+		chunks = append(chunks, ChunkLog(string(data), requestID, buildID, job.Name, job.ID, artifactMetadata)...)
+	}
+	return chunks
+}
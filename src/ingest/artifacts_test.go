@@ -0,0 +1,92 @@
+package ingest
+
+import (
+	"context"
+	"testing"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/logger"
+	"destill-agent/src/provider"
+)
+
+type stubArtifactProvider struct {
+	stubProvider
+	artifacts []provider.Artifact
+	files     map[string]string // Path -> content
+}
+
+func (s *stubArtifactProvider) FetchArtifacts(ctx context.Context, jobID string) ([]provider.Artifact, error) {
+	return s.artifacts, nil
+}
+
+func (s *stubArtifactProvider) DownloadArtifact(ctx context.Context, artifact provider.Artifact) ([]byte, error) {
+	return []byte(s.files[artifact.Path]), nil
+}
+
+func TestIsTextArtifact(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"logs/server.log", true},
+		{"hs_err_pid1234.log", true},
+		{"output/STDERR.TXT", true},
+		{"crash-logs", true},
+		{"test-results/junit.xml", false},
+		{"screenshots/login.png", false},
+		{"dist/app.tar.gz", false},
+	}
+
+	for _, tt := range tests {
+		if got := isTextArtifact(tt.path); got != tt.want {
+			t.Errorf("isTextArtifact(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAgent_JobArtifacts_ReadOncePerRequest(t *testing.T) {
+	agent := NewAgent(nil, logger.NewSilentLogger())
+	prov := &stubArtifactProvider{artifacts: []provider.Artifact{{ID: "1", Path: "crash-logs"}}}
+	seen := make(map[string]bool)
+
+	// GitHub lists a run's artifacts for each of its jobs
+	if got := agent.jobArtifacts(context.Background(), prov, provider.Job{ID: "job-1"}, seen); len(got) != 1 {
+		t.Fatalf("first job got %d artifacts, want 1", len(got))
+	}
+	if got := agent.jobArtifacts(context.Background(), prov, provider.Job{ID: "job-2"}, seen); len(got) != 0 {
+		t.Errorf("second job got artifacts %+v, want none", got)
+	}
+}
+
+func TestAgent_ArtifactChunks(t *testing.T) {
+	agent := NewAgent(nil, logger.NewSilentLogger())
+	prov := &stubArtifactProvider{
+		artifacts: []provider.Artifact{
+			{ID: "1", Path: "logs/server.log"},
+			{ID: "2", Path: "core.out"},
+			{ID: "3", Path: "test-results/junit.xml"},
+			{ID: "4", Path: "huge.log", FileSize: MaxTextArtifactSize + 1},
+		},
+		files: map[string]string{
+			"logs/server.log":        "starting\nERROR: database connection refused\n",
+			"core.out":               "\x7fELF\x00\x00",
+			"test-results/junit.xml": "<testsuites/>",
+		},
+	}
+	metadata := map[string]string{"job_id": "job-1", "exit_status": "1"}
+
+	chunks := agent.artifactChunks(context.Background(), prov, "req-1", "build-1", provider.Job{ID: "job-1", Name: "tests"}, prov.artifacts, metadata)
+	if len(chunks) != 1 {
+		t.Fatalf("got %d chunks, want the text log only: %+v", len(chunks), chunks)
+	}
+	chunk := chunks[0]
+	if chunk.Metadata[contracts.MetadataArtifact] != "logs/server.log" || chunk.Metadata["exit_status"] != "1" {
+		t.Errorf("Metadata = %v, want the job's metadata and the artifact path", chunk.Metadata)
+	}
+	if chunk.JobID != "job-1" || chunk.JobName != "tests" || chunk.RequestID != "req-1" {
+		t.Errorf("chunk = %+v, want it attributed to the job", chunk)
+	}
+	if _, ok := metadata[contracts.MetadataArtifact]; ok {
+		t.Error("artifactChunks modified the job metadata")
+	}
+}
@@ -0,0 +1,69 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/contracts"
+	"destill-agent/src/provider"
+	"destill-agent/src/rules"
+)
+
+// baselineOptions analyzes every line of a baseline build that would be a
+// finding at any confidence: a message the passing build has is expected,
+// however unlikely the analyzer thought it was a cause.
+var baselineOptions = &contracts.AnalysisOptions{MinConfidence: 0.01}
+
+// baselineHashes analyzes the jobs of a known-good build and returns the
+// message hashes of its findings, sorted. The analyze agent suppresses
+// findings with them (see contracts.LogChunk.BaselineHashes). The build is
+// analyzed with the pattern config at rules.DefaultPath, as the analyze
+// agent does, so user normalizations give both builds the same hashes.
+func (a *Agent) baselineHashes(ctx context.Context, baselineURL string) ([]string, error) {
+	ref, err := provider.ParseURL(baselineURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse baseline URL: %w", err)
+	}
+	prov, err := provider.GetProvider(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider for baseline: %w", err)
+	}
+	build, err := prov.FetchBuild(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch baseline build: %w", err)
+	}
+
+	ruleSet, err := rules.LoadRuleSet()
+	if err != nil {
+		a.logger.Error("[IngestAgent] Analyzing baseline without the pattern config: %v", err)
+		ruleSet = nil
+	}
+
+	seen := make(map[string]bool)
+	for _, job := range build.Jobs {
+		if job.Type != "script" && job.Type != "" {
+			continue
+		}
+		content, err := prov.FetchJobLog(ctx, job.ID)
+		if err != nil {
+			a.logger.Error("[IngestAgent] Failed to fetch baseline log for job %s: %v", job.Name, err)
+			continue
+		}
+		for _, chunk := range ChunkLog(content, "", build.ID, job.Name, job.ID, nil) {
+			chunk.Options = baselineOptions
+			findings, _ := analyze.AnalyzeChunkWithStats(chunk, ruleSet)
+			for _, finding := range findings {
+				seen[analyze.CalculateMessageHash(finding.NormalizedMsg)] = true
+			}
+		}
+	}
+
+	hashes := make([]string, 0, len(seen))
+	for hash := range seen {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes, nil
+}
@@ -0,0 +1,62 @@
+package ingest
+
+import (
+	"slices"
+	"strings"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/provider"
+)
+
+// cascadeMetadata returns the job dependency metadata of a build's jobs,
+// by job ID: jobs that ran after a failed job they depend on, directly or
+// through others, are downstream of it, and failed jobs that aren't are
+// roots (see contracts.MetadataCascade). Other jobs get none. Only the last
+// attempt of a retried job counts as failed. Returns nil when the provider
+// reports no dependencies.
+func cascadeMetadata(jobs []provider.Job) map[string]map[string]string {
+	byID := make(map[string]provider.Job, len(jobs))
+	hasDeps := false
+	for _, job := range jobs {
+		byID[job.ID] = job
+		hasDeps = hasDeps || len(job.DependsOn) > 0
+	}
+	if !hasDeps {
+		return nil
+	}
+
+	failed := func(job provider.Job) bool {
+		return jobFailed(job) && job.Attempt == job.Attempts
+	}
+
+	result := make(map[string]map[string]string)
+	for _, job := range jobs {
+		var upstream []string // Names of the failed jobs upstream
+		seen := map[string]bool{job.ID: true}
+		queue := append([]string(nil), job.DependsOn...)
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			dep, ok := byID[id]
+			if seen[id] || !ok {
+				continue
+			}
+			seen[id] = true
+			if failed(dep) && !slices.Contains(upstream, dep.Name) {
+				upstream = append(upstream, dep.Name)
+			}
+			queue = append(queue, dep.DependsOn...)
+		}
+
+		switch {
+		case len(upstream) > 0:
+			result[job.ID] = map[string]string{
+				contracts.MetadataCascade:        contracts.CascadeDownstream,
+				contracts.MetadataFailedUpstream: strings.Join(upstream, contracts.FailedUpstreamSeparator),
+			}
+		case failed(job):
+			result[job.ID] = map[string]string{contracts.MetadataCascade: contracts.CascadeRoot}
+		}
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package ingest
+
+import (
+	"testing"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/provider"
+)
+
+func TestCascadeMetadata(t *testing.T) {
+	jobs := []provider.Job{
+		{ID: "build", Name: "build", State: "failed", ExitCode: 1},
+		{ID: "lint", Name: "lint", State: "passed"},
+		{ID: "test", Name: "test (ubuntu, 1.22)", State: "failed", ExitCode: 1, DependsOn: []string{"build"}},
+		{ID: "deploy", Name: "deploy", State: "failed", ExitCode: 1, DependsOn: []string{"test", "lint"}},
+		{ID: "docs", Name: "docs", State: "passed", DependsOn: []string{"lint"}},
+		// A failed attempt that a retry fixed is no root cause
+		{ID: "flaky-1", Name: "flaky", State: "failed", ExitCode: 1, RetryGroup: "flaky-1", Attempt: 1, Attempts: 2},
+		{ID: "flaky-2", Name: "flaky", State: "passed", RetryGroup: "flaky-1", Attempt: 2, Attempts: 2},
+		{ID: "e2e", Name: "e2e", State: "failed", ExitCode: 1, DependsOn: []string{"flaky-1", "flaky-2"}},
+	}
+
+	cascades := cascadeMetadata(jobs)
+
+	want := map[string]map[string]string{
+		"build":  {contracts.MetadataCascade: contracts.CascadeRoot},
+		"test":   {contracts.MetadataCascade: contracts.CascadeDownstream, contracts.MetadataFailedUpstream: "build"},
+		"deploy": {contracts.MetadataCascade: contracts.CascadeDownstream, contracts.MetadataFailedUpstream: "test (ubuntu, 1.22); build"},
+		"e2e":    {contracts.MetadataCascade: contracts.CascadeRoot},
+	}
+	if len(cascades) != len(want) {
+		t.Fatalf("cascadeMetadata() = %v, want %v", cascades, want)
+	}
+	for id, w := range want {
+		for k, v := range w {
+			if got := cascades[id][k]; got != v {
+				t.Errorf("cascadeMetadata()[%s][%s] = %q, want %q", id, k, got, v)
+			}
+		}
+	}
+
+	// Without dependencies there's nothing to tell roots from echoes
+	if cascades := cascadeMetadata([]provider.Job{{ID: "build", State: "failed"}}); cascades != nil {
+		t.Errorf("cascadeMetadata(no dependencies) = %v, want nil", cascades)
+	}
+}
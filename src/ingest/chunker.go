@@ -3,10 +3,14 @@ package ingest
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"regexp"
 	"strings"
 
 	"destill-agent/src/contracts"
+	"destill-agent/src/experiments"
 )
 
 const (
@@ -16,85 +20,93 @@ const (
 	// ContextOverlap is the number of lines to overlap between chunks
 	// This helps preserve context at chunk boundaries
 	ContextOverlap = 50
+
+	// MaxStitchedLines bounds the stack trace carried into the next chunk
+	// with the stacktrace_stitching experiment
+	MaxStitchedLines = 1000
 )
 
+// stackFrameLine matches the lines of a stack trace after its header: Java
+// and JavaScript "at" frames and their "Caused by:" and "... 12 more"
+// lines, Python "File" lines, and Go goroutine headers and frames.
+var stackFrameLine = regexp.MustCompile(`^\s+(?:at\s|File "|\S+\.\w+:\d+)|^\s*(?:Caused by: |\.\.\. \d+ (?:more|common frames omitted))|^goroutine \d+ \[|^[\w./*()\[\]{}-]+\(.*\)$`)
+
 // ChunkLog splits a log into ~500KB chunks with line overlap.
 // Each chunk maintains context by overlapping 50 lines with the previous chunk.
 func ChunkLog(content string, requestID, buildID, jobName, jobID string, metadata map[string]string) []contracts.LogChunk {
-	if len(content) == 0 {
-		return []contracts.LogChunk{}
-	}
+	chunks := []contracts.LogChunk{}
+	// Neither reading a string nor collecting chunks can fail
+	ChunkStream(strings.NewReader(content), requestID, buildID, jobName, jobID, metadata, func(chunk contracts.LogChunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
 
-	// Split into lines for processing
-	scanner := bufio.NewScanner(strings.NewReader(content))
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	// If content is small, the single chunk is the log as is
+	if len(chunks) == 1 && len(content) <= TargetChunkSize {
+		chunks[0].Content = content
+		chunks[0].Metadata = metadata
 	}
 
-	if len(lines) == 0 {
-		return []contracts.LogChunk{}
+	// Update total chunks count
+	for i := range chunks {
+		chunks[i].TotalChunks = len(chunks)
 	}
+	return chunks
+}
 
-	// If content is small, return single chunk
-	if len(content) <= TargetChunkSize {
+// ChunkStream reads a log from r and passes each ~500KB chunk to emit as
+// soon as it's complete, overlapping lines like ChunkLog, so a log of any
+// size is chunked in memory bounded by the chunk size. Lines longer than
+// TargetChunkSize are cut short. The number of chunks isn't known until the
+// log ends, so TotalChunks is left 0. Returns the number of chunks emitted,
+// stopping at the first read or emit error.
+func ChunkStream(r io.Reader, requestID, buildID, jobName, jobID string, metadata map[string]string, emit func(contracts.LogChunk) error) (int, error) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	var currentLines []string
+	currentSize := 0
+	lineStart := 1
+	lines := 0 // Lines read so far
+	count := 0 // Chunks emitted so far
+	stitch := experiments.Enabled(experiments.StackTraceStitching)
+
+	flush := func() error {
 		chunk := contracts.LogChunk{
-			RequestID:   requestID,
-			BuildID:     buildID,
-			JobName:     jobName,
-			JobID:       jobID,
-			ChunkIndex:  0,
-			TotalChunks: 1,
-			Content:     content,
-			LineStart:   1,
-			LineEnd:     len(lines),
-			Metadata:    metadata,
+			RequestID:  requestID,
+			BuildID:    buildID,
+			JobName:    jobName,
+			JobID:      jobID,
+			ChunkIndex: count,
+			Content:    strings.Join(currentLines, "\n"),
+			LineStart:  lineStart,
+			LineEnd:    lines,
+			Metadata:   copyMetadata(metadata),
 		}
-		return []contracts.LogChunk{chunk}
+		count++
+		return emit(chunk)
 	}
 
-	// Build chunks with target size
-	var chunks []contracts.LogChunk
-	currentLines := []string{}
-	currentSize := 0
-	lineStart := 1
-	overlapBuffer := []string{} // Keep last N lines for overlap
-
-	for i, line := range lines {
+	for {
+		line, err := readLine(reader, TargetChunkSize)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to read log: %w", err)
+		}
 		lineSize := len(line) + 1 // +1 for newline
 
 		// Check if adding this line would exceed target size
 		if currentSize+lineSize > TargetChunkSize && len(currentLines) > 0 {
-			// Create chunk from current lines
-			chunkContent := strings.Join(currentLines, "\n")
-			chunk := contracts.LogChunk{
-				RequestID:   requestID,
-				BuildID:     buildID,
-				JobName:     jobName,
-				JobID:       jobID,
-				ChunkIndex:  len(chunks),
-				TotalChunks: 0, // Will be set after all chunks are created
-				Content:     chunkContent,
-				LineStart:   lineStart,
-				LineEnd:     i,
-				Metadata:    copyMetadata(metadata),
-			}
-			chunks = append(chunks, chunk)
-
-			// Prepare for next chunk with overlap
-			// Keep last ContextOverlap lines as overlap buffer
-			if len(currentLines) >= ContextOverlap {
-				overlapBuffer = currentLines[len(currentLines)-ContextOverlap:]
-			} else {
-				overlapBuffer = currentLines
+			if err := flush(); err != nil {
+				return count, err
 			}
 
-			// Start new chunk with overlap
-			lineStart = i + 1 - len(overlapBuffer)
-			currentLines = make([]string, len(overlapBuffer))
-			copy(currentLines, overlapBuffer)
+			// Keep last ContextOverlap lines as overlap for the next chunk
+			overlap := currentLines[len(currentLines)-overlapLines(currentLines, stitch):]
+			lineStart = lines + 1 - len(overlap)
+			currentLines = append([]string(nil), overlap...)
 			currentSize = 0
-			for _, ol := range overlapBuffer {
+			for _, ol := range currentLines {
 				currentSize += len(ol) + 1
 			}
 		}
@@ -102,33 +114,68 @@ func ChunkLog(content string, requestID, buildID, jobName, jobID string, metadat
 		// Add current line
 		currentLines = append(currentLines, line)
 		currentSize += lineSize
+		lines++
 	}
 
 	// Add final chunk if there are remaining lines
 	if len(currentLines) > 0 {
-		chunkContent := strings.Join(currentLines, "\n")
-		chunk := contracts.LogChunk{
-			RequestID:   requestID,
-			BuildID:     buildID,
-			JobName:     jobName,
-			JobID:       jobID,
-			ChunkIndex:  len(chunks),
-			TotalChunks: 0, // Will be set below
-			Content:     chunkContent,
-			LineStart:   lineStart,
-			LineEnd:     len(lines),
-			Metadata:    copyMetadata(metadata),
+		if err := flush(); err != nil {
+			return count, err
 		}
-		chunks = append(chunks, chunk)
 	}
+	return count, nil
+}
 
-	// Update total chunks count
-	totalChunks := len(chunks)
-	for i := range chunks {
-		chunks[i].TotalChunks = totalChunks
+// overlapLines returns how many of a full chunk's last lines start the
+// next one: ContextOverlap, or with stitch, all of the stack trace the chunk
+// ends in and its header if that's more, so the trace is analyzed whole. A
+// trace over MaxStitchedLines or half a chunk gets the usual overlap.
+func overlapLines(lines []string, stitch bool) int {
+	n := min(len(lines), ContextOverlap)
+	if !stitch {
+		return n
+	}
+	start, size := len(lines), 0
+	for start > 0 && stackFrameLine.MatchString(lines[start-1]) {
+		size += len(lines[start-1]) + 1
+		if len(lines)-start >= MaxStitchedLines || size > TargetChunkSize/2 {
+			return n
+		}
+		start--
 	}
+	if start == len(lines) || start == 0 {
+		return n
+	}
+	return max(n, len(lines)-start+1)
+}
 
-	return chunks
+// readLine reads the next line without its line ending ("\n" or "\r\n"),
+// keeping at most max bytes of it. It returns io.EOF only once there are no
+// more lines.
+func readLine(reader *bufio.Reader, max int) (string, error) {
+	var line []byte
+	read := false
+	for {
+		fragment, err := reader.ReadSlice('\n')
+		read = read || len(fragment) > 0
+		if room := max - len(line); room > 0 {
+			if len(fragment) > room {
+				fragment = fragment[:room]
+			}
+			line = append(line, fragment...)
+		}
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && !read:
+			return "", io.EOF
+		case err != nil && err != io.EOF:
+			return "", err
+		}
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		return string(line), nil
+	}
 }
 
 // copyMetadata creates a copy of the metadata map.
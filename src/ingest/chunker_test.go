@@ -1,8 +1,15 @@
 package ingest
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"strings"
 	"testing"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/contracts"
+	"destill-agent/src/sampledata"
 )
 
 func TestChunkLog_SmallContent(t *testing.T) {
@@ -121,6 +128,123 @@ func TestChunkLog_Overlap(t *testing.T) {
 	}
 }
 
+func TestOverlapLines_StackTraceStitching(t *testing.T) {
+	lines := make([]string, 200)
+	for i := range lines {
+		lines[i] = "building..."
+	}
+	lines = append(lines, "Exception in thread \"main\" java.lang.IllegalStateException: boom")
+	for i := 0; i < 80; i++ {
+		lines = append(lines, fmt.Sprintf("\tat com.acme.Cart.step%d(Cart.java:%d)", i, i))
+	}
+
+	if got := overlapLines(lines, false); got != ContextOverlap {
+		t.Errorf("overlapLines() without stitching = %d, want %d", got, ContextOverlap)
+	}
+	// The 80 frames and their header go into the next chunk
+	if got := overlapLines(lines, true); got != 81 {
+		t.Errorf("overlapLines() with stitching = %d, want 81", got)
+	}
+	// A chunk that doesn't end in a trace keeps the usual overlap
+	if got := overlapLines(append(lines, "done"), true); got != ContextOverlap {
+		t.Errorf("overlapLines() after the trace = %d, want %d", got, ContextOverlap)
+	}
+}
+
+func TestChunkStream_MatchesChunkLog(t *testing.T) {
+	var lines []string
+	for i := 0; i < 2000; i++ {
+		lines = append(lines, strings.Repeat("y", 700))
+	}
+	content := strings.Join(lines, "\n")
+	want := ChunkLog(content, "req-1", "build-1", "job1", "job-id-1", nil)
+
+	var got []contracts.LogChunk
+	n, err := ChunkStream(strings.NewReader(content), "req-1", "build-1", "job1", "job-id-1", nil, func(chunk contracts.LogChunk) error {
+		got = append(got, chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ChunkStream() error = %v", err)
+	}
+	if n != len(want) || len(got) != len(want) {
+		t.Fatalf("ChunkStream() = %d chunks (%d emitted), want %d", n, len(got), len(want))
+	}
+	for i := range want {
+		if got[i].TotalChunks != 0 {
+			t.Errorf("chunk %d TotalChunks = %d, want 0 while streaming", i, got[i].TotalChunks)
+		}
+		if got[i].Content != want[i].Content || got[i].LineStart != want[i].LineStart || got[i].LineEnd != want[i].LineEnd {
+			t.Errorf("chunk %d = lines %d-%d, want lines %d-%d as ChunkLog", i, got[i].LineStart, got[i].LineEnd, want[i].LineStart, want[i].LineEnd)
+		}
+	}
+}
+
+func TestChunkStream_LongLinesAndCRLF(t *testing.T) {
+	content := "first\r\n" + strings.Repeat("z", TargetChunkSize+100) + "\r\nlast"
+
+	var got []contracts.LogChunk
+	if _, err := ChunkStream(strings.NewReader(content), "req-1", "build-1", "job1", "job-id-1", nil, func(chunk contracts.LogChunk) error {
+		got = append(got, chunk)
+		return nil
+	}); err != nil {
+		t.Fatalf("ChunkStream() error = %v", err)
+	}
+
+	var lines []string
+	for _, chunk := range got {
+		lines = append(lines, strings.Split(chunk.Content, "\n")...)
+	}
+	if lines[0] != "first" || lines[len(lines)-1] != "last" {
+		t.Errorf("first and last lines = %q, %q, want CR stripped", lines[0], lines[len(lines)-1])
+	}
+	for _, line := range lines {
+		if len(line) > TargetChunkSize {
+			t.Errorf("line of %d bytes, want at most %d", len(line), TargetChunkSize)
+		}
+	}
+}
+
+// failingReader returns its content, then err.
+type failingReader struct {
+	content io.Reader
+	err     error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.content.Read(p)
+	if err == io.EOF {
+		return n, r.err
+	}
+	return n, err
+}
+
+func TestChunkStream_Errors(t *testing.T) {
+	var lines []string
+	for i := 0; i < 2000; i++ {
+		lines = append(lines, strings.Repeat("w", 700))
+	}
+	content := strings.Join(lines, "\n")
+	emit := func(contracts.LogChunk) error { return nil }
+
+	// Chunks completed before a read error have been emitted
+	readErr := errors.New("connection reset")
+	n, err := ChunkStream(&failingReader{content: strings.NewReader(content), err: readErr}, "req-1", "build-1", "job1", "job-id-1", nil, emit)
+	if !errors.Is(err, readErr) {
+		t.Errorf("ChunkStream() error = %v, want %v", err, readErr)
+	}
+	if n == 0 {
+		t.Error("ChunkStream() emitted no chunks before the read error")
+	}
+
+	// An emit error stops the stream
+	emitErr := errors.New("broker closed")
+	n, err = ChunkStream(strings.NewReader(content), "req-1", "build-1", "job1", "job-id-1", nil, func(contracts.LogChunk) error { return emitErr })
+	if !errors.Is(err, emitErr) || n != 1 {
+		t.Errorf("ChunkStream() = %d, %v, want 1, %v", n, err, emitErr)
+	}
+}
+
 func TestChunkLog_Metadata(t *testing.T) {
 	metadata := map[string]string{
 		"key1": "value1",
@@ -198,3 +322,21 @@ func TestChunkLog_LineNumbers(t *testing.T) {
 		}
 	}
 }
+
+// BenchmarkChunkAndAnalyze chunks and analyzes a generated build, as the
+// ingest and analyze agents do for every job.
+func BenchmarkChunkAndAnalyze(b *testing.B) {
+	build, err := sampledata.Generate(sampledata.Options{Seed: 1, Jobs: 4, LinesPerJob: 20000, ErrorDensity: 0.01, FailedJobs: 2})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, job := range build.Jobs {
+			for _, chunk := range ChunkLog(build.Logs[job.ID], "req-bench", build.ID, job.Name, job.ID, nil) {
+				analyze.AnalyzeChunk(chunk)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package ingest
+
+import (
+	"regexp"
+	"strings"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/sanitize"
+)
+
+// envScanLines is how far into a log to look for the environment dump.
+// CI systems print it while setting up the job, before any user output.
+const envScanLines = 1000
+
+// maxEnvValueLength caps extracted values so a runaway line can't bloat
+// every chunk's metadata.
+const maxEnvValueLength = 200
+
+// envVarKeys maps the environment variables CI systems print at job start
+// to runner metadata keys. Only these are extracted, so secrets in the dump
+// never reach the metadata.
+var envVarKeys = map[string]string{
+	// Buildkite
+	"BUILDKITE_AGENT_NAME":            contracts.MetadataRunnerName,
+	"BUILDKITE_AGENT_META_DATA_QUEUE": contracts.MetadataRunnerQueue,
+	"BUILDKITE_PLUGIN_DOCKER_IMAGE":   contracts.MetadataContainerImage,
+
+	// GitHub Actions
+	"RUNNER_NAME": contracts.MetadataRunnerName,
+	"RUNNER_OS":   contracts.MetadataRunnerOS,
+	"ImageOS":     contracts.MetadataRunnerImage,
+
+	// GitLab CI
+	"CI_RUNNER_DESCRIPTION": contracts.MetadataRunnerName,
+	"CI_RUNNER_TAGS":        contracts.MetadataRunnerLabels,
+	"CI_JOB_IMAGE":          contracts.MetadataContainerImage,
+
+	// Jenkins
+	"NODE_NAME":   contracts.MetadataRunnerName,
+	"NODE_LABELS": contracts.MetadataRunnerLabels,
+}
+
+var (
+	// logTimestampPrefix matches the ISO timestamps GitHub Actions (and GitLab,
+	// with timestamps enabled) prefixes lines with
+	logTimestampPrefix = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z\s+`)
+
+	// envAssignment matches KEY=value as printed by env, export -p, declare -x, or set -x
+	envAssignment = regexp.MustCompile(`^(?:export\s+|declare\s+-x\s+|\+\s+)?([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+
+	// setupLinePatterns match runner details printed as prose during job setup
+	setupLinePatterns = []struct {
+		re  *regexp.Regexp
+		key string
+	}{
+		{regexp.MustCompile(`^Runner name: '?([^']+)'?$`), contracts.MetadataRunnerName},                  // GitHub Actions
+		{regexp.MustCompile(`^Runner group name: '?([^']+)'?$`), contracts.MetadataRunnerQueue},           // GitHub Actions
+		{regexp.MustCompile(`^Image: (\S+)$`), contracts.MetadataRunnerImage},                             // GitHub Actions "Runner Image" group
+		{regexp.MustCompile(`^Using Docker executor with image (\S+)`), contracts.MetadataContainerImage}, // GitLab CI
+	}
+)
+
+// parseEnvMetadata extracts runner metadata (name, labels, queue, OS, and
+// images) from the environment dump at the start of a job log. The first
+// value found for a key wins.
+func parseEnvMetadata(content string) map[string]string {
+	metadata := make(map[string]string)
+	set := func(key, value string) {
+		value = unquote(strings.TrimSpace(value))
+		if value == "" || metadata[key] != "" {
+			return
+		}
+		if len(value) > maxEnvValueLength {
+			value = value[:maxEnvValueLength]
+		}
+		metadata[key] = value
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		if i >= envScanLines {
+			break
+		}
+		line = logTimestampPrefix.ReplaceAllString(sanitize.Clean(line), "")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if m := envAssignment.FindStringSubmatch(line); m != nil {
+			if key, ok := envVarKeys[m[1]]; ok {
+				set(key, m[2])
+			}
+			continue
+		}
+		for _, p := range setupLinePatterns {
+			if m := p.re.FindStringSubmatch(line); m != nil {
+				set(p.key, m[1])
+				break
+			}
+		}
+	}
+	return metadata
+}
+
+// unquote removes one pair of matching single or double quotes.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,73 @@
+package ingest
+
+import (
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestParseEnvMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want map[string]string
+	}{
+		{
+			name: "buildkite env dump",
+			log: "\x1b_bk;t=1700000000000\x07~~~ Preparing working directory\n" +
+				"\x1b_bk;t=1700000000001\x07BUILDKITE_AGENT_NAME=\"ci-agent-gpu-3\"\n" +
+				"BUILDKITE_AGENT_META_DATA_QUEUE=gpu\n" +
+				"BUILDKITE_AGENT_ACCESS_TOKEN=secret\n" +
+				"BUILDKITE_PLUGIN_DOCKER_IMAGE=golang:1.22\n",
+			want: map[string]string{
+				contracts.MetadataRunnerName:     "ci-agent-gpu-3",
+				contracts.MetadataRunnerQueue:    "gpu",
+				contracts.MetadataContainerImage: "golang:1.22",
+			},
+		},
+		{
+			name: "github actions setup",
+			log: "2024-01-01T00:00:00.0000000Z Current runner version: '2.311.0'\n" +
+				"2024-01-01T00:00:00.0000000Z Runner name: 'GitHub Actions 5'\n" +
+				"2024-01-01T00:00:00.0000000Z Runner group name: 'large-runners'\n" +
+				"2024-01-01T00:00:00.0000000Z ##[group]Runner Image\n" +
+				"2024-01-01T00:00:00.0000000Z   Image: ubuntu-22.04\n" +
+				"2024-01-01T00:00:01.0000000Z + RUNNER_OS=Linux\n",
+			want: map[string]string{
+				contracts.MetadataRunnerName:  "GitHub Actions 5",
+				contracts.MetadataRunnerQueue: "large-runners",
+				contracts.MetadataRunnerImage: "ubuntu-22.04",
+				contracts.MetadataRunnerOS:    "Linux",
+			},
+		},
+		{
+			name: "gitlab and jenkins",
+			log: "Using Docker executor with image node:20 ...\n" +
+				"export CI_RUNNER_TAGS='[\"docker\", \"linux\"]'\n" +
+				"declare -x NODE_LABELS=\"linux arm64\"\n",
+			want: map[string]string{
+				contracts.MetadataContainerImage: "node:20",
+				contracts.MetadataRunnerLabels:   `["docker", "linux"]`,
+			},
+		},
+		{
+			name: "no env dump",
+			log:  "npm ERR! code ELIFECYCLE\n",
+			want: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseEnvMetadata(tt.log)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseEnvMetadata() = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("parseEnvMetadata()[%s] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
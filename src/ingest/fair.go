@@ -0,0 +1,182 @@
+package ingest
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/provider"
+)
+
+// fairQueue buffers pending requests per pipeline and hands them out
+// round-robin, so a pipeline that submits many builds at once doesn't
+// delay everyone else's. push blocks while maxPending requests are queued.
+// Prioritized requests go before all others.
+type fairQueue struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	pending  map[string][]broker.Message
+	order    []string        // Pipelines with pending requests, next turn first
+	urgent   []urgentRequest // Prioritized requests, first prioritized first
+	inflight map[string]int
+	size     int
+
+	maxPending  int
+	perPipeline int // Max requests in flight per pipeline; 0 = no limit
+
+	closed  bool // No more pushes; pop drains what's left
+	aborted bool // Stop now; pending requests are dropped
+}
+
+// urgentRequest is a prioritized request and its pipeline.
+type urgentRequest struct {
+	key string
+	msg broker.Message
+}
+
+func newFairQueue(maxPending, perPipeline int) *fairQueue {
+	q := &fairQueue{
+		pending:     make(map[string][]broker.Message),
+		inflight:    make(map[string]int),
+		maxPending:  maxPending,
+		perPipeline: perPipeline,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues a request for a pipeline. Returns false if the queue was aborted.
+func (q *fairQueue) push(key string, msg broker.Message) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for q.maxPending > 0 && q.size >= q.maxPending && !q.aborted {
+		q.cond.Wait()
+	}
+	if q.aborted {
+		return false
+	}
+
+	if len(q.pending[key]) == 0 {
+		q.order = append(q.order, key)
+	}
+	q.pending[key] = append(q.pending[key], msg)
+	q.size++
+	q.cond.Broadcast()
+	return true
+}
+
+// prioritize moves a pending request, by ID (its message key), ahead of
+// every request not prioritized. Returns false if it isn't pending.
+func (q *fairQueue) prioritize(requestID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for key, msgs := range q.pending {
+		i := slices.IndexFunc(msgs, func(msg broker.Message) bool { return msg.Key == requestID })
+		if i < 0 {
+			continue
+		}
+		q.urgent = append(q.urgent, urgentRequest{key: key, msg: msgs[i]})
+		q.pending[key] = slices.Delete(msgs, i, i+1)
+		if len(q.pending[key]) == 0 {
+			delete(q.pending, key)
+			q.order = slices.DeleteFunc(q.order, func(k string) bool { return k == key })
+		}
+		q.cond.Broadcast()
+		return true
+	}
+	return false
+}
+
+// pop returns the next request: a prioritized one, or else taking turns
+// between pipelines and skipping pipelines at their in-flight limit.
+// Prioritized requests skip the limit. Blocks until a request is
+// available; returns false once the queue is closed and drained, or
+// aborted. Call done with the returned key when the request has been
+// processed.
+func (q *fairQueue) pop() (string, broker.Message, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if q.aborted || (q.closed && q.size == 0) {
+			return "", broker.Message{}, false
+		}
+
+		if len(q.urgent) > 0 {
+			next := q.urgent[0]
+			q.urgent = q.urgent[1:]
+			q.inflight[next.key]++
+			q.size--
+			q.cond.Broadcast()
+			return next.key, next.msg, true
+		}
+
+		for i, key := range q.order {
+			if q.perPipeline > 0 && q.inflight[key] >= q.perPipeline {
+				continue
+			}
+
+			msg := q.pending[key][0]
+			q.pending[key] = q.pending[key][1:]
+			q.order = append(q.order[:i], q.order[i+1:]...)
+			if len(q.pending[key]) > 0 {
+				q.order = append(q.order, key) // Back of the line
+			} else {
+				delete(q.pending, key)
+			}
+			q.inflight[key]++
+			q.size--
+			q.cond.Broadcast()
+			return key, msg, true
+		}
+
+		q.cond.Wait()
+	}
+}
+
+// done marks a request returned by pop as processed.
+func (q *fairQueue) done(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.inflight[key]--; q.inflight[key] <= 0 {
+		delete(q.inflight, key)
+	}
+	q.cond.Broadcast()
+}
+
+// close stops accepting requests; pop still returns the ones already queued.
+func (q *fairQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// abort wakes every waiter and drops the queued requests.
+func (q *fairQueue) abort() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.aborted = true
+	q.cond.Broadcast()
+}
+
+// requestPipeline returns the scheduling key of a request: its provider and
+// pipeline. Requests that can't be parsed share the empty key and fail later
+// in processRequest with a proper error.
+func requestPipeline(msg broker.Message) string {
+	var request contracts.AnalysisRequest
+	if err := json.Unmarshal(msg.Value, &request); err != nil {
+		return ""
+	}
+	ref, err := provider.ParseURL(request.BuildURL)
+	if err != nil {
+		return ""
+	}
+	return ref.Provider + ":" + provider.PipelineName(ref)
+}
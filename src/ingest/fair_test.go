@@ -0,0 +1,179 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/logger"
+)
+
+func TestFairQueue_RoundRobin(t *testing.T) {
+	q := newFairQueue(0, 0)
+	for _, item := range []struct{ key, id string }{
+		{"monorepo", "m1"}, {"monorepo", "m2"}, {"monorepo", "m3"},
+		{"api", "a1"}, {"web", "w1"}, {"api", "a2"},
+	} {
+		q.push(item.key, broker.Message{Key: item.id})
+	}
+	q.close()
+
+	var got []string
+	for {
+		key, msg, ok := q.pop()
+		if !ok {
+			break
+		}
+		got = append(got, msg.Key)
+		q.done(key)
+	}
+
+	want := []string{"m1", "a1", "w1", "m2", "a2", "m3"}
+	if len(got) != len(want) {
+		t.Fatalf("pop order = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("pop order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFairQueue_PerPipelineLimit(t *testing.T) {
+	q := newFairQueue(0, 1)
+	q.push("monorepo", broker.Message{Key: "m1"})
+	q.push("monorepo", broker.Message{Key: "m2"})
+
+	key, msg, _ := q.pop()
+	if msg.Key != "m1" {
+		t.Fatalf("first pop = %s, want m1", msg.Key)
+	}
+
+	// m2 must wait until m1 is done, but another pipeline can go first
+	popped := make(chan string, 1)
+	go func() {
+		_, msg, _ := q.pop()
+		popped <- msg.Key
+	}()
+	select {
+	case id := <-popped:
+		t.Fatalf("pop returned %s while monorepo was at its limit", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	q.push("api", broker.Message{Key: "a1"})
+	if id := <-popped; id != "a1" {
+		t.Fatalf("pop = %s, want a1", id)
+	}
+
+	q.done(key)
+	if _, msg, _ := q.pop(); msg.Key != "m2" {
+		t.Fatalf("pop after done = %s, want m2", msg.Key)
+	}
+}
+
+func TestFairQueue_Abort(t *testing.T) {
+	q := newFairQueue(1, 0)
+	q.push("api", broker.Message{Key: "a1"})
+
+	pushed := make(chan bool, 1)
+	go func() { pushed <- q.push("api", broker.Message{Key: "a2"}) }()
+
+	q.abort()
+	if <-pushed {
+		t.Error("push into a full, aborted queue succeeded")
+	}
+	if _, _, ok := q.pop(); ok {
+		t.Error("pop from an aborted queue returned a request")
+	}
+}
+
+func TestFairQueue_Prioritize(t *testing.T) {
+	q := newFairQueue(0, 1)
+	for _, item := range []struct{ key, id string }{
+		{"monorepo", "m1"}, {"monorepo", "m2"}, {"monorepo", "m3"}, {"api", "a1"},
+	} {
+		q.push(item.key, broker.Message{Key: item.id})
+	}
+
+	key, msg, _ := q.pop()
+	if msg.Key != "m1" {
+		t.Fatalf("first pop = %s, want m1", msg.Key)
+	}
+
+	// m3 jumps the queue, and monorepo's in-flight limit
+	if !q.prioritize("m3") {
+		t.Fatal("prioritize(m3) = false, want true")
+	}
+	if q.prioritize("m1") || q.prioritize("missing") {
+		t.Error("prioritize() of a request not pending = true")
+	}
+	if _, msg, _ := q.pop(); msg.Key != "m3" {
+		t.Errorf("pop after prioritize = %s, want m3", msg.Key)
+	}
+	if _, msg, _ := q.pop(); msg.Key != "a1" {
+		t.Errorf("next pop = %s, want a1", msg.Key)
+	}
+	q.done(key)
+	q.done(key)
+	q.close()
+	if _, msg, _ := q.pop(); msg.Key != "m2" {
+		t.Errorf("last pop = %s, want m2", msg.Key)
+	}
+	if _, _, ok := q.pop(); ok {
+		t.Error("pop from a drained queue returned a request")
+	}
+}
+
+func TestAgent_PrioritizeFrom(t *testing.T) {
+	q := newFairQueue(0, 0)
+	q.push("monorepo", broker.Message{Key: "m1"})
+	q.push("monorepo", broker.Message{Key: "m2"})
+	q.push("monorepo", broker.Message{Key: "m3"})
+
+	started := time.Now()
+	control := make(chan broker.Message, 3)
+	for _, c := range []contracts.ControlMessage{
+		{Type: contracts.ControlPrioritize, RequestID: "m2", Timestamp: started.Add(-time.Hour).Format(time.RFC3339)}, // Replayed
+		{Type: contracts.ControlAnalysisComplete, RequestID: "m2"},
+		{Type: contracts.ControlPrioritize, RequestID: "m3", Timestamp: started.Format(time.RFC3339)},
+	} {
+		data, _ := json.Marshal(c)
+		control <- broker.Message{Topic: contracts.TopicControl, Value: data}
+	}
+	close(control)
+
+	agent := NewAgent(broker.NewInMemoryBroker(), logger.NewSilentLogger())
+	agent.prioritizeFrom(context.Background(), control, q, started)
+
+	if _, msg, _ := q.pop(); msg.Key != "m3" {
+		t.Errorf("first pop = %s, want the prioritized m3", msg.Key)
+	}
+	if _, msg, _ := q.pop(); msg.Key != "m1" {
+		t.Errorf("second pop = %s, want m1", msg.Key)
+	}
+}
+
+func TestRequestPipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		buildURL string
+		want     string
+	}{
+		{"buildkite", "https://buildkite.com/org/pipeline/builds/1", "buildkite:org/pipeline"},
+		{"github", "https://github.com/owner/repo/actions/runs/2", "github:owner/repo"},
+		{"unparseable", "not a url", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := json.Marshal(contracts.AnalysisRequest{RequestID: "req-1", BuildURL: tt.buildURL})
+			if got := requestPipeline(broker.Message{Value: data}); got != tt.want {
+				t.Errorf("requestPipeline() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
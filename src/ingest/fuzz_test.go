@@ -0,0 +1,72 @@
+package ingest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/fuzzlimit"
+)
+
+// fuzzTimeLimit allows for the several chunks of the logs FuzzChunkLog
+// builds (see fuzzlimit.PerInput).
+const fuzzTimeLimit = 5 * fuzzlimit.PerInput
+
+// fuzzMaxLogSize caps the logs FuzzChunkLog builds, to keep runs fast.
+const fuzzMaxLogSize = 4 * TargetChunkSize
+
+// FuzzChunkLog chunks a log of one line repeated, so small inputs still
+// reach multi-chunk logs, and checks that the chunks cover every line.
+func FuzzChunkLog(f *testing.F) {
+	f.Add("", uint16(1))
+	f.Add("ERROR: connection refused", uint16(1))
+	f.Add("line with a windows ending\r", uint16(30000))
+	f.Add("\n\n", uint16(40000))
+	f.Add(strings.Repeat("x", 70*1024), uint16(10)) // Lines over bufio's default limit
+	f.Add("no trailing newline", uint16(0))
+
+	f.Fuzz(func(t *testing.T, line string, count uint16) {
+		n := int(count)
+		if len(line) > 0 && n > fuzzMaxLogSize/len(line) {
+			n = fuzzMaxLogSize / len(line)
+		}
+		content := strings.Repeat(line+"\n", n) + line
+
+		var chunks []contracts.LogChunk
+		fuzzlimit.Check(t, fuzzTimeLimit, fmt.Sprintf("ChunkLog(%d bytes)", len(content)), func() {
+			chunks = ChunkLog(content, "req-1", "build-1", "job", "job-1", nil)
+		})
+
+		if content == "" {
+			if len(chunks) != 0 {
+				t.Errorf("ChunkLog(\"\") = %d chunks, want none", len(chunks))
+			}
+			return
+		}
+		if len(chunks) == 0 {
+			t.Fatalf("ChunkLog(%d bytes) = no chunks", len(content))
+		}
+		if chunks[0].LineStart != 1 {
+			t.Errorf("first chunk starts at line %d, want 1", chunks[0].LineStart)
+		}
+		for i, chunk := range chunks {
+			if chunk.ChunkIndex != i || chunk.TotalChunks != len(chunks) {
+				t.Errorf("chunk %d is %d/%d, want %d/%d", i, chunk.ChunkIndex, chunk.TotalChunks, i, len(chunks))
+			}
+			if chunk.LineEnd < chunk.LineStart {
+				t.Errorf("chunk %d covers lines %d-%d", i, chunk.LineStart, chunk.LineEnd)
+			}
+			if i > 0 && chunk.LineStart > chunks[i-1].LineEnd+1 {
+				t.Errorf("chunk %d starts at line %d, after a gap from line %d", i, chunk.LineStart, chunks[i-1].LineEnd)
+			}
+		}
+		lines := strings.Count(content, "\n")
+		if !strings.HasSuffix(content, "\n") {
+			lines++
+		}
+		if last := chunks[len(chunks)-1]; last.LineEnd != lines {
+			t.Errorf("chunks end at line %d of %d", last.LineEnd, lines)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/contracts"
+	"destill-agent/src/provider"
+)
+
+// interruptTailLines is how far from the end of a failed job's log the
+// runner's timeout or cancellation marker is looked for.
+const interruptTailLines = 20
+
+// interruptedGuidance is the remediation of each kind of interrupted job.
+var interruptedGuidance = map[string]contracts.Remediation{
+	contracts.InterruptedTimeout: {
+		Name:        "job-timeout",
+		Fix:         "Find what hung or slowed down in the last steps before the timeout, or raise the job's timeout if its work grew",
+		Description: "CI stopped the job at its time limit; the errors printed as it was killed are symptoms, not the cause",
+	},
+	contracts.InterruptedCanceled: {
+		Name:        "job-canceled",
+		Fix:         "Re-run the job; it was likely canceled by hand, by a newer build, or by another job failing fast",
+		Description: "CI canceled the job before it finished; its log ends wherever it was stopped",
+	},
+}
+
+// interruption classifies a job CI stopped before it finished, by its state
+// or, for a failed job or one of unknown outcome, by the marker its runner
+// printed near the end of its log: contracts.InterruptedTimeout, contracts.InterruptedCanceled, or
+// "" for a job that ran to its end. marker is the runner's line, if found.
+func interruption(job provider.Job, sections []logSection) (kind, marker string) {
+	switch job.State {
+	case "timed_out", "timing_out", "timedout":
+		kind = contracts.InterruptedTimeout
+	case "canceled", "canceling", "cancelled":
+		kind = contracts.InterruptedCanceled
+	}
+	passed := !jobFailed(job) && job.State != provider.JobStateUnknown
+	if (kind == "" && passed) || len(sections) == 0 {
+		return kind, ""
+	}
+
+	lines := strings.Split(strings.TrimRight(sections[len(sections)-1].content, "\n"), "\n")
+	for i := len(lines) - 1; i >= max(len(lines)-interruptTailLines, 0); i-- {
+		if found := analyze.InterruptionMarker(lines[i]); found != "" {
+			if kind == "" {
+				kind = found
+			}
+			return kind, strings.TrimSpace(lines[i])
+		}
+	}
+	return kind, ""
+}
+
+// interruptedCard builds the finding for a job CI timed out or canceled.
+// The message hash leaves out the marker, so the finding matches across
+// builds.
+func interruptedCard(requestID string, job provider.Job, kind, marker string, jobMetadata map[string]string) contracts.TriageCard {
+	normalized := fmt.Sprintf("Job %q was canceled", job.Name)
+	findingType, severity, confidence := contracts.FindingTypeJobCanceled, "WARN", 0.5
+	if kind == contracts.InterruptedTimeout {
+		normalized = fmt.Sprintf("Job %q timed out", job.Name)
+		findingType, severity, confidence = contracts.FindingTypeJobTimeout, "ERROR", 0.8
+	}
+	messageHash := analyze.CalculateMessageHash(normalized)
+
+	metadata := make(map[string]string, len(jobMetadata)+1)
+	for k, v := range jobMetadata {
+		metadata[k] = v
+	}
+	metadata[contracts.MetadataFindingType] = findingType
+
+	raw := fmt.Sprintf("%s (state: %s)", normalized, job.State)
+	if marker != "" {
+		raw = normalized + ": " + marker
+	}
+	guidance := interruptedGuidance[kind]
+
+	return contracts.TriageCard{
+		ID:              fmt.Sprintf("%s-%s-interrupted", metadata["job_id"], messageHash[:8]),
+		RequestID:       requestID,
+		MessageHash:     messageHash,
+		Source:          metadata["provider"],
+		JobName:         job.Name,
+		BuildURL:        metadata["build_url"],
+		Severity:        severity,
+		RawMessage:      raw,
+		NormalizedMsg:   normalized,
+		ConfidenceScore: confidence,
+		Metadata:        metadata,
+		Remediation:     &guidance,
+		Timestamp:       time.Now().Format(time.RFC3339),
+	}
+}
+
+// publishInterruption publishes the finding for an interrupted job, unless
+// it's below the request's minimum confidence. Returns how many findings
+// it published.
+func (a *Agent) publishInterruption(ctx context.Context, request contracts.AnalysisRequest, card contracts.TriageCard) int {
+	if request.Options != nil && card.ConfidenceScore < request.Options.MinConfidence {
+		return 0
+	}
+	a.logger.Info("[IngestAgent] %s", card.RawMessage)
+	data, err := json.Marshal(card)
+	if err != nil {
+		a.logger.Error("[IngestAgent] Failed to marshal interrupted job finding: %v", err)
+		return 0
+	}
+	if err := a.broker.Publish(ctx, contracts.TopicAnalysisFindings, request.RequestID, data); err != nil {
+		a.logger.Error("[IngestAgent] Failed to publish interrupted job finding: %v", err)
+		return 0
+	}
+	return 1
+}
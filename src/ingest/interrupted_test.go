@@ -0,0 +1,106 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/logger"
+	"destill-agent/src/provider"
+)
+
+func TestInterruption(t *testing.T) {
+	timeoutLog := []logSection{{content: "running tests\n##[error]The job running on runner gh-7 has exceeded the maximum execution time of 60 minutes.\n"}}
+	canceledLog := []logSection{{content: "compiling\n##[error]The operation was canceled.\n"}}
+
+	tests := []struct {
+		name       string
+		job        provider.Job
+		sections   []logSection
+		wantKind   string
+		wantMarker bool
+	}{
+		{name: "timed out state", job: provider.Job{State: "timed_out"}, wantKind: contracts.InterruptedTimeout},
+		{name: "canceled state", job: provider.Job{State: "canceled"}, sections: canceledLog, wantKind: contracts.InterruptedCanceled, wantMarker: true},
+		{name: "failed with timeout marker", job: provider.Job{State: "failed", ExitCode: 1}, sections: timeoutLog, wantKind: contracts.InterruptedTimeout, wantMarker: true},
+		{name: "unknown outcome with marker", job: provider.Job{State: provider.JobStateUnknown}, sections: canceledLog, wantKind: contracts.InterruptedCanceled, wantMarker: true},
+		{name: "genuine failure", job: provider.Job{State: "failed", ExitCode: 1}, sections: []logSection{{content: "FAIL: TestCart\n"}}},
+		{name: "passed job's marker ignored", job: provider.Job{State: "passed"}, sections: timeoutLog},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, marker := interruption(tt.job, tt.sections)
+			if kind != tt.wantKind || (marker != "") != tt.wantMarker {
+				t.Errorf("interruption() = %q, %q, want %q (marker %v)", kind, marker, tt.wantKind, tt.wantMarker)
+			}
+		})
+	}
+}
+
+func TestAgent_PublishesInterruptedJobFinding(t *testing.T) {
+	dir := t.TempDir()
+	log := "2024-01-15T10:00:00Z go test ./...\n" +
+		"2024-01-15T11:00:00Z signal: killed\n" +
+		"2024-01-15T11:00:00Z ##[error]The job running on runner gh-7 has exceeded the maximum execution time of 60 minutes.\n"
+	if err := os.WriteFile(filepath.Join(dir, "integration.log"), []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	buildURL, err := provider.LocalDirURL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	findings, err := brk.Subscribe(ctx, contracts.TopicAnalysisFindings, "test-consumer")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	chunks, err := brk.Subscribe(ctx, contracts.TopicLogsRaw, "test-consumer")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	data, _ := json.Marshal(contracts.AnalysisRequest{RequestID: "req-timeout", BuildURL: buildURL})
+	agent := NewAgent(brk, logger.NewSilentLogger())
+	if err := agent.processRequest(ctx, broker.Message{Topic: contracts.TopicRequests, Value: data}); err != nil {
+		t.Fatalf("processRequest() error = %v", err)
+	}
+
+	select {
+	case msg := <-findings:
+		var card contracts.TriageCard
+		if err := json.Unmarshal(msg.Value, &card); err != nil {
+			t.Fatalf("Failed to unmarshal finding: %v", err)
+		}
+		if card.NormalizedMsg != `Job "integration" timed out` || card.Metadata[contracts.MetadataFindingType] != contracts.FindingTypeJobTimeout {
+			t.Errorf("finding = %q (%s), want the integration job's timeout", card.NormalizedMsg, card.Metadata[contracts.MetadataFindingType])
+		}
+		if card.Remediation == nil || card.Remediation.Name != "job-timeout" {
+			t.Errorf("Remediation = %+v, want job-timeout guidance", card.Remediation)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for interrupted job finding")
+	}
+
+	select {
+	case msg := <-chunks:
+		var chunk contracts.LogChunk
+		if err := json.Unmarshal(msg.Value, &chunk); err != nil {
+			t.Fatalf("Failed to unmarshal chunk: %v", err)
+		}
+		if chunk.Metadata[contracts.MetadataInterrupted] != contracts.InterruptedTimeout {
+			t.Errorf("chunk metadata = %v, want interrupted=timeout", chunk.Metadata)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for log chunk")
+	}
+}
@@ -0,0 +1,118 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/contracts"
+	"destill-agent/src/provider"
+)
+
+// MaxJUnitReportSize is the largest JUnit report artifact downloaded.
+const MaxJUnitReportSize = 10 << 20
+
+// isJUnitReport reports whether an artifact path looks like a JUnit XML
+// report: an .xml file with "junit" or "test" in its path, e.g.
+// "test-results/junit.xml" or "target/surefire-reports/TEST-Cart.xml".
+func isJUnitReport(artifactPath string) bool {
+	lower := strings.ToLower(artifactPath)
+	return path.Ext(lower) == ".xml" && (strings.Contains(lower, "junit") || strings.Contains(lower, "test"))
+}
+
+// junitFailureCards downloads the JUnit report artifacts of a failed job
+// and returns a finding for each failed test in them. Findings use the same
+// message as the log finding for the test (see analyze.TestFailureMessage),
+// so readers deduplicate the two.
+func (a *Agent) junitFailureCards(ctx context.Context, prov provider.Provider, requestID string, job provider.Job, artifacts []provider.Artifact, metadata map[string]string) []contracts.TriageCard {
+	var cards []contracts.TriageCard
+	for _, artifact := range artifacts {
+		if !isJUnitReport(artifact.Path) || artifact.FileSize > MaxJUnitReportSize {
+			continue
+		}
+		data, err := prov.DownloadArtifact(ctx, artifact)
+		if err != nil {
+			a.logger.Error("[IngestAgent] Failed to download test report %s of job %s: %v", artifact.Path, job.Name, err)
+			continue
+		}
+		failures, err := analyze.ParseJUnitXML(data)
+		if err != nil {
+			a.logger.Debug("[IngestAgent] Skipping artifact %s of job %s: %v", artifact.Path, job.Name, err)
+			continue
+		}
+		for _, failure := range failures {
+			cards = append(cards, junitFailureCard(requestID, job.Name, artifact.Path, failure, metadata))
+		}
+	}
+	return cards
+}
+
+// publishJUnitFailures publishes the findings of a failed job's JUnit
+// reports, leaving out those below the request's minimum confidence and
+// those the baseline build also has. Returns how many it published.
+func (a *Agent) publishJUnitFailures(ctx context.Context, prov provider.Provider, request contracts.AnalysisRequest, job provider.Job, artifacts []provider.Artifact, metadata map[string]string, baseline []string) int {
+	inBaseline := make(map[string]bool, len(baseline))
+	for _, hash := range baseline {
+		inBaseline[hash] = true
+	}
+
+	published := 0
+	for _, card := range a.junitFailureCards(ctx, prov, request.RequestID, job, artifacts, metadata) {
+		if request.Options != nil && card.ConfidenceScore < request.Options.MinConfidence {
+			continue
+		}
+		if inBaseline[card.MessageHash] {
+			continue
+		}
+		data, err := json.Marshal(card)
+		if err != nil {
+			a.logger.Error("[IngestAgent] Failed to marshal test report finding: %v", err)
+			continue
+		}
+		if err := a.broker.Publish(ctx, contracts.TopicAnalysisFindings, request.RequestID, data); err != nil {
+			a.logger.Error("[IngestAgent] Failed to publish test report finding: %v", err)
+			continue
+		}
+		published++
+	}
+	return published
+}
+
+// junitFailureCard builds the finding for a failed test in a JUnit report.
+// The assertion output stands in for the log context.
+func junitFailureCard(requestID, jobName, reportPath string, failure analyze.TestFailure, jobMetadata map[string]string) contracts.TriageCard {
+	normalized := analyze.TestFailureMessage(failure.Name)
+	messageHash := analyze.CalculateMessageHash(normalized)
+
+	metadata := make(map[string]string, len(jobMetadata)+5)
+	for k, v := range jobMetadata {
+		metadata[k] = v
+	}
+	analyze.SetTestFailureMetadata(metadata, failure)
+	metadata[contracts.MetadataTestReport] = reportPath
+
+	raw := fmt.Sprintf("Test %s failed", failure.Name)
+	if failure.Message != "" {
+		raw += ": " + failure.Message
+	}
+
+	return contracts.TriageCard{
+		ID:              fmt.Sprintf("%s-%s-junit", metadata["job_id"], messageHash[:8]),
+		RequestID:       requestID,
+		MessageHash:     messageHash,
+		Source:          metadata["provider"],
+		JobName:         jobName,
+		BuildURL:        metadata["build_url"],
+		Severity:        "ERROR",
+		RawMessage:      raw,
+		NormalizedMsg:   normalized,
+		ConfidenceScore: analyze.TestFailureConfidence,
+		PostContext:     failure.Output,
+		Metadata:        metadata,
+		Timestamp:       time.Now().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,58 @@
+package ingest
+
+import (
+	"testing"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/contracts"
+)
+
+func TestIsJUnitReport(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"test-results/junit.xml", true},
+		{"target/surefire-reports/TEST-org.acme.CartTest.xml", true},
+		{"reports/JUnit-report.XML", true},
+		{"coverage/cobertura.xml", false},
+		{"test-results/output.log", false},
+		{"pom.xml", false},
+	}
+
+	for _, tt := range tests {
+		if got := isJUnitReport(tt.path); got != tt.want {
+			t.Errorf("isJUnitReport(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestJUnitFailureCard(t *testing.T) {
+	failure := analyze.TestFailure{
+		Framework: contracts.TestFrameworkJUnit,
+		Name:      "TestTotal",
+		Line:      -1,
+		Message:   "total = 12, want 10",
+		Output:    []string{"cart_test.go:40: total = 12, want 10"},
+	}
+	metadata := map[string]string{"job_id": "job-1", "provider": "buildkite", "build_url": "https://buildkite.com/acme/cart/builds/7"}
+
+	card := junitFailureCard("req-1", "unit tests", "test-results/junit.xml", failure, metadata)
+
+	if card.NormalizedMsg != analyze.TestFailureMessage("TestTotal") {
+		t.Errorf("NormalizedMsg = %q, want the log finding's message", card.NormalizedMsg)
+	}
+	if card.RawMessage != "Test TestTotal failed: total = 12, want 10" {
+		t.Errorf("RawMessage = %q", card.RawMessage)
+	}
+	if card.Metadata[contracts.MetadataTestReport] != "test-results/junit.xml" ||
+		card.Metadata[contracts.MetadataFindingType] != contracts.FindingTypeTestFailure {
+		t.Errorf("Metadata = %v", card.Metadata)
+	}
+	if _, ok := metadata[contracts.MetadataTestReport]; ok {
+		t.Error("junitFailureCard modified the job metadata")
+	}
+	if card.BuildURL != metadata["build_url"] || card.Source != "buildkite" {
+		t.Errorf("BuildURL, Source = %q, %q", card.BuildURL, card.Source)
+	}
+}
@@ -0,0 +1,139 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultMaxLogMB is the most of each job's log read, in megabytes, so
+	// a pathological log can't flood the pipeline.
+	DefaultMaxLogMB = 256
+
+	// DefaultMaxChunks is the most log chunks published for one request.
+	DefaultMaxChunks = 5000
+
+	// EnvMaxLogMB overrides DefaultMaxLogMB; 0 reads logs whole.
+	EnvMaxLogMB = "DESTILL_MAX_LOG_MB"
+
+	// EnvMaxChunks overrides DefaultMaxChunks; 0 publishes every chunk.
+	EnvMaxChunks = "DESTILL_MAX_CHUNKS"
+)
+
+// Limits bound how much of a build the agent ingests. A request that hits
+// one is still analyzed, and its ControlIngestComplete says what was cut.
+type Limits struct {
+	MaxLogBytes int64 // Of each job's log; 0 = no limit
+	MaxChunks   int   // Per request; 0 = no limit
+}
+
+// limits are the configured limits, read once from the environment.
+var limits = LimitsFromEnv()
+
+// LimitsFromEnv reads DESTILL_MAX_LOG_MB and DESTILL_MAX_CHUNKS, falling
+// back to the defaults when they're unset or invalid.
+func LimitsFromEnv() Limits {
+	return Limits{
+		MaxLogBytes: int64(intFromEnv(EnvMaxLogMB, DefaultMaxLogMB)) << 20,
+		MaxChunks:   intFromEnv(EnvMaxChunks, DefaultMaxChunks),
+	}
+}
+
+func intFromEnv(name string, fallback int) int {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: invalid %s %q, using %d\n", name, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// errChunkLimit stops publishing a request's chunks once it has
+// Limits.MaxChunks of them.
+var errChunkLimit = errors.New("chunk limit reached")
+
+// cappedReader reads at most max bytes of a log and notes whether there
+// was more to it.
+type cappedReader struct {
+	r   io.Reader
+	max int64
+	n   int64
+	cut bool
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	if c.n >= c.max {
+		// One more byte tells a log of exactly max bytes from a longer one
+		var b [1]byte
+		if n, _ := io.ReadFull(c.r, b[:]); n > 0 {
+			c.cut = true
+		}
+		return 0, io.EOF
+	}
+	if remaining := c.max - c.n; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// capSections cuts a job's log sections to max bytes in all, at the end of
+// the last whole line, dropping the sections past it. Reports whether
+// anything was cut.
+func capSections(sections []logSection, max int64) ([]logSection, bool) {
+	if max <= 0 {
+		return sections, false
+	}
+	var size int64
+	for i, section := range sections {
+		if size+int64(len(section.content)) <= max {
+			size += int64(len(section.content))
+			continue
+		}
+		content := section.content[:max-size]
+		if end := strings.LastIndexByte(content, '\n'); end >= 0 {
+			content = content[:end]
+		}
+		capped := sections[:i:i]
+		if content != "" {
+			section.content = content
+			capped = append(capped, section)
+		}
+		return capped, true
+	}
+	return sections, false
+}
+
+// formatSize formats a log size limit, in megabytes when it's whole ones.
+func formatSize(bytes int64) string {
+	if bytes%(1<<20) == 0 {
+		return fmt.Sprintf("%d MB", bytes>>20)
+	}
+	return fmt.Sprintf("%d bytes", bytes)
+}
+
+// logCutNote says a job's log was cut at the size limit.
+func logCutNote(jobName string, max int64) string {
+	return fmt.Sprintf("log of job %s cut at %s (%s)", jobName, formatSize(max), EnvMaxLogMB)
+}
+
+// chunkLimitNote says which chunks and jobs the chunk limit left out.
+func chunkLimitNote(max int, cutJob string, skippedJobs int) string {
+	note := fmt.Sprintf("stopped at %d log chunks (%s)", max, EnvMaxChunks)
+	if cutJob != "" {
+		note += fmt.Sprintf(", cutting job %s short", cutJob)
+	}
+	if skippedJobs > 0 {
+		note += fmt.Sprintf(", skipping %d later jobs", skippedJobs)
+	}
+	return note
+}
@@ -0,0 +1,119 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/logger"
+	"destill-agent/src/provider"
+)
+
+func TestCapSections(t *testing.T) {
+	sections := []logSection{{content: "step one\nok"}, {content: "step two\nERROR: boom\nmore"}, {content: "step three"}}
+
+	capped, cut := capSections(sections, 25)
+	if !cut || len(capped) != 2 || capped[1].content != "step two" {
+		t.Errorf("capSections(25) = %+v, %v; want the first section and the second's first line", capped, cut)
+	}
+	if sections[1].content != "step two\nERROR: boom\nmore" {
+		t.Error("capSections() changed the sections it was given")
+	}
+
+	if capped, cut := capSections(sections, 0); cut || len(capped) != 3 {
+		t.Errorf("capSections(no limit) = %d sections, %v; want all 3", len(capped), cut)
+	}
+	if capped, cut := capSections(sections, 1000); cut || len(capped) != 3 {
+		t.Errorf("capSections(1000) = %d sections, %v; want all 3", len(capped), cut)
+	}
+}
+
+func TestAgent_StreamJobLog_Cut(t *testing.T) {
+	agent := NewAgent(nil, logger.NewSilentLogger())
+	agent.SetLimits(Limits{MaxLogBytes: 100})
+	log := strings.Repeat("line of the log\n", 100)
+
+	var content strings.Builder
+	_, _, cut, err := agent.streamJobLog(context.Background(), &stubLogStreamer{stubProvider{log: log}}, "req-1", "build-1", provider.Job{ID: "job-1", Name: "tests"}, map[string]string{}, func(chunk contracts.LogChunk) error {
+		content.WriteString(chunk.Content)
+		return nil
+	})
+	if err != nil || !cut {
+		t.Fatalf("streamJobLog() cut = %v, error = %v; want the log cut", cut, err)
+	}
+	if content.Len() > 100 {
+		t.Errorf("streamed %d bytes, want at most 100", content.Len())
+	}
+
+	// A log of exactly the limit isn't cut
+	agent.SetLimits(Limits{MaxLogBytes: int64(len(log))})
+	if _, _, cut, _ := agent.streamJobLog(context.Background(), &stubLogStreamer{stubProvider{log: log}}, "req-1", "build-1", provider.Job{ID: "job-1", Name: "tests"}, map[string]string{}, func(contracts.LogChunk) error { return nil }); cut {
+		t.Error("streamJobLog() cut a log of exactly the limit")
+	}
+}
+
+func TestAgent_ProcessRequest_Limits(t *testing.T) {
+	dir := t.TempDir()
+	log := strings.Repeat(strings.Repeat("v", 600)+"\n", 2000) // About 3 chunks
+	for _, name := range []string{"a-build", "b-tests", "c-lint"} {
+		if err := os.WriteFile(filepath.Join(dir, name+".log"), []byte(log), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buildURL, err := provider.LocalDirURL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+	controlChan, err := brk.Subscribe(ctx, contracts.TopicControl, "test-consumer")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// Each log is cut to 2 chunks, so the chunk limit cuts the second job short
+	agent := NewAgent(brk, logger.NewSilentLogger())
+	agent.SetLimits(Limits{MaxLogBytes: 700 << 10, MaxChunks: 3})
+	data, _ := json.Marshal(contracts.AnalysisRequest{
+		Version:   contracts.AnalysisRequestVersion,
+		RequestID: "req-limits",
+		BuildURL:  buildURL,
+	})
+	if err := agent.processRequest(ctx, broker.Message{Topic: contracts.TopicRequests, Value: data}); err != nil {
+		t.Fatalf("processRequest() error = %v", err)
+	}
+
+	select {
+	case msg := <-controlChan:
+		var control contracts.ControlMessage
+		if err := json.Unmarshal(msg.Value, &control); err != nil {
+			t.Fatal(err)
+		}
+		if control.Chunks != 3 {
+			t.Errorf("Chunks = %d, want the limit of 3", control.Chunks)
+		}
+		want := []string{
+			"log of job a-build cut at 716800 bytes (DESTILL_MAX_LOG_MB)",
+			"log of job b-tests cut at 716800 bytes (DESTILL_MAX_LOG_MB)",
+			"stopped at 3 log chunks (DESTILL_MAX_CHUNKS), cutting job b-tests short, skipping 1 later jobs",
+		}
+		if len(control.Truncated) != len(want) {
+			t.Fatalf("Truncated = %q, want %d notes", control.Truncated, len(want))
+		}
+		for i, note := range want {
+			if control.Truncated[i] != note {
+				t.Errorf("Truncated[%d] = %q, want %q", i, control.Truncated[i], note)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for the ingest complete message")
+	}
+}
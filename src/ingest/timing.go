@@ -0,0 +1,149 @@
+package ingest
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/contracts"
+)
+
+const (
+	// SlowJobFactor is how many times the build's median job duration a job
+	// must take to be reported as slow.
+	SlowJobFactor = 3.0
+
+	// MinSlowJobDuration keeps short jobs from being reported as slow, since
+	// a few seconds of variance can triple them.
+	MinSlowJobDuration = time.Minute
+
+	// minTimedJobs is the number of jobs with a known duration a build
+	// needs for its median to mean anything.
+	minTimedJobs = 3
+)
+
+// buildkiteTimestampMarker matches the timestamp markers Buildkite writes
+// into logs, in epoch milliseconds
+var buildkiteTimestampMarker = regexp.MustCompile(`\x1b_bk;t=([0-9]+)\x07`)
+
+// jobTiming is how long one job of the build ran.
+type jobTiming struct {
+	duration time.Duration
+	analyzed bool              // Its log was ingested, so findings are reported for it
+	name     string            // Job name
+	metadata map[string]string // Chunk metadata of the job; nil when not analyzed
+}
+
+// logDuration estimates how long a job ran from the timestamps in its log:
+// Buildkite's timestamp markers, or RFC 3339 timestamps at the start of
+// lines (GitHub Actions, GitLab). Zero without two timestamps.
+func logDuration(content string) time.Duration {
+	var first, last time.Time
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		ts, ok := lineTimestamp(scanner.Text())
+		if !ok {
+			continue
+		}
+		if first.IsZero() {
+			first = ts
+		}
+		last = ts
+	}
+	if first.IsZero() || !last.After(first) {
+		return 0
+	}
+	return last.Sub(first)
+}
+
+// lineTimestamp returns the timestamp of a log line, if it has one.
+func lineTimestamp(line string) (time.Time, bool) {
+	if m := buildkiteTimestampMarker.FindStringSubmatch(line); m != nil {
+		ms, err := strconv.ParseInt(m[1], 10, 64)
+		if err == nil {
+			return time.UnixMilli(ms), true
+		}
+	}
+	if m := logTimestampPrefix.FindString(line); m != "" {
+		if ts, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(m)); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// medianDuration returns the median of the known (non-zero) durations, or
+// zero with fewer than minTimedJobs of them.
+func medianDuration(timings []jobTiming) time.Duration {
+	var durations []time.Duration
+	for _, t := range timings {
+		if t.duration > 0 {
+			durations = append(durations, t.duration)
+		}
+	}
+	if len(durations) < minTimedJobs {
+		return 0
+	}
+	slices.Sort(durations)
+	mid := len(durations) / 2
+	if len(durations)%2 == 0 {
+		return (durations[mid-1] + durations[mid]) / 2
+	}
+	return durations[mid]
+}
+
+// slowJobCards returns an informational finding for every analyzed job that
+// ran at least SlowJobFactor times the build's median job.
+func slowJobCards(requestID string, timings []jobTiming) []contracts.TriageCard {
+	median := medianDuration(timings)
+	if median == 0 {
+		return nil
+	}
+
+	var cards []contracts.TriageCard
+	for _, t := range timings {
+		factor := float64(t.duration) / float64(median)
+		if !t.analyzed || t.duration < MinSlowJobDuration || factor < SlowJobFactor {
+			continue
+		}
+		cards = append(cards, slowJobCard(requestID, t, median, factor))
+	}
+	return cards
+}
+
+// slowJobCard builds the informational finding for a slow job. The message
+// hash leaves out the durations, so the finding matches across builds.
+func slowJobCard(requestID string, t jobTiming, median time.Duration, factor float64) contracts.TriageCard {
+	normalized := fmt.Sprintf("Job %q ran much longer than the median job of the build", t.name)
+	messageHash := analyze.CalculateMessageHash(normalized)
+
+	metadata := make(map[string]string, len(t.metadata)+3)
+	for k, v := range t.metadata {
+		metadata[k] = v
+	}
+	metadata[contracts.MetadataFindingType] = contracts.FindingTypeSlowJob
+	metadata[contracts.MetadataJobDuration] = strconv.FormatInt(t.duration.Milliseconds(), 10)
+	metadata[contracts.MetadataMedianJobDuration] = strconv.FormatInt(median.Milliseconds(), 10)
+
+	return contracts.TriageCard{
+		ID:          fmt.Sprintf("%s-%s-timing", metadata["job_id"], messageHash[:8]),
+		RequestID:   requestID,
+		MessageHash: messageHash,
+		Source:      metadata["provider"],
+		JobName:     t.name,
+		BuildURL:    metadata["build_url"],
+		Severity:    "INFO",
+		RawMessage: fmt.Sprintf("Job %q took %s, %.1fx the median job of this build (%s)",
+			t.name, t.duration.Round(time.Second), factor, median.Round(time.Second)),
+		NormalizedMsg:   normalized,
+		ConfidenceScore: 0.5,
+		Metadata:        metadata,
+		Timestamp:       time.Now().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,169 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/logger"
+	"destill-agent/src/provider"
+)
+
+func TestLogDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want time.Duration
+	}{
+		{
+			name: "buildkite markers",
+			log:  "\x1b_bk;t=1700000000000\x07--- setup\n\x1b_bk;t=1700000001000\x07running\n\x1b_bk;t=1700000090500\x07done",
+			want: 90500 * time.Millisecond,
+		},
+		{
+			name: "github timestamps",
+			log:  "2024-01-15T10:00:00.0000000Z Run tests\nno timestamp here\n2024-01-15T10:02:30.5000000Z FAIL",
+			want: 150500 * time.Millisecond,
+		},
+		{name: "single timestamp", log: "2024-01-15T10:00:00Z Run tests\nFAIL", want: 0},
+		{name: "no timestamps", log: "Run tests\nFAIL", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := logDuration(tt.log); got != tt.want {
+				t.Errorf("logDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobDuration(t *testing.T) {
+	sections := []logSection{
+		{content: "checkout", duration: 30 * time.Second},
+		{content: "2024-01-15T10:00:00Z go test\n2024-01-15T10:01:00Z FAIL"},
+	}
+	if got := jobDuration(provider.Job{Duration: 5 * time.Minute}, sections); got != 5*time.Minute {
+		t.Errorf("jobDuration(reported) = %v, want 5m", got)
+	}
+	if got := jobDuration(provider.Job{}, sections); got != 90*time.Second {
+		t.Errorf("jobDuration(from steps) = %v, want 1m30s", got)
+	}
+}
+
+func TestSlowJobCards(t *testing.T) {
+	timing := func(name string, duration time.Duration, analyzed bool) jobTiming {
+		return jobTiming{duration: duration, analyzed: analyzed, name: name, metadata: map[string]string{"job_id": name}}
+	}
+
+	tests := []struct {
+		name    string
+		timings []jobTiming
+		want    []string
+	}{
+		{
+			name: "one slow job",
+			timings: []jobTiming{
+				timing("lint", 2*time.Minute, true),
+				timing("unit", 3*time.Minute, true),
+				timing("build", 2*time.Minute, true),
+				timing("integration", 10*time.Minute, true),
+			},
+			want: []string{"integration"},
+		},
+		{
+			name: "skipped jobs count toward the median only",
+			timings: []jobTiming{
+				timing("lint", 2*time.Minute, false),
+				timing("unit", 2*time.Minute, false),
+				timing("build", 3*time.Minute, false),
+				timing("integration", 8*time.Minute, true),
+			},
+			want: []string{"integration"},
+		},
+		{
+			name: "short jobs never slow",
+			timings: []jobTiming{
+				timing("lint", 5*time.Second, true),
+				timing("unit", 5*time.Second, true),
+				timing("build", 50*time.Second, true),
+			},
+		},
+		{
+			name: "too few timed jobs",
+			timings: []jobTiming{
+				timing("lint", time.Minute, true),
+				timing("integration", 30*time.Minute, true),
+				timing("unit", 0, true),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards := slowJobCards("req-1", tt.timings)
+			var got []string
+			for _, card := range cards {
+				got = append(got, card.JobName)
+				if card.Metadata[contracts.MetadataFindingType] != contracts.FindingTypeSlowJob || card.Severity != "INFO" {
+					t.Errorf("card = %+v, want an INFO slow_job finding", card)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("slow jobs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgent_PublishesSlowJobFinding(t *testing.T) {
+	dir := t.TempDir()
+	for name, minutes := range map[string]int{"lint": 1, "unit": 2, "build": 2, "integration": 9} {
+		log := fmt.Sprintf("2024-01-15T10:00:00Z start\n2024-01-15T10:%02d:00Z done\n", minutes)
+		if err := os.WriteFile(filepath.Join(dir, name+".log"), []byte(log), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buildURL, err := provider.LocalDirURL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	findings, err := brk.Subscribe(ctx, contracts.TopicAnalysisFindings, "test-consumer")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	data, _ := json.Marshal(contracts.AnalysisRequest{RequestID: "req-timing", BuildURL: buildURL})
+	agent := NewAgent(brk, logger.NewSilentLogger())
+	if err := agent.processRequest(ctx, broker.Message{Topic: contracts.TopicRequests, Value: data}); err != nil {
+		t.Fatalf("processRequest() error = %v", err)
+	}
+
+	select {
+	case msg := <-findings:
+		var card contracts.TriageCard
+		if err := json.Unmarshal(msg.Value, &card); err != nil {
+			t.Fatalf("Failed to unmarshal finding: %v", err)
+		}
+		if card.JobName != "integration" || !strings.Contains(card.RawMessage, "4.5x") {
+			t.Errorf("finding = %q for %q, want integration at 4.5x the median", card.RawMessage, card.JobName)
+		}
+		if card.Metadata[contracts.MetadataJobDuration] != "540000" || card.Metadata[contracts.MetadataMedianJobDuration] != "120000" {
+			t.Errorf("timing metadata = %v", card.Metadata)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for slow job finding")
+	}
+}
@@ -0,0 +1,161 @@
+// Package jenkins provides a Jenkins provider using the Jenkins JSON API.
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"destill-agent/src/provider"
+)
+
+// ErrNotFound is returned when Jenkins responds 404
+var ErrNotFound = errors.New("not found on Jenkins")
+
+// Client is a Jenkins API client. Jenkins is self-hosted, so every method
+// takes absolute build or job URLs rather than paths on a fixed base URL.
+type Client struct {
+	user       string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a Jenkins client authenticating with user and API token
+func NewClient(user, token string) *Client {
+	return &Client{
+		user:  user,
+		token: token,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// GetBuild fetches build metadata
+func (c *Client) GetBuild(ctx context.Context, buildURL string) (*Build, error) {
+	var build Build
+	if err := c.getJSON(ctx, withSlash(buildURL)+"api/json", &build); err != nil {
+		return nil, err
+	}
+	return &build, nil
+}
+
+// GetLastFailedBuild fetches the most recent failed build of a job.
+// Returns ErrNotFound if the job has never failed.
+func (c *Client) GetLastFailedBuild(ctx context.Context, jobURL string) (*Build, error) {
+	return c.GetBuild(ctx, withSlash(jobURL)+"lastFailedBuild/")
+}
+
+// GetPipelineRun fetches stage metadata for a Pipeline build.
+// Returns ErrNotFound for freestyle jobs or when the stage view plugin is missing.
+func (c *Client) GetPipelineRun(ctx context.Context, buildURL string) (*PipelineRun, error) {
+	var run PipelineRun
+	if err := c.getJSON(ctx, withSlash(buildURL)+"wfapi/describe", &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// GetConsoleText fetches the plain-text console output of a build
+func (c *Client) GetConsoleText(ctx context.Context, buildURL string) (string, error) {
+	resp, err := c.do(ctx, withSlash(buildURL)+"consoleText")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := provider.ReadLog(ctx, resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	return string(body), nil
+}
+
+// GetArtifacts lists the artifacts archived by a build
+func (c *Client) GetArtifacts(ctx context.Context, buildURL string) ([]Artifact, error) {
+	var resp artifactsResponse
+	endpoint := withSlash(buildURL) + "api/json?tree=artifacts[displayPath,fileName,relativePath]"
+	if err := c.getJSON(ctx, endpoint, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Artifacts, nil
+}
+
+// ArtifactURL returns the download URL for a build artifact
+func (c *Client) ArtifactURL(buildURL string, artifact Artifact) string {
+	return withSlash(buildURL) + "artifact/" + artifact.RelativePath
+}
+
+// Download fetches raw content from a Jenkins URL
+func (c *Client) Download(ctx context.Context, downloadURL string) ([]byte, error) {
+	return c.get(ctx, downloadURL)
+}
+
+// getJSON performs an authenticated GET and decodes the JSON body into v
+func (c *Client) getJSON(ctx context.Context, endpoint string, v any) error {
+	body, err := c.get(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// get performs an authenticated GET and returns the raw body
+func (c *Client) get(ctx context.Context, endpoint string) ([]byte, error) {
+	resp, err := c.do(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, nil
+}
+
+// do performs an authenticated GET. Non-200 responses are returned as
+// errors.
+func (c *Client) do(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, endpoint)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("Jenkins API error %d: %s", resp.StatusCode, string(body))
+	}
+	return resp, nil
+}
+
+// withSlash ensures a Jenkins URL ends in "/" so paths can be appended
+func withSlash(u string) string {
+	if strings.HasSuffix(u, "/") {
+		return u
+	}
+	return u + "/"
+}
@@ -0,0 +1,71 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"destill-agent/src/provider"
+)
+
+func TestClient_BasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, token, ok := r.BasicAuth()
+		if !ok || user != "alice" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("Started by user alice\nFinished: FAILURE\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient("alice", "secret")
+	text, err := client.GetConsoleText(context.Background(), server.URL+"/job/foo/1")
+	if err != nil {
+		t.Fatalf("GetConsoleText() error = %v", err)
+	}
+	if text != "Started by user alice\nFinished: FAILURE\n" {
+		t.Errorf("GetConsoleText() = %q", text)
+	}
+
+	text, err = client.GetConsoleText(provider.WithMaxLogBytes(context.Background(), 9), server.URL+"/job/foo/1")
+	if err != nil || text != "Started by" {
+		t.Errorf("GetConsoleText() with a 9 byte limit = %q, %v; want 10 bytes", text, err)
+	}
+
+	bad := NewClient("alice", "wrong")
+	if _, err := bad.GetConsoleText(context.Background(), server.URL+"/job/foo/1"); err == nil {
+		t.Error("GetConsoleText() with bad credentials expected error, got nil")
+	}
+}
+
+func TestClient_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	client := NewClient("", "")
+	_, err := client.GetPipelineRun(context.Background(), server.URL+"/job/foo/1/")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetPipelineRun() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestWithSlash(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://ci/job/foo/1", "https://ci/job/foo/1/"},
+		{"https://ci/job/foo/1/", "https://ci/job/foo/1/"},
+	}
+
+	for _, tt := range tests {
+		if got := withSlash(tt.in); got != tt.want {
+			t.Errorf("withSlash(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
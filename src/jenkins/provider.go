@@ -0,0 +1,248 @@
+package jenkins
+
+import (
+	"context"
+	"destill-agent/src/provider"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	// Register the Jenkins provider factory. The token is "user:apiToken".
+	provider.RegisterProvider("jenkins", func(token string) provider.Provider {
+		user, apiToken, _ := strings.Cut(token, ":")
+		return NewProvider(user, apiToken)
+	})
+}
+
+// Provider implements provider.Provider for Jenkins
+type Provider struct {
+	client *Client
+}
+
+// NewProvider creates a Jenkins provider with user and API token
+func NewProvider(user, token string) *Provider {
+	return &Provider{
+		client: NewClient(user, token),
+	}
+}
+
+// Name returns "jenkins"
+func (p *Provider) Name() string {
+	return "jenkins"
+}
+
+// ParseURL delegates to provider.ParseURL
+func (p *Provider) ParseURL(url string) (*provider.BuildRef, error) {
+	return provider.ParseURL(url)
+}
+
+// FetchBuild retrieves build metadata and stages using the Jenkins JSON API.
+// Jenkins has a single console log per build, so the build is returned as one
+// "script" job whose ID is the build URL. Pipeline stages, when available,
+// are added as "stage" jobs for metadata only.
+func (p *Provider) FetchBuild(ctx context.Context, ref *provider.BuildRef) (*provider.Build, error) {
+	buildURL := jobURL(ref) + ref.BuildID + "/"
+
+	jBuild, err := p.client.GetBuild(ctx, buildURL)
+	if err != nil {
+		return nil, err
+	}
+
+	build := toBuild(jBuild)
+	if build.URL == "" {
+		build.URL = buildURL
+	}
+
+	exitCode := 0
+	if build.State == "failed" {
+		exitCode = 1
+	}
+	build.Jobs = []provider.Job{{
+		ID:        build.URL,
+		Name:      jobName(ref.Metadata["job_path"]),
+		Type:      "script",
+		State:     build.State,
+		ExitCode:  exitCode,
+		BuildID:   build.ID,
+		Timestamp: build.Timestamp,
+		Duration:  time.Duration(jBuild.Duration) * time.Millisecond,
+	}}
+
+	// Freestyle jobs have no stages; that's not an error
+	run, err := p.client.GetPipelineRun(ctx, build.URL)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if run != nil {
+		for _, stage := range run.Stages {
+			build.Jobs = append(build.Jobs, provider.Job{
+				ID:        build.URL + "#stage-" + stage.ID,
+				Name:      stage.Name,
+				Type:      "stage",
+				State:     mapStageStatus(stage.Status),
+				BuildID:   build.ID,
+				Timestamp: time.UnixMilli(stage.StartTimeMillis),
+			})
+		}
+	}
+
+	return build, nil
+}
+
+// FetchLatestFailedBuild finds the most recent failed build of a job.
+// For multibranch jobs the branch's child job is tried first; otherwise
+// the job itself is used and branch is ignored.
+func (p *Provider) FetchLatestFailedBuild(ctx context.Context, ref *provider.BuildRef, branch string) (*provider.Build, error) {
+	base := jobURL(ref)
+
+	candidates := []string{base}
+	if branch != "" {
+		candidates = []string{base + "job/" + url.PathEscape(branch) + "/", base}
+	}
+
+	for _, candidate := range candidates {
+		jBuild, err := p.client.GetLastFailedBuild(ctx, candidate)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return toBuild(jBuild), nil
+	}
+
+	return nil, fmt.Errorf("%w: no failed builds for %s", provider.ErrBuildNotFound, ref.Metadata["job_path"])
+}
+
+// FetchJobLog retrieves the console output. jobID is the build URL.
+func (p *Provider) FetchJobLog(ctx context.Context, jobID string) (string, error) {
+	return p.client.GetConsoleText(ctx, jobID)
+}
+
+// FetchArtifacts lists the artifacts archived by a build. jobID is the build URL.
+func (p *Provider) FetchArtifacts(ctx context.Context, jobID string) ([]provider.Artifact, error) {
+	jArtifacts, err := p.client.GetArtifacts(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make([]provider.Artifact, 0, len(jArtifacts))
+	for _, a := range jArtifacts {
+		artifacts = append(artifacts, provider.Artifact{
+			ID:          a.RelativePath,
+			JobID:       jobID,
+			Path:        a.RelativePath,
+			DownloadURL: p.client.ArtifactURL(jobID, a),
+		})
+	}
+	return artifacts, nil
+}
+
+// DownloadArtifact downloads artifact content
+func (p *Provider) DownloadArtifact(ctx context.Context, artifact provider.Artifact) ([]byte, error) {
+	return p.client.Download(ctx, artifact.DownloadURL)
+}
+
+// jobURL builds the job URL (with trailing slash) from a BuildRef
+func jobURL(ref *provider.BuildRef) string {
+	return ref.Metadata["base_url"] + "/" + ref.Metadata["job_path"] + "/"
+}
+
+// jobName returns the last job segment of "job/folder/job/name"
+func jobName(jobPath string) string {
+	if name := path.Base(jobPath); name != "." && name != "/" {
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			return unescaped
+		}
+		return name
+	}
+	return "console"
+}
+
+// toBuild converts a Jenkins build to a provider.Build without jobs
+func toBuild(b *Build) *provider.Build {
+	id := b.ID
+	if id == "" {
+		id = strconv.Itoa(b.Number)
+	}
+	return &provider.Build{
+		ID:        id,
+		Number:    strconv.Itoa(b.Number),
+		URL:       b.URL,
+		State:     mapJenkinsResult(b.Result, b.Building),
+		Trigger:   buildTrigger(b),
+		Timestamp: time.UnixMilli(b.Timestamp),
+	}
+}
+
+// causeTriggers maps Jenkins cause class name suffixes to normalized triggers
+var causeTriggers = []struct {
+	suffix  string
+	trigger string
+}{
+	{"TimerTriggerCause", provider.TriggerSchedule},
+	{"PullRequestCause", provider.TriggerPullRequest},
+	{"SCMTriggerCause", provider.TriggerPush},
+	{"BranchEventCause", provider.TriggerPush},
+	{"GitHubPushCause", provider.TriggerPush},
+	{"UserIdCause", provider.TriggerManual},
+	{"RemoteCause", provider.TriggerAPI},
+	{"UpstreamCause", provider.TriggerUpstream},
+}
+
+// buildTrigger returns the trigger of the first recognized build cause
+func buildTrigger(b *Build) string {
+	for _, action := range b.Actions {
+		for _, cause := range action.Causes {
+			for _, ct := range causeTriggers {
+				if strings.HasSuffix(cause.Class, ct.suffix) {
+					return ct.trigger
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// mapJenkinsResult maps a Jenkins build result to Buildkite-like state
+func mapJenkinsResult(result string, building bool) string {
+	if building {
+		return "running"
+	}
+	switch result {
+	case "SUCCESS":
+		return "passed"
+	case "FAILURE", "UNSTABLE":
+		return "failed"
+	case "ABORTED":
+		return "canceled"
+	case "NOT_BUILT":
+		return "skipped"
+	default:
+		return strings.ToLower(result)
+	}
+}
+
+// mapStageStatus maps a Pipeline stage status to Buildkite-like state
+func mapStageStatus(status string) string {
+	switch status {
+	case "SUCCESS":
+		return "passed"
+	case "FAILED", "UNSTABLE":
+		return "failed"
+	case "ABORTED":
+		return "canceled"
+	case "IN_PROGRESS", "PAUSED_PENDING_INPUT":
+		return "running"
+	case "NOT_EXECUTED":
+		return "skipped"
+	default:
+		return strings.ToLower(status)
+	}
+}
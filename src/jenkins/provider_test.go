@@ -0,0 +1,233 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"destill-agent/src/provider"
+)
+
+func TestJenkinsProvider_Name(t *testing.T) {
+	p := NewProvider("user", "token")
+	if p.Name() != "jenkins" {
+		t.Errorf("Name() = %v, want jenkins", p.Name())
+	}
+}
+
+func TestJenkinsProvider_FetchBuild(t *testing.T) {
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/job/team/job/api/7/api/json":
+			json.NewEncoder(w).Encode(Build{
+				ID:        "7",
+				Number:    7,
+				Result:    "FAILURE",
+				Timestamp: 1704110400000,
+				URL:       serverURL + "/job/team/job/api/7/",
+			})
+		case "/job/team/job/api/7/wfapi/describe":
+			json.NewEncoder(w).Encode(PipelineRun{
+				ID:     "7",
+				Status: "FAILED",
+				Stages: []Stage{
+					{ID: "6", Name: "Build", Status: "SUCCESS"},
+					{ID: "12", Name: "Test", Status: "FAILED"},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	p := NewProvider("user", "token")
+	ref := &provider.BuildRef{
+		Provider: "jenkins",
+		BuildID:  "7",
+		Metadata: map[string]string{"base_url": server.URL, "job_path": "job/team/job/api"},
+	}
+
+	build, err := p.FetchBuild(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("FetchBuild() error = %v", err)
+	}
+
+	if build.ID != "7" || build.State != "failed" {
+		t.Errorf("Build = %+v, want ID 7, State failed", build)
+	}
+	if len(build.Jobs) != 3 {
+		t.Fatalf("len(Build.Jobs) = %v, want 3", len(build.Jobs))
+	}
+
+	console := build.Jobs[0]
+	if console.ID != server.URL+"/job/team/job/api/7/" || console.Name != "api" || console.Type != "script" || console.ExitCode != 1 {
+		t.Errorf("Job[0] = %+v, want script job for the build URL with exit 1", console)
+	}
+
+	test := build.Jobs[2]
+	if test.Name != "Test" || test.Type != "stage" || test.State != "failed" {
+		t.Errorf("Job[2] = %+v, want failed stage Test", test)
+	}
+}
+
+func TestJenkinsProvider_FetchBuild_Freestyle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/job/foo/3/api/json" {
+			json.NewEncoder(w).Encode(Build{ID: "3", Number: 3, Result: "SUCCESS"})
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	p := NewProvider("user", "token")
+	ref := &provider.BuildRef{
+		Provider: "jenkins",
+		BuildID:  "3",
+		Metadata: map[string]string{"base_url": server.URL, "job_path": "job/foo"},
+	}
+
+	build, err := p.FetchBuild(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("FetchBuild() error = %v", err)
+	}
+	if len(build.Jobs) != 1 || build.Jobs[0].State != "passed" {
+		t.Errorf("Jobs = %+v, want one passed console job", build.Jobs)
+	}
+	if build.URL != server.URL+"/job/foo/3/" {
+		t.Errorf("URL = %v, want build URL fallback", build.URL)
+	}
+}
+
+func TestJenkinsProvider_FetchLatestFailedBuild(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/multi/job/main/lastFailedBuild/api/json":
+			json.NewEncoder(w).Encode(Build{ID: "21", Number: 21, Result: "FAILURE"})
+		case "/job/foo/lastFailedBuild/api/json":
+			json.NewEncoder(w).Encode(Build{ID: "9", Number: 9, Result: "FAILURE"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("user", "token")
+
+	tests := []struct {
+		name    string
+		jobPath string
+		wantID  string
+		wantErr error
+	}{
+		{name: "multibranch child", jobPath: "job/multi", wantID: "21"},
+		{name: "falls back to job", jobPath: "job/foo", wantID: "9"},
+		{name: "never failed", jobPath: "job/green", wantErr: provider.ErrBuildNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref := &provider.BuildRef{
+				Provider: "jenkins",
+				Metadata: map[string]string{"base_url": server.URL, "job_path": tt.jobPath},
+			}
+
+			build, err := p.FetchLatestFailedBuild(context.Background(), ref, "main")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchLatestFailedBuild() error = %v", err)
+			}
+			if build.ID != tt.wantID {
+				t.Errorf("ID = %v, want %v", build.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestJenkinsProvider_FetchArtifacts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/foo/3/api/json":
+			json.NewEncoder(w).Encode(map[string]any{
+				"artifacts": []Artifact{{FileName: "junit.xml", RelativePath: "reports/junit.xml"}},
+			})
+		case "/job/foo/3/artifact/reports/junit.xml":
+			w.Write([]byte("<testsuites/>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("user", "token")
+	buildURL := server.URL + "/job/foo/3/"
+
+	artifacts, err := p.FetchArtifacts(context.Background(), buildURL)
+	if err != nil {
+		t.Fatalf("FetchArtifacts() error = %v", err)
+	}
+	if len(artifacts) != 1 || artifacts[0].Path != "reports/junit.xml" {
+		t.Fatalf("artifacts = %+v, want reports/junit.xml", artifacts)
+	}
+
+	data, err := p.DownloadArtifact(context.Background(), artifacts[0])
+	if err != nil {
+		t.Fatalf("DownloadArtifact() error = %v", err)
+	}
+	if string(data) != "<testsuites/>" {
+		t.Errorf("DownloadArtifact() = %q", data)
+	}
+}
+
+func TestMapJenkinsResult(t *testing.T) {
+	tests := []struct {
+		result   string
+		building bool
+		want     string
+	}{
+		{"SUCCESS", false, "passed"},
+		{"FAILURE", false, "failed"},
+		{"UNSTABLE", false, "failed"},
+		{"ABORTED", false, "canceled"},
+		{"NOT_BUILT", false, "skipped"},
+		{"", true, "running"},
+	}
+
+	for _, tt := range tests {
+		if got := mapJenkinsResult(tt.result, tt.building); got != tt.want {
+			t.Errorf("mapJenkinsResult(%q, %v) = %v, want %v", tt.result, tt.building, got, tt.want)
+		}
+	}
+}
+
+func TestBuildTrigger(t *testing.T) {
+	tests := []struct {
+		class string
+		want  string
+	}{
+		{"hudson.triggers.TimerTrigger$TimerTriggerCause", "schedule"},
+		{"hudson.model.Cause$UserIdCause", "manual"},
+		{"hudson.model.Cause$UpstreamCause", "upstream"},
+		{"jenkins.branch.BranchIndexingCause", ""},
+	}
+
+	for _, tt := range tests {
+		build := &Build{Actions: []Action{{}, {Causes: []Cause{{Class: tt.class}}}}}
+		if got := buildTrigger(build); got != tt.want {
+			t.Errorf("buildTrigger(%s) = %q, want %q", tt.class, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package jenkins
+
+// Build is the subset of a Jenkins build's /api/json used by destill
+type Build struct {
+	ID              string   `json:"id"`
+	Number          int      `json:"number"`
+	Result          string   `json:"result"` // SUCCESS, FAILURE, UNSTABLE, ABORTED, NOT_BUILT; empty while building
+	Building        bool     `json:"building"`
+	Timestamp       int64    `json:"timestamp"` // Start time, epoch millis
+	Duration        int64    `json:"duration"`  // Milliseconds
+	URL             string   `json:"url"`
+	FullDisplayName string   `json:"fullDisplayName"`
+	Actions         []Action `json:"actions"`
+}
+
+// Action is an entry of a build's actions list. Only CauseAction entries
+// carry causes; the rest decode as empty.
+type Action struct {
+	Causes []Cause `json:"causes"`
+}
+
+// Cause records why a build started
+type Cause struct {
+	Class            string `json:"_class"` // e.g. hudson.triggers.TimerTrigger$TimerTriggerCause
+	ShortDescription string `json:"shortDescription"`
+}
+
+// PipelineRun is the Pipeline (workflow) REST API description of a build.
+// Only available for Pipeline jobs with the pipeline-stage-view plugin.
+type PipelineRun struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Status          string  `json:"status"` // SUCCESS, FAILED, UNSTABLE, ABORTED, IN_PROGRESS, NOT_EXECUTED
+	StartTimeMillis int64   `json:"startTimeMillis"`
+	DurationMillis  int64   `json:"durationMillis"`
+	Stages          []Stage `json:"stages"`
+}
+
+// Stage is a stage of a Pipeline run
+type Stage struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Status          string `json:"status"`
+	StartTimeMillis int64  `json:"startTimeMillis"`
+	DurationMillis  int64  `json:"durationMillis"`
+}
+
+// Artifact is a file archived by a build
+type Artifact struct {
+	DisplayPath  string `json:"displayPath"`
+	FileName     string `json:"fileName"`
+	RelativePath string `json:"relativePath"`
+}
+
+// artifactsResponse wraps the artifacts list of a build's /api/json
+type artifactsResponse struct {
+	Artifacts []Artifact `json:"artifacts"`
+}
@@ -0,0 +1,140 @@
+// Package localdir implements a provider for directories of exported job
+// logs, for environments where CI logs are archived as artifacts rather than
+// fetched from an API. Each file is one job; its name is the job name.
+package localdir
+
+import (
+	"compress/gzip"
+	"context"
+	"destill-agent/src/provider"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	// Register the local directory provider factory (no token needed)
+	provider.RegisterProvider("local", func(string) provider.Provider {
+		return NewProvider()
+	})
+}
+
+// Provider implements provider.Provider for a local directory of logs
+type Provider struct{}
+
+// NewProvider creates a local directory provider
+func NewProvider() *Provider {
+	return &Provider{}
+}
+
+// Name returns "local"
+func (p *Provider) Name() string {
+	return "local"
+}
+
+// ParseURL delegates to provider.ParseURL
+func (p *Provider) ParseURL(url string) (*provider.BuildRef, error) {
+	return provider.ParseURL(url)
+}
+
+// FetchBuild lists the directory. Every regular, non-hidden file becomes a
+// job whose ID is the file path. Outcomes aren't known, so job states are
+// provider.JobStateUnknown.
+func (p *Provider) FetchBuild(ctx context.Context, ref *provider.BuildRef) (*provider.Build, error) {
+	dir := ref.Metadata["dir"]
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", provider.ErrBuildNotFound, dir)
+		}
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	buildURL, err := provider.LocalDirURL(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	build := &provider.Build{
+		ID:    dir,
+		URL:   buildURL,
+		State: provider.JobStateUnknown,
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		job := provider.Job{
+			ID:      filepath.Join(dir, entry.Name()),
+			Name:    JobName(entry.Name()),
+			Type:    "script",
+			State:   provider.JobStateUnknown,
+			BuildID: build.ID,
+		}
+		if info, err := entry.Info(); err == nil {
+			job.Timestamp = info.ModTime()
+		}
+		build.Jobs = append(build.Jobs, job)
+	}
+
+	if len(build.Jobs) == 0 {
+		return nil, fmt.Errorf("%w: no log files in %s", provider.ErrBuildNotFound, dir)
+	}
+	return build, nil
+}
+
+// FetchLatestFailedBuild is not supported: a directory is a single build
+func (p *Provider) FetchLatestFailedBuild(ctx context.Context, ref *provider.BuildRef, branch string) (*provider.Build, error) {
+	return nil, errors.New("--latest-failed is not supported for local log directories")
+}
+
+// FetchJobLog reads a log file. Gzipped files (.gz) are decompressed.
+func (p *Provider) FetchJobLog(ctx context.Context, jobID string) (string, error) {
+	f, err := os.Open(jobID)
+	if err != nil {
+		return "", fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(jobID, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress %s: %w", jobID, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	data, err := provider.ReadLog(ctx, r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read log file: %w", err)
+	}
+	return string(data), nil
+}
+
+// FetchArtifacts returns no artifacts; exported logs have none
+func (p *Provider) FetchArtifacts(ctx context.Context, jobID string) ([]provider.Artifact, error) {
+	return nil, nil
+}
+
+// DownloadArtifact is not supported for local log directories
+func (p *Provider) DownloadArtifact(ctx context.Context, artifact provider.Artifact) ([]byte, error) {
+	return nil, errors.New("artifacts are not supported for local log directories")
+}
+
+// JobName derives a job name from a log file name by dropping the extension
+// (and .gz), e.g. "unit-tests.log.gz" -> "unit-tests".
+func JobName(fileName string) string {
+	name := strings.TrimSuffix(fileName, ".gz")
+	if ext := filepath.Ext(name); ext != "" && ext != name {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
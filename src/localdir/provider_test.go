@@ -0,0 +1,117 @@
+package localdir
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"destill-agent/src/provider"
+)
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProvider_FetchBuild(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "unit-tests.log"), []byte("ok\n"))
+	writeFile(t, filepath.Join(dir, "lint.txt"), []byte("ok\n"))
+	writeFile(t, filepath.Join(dir, ".DS_Store"), []byte{0})
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProvider()
+	build, err := p.FetchBuild(context.Background(), &provider.BuildRef{
+		Provider: "local",
+		BuildID:  dir,
+		Metadata: map[string]string{"dir": dir},
+	})
+	if err != nil {
+		t.Fatalf("FetchBuild() error = %v", err)
+	}
+
+	// os.ReadDir sorts by file name
+	wantNames := []string{"lint", "unit-tests"}
+	if len(build.Jobs) != len(wantNames) {
+		t.Fatalf("FetchBuild() returned %d jobs, want %d: %+v", len(build.Jobs), len(wantNames), build.Jobs)
+	}
+	for i, job := range build.Jobs {
+		if job.Name != wantNames[i] {
+			t.Errorf("job %d name = %q, want %q", i, job.Name, wantNames[i])
+		}
+		if job.State != provider.JobStateUnknown || job.Type != "script" {
+			t.Errorf("job %d state/type = %q/%q, want %q/script", i, job.State, job.Type, provider.JobStateUnknown)
+		}
+		if filepath.Dir(job.ID) != dir {
+			t.Errorf("job %d ID = %q, want a path in %q", i, job.ID, dir)
+		}
+	}
+}
+
+func TestProvider_FetchBuild_Errors(t *testing.T) {
+	p := NewProvider()
+	for name, dir := range map[string]string{
+		"missing directory": filepath.Join(t.TempDir(), "missing"),
+		"empty directory":   t.TempDir(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := p.FetchBuild(context.Background(), &provider.BuildRef{Metadata: map[string]string{"dir": dir}})
+			if !errors.Is(err, provider.ErrBuildNotFound) {
+				t.Errorf("FetchBuild() error = %v, want ErrBuildNotFound", err)
+			}
+		})
+	}
+}
+
+func TestProvider_FetchJobLog(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "build.log")
+	writeFile(t, plain, []byte("ERROR: boom\n"))
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("FATAL: compressed\n"))
+	gz.Close()
+	compressed := filepath.Join(dir, "deploy.log.gz")
+	writeFile(t, compressed, buf.Bytes())
+
+	p := NewProvider()
+	for path, want := range map[string]string{plain: "ERROR: boom\n", compressed: "FATAL: compressed\n"} {
+		got, err := p.FetchJobLog(context.Background(), path)
+		if err != nil {
+			t.Fatalf("FetchJobLog(%s) error = %v", path, err)
+		}
+		if got != want {
+			t.Errorf("FetchJobLog(%s) = %q, want %q", path, got, want)
+		}
+	}
+
+	got, err := p.FetchJobLog(provider.WithMaxLogBytes(context.Background(), 5), compressed)
+	if err != nil || got != "FATAL:" {
+		t.Errorf("FetchJobLog() with a 5 byte limit = %q, %v; want 6 bytes", got, err)
+	}
+}
+
+func TestJobName(t *testing.T) {
+	tests := map[string]string{
+		"unit-tests.log":    "unit-tests",
+		"deploy.log.gz":     "deploy",
+		"build":             "build",
+		"e2e.chrome.txt":    "e2e.chrome",
+		"integration.gz":    "integration",
+		"job (retry 2).log": "job (retry 2)",
+	}
+	for in, want := range tests {
+		if got := JobName(in); got != want {
+			t.Errorf("JobName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
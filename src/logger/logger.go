@@ -44,3 +44,38 @@ func NewSilentLogger() *SilentLogger {
 func (s *SilentLogger) Info(msg string, args ...interface{})  {}
 func (s *SilentLogger) Error(msg string, args ...interface{}) {}
 func (s *SilentLogger) Debug(msg string, args ...interface{}) {}
+
+// Log levels passed to a HookLogger hook.
+const (
+	LevelInfo  = "info"
+	LevelError = "error"
+	LevelDebug = "debug"
+)
+
+// HookLogger forwards every message to a base logger and then to a hook,
+// e.g. to publish agent logs to the broker for self-triage.
+type HookLogger struct {
+	base Logger
+	hook func(level, message string)
+}
+
+// NewHookLogger wraps base so each formatted message is also passed to hook.
+// The hook must not log through the returned logger.
+func NewHookLogger(base Logger, hook func(level, message string)) *HookLogger {
+	return &HookLogger{base: base, hook: hook}
+}
+
+func (h *HookLogger) Info(msg string, args ...interface{}) {
+	h.base.Info(msg, args...)
+	h.hook(LevelInfo, fmt.Sprintf(msg, args...))
+}
+
+func (h *HookLogger) Error(msg string, args ...interface{}) {
+	h.base.Error(msg, args...)
+	h.hook(LevelError, fmt.Sprintf(msg, args...))
+}
+
+func (h *HookLogger) Debug(msg string, args ...interface{}) {
+	h.base.Debug(msg, args...)
+	h.hook(LevelDebug, fmt.Sprintf(msg, args...))
+}
@@ -0,0 +1,117 @@
+// Package marks records the findings a user has triaged or ignored, by
+// message hash, in a local state file (default ~/.destill/marks.json,
+// override with DESTILL_MARKS_FILE). The same failure in a later build has
+// the same message hash, so it shows up as already handled.
+package marks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+// EnvMarksFile overrides the marks file path.
+const EnvMarksFile = "DESTILL_MARKS_FILE"
+
+// States of a marked finding.
+const (
+	Triaged = "triaged" // Looked at; shown dimmed
+	Ignored = "ignored" // Noise; hidden
+)
+
+// Mark is the state of one finding. Message and JobName describe the
+// finding it was set on, for reading the file.
+type Mark struct {
+	State    string    `json:"state"`
+	Message  string    `json:"message,omitempty"`
+	JobName  string    `json:"job_name,omitempty"`
+	MarkedAt time.Time `json:"marked_at"`
+}
+
+// File is the marks read from a state file. Safe for concurrent use.
+type File struct {
+	path string
+
+	mu    sync.Mutex
+	marks map[string]Mark // message hash -> mark
+}
+
+// DefaultPath returns the marks file path, honoring DESTILL_MARKS_FILE.
+func DefaultPath() string {
+	if path := os.Getenv(EnvMarksFile); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".destill", "marks.json")
+}
+
+// Load reads the marks at path. A missing file has no marks; it's created
+// by the first Set.
+func Load(path string) (*File, error) {
+	if path == "" {
+		return nil, errors.New("no marks file: set " + EnvMarksFile)
+	}
+	f := &File{path: path, marks: make(map[string]Mark)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read marks: %w", err)
+	}
+	if err := json.Unmarshal(data, &f.marks); err != nil {
+		return nil, fmt.Errorf("%s: failed to parse marks: %w", path, err)
+	}
+	return f, nil
+}
+
+// Path returns the file the marks are saved to.
+func (f *File) Path() string {
+	return f.path
+}
+
+// State returns the state of the finding with messageHash, or "" if it
+// isn't marked.
+func (f *File) State(messageHash string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.marks[messageHash].State
+}
+
+// Set marks a card's finding with state (Triaged or Ignored), or unmarks
+// it when state is empty, and saves the file.
+func (f *File) Set(card contracts.TriageCard, state string, now time.Time) error {
+	if state != "" && state != Triaged && state != Ignored {
+		return fmt.Errorf("unknown mark %q (expected %s or %s)", state, Triaged, Ignored)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if state == "" {
+		delete(f.marks, card.MessageHash)
+	} else {
+		f.marks[card.MessageHash] = Mark{State: state, Message: card.NormalizedMsg, JobName: card.JobName, MarkedAt: now.UTC()}
+	}
+
+	data, err := json.MarshalIndent(f.marks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode marks: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create marks directory: %w", err)
+	}
+	if err := os.WriteFile(f.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write marks: %w", err)
+	}
+	return nil
+}
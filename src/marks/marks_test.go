@@ -0,0 +1,73 @@
+package marks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "marks.json")
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() of a missing file error = %v", err)
+	}
+
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	noise := contracts.TriageCard{MessageHash: "hash-noise", NormalizedMsg: "npm WARN deprecated", JobName: "build"}
+	flake := contracts.TriageCard{MessageHash: "hash-flake", NormalizedMsg: "connection reset", JobName: "tests"}
+	if err := f.Set(noise, Ignored, now); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := f.Set(flake, Triaged, now); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := f.Set(flake, "done", now); err == nil {
+		t.Error("Set() with an unknown state: want an error")
+	}
+
+	// Marks survive a reload, by message hash
+	f, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := f.State("hash-noise"); got != Ignored {
+		t.Errorf("State(hash-noise) = %q, want %q", got, Ignored)
+	}
+	if got := f.State("hash-flake"); got != Triaged {
+		t.Errorf("State(hash-flake) = %q, want %q", got, Triaged)
+	}
+	if got := f.State("hash-other"); got != "" {
+		t.Errorf("State(hash-other) = %q, want unmarked", got)
+	}
+
+	if err := f.Set(flake, "", now); err != nil {
+		t.Fatalf("Set() to unmark error = %v", err)
+	}
+	if f, err = Load(path); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := f.State("hash-flake"); got != "" {
+		t.Errorf("after unmarking, State(hash-flake) = %q, want unmarked", got)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "marks.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() of an invalid file: want an error")
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv(EnvMarksFile, "/tmp/my-marks.json")
+	if got := DefaultPath(); got != "/tmp/my-marks.json" {
+		t.Errorf("DefaultPath() = %q, want %s", got, "/tmp/my-marks.json")
+	}
+}
@@ -0,0 +1,152 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/ranking"
+)
+
+// Defaults for list_recent_failures.
+const (
+	DefaultRecentWindow      = 7 * 24 * time.Hour // How far back to look
+	DefaultRecentLimit       = 10                 // Failed builds listed
+	DefaultRecentTopFindings = 3                  // Findings listed per build
+)
+
+// findingsReader is implemented by stores that read findings by time, such
+// as store.Persistent, or a store.FallbackStore in front of one.
+type findingsReader interface {
+	GetFindingsSince(ctx context.Context, since time.Time) ([]contracts.TriageCard, error)
+}
+
+// RecentFailuresResponse is the response from list_recent_failures.
+type RecentFailuresResponse struct {
+	Since    string          `json:"since"`              // RFC3339
+	Pipeline string          `json:"pipeline,omitempty"` // Empty for every pipeline
+	Builds   int             `json:"builds"`             // Builds in the window with findings stored
+	Failed   int             `json:"failed"`             // Of which failed
+	Failures []RecentFailure `json:"failures"`           // Newest first, up to the limit
+
+	// The unique failures that broke the most builds in the window
+	Recurring []RecurringFailure `json:"recurring,omitempty"`
+}
+
+// RecentFailure is a failed build, with its top findings. Use
+// get_finding_details with its request ID to drill into them.
+type RecentFailure struct {
+	RequestID   string           `json:"request_id"`
+	Build       BuildInfo        `json:"build"`
+	Pipeline    string           `json:"pipeline,omitempty"`
+	Branch      string           `json:"branch,omitempty"`
+	TopFindings []FindingSummary `json:"top_findings"`
+}
+
+// RecurringFailure is a unique failure seen in several failed builds.
+type RecurringFailure struct {
+	ID         string `json:"id"` // MessageHash
+	Message    string `json:"message"`
+	Builds     int    `json:"builds"`
+	LastSeenIn string `json:"last_seen_in"` // Request ID of the newest build
+}
+
+// RecentFailures groups stored findings by request and lists the requests
+// of failed builds of pipeline (every pipeline when ""), newest first, up
+// to limit, each with its top findings by rank: unique failures first.
+func RecentFailures(cards []contracts.TriageCard, pipeline string, limit, topFindings int) RecentFailuresResponse {
+	byRequest := make(map[string][]contracts.TriageCard)
+	var order []string
+	for _, card := range cards {
+		if pipeline != "" && !strings.EqualFold(card.Metadata["pipeline_name"], pipeline) {
+			continue
+		}
+		if _, ok := byRequest[card.RequestID]; !ok {
+			order = append(order, card.RequestID)
+		}
+		byRequest[card.RequestID] = append(byRequest[card.RequestID], card)
+	}
+
+	response := RecentFailuresResponse{Pipeline: pipeline, Builds: len(order), Failures: []RecentFailure{}}
+	recurring := make(map[string]*RecurringFailure)
+	var failures []RecentFailure
+	analyzed := make(map[string]string)
+	for _, requestID := range order {
+		requestCards := byRequest[requestID]
+		build := extractBuildInfo(requestCards, requestCards[0].BuildURL)
+		if build.Status != "failed" {
+			continue
+		}
+		slices.Sort(build.FailedJobs)
+		build.Timestamp = latestTimestamp(requestCards)
+		analyzed[requestID] = build.Timestamp
+
+		tiered := ranking.RankCards(requestCards)
+		failure := RecentFailure{
+			RequestID:   requestID,
+			Build:       build,
+			Pipeline:    requestCards[0].Metadata["pipeline_name"],
+			Branch:      requestCards[0].Metadata["branch"],
+			TopFindings: []FindingSummary{},
+		}
+		for _, rc := range slices.Concat(tiered.Unique, tiered.Noise) {
+			if len(failure.TopFindings) == topFindings {
+				break
+			}
+			failure.TopFindings = append(failure.TopFindings, toSummary(convertToFinding(rc.Card, false, rc.Tier), rc.Tier))
+		}
+		failures = append(failures, failure)
+
+		for _, rc := range tiered.Unique {
+			r := recurring[rc.Card.MessageHash]
+			if r == nil {
+				r = &RecurringFailure{ID: rc.Card.MessageHash, Message: toSummary(convertToFinding(rc.Card, false, rc.Tier), rc.Tier).Message}
+				recurring[rc.Card.MessageHash] = r
+			}
+			r.Builds++
+			if analyzed[requestID] >= analyzed[r.LastSeenIn] {
+				r.LastSeenIn = requestID
+			}
+		}
+	}
+
+	// RFC3339 timestamps in UTC sort as strings
+	slices.SortStableFunc(failures, func(a, b RecentFailure) int {
+		return strings.Compare(b.Build.Timestamp, a.Build.Timestamp)
+	})
+	response.Failed = len(failures)
+	if limit > 0 && len(failures) > limit {
+		failures = failures[:limit]
+	}
+	response.Failures = append(response.Failures, failures...)
+
+	for _, r := range recurring {
+		if r.Builds > 1 {
+			response.Recurring = append(response.Recurring, *r)
+		}
+	}
+	slices.SortFunc(response.Recurring, func(a, b RecurringFailure) int {
+		return cmp.Or(b.Builds-a.Builds, strings.Compare(a.ID, b.ID))
+	})
+	if limit > 0 && len(response.Recurring) > limit {
+		response.Recurring = response.Recurring[:limit]
+	}
+	return response
+}
+
+// latestTimestamp returns the latest analysis time of cards, in RFC3339.
+func latestTimestamp(cards []contracts.TriageCard) string {
+	var latest time.Time
+	for _, card := range cards {
+		if t, err := time.Parse(time.RFC3339, card.Timestamp); err == nil && t.After(latest) {
+			latest = t
+		}
+	}
+	if latest.IsZero() {
+		return ""
+	}
+	return latest.UTC().Format(time.RFC3339)
+}
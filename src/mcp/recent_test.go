@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/store"
+)
+
+func TestRecentFailures(t *testing.T) {
+	card := func(requestID, pipeline, job, state, hash, timestamp string, confidence float64) contracts.TriageCard {
+		return contracts.TriageCard{
+			RequestID:       requestID,
+			BuildURL:        "https://ci.example.com/" + requestID,
+			JobName:         job,
+			MessageHash:     hash,
+			NormalizedMsg:   "error " + hash,
+			RawMessage:      "error " + hash,
+			ConfidenceScore: confidence,
+			Timestamp:       timestamp,
+			Metadata:        map[string]string{"pipeline_name": pipeline, "job_state": state},
+		}
+	}
+	cards := []contracts.TriageCard{
+		card("req-1", "api", "test", "failed", "db-down", "2026-10-12T10:00:00Z", 0.9),
+		card("req-1", "api", "test", "failed", "warning", "2026-10-12T10:00:00Z", 0.5),
+		card("req-2", "api", "lint", "passed", "warning", "2026-10-13T10:00:00Z", 0.5),
+		card("req-3", "api", "test", "failed", "db-down", "2026-10-14T10:00:00Z", 0.9),
+		card("req-4", "web", "build", "failed", "npm", "2026-10-15T10:00:00Z", 0.8),
+	}
+
+	response := RecentFailures(cards, "API", 10, 1)
+	if response.Builds != 3 || response.Failed != 2 || len(response.Failures) != 2 {
+		t.Fatalf("RecentFailures(api) = %d builds, %d failed, %d listed; want 3, 2, 2", response.Builds, response.Failed, len(response.Failures))
+	}
+	newest := response.Failures[0]
+	if newest.RequestID != "req-3" || newest.Build.Status != "failed" || len(newest.Build.FailedJobs) != 1 || newest.Pipeline != "api" {
+		t.Errorf("newest failure = %+v, want req-3 of api", newest)
+	}
+	if top := response.Failures[1].TopFindings; len(top) != 1 || top[0].ID != "db-down" {
+		t.Errorf("req-1 top findings = %+v, want only db-down", top)
+	}
+	if len(response.Recurring) != 1 || response.Recurring[0].ID != "db-down" || response.Recurring[0].Builds != 2 || response.Recurring[0].LastSeenIn != "req-3" {
+		t.Errorf("recurring = %+v, want db-down in 2 builds, last in req-3", response.Recurring)
+	}
+
+	if all := RecentFailures(cards, "", 1, 3); all.Failed != 3 || len(all.Failures) != 1 || all.Failures[0].RequestID != "req-4" {
+		t.Errorf("RecentFailures(every pipeline, limit 1) = %+v, want req-4 of 3 failed", all)
+	}
+}
+
+func TestListRecentFailuresNeedsPersistentStore(t *testing.T) {
+	srv := NewServer(store.NewInMemoryStore())
+	var request mcp.CallToolRequest
+	request.Params.Arguments = map[string]any{"pipeline": "api"}
+	result, err := srv.handleListRecentFailures(context.Background(), request)
+	if err != nil {
+		t.Fatalf("handleListRecentFailures() error = %v", err)
+	}
+	if !result.IsError {
+		t.Errorf("IsError = false without a store reading findings by time: %+v", result.Content)
+	}
+
+	srv = NewServer(store.NewFallbackStore(store.NewInMemoryStore(), store.NewInMemoryStore()))
+	request.Params.Arguments = map[string]any{"since": "soon"}
+	if result, _ := srv.handleListRecentFailures(context.Background(), request); !result.IsError {
+		t.Error("IsError = false for an invalid since")
+	}
+}
@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"destill-agent/src/sanitize"
+	"destill-agent/src/store"
+)
+
+// Resource URI templates: a request's findings, as the analyze_build
+// manifest, and the tail of one of its jobs' logs. Job names are
+// percent-encoded.
+const (
+	FindingsResourceTemplate = "destill://request/{id}/findings"
+	JobLogResourceTemplate   = "destill://request/{id}/job/{name}/log"
+)
+
+// registerResources registers the resource templates, for clients that
+// browse analysis output by reading resources rather than calling tools.
+func (s *Server) registerResources() {
+	findings := mcp.NewResourceTemplate(FindingsResourceTemplate, "Build findings",
+		mcp.WithTemplateDescription("The tiered findings of an analyzed request, as analyze_build returns them"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	jobLog := mcp.NewResourceTemplate(JobLogResourceTemplate, "Job log",
+		mcp.WithTemplateDescription(fmt.Sprintf("The last %d lines of a job's log (of its last step, for a log split by step), by job name or ID; use get_job_log for other lines", store.MaxExcerptLines)),
+		mcp.WithTemplateMIMEType("text/plain"),
+	)
+
+	s.mcpServer.AddResourceTemplate(findings, s.readFindingsResource)
+	s.mcpServer.AddResourceTemplate(jobLog, s.readJobLogResource)
+}
+
+// parseResourceURI splits a destill:// resource URI into its request ID
+// and the rest of its path, e.g. "findings" or "job/{name}/log".
+func parseResourceURI(uri string) (requestID string, rest []string, err error) {
+	path, ok := strings.CutPrefix(uri, "destill://request/")
+	if !ok {
+		return "", nil, fmt.Errorf("not a destill request resource: %s", uri)
+	}
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if parts[i], err = url.PathUnescape(part); err != nil {
+			return "", nil, fmt.Errorf("invalid resource URI %s: %w", uri, err)
+		}
+	}
+	return parts[0], parts[1:], nil
+}
+
+// readFindingsResource reads destill://request/{id}/findings.
+func (s *Server) readFindingsResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	uri := request.Params.URI
+	requestID, rest, err := parseResourceURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 1 || rest[0] != "findings" {
+		return nil, fmt.Errorf("unknown resource: %s", uri)
+	}
+
+	cards, err := s.store.GetFindings(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get findings: %w", err)
+	}
+	response := TierFindings(cards, 0)
+	if len(cards) > 0 {
+		response.Build = extractBuildInfo(cards, cards[0].BuildURL)
+	}
+
+	jsonBytes, err := json.Marshal(ToManifest(requestID, response))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal findings: %w", err)
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: "application/json", Text: string(jsonBytes)}}, nil
+}
+
+// readJobLogResource reads destill://request/{id}/job/{name}/log.
+func (s *Server) readJobLogResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	uri := request.Params.URI
+	requestID, rest, err := parseResourceURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 3 || rest[0] != "job" || rest[2] != "log" {
+		return nil, fmt.Errorf("unknown resource: %s", uri)
+	}
+	job := rest[1]
+
+	reader, ok := s.store.(store.ChunkReader)
+	if !ok {
+		return nil, errors.New("job logs are not stored")
+	}
+	chunks, err := reader.GetChunks(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job log of request %s: %w", requestID, err)
+	}
+
+	// Read the job's length, and which steps its log has, then its tail
+	excerpt, err := store.ReadExcerpt(chunks, job, "", 1, 0)
+	if err == nil && len(excerpt.Steps) > 0 {
+		excerpt, err = store.ReadExcerpt(chunks, job, excerpt.Steps[len(excerpt.Steps)-1], 1, 0)
+	}
+	if err == nil {
+		excerpt, err = store.ReadExcerpt(chunks, job, excerpt.Step, excerpt.TotalLines-store.MaxExcerptLines+1, excerpt.TotalLines)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log of job %q of request %s: %w", job, requestID, err)
+	}
+
+	text := strings.Join(sanitize.CleanLines(excerpt.Lines), "\n")
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: "text/plain", Text: text}}, nil
+}
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/store"
+)
+
+// readResource reads uri through the MCP server, as a client would.
+func readResource(t *testing.T, srv *Server, uri string) (mcp.TextResourceContents, bool) {
+	t.Helper()
+	message, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "resources/read",
+		"params":  map[string]any{"uri": uri},
+	})
+	response, _ := json.Marshal(srv.mcpServer.HandleMessage(context.Background(), message))
+	var result struct {
+		Result struct {
+			Contents []mcp.TextResourceContents `json:"contents"`
+		} `json:"result"`
+		Error any `json:"error"`
+	}
+	if err := json.Unmarshal(response, &result); err != nil {
+		t.Fatalf("invalid response %s: %v", response, err)
+	}
+	if result.Error != nil || len(result.Result.Contents) != 1 {
+		return mcp.TextResourceContents{}, false
+	}
+	return result.Result.Contents[0], true
+}
+
+func TestResources(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewInMemoryStore()
+	st.Store(ctx, "req-1", []contracts.TriageCard{{
+		ID:              "card-1",
+		RequestID:       "req-1",
+		MessageHash:     "hash-1",
+		JobName:         "test (linux)",
+		RawMessage:      "panic: nil map",
+		NormalizedMsg:   "panic: nil map",
+		ConfidenceScore: 0.9,
+		Metadata:        map[string]string{"job_id": "job-1", "job_state": "failed"},
+	}})
+	var lines []string
+	for i := 1; i <= store.MaxExcerptLines+10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	st.StoreChunks(ctx, []contracts.LogChunk{{
+		RequestID: "req-1", JobID: "job-1", JobName: "test (linux)",
+		Content: strings.Join(lines, "\n"), LineStart: 1, LineEnd: len(lines),
+		Metadata: map[string]string{"job_id": "job-1"},
+	}})
+	srv := NewServer(st)
+
+	contents, ok := readResource(t, srv, "destill://request/req-1/findings")
+	if !ok || contents.MIMEType != "application/json" {
+		t.Fatalf("findings resource = %+v, %v", contents, ok)
+	}
+	var manifest ManifestResponse
+	if err := json.Unmarshal([]byte(contents.Text), &manifest); err != nil {
+		t.Fatalf("invalid findings JSON: %v", err)
+	}
+	if manifest.RequestID != "req-1" || len(manifest.Tier1Findings) != 1 || manifest.Tier1Findings[0].ID != "hash-1" {
+		t.Errorf("findings resource = %+v, want the request's one unique failure", manifest)
+	}
+
+	contents, ok = readResource(t, srv, "destill://request/req-1/job/test%20%28linux%29/log")
+	if !ok || contents.MIMEType != "text/plain" {
+		t.Fatalf("job log resource = %+v, %v", contents, ok)
+	}
+	got := strings.Split(contents.Text, "\n")
+	if len(got) != store.MaxExcerptLines || got[0] != "line 11" || got[len(got)-1] != lines[len(lines)-1] {
+		t.Errorf("job log resource = %d lines from %q, want the last %d", len(got), got[0], store.MaxExcerptLines)
+	}
+
+	for _, uri := range []string{
+		"destill://request/req-other/findings",
+		"destill://request/req-1/job/lint/log",
+		"destill://request/req-1/job/test/steps",
+	} {
+		if contents, ok := readResource(t, srv, uri); ok {
+			t.Errorf("read %s = %+v, want an error", uri, contents)
+		}
+	}
+}
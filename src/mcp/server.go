@@ -14,8 +14,10 @@ import (
 
 	"destill-agent/src/broker"
 	"destill-agent/src/contracts"
+	"destill-agent/src/flaky"
 	"destill-agent/src/pipeline"
 	"destill-agent/src/provider"
+	"destill-agent/src/sanitize"
 	"destill-agent/src/store"
 )
 
@@ -23,21 +25,31 @@ import (
 type Server struct {
 	mcpServer *server.MCPServer
 	store     store.Store
+	timeouts  pipeline.CollectTimeouts
 }
 
-// NewServer creates a new MCP server with the given store.
+// DefaultCollectTimeouts bound how long analyze_build waits for findings:
+// 10s after the last one, and 2 minutes in all.
+var DefaultCollectTimeouts = pipeline.CollectTimeouts{Idle: 10 * time.Second, Hard: 2 * time.Minute}
+
+// NewServer creates a new MCP server with the given store. Analysis waits
+// for findings as DESTILL_IDLE_TIMEOUT and DESTILL_HARD_TIMEOUT set, by
+// default DefaultCollectTimeouts.
 func NewServer(st store.Store) *Server {
 	mcpSrv := server.NewMCPServer(
 		"destill",
 		"1.0.0",
 		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(false, false),
 	)
 
 	srv := &Server{
 		mcpServer: mcpSrv,
 		store:     st,
+		timeouts:  pipeline.CollectTimeoutsFromEnv(DefaultCollectTimeouts),
 	}
 	srv.registerTools()
+	srv.registerResources()
 
 	return srv
 }
@@ -48,7 +60,7 @@ func (s *Server) registerTools() {
 		mcp.WithDescription("Analyze a CI/CD build and return tiered findings. Returns all tier 1 findings (unique failures) fully expanded with context - these are the likely root causes. Tier 2-3 findings are summarized; use get_finding_details to drill into them if needed."),
 		mcp.WithString("url",
 			mcp.Required(),
-			mcp.Description("Build URL (Buildkite or GitHub Actions)"),
+			mcp.Description("Build URL (Buildkite, GitHub Actions, GitLab CI, CircleCI, or Jenkins)"),
 		),
 		mcp.WithNumber("limit",
 			mcp.Description("Max findings per tier (default: 15)"),
@@ -59,16 +71,69 @@ func (s *Server) registerTools() {
 		mcp.WithDescription("Get full details for a specific finding, including context lines. Use after analyze_build to drill into findings."),
 		mcp.WithString("request_id",
 			mcp.Required(),
-			mcp.Description("Request ID from analyze_build response"),
+			mcp.Description("Request ID from analyze_build response, or of a request submitted to the distributed agents"),
 		),
 		mcp.WithString("finding_id",
 			mcp.Required(),
 			mcp.Description("Finding ID (message_hash) from the manifest"),
 		),
+		mcp.WithNumber("context_lines",
+			mcp.Description(fmt.Sprintf("Lines of log to return on each side of the finding, read from the full log (default: the finding's own context, max: %d)", store.MaxContextLines)),
+		),
+	)
+
+	jobLogTool := mcp.NewTool("get_job_log",
+		mcp.WithDescription("Get an excerpt of a job's raw log from an analyzed request: a line range of a job, or the lines around a finding. Use to pull more evidence than a finding's context without analyzing the build again."),
+		mcp.WithString("request_id",
+			mcp.Required(),
+			mcp.Description("Request ID from analyze_build response, or of a request submitted to the distributed agents"),
+		),
+		mcp.WithString("job",
+			mcp.Description("Job name or ID; required unless finding_id is given"),
+		),
+		mcp.WithString("finding_id",
+			mcp.Description("Finding ID (message_hash): return the lines around it, in its job"),
+		),
+		mcp.WithNumber("start_line",
+			mcp.Description("First line of the job's log to return (default: 1)"),
+		),
+		mcp.WithNumber("end_line",
+			mcp.Description(fmt.Sprintf("Last line of the job's log to return (default and max: %d lines from start_line)", store.MaxExcerptLines)),
+		),
+		mcp.WithNumber("context_lines",
+			mcp.Description(fmt.Sprintf("With finding_id, lines to return on each side of the finding (default: %d)", defaultExcerptContext)),
+		),
+		mcp.WithString("step",
+			mcp.Description("Step index, for a job whose log is split by step (default: its first step; the response lists the others)"),
+		),
+	)
+
+	recentTool := mcp.NewTool("list_recent_failures",
+		mcp.WithDescription("List the recent failed builds analyzed by the distributed agents, with their top findings and the failures that broke the most of them. Use to answer what's been breaking CI without analyzing builds again; drill in with get_finding_details. Needs the Postgres store (POSTGRES_DSN)."),
+		mcp.WithString("pipeline",
+			mcp.Description("Pipeline name (default: every pipeline)"),
+		),
+		mcp.WithString("since",
+			mcp.Description("How far back to look, e.g. 7d, 2w, or 36h (default: 7d)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Max failed builds listed (default: %d)", DefaultRecentLimit)),
+		),
+		mcp.WithNumber("top_findings",
+			mcp.Description(fmt.Sprintf("Findings listed per build (default: %d)", DefaultRecentTopFindings)),
+		),
 	)
 
 	s.mcpServer.AddTool(analyzeTool, s.handleAnalyzeBuild)
 	s.mcpServer.AddTool(detailsTool, s.handleGetFindingDetails)
+	s.mcpServer.AddTool(jobLogTool, s.handleGetJobLog)
+	s.mcpServer.AddTool(recentTool, s.handleListRecentFailures)
+}
+
+// SetCollectTimeouts sets how long analyze_build waits for findings (see
+// pipeline.CollectTimeouts).
+func (s *Server) SetCollectTimeouts(timeouts pipeline.CollectTimeouts) {
+	s.timeouts = timeouts
 }
 
 // Run starts the MCP server on stdio.
@@ -104,12 +169,20 @@ func (s *Server) handleAnalyzeBuild(ctx context.Context, request mcp.CallToolReq
 		return mcp.NewToolResultError(fmt.Sprintf("failed to store findings: %v", err)), nil
 	}
 
+	// Annotate against the previous analysis of the same build, if any.
+	// Best effort: a failed lookup leaves the findings unannotated.
+	cards, rerun, _ := store.MarkRerun(ctx, s.store, cards)
+
+	// Classify against earlier builds of the pipeline, when Postgres has them
+	cards, _ = flaky.MarkFlakiness(ctx, s.store, cards, flaky.DefaultHistoryWindow)
+
 	// Tier findings on read
 	response := TierFindings(cards, limit)
 	response.Build = buildInfo
 
 	// Return lightweight manifest
 	manifest := ToManifest(requestID, response)
+	manifest.Rerun = rerun
 	jsonBytes, err := json.Marshal(manifest)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal response: %v", err)), nil
@@ -144,6 +217,21 @@ func (s *Server) handleGetFindingDetails(ctx context.Context, request mcp.CallTo
 	// Convert TriageCard to Finding
 	finding := CardToFinding(card)
 
+	// Widen the context from the stored log if asked
+	if lines := request.GetInt("context_lines", 0); lines > 0 {
+		fc, err := s.store.GetFindingContext(ctx, card.ID, lines, lines)
+		switch {
+		case err == nil:
+			finding.PreContext = sanitize.CleanLines(fc.Before)
+			finding.PostContext = sanitize.CleanLines(fc.After)
+			finding.ContextTruncated = fc.Truncated
+		case errors.Is(err, store.ErrNoChunks):
+			// Keep the finding's own context
+		default:
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get finding context: %v", err)), nil
+		}
+	}
+
 	// Return full finding with context
 	jsonBytes, err := json.Marshal(finding)
 	if err != nil {
@@ -153,15 +241,107 @@ func (s *Server) handleGetFindingDetails(ctx context.Context, request mcp.CallTo
 	return mcp.NewToolResultText(string(jsonBytes)), nil
 }
 
+// defaultExcerptContext is how many lines get_job_log returns on each side
+// of a finding by default.
+const defaultExcerptContext = 50
+
+// handleGetJobLog handles the get_job_log tool call.
+// Returns a bounded excerpt of a job's log, by line range or around a finding.
+func (s *Server) handleGetJobLog(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	requestID := request.GetString("request_id", "")
+	if requestID == "" {
+		return mcp.NewToolResultError("request_id parameter is required"), nil
+	}
+	job := request.GetString("job", "")
+	findingID := request.GetString("finding_id", "")
+	if job == "" && findingID == "" {
+		return mcp.NewToolResultError("job or finding_id parameter is required"), nil
+	}
+
+	reader, ok := s.store.(store.ChunkReader)
+	if !ok {
+		return mcp.NewToolResultError("job logs are not stored"), nil
+	}
+	chunks, err := reader.GetChunks(ctx, requestID)
+	if errors.Is(err, store.ErrNoChunks) {
+		return mcp.NewToolResultError(fmt.Sprintf("no job logs stored for request_id=%s", requestID)), nil
+	} else if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get job log: %v", err)), nil
+	}
+
+	step := request.GetString("step", "")
+	start := request.GetInt("start_line", 1)
+	end := request.GetInt("end_line", start+store.MaxExcerptLines-1)
+	if findingID != "" {
+		card, err := s.store.GetByHash(ctx, requestID, findingID)
+		if err != nil {
+			var notFound store.ErrNotFound
+			if errors.As(err, &notFound) {
+				return mcp.NewToolResultError(fmt.Sprintf("finding not found: request_id=%s, finding_id=%s", requestID, findingID)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get finding: %v", err)), nil
+		}
+		line, ok := store.FindingLine(chunks, card)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("log of finding not stored: request_id=%s, finding_id=%s", requestID, findingID)), nil
+		}
+		lines := min(max(request.GetInt("context_lines", defaultExcerptContext), 0), store.MaxExcerptLines/2)
+		job, step = card.Metadata["job_id"], card.Metadata["step_index"]
+		start, end = line-lines, line+lines
+	}
+
+	excerpt, err := store.ReadExcerpt(chunks, job, step, start, end)
+	if errors.Is(err, store.ErrNoChunks) {
+		return mcp.NewToolResultError(fmt.Sprintf("no log stored for job %q (step %q) of request_id=%s", job, step, requestID)), nil
+	}
+	excerpt.Lines = sanitize.CleanLines(excerpt.Lines)
+
+	jsonBytes, err := json.Marshal(excerpt)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal job log: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleListRecentFailures handles the list_recent_failures tool call.
+// Returns the recent failed builds of a pipeline from the stored findings.
+func (s *Server) handleListRecentFailures(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	reader, ok := s.store.(findingsReader)
+	if !ok {
+		return mcp.NewToolResultError("recent failures need the Postgres store; set POSTGRES_DSN"), nil
+	}
+
+	window := DefaultRecentWindow
+	if value := request.GetString("since", ""); value != "" {
+		var err error
+		if window, err = store.ParseRetention(value); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid since %q (expected e.g. 7d, 2w, or 36h)", value)), nil
+		}
+	}
+	since := time.Now().Add(-window)
+
+	cards, err := reader.GetFindingsSince(ctx, since)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get findings: %v", err)), nil
+	}
+	response := RecentFailures(cards, request.GetString("pipeline", ""),
+		request.GetInt("limit", DefaultRecentLimit), request.GetInt("top_findings", DefaultRecentTopFindings))
+	response.Since = since.UTC().Format(time.RFC3339)
+
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal response: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
 // runAnalysis runs the full analysis pipeline and collects cards.
 func (s *Server) runAnalysis(ctx context.Context, buildURL string) ([]contracts.TriageCard, BuildInfo, error) {
 	// Validate URL and token upfront to fail fast
-	ref, err := provider.ParseURL(buildURL)
-	if err != nil {
-		return nil, BuildInfo{}, provider.WrapError(err)
-	}
-	if err := provider.ValidateToken(ref); err != nil {
-		return nil, BuildInfo{}, provider.WrapError(err)
+	if _, err := provider.ValidateBuildURL(buildURL); err != nil {
+		return nil, BuildInfo{}, err
 	}
 
 	// Create in-memory broker and start pipeline
@@ -174,10 +354,25 @@ func (s *Server) runAnalysis(ctx context.Context, buildURL string) ([]contracts.
 	if err := pipeline.Start(msgBroker, pipelineCtx); err != nil {
 		return nil, BuildInfo{}, fmt.Errorf("failed to start pipeline: %w", err)
 	}
+	// Keep the log chunks for get_finding_details context_lines
+	if err := pipeline.StoreChunks(msgBroker, pipelineCtx, s.store); err != nil {
+		return nil, BuildInfo{}, fmt.Errorf("failed to start pipeline: %w", err)
+	}
+
+	// Subscribe before submitting, so no finding is published unheard
+	findings, err := msgBroker.Subscribe(pipelineCtx, contracts.TopicAnalysisFindings, "mcp-server")
+	if err != nil {
+		return nil, BuildInfo{}, fmt.Errorf("failed to subscribe: %w", err)
+	}
+	control, err := msgBroker.Subscribe(pipelineCtx, contracts.TopicControl, "mcp-server-control")
+	if err != nil {
+		return nil, BuildInfo{}, fmt.Errorf("failed to subscribe: %w", err)
+	}
 
 	// Submit analysis request
 	requestID := generateRequestID()
 	req := contracts.AnalysisRequest{
+		Version:   contracts.AnalysisRequestVersion,
 		RequestID: requestID,
 		BuildURL:  buildURL,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
@@ -189,7 +384,7 @@ func (s *Server) runAnalysis(ctx context.Context, buildURL string) ([]contracts.
 	msgBroker.Publish(ctx, contracts.TopicRequests, requestID, reqData)
 
 	// Collect findings with timeout
-	cards, err := s.collectFindings(ctx, msgBroker)
+	cards, err := s.collectFindings(ctx, requestID, findings, control)
 	if err != nil {
 		return nil, BuildInfo{}, err
 	}
@@ -200,34 +395,75 @@ func (s *Server) runAnalysis(ctx context.Context, buildURL string) ([]contracts.
 	return cards, buildInfo, nil
 }
 
-// collectFindings subscribes to findings and collects them until timeout.
-func (s *Server) collectFindings(ctx context.Context, msgBroker broker.Broker) ([]contracts.TriageCard, error) {
-	ch, err := msgBroker.Subscribe(ctx, contracts.TopicAnalysisFindings, "mcp-server")
-	if err != nil {
-		return nil, fmt.Errorf("failed to subscribe: %w", err)
-	}
-
+// collectFindings collects the findings arriving on ch until the analysis
+// of requestID completes (a ControlAnalysisComplete on control) and they've
+// all arrived. Should that never come, it stops at the hard timeout, or the
+// idle timeout once the first finding has arrived.
+func (s *Server) collectFindings(ctx context.Context, requestID string, ch, control <-chan broker.Message) ([]contracts.TriageCard, error) {
 	var cards []contracts.TriageCard
-	timeout := time.After(120 * time.Second)
-	lastActivity := time.Now()
+	analyzed, expected := false, 0
+	var idle, hard <-chan time.Time
+	if s.timeouts.Hard > 0 {
+		deadline := time.NewTimer(s.timeouts.Hard)
+		defer deadline.Stop()
+		hard = deadline.C
+	}
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
 
 	for {
 		select {
-		case msg := <-ch:
+		case msg, ok := <-ch:
+			if !ok {
+				return cards, nil
+			}
 			var card contracts.TriageCard
-			if err := json.Unmarshal(msg.Value, &card); err == nil {
-				cards = append(cards, card)
-				lastActivity = time.Now()
+			if err := json.Unmarshal(msg.Value, &card); err != nil {
+				continue
+			}
+			cards = append(cards, card)
+			if analyzed && len(cards) >= expected {
+				return cards, nil
+			}
+			if s.timeouts.Idle <= 0 {
+				continue
 			}
-		case <-timeout:
+			if timer == nil {
+				timer = time.NewTimer(s.timeouts.Idle)
+				idle = timer.C
+				continue
+			}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(s.timeouts.Idle)
+		case msg, ok := <-control:
+			if !ok {
+				control = nil
+				continue
+			}
+			var done contracts.ControlMessage
+			if err := json.Unmarshal(msg.Value, &done); err != nil ||
+				done.Type != contracts.ControlAnalysisComplete || done.RequestID != requestID {
+				continue
+			}
+			analyzed, expected = true, done.Findings
+			if len(cards) >= expected {
+				return cards, nil
+			}
+		case <-idle:
+			return cards, nil
+		case <-hard:
 			return cards, nil
 		case <-ctx.Done():
 			return cards, ctx.Err()
-		default:
-			if time.Since(lastActivity) > 10*time.Second && len(cards) > 0 {
-				return cards, nil
-			}
-			time.Sleep(100 * time.Millisecond)
 		}
 	}
 }
@@ -239,16 +475,18 @@ func extractBuildInfo(cards []contracts.TriageCard, url string) BuildInfo {
 	otherJobs := make(map[string]bool)
 
 	for _, card := range cards {
-		switch card.Metadata["job_state"] {
-		case "failed":
-			failedJobs[card.JobName] = true
-		case "passed":
-			passedJobs[card.JobName] = true
-		case "":
-			// Skip cards without job_state metadata
-		default:
-			// Track canceled, skipped, in_progress, etc.
-			otherJobs[card.JobName] = true
+		for _, job := range card.Jobs() {
+			switch card.Metadata["job_state"] {
+			case "failed":
+				failedJobs[job] = true
+			case "passed":
+				passedJobs[job] = true
+			case "":
+				// Skip cards without job_state metadata
+			default:
+				// Track canceled, skipped, in_progress, etc.
+				otherJobs[job] = true
+			}
 		}
 	}
 
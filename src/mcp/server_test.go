@@ -0,0 +1,193 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/pipeline"
+	"destill-agent/src/store"
+)
+
+func TestGetFindingDetailsFallsBackToStore(t *testing.T) {
+	ctx := context.Background()
+
+	// Findings analyzed by remote agents are only in the fallback store
+	remote := store.NewInMemoryStore()
+	remote.Store(ctx, "req-remote", []contracts.TriageCard{{
+		ID:          "row-1",
+		RequestID:   "req-remote",
+		MessageHash: "hash-1",
+		RawMessage:  "panic: nil map",
+		PreContext:  []string{"own context"},
+		LineInChunk: 2,
+	}})
+	remote.StoreChunks(ctx, []contracts.LogChunk{{RequestID: "req-remote", Content: "a\nb\npanic: nil map\nc\nd", LineStart: 1, LineEnd: 5}})
+	srv := NewServer(store.NewFallbackStore(store.NewInMemoryStore(), remote))
+
+	tests := []struct {
+		name        string
+		args        map[string]any
+		wantErr     bool
+		wantPre     []string
+		wantPostLen int
+	}{
+		{"stored finding", map[string]any{"request_id": "req-remote", "finding_id": "hash-1"}, false, []string{"own context"}, 0},
+		{"with context lines", map[string]any{"request_id": "req-remote", "finding_id": "hash-1", "context_lines": 2}, false, []string{"a", "b"}, 2},
+		{"unknown request", map[string]any{"request_id": "req-other", "finding_id": "hash-1"}, true, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request mcp.CallToolRequest
+			request.Params.Arguments = tt.args
+			result, err := srv.handleGetFindingDetails(ctx, request)
+			if err != nil {
+				t.Fatalf("handleGetFindingDetails() error = %v", err)
+			}
+			if result.IsError != tt.wantErr {
+				t.Fatalf("IsError = %v, want %v: %+v", result.IsError, tt.wantErr, result.Content)
+			}
+			if tt.wantErr {
+				return
+			}
+			var finding Finding
+			if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &finding); err != nil {
+				t.Fatalf("invalid finding JSON: %v", err)
+			}
+			if finding.Message != "panic: nil map" {
+				t.Errorf("Message = %q", finding.Message)
+			}
+			if !slices.Equal(finding.PreContext, tt.wantPre) {
+				t.Errorf("PreContext = %q, want %q", finding.PreContext, tt.wantPre)
+			}
+			if len(finding.PostContext) != tt.wantPostLen {
+				t.Errorf("PostContext = %q, want %d lines", finding.PostContext, tt.wantPostLen)
+			}
+		})
+	}
+}
+
+func TestExtractBuildInfo_MergedCard(t *testing.T) {
+	merged := contracts.TriageCard{JobName: "test (linux)", Metadata: map[string]string{"job_state": "failed"}}
+	merged.AddJobs("test (linux)", "test (macos)")
+	passing := contracts.TriageCard{JobName: "lint", Metadata: map[string]string{"job_state": "passed"}}
+
+	info := extractBuildInfo([]contracts.TriageCard{merged, passing}, "https://buildkite.com/org/pipe/builds/1")
+	slices.Sort(info.FailedJobs)
+	if !slices.Equal(info.FailedJobs, []string{"test (linux)", "test (macos)"}) {
+		t.Errorf("FailedJobs = %q, want every job of the merged card", info.FailedJobs)
+	}
+	if info.Status != "failed" || info.PassedJobsCount != 1 {
+		t.Errorf("Status = %q, PassedJobsCount = %d", info.Status, info.PassedJobsCount)
+	}
+}
+
+func TestCollectFindings_Timeouts(t *testing.T) {
+	srv := NewServer(store.NewInMemoryStore())
+	srv.SetCollectTimeouts(pipeline.CollectTimeouts{Idle: 20 * time.Millisecond, Hard: 200 * time.Millisecond})
+	card, _ := json.Marshal(contracts.TriageCard{ID: "card-1"})
+
+	// The idle timeout only starts with the first finding
+	ch := make(chan broker.Message, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		ch <- broker.Message{Value: card}
+	}()
+	cards, err := srv.collectFindings(context.Background(), "req", ch, nil)
+	if err != nil || len(cards) != 1 {
+		t.Fatalf("collectFindings() = %d cards, %v; want the finding after the idle timeout", len(cards), err)
+	}
+
+	// Without findings, the hard timeout ends collection
+	start := time.Now()
+	cards, err = srv.collectFindings(context.Background(), "req", make(chan broker.Message), nil)
+	if err != nil || len(cards) != 0 || time.Since(start) < 200*time.Millisecond {
+		t.Errorf("collectFindings() = %d cards, %v after %v; want none after the hard timeout", len(cards), err, time.Since(start))
+	}
+}
+
+func TestCollectFindings_Complete(t *testing.T) {
+	srv := NewServer(store.NewInMemoryStore())
+	srv.SetCollectTimeouts(pipeline.CollectTimeouts{Idle: time.Minute})
+	card, _ := json.Marshal(contracts.TriageCard{ID: "card-1"})
+	other, _ := json.Marshal(contracts.ControlMessage{Type: contracts.ControlAnalysisComplete, RequestID: "other"})
+	done, _ := json.Marshal(contracts.ControlMessage{Type: contracts.ControlAnalysisComplete, RequestID: "req", Findings: 1})
+
+	// The completion can arrive before the last finding: collection waits
+	// for it, then stops without waiting out the idle timeout
+	ch := make(chan broker.Message, 1)
+	control := make(chan broker.Message, 2)
+	control <- broker.Message{Value: other}
+	control <- broker.Message{Value: done}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		ch <- broker.Message{Value: card}
+	}()
+	start := time.Now()
+	cards, err := srv.collectFindings(context.Background(), "req", ch, control)
+	if err != nil || len(cards) != 1 || time.Since(start) > 10*time.Second {
+		t.Errorf("collectFindings() = %d cards, %v after %v; want the finding, once the analysis completes", len(cards), err, time.Since(start))
+	}
+}
+
+func TestGetJobLog(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewInMemoryStore()
+	st.Store(ctx, "req-1", []contracts.TriageCard{{
+		ID:          "card-1",
+		RequestID:   "req-1",
+		MessageHash: "hash-1",
+		RawMessage:  "panic: nil map",
+		ChunkIndex:  1,
+		LineInChunk: 1,
+		Metadata:    map[string]string{"job_id": "job-1"},
+	}})
+	st.StoreChunks(ctx, []contracts.LogChunk{
+		{RequestID: "req-1", JobID: "job-1", JobName: "tests", Content: "a\nb\nc", LineStart: 1, LineEnd: 3, Metadata: map[string]string{"job_id": "job-1"}},
+		{RequestID: "req-1", JobID: "job-1", JobName: "tests", ChunkIndex: 1, Content: "d\npanic: nil map\nf\ng", LineStart: 4, LineEnd: 7, Metadata: map[string]string{"job_id": "job-1"}},
+	})
+	srv := NewServer(st)
+
+	tests := []struct {
+		name      string
+		args      map[string]any
+		wantErr   bool
+		wantLines []string
+	}{
+		{"line range", map[string]any{"request_id": "req-1", "job": "tests", "start_line": 2, "end_line": 4}, false, []string{"b", "c", "d"}},
+		{"whole job", map[string]any{"request_id": "req-1", "job": "job-1"}, false, []string{"a", "b", "c", "d", "panic: nil map", "f", "g"}},
+		{"around finding", map[string]any{"request_id": "req-1", "finding_id": "hash-1", "context_lines": 1}, false, []string{"d", "panic: nil map", "f"}},
+		{"no job or finding", map[string]any{"request_id": "req-1"}, true, nil},
+		{"unknown job", map[string]any{"request_id": "req-1", "job": "lint"}, true, nil},
+		{"unknown request", map[string]any{"request_id": "req-other", "job": "tests"}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request mcp.CallToolRequest
+			request.Params.Arguments = tt.args
+			result, err := srv.handleGetJobLog(ctx, request)
+			if err != nil {
+				t.Fatalf("handleGetJobLog() error = %v", err)
+			}
+			if result.IsError != tt.wantErr {
+				t.Fatalf("IsError = %v, want %v: %+v", result.IsError, tt.wantErr, result.Content)
+			}
+			if tt.wantErr {
+				return
+			}
+			var excerpt store.LogExcerpt
+			if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &excerpt); err != nil {
+				t.Fatalf("invalid job log JSON: %v", err)
+			}
+			if !slices.Equal(excerpt.Lines, tt.wantLines) {
+				t.Errorf("Lines = %q, want %q", excerpt.Lines, tt.wantLines)
+			}
+		})
+	}
+}
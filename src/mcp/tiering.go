@@ -34,12 +34,20 @@ func CardToFinding(card contracts.TriageCard) Finding {
 		ID:          card.MessageHash,
 		Message:     sanitize.Clean(card.RawMessage),
 		Severity:    card.Severity,
+		Category:    card.Category,
 		Confidence:  card.ConfidenceScore,
 		Job:         card.JobName,
+		Step:        card.Metadata["step_name"],
 		JobState:    card.Metadata["job_state"],
 		Recurrence:  card.GetRecurrenceCount(),
 		PreContext:  sanitize.CleanLines(card.PreContext),
 		PostContext: sanitize.CleanLines(card.PostContext),
+		ContextNote: card.ContextNote,
+		Transient:   ranking.IsTransient(card),
+		NewInRerun:  ranking.IsNewInRerun(card),
+		Flakiness:   card.Metadata[contracts.MetadataFlakiness],
+		Summary:     card.Summary,
+		Remediation: card.Remediation,
 	}
 }
 
@@ -59,13 +67,21 @@ func convertToFinding(card contracts.TriageCard, alsoInPassing bool, tier int) F
 		ID:                card.MessageHash, // Stable identifier for drill-down
 		Message:           sanitize.Clean(card.RawMessage),
 		Severity:          card.Severity,
+		Category:          card.Category,
 		Confidence:        card.ConfidenceScore,
 		Job:               card.JobName,
+		Step:              card.Metadata["step_name"],
 		JobState:          card.Metadata["job_state"],
 		Recurrence:        card.GetRecurrenceCount(),
 		AlsoInPassingJobs: alsoInPassing,
 		PreContext:        sanitize.CleanLines(preContext),
 		PostContext:       sanitize.CleanLines(postContext),
+		ContextNote:       card.ContextNote,
+		Transient:         ranking.IsTransient(card),
+		NewInRerun:        ranking.IsNewInRerun(card),
+		Flakiness:         card.Metadata[contracts.MetadataFlakiness],
+		Summary:           card.Summary,
+		Remediation:       card.Remediation,
 	}
 }
 
@@ -139,6 +155,7 @@ func convertRankedToFindings(ranked []ranking.RankedCard, jobStates map[string]s
 		}
 		alsoInPassing := jobStates[rc.Card.NormalizedMsg] == "both"
 		finding := convertToFinding(rc.Card, alsoInPassing, rc.Tier)
+		finding.Score = rc.Score
 		if rc.Tier == ranking.TierNoise {
 			finding.PassingJobCount = ranking.CountPassingJobs(allCards, rc.Card.NormalizedMsg)
 		}
@@ -176,18 +193,10 @@ func ToManifest(requestID string, response TieredResponse) ManifestResponse {
 
 // compressFinding applies log compression to a Finding.
 func compressFinding(f Finding) Finding {
-	return Finding{
-		ID:                f.ID,
-		Message:           CompressLine(f.Message),
-		Severity:          f.Severity,
-		Confidence:        f.Confidence,
-		Job:               f.Job,
-		JobState:          f.JobState,
-		Recurrence:        f.Recurrence,
-		AlsoInPassingJobs: f.AlsoInPassingJobs,
-		PreContext:        CompressContextLines(f.PreContext),
-		PostContext:       CompressContextLines(f.PostContext),
-	}
+	f.Message = CompressLine(f.Message)
+	f.PreContext = CompressContextLines(f.PreContext)
+	f.PostContext = CompressContextLines(f.PostContext)
+	return f
 }
 
 // toSummary converts a Finding to a FindingSummary.
@@ -201,7 +210,9 @@ func toSummary(f Finding, tier int) FindingSummary {
 		Tier:       tier,
 		Message:    msg,
 		Severity:   f.Severity,
+		Category:   f.Category,
 		Confidence: f.Confidence,
 		Job:        f.Job,
+		Flakiness:  f.Flakiness,
 	}
 }
@@ -17,9 +17,12 @@ func TestConvertToFinding(t *testing.T) {
 		JobName:         "test-job",
 		PreContext:      []string{"\x1b[32mline1\x1b[0m", "line2"},
 		PostContext:     []string{"line3", "\x1b[33mline4\x1b[0m"},
+		ContextNote:     "truncated at chunk start",
 		Metadata: map[string]string{
 			"job_state":        "failed",
 			"recurrence_count": "5",
+
+			contracts.MetadataFlakiness: contracts.FlakinessNewRegression,
 		},
 	}
 
@@ -40,6 +43,12 @@ func TestConvertToFinding(t *testing.T) {
 	if finding.Severity != "ERROR" {
 		t.Errorf("Severity = %q, expected %q", finding.Severity, "ERROR")
 	}
+	if finding.Flakiness != contracts.FlakinessNewRegression {
+		t.Errorf("Flakiness = %q, expected %q", finding.Flakiness, contracts.FlakinessNewRegression)
+	}
+	if finding.ContextNote != "truncated at chunk start" {
+		t.Errorf("ContextNote = %q, expected the card's note", finding.ContextNote)
+	}
 	// Check context was sanitized
 	if len(finding.PreContext) != 2 || finding.PreContext[0] != "line1" {
 		t.Errorf("PreContext not properly sanitized: %v", finding.PreContext)
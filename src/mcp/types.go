@@ -1,7 +1,10 @@
 // Package mcp provides the MCP server implementation for LLM-optimized build analysis.
 package mcp
 
-import "destill-agent/src/contracts"
+import (
+	"destill-agent/src/contracts"
+	"destill-agent/src/ranking"
+)
 
 // TieredResponse is the MCP tool response structure.
 type TieredResponse struct {
@@ -26,14 +29,41 @@ type Finding struct {
 	ID                string   `json:"id"` // MessageHash - stable identifier for drill-down
 	Message           string   `json:"message"`
 	Severity          string   `json:"severity"`
+	Category          string   `json:"category,omitempty"` // Language or tool ecosystem, e.g. "go" or "docker"
 	Confidence        float64  `json:"confidence"`
 	Job               string   `json:"job"`
+	Step              string   `json:"step,omitempty"` // Set by providers that split logs by step
 	JobState          string   `json:"job_state"`
 	Recurrence        int      `json:"recurrence"`
 	AlsoInPassingJobs bool     `json:"also_in_passing_jobs"`
 	PreContext        []string `json:"pre_context"`
 	PostContext       []string `json:"post_context"`
 
+	// Set when context_lines asked for more context than the size caps allow
+	ContextTruncated bool `json:"context_truncated,omitempty"`
+
+	// Why the card's context is shorter than configured, e.g. "truncated at chunk start"
+	ContextNote string `json:"context_note,omitempty"`
+
+	// Set when the finding disappeared after its job was retried
+	Transient bool `json:"transient,omitempty"`
+
+	// Set when the finding was not present in the build's previous analysis
+	NewInRerun bool `json:"new_in_rerun,omitempty"`
+
+	// Set when the finding was classified against earlier builds of the
+	// pipeline: "likely_flaky" or "new_regression" (see flaky.Classify)
+	Flakiness string `json:"flakiness,omitempty"`
+
+	// Composite ranking score (see ranking.SortCards); findings are listed by it
+	Score float64 `json:"score,omitempty"`
+
+	// Set when the LLM summarization stage ran on the finding
+	Summary *contracts.Summary `json:"summary,omitempty"`
+
+	// Set when a remediation rule matched the finding
+	Remediation *contracts.Remediation `json:"remediation,omitempty"`
+
 	// Tier 2 specific
 	RecurrenceThisBuild int `json:"recurrence_this_build,omitempty"`
 	AvgRecurrence       int `json:"avg_recurrence,omitempty"`
@@ -49,8 +79,10 @@ type FindingSummary struct {
 	Tier       int     `json:"tier"`
 	Message    string  `json:"message"`    // Truncated to ~100 chars
 	Severity   string  `json:"severity"`
+	Category   string  `json:"category,omitempty"`
 	Confidence float64 `json:"confidence"`
 	Job        string  `json:"job"`
+	Flakiness  string  `json:"flakiness,omitempty"`
 }
 
 // ManifestResponse is the response from analyze_build.
@@ -61,6 +93,9 @@ type ManifestResponse struct {
 	Build         BuildInfo        `json:"build"`
 	Tier1Findings []Finding        `json:"tier_1_findings"`
 	OtherFindings []FindingSummary `json:"other_findings"`
+
+	// Set when the same build was analyzed before under another request
+	Rerun *ranking.RerunSummary `json:"rerun,omitempty"`
 }
 
 // ExtractRequestID extracts the request_id from triage cards.
@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/logger"
+)
+
+// Options configure an Agent.
+type Options struct {
+	Idle    time.Duration // Once the analysis completes, notify after this long without the findings still missing
+	Timeout time.Duration // Notify with what has arrived after this long
+}
+
+// pending is an opted-in request whose analysis hasn't completed yet.
+type pending struct {
+	buildURL string
+	cards    []contracts.TriageCard
+	complete bool      // The analysis agents published ControlAnalysisComplete
+	expected int       // Findings the analysis counted, once complete
+	started  time.Time // When the request was seen
+	last     time.Time // When the last message for the request arrived
+}
+
+// Agent watches requests that opted in to notifications (AnalysisOptions.Notify),
+// collects their findings, and notifies once the analysis has completed
+// (contracts.ControlAnalysisComplete) and the findings it counted arrived.
+type Agent struct {
+	broker   broker.Broker
+	logger   logger.Logger
+	notifier Notifier
+	opts     Options
+	now      func() time.Time
+
+	pending map[string]*pending // Request ID -> collected findings
+
+	drain     chan struct{} // Closed by Drain
+	drainOnce sync.Once
+}
+
+// NewAgent creates a notify agent.
+func NewAgent(brk broker.Broker, log logger.Logger, notifier Notifier, opts Options) *Agent {
+	if opts.Idle <= 0 {
+		opts.Idle = 10 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Minute
+	}
+	return &Agent{
+		broker:   brk,
+		logger:   log,
+		notifier: notifier,
+		opts:     opts,
+		now:      time.Now,
+		pending:  make(map[string]*pending),
+		drain:    make(chan struct{}),
+	}
+}
+
+// Drain stops the agent reading messages, for a graceful shutdown: Run
+// notifies right away for the requests whose analysis has completed,
+// without waiting for findings still missing, and returns nil. Requests
+// still being analyzed can't be reported and are dropped.
+func (a *Agent) Drain() {
+	a.drainOnce.Do(func() { close(a.drain) })
+}
+
+// Run subscribes to requests, findings, and control messages, and notifies
+// for completed requests until ctx is done.
+func (a *Agent) Run(ctx context.Context) error {
+	requests, err := a.broker.Subscribe(ctx, contracts.TopicRequests, "destill-notify-requests")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicRequests, err)
+	}
+	findings, err := a.broker.Subscribe(ctx, contracts.TopicAnalysisFindings, "destill-notify")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicAnalysisFindings, err)
+	}
+	control, err := a.broker.Subscribe(ctx, contracts.TopicControl, "destill-notify-control")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicControl, err)
+	}
+
+	a.logger.Info("[NotifyAgent] Waiting for requests that opted in to notifications...")
+	ticker := time.NewTicker(min(a.opts.Idle/2, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case msg, ok := <-requests:
+			if !ok {
+				return nil
+			}
+			a.handleRequest(msg)
+		case msg, ok := <-findings:
+			if !ok {
+				return nil
+			}
+			a.handleFinding(msg)
+		case msg, ok := <-control:
+			if !ok {
+				return nil
+			}
+			a.handleControl(msg)
+		case <-ticker.C:
+			a.flush(ctx)
+		case <-a.drain:
+			a.flushCompleted(ctx)
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (a *Agent) handleRequest(msg broker.Message) {
+	var request contracts.AnalysisRequest
+	if err := json.Unmarshal(msg.Value, &request); err != nil {
+		a.logger.Error("[NotifyAgent] Failed to unmarshal request: %v", err)
+		return
+	}
+	if request.Options == nil || !request.Options.Notify {
+		return
+	}
+	now := a.now()
+	a.pending[request.RequestID] = &pending{buildURL: request.BuildURL, started: now, last: now}
+}
+
+func (a *Agent) handleFinding(msg broker.Message) {
+	var card contracts.TriageCard
+	if err := json.Unmarshal(msg.Value, &card); err != nil {
+		a.logger.Error("[NotifyAgent] Failed to unmarshal finding: %v", err)
+		return
+	}
+	if p, ok := a.pending[card.RequestID]; ok {
+		p.cards = append(p.cards, card)
+		p.last = a.now()
+	}
+}
+
+func (a *Agent) handleControl(msg broker.Message) {
+	var control contracts.ControlMessage
+	if err := json.Unmarshal(msg.Value, &control); err != nil || control.Type != contracts.ControlAnalysisComplete {
+		return
+	}
+	if p, ok := a.pending[control.RequestID]; ok {
+		p.last = a.now()
+		p.complete, p.expected = true, control.Findings
+	}
+}
+
+// flush notifies for requests that completed, once their findings arrived
+// or stopped arriving, or timed out.
+func (a *Agent) flush(ctx context.Context) {
+	now := a.now()
+	for requestID, p := range a.pending {
+		settled := p.complete && (len(p.cards) >= p.expected || now.Sub(p.last) >= a.opts.Idle)
+		timedOut := now.Sub(p.started) >= a.opts.Timeout
+		if !settled && !timedOut {
+			continue
+		}
+		delete(a.pending, requestID)
+		if timedOut && !settled {
+			a.logger.Error("[NotifyAgent] Timed out waiting for %s after %v; notifying with %d findings", requestID, a.opts.Timeout, len(p.cards))
+		}
+		a.notify(ctx, requestID, p)
+	}
+}
+
+// flushCompleted notifies for every request whose analysis has completed
+// and drops the others, when draining.
+func (a *Agent) flushCompleted(ctx context.Context) {
+	for requestID, p := range a.pending {
+		delete(a.pending, requestID)
+		if !p.complete {
+			a.logger.Error("[NotifyAgent] Shutting down before %s was analyzed; not notifying", requestID)
+			continue
+		}
+		a.notify(ctx, requestID, p)
+	}
+}
+
+// notify sends the summary of a request's findings.
+func (a *Agent) notify(ctx context.Context, requestID string, p *pending) {
+	summary := Summarize(requestID, p.buildURL, p.cards)
+	if err := a.notifier.Notify(ctx, summary); err != nil {
+		a.logger.Error("[NotifyAgent] Failed to notify for %s: %v", requestID, err)
+		return
+	}
+	a.logger.Info("[NotifyAgent] Notified for %s: %d unique failures", requestID, len(summary.Unique))
+}
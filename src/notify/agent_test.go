@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/logger"
+)
+
+type recordingNotifier struct {
+	summaries []Summary
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, summary Summary) error {
+	n.summaries = append(n.summaries, summary)
+	return nil
+}
+
+func message(t *testing.T, v any) broker.Message {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	return broker.Message{Value: data}
+}
+
+func TestAgent_NotifiesOptedInRequests(t *testing.T) {
+	notifier := &recordingNotifier{}
+	a := NewAgent(nil, logger.NewSilentLogger(), notifier, Options{Idle: 10 * time.Second, Timeout: time.Hour})
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	a.handleRequest(message(t, contracts.AnalysisRequest{RequestID: "req-1", BuildURL: "https://ci/1",
+		Options: &contracts.AnalysisOptions{Notify: true}}))
+	a.handleRequest(message(t, contracts.AnalysisRequest{RequestID: "req-2", BuildURL: "https://ci/2"}))
+	for _, card := range testCards() {
+		card.RequestID = "req-1"
+		a.handleFinding(message(t, card))
+	}
+	a.handleFinding(message(t, contracts.TriageCard{RequestID: "req-2", NormalizedMsg: "ignored"}))
+
+	// Idle, but the analysis hasn't completed
+	now = now.Add(time.Minute)
+	a.flush(context.Background())
+	if len(notifier.summaries) != 0 {
+		t.Fatal("notified before the analysis completed")
+	}
+
+	// The analysis counted one more finding than has arrived
+	last := contracts.TriageCard{RequestID: "req-1", JobName: "lint", NormalizedMsg: "unused import", RawMessage: "unused import",
+		Metadata: map[string]string{"job_state": "passed"}}
+	a.handleControl(message(t, contracts.ControlMessage{Type: contracts.ControlAnalysisComplete, RequestID: "req-2"}))
+	a.handleControl(message(t, contracts.ControlMessage{Type: contracts.ControlAnalysisComplete, RequestID: "req-1", Findings: len(testCards()) + 1}))
+	now = now.Add(5 * time.Second)
+	a.flush(context.Background())
+	if len(notifier.summaries) != 0 {
+		t.Fatal("notified before every finding arrived")
+	}
+
+	a.handleFinding(message(t, last))
+	a.flush(context.Background())
+	if len(notifier.summaries) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(notifier.summaries))
+	}
+	s := notifier.summaries[0]
+	if s.RequestID != "req-1" || s.BuildURL != "https://ci/1" || len(s.Unique) != 2 {
+		t.Errorf("summary = %+v", s)
+	}
+
+	a.flush(context.Background())
+	if len(notifier.summaries) != 1 || len(a.pending) != 0 {
+		t.Error("request notified more than once")
+	}
+}
+
+func TestAgent_FindingsMissing(t *testing.T) {
+	notifier := &recordingNotifier{}
+	a := NewAgent(nil, logger.NewSilentLogger(), notifier, Options{Idle: 10 * time.Second, Timeout: time.Hour})
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	a.handleRequest(message(t, contracts.AnalysisRequest{RequestID: "req-1", Options: &contracts.AnalysisOptions{Notify: true}}))
+	a.handleControl(message(t, contracts.ControlMessage{Type: contracts.ControlAnalysisComplete, RequestID: "req-1", Findings: 1}))
+
+	// The finding counted never arrives: notify without it after Idle
+	now = now.Add(5 * time.Second)
+	a.flush(context.Background())
+	if len(notifier.summaries) != 0 {
+		t.Fatal("notified before the missing finding had time to arrive")
+	}
+	now = now.Add(5 * time.Second)
+	a.flush(context.Background())
+	if len(notifier.summaries) != 1 {
+		t.Errorf("got %d notifications once the missing finding stopped arriving, want 1", len(notifier.summaries))
+	}
+}
+
+func TestAgent_Timeout(t *testing.T) {
+	notifier := &recordingNotifier{}
+	a := NewAgent(nil, logger.NewSilentLogger(), notifier, Options{Idle: time.Second, Timeout: time.Minute})
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	a.handleRequest(message(t, contracts.AnalysisRequest{RequestID: "req-1", Options: &contracts.AnalysisOptions{Notify: true}}))
+	now = now.Add(time.Minute)
+	a.flush(context.Background())
+
+	if len(notifier.summaries) != 1 {
+		t.Errorf("got %d notifications after the timeout, want 1", len(notifier.summaries))
+	}
+}
+
+func TestAgent_FlushCompleted(t *testing.T) {
+	notifier := &recordingNotifier{}
+	a := NewAgent(nil, logger.NewSilentLogger(), notifier, Options{Idle: time.Minute, Timeout: time.Hour})
+
+	a.handleRequest(message(t, contracts.AnalysisRequest{RequestID: "req-1", Options: &contracts.AnalysisOptions{Notify: true}}))
+	a.handleRequest(message(t, contracts.AnalysisRequest{RequestID: "req-2", Options: &contracts.AnalysisOptions{Notify: true}}))
+	a.handleControl(message(t, contracts.ControlMessage{Type: contracts.ControlAnalysisComplete, RequestID: "req-1", Findings: 3}))
+
+	// Draining doesn't wait for req-1's missing findings, and drops req-2
+	a.flushCompleted(context.Background())
+	if len(notifier.summaries) != 1 || notifier.summaries[0].RequestID != "req-1" {
+		t.Errorf("notifications = %+v, want req-1 only", notifier.summaries)
+	}
+	if len(a.pending) != 0 {
+		t.Errorf("%d requests still pending after draining", len(a.pending))
+	}
+}
@@ -0,0 +1,271 @@
+// Package notify posts a summary of a request's findings to Slack when its
+// analysis completes in distributed mode.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/permalink"
+	"destill-agent/src/ranking"
+)
+
+// EnvSlackWebhook is the Slack incoming webhook URL summaries are posted to.
+const EnvSlackWebhook = "SLACK_WEBHOOK_URL"
+
+const (
+	// maxSlackFindings limits how many unique failures a message lists.
+	maxSlackFindings = 5
+	// maxSlackMessage caps each listed error message.
+	maxSlackMessage = 300
+)
+
+// Summary is what gets posted for a completed request.
+type Summary struct {
+	RequestID  string
+	BuildURL   string
+	Findings   int                    // Unique findings across all tiers
+	Unique     []contracts.TriageCard // Tier-1 unique failures, most confident first
+	FailedJobs []string               // Names of jobs that failed, sorted
+}
+
+// Summarize ranks a request's findings the same way the TUI does and picks
+// out the tier-1 unique failures and the jobs that failed. Findings
+// suppressed by the repo's ignore file are left out.
+func Summarize(requestID, buildURL string, cards []contracts.TriageCard) Summary {
+	shown := make([]contracts.TriageCard, 0, len(cards))
+	for _, card := range cards {
+		if !card.Suppressed() {
+			shown = append(shown, card)
+		}
+	}
+	tiered := ranking.RankCards(shown)
+	s := Summary{
+		RequestID: requestID,
+		BuildURL:  buildURL,
+		Findings:  len(tiered.Unique) + len(tiered.Noise),
+	}
+	for _, rc := range tiered.Unique {
+		s.Unique = append(s.Unique, rc.Card)
+	}
+
+	failed := make(map[string]bool)
+	for _, card := range cards {
+		if status := card.Metadata["exit_status"]; status != "" && status != "0" {
+			failed[card.JobName] = true
+		}
+	}
+	for job := range failed {
+		s.FailedJobs = append(s.FailedJobs, job)
+	}
+	sort.Strings(s.FailedJobs)
+	return s
+}
+
+// Notifier delivers a completed request's summary.
+type Notifier interface {
+	Notify(ctx context.Context, summary Summary) error
+}
+
+// Assignment is a finding someone was assigned to triage.
+type Assignment struct {
+	Card     contracts.TriageCard
+	Assignee string
+}
+
+// AssignmentNotifier tells people about findings assigned to them.
+type AssignmentNotifier interface {
+	NotifyAssignment(ctx context.Context, assignment Assignment) error
+}
+
+// SlackNotifier posts summaries and assignments to a Slack incoming webhook.
+type SlackNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewSlackNotifier creates a notifier that posts to webhookURL.
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{
+		url:        webhookURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, summary Summary) error {
+	return n.post(ctx, slackMessage(summary))
+}
+
+// NotifyAssignment posts that a finding was assigned to someone.
+func (n *SlackNotifier) NotifyAssignment(ctx context.Context, assignment Assignment) error {
+	return n.post(ctx, slackAssignmentMessage(assignment))
+}
+
+// post sends a message to the webhook.
+func (n *SlackNotifier) post(ctx context.Context, message slackPayload) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// slackPayload is a Slack message with Block Kit blocks; text is the
+// fallback shown in notifications.
+type slackPayload struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func markdownBlock(text string) slackBlock {
+	return slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}}
+}
+
+// slackMessage renders a summary: a headline, the build link, the failed
+// jobs, the top unique failures, and the command to see everything.
+func slackMessage(s Summary) slackPayload {
+	headline := fmt.Sprintf("Destill: %d unique failures in %d failed jobs", len(s.Unique), len(s.FailedJobs))
+	if len(s.Unique) == 0 {
+		headline = fmt.Sprintf("Destill: no unique failures (%d findings)", s.Findings)
+	}
+
+	build := fmt.Sprintf("Request `%s`", s.RequestID)
+	if s.BuildURL != "" {
+		build = fmt.Sprintf("<%s|%s> · request `%s`", s.BuildURL, slackEscape(s.BuildURL), s.RequestID)
+	}
+	blocks := []slackBlock{
+		{Type: "header", Text: &slackText{Type: "plain_text", Text: headline}},
+		markdownBlock(build),
+	}
+
+	if len(s.FailedJobs) > 0 {
+		jobs := make([]string, len(s.FailedJobs))
+		for i, job := range s.FailedJobs {
+			jobs[i] = slackEscape(job)
+		}
+		blocks = append(blocks, markdownBlock(fmt.Sprintf("*Failed jobs (%d):* %s", len(jobs), strings.Join(jobs, ", "))))
+	}
+
+	if len(s.Unique) > 0 {
+		var lines []string
+		for i, card := range s.Unique {
+			if i == maxSlackFindings {
+				lines = append(lines, fmt.Sprintf("… and %d more", len(s.Unique)-i))
+				break
+			}
+			message := card.RawMessage
+			if message == "" {
+				message = card.NormalizedMsg
+			}
+			if len(message) > maxSlackMessage {
+				message = message[:maxSlackMessage] + "..."
+			}
+			lines = append(lines, fmt.Sprintf("• *%.2f* _%s_: `%s`", card.ConfidenceScore, slackEscape(card.JobName),
+				strings.ReplaceAll(slackEscape(message), "`", "'")))
+			if card.MessageHash != "" {
+				lines = append(lines, fmt.Sprintf("    `destill view '%s'`", slackEscape(findingLink(s, card))))
+			}
+		}
+		blocks = append(blocks, markdownBlock("*Top unique failures:*\n"+strings.Join(lines, "\n")))
+	}
+
+	blocks = append(blocks, slackBlock{
+		Type:     "context",
+		Elements: []slackText{{Type: "mrkdwn", Text: fmt.Sprintf("All findings: `destill view %s`", s.RequestID)}},
+	})
+	return slackPayload{Text: headline, Blocks: blocks}
+}
+
+// slackAssignmentMessage renders an assignment: who it's for, the
+// finding's message and job, and the command to open it.
+func slackAssignmentMessage(a Assignment) slackPayload {
+	card := a.Card
+	headline := fmt.Sprintf("Destill: %s was assigned a failure in %s", a.Assignee, card.JobName)
+
+	message := card.RawMessage
+	if message == "" {
+		message = card.NormalizedMsg
+	}
+	if len(message) > maxSlackMessage {
+		message = message[:maxSlackMessage] + "..."
+	}
+	lines := []string{
+		fmt.Sprintf("%s, you were assigned a failure in _%s_:", slackMention(a.Assignee), slackEscape(card.JobName)),
+		fmt.Sprintf("`%s`", strings.ReplaceAll(slackEscape(message), "`", "'")),
+	}
+	if card.BuildURL != "" {
+		lines = append(lines, fmt.Sprintf("<%s|%s>", card.BuildURL, slackEscape(card.BuildURL)))
+	}
+
+	return slackPayload{Text: headline, Blocks: []slackBlock{
+		markdownBlock(strings.Join(lines, "\n")),
+		{
+			Type:     "context",
+			Elements: []slackText{{Type: "mrkdwn", Text: fmt.Sprintf("`destill view '%s'`", slackEscape(permalink.For(card).String()))}},
+		},
+	}}
+}
+
+// slackMemberID matches Slack user IDs, which Slack renders as mentions.
+var slackMemberID = regexp.MustCompile(`^[UW][A-Z0-9]{6,}$`)
+
+// slackMention mentions assignee if it's a Slack user ID, and otherwise
+// shows the name in bold.
+func slackMention(assignee string) string {
+	if slackMemberID.MatchString(assignee) {
+		return "<@" + assignee + ">"
+	}
+	return "*" + slackEscape(assignee) + "*"
+}
+
+// findingLink returns the permalink of one of the summary's findings.
+func findingLink(s Summary, card contracts.TriageCard) string {
+	link := permalink.For(card)
+	if link.RequestID == "" {
+		link.RequestID = s.RequestID
+	}
+	if link.BuildURL == "" {
+		link.BuildURL = s.BuildURL
+	}
+	return link.String()
+}
+
+// slackEscape escapes the characters Slack treats as markup.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
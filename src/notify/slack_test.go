@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func testCards() []contracts.TriageCard {
+	return []contracts.TriageCard{
+		{JobName: "unit", MessageHash: "f00d", NormalizedMsg: "panic: nil map", RawMessage: "panic: nil map", ConfidenceScore: 0.95,
+			Metadata: map[string]string{"exit_status": "1", "job_state": "failed"}},
+		{JobName: "e2e", NormalizedMsg: "timeout <30s>", RawMessage: "timeout <30s>", ConfidenceScore: 0.8,
+			Metadata: map[string]string{"exit_status": "2", "job_state": "failed"}},
+		{JobName: "lint", NormalizedMsg: "deprecated flag", RawMessage: "deprecated flag", ConfidenceScore: 0.6,
+			Metadata: map[string]string{"exit_status": "0", "job_state": "passed"}},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	s := Summarize("req-1", "https://buildkite.com/org/p/builds/1", testCards())
+
+	if s.Findings != 3 {
+		t.Errorf("Findings = %d, want 3", s.Findings)
+	}
+	if len(s.Unique) != 2 || s.Unique[0].JobName != "unit" || s.Unique[1].JobName != "e2e" {
+		t.Errorf("Unique = %+v, want unit then e2e", s.Unique)
+	}
+	if strings.Join(s.FailedJobs, ",") != "e2e,unit" {
+		t.Errorf("FailedJobs = %v, want [e2e unit]", s.FailedJobs)
+	}
+}
+
+func TestSummarize_Suppressed(t *testing.T) {
+	cards := testCards()
+	for i := range cards {
+		if cards[i].JobName == "unit" {
+			cards[i].Metadata[contracts.MetadataSuppressedBy] = "known unit noise"
+		}
+	}
+	s := Summarize("req-1", "https://buildkite.com/org/p/builds/1", cards)
+	if s.Findings != 2 || len(s.Unique) != 1 || s.Unique[0].JobName != "e2e" {
+		t.Errorf("Summarize() = %d findings, unique %+v; want the suppressed unit finding left out", s.Findings, s.Unique)
+	}
+}
+
+func TestSlackMessage(t *testing.T) {
+	msg := slackMessage(Summarize("req-1", "https://buildkite.com/org/p/builds/1", testCards()))
+
+	if msg.Text != "Destill: 2 unique failures in 2 failed jobs" {
+		t.Errorf("Text = %q", msg.Text)
+	}
+	var texts []string
+	for _, block := range msg.Blocks {
+		if block.Text != nil {
+			texts = append(texts, block.Text.Text)
+		}
+		for _, element := range block.Elements {
+			texts = append(texts, element.Text)
+		}
+	}
+	out := strings.Join(texts, "\n")
+	for _, want := range []string{
+		"Destill: 2 unique failures in 2 failed jobs",
+		"<https://buildkite.com/org/p/builds/1|",
+		`*Failed jobs (2):* e2e, unit`,
+		"*0.95* _unit_: `panic: nil map`",
+		"`timeout &lt;30s&gt;`",
+		"destill view req-1",
+		"`destill view 'destill://finding/f00d?build=https%3A%2F%2Fbuildkite.com%2Forg%2Fp%2Fbuilds%2F1&amp;request=req-1'`",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("message missing %q\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "deprecated flag") {
+		t.Error("message lists a noise finding")
+	}
+}
+
+func TestSlackMessage_NoUniqueFailures(t *testing.T) {
+	msg := slackMessage(Summarize("req-2", "", nil))
+	if msg.Text != "Destill: no unique failures (0 findings)" {
+		t.Errorf("Text = %q", msg.Text)
+	}
+}
+
+func TestSlackNotifier_Notify(t *testing.T) {
+	var got slackPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	if err := NewSlackNotifier(server.URL).Notify(context.Background(), Summarize("req-1", "", testCards())); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if got.Text == "" || len(got.Blocks) == 0 {
+		t.Errorf("payload = %+v", got)
+	}
+}
+
+func TestSlackNotifier_NotifyError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := NewSlackNotifier(server.URL).Notify(context.Background(), Summary{RequestID: "req-1"})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Notify() error = %v, want the 403 status", err)
+	}
+}
+
+func TestSlackAssignmentMessage(t *testing.T) {
+	card := testCards()[0]
+	card.RequestID = "req-1"
+	card.BuildURL = "https://buildkite.com/org/pipeline/builds/7"
+
+	msg := slackAssignmentMessage(Assignment{Card: card, Assignee: "U024BE7LH"})
+	text := msg.Blocks[0].Text.Text + "\n" + msg.Blocks[1].Elements[0].Text
+	for _, want := range []string{"<@U024BE7LH>, you were assigned a failure in _unit_", "panic: nil map", "destill://finding/f00d"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("assignment message missing %q: %s", want, text)
+		}
+	}
+
+	if got := slackMention("alex <ops>"); got != "*alex &lt;ops&gt;*" {
+		t.Errorf("slackMention(name) = %q, want the escaped name in bold", got)
+	}
+}
@@ -0,0 +1,162 @@
+// Package pager shows text a page at a time, like less, without taking over
+// the screen. Commands are read a line at a time, so it works in dumb
+// terminals, over serial consoles, and with screen readers.
+package pager
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultHeight is the page height when the terminal's isn't known.
+const DefaultHeight = 24
+
+// Help lists the pager commands.
+const Help = `Commands (press Enter after each):
+  Enter, f, space  next page
+  b                previous page
+  g / G            first / last page
+  /text            search forward (case-insensitive)
+  n                repeat the last search
+  <number>         go to that line
+  h                show this help
+  q                quit`
+
+// Pager pages lines to Out, reading commands from In.
+type Pager struct {
+	In     io.Reader
+	Out    io.Writer
+	Height int // Lines per screen, including the prompt line
+}
+
+// New returns a pager on stdin and stdout sized from $LINES.
+func New() *Pager {
+	return &Pager{In: os.Stdin, Out: os.Stdout, Height: Height()}
+}
+
+// Height returns the terminal height from $LINES, or DefaultHeight.
+func Height() int {
+	if lines, err := strconv.Atoi(os.Getenv("LINES")); err == nil && lines > 2 {
+		return lines
+	}
+	return DefaultHeight
+}
+
+// Interactive reports whether stdin and stdout are both terminals, so that
+// paging makes sense. When output goes to a file or CI log, callers should
+// write everything instead.
+func Interactive() bool {
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Page shows lines a page at a time until the user quits, the input ends,
+// or Enter is pressed on the last page. Text that fits on one page is
+// written without a prompt.
+func (p *Pager) Page(lines []string) error {
+	pageSize := max(p.Height-1, 1)
+	if len(lines) <= pageSize {
+		return p.write(lines)
+	}
+
+	in := bufio.NewScanner(p.In)
+	last := len(lines) - pageSize // Top line of the last page
+	top := 0
+	var search string
+	for {
+		if err := p.write(lines[top:min(top+pageSize, len(lines))]); err != nil {
+			return err
+		}
+
+		prompt := fmt.Sprintf(":lines %d-%d of %d (h for help)", top+1, min(top+pageSize, len(lines)), len(lines))
+		if top >= last {
+			prompt = "(END, Enter or q to quit)"
+		}
+		for {
+			if _, err := fmt.Fprintf(p.Out, "%s ", prompt); err != nil {
+				return err
+			}
+			if !in.Scan() {
+				fmt.Fprintln(p.Out)
+				return in.Err()
+			}
+
+			next, ok := top, true
+			cmd := strings.TrimSpace(in.Text())
+			switch {
+			case cmd == "" || cmd == "f":
+				if top >= last {
+					return nil
+				}
+				next = min(top+pageSize, last)
+			case cmd == "q" || cmd == "Q":
+				return nil
+			case cmd == "b":
+				next = max(top-pageSize, 0)
+			case cmd == "g":
+				next = 0
+			case cmd == "G":
+				next = last
+			case cmd == "h":
+				fmt.Fprintln(p.Out, Help)
+				ok = false
+			case strings.HasPrefix(cmd, "/") || cmd == "n":
+				if cmd != "n" {
+					search = strings.ToLower(cmd[1:])
+				}
+				if search == "" {
+					fmt.Fprintln(p.Out, "No previous search")
+					ok = false
+					break
+				}
+				match := find(lines, search, top+1)
+				if match < 0 {
+					fmt.Fprintf(p.Out, "Pattern not found: %s\n", search)
+					ok = false
+					break
+				}
+				next = min(match, last)
+			default:
+				line, err := strconv.Atoi(cmd)
+				if err != nil {
+					fmt.Fprintf(p.Out, "Unknown command %q (h for help)\n", cmd)
+					ok = false
+					break
+				}
+				next = min(max(line-1, 0), last)
+			}
+			if ok {
+				top = next
+				break
+			}
+		}
+	}
+}
+
+// find returns the index of the first line at or after from containing
+// the lowercase search text, or -1.
+func find(lines []string, search string, from int) int {
+	for i := from; i < len(lines); i++ {
+		if strings.Contains(strings.ToLower(lines[i]), search) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *Pager) write(lines []string) error {
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(p.Out, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package pager
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func numbered(n int) []string {
+	lines := make([]string, n)
+	for i := range n {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	return lines
+}
+
+func TestPage_FitsOnOneScreen(t *testing.T) {
+	var out strings.Builder
+	p := &Pager{In: strings.NewReader(""), Out: &out, Height: 10}
+
+	if err := p.Page(numbered(3)); err != nil {
+		t.Fatalf("Page() error = %v", err)
+	}
+	if got := out.String(); got != "line 1\nline 2\nline 3\n" {
+		t.Errorf("Page() wrote %q, want the lines without a prompt", got)
+	}
+}
+
+func TestPage_Commands(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string // Substrings expected in order
+		avoid string
+	}{
+		{
+			name:  "enter pages to the end and quits",
+			input: "\n\n\n",
+			want:  []string{"line 4\n:lines 1-4 of 10", "line 8\n:lines 5-8", "line 10\n(END"},
+		},
+		{
+			name:  "quit",
+			input: "q\n",
+			want:  []string{":lines 1-4 of 10"},
+			avoid: "line 5",
+		},
+		{
+			name:  "back and last",
+			input: "G\nb\nq\n",
+			want:  []string{"line 7\nline 8\nline 9\nline 10\n(END", "line 3\nline 4\nline 5\nline 6\n:lines 3-6"},
+		},
+		{
+			name:  "go to line",
+			input: "6\nq\n",
+			want:  []string{"line 6\nline 7\nline 8\nline 9\n:lines 6-9"},
+		},
+		{
+			name:  "search and repeat",
+			input: "/LINE 5\ng\nn\nq\n",
+			want:  []string{"line 5\nline 6", ":lines 1-4", "line 5\nline 6"},
+		},
+		{
+			name:  "search not found stays on the page",
+			input: "/missing\nq\n",
+			want:  []string{"Pattern not found: missing\n:lines 1-4"},
+		},
+		{
+			name:  "help and unknown commands",
+			input: "h\nx\nq\n",
+			want:  []string{"Commands (press Enter after each)", "Unknown command \"x\""},
+		},
+		{
+			name:  "end of input quits",
+			input: "",
+			want:  []string{":lines 1-4 of 10"},
+			avoid: "line 5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			p := &Pager{In: strings.NewReader(tt.input), Out: &out, Height: 5}
+			if err := p.Page(numbered(10)); err != nil {
+				t.Fatalf("Page() error = %v", err)
+			}
+
+			got := out.String()
+			rest := got
+			for _, want := range tt.want {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("output missing %q (in order)\n%s", want, got)
+				}
+				rest = rest[i+len(want):]
+			}
+			if tt.avoid != "" && strings.Contains(got, tt.avoid) {
+				t.Errorf("output contains %q\n%s", tt.avoid, got)
+			}
+		})
+	}
+}
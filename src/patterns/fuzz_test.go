@@ -0,0 +1,40 @@
+package patterns
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"destill-agent/src/fuzzlimit"
+)
+
+func FuzzNormalize(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"2024-05-21T10:00:05.123Z [ERROR] Connection failed",
+		"/var/lib/jenkins/workspace/pipeline-123/src/test/java/com/app/AuthTest.java:45 - failed",
+		"Request 550e8400-e29b-41d4-a716-446655440000 failed at 0x7fff5fbff8c0",
+		"Container abc123def456789 exited with code 137",
+		strings.Repeat("/a", 1000) + ":1",
+		strings.Repeat("0x", 1000),
+		strings.Repeat("2024-05-21 ", 500),
+		"\x00\xff\xfe\t\r\n日本語 エラー",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		for _, level := range []MaskingLevel{MaskPresentation, MaskRecurrence} {
+			// The masking regexps are what could backtrack
+			var got string
+			fuzzlimit.Check(t, fuzzlimit.PerInput, fmt.Sprintf("Normalize(%d bytes, %d)", len(line), level), func() {
+				got = Normalize(line, level)
+			})
+			if utf8.ValidString(line) && !utf8.ValidString(got) {
+				t.Errorf("Normalize(%q, %d) = %q, not valid UTF-8", line, level, got)
+			}
+		}
+		NormalizeLines(strings.Split(line, "\n"), MaskRecurrence)
+	})
+}
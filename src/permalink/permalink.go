@@ -0,0 +1,95 @@
+// Package permalink builds and resolves destill:// links to individual
+// findings, so a teammate can open the exact card someone shared.
+//
+// A permalink names a finding by its message hash, which is stable across
+// re-analysis of the same build, and carries the request it was found in
+// and the build URL:
+//
+//	destill://finding/<message-hash>?request=<request-id>&build=<build-url>
+//
+// 'destill view' resolves it from Postgres when the request is stored, and
+// otherwise by analyzing the build again.
+package permalink
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"destill-agent/src/contracts"
+)
+
+// Scheme is the URI scheme of finding permalinks.
+const Scheme = "destill"
+
+// findingHost is the host part of finding permalinks, leaving room for
+// links to other kinds of things.
+const findingHost = "finding"
+
+// Link identifies one finding.
+type Link struct {
+	MessageHash string
+	RequestID   string // Empty when the finding was never stored
+	BuildURL    string
+}
+
+// For returns the link to a finding.
+func For(card contracts.TriageCard) Link {
+	buildURL := card.BuildURL
+	if buildURL == "" {
+		buildURL = card.Metadata["build_url"]
+	}
+	return Link{MessageHash: card.MessageHash, RequestID: card.RequestID, BuildURL: buildURL}
+}
+
+// String formats the link as a destill:// URI.
+func (l Link) String() string {
+	query := url.Values{}
+	if l.RequestID != "" {
+		query.Set("request", l.RequestID)
+	}
+	if l.BuildURL != "" {
+		query.Set("build", l.BuildURL)
+	}
+	u := url.URL{Scheme: Scheme, Host: findingHost, Path: "/" + l.MessageHash, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// IsPermalink reports whether s looks like a destill:// URI.
+func IsPermalink(s string) bool {
+	return strings.HasPrefix(s, Scheme+"://")
+}
+
+// Parse parses a permalink produced by Link.String.
+func Parse(s string) (Link, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return Link{}, fmt.Errorf("invalid permalink: %w", err)
+	}
+	if u.Scheme != Scheme || u.Host != findingHost {
+		return Link{}, fmt.Errorf("invalid permalink %q (expected %s://%s/<hash>)", s, Scheme, findingHost)
+	}
+
+	link := Link{
+		MessageHash: strings.Trim(u.Path, "/"),
+		RequestID:   u.Query().Get("request"),
+		BuildURL:    u.Query().Get("build"),
+	}
+	if link.MessageHash == "" || strings.Contains(link.MessageHash, "/") {
+		return Link{}, fmt.Errorf("invalid permalink %q: missing finding hash", s)
+	}
+	if link.RequestID == "" && link.BuildURL == "" {
+		return Link{}, fmt.Errorf("invalid permalink %q: needs a request or build", s)
+	}
+	return link, nil
+}
+
+// Find returns the finding a link points to among cards.
+func Find(cards []contracts.TriageCard, link Link) (contracts.TriageCard, bool) {
+	for _, card := range cards {
+		if card.MessageHash == link.MessageHash {
+			return card, true
+		}
+	}
+	return contracts.TriageCard{}, false
+}
@@ -0,0 +1,97 @@
+package permalink
+
+import (
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		link Link
+	}{
+		{
+			name: "stored request",
+			link: Link{MessageHash: "a1b2c3", RequestID: "req-20240115T143022-a3f8c91d", BuildURL: "https://buildkite.com/org/pipe/builds/42"},
+		},
+		{
+			name: "local analysis",
+			link: Link{MessageHash: "a1b2c3", BuildURL: "https://github.com/owner/repo/actions/runs/456?check_suite_focus=true#step:3"},
+		},
+		{
+			name: "request only",
+			link: Link{MessageHash: "a1b2c3", RequestID: "req-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.link.String()
+			if !IsPermalink(s) {
+				t.Fatalf("IsPermalink(%q) = false", s)
+			}
+			got, err := Parse(s)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", s, err)
+			}
+			if got != tt.link {
+				t.Errorf("Parse(%q) = %+v, want %+v", s, got, tt.link)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"wrong scheme", "https://finding/abc?request=req-1", "expected destill://finding"},
+		{"wrong host", "destill://build/abc?request=req-1", "expected destill://finding"},
+		{"no hash", "destill://finding/?request=req-1", "missing finding hash"},
+		{"nested path", "destill://finding/a/b?request=req-1", "missing finding hash"},
+		{"no source", "destill://finding/abc", "needs a request or build"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %v, want %q", tt.input, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFor(t *testing.T) {
+	card := contracts.TriageCard{
+		MessageHash: "deadbeef",
+		RequestID:   "req-1",
+		Metadata:    map[string]string{"build_url": "https://ci.example.com/builds/7"},
+	}
+	link := For(card)
+	if link.BuildURL != "https://ci.example.com/builds/7" {
+		t.Errorf("For() BuildURL = %q, want metadata fallback", link.BuildURL)
+	}
+
+	card.BuildURL = "https://ci.example.com/builds/8"
+	if got := For(card).BuildURL; got != card.BuildURL {
+		t.Errorf("For() BuildURL = %q, want %q", got, card.BuildURL)
+	}
+}
+
+func TestFind(t *testing.T) {
+	cards := []contracts.TriageCard{
+		{ID: "1", MessageHash: "aaa"},
+		{ID: "2", MessageHash: "bbb"},
+	}
+	if card, ok := Find(cards, Link{MessageHash: "bbb"}); !ok || card.ID != "2" {
+		t.Errorf("Find(bbb) = %+v, %v", card, ok)
+	}
+	if _, ok := Find(cards, Link{MessageHash: "ccc"}); ok {
+		t.Error("Find(ccc) found a card")
+	}
+}
@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// EnvIdleTimeout overrides how long collectors wait for another finding,
+	// with a duration such as "30s"; 0 disables the idle timeout.
+	EnvIdleTimeout = "DESTILL_IDLE_TIMEOUT"
+
+	// EnvHardTimeout overrides how long collectors wait in all, with a
+	// duration such as "10m"; 0 disables the hard timeout.
+	EnvHardTimeout = "DESTILL_HARD_TIMEOUT"
+)
+
+// CollectTimeouts bound how long a collector waits for the findings of a
+// local mode request. Collection ends when the analysis completes
+// (events.Complete), or should that never come, when findings stop arriving
+// (Idle) or at a deadline (Hard). Zero disables either.
+type CollectTimeouts struct {
+	Idle time.Duration // Stop after this long without a new finding
+	Hard time.Duration // Stop after this long, however findings are arriving
+}
+
+// CollectTimeoutsFromEnv returns defaults with DESTILL_IDLE_TIMEOUT and
+// DESTILL_HARD_TIMEOUT applied. Invalid values are warned about and the
+// defaults kept.
+func CollectTimeoutsFromEnv(defaults CollectTimeouts) CollectTimeouts {
+	timeouts := defaults
+	timeouts.Idle = durationFromEnv(EnvIdleTimeout, defaults.Idle)
+	timeouts.Hard = durationFromEnv(EnvHardTimeout, defaults.Hard)
+	return timeouts
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: invalid %s %q, using %s\n", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// String describes the timeouts for a status line: "10s of inactivity or
+// 2m0s in all".
+func (t CollectTimeouts) String() string {
+	switch {
+	case t.Idle > 0 && t.Hard > 0:
+		return fmt.Sprintf("%v of inactivity or %v in all", t.Idle, t.Hard)
+	case t.Idle > 0:
+		return fmt.Sprintf("%v of inactivity", t.Idle)
+	case t.Hard > 0:
+		return fmt.Sprintf("%v in all", t.Hard)
+	}
+	return "no timeout"
+}
@@ -0,0 +1,34 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCollectTimeoutsFromEnv(t *testing.T) {
+	defaults := CollectTimeouts{Idle: 10 * time.Second}
+
+	t.Setenv(EnvIdleTimeout, "soon")
+	t.Setenv(EnvHardTimeout, "5m")
+	got := CollectTimeoutsFromEnv(defaults)
+	if want := (CollectTimeouts{Idle: 10 * time.Second, Hard: 5 * time.Minute}); got != want {
+		t.Errorf("CollectTimeoutsFromEnv() = %+v, want %+v (invalid idle timeout ignored)", got, want)
+	}
+}
+
+func TestCollectTimeouts_String(t *testing.T) {
+	tests := []struct {
+		timeouts CollectTimeouts
+		want     string
+	}{
+		{CollectTimeouts{Idle: 10 * time.Second}, "10s of inactivity"},
+		{CollectTimeouts{Idle: 10 * time.Second, Hard: 2 * time.Minute}, "10s of inactivity or 2m0s in all"},
+		{CollectTimeouts{}, "no timeout"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.timeouts.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/events"
+)
+
+// StartLocal starts the agents like Start for local mode, where the TUI and
+// CLI collectors read bus instead of broker topics: findings, progress, and
+// the end of each request's analysis are forwarded to it as typed events, and so are the requests the ingest
+// agent fails to process, which Start only logs. Like Start, it subscribes
+// before returning and forwards in goroutines.
+func StartLocal(msgBroker broker.Broker, ctx context.Context, bus *events.Bus) error {
+	findingsCh, err := msgBroker.Subscribe(ctx, contracts.TopicAnalysisFindings, "destill-local-findings")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicAnalysisFindings, err)
+	}
+	progressCh, err := msgBroker.Subscribe(ctx, contracts.TopicProgress, "destill-local-progress")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicProgress, err)
+	}
+	controlCh, err := msgBroker.Subscribe(ctx, contracts.TopicControl, "destill-local-control")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicControl, err)
+	}
+
+	go forward(ctx, findingsCh, bus, func(msg broker.Message) (events.Event, error) {
+		var card contracts.TriageCard
+		err := json.Unmarshal(msg.Value, &card)
+		return events.Finding{Card: card}, err
+	})
+	go forward(ctx, progressCh, bus, func(msg broker.Message) (events.Event, error) {
+		var update contracts.ProgressUpdate
+		err := json.Unmarshal(msg.Value, &update)
+		return events.Progress{Update: update}, err
+	})
+	go forward(ctx, controlCh, bus, func(msg broker.Message) (events.Event, error) {
+		var control contracts.ControlMessage
+		if err := json.Unmarshal(msg.Value, &control); err != nil {
+			return nil, err
+		}
+		if control.Type != contracts.ControlAnalysisComplete {
+			return nil, nil
+		}
+		return events.Complete{RequestID: control.RequestID, Findings: control.Findings}, nil
+	})
+
+	return start(msgBroker, ctx, func(requestID string, err error) {
+		if err := bus.Publish(ctx, events.Error{RequestID: requestID, Err: err}); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "[Pipeline] Failed to publish error event: %v\n", err)
+		}
+	})
+}
+
+// forward publishes the messages of ch to bus, decoded by decode, until ch
+// closes or ctx is done. Messages decoded to a nil event are skipped.
+func forward(ctx context.Context, ch <-chan broker.Message, bus *events.Bus, decode func(broker.Message) (events.Event, error)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			event, err := decode(msg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[Pipeline] Failed to unmarshal %s message: %v\n", msg.Topic, err)
+				continue
+			}
+			if event == nil {
+				continue
+			}
+			if err := bus.Publish(ctx, event); err != nil {
+				return
+			}
+		}
+	}
+}
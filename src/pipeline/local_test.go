@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/events"
+	"destill-agent/src/provider"
+)
+
+func TestStartLocal_ForwardsEvents(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "unit-tests.log"), []byte("ERROR: test failed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	buildURL, err := provider.LocalDirURL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgBroker := broker.NewInMemoryBroker()
+	defer msgBroker.Close()
+	bus := events.NewBus()
+	defer bus.Close()
+
+	if err := StartLocal(msgBroker, ctx, bus); err != nil {
+		t.Fatal(err)
+	}
+	evs, err := bus.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	submit := func(requestID, buildURL string) {
+		data, err := json.Marshal(contracts.AnalysisRequest{
+			Version:   contracts.AnalysisRequestVersion,
+			RequestID: requestID,
+			BuildURL:  buildURL,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := msgBroker.Publish(ctx, contracts.TopicRequests, requestID, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	submit("req-ok", buildURL)
+	submit("req-bad", "https://example.com/not/a/build")
+
+	var finding, progress, complete, failed bool
+	timeout := time.After(5 * time.Second)
+	for !finding || !progress || !complete || !failed {
+		select {
+		case event := <-evs:
+			switch event := event.(type) {
+			case events.Finding:
+				finding = event.Card.RequestID == "req-ok"
+			case events.Progress:
+				progress = progress || event.Update.RequestID == "req-ok"
+			case events.Complete:
+				if event.RequestID != "req-ok" || event.Findings == 0 {
+					t.Errorf("Complete event = %+v, want req-ok with its finding", event)
+				}
+				complete = true
+			case events.Error:
+				if event.RequestID != "req-bad" || event.Err == nil {
+					t.Errorf("Error event = %+v, want the failure of req-bad", event)
+				}
+				failed = true
+			}
+		case <-timeout:
+			t.Fatalf("finding %v, progress %v, complete %v, error %v; want all four events", finding, progress, complete, failed)
+		}
+	}
+}
@@ -4,25 +4,49 @@ package pipeline
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"destill-agent/src/analyze"
 	"destill-agent/src/broker"
 	"destill-agent/src/contracts"
 	"destill-agent/src/ingest"
 	"destill-agent/src/logger"
+	"destill-agent/src/rules"
+	"destill-agent/src/store"
+	"destill-agent/src/summarize"
 )
 
 // Start starts the ingest and analyze agents as goroutines.
 // Subscriptions are created synchronously to avoid race conditions, then processing
-// loops run asynchronously. Returns error if subscriptions fail.
+// loops run asynchronously. Returns error if the user pattern config is invalid
+// or subscriptions fail.
 // It uses silent logging to prevent log pollution when running in TUI mode or MCP server mode.
 // Errors are still logged to stderr even in silent mode.
 func Start(msgBroker broker.Broker, ctx context.Context) error {
+	return start(msgBroker, ctx, nil)
+}
+
+// start implements Start and StartLocal; onError, if set, is called with
+// each request the ingest agent fails to process.
+func start(msgBroker broker.Broker, ctx context.Context, onError func(requestID string, err error)) error {
 	// Use silent logger to prevent log pollution in TUI mode
 	log := logger.NewSilentLogger()
 
+	// Load user patterns and suppressions before subscribing so a bad config fails fast
+	ruleSet, err := rules.LoadRuleSet()
+	if err != nil {
+		return err
+	}
+
+	// Optional LLM summaries, also validated before anything starts
+	summarizer, err := summarize.StageFromEnv()
+	if err != nil {
+		return fmt.Errorf("LLM summaries: %w", err)
+	}
+
 	// Subscribe to topics synchronously BEFORE starting goroutines.
 	// This ensures agents are ready to receive messages when Start returns.
 	requestsCh, err := msgBroker.Subscribe(ctx, contracts.TopicRequests, "destill-ingest")
@@ -35,8 +59,14 @@ func Start(msgBroker broker.Broker, ctx context.Context) error {
 		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicLogsRaw, err)
 	}
 
+	controlCh, err := msgBroker.Subscribe(ctx, contracts.TopicControl, "destill-analyze-control")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicControl, err)
+	}
+
 	// Start Ingestion Agent processing loop as a goroutine
 	ingestionAgent := ingest.NewAgent(msgBroker, log)
+	ingestionAgent.SetErrorHandler(onError)
 	go func() {
 		if err := ingestionAgent.RunWithChannel(ctx, requestsCh); err != nil && err != context.Canceled {
 			// Error logging always goes to stderr even in silent mode
@@ -46,8 +76,13 @@ func Start(msgBroker broker.Broker, ctx context.Context) error {
 
 	// Start Analysis Agent processing loop as a goroutine
 	analysisAgent := analyze.NewAgent(msgBroker, log)
+	analysisAgent.SetRules(ruleSet)
+	analysisAgent.SetSummarizer(summarizer)
+	if path := rules.DefaultPath(); path != "" {
+		go analysisAgent.WatchRules(ctx, path, rules.DefaultWatchInterval)
+	}
 	go func() {
-		if err := analysisAgent.RunWithChannel(ctx, logsRawCh); err != nil && err != context.Canceled {
+		if err := analysisAgent.RunWithChannels(ctx, logsRawCh, controlCh); err != nil && err != context.Canceled {
 			// Error logging always goes to stderr even in silent mode
 			fmt.Fprintf(os.Stderr, "[Pipeline] Analysis agent error: %v\n", err)
 		}
@@ -55,3 +90,187 @@ func Start(msgBroker broker.Broker, ctx context.Context) error {
 
 	return nil
 }
+
+// StoreChunks saves the raw log chunks published to msgBroker in st, so
+// context around findings can be read back with GetFindingContext. Like
+// Start, it subscribes before returning and stores in a goroutine. Chunks
+// are committed once stored where the broker supports it (see
+// broker.SubscribeAtLeastOnce), retrying a failed store until it succeeds:
+// committing a later chunk of the partition would commit past it.
+func StoreChunks(msgBroker broker.Broker, ctx context.Context, st store.Store) error {
+	chunksCh, handled, err := broker.SubscribeAtLeastOnce(ctx, msgBroker, contracts.TopicLogsRaw, "destill-chunks")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicLogsRaw, err)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-chunksCh:
+				if !ok {
+					return
+				}
+				var chunk contracts.LogChunk
+				if err := json.Unmarshal(msg.Value, &chunk); err != nil {
+					fmt.Fprintf(os.Stderr, "[Pipeline] Failed to unmarshal chunk: %v\n", err)
+				} else if !storeUntilDone(ctx, "chunk", func() error {
+					return st.StoreChunks(ctx, []contracts.LogChunk{chunk})
+				}) {
+					return
+				}
+				handled(msg)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// storeUntilDone calls store until it succeeds (see
+// broker.RetryUntilDone), and reports whether it did before ctx was done.
+func storeUntilDone(ctx context.Context, what string, store func() error) bool {
+	return broker.RetryUntilDone(ctx, store, func(err error, retryIn time.Duration) {
+		fmt.Fprintf(os.Stderr, "[Pipeline] Failed to store %s, retrying in %s: %v\n", what, retryIn, err)
+	}) == nil
+}
+
+// StoreFindings saves the findings published to msgBroker in st, taking the
+// place of the Redpanda Connect sink where there is none (grpc and nats
+// modes). Like Start, it subscribes before returning and stores in a
+// goroutine. Findings are committed once stored, like in StoreChunks.
+func StoreFindings(msgBroker broker.Broker, ctx context.Context, st store.Store) error {
+	findingsCh, handled, err := broker.SubscribeAtLeastOnce(ctx, msgBroker, contracts.TopicAnalysisFindings, "destill-findings")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicAnalysisFindings, err)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-findingsCh:
+				if !ok {
+					return
+				}
+				var card contracts.TriageCard
+				if err := json.Unmarshal(msg.Value, &card); err != nil {
+					fmt.Fprintf(os.Stderr, "[Pipeline] Failed to unmarshal finding: %v\n", err)
+				} else if !storeUntilDone(ctx, "finding", func() error {
+					return st.Store(ctx, card.RequestID, []contracts.TriageCard{card})
+				}) {
+					return
+				}
+				handled(msg)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// TrackRequests records the status and progress of requests in a store
+// that tracks them itself (see store.RequestTracker): pending when
+// submitted, processing with the jobs fetched and chunks analyzed as the
+// agents report progress, and completed when the analyze agent reports
+// every chunk analyzed (contracts.ControlAnalysisComplete), noting what
+// either agent's limits left out. Like Start, it subscribes before
+// returning and records in a goroutine.
+func TrackRequests(msgBroker broker.Broker, ctx context.Context, tracker store.RequestTracker) error {
+	requestsCh, err := msgBroker.Subscribe(ctx, contracts.TopicRequests, "destill-requests")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicRequests, err)
+	}
+	progressCh, err := msgBroker.Subscribe(ctx, contracts.TopicProgress, "destill-requests-progress")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicProgress, err)
+	}
+	controlCh, err := msgBroker.Subscribe(ctx, contracts.TopicControl, "destill-requests-control")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicControl, err)
+	}
+
+	go func() {
+		for {
+			var requestID, buildURL, status string
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-requestsCh:
+				if !ok {
+					return
+				}
+				var request contracts.AnalysisRequest
+				if err := json.Unmarshal(msg.Value, &request); err != nil {
+					fmt.Fprintf(os.Stderr, "[Pipeline] Failed to unmarshal request: %v\n", err)
+					continue
+				}
+				requestID, buildURL, status = request.RequestID, request.BuildURL, store.RequestPending
+			case msg, ok := <-progressCh:
+				if !ok {
+					return
+				}
+				var update contracts.ProgressUpdate
+				if err := json.Unmarshal(msg.Value, &update); err != nil {
+					fmt.Fprintf(os.Stderr, "[Pipeline] Failed to unmarshal progress: %v\n", err)
+					continue
+				}
+				if err := tracker.SetRequestProgress(ctx, update); err != nil {
+					fmt.Fprintf(os.Stderr, "[Pipeline] Failed to record request progress: %v\n", err)
+				}
+				continue
+			case msg, ok := <-controlCh:
+				if !ok {
+					return
+				}
+				var control contracts.ControlMessage
+				if err := json.Unmarshal(msg.Value, &control); err != nil {
+					fmt.Fprintf(os.Stderr, "[Pipeline] Failed to unmarshal control message: %v\n", err)
+					continue
+				}
+				// An analyze agent's count repeats in the completion
+				if len(control.Truncated) > 0 && control.Type != contracts.ControlChunksAnalyzed {
+					if err := tracker.SetRequestTruncated(ctx, control.RequestID, control.Truncated); err != nil {
+						fmt.Fprintf(os.Stderr, "[Pipeline] Failed to record request truncation: %v\n", err)
+					}
+				}
+				if control.Type != contracts.ControlAnalysisComplete {
+					continue
+				}
+				requestID, status = control.RequestID, store.RequestCompleted
+			}
+			if err := tracker.SetRequestStatus(ctx, requestID, buildURL, status); err != nil {
+				fmt.Fprintf(os.Stderr, "[Pipeline] Failed to record request status: %v\n", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// PruneOld deletes the data in p older than maxAge every interval, starting
+// now, until ctx is done. Unlike the functions above it has nothing to
+// subscribe to, so it only starts the goroutine.
+func PruneOld(ctx context.Context, p store.Pruner, maxAge, interval time.Duration, log logger.Logger) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			res, err := p.Prune(ctx, time.Now().Add(-maxAge))
+			if err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "[Pipeline] Failed to prune old data: %v\n", err)
+			} else if err == nil && res.Findings+res.LogChunks+res.Requests+res.HashHistory > 0 {
+				log.Info("Pruned %d findings, %d log chunks, %d requests, and %d message history rows older than %s",
+					res.Findings, res.LogChunks, res.Requests, res.HashHistory, maxAge)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,325 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/ingest"
+	"destill-agent/src/logger"
+	"destill-agent/src/provider"
+	"destill-agent/src/sampledata"
+	"destill-agent/src/store"
+)
+
+// TestAnalyzeAgentsShareWork runs a build through two analyze agents
+// consuming destill.logs.raw as one consumer group, and checks they find
+// exactly what one agent finds, sharing the build's jobs between them.
+func TestAnalyzeAgentsShareWork(t *testing.T) {
+	build, err := sampledata.Generate(sampledata.Options{Seed: 7, Jobs: 8, LinesPerJob: 300, FailedJobs: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := build.WriteDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	buildURL, err := provider.LocalDirURL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	single, _ := analyzeWithAgents(t, buildURL, 1)
+	shared, keysByAgent := analyzeWithAgents(t, buildURL, 2)
+
+	if len(single) == 0 {
+		t.Fatal("one agent found nothing; the sample build should have failures")
+	}
+	if !slices.Equal(shared, single) {
+		t.Errorf("two agents found %d findings, one agent %d; want the same findings\nshared: %v\nsingle: %v",
+			len(shared), len(single), shared, single)
+	}
+	for agent, keys := range keysByAgent {
+		if len(keys) == 0 {
+			t.Errorf("agent %d got no chunks; want the jobs shared between both", agent)
+		}
+		for key := range keys {
+			for other, otherKeys := range keysByAgent {
+				if other != agent && otherKeys[key] {
+					t.Errorf("chunks keyed %s went to agents %d and %d; want a job's chunks on one agent", key, agent, other)
+				}
+			}
+		}
+	}
+}
+
+// analyzeWithAgents ingests buildURL and analyzes its chunks with agents
+// analyze agents, handing each chunk to one of them by its key as a
+// Kafka-compatible broker's partitioner does, and every control message to
+// all of them. It returns the findings, one "job/hash" entry for each job a
+// finding was in, sorted, and the chunk keys each agent was handed. The
+// agents must report the request's analysis complete exactly once.
+func analyzeWithAgents(t *testing.T, buildURL string, agents int) ([]string, []map[string]bool) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	subscribe := func(topic string) <-chan broker.Message {
+		ch, err := brk.Subscribe(ctx, topic, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ch
+	}
+	requests := subscribe(contracts.TopicRequests)
+	chunks := subscribe(contracts.TopicLogsRaw)
+	findingsCh := subscribe(contracts.TopicAnalysisFindings)
+	controlCh := subscribe(contracts.TopicControl)
+
+	log := logger.NewSilentLogger()
+	go ingest.NewAgent(brk, log).RunWithChannel(ctx, requests)
+
+	// One partition, with its own agent, per agent of the group
+	partitions := make([]chan broker.Message, agents)
+	keysByAgent := make([]map[string]bool, agents)
+	for i := range partitions {
+		partitions[i] = make(chan broker.Message, 100)
+		keysByAgent[i] = make(map[string]bool)
+		go analyze.NewAgent(brk, log).RunWithChannels(ctx, partitions[i], subscribe(contracts.TopicControl))
+	}
+	go func() {
+		for msg := range chunks {
+			h := fnv.New32a()
+			h.Write([]byte(msg.Key))
+			partition := int(h.Sum32() % uint32(agents))
+			keysByAgent[partition][msg.Key] = true
+			partitions[partition] <- msg
+		}
+	}()
+
+	data, _ := json.Marshal(contracts.AnalysisRequest{
+		Version:   contracts.AnalysisRequestVersion,
+		RequestID: fmt.Sprintf("req-%d-agents", agents),
+		BuildURL:  buildURL,
+	})
+	if err := brk.Publish(ctx, contracts.TopicRequests, "", data); err != nil {
+		t.Fatal(err)
+	}
+
+	// The findings are all published before the analysis completes; wait
+	// a while after it for another completion
+	var findings []string
+	expected, completes := -1, 0
+	receive := func(msg broker.Message) {
+		var control contracts.ControlMessage
+		json.Unmarshal(msg.Value, &control)
+		switch control.Type {
+		case contracts.ControlIngestComplete:
+			expected = control.Chunks
+		case contracts.ControlAnalysisComplete:
+			completes++
+			if control.Chunks != expected {
+				t.Errorf("%d agents completed the analysis with %d chunks, want %d", agents, control.Chunks, expected)
+			}
+		}
+	}
+	for completes == 0 {
+		select {
+		case msg := <-findingsCh:
+			findings = append(findings, findingEntries(t, msg)...)
+		case msg := <-controlCh:
+			receive(msg)
+		case <-ctx.Done():
+			t.Fatalf("%d agents never completed the analysis", agents)
+		}
+	}
+	for idle := false; !idle; {
+		select {
+		case msg := <-findingsCh:
+			findings = append(findings, findingEntries(t, msg)...)
+		case msg := <-controlCh:
+			receive(msg)
+		case <-time.After(time.Second):
+			idle = true
+		}
+	}
+	if completes != 1 {
+		t.Errorf("%d agents completed the analysis %d times, want once", agents, completes)
+	}
+	slices.Sort(findings)
+	findings = slices.Compact(findings)
+
+	// The router goroutine is done with the keys once every chunk is analyzed
+	return findings, keysByAgent
+}
+
+// findingEntries identifies a published finding by message, in each job
+// it was in.
+func findingEntries(t *testing.T, msg broker.Message) []string {
+	var card contracts.TriageCard
+	if err := json.Unmarshal(msg.Value, &card); err != nil {
+		t.Fatalf("Failed to unmarshal finding: %v", err)
+	}
+	var entries []string
+	for _, job := range card.Jobs() {
+		entries = append(entries, fmt.Sprintf("%s/%s", job, card.MessageHash))
+	}
+	return entries
+}
+
+// BenchmarkTimeToFirstFinding runs the local mode pipeline on a reference
+// build, a generated one read from a log directory, and reports how long the
+// first finding takes to arrive after the request is published: the wait
+// before the TUI shows anything.
+func BenchmarkTimeToFirstFinding(b *testing.B) {
+	build, err := sampledata.Generate(sampledata.Options{Seed: 1, Jobs: 8, LinesPerJob: 20000, FailedJobs: 2})
+	if err != nil {
+		b.Fatal(err)
+	}
+	dir := b.TempDir()
+	if err := build.WriteDir(dir); err != nil {
+		b.Fatal(err)
+	}
+	buildURL, err := provider.LocalDirURL(dir)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var total time.Duration
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		total += timeToFirstFinding(b, buildURL)
+	}
+	b.ReportMetric(float64(total.Milliseconds())/float64(b.N), "ms/first-finding")
+}
+
+// timeToFirstFinding starts a pipeline, submits buildURL, and returns the
+// time until the first finding is published.
+func timeToFirstFinding(b *testing.B, buildURL string) time.Duration {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgBroker := broker.NewInMemoryBroker()
+	defer msgBroker.Close()
+
+	if err := Start(msgBroker, ctx); err != nil {
+		b.Fatal(err)
+	}
+	findings, err := msgBroker.Subscribe(ctx, contracts.TopicAnalysisFindings, "bench-consumer")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	data, err := json.Marshal(contracts.AnalysisRequest{
+		Version:   contracts.AnalysisRequestVersion,
+		RequestID: "req-bench",
+		BuildURL:  buildURL,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	start := time.Now()
+	if err := msgBroker.Publish(ctx, contracts.TopicRequests, "req-bench", data); err != nil {
+		b.Fatal(err)
+	}
+
+	select {
+	case <-findings:
+		return time.Since(start)
+	case <-time.After(30 * time.Second):
+		b.Fatal("no finding within 30s")
+		return 0
+	}
+}
+
+// committingBroker is an in-memory broker that records the messages
+// committed with SubscribeAtLeastOnce, like Redpanda.
+type committingBroker struct {
+	*broker.InMemoryBroker
+	mu        sync.Mutex
+	committed []string // Keys
+}
+
+func (b *committingBroker) SubscribeUncommitted(ctx context.Context, topic, groupID string) (<-chan broker.Message, error) {
+	return b.Subscribe(ctx, topic, groupID)
+}
+
+func (b *committingBroker) Commit(msg broker.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.committed = append(b.committed, msg.Key)
+}
+
+func (b *committingBroker) commits() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.committed)
+}
+
+// failingStore fails to store chunks until fail is cleared.
+type failingStore struct {
+	*store.InMemoryStore
+	fail     atomic.Bool
+	attempts atomic.Int32
+}
+
+func (s *failingStore) StoreChunks(ctx context.Context, chunks []contracts.LogChunk) error {
+	s.attempts.Add(1)
+	if s.fail.Load() {
+		return errors.New("database unavailable")
+	}
+	return s.InMemoryStore.StoreChunks(ctx, chunks)
+}
+
+// TestStoreChunksRetriesBeforeCommitting checks a chunk that fails to store
+// isn't committed past: it's retried, and the chunks after it wait for it.
+func TestStoreChunksRetriesBeforeCommitting(t *testing.T) {
+	defer func(delay time.Duration) { broker.RetryDelay = delay }(broker.RetryDelay)
+	broker.RetryDelay = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	brk := &committingBroker{InMemoryBroker: broker.NewInMemoryBroker()}
+	defer brk.Close()
+	st := &failingStore{InMemoryStore: store.NewInMemoryStore()}
+	st.fail.Store(true)
+	if err := StoreChunks(brk, ctx, st); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"chunk-1", "chunk-2"} {
+		data, _ := json.Marshal(contracts.LogChunk{RequestID: "req", JobID: key, Content: "line"})
+		if err := brk.Publish(ctx, contracts.TopicLogsRaw, key, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for st.attempts.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := brk.commits(); len(got) != 0 {
+		t.Fatalf("committed %q while the first chunk failed to store, want nothing", got)
+	}
+
+	st.fail.Store(false)
+	for len(brk.commits()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := brk.commits(); !slices.Equal(got, []string{"chunk-1", "chunk-2"}) {
+		t.Errorf("committed %q, want both chunks in order once stored", got)
+	}
+	if chunks, _ := st.GetChunks(ctx, "req"); len(chunks) != 2 {
+		t.Errorf("stored %d chunks, want 2", len(chunks))
+	}
+}
@@ -0,0 +1,96 @@
+// Package platform wraps the OS-specific parts of the CLI and TUI: opening
+// URLs in a browser, copying to the clipboard, and locating cache files.
+// Each OS provides openCommand and clipboardCommands in a build-tagged file.
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoClipboard is returned when no clipboard tool is installed.
+var ErrNoClipboard = errors.New("no clipboard tool found")
+
+// lookPath is swapped out in tests.
+var lookPath = exec.LookPath
+
+// OpenURL opens url in the default browser without waiting for it to exit.
+func OpenURL(url string) error {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("refusing to open non-HTTP URL: %q", url)
+	}
+
+	name, args := openCommand(url)
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", url, err)
+	}
+	go func() { _ = cmd.Wait() }() // Reap the launcher; the browser outlives it
+	return nil
+}
+
+// CopyToClipboard copies text to the system clipboard using the first
+// available tool for this OS.
+func CopyToClipboard(text string) error {
+	name, args, err := findClipboardCommand()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// findClipboardCommand returns the first clipboard command found on PATH.
+func findClipboardCommand() (string, []string, error) {
+	var tried []string
+	for _, command := range clipboardCommands() {
+		if _, err := lookPath(command[0]); err == nil {
+			return command[0], command[1:], nil
+		}
+		tried = append(tried, command[0])
+	}
+	return "", nil, fmt.Errorf("%w (tried %s)", ErrNoClipboard, strings.Join(tried, ", "))
+}
+
+// CacheDir returns destill's cache directory, creating it if needed:
+// $XDG_CACHE_HOME/destill or ~/.cache/destill on Linux,
+// ~/Library/Caches/destill on macOS, and %LocalAppData%\destill on Windows.
+func CacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+
+	dir := filepath.Join(base, "destill")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return dir, nil
+}
+
+// CachePath resolves a cache file name. Paths with a directory component,
+// and names that exist in the working directory, are returned unchanged;
+// other bare names are placed in CacheDir.
+func CachePath(name string) (string, error) {
+	if name == "" || filepath.Base(name) != name {
+		return name, nil
+	}
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,11 @@
+//go:build darwin
+
+package platform
+
+func openCommand(url string) (string, []string) {
+	return "open", []string{url}
+}
+
+func clipboardCommands() [][]string {
+	return [][]string{{"pbcopy"}}
+}
@@ -0,0 +1,17 @@
+//go:build darwin
+
+package platform
+
+import "testing"
+
+func TestOpenCommandDarwin(t *testing.T) {
+	if name, _ := openCommand("https://example.com"); name != "open" {
+		t.Errorf("openCommand() = %q, want open", name)
+	}
+}
+
+func TestClipboardCommandsDarwin(t *testing.T) {
+	if got := clipboardCommands()[0][0]; got != "pbcopy" {
+		t.Errorf("clipboard command = %q, want pbcopy", got)
+	}
+}
@@ -0,0 +1,125 @@
+package platform
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenURLRejectsNonHTTP(t *testing.T) {
+	for _, url := range []string{"", "file:///etc/passwd", "javascript:alert(1)", "-n https://example.com"} {
+		if err := OpenURL(url); err == nil {
+			t.Errorf("OpenURL(%q) = nil, want error", url)
+		}
+	}
+}
+
+func TestOpenCommandPassesURL(t *testing.T) {
+	url := "https://buildkite.com/org/pipeline/builds/1?a=1&b=2"
+	name, args := openCommand(url)
+	if name == "" {
+		t.Fatal("openCommand() returned no command")
+	}
+	if len(args) == 0 || args[len(args)-1] != url {
+		t.Errorf("openCommand() args = %q, want the URL as the last argument", args)
+	}
+}
+
+func TestFindClipboardCommand(t *testing.T) {
+	commands := clipboardCommands()
+	if len(commands) == 0 {
+		t.Fatal("clipboardCommands() is empty")
+	}
+	defer func(orig func(string) (string, error)) { lookPath = orig }(lookPath)
+
+	t.Run("first available tool wins", func(t *testing.T) {
+		want := commands[len(commands)-1][0]
+		lookPath = func(file string) (string, error) {
+			if file == want {
+				return "/usr/bin/" + file, nil
+			}
+			return "", errors.New("not found")
+		}
+
+		name, _, err := findClipboardCommand()
+		if err != nil {
+			t.Fatalf("findClipboardCommand() error = %v", err)
+		}
+		if name != want {
+			t.Errorf("findClipboardCommand() = %q, want %q", name, want)
+		}
+	})
+
+	t.Run("no tool installed", func(t *testing.T) {
+		lookPath = func(string) (string, error) { return "", errors.New("not found") }
+
+		_, _, err := findClipboardCommand()
+		if !errors.Is(err, ErrNoClipboard) {
+			t.Fatalf("findClipboardCommand() error = %v, want ErrNoClipboard", err)
+		}
+		if !strings.Contains(err.Error(), commands[0][0]) {
+			t.Errorf("error %q does not list the tools tried", err)
+		}
+	})
+}
+
+// setCacheHome points os.UserCacheDir at dir on every OS.
+func setCacheHome(t *testing.T, dir string) {
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("LocalAppData", dir)
+	t.Setenv("HOME", dir)
+}
+
+func TestCacheDir(t *testing.T) {
+	setCacheHome(t, t.TempDir())
+
+	dir, err := CacheDir()
+	if err != nil {
+		t.Fatalf("CacheDir() error = %v", err)
+	}
+	if filepath.Base(dir) != "destill" {
+		t.Errorf("CacheDir() = %q, want a destill directory", dir)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("CacheDir() did not create %q", dir)
+	}
+}
+
+func TestCachePath(t *testing.T) {
+	setCacheHome(t, t.TempDir())
+	cacheDir, err := CacheDir()
+	if err != nil {
+		t.Fatalf("CacheDir() error = %v", err)
+	}
+
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+	if err := os.WriteFile("existing.json", []byte("[]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "empty", in: "", want: ""},
+		{name: "bare name goes to cache dir", in: "build.json", want: filepath.Join(cacheDir, "build.json")},
+		{name: "existing file in working dir", in: "existing.json", want: "existing.json"},
+		{name: "relative path unchanged", in: filepath.Join("out", "build.json"), want: filepath.Join("out", "build.json")},
+		{name: "absolute path unchanged", in: filepath.Join(workDir, "build.json"), want: filepath.Join(workDir, "build.json")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CachePath(tt.in)
+			if err != nil {
+				t.Fatalf("CachePath(%q) error = %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("CachePath(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
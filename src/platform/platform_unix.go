@@ -0,0 +1,21 @@
+//go:build !darwin && !windows
+
+package platform
+
+import "os"
+
+func openCommand(url string) (string, []string) {
+	return "xdg-open", []string{url}
+}
+
+// clipboardCommands prefers wl-copy under Wayland, then the X11 tools.
+func clipboardCommands() [][]string {
+	x11 := [][]string{
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		return append([][]string{{"wl-copy"}}, x11...)
+	}
+	return x11
+}
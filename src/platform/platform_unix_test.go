@@ -0,0 +1,23 @@
+//go:build !darwin && !windows
+
+package platform
+
+import "testing"
+
+func TestClipboardCommandsWayland(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+	if got := clipboardCommands()[0][0]; got != "wl-copy" {
+		t.Errorf("first clipboard command under Wayland = %q, want wl-copy", got)
+	}
+
+	t.Setenv("WAYLAND_DISPLAY", "")
+	if got := clipboardCommands()[0][0]; got != "xclip" {
+		t.Errorf("first clipboard command under X11 = %q, want xclip", got)
+	}
+}
+
+func TestOpenCommandUnix(t *testing.T) {
+	if name, _ := openCommand("https://example.com"); name != "xdg-open" {
+		t.Errorf("openCommand() = %q, want xdg-open", name)
+	}
+}
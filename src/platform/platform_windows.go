@@ -0,0 +1,13 @@
+//go:build windows
+
+package platform
+
+// openCommand uses rundll32 rather than "cmd /c start", which would treat
+// '&' in query strings as a command separator.
+func openCommand(url string) (string, []string) {
+	return "rundll32", []string{"url.dll,FileProtocolHandler", url}
+}
+
+func clipboardCommands() [][]string {
+	return [][]string{{"clip"}}
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package platform
+
+import "testing"
+
+func TestOpenCommandWindows(t *testing.T) {
+	name, args := openCommand("https://example.com/?a=1&b=2")
+	if name != "rundll32" || len(args) != 2 || args[0] != "url.dll,FileProtocolHandler" {
+		t.Errorf("openCommand() = %q %q, want rundll32 url.dll,FileProtocolHandler <url>", name, args)
+	}
+}
+
+func TestClipboardCommandsWindows(t *testing.T) {
+	if got := clipboardCommands()[0][0]; got != "clip" {
+		t.Errorf("clipboard command = %q, want clip", got)
+	}
+}
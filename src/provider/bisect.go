@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"destill-agent/src/contracts"
+)
+
+// CommitHistory is implemented by providers that can look back at earlier
+// builds of a pipeline, to narrow down the commits a failure was introduced
+// in (see FindCommitRange). Wrapped providers return errors.ErrUnsupported
+// when the provider underneath can't.
+type CommitHistory interface {
+	// FetchLastPassedBuild finds the most recent passed build created
+	// before build, on its branch and with its trigger, among the
+	// pipeline's recent builds. ErrBuildNotFound when there is none.
+	FetchLastPassedBuild(ctx context.Context, ref *BuildRef, build *Build) (*Build, error)
+
+	// CountCommits counts the commits after base up to and including
+	// head, or returns 0 when the provider can't tell.
+	CountCommits(ctx context.Context, ref *BuildRef, base, head string) (int, error)
+}
+
+// CommitRange is where a failure was introduced: after the commit of the
+// last clean build (Good), up to the failing build's (Bad).
+type CommitRange struct {
+	Good         string
+	Bad          string
+	GoodBuildURL string
+	Commits      int // Commits after Good up to Bad; 0 if unknown
+}
+
+// String describes the range, e.g. "introduced somewhere in 14 commits
+// between 1a2b3c4 and 5d6e7f8".
+func (r CommitRange) String() string {
+	between := fmt.Sprintf("between %s and %s", ShortCommit(r.Good), ShortCommit(r.Bad))
+	switch r.Commits {
+	case 0:
+		return "introduced somewhere " + between
+	case 1:
+		return "introduced in the 1 commit " + between
+	}
+	return fmt.Sprintf("introduced somewhere in %d commits %s", r.Commits, between)
+}
+
+// CommitRangeOf returns the commit range recorded in a finding's metadata
+// (see contracts.MetadataBisectGood), or nil if there is none.
+func CommitRangeOf(metadata map[string]string) *CommitRange {
+	good, bad := metadata[contracts.MetadataBisectGood], metadata[contracts.MetadataBisectBad]
+	if good == "" || bad == "" {
+		return nil
+	}
+	commits, _ := strconv.Atoi(metadata[contracts.MetadataBisectCommits])
+	return &CommitRange{Good: good, Bad: bad, GoodBuildURL: metadata[contracts.MetadataBisectGoodBuild], Commits: commits}
+}
+
+// ShortCommit abbreviates a commit SHA to 7 characters, as git does.
+func ShortCommit(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// FindCommitRange returns the commits a failure of build was introduced
+// in, going back to the last passed build with the same branch and
+// trigger. It returns nil without an error when the provider has no
+// CommitHistory, commits are unknown, there is no earlier passed build, or
+// that build ran the same commit (so the failure isn't the code's).
+func FindCommitRange(ctx context.Context, p Provider, ref *BuildRef, build *Build) (*CommitRange, error) {
+	history, ok := p.(CommitHistory)
+	if !ok || build.Commit == "" {
+		return nil, nil
+	}
+
+	good, err := history.FetchLastPassedBuild(ctx, ref, build)
+	if errors.Is(err, errors.ErrUnsupported) || errors.Is(err, ErrBuildNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the last passed build: %w", err)
+	}
+	if good.Commit == "" || good.Commit == build.Commit {
+		return nil, nil
+	}
+
+	r := &CommitRange{Good: good.Commit, Bad: build.Commit, GoodBuildURL: good.URL}
+	// The range is useful without its length
+	if n, err := history.CountCommits(ctx, ref, good.Commit, build.Commit); err == nil {
+		r.Commits = n
+	}
+	return r, nil
+}
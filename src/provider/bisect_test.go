@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// historyProvider has a fixed last passed build and commit count.
+type historyProvider struct {
+	Provider
+	good    *Build
+	err     error
+	commits int
+}
+
+func (p *historyProvider) FetchLastPassedBuild(ctx context.Context, ref *BuildRef, build *Build) (*Build, error) {
+	return p.good, p.err
+}
+
+func (p *historyProvider) CountCommits(ctx context.Context, ref *BuildRef, base, head string) (int, error) {
+	return p.commits, nil
+}
+
+func TestFindCommitRange(t *testing.T) {
+	ctx := context.Background()
+	ref := &BuildRef{Provider: "buildkite"}
+	failed := &Build{Commit: "5d6e7f8a9b0c", Trigger: TriggerSchedule}
+	good := &Build{Commit: "1a2b3c4d5e6f", URL: "https://buildkite.com/org/nightly/builds/41"}
+
+	tests := []struct {
+		name string
+		p    Provider
+		want string // CommitRange.String(), or "" for no range
+	}{
+		{"counted", &historyProvider{good: good, commits: 14}, "introduced somewhere in 14 commits between 1a2b3c4 and 5d6e7f8"},
+		{"uncounted", &historyProvider{good: good}, "introduced somewhere between 1a2b3c4 and 5d6e7f8"},
+		{"limited", limitProvider(&historyProvider{good: good, commits: 1}, "buildkite", "bisect-token", 1), "introduced in the 1 commit between 1a2b3c4 and 5d6e7f8"},
+		{"no passed build", &historyProvider{err: ErrBuildNotFound}, ""},
+		{"same commit", &historyProvider{good: &Build{Commit: failed.Commit}}, ""},
+		{"no history", &slowProvider{}, ""},
+		{"limited without history", limitProvider(&slowProvider{}, "buildkite", "bisect-token", 1), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := FindCommitRange(ctx, tt.p, ref, failed)
+			if err != nil {
+				t.Fatalf("FindCommitRange() error = %v", err)
+			}
+			got := ""
+			if r != nil {
+				got = r.String()
+			}
+			if got != tt.want {
+				t.Errorf("FindCommitRange() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := FindCommitRange(ctx, &historyProvider{err: errors.New("boom")}, ref, failed); err == nil {
+		t.Error("FindCommitRange() error = nil, want the lookup error")
+	}
+}
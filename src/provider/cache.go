@@ -0,0 +1,296 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"destill-agent/src/platform"
+)
+
+// EnvHTTPCache sets the directory of the on-disk API response cache, or
+// turns it off with "off" (see HTTPCacheDir).
+const EnvHTTPCache = "DESTILL_HTTP_CACHE"
+
+// HTTPCacheDir returns the directory of the on-disk API response cache,
+// creating it if needed: DESTILL_HTTP_CACHE, or "http" in the user's cache
+// directory (see platform.CacheDir). It returns "" when DESTILL_HTTP_CACHE
+// is "off", "false", or "0".
+func HTTPCacheDir() (string, error) {
+	dir := strings.TrimSpace(os.Getenv(EnvHTTPCache))
+	switch strings.ToLower(dir) {
+	case "off", "false", "0":
+		return "", nil
+	case "":
+		base, err := platform.CacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(base, "http")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create HTTP cache directory: %w", err)
+	}
+	return dir, nil
+}
+
+// ClearHTTPCache deletes the responses in the on-disk API response cache
+// and returns how many there were.
+func ClearHTTPCache() (int, error) {
+	dir, err := HTTPCacheDir()
+	if err != nil || dir == "" {
+		return 0, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	cleared := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			return cleared, err
+		}
+		if !strings.HasPrefix(entry.Name(), "tmp-") {
+			cleared++
+		}
+	}
+	return cleared, nil
+}
+
+// NewAPITransport returns the http.RoundTripper of provider API clients:
+// a RetryTransport, behind a CacheTransport unless HTTPCacheDir is off or
+// unavailable.
+func NewAPITransport() http.RoundTripper {
+	retry := NewRetryTransport(nil)
+	dir, err := HTTPCacheDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: API responses won't be cached: %v\n", err)
+	}
+	if dir == "" {
+		return retry
+	}
+	return &CacheTransport{Base: retry, Dir: dir}
+}
+
+type cacheKeyContextKey struct{}
+
+// WithCacheKey returns a context whose GET requests are cached under key
+// instead of their URL, for content served from short-lived signed URLs,
+// such as GitHub job logs.
+func WithCacheKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, cacheKeyContextKey{}, key)
+}
+
+// CacheTransport is an http.RoundTripper that keeps GET responses carrying
+// an ETag or Last-Modified header in Dir, one file per URL. A cached
+// response is revalidated on every request (If-None-Match and
+// If-Modified-Since), and served from disk when the server answers 304 Not
+// Modified, so unchanged job logs aren't downloaded again and the server
+// still checks access each time. Responses are written as they're read,
+// and kept only if read to the end.
+type CacheTransport struct {
+	Base http.RoundTripper // nil means http.DefaultTransport
+	Dir  string
+}
+
+// cacheMeta is the first line of a cache file; the response body follows.
+type cacheMeta struct {
+	Key          string      `json:"key"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"`
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return base.RoundTrip(req)
+	}
+
+	key, _ := req.Context().Value(cacheKeyContextKey{}).(string)
+	if key == "" {
+		key = req.URL.String()
+	}
+	path := filepath.Join(t.Dir, cacheFileName(key))
+
+	cached, ok := readCacheMeta(path, key)
+	outgoing := req
+	if ok {
+		outgoing = req.Clone(req.Context())
+		if cached.ETag != "" {
+			outgoing.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			outgoing.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := base.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		if cachedResp, err := openCachedResponse(path, req, resp); err == nil {
+			discard(resp)
+			return cachedResp, nil
+		}
+		// The entry went away since it was read: fetch it whole
+		discard(resp)
+		return base.RoundTrip(req)
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode == http.StatusOK && (etag != "" || lastModified != "") {
+		meta := cacheMeta{Key: key, ETag: etag, LastModified: lastModified, Header: resp.Header.Clone()}
+		if body, err := newCachingBody(resp.Body, t.Dir, path, meta); err == nil {
+			resp.Body = body
+		}
+	}
+	return resp, nil
+}
+
+// cacheFileName is the file a key is cached in: its SHA-256, so URLs with
+// tokens or odd characters make safe names.
+func cacheFileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// readCacheMeta reads the metadata of the cache file at path, if it holds
+// key.
+func readCacheMeta(path, key string) (cacheMeta, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return cacheMeta{}, false
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil {
+		return cacheMeta{}, false
+	}
+	var meta cacheMeta
+	if err := json.Unmarshal(line, &meta); err != nil || meta.Key != key {
+		return cacheMeta{}, false
+	}
+	return meta, true
+}
+
+// openCachedResponse returns the response cached at path for req, with the
+// headers of the 304 that revalidated it.
+func openCachedResponse(path string, req *http.Request, notModified *http.Response) (*http.Response, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(f)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	var meta cacheMeta
+	if err := json.Unmarshal(line, &meta); err != nil {
+		f.Close()
+		return nil, err
+	}
+	contentLength := int64(-1)
+	if info, err := f.Stat(); err == nil {
+		contentLength = info.Size() - int64(len(line))
+	}
+
+	header := meta.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+	for k, v := range notModified.Header {
+		header[k] = v
+	}
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      notModified.Proto,
+		ProtoMajor: notModified.ProtoMajor,
+		ProtoMinor: notModified.ProtoMinor,
+		Header:     header,
+		Body: struct {
+			io.Reader
+			io.Closer
+		}{reader, f},
+		ContentLength: contentLength,
+		Request:       req,
+	}, nil
+}
+
+// cachingBody copies a response body to a temporary file as it's read, and
+// moves the file into place on Close if the body was read to the end.
+type cachingBody struct {
+	io.ReadCloser
+	tmp    *os.File
+	path   string
+	eof    bool
+	failed bool
+}
+
+func newCachingBody(body io.ReadCloser, dir, path string, meta cacheMeta) (*cachingBody, error) {
+	line, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(dir, "tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Write(append(line, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return &cachingBody{ReadCloser: body, tmp: tmp, path: path}, nil
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && !b.failed {
+		if _, werr := b.tmp.Write(p[:n]); werr != nil {
+			b.failed = true
+		}
+	}
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+func (b *cachingBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.tmp == nil {
+		return err
+	}
+	tmp := b.tmp
+	b.tmp = nil
+	if cerr := tmp.Close(); cerr != nil {
+		b.failed = true
+	}
+	if !b.eof || b.failed || os.Rename(tmp.Name(), b.path) != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+// etagServer serves body with an ETag, answering 304 to a matching
+// If-None-Match, and counts the full responses it sends.
+func etagServer(t *testing.T, body string) (*httptest.Server, *atomic.Int32) {
+	var full atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &full
+}
+
+func get(t *testing.T, client *http.Client, ctx context.Context, url string) (*http.Response, string) {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	return resp, string(body)
+}
+
+func TestCacheTransport_ServesNotModifiedFromDisk(t *testing.T) {
+	server, full := etagServer(t, "line 1\nline 2\n")
+	client := &http.Client{Transport: &CacheTransport{Dir: t.TempDir()}}
+
+	for i := 0; i < 3; i++ {
+		resp, body := get(t, client, context.Background(), server.URL+"/log")
+		if resp.StatusCode != http.StatusOK || body != "line 1\nline 2\n" {
+			t.Fatalf("request %d: status %d, body %q", i, resp.StatusCode, body)
+		}
+		if got := resp.Header.Get("Content-Type"); got != "text/plain" {
+			t.Errorf("request %d: Content-Type = %q, want the cached header", i, got)
+		}
+	}
+	if full.Load() != 1 {
+		t.Errorf("server sent the body %d times, want once", full.Load())
+	}
+}
+
+func TestCacheTransport_PartialReadNotCached(t *testing.T) {
+	server, full := etagServer(t, "line 1\nline 2\n")
+	dir := t.TempDir()
+	client := &http.Client{Transport: &CacheTransport{Dir: dir}}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Read(make([]byte, 3))
+	resp.Body.Close()
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("cache holds %d files after a partial read, want none", len(entries))
+	}
+	if _, body := get(t, client, context.Background(), server.URL); body != "line 1\nline 2\n" || full.Load() != 2 {
+		t.Errorf("body %q after %d full responses, want the whole log downloaded again", body, full.Load())
+	}
+}
+
+func TestCacheTransport_WithCacheKey(t *testing.T) {
+	// Like GitHub logs: the same content behind a different signed URL each time
+	server, full := etagServer(t, "log")
+	client := &http.Client{Transport: &CacheTransport{Dir: t.TempDir()}}
+	ctx := WithCacheKey(context.Background(), "https://api.github.com/repos/o/r/actions/jobs/1/logs")
+
+	get(t, client, ctx, server.URL+"/blob?sig=a")
+	if _, body := get(t, client, ctx, server.URL+"/blob?sig=b"); body != "log" {
+		t.Errorf("body = %q", body)
+	}
+	if full.Load() != 1 {
+		t.Errorf("server sent the body %d times, want once", full.Load())
+	}
+}
+
+func TestCacheTransport_SkipsUncacheable(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		io.WriteString(w, "no validator")
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	client := &http.Client{Transport: &CacheTransport{Dir: dir}}
+
+	get(t, client, context.Background(), server.URL)
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("cache holds %d files for a response without ETag or Last-Modified, want none", len(entries))
+	}
+}
+
+func TestHTTPCacheDir(t *testing.T) {
+	t.Setenv(EnvHTTPCache, "off")
+	if dir, err := HTTPCacheDir(); err != nil || dir != "" {
+		t.Errorf("HTTPCacheDir() with %s=off = %q, %v, want disabled", EnvHTTPCache, dir, err)
+	}
+	if _, ok := NewAPITransport().(*RetryTransport); !ok {
+		t.Error("NewAPITransport() with the cache off isn't a bare RetryTransport")
+	}
+
+	custom := t.TempDir()
+	t.Setenv(EnvHTTPCache, custom)
+	if dir, err := HTTPCacheDir(); err != nil || dir != custom {
+		t.Errorf("HTTPCacheDir() = %q, %v, want %q", dir, err, custom)
+	}
+
+	server, _ := etagServer(t, "log")
+	get(t, &http.Client{Transport: NewAPITransport()}, context.Background(), server.URL)
+	if cleared, err := ClearHTTPCache(); err != nil || cleared != 1 {
+		t.Errorf("ClearHTTPCache() = %d, %v, want 1 response cleared", cleared, err)
+	}
+	if entries, _ := os.ReadDir(custom); len(entries) != 0 {
+		t.Errorf("cache holds %d files after clearing", len(entries))
+	}
+}
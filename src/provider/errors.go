@@ -12,6 +12,9 @@ var (
 	ErrNetworkTimeout = errors.New("network timeout")
 )
 
+// supportedFormats lists the build references the CLI accepts.
+const supportedFormats = "Supported formats:\n  - https://buildkite.com/org/pipeline/builds/123\n  - https://github.com/owner/repo/actions/runs/456\n  - https://gitlab.com/group/project/-/pipelines/789\n  - https://app.circleci.com/pipelines/gh/org/repo/12/workflows/<id>\n  - https://jenkins.example.com/job/name/123/\n  - ./logs-dir/ (directory of exported job logs, one file per job)\n  - org/pipeline/123 (Buildkite shorthand)\n  - alias#123 (set DESTILL_PIPELINE_ALIASES=alias=org/pipeline)"
+
 // UserError wraps errors with user-friendly messages
 type UserError struct {
 	Message string
@@ -44,9 +47,14 @@ func WrapError(err error) error {
 	msg := err.Error()
 
 	if errors.Is(err, ErrInvalidURL) {
+		hint := supportedFormats
+		var urlErr *URLError
+		if errors.As(err, &urlErr) && urlErr.Hint != "" {
+			hint = urlErr.Hint + "\n\n" + supportedFormats
+		}
 		return &UserError{
 			Message: "Invalid build URL",
-			Hint:    "Supported formats:\n  - https://buildkite.com/org/pipeline/builds/123\n  - https://github.com/owner/repo/actions/runs/456",
+			Hint:    hint,
 			Err:     err,
 		}
 	}
@@ -54,7 +62,15 @@ func WrapError(err error) error {
 	if msg == "401 Unauthorized" || errors.Is(err, ErrAuthFailed) {
 		return &UserError{
 			Message: "Authentication failed",
-			Hint:    "Check that your API token is valid and has the correct permissions.\n  - Buildkite: Set BUILDKITE_API_TOKEN\n  - GitHub: Set GITHUB_TOKEN",
+			Hint:    "Check that your API token is valid and has the correct permissions.\n  - Buildkite: Set BUILDKITE_API_TOKEN\n  - GitHub: Set GITHUB_TOKEN\n  - GitLab: Set GITLAB_TOKEN\n  - CircleCI: Set CIRCLECI_TOKEN\n  - Jenkins: Set JENKINS_USER and JENKINS_TOKEN",
+			Err:     err,
+		}
+	}
+
+	if errors.Is(err, ErrRateLimited) {
+		return &UserError{
+			Message: "API rate limit exhausted",
+			Hint:    "Destill retried until the limit's reset was too far off. Try again after it resets, or lower DESTILL_PROVIDER_CONCURRENCY so large builds use the API more slowly.",
 			Err:     err,
 		}
 	}
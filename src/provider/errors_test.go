@@ -139,15 +139,42 @@ func TestWrapError_BuildNotFound(t *testing.T) {
 	}
 }
 
-func TestWrapError_OtherErrors(t *testing.T) {
+func TestWrapError_RateLimited(t *testing.T) {
 	tests := []struct {
 		name string
 		err  error
 	}{
 		{
-			name: "rate limited",
+			name: "ErrRateLimited sentinel",
 			err:  ErrRateLimited,
 		},
+		{
+			name: "wrapped RateLimitError",
+			err:  fmt.Errorf("failed to fetch log for job tests: %w", &RateLimitError{Host: "api.github.com"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userErr, ok := WrapError(tt.err).(*UserError)
+			if !ok {
+				t.Fatalf("WrapError() returned %T, want *UserError", WrapError(tt.err))
+			}
+			if userErr.Message != "API rate limit exhausted" {
+				t.Errorf("Message = %q, want %q", userErr.Message, "API rate limit exhausted")
+			}
+			if !strings.Contains(userErr.Hint, "DESTILL_PROVIDER_CONCURRENCY") {
+				t.Errorf("Hint should mention DESTILL_PROVIDER_CONCURRENCY, got %q", userErr.Hint)
+			}
+		})
+	}
+}
+
+func TestWrapError_OtherErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
 		{
 			name: "network timeout",
 			err:  ErrNetworkTimeout,
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 )
 
 var (
@@ -15,7 +18,7 @@ var (
 
 // Provider defines the interface for CI/CD platform integrations
 type Provider interface {
-	// Name returns the provider name (e.g., "buildkite", "github")
+	// Name returns the provider name (e.g., "buildkite", "github", "gitlab", "circleci", "jenkins", "local")
 	Name() string
 
 	// ParseURL extracts build reference from URL
@@ -24,6 +27,10 @@ type Provider interface {
 	// FetchBuild retrieves build metadata and jobs
 	FetchBuild(ctx context.Context, ref *BuildRef) (*Build, error)
 
+	// FetchLatestFailedBuild finds the most recent failed build for a pipeline
+	// on the given branch. ref identifies the pipeline; its BuildID is ignored.
+	FetchLatestFailedBuild(ctx context.Context, ref *BuildRef, branch string) (*Build, error)
+
 	// FetchJobLog retrieves raw log content for a job
 	FetchJobLog(ctx context.Context, jobID string) (string, error)
 
@@ -34,13 +41,52 @@ type Provider interface {
 	DownloadArtifact(ctx context.Context, artifact Artifact) ([]byte, error)
 }
 
+// StepProvider is implemented by providers that can return a job's log split
+// by step. Ingest prefers it over FetchJobLog so findings can be attributed
+// to the step that produced them.
+type StepProvider interface {
+	FetchJobSteps(ctx context.Context, jobID string) ([]Step, error)
+}
+
+// LogStreamer is implemented by providers that can stream a job's log
+// rather than return it whole. Ingest prefers it over FetchJobLog, chunking
+// the log as it's read, so memory use doesn't grow with the log. The caller
+// closes the returned reader.
+type LogStreamer interface {
+	StreamJobLog(ctx context.Context, jobID string) (io.ReadCloser, error)
+}
+
 var (
 	buildkiteURLPattern = regexp.MustCompile(`^https://buildkite\.com/([^/]+)/([^/]+)/builds/(\d+)`)
 	githubURLPattern    = regexp.MustCompile(`^https://github\.com/([^/]+)/([^/]+)/actions/runs/(\d+)`)
+	gitlabURLPattern    = regexp.MustCompile(`^https://gitlab\.com/(.+?)/-/pipelines/(\d+)`)
+	circleciURLPattern  = regexp.MustCompile(`^https://app\.circleci\.com/pipelines/([^/]+/[^/]+/[^/]+)/(\d+)/workflows/([0-9a-f-]+)`)
+
+	// Jenkins is self-hosted, so match on path shape: {base}/job/a[/job/b...]/123/
+	jenkinsURLPattern = regexp.MustCompile(`^(https?://.+?)/((?:job/[^/]+/)*job/[^/]+)/(\d+)/?$`)
+
+	// Pipeline (not build) references, used to look up builds by branch/state
+	buildkitePipelinePattern = regexp.MustCompile(`^https://buildkite\.com/([^/]+)/([^/]+)/?$`)
+	githubRepoPattern        = regexp.MustCompile(`^https://github\.com/([^/]+)/([^/]+?)(?:/actions)?/?$`)
+	gitlabProjectPattern     = regexp.MustCompile(`^https://gitlab\.com/(.+?)(?:/-/pipelines)?/?$`)
+	circleciProjectPattern   = regexp.MustCompile(`^https://app\.circleci\.com/pipelines/([^/]+/[^/]+/[^/]+?)/?$`)
+	jenkinsJobPattern        = regexp.MustCompile(`^(https?://.+?)/((?:job/[^/]+/)*job/[^/]+)/?$`)
+	pipelineSlugPattern      = regexp.MustCompile(`^([^/\s#]+)/([^/\s#]+)$`)
 )
 
-// ParseURL detects provider and parses build reference from URL
+// ParseURL detects provider and parses build reference from URL. A URL no
+// provider matches is a *URLError.
 func ParseURL(url string) (*BuildRef, error) {
+	ref, err := matchURL(url)
+	if ref == nil && err == nil {
+		return nil, &URLError{URL: url, Hint: nearMissHint(url)}
+	}
+	return ref, err
+}
+
+// matchURL parses url with the first provider pattern it matches, and
+// returns nil without an error if none does.
+func matchURL(url string) (*BuildRef, error) {
 	// Try Buildkite pattern
 	if matches := buildkiteURLPattern.FindStringSubmatch(url); matches != nil {
 		return &BuildRef{
@@ -65,7 +111,162 @@ func ParseURL(url string) (*BuildRef, error) {
 		}, nil
 	}
 
-	return nil, fmt.Errorf("%w: %s", ErrInvalidURL, url)
+	// Try GitLab CI pattern (project path may include subgroups)
+	if matches := gitlabURLPattern.FindStringSubmatch(url); matches != nil {
+		return &BuildRef{
+			Provider: "gitlab",
+			BuildID:  matches[2],
+			Metadata: map[string]string{
+				"project": matches[1],
+			},
+		}, nil
+	}
+
+	// Try CircleCI pattern (the build is a workflow within a pipeline)
+	if matches := circleciURLPattern.FindStringSubmatch(url); matches != nil {
+		return &BuildRef{
+			Provider: "circleci",
+			BuildID:  matches[3],
+			Metadata: map[string]string{
+				"project_slug":    matches[1],
+				"pipeline_number": matches[2],
+			},
+		}, nil
+	}
+
+	// Try Jenkins pattern last since it matches any host
+	if ref := parseJenkins(jenkinsURLPattern, url); ref != nil {
+		return ref, nil
+	}
+
+	// Directory of exported job logs, one file per job
+	if strings.HasPrefix(url, "file://") {
+		return parseLocalDir(url)
+	}
+
+	return nil, nil
+}
+
+// parseJenkins matches a Jenkins build or job URL. The base URL is everything
+// before the first /job/ segment, so Jenkins served under a path prefix
+// (https://ci.example.com/jenkins/job/...) works. When JENKINS_URL is set,
+// only URLs under it are treated as Jenkins.
+func parseJenkins(pattern *regexp.Regexp, rawURL string) *BuildRef {
+	matches := pattern.FindStringSubmatch(rawURL)
+	if matches == nil {
+		return nil
+	}
+
+	baseURL := matches[1]
+	if configured := strings.TrimRight(os.Getenv("JENKINS_URL"), "/"); configured != "" && configured != baseURL {
+		return nil
+	}
+
+	ref := &BuildRef{
+		Provider: "jenkins",
+		Metadata: map[string]string{
+			"base_url": baseURL,
+			"job_path": matches[2],
+		},
+	}
+	if len(matches) > 3 {
+		ref.BuildID = matches[3]
+	}
+	return ref
+}
+
+// ParsePipeline parses a pipeline reference (rather than a single build).
+// The returned BuildRef has an empty BuildID.
+//
+// Accepted forms:
+//   - https://buildkite.com/org/pipeline
+//   - https://github.com/owner/repo
+//   - https://gitlab.com/group/project
+//   - https://app.circleci.com/pipelines/gh/org/repo
+//   - https://jenkins.example.com/job/name
+//   - org/pipeline (Buildkite)
+//   - alias (looked up in aliases, expanded to its Buildkite org/pipeline)
+func ParsePipeline(spec string, aliases map[string]string) (*BuildRef, error) {
+	if slug, ok := aliases[spec]; ok {
+		spec = slug
+	}
+
+	if matches := buildkitePipelinePattern.FindStringSubmatch(spec); matches != nil {
+		return buildkitePipelineRef(matches[1], matches[2]), nil
+	}
+
+	if matches := githubRepoPattern.FindStringSubmatch(spec); matches != nil {
+		return &BuildRef{
+			Provider: "github",
+			Metadata: map[string]string{
+				"owner": matches[1],
+				"repo":  matches[2],
+			},
+		}, nil
+	}
+
+	if matches := gitlabProjectPattern.FindStringSubmatch(spec); matches != nil {
+		return &BuildRef{
+			Provider: "gitlab",
+			Metadata: map[string]string{
+				"project": matches[1],
+			},
+		}, nil
+	}
+
+	if matches := circleciProjectPattern.FindStringSubmatch(spec); matches != nil {
+		return &BuildRef{
+			Provider: "circleci",
+			Metadata: map[string]string{
+				"project_slug": matches[1],
+			},
+		}, nil
+	}
+
+	if ref := parseJenkins(jenkinsJobPattern, spec); ref != nil {
+		return ref, nil
+	}
+
+	if matches := pipelineSlugPattern.FindStringSubmatch(spec); matches != nil {
+		return buildkitePipelineRef(matches[1], matches[2]), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrInvalidURL, spec)
+}
+
+// buildkitePipelineRef creates a pipeline-level BuildRef for Buildkite.
+func buildkitePipelineRef(org, pipeline string) *BuildRef {
+	return &BuildRef{
+		Provider: "buildkite",
+		Metadata: map[string]string{
+			"org":      org,
+			"pipeline": pipeline,
+		},
+	}
+}
+
+// PipelineName returns a provider-neutral name for the pipeline a BuildRef
+// belongs to: "org/pipeline" (Buildkite), "owner/repo" (GitHub),
+// the project path (GitLab), "org/repo" (CircleCI), the job path without
+// "job/" (Jenkins), or the directory name (local).
+func PipelineName(ref *BuildRef) string {
+	switch ref.Provider {
+	case "buildkite":
+		return ref.Metadata["org"] + "/" + ref.Metadata["pipeline"]
+	case "github":
+		return ref.Metadata["owner"] + "/" + ref.Metadata["repo"]
+	case "gitlab":
+		return ref.Metadata["project"]
+	case "circleci":
+		_, name, _ := strings.Cut(ref.Metadata["project_slug"], "/")
+		return name
+	case "jenkins":
+		return strings.ReplaceAll(strings.TrimPrefix(ref.Metadata["job_path"], "job/"), "/job/", "/")
+	case "local":
+		return filepath.Base(ref.Metadata["dir"])
+	default:
+		return ""
+	}
 }
 
 // ProviderFactory is a function that creates a provider instance
@@ -90,13 +291,29 @@ func ValidateToken(ref *BuildRef) error {
 		if os.Getenv("GITHUB_TOKEN") == "" {
 			return errors.New("GITHUB_TOKEN environment variable not set")
 		}
+	case "gitlab":
+		if os.Getenv("GITLAB_TOKEN") == "" {
+			return errors.New("GITLAB_TOKEN environment variable not set")
+		}
+	case "circleci":
+		if os.Getenv("CIRCLECI_TOKEN") == "" {
+			return errors.New("CIRCLECI_TOKEN environment variable not set")
+		}
+	case "jenkins":
+		if os.Getenv("JENKINS_USER") == "" || os.Getenv("JENKINS_TOKEN") == "" {
+			return errors.New("JENKINS_USER and JENKINS_TOKEN environment variables not set")
+		}
+	case "local":
+		// Local log directories need no token
 	default:
 		return fmt.Errorf("%w: %s", ErrProviderUnknown, ref.Provider)
 	}
 	return nil
 }
 
-// GetProvider returns the appropriate provider implementation for a build ref
+// GetProvider returns the appropriate provider implementation for a build ref.
+// API calls through it share a concurrency limit with every other provider
+// for the same token (see DESTILL_PROVIDER_CONCURRENCY).
 func GetProvider(ref *BuildRef) (Provider, error) {
 	factory, ok := providers[ref.Provider]
 	if !ok {
@@ -115,9 +332,33 @@ func GetProvider(ref *BuildRef) (Provider, error) {
 		if token == "" {
 			return nil, errors.New("GITHUB_TOKEN environment variable not set")
 		}
+	case "gitlab":
+		token = os.Getenv("GITLAB_TOKEN")
+		if token == "" {
+			return nil, errors.New("GITLAB_TOKEN environment variable not set")
+		}
+	case "circleci":
+		token = os.Getenv("CIRCLECI_TOKEN")
+		if token == "" {
+			return nil, errors.New("CIRCLECI_TOKEN environment variable not set")
+		}
+	case "jenkins":
+		// Jenkins uses basic auth; the factory receives "user:token"
+		user, apiToken := os.Getenv("JENKINS_USER"), os.Getenv("JENKINS_TOKEN")
+		if user == "" || apiToken == "" {
+			return nil, errors.New("JENKINS_USER and JENKINS_TOKEN environment variables not set")
+		}
+		token = user + ":" + apiToken
+	case "local":
+		// Local log directories need no token or limit
+		return factory(token), nil
 	default:
 		return nil, fmt.Errorf("%w: %s", ErrProviderUnknown, ref.Provider)
 	}
 
-	return factory(token), nil
+	limit, err := TokenConcurrencyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return limitProvider(factory(token), ref.Provider, token, limit), nil
 }
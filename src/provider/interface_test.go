@@ -20,6 +20,11 @@ func TestBuildProvider_ParseURL(t *testing.T) {
 			url:     "https://github.com/owner/repo/actions/runs/456",
 			wantErr: false,
 		},
+		{
+			name:    "gitlab pipeline URL",
+			url:     "https://gitlab.com/group/subgroup/project/-/pipelines/789",
+			wantErr: false,
+		},
 		{
 			name:    "invalid URL",
 			url:     "https://example.com/invalid",
@@ -36,3 +41,238 @@ func TestBuildProvider_ParseURL(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePipeline(t *testing.T) {
+	aliases := map[string]string{"backend": "myorg/backend"}
+
+	tests := []struct {
+		name         string
+		spec         string
+		wantProvider string
+		wantMeta     map[string]string
+		wantErr      bool
+	}{
+		{
+			name:         "buildkite pipeline URL",
+			spec:         "https://buildkite.com/org/pipeline",
+			wantProvider: "buildkite",
+			wantMeta:     map[string]string{"org": "org", "pipeline": "pipeline"},
+		},
+		{
+			name:         "github repo URL",
+			spec:         "https://github.com/owner/repo",
+			wantProvider: "github",
+			wantMeta:     map[string]string{"owner": "owner", "repo": "repo"},
+		},
+		{
+			name:         "github actions URL",
+			spec:         "https://github.com/owner/repo/actions",
+			wantProvider: "github",
+			wantMeta:     map[string]string{"owner": "owner", "repo": "repo"},
+		},
+		{
+			name:         "gitlab project URL",
+			spec:         "https://gitlab.com/group/subgroup/project",
+			wantProvider: "gitlab",
+			wantMeta:     map[string]string{"project": "group/subgroup/project"},
+		},
+		{
+			name:         "gitlab pipelines page",
+			spec:         "https://gitlab.com/group/project/-/pipelines",
+			wantProvider: "gitlab",
+			wantMeta:     map[string]string{"project": "group/project"},
+		},
+		{
+			name:         "bare org/pipeline",
+			spec:         "org/pipeline",
+			wantProvider: "buildkite",
+			wantMeta:     map[string]string{"org": "org", "pipeline": "pipeline"},
+		},
+		{
+			name:         "alias",
+			spec:         "backend",
+			wantProvider: "buildkite",
+			wantMeta:     map[string]string{"org": "myorg", "pipeline": "backend"},
+		},
+		{
+			name:    "build URL is not a pipeline",
+			spec:    "https://buildkite.com/org/pipeline/builds/123",
+			wantErr: true,
+		},
+		{
+			name:    "unknown alias",
+			spec:    "frontend",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := ParsePipeline(tt.spec, aliases)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePipeline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if ref.Provider != tt.wantProvider {
+				t.Errorf("Provider = %v, want %v", ref.Provider, tt.wantProvider)
+			}
+			if ref.BuildID != "" {
+				t.Errorf("BuildID = %v, want empty", ref.BuildID)
+			}
+			for k, want := range tt.wantMeta {
+				if ref.Metadata[k] != want {
+					t.Errorf("Metadata[%s] = %v, want %v", k, ref.Metadata[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseURL_GitLab(t *testing.T) {
+	ref, err := ParseURL("https://gitlab.com/group/subgroup/project/-/pipelines/789")
+	if err != nil {
+		t.Fatalf("ParseURL() error = %v", err)
+	}
+	if ref.Provider != "gitlab" {
+		t.Errorf("Provider = %v, want gitlab", ref.Provider)
+	}
+	if ref.BuildID != "789" {
+		t.Errorf("BuildID = %v, want 789", ref.BuildID)
+	}
+	if ref.Metadata["project"] != "group/subgroup/project" {
+		t.Errorf("project = %v, want group/subgroup/project", ref.Metadata["project"])
+	}
+}
+
+func TestParseURL_Jenkins(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		jenkinsURL string
+		wantErr    bool
+		wantBase   string
+		wantPath   string
+		wantBuild  string
+	}{
+		{
+			name:      "classic job",
+			url:       "https://jenkins.example.com/job/foo/123/",
+			wantBase:  "https://jenkins.example.com",
+			wantPath:  "job/foo",
+			wantBuild: "123",
+		},
+		{
+			name:      "folder and path prefix without trailing slash",
+			url:       "https://ci.example.com/jenkins/job/team/job/api/45",
+			wantBase:  "https://ci.example.com/jenkins",
+			wantPath:  "job/team/job/api",
+			wantBuild: "45",
+		},
+		{
+			name:       "matches JENKINS_URL",
+			url:        "https://jenkins.example.com/job/foo/123/",
+			jenkinsURL: "https://jenkins.example.com/",
+			wantBase:   "https://jenkins.example.com",
+			wantPath:   "job/foo",
+			wantBuild:  "123",
+		},
+		{
+			name:       "outside JENKINS_URL",
+			url:        "https://other.example.com/job/foo/123/",
+			jenkinsURL: "https://jenkins.example.com",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JENKINS_URL", tt.jenkinsURL)
+
+			ref, err := ParseURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if ref.Provider != "jenkins" {
+				t.Errorf("Provider = %v, want jenkins", ref.Provider)
+			}
+			if ref.BuildID != tt.wantBuild {
+				t.Errorf("BuildID = %v, want %v", ref.BuildID, tt.wantBuild)
+			}
+			if ref.Metadata["base_url"] != tt.wantBase {
+				t.Errorf("base_url = %v, want %v", ref.Metadata["base_url"], tt.wantBase)
+			}
+			if ref.Metadata["job_path"] != tt.wantPath {
+				t.Errorf("job_path = %v, want %v", ref.Metadata["job_path"], tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestParsePipeline_Jenkins(t *testing.T) {
+	t.Setenv("JENKINS_URL", "")
+
+	ref, err := ParsePipeline("https://jenkins.example.com/job/team/job/api/", nil)
+	if err != nil {
+		t.Fatalf("ParsePipeline() error = %v", err)
+	}
+	if ref.Provider != "jenkins" || ref.BuildID != "" {
+		t.Errorf("ref = %+v, want jenkins with empty BuildID", ref)
+	}
+	if ref.Metadata["job_path"] != "job/team/job/api" {
+		t.Errorf("job_path = %v, want job/team/job/api", ref.Metadata["job_path"])
+	}
+}
+
+func TestPipelineName(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  *BuildRef
+		want string
+	}{
+		{"buildkite", &BuildRef{Provider: "buildkite", Metadata: map[string]string{"org": "acme", "pipeline": "api"}}, "acme/api"},
+		{"github", &BuildRef{Provider: "github", Metadata: map[string]string{"owner": "acme", "repo": "web"}}, "acme/web"},
+		{"gitlab", &BuildRef{Provider: "gitlab", Metadata: map[string]string{"project": "group/sub/proj"}}, "group/sub/proj"},
+		{"jenkins", &BuildRef{Provider: "jenkins", Metadata: map[string]string{"job_path": "job/team/job/api"}}, "team/api"},
+		{"unknown", &BuildRef{Provider: "other"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PipelineName(tt.ref); got != tt.want {
+				t.Errorf("PipelineName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseURL_CircleCI(t *testing.T) {
+	ref, err := ParseURL("https://app.circleci.com/pipelines/gh/acme/api/42/workflows/5f1c2d3e-0000-4a4b-9c9d-0123456789ab")
+	if err != nil {
+		t.Fatalf("ParseURL() error = %v", err)
+	}
+	if ref.Provider != "circleci" {
+		t.Errorf("Provider = %v, want circleci", ref.Provider)
+	}
+	if ref.BuildID != "5f1c2d3e-0000-4a4b-9c9d-0123456789ab" {
+		t.Errorf("BuildID = %v, want workflow ID", ref.BuildID)
+	}
+	if ref.Metadata["project_slug"] != "gh/acme/api" || ref.Metadata["pipeline_number"] != "42" {
+		t.Errorf("Metadata = %v, want gh/acme/api pipeline 42", ref.Metadata)
+	}
+	if got := PipelineName(ref); got != "acme/api" {
+		t.Errorf("PipelineName() = %q, want acme/api", got)
+	}
+
+	ref, err = ParsePipeline("https://app.circleci.com/pipelines/gh/acme/api", nil)
+	if err != nil {
+		t.Fatalf("ParsePipeline() error = %v", err)
+	}
+	if ref.Provider != "circleci" || ref.Metadata["project_slug"] != "gh/acme/api" {
+		t.Errorf("ParsePipeline() = %+v, want circleci gh/acme/api", ref)
+	}
+}
@@ -0,0 +1,212 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// DefaultTokenConcurrency is how many API calls may be in flight at once
+// for one provider token, summed over every analysis in the process.
+const DefaultTokenConcurrency = 4
+
+// tokenLimits holds one semaphore per provider and token, shared by every
+// provider GetProvider returns, so parallel analyses (MCP tool calls, ingest
+// workers, batch runs) together stay within the token's API quota.
+var tokenLimits = struct {
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}{slots: make(map[string]chan struct{})}
+
+// TokenConcurrencyFromEnv reads DESTILL_PROVIDER_CONCURRENCY, the per-token
+// limit (default DefaultTokenConcurrency; 0 = unlimited).
+func TokenConcurrencyFromEnv() (int, error) {
+	value := os.Getenv("DESTILL_PROVIDER_CONCURRENCY")
+	if value == "" {
+		return DefaultTokenConcurrency, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("DESTILL_PROVIDER_CONCURRENCY must be a non-negative integer, got %q", value)
+	}
+	return n, nil
+}
+
+// tokenSlots returns the semaphore for a provider and token, creating it
+// with room for limit calls. The token is hashed so it isn't kept around
+// as a map key.
+func tokenSlots(name, token string, limit int) chan struct{} {
+	sum := sha256.Sum256([]byte(token))
+	key := name + "/" + hex.EncodeToString(sum[:8])
+
+	tokenLimits.mu.Lock()
+	defer tokenLimits.mu.Unlock()
+
+	slots, ok := tokenLimits.slots[key]
+	if !ok {
+		slots = make(chan struct{}, limit)
+		tokenLimits.slots[key] = slots
+	}
+	return slots
+}
+
+// limitProvider wraps p so its API calls take a slot of the semaphore for
+// name and token. The limit is fixed by whichever call creates the
+// semaphore first. With limit 0, p is returned as is. A StepProvider or
+// LogStreamer (no provider is both) stays one, and the result is always a
+// CommitHistory, unsupported unless p is one.
+func limitProvider(p Provider, name, token string, limit int) Provider {
+	if limit <= 0 {
+		return p
+	}
+	limited := &limitedProvider{Provider: p, slots: tokenSlots(name, token, limit)}
+	if sp, ok := p.(StepProvider); ok {
+		return &limitedStepProvider{limitedProvider: limited, steps: sp}
+	}
+	if ls, ok := p.(LogStreamer); ok {
+		return &limitedLogStreamer{limitedProvider: limited, streamer: ls}
+	}
+	return limited
+}
+
+// limitedProvider is a Provider whose API calls are bounded by slots.
+type limitedProvider struct {
+	Provider
+	slots chan struct{}
+}
+
+// acquire waits for a free slot, returning the function that frees it.
+func (p *limitedProvider) acquire(ctx context.Context) (func(), error) {
+	select {
+	case p.slots <- struct{}{}:
+		return func() { <-p.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *limitedProvider) FetchBuild(ctx context.Context, ref *BuildRef) (*Build, error) {
+	release, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return p.Provider.FetchBuild(ctx, ref)
+}
+
+func (p *limitedProvider) FetchLatestFailedBuild(ctx context.Context, ref *BuildRef, branch string) (*Build, error) {
+	release, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return p.Provider.FetchLatestFailedBuild(ctx, ref, branch)
+}
+
+func (p *limitedProvider) FetchJobLog(ctx context.Context, jobID string) (string, error) {
+	release, err := p.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return p.Provider.FetchJobLog(ctx, jobID)
+}
+
+func (p *limitedProvider) FetchArtifacts(ctx context.Context, jobID string) ([]Artifact, error) {
+	release, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return p.Provider.FetchArtifacts(ctx, jobID)
+}
+
+func (p *limitedProvider) DownloadArtifact(ctx context.Context, artifact Artifact) ([]byte, error) {
+	release, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return p.Provider.DownloadArtifact(ctx, artifact)
+}
+
+func (p *limitedProvider) FetchLastPassedBuild(ctx context.Context, ref *BuildRef, build *Build) (*Build, error) {
+	history, ok := p.Provider.(CommitHistory)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	release, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return history.FetchLastPassedBuild(ctx, ref, build)
+}
+
+func (p *limitedProvider) CountCommits(ctx context.Context, ref *BuildRef, base, head string) (int, error) {
+	history, ok := p.Provider.(CommitHistory)
+	if !ok {
+		return 0, errors.ErrUnsupported
+	}
+	release, err := p.acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	return history.CountCommits(ctx, ref, base, head)
+}
+
+// limitedStepProvider keeps the StepProvider of a limited provider.
+type limitedStepProvider struct {
+	*limitedProvider
+	steps StepProvider
+}
+
+func (p *limitedStepProvider) FetchJobSteps(ctx context.Context, jobID string) ([]Step, error) {
+	release, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return p.steps.FetchJobSteps(ctx, jobID)
+}
+
+// limitedLogStreamer keeps the LogStreamer of a limited provider. A stream
+// holds its slot until it's closed, like FetchJobLog holds one until the
+// whole log is read.
+type limitedLogStreamer struct {
+	*limitedProvider
+	streamer LogStreamer
+}
+
+func (p *limitedLogStreamer) StreamJobLog(ctx context.Context, jobID string) (io.ReadCloser, error) {
+	release, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.streamer.StreamJobLog(ctx, jobID)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &releasingReader{ReadCloser: body, release: release}, nil
+}
+
+// releasingReader frees its slot when it's closed.
+type releasingReader struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (r *releasingReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// callCounter records how many calls run at once.
+type callCounter struct {
+	running, peak atomic.Int32
+}
+
+// slowProvider counts its FetchJobLog calls in calls.
+type slowProvider struct {
+	Provider
+	calls *callCounter
+}
+
+func (p *slowProvider) FetchJobLog(ctx context.Context, jobID string) (string, error) {
+	n := p.calls.running.Add(1)
+	defer p.calls.running.Add(-1)
+	for {
+		peak := p.calls.peak.Load()
+		if n <= peak || p.calls.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return "log", nil
+}
+
+type slowStepProvider struct{ slowProvider }
+
+func (p *slowStepProvider) FetchJobSteps(ctx context.Context, jobID string) ([]Step, error) {
+	return []Step{{Name: "build"}}, nil
+}
+
+func TestLimitProvider_SharedPerToken(t *testing.T) {
+	// Two analyses with the same token share one limit
+	calls := &callCounter{}
+	providers := []Provider{
+		limitProvider(&slowProvider{calls: calls}, "buildkite", "shared-token", 2),
+		limitProvider(&slowProvider{calls: calls}, "buildkite", "shared-token", 2),
+	}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := providers[i%2].FetchJobLog(context.Background(), "job"); err != nil {
+				t.Errorf("FetchJobLog() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak := calls.peak.Load(); peak > 2 {
+		t.Errorf("peak concurrent calls = %d, want at most 2", peak)
+	}
+}
+
+func TestLimitProvider_SeparateTokens(t *testing.T) {
+	p := limitProvider(&slowProvider{}, "buildkite", "token-a", 1).(*limitedProvider)
+	other := limitProvider(&slowProvider{}, "buildkite", "token-b", 1).(*limitedProvider)
+	gitlab := limitProvider(&slowProvider{}, "gitlab", "token-a", 1).(*limitedProvider)
+
+	if p.slots == other.slots || p.slots == gitlab.slots {
+		t.Error("different tokens or providers share a limit")
+	}
+}
+
+func TestLimitProvider_ContextCanceled(t *testing.T) {
+	p := limitProvider(&slowProvider{calls: &callCounter{}}, "github", "busy-token", 1).(*limitedProvider)
+	release, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.FetchJobLog(ctx, "job"); !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchJobLog() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestLimitProvider_KeepsStepProvider(t *testing.T) {
+	if _, ok := limitProvider(&slowStepProvider{}, "circleci", "token", 1).(StepProvider); !ok {
+		t.Error("limited provider lost StepProvider")
+	}
+	if _, ok := limitProvider(&slowProvider{}, "circleci", "token", 1).(StepProvider); ok {
+		t.Error("limited provider gained StepProvider")
+	}
+
+	p := &slowProvider{}
+	if limitProvider(p, "circleci", "token", 0) != Provider(p) {
+		t.Error("limit 0 should return the provider unwrapped")
+	}
+}
+
+type slowLogStreamer struct{ slowProvider }
+
+func (p *slowLogStreamer) StreamJobLog(ctx context.Context, jobID string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("log")), nil
+}
+
+func TestLimitProvider_KeepsLogStreamer(t *testing.T) {
+	p, ok := limitProvider(&slowLogStreamer{}, "buildkite", "stream-token", 1).(LogStreamer)
+	if !ok {
+		t.Fatal("limited provider lost LogStreamer")
+	}
+
+	// An open stream holds the only slot until it's closed
+	body, err := p.StreamJobLog(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("StreamJobLog() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.StreamJobLog(ctx, "job-2"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("StreamJobLog() with the slot taken error = %v, want context.DeadlineExceeded", err)
+	}
+
+	body.Close()
+	body.Close()
+	second, err := p.StreamJobLog(context.Background(), "job-2")
+	if err != nil {
+		t.Fatalf("StreamJobLog() after close error = %v", err)
+	}
+	second.Close()
+}
+
+func TestTokenConcurrencyFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "", want: DefaultTokenConcurrency},
+		{value: "0", want: 0},
+		{value: "8", want: 8},
+		{value: "-1", wantErr: true},
+		{value: "many", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("DESTILL_PROVIDER_CONCURRENCY", tt.value)
+			got, err := TokenConcurrencyFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TokenConcurrencyFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("TokenConcurrencyFromEnv() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
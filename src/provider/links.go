@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LineURL returns the provider's web link to a line of a job's log, so
+// opening a finding lands on the failing line instead of the top of the
+// log. metadata is a log chunk's metadata (provider, build_url, build_id,
+// and the BuildRef metadata), and line is 1-based within the job log.
+// Returns "" for providers without line anchors and for logs split by step,
+// whose line numbers are relative to the step.
+func LineURL(metadata map[string]string, jobID string, line int) string {
+	if jobID == "" || line < 1 || metadata["step_name"] != "" {
+		return ""
+	}
+
+	switch metadata["provider"] {
+	case "buildkite":
+		// https://buildkite.com/org/pipeline/builds/123#<job-uuid>/<line>
+		buildURL, _, _ := strings.Cut(metadata["build_url"], "#")
+		buildURL, _, _ = strings.Cut(buildURL, "?")
+		if !buildkiteURLPattern.MatchString(buildURL) {
+			return ""
+		}
+		return fmt.Sprintf("%s#%s/%d", strings.TrimSuffix(buildURL, "/"), jobID, line)
+
+	case "github":
+		// Job logs are fetched whole, so the step number behind GitHub's
+		// #step:N:L anchors is unknown; link to the job instead
+		owner, repo, runID := metadata["owner"], metadata["repo"], metadata["build_id"]
+		if owner == "" || repo == "" || runID == "" {
+			return ""
+		}
+		return fmt.Sprintf("https://github.com/%s/%s/actions/runs/%s/job/%s", owner, repo, runID, lastSegment(jobID))
+
+	case "gitlab":
+		// https://gitlab.com/group/project/-/jobs/456#L123
+		project := metadata["project"]
+		if project == "" {
+			return ""
+		}
+		return fmt.Sprintf("https://gitlab.com/%s/-/jobs/%s#L%d", project, lastSegment(jobID), line)
+	}
+	return ""
+}
+
+// lastSegment strips the owner/repo or project prefix providers add to job
+// IDs, leaving the provider's own numeric ID.
+func lastSegment(id string) string {
+	return id[strings.LastIndex(id, "/")+1:]
+}
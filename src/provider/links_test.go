@@ -0,0 +1,67 @@
+package provider
+
+import "testing"
+
+func TestLineURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		jobID    string
+		line     int
+		want     string
+	}{
+		{
+			name:     "buildkite",
+			metadata: map[string]string{"provider": "buildkite", "build_url": "https://buildkite.com/org/pipe/builds/42"},
+			jobID:    "0190a1b2-c3d4-4e5f-8a9b-0c1d2e3f4a5b",
+			line:     1234,
+			want:     "https://buildkite.com/org/pipe/builds/42#0190a1b2-c3d4-4e5f-8a9b-0c1d2e3f4a5b/1234",
+		},
+		{
+			name:     "buildkite URL with fragment",
+			metadata: map[string]string{"provider": "buildkite", "build_url": "https://buildkite.com/org/pipe/builds/42/#other-job"},
+			jobID:    "job-1",
+			line:     7,
+			want:     "https://buildkite.com/org/pipe/builds/42#job-1/7",
+		},
+		{
+			name:     "github links to the job",
+			metadata: map[string]string{"provider": "github", "owner": "octo", "repo": "app", "build_id": "456"},
+			jobID:    "octo/app/789",
+			line:     10,
+			want:     "https://github.com/octo/app/actions/runs/456/job/789",
+		},
+		{
+			name:     "gitlab with subgroup",
+			metadata: map[string]string{"provider": "gitlab", "project": "group/sub/project"},
+			jobID:    "group/sub/project/321",
+			line:     55,
+			want:     "https://gitlab.com/group/sub/project/-/jobs/321#L55",
+		},
+		{
+			name:     "step-relative line",
+			metadata: map[string]string{"provider": "buildkite", "build_url": "https://buildkite.com/org/pipe/builds/42", "step_name": "test"},
+			jobID:    "job-1",
+			line:     7,
+		},
+		{
+			name:     "provider without anchors",
+			metadata: map[string]string{"provider": "jenkins", "build_url": "https://jenkins.example.com/job/api/12/"},
+			jobID:    "api#12",
+			line:     7,
+		},
+		{
+			name:     "missing line",
+			metadata: map[string]string{"provider": "gitlab", "project": "group/project"},
+			jobID:    "group/project/321",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LineURL(tt.metadata, tt.jobID, tt.line); got != tt.want {
+				t.Errorf("LineURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// windowsDrivePath matches the path of a file URL on Windows, e.g. "/C:/logs"
+var windowsDrivePath = regexp.MustCompile(`^/[A-Za-z]:/`)
+
+// LocalDirURL returns the file:// URL for a directory of exported job logs.
+// The directory is made absolute so the URL stays valid for agents.
+func LocalDirURL(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+
+	path := filepath.ToSlash(abs)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // C:/logs -> /C:/logs
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String(), nil
+}
+
+// parseLocalDir parses a file:// URL created by LocalDirURL.
+func parseLocalDir(rawURL string) (*BuildRef, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path == "" || (u.Host != "" && u.Host != "localhost") {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidURL, rawURL)
+	}
+
+	path := u.Path
+	if windowsDrivePath.MatchString(path) {
+		path = path[1:]
+	}
+	path = filepath.Clean(filepath.FromSlash(path))
+
+	return &BuildRef{
+		Provider: "local",
+		BuildID:  path,
+		Metadata: map[string]string{
+			"dir": path,
+		},
+	}, nil
+}
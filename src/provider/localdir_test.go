@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalDirURL_RoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ci logs")
+
+	rawURL, err := LocalDirURL(dir)
+	if err != nil {
+		t.Fatalf("LocalDirURL() error = %v", err)
+	}
+	if !strings.HasPrefix(rawURL, "file:///") {
+		t.Errorf("LocalDirURL() = %q, want a file:/// URL", rawURL)
+	}
+
+	ref, err := ParseURL(rawURL)
+	if err != nil {
+		t.Fatalf("ParseURL(%q) error = %v", rawURL, err)
+	}
+	if ref.Provider != "local" {
+		t.Errorf("Provider = %q, want local", ref.Provider)
+	}
+	if ref.Metadata["dir"] != dir || ref.BuildID != dir {
+		t.Errorf("dir = %q, BuildID = %q, want %q", ref.Metadata["dir"], ref.BuildID, dir)
+	}
+	if got := PipelineName(ref); got != "ci logs" {
+		t.Errorf("PipelineName() = %q, want %q", got, "ci logs")
+	}
+	if err := ValidateToken(ref); err != nil {
+		t.Errorf("ValidateToken() error = %v, want nil", err)
+	}
+}
+
+func TestParseURL_LocalDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantDir string
+		wantErr bool
+	}{
+		{name: "unix path", url: "file:///var/log/ci", wantDir: filepath.FromSlash("/var/log/ci")},
+		{name: "windows drive", url: "file:///C:/logs/ci", wantDir: filepath.FromSlash("C:/logs/ci")},
+		{name: "localhost host", url: "file://localhost/var/log/ci", wantDir: filepath.FromSlash("/var/log/ci")},
+		{name: "remote host", url: "file://server/share/logs", wantErr: true},
+		{name: "no path", url: "file://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := ParseURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && ref.Metadata["dir"] != tt.wantDir {
+				t.Errorf("dir = %q, want %q", ref.Metadata["dir"], tt.wantDir)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"context"
+	"io"
+)
+
+type maxLogBytesContextKey struct{}
+
+// WithMaxLogBytes returns a context in which FetchJobLog and FetchJobSteps
+// read at most max+1 bytes of a job's log, so a caller capping logs at max
+// bytes sees a log was cut without holding all of it in memory. max is at
+// least 0; without WithMaxLogBytes, logs are read whole.
+func WithMaxLogBytes(ctx context.Context, max int64) context.Context {
+	return context.WithValue(ctx, maxLogBytesContextKey{}, max)
+}
+
+// MaxLogBytes returns the log size limit of ctx (see WithMaxLogBytes), and
+// whether it has one.
+func MaxLogBytes(ctx context.Context) (int64, bool) {
+	max, ok := ctx.Value(maxLogBytesContextKey{}).(int64)
+	return max, ok
+}
+
+// ReadLog reads a job's log from r, stopping one byte past the limit of
+// ctx, if any.
+func ReadLog(ctx context.Context, r io.Reader) ([]byte, error) {
+	if max, ok := MaxLogBytes(ctx); ok {
+		r = io.LimitReader(r, max+1)
+	}
+	return io.ReadAll(r)
+}
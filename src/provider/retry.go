@@ -0,0 +1,242 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Retry defaults for provider API clients (see NewRetryTransport).
+const (
+	DefaultMaxRetries   = 4
+	DefaultBaseDelay    = 500 * time.Millisecond
+	DefaultMaxDelay     = 30 * time.Second
+	DefaultMaxLimitWait = time.Minute
+)
+
+// RateLimitError reports that a provider's API rate limit is exhausted and
+// won't reset soon enough to wait for. It matches ErrRateLimited, which
+// WrapError turns into a UserError.
+type RateLimitError struct {
+	Host  string
+	Reset time.Time // When the limit resets; zero if unknown
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("%s API rate limit exhausted", e.Host)
+	}
+	return fmt.Sprintf("%s API rate limit exhausted until %s", e.Host, e.Reset.Local().Format(time.Kitchen))
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// RetryTransport is an http.RoundTripper for provider API clients. It
+// retries GET requests that are rate limited (429, or GitHub's 403 with no
+// requests remaining) or fail with a 5xx status, waiting as long as
+// Retry-After or the rate limit headers say, or else backing off
+// exponentially with jitter. Once a response reports the limit used up
+// (GitHub's X-RateLimit-* or Buildkite's RateLimit-* headers), later
+// requests to the host wait for the reset instead of failing. A wait longer
+// than MaxLimitWait or past the request's deadline (such as the client's
+// Timeout), or running out of retries while rate limited, fails the request
+// with a *RateLimitError.
+type RetryTransport struct {
+	Base         http.RoundTripper // nil means http.DefaultTransport
+	MaxRetries   int               // Retries after the first attempt
+	BaseDelay    time.Duration     // First backoff, doubled on each retry
+	MaxDelay     time.Duration     // Longest single backoff
+	MaxLimitWait time.Duration     // Longest wait for a rate limit to reset
+
+	mu      sync.Mutex
+	resetAt map[string]time.Time // Host -> when its exhausted limit resets
+}
+
+// NewRetryTransport returns a RetryTransport over base with the default
+// retries and delays.
+func NewRetryTransport(base http.RoundTripper) *RetryTransport {
+	return &RetryTransport{
+		Base:         base,
+		MaxRetries:   DefaultMaxRetries,
+		BaseDelay:    DefaultBaseDelay,
+		MaxDelay:     DefaultMaxDelay,
+		MaxLimitWait: DefaultMaxLimitWait,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Host
+	if err := t.waitForReset(ctx, host); err != nil {
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	retryable := req.Method == http.MethodGet || req.Method == http.MethodHead
+
+	for attempt := 0; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		reset, exhausted := limitReset(resp.Header, now)
+		if exhausted {
+			t.setReset(host, reset)
+		}
+		limited := resp.StatusCode == http.StatusTooManyRequests ||
+			(resp.StatusCode == http.StatusForbidden && exhausted)
+		if !retryable || (!limited && !retryableStatus(resp.StatusCode)) {
+			return resp, nil
+		}
+
+		wait := retryAfter(resp.Header, now)
+		if wait == 0 && reset.After(now) {
+			wait = reset.Sub(now)
+		}
+		if limited && (attempt >= t.MaxRetries || wait > t.MaxLimitWait || pastDeadline(ctx, now.Add(wait))) {
+			discard(resp)
+			limitErr := &RateLimitError{Host: host}
+			if wait > 0 {
+				limitErr.Reset = now.Add(wait)
+			}
+			return nil, limitErr
+		}
+		if attempt >= t.MaxRetries {
+			return resp, nil
+		}
+		if wait <= 0 {
+			wait = t.backoff(attempt)
+		}
+		if pastDeadline(ctx, now.Add(wait)) {
+			return resp, nil
+		}
+
+		discard(resp)
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitForReset waits until host's exhausted rate limit resets, failing
+// with a *RateLimitError if that's further off than MaxLimitWait.
+func (t *RetryTransport) waitForReset(ctx context.Context, host string) error {
+	t.mu.Lock()
+	reset := t.resetAt[host]
+	t.mu.Unlock()
+
+	wait := time.Until(reset)
+	if wait <= 0 {
+		return nil
+	}
+	if wait > t.MaxLimitWait || pastDeadline(ctx, reset) {
+		return &RateLimitError{Host: host, Reset: reset}
+	}
+	return sleepContext(ctx, wait)
+}
+
+func (t *RetryTransport) setReset(host string, reset time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.resetAt == nil {
+		t.resetAt = make(map[string]time.Time)
+	}
+	t.resetAt[host] = reset
+}
+
+// backoff returns the delay before retry attempt+1: BaseDelay doubled per
+// attempt, capped at MaxDelay, with the upper half jittered so concurrent
+// clients don't retry in step.
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	d := t.BaseDelay << attempt
+	if d <= 0 || d > t.MaxDelay {
+		d = t.MaxDelay
+	}
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2)
+}
+
+// retryableStatus reports whether a status is a server error worth
+// retrying: 500, 502, 503, or 504.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter reads a Retry-After header, in seconds or as an HTTP date.
+// Zero if absent or invalid.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// limitReset reports whether the rate limit headers say no requests remain,
+// and when the limit resets: X-RateLimit-Reset is a Unix time (GitHub),
+// RateLimit-Reset a number of seconds (Buildkite).
+func limitReset(h http.Header, now time.Time) (time.Time, bool) {
+	if h.Get("X-RateLimit-Remaining") == "0" {
+		if epoch, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Unix(epoch, 0), true
+		}
+		return time.Time{}, true
+	}
+	if h.Get("RateLimit-Remaining") == "0" {
+		if seconds, err := strconv.Atoi(h.Get("RateLimit-Reset")); err == nil {
+			return now.Add(time.Duration(seconds) * time.Second), true
+		}
+		return time.Time{}, true
+	}
+	return time.Time{}, false
+}
+
+// pastDeadline reports whether ctx's deadline comes before t.
+func pastDeadline(ctx context.Context, t time.Time) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && deadline.Before(t)
+}
+
+// discard drains and closes a response that won't be returned, so its
+// connection can be reused.
+func discard(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+// sleepContext waits for d, or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,206 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRetryClient returns a client retrying quickly, for tests.
+func testRetryClient() (*http.Client, *RetryTransport) {
+	transport := NewRetryTransport(nil)
+	transport.BaseDelay = time.Millisecond
+	transport.MaxDelay = 5 * time.Millisecond
+	transport.MaxLimitWait = 2 * time.Second
+	return &http.Client{Transport: transport}, transport
+}
+
+// failingServer responds with statuses in turn, then 200.
+func failingServer(t *testing.T, headers http.Header, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			for k, v := range headers {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRetryTransport_RetriesServerErrorsAndTooManyRequests(t *testing.T) {
+	server, calls := failingServer(t, nil, http.StatusBadGateway, http.StatusTooManyRequests, http.StatusServiceUnavailable)
+	client, _ := testRetryClient()
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 4 {
+		t.Errorf("status %d after %d calls, want 200 after 4", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetryTransport_DoesNotRetryClientErrors(t *testing.T) {
+	server, calls := failingServer(t, nil, http.StatusNotFound)
+	client, _ := testRetryClient()
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || calls.Load() != 1 {
+		t.Errorf("status %d after %d calls, want 404 after 1", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetryTransport_ServerErrorsExhausted(t *testing.T) {
+	server, calls := failingServer(t, nil, 500, 500, 500, 500, 500, 500)
+	client, transport := testRetryClient()
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || int(calls.Load()) != transport.MaxRetries+1 {
+		t.Errorf("status %d after %d calls, want the last 500 after %d", resp.StatusCode, calls.Load(), transport.MaxRetries+1)
+	}
+}
+
+func TestRetryTransport_HonorsRetryAfter(t *testing.T) {
+	server, _ := failingServer(t, http.Header{"Retry-After": {"1"}}, http.StatusTooManyRequests)
+	client, _ := testRetryClient()
+
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %v, want Retry-After's 1s", waited)
+	}
+}
+
+func TestRetryTransport_LimitExhausted(t *testing.T) {
+	// GitHub reports a used up primary limit as 403 with a reset an hour off
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	server, calls := failingServer(t, http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {reset}}, http.StatusForbidden)
+	client, _ := testRetryClient()
+
+	_, err := client.Get(server.URL)
+	var limitErr *RateLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Get() error = %v, want a RateLimitError", err)
+	}
+	if limitErr.Reset.Before(time.Now().Add(59 * time.Minute)) {
+		t.Errorf("Reset = %v, want the header's reset an hour off", limitErr.Reset)
+	}
+
+	// Later requests fail without calling the API until the reset
+	if _, err := client.Get(server.URL); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("second Get() error = %v, want ErrRateLimited", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("API called %d times, want once", calls.Load())
+	}
+}
+
+func TestRetryTransport_WaitsForBuildkiteReset(t *testing.T) {
+	// The last request of the window succeeds, saying the limit resets in 1s
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("RateLimit-Remaining", "0")
+		w.Header().Set("RateLimit-Reset", "1")
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client, _ := testRetryClient()
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	start := time.Now()
+	resp, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("second Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if waited := time.Since(start); waited < 900*time.Millisecond {
+		t.Errorf("second request waited %v, want the 1s until reset", waited)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("API called %d times, want twice", calls.Load())
+	}
+}
+
+func TestRetryTransport_Deadline(t *testing.T) {
+	server, _ := failingServer(t, http.Header{"Retry-After": {"1"}}, http.StatusServiceUnavailable)
+	client, _ := testRetryClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+	}
+	// A wait past the deadline isn't started: the 503 is returned as is
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Do() took %v, want it to give up before the deadline", time.Since(start))
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"Mon, 15 Jan 2024 10:02:00 GMT", 2 * time.Minute},
+		{"Mon, 15 Jan 2024 09:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.value != "" {
+			h.Set("Retry-After", tt.value)
+		}
+		if got := retryAfter(h, now); got != tt.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRetryTransport_Backoff(t *testing.T) {
+	transport := NewRetryTransport(nil)
+	for attempt := 0; attempt < 10; attempt++ {
+		want := DefaultBaseDelay << attempt
+		if want > DefaultMaxDelay {
+			want = DefaultMaxDelay
+		}
+		if got := transport.backoff(attempt); got < want/2 || got > want {
+			t.Errorf("backoff(%d) = %v, want within [%v, %v]", attempt, got, want/2, want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// shorthandPattern matches bare Buildkite shorthands: org/pipeline/123
+	shorthandPattern = regexp.MustCompile(`^([^/\s#]+)/([^/\s#]+)/(\d+)$`)
+
+	// aliasPattern matches alias shorthands: backend#4091
+	aliasPattern = regexp.MustCompile(`^([^/\s#]+)#(\d+)$`)
+)
+
+// ExpandShorthand expands build shorthands into full build URLs.
+//
+// Supported forms:
+//   - alias#123        -> alias looked up in aliases, expanded to its Buildkite pipeline
+//   - org/pipeline/123 -> https://buildkite.com/org/pipeline/builds/123
+//
+// Alias values are "org/pipeline" slugs. Anything that is not a shorthand
+// (full URLs, request IDs) is returned unchanged.
+func ExpandShorthand(input string, aliases map[string]string) (string, error) {
+	input = strings.TrimSpace(input)
+
+	if matches := aliasPattern.FindStringSubmatch(input); matches != nil {
+		slug, ok := aliases[matches[1]]
+		if !ok {
+			return "", fmt.Errorf("%w: unknown pipeline alias %q", ErrInvalidURL, matches[1])
+		}
+		return buildkiteBuildURL(slug, matches[2]), nil
+	}
+
+	if matches := shorthandPattern.FindStringSubmatch(input); matches != nil {
+		return buildkiteBuildURL(matches[1]+"/"+matches[2], matches[3]), nil
+	}
+
+	return input, nil
+}
+
+// buildkiteBuildURL formats a Buildkite build URL from an "org/pipeline" slug.
+func buildkiteBuildURL(slug, buildNumber string) string {
+	return fmt.Sprintf("https://buildkite.com/%s/builds/%s", strings.Trim(slug, "/"), buildNumber)
+}
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpandShorthand(t *testing.T) {
+	aliases := map[string]string{
+		"backend": "myorg/backend-pipeline",
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "alias shorthand",
+			input: "backend#4091",
+			want:  "https://buildkite.com/myorg/backend-pipeline/builds/4091",
+		},
+		{
+			name:  "bare org/pipeline/number",
+			input: "myorg/web/123",
+			want:  "https://buildkite.com/myorg/web/builds/123",
+		},
+		{
+			name:  "full URL unchanged",
+			input: "https://github.com/owner/repo/actions/runs/456",
+			want:  "https://github.com/owner/repo/actions/runs/456",
+		},
+		{
+			name:  "request ID unchanged",
+			input: "req-20240115T143022-a3f8c91d",
+			want:  "req-20240115T143022-a3f8c91d",
+		},
+		{
+			name:    "unknown alias",
+			input:   "frontend#12",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandShorthand(tt.input, aliases)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandShorthand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidURL) {
+					t.Errorf("errors.Is(err, ErrInvalidURL) = false, want true")
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ExpandShorthand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
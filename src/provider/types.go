@@ -4,7 +4,7 @@ import "time"
 
 // BuildRef identifies a build in a CI system
 type BuildRef struct {
-	Provider string            // "buildkite" or "github"
+	Provider string            // "buildkite", "github", "gitlab", "circleci", "jenkins", or "local"
 	BuildID  string            // Unique build identifier
 	Metadata map[string]string // Provider-specific metadata
 }
@@ -15,10 +15,24 @@ type Build struct {
 	Number    string
 	URL       string
 	State     string
+	Branch    string
+	Trigger   string // Normalized trigger, one of the Trigger* constants or empty if unknown
+	Commit    string // SHA of the commit built, or empty if unknown
 	Timestamp time.Time
 	Jobs      []Job
 }
 
+// Normalized build triggers. Providers map their own event names onto these
+// so pipeline rules can match on them regardless of CI system.
+const (
+	TriggerSchedule    = "schedule"
+	TriggerPullRequest = "pull_request"
+	TriggerPush        = "push"
+	TriggerManual      = "manual"
+	TriggerAPI         = "api"
+	TriggerUpstream    = "upstream" // Started by another build or pipeline
+)
+
 // Job represents a single job within a build
 type Job struct {
 	ID        string
@@ -28,6 +42,43 @@ type Job struct {
 	ExitCode  int
 	BuildID   string
 	Timestamp time.Time
+
+	// Duration is how long the job ran. Zero when the provider doesn't
+	// report it, e.g. for a job that hasn't finished.
+	Duration time.Duration
+
+	// Retries, for providers that report them. Attempts of the same job share
+	// a RetryGroup; Attempt counts from 1 up to Attempts. Zero without retries.
+	RetryGroup string
+	Attempt    int
+	Attempts   int
+
+	// DependsOn lists the IDs of the jobs this job waited on, for providers
+	// that report job dependencies. Nil without any.
+	DependsOn []string
+}
+
+// JobStateUnknown is the state of a job whose outcome the provider can't
+// report, such as an exported log file. Its ExitCode is meaningless.
+const JobStateUnknown = "unknown"
+
+// Step is a section of a job's log produced by one step of the job
+type Step struct {
+	Name     string
+	Index    int // Position within the job, starting at 0
+	State    string
+	ExitCode int
+	Duration time.Duration // Zero when unknown
+	Log      string
+}
+
+// Elapsed returns the time from start to end, or zero unless both are set
+// and end is after start.
+func Elapsed(start, end *time.Time) time.Duration {
+	if start == nil || end == nil || start.IsZero() || end.IsZero() || !end.After(*start) {
+		return 0
+	}
+	return end.Sub(*start)
 }
 
 // Artifact represents a build artifact
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// URLError is returned by ParseURL for a string that isn't a build URL of
+// any registered provider. It matches ErrInvalidURL with errors.Is. Hint is
+// set when the URL is a near miss, e.g. a pull request or pipeline page of a
+// known provider, and says what the build URL looks like instead.
+type URLError struct {
+	URL  string
+	Hint string
+}
+
+func (e *URLError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrInvalidURL, e.URL)
+}
+
+func (e *URLError) Unwrap() error {
+	return ErrInvalidURL
+}
+
+// IsURL reports whether s is a URL rather than a request ID or shorthand:
+// it has an http, https, or file scheme.
+func IsURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "file://")
+}
+
+// ValidateBuildURL parses a build URL and checks the token its provider
+// needs is set, so a bad URL or missing token fails before any work starts.
+// Errors are wrapped for display (see WrapError).
+func ValidateBuildURL(buildURL string) (*BuildRef, error) {
+	ref, err := ParseURL(buildURL)
+	if err != nil {
+		return nil, WrapError(err)
+	}
+	if err := ValidateToken(ref); err != nil {
+		return nil, WrapError(err)
+	}
+	return ref, nil
+}
+
+var (
+	githubPullPattern = regexp.MustCompile(`^/[^/]+/[^/]+/pull/\d+`)
+	gitlabJobPattern  = regexp.MustCompile(`^/.+?/-/(jobs|merge_requests)/\d+`)
+)
+
+// nearMissHint explains how to get from a string ParseURL rejected to a build
+// URL, for strings that are almost one: a known provider's URL of another
+// page, the wrong scheme, or no scheme. It returns "" for anything else.
+func nearMissHint(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		if isBuildURL("https://" + raw) {
+			return "Add the scheme: https://" + raw
+		}
+		if u, err := url.Parse("https://" + raw); err == nil && knownHost(u.Host) {
+			return nearMissHint("https://" + raw)
+		}
+		return ""
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(u.Host, "www.")
+	if knownHost(host) && (u.Scheme != "https" || host != u.Host) {
+		fixed := "https://" + host + u.RequestURI()
+		if isBuildURL(fixed) {
+			return "Use " + fixed
+		}
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	switch host {
+	case "buildkite.com":
+		if strings.Count(path, "/") == 2 {
+			return "This is a Buildkite pipeline, not a build. Add /builds/<number>, or pass the pipeline with --latest-failed."
+		}
+		return "Buildkite build URLs look like https://buildkite.com/org/pipeline/builds/123"
+	case "github.com":
+		if githubPullPattern.MatchString(path) {
+			return "This is a pull request. Open its failed check and use the Actions run URL (https://github.com/owner/repo/actions/runs/<id>)."
+		}
+		if githubRepoPattern.MatchString("https://github.com" + path) {
+			return "This is a repository, not a workflow run. Use an Actions run URL, or pass the repository with --latest-failed."
+		}
+		return "GitHub Actions run URLs look like https://github.com/owner/repo/actions/runs/456"
+	case "gitlab.com":
+		if matches := gitlabJobPattern.FindStringSubmatch(path); matches != nil {
+			return fmt.Sprintf("This is a %s, not a pipeline. Use its pipeline URL (https://gitlab.com/group/project/-/pipelines/<id>).",
+				strings.TrimSuffix(strings.ReplaceAll(matches[1], "_", " "), "s"))
+		}
+		return "GitLab pipeline URLs look like https://gitlab.com/group/project/-/pipelines/789"
+	case "app.circleci.com":
+		return "CircleCI build URLs name the workflow: https://app.circleci.com/pipelines/gh/org/repo/12/workflows/<id>"
+	}
+
+	if strings.Contains(path, "/job/") {
+		if configured := strings.TrimRight(os.Getenv("JENKINS_URL"), "/"); configured != "" && !strings.HasPrefix(raw, configured+"/") {
+			return fmt.Sprintf("JENKINS_URL is %s, and only Jenkins URLs under it are recognized.", configured)
+		}
+		return "Jenkins build URLs end in the build number: https://jenkins.example.com/job/name/123/"
+	}
+	return ""
+}
+
+// isBuildURL reports whether a provider parses s as a build URL.
+func isBuildURL(s string) bool {
+	ref, err := matchURL(s)
+	return ref != nil && err == nil
+}
+
+// knownHost reports whether host is the fixed host of a hosted provider.
+func knownHost(host string) bool {
+	switch strings.TrimPrefix(host, "www.") {
+	case "buildkite.com", "github.com", "gitlab.com", "app.circleci.com":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateBuildURL(t *testing.T) {
+	t.Setenv("BUILDKITE_API_TOKEN", "bk-token")
+	t.Setenv("GITHUB_TOKEN", "")
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{"buildkite with token", "https://buildkite.com/myorg/pipeline/builds/123", ""},
+		{"github without token", "https://github.com/owner/repo/actions/runs/123456", "GITHUB_TOKEN"},
+		{"not a URL", "not-a-url", "Invalid build URL"},
+		{"wrong domain", "https://example.com/builds/123", "Invalid build URL"},
+		{"empty", "", "Invalid build URL"},
+		{"short", "http", "Invalid build URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateBuildURL(tt.url)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateBuildURL(%q) error = %v, want nil", tt.url, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateBuildURL(%q) error = %v, want one containing %q", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsURL(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"https://buildkite.com/org/pipeline/builds/1", true},
+		{"http://jenkins.local/job/a/1/", true},
+		{"file:///tmp/logs", true},
+		{"req-20250115T093000-deadbeef", false},
+		{"http", false},
+		{"https", false},
+		{"httpd-logs", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsURL(tt.s); got != tt.want {
+			t.Errorf("IsURL(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestParseURL_NearMissHints(t *testing.T) {
+	t.Setenv("JENKINS_URL", "")
+
+	tests := []struct {
+		name string
+		url  string
+		want string // Substring of the hint; "" for no hint
+	}{
+		{"buildkite pipeline", "https://buildkite.com/org/pipeline", "--latest-failed"},
+		{"buildkite http", "http://buildkite.com/org/pipeline/builds/12", "Use https://buildkite.com/org/pipeline/builds/12"},
+		{"buildkite no scheme", "buildkite.com/org/pipeline/builds/12", "Add the scheme"},
+		{"github www", "https://www.github.com/o/r/actions/runs/5", "Use https://github.com/o/r/actions/runs/5"},
+		{"github pull request", "https://github.com/o/r/pull/42", "pull request"},
+		{"github pull request no scheme", "github.com/o/r/pull/42", "pull request"},
+		{"github repository", "https://github.com/o/r", "repository"},
+		{"gitlab job", "https://gitlab.com/group/project/-/jobs/99", "This is a job"},
+		{"gitlab merge request", "https://gitlab.com/group/project/-/merge_requests/7", "This is a merge request"},
+		{"circleci pipeline", "https://app.circleci.com/pipelines/gh/org/repo/12", "workflows"},
+		{"jenkins job", "https://ci.example.com/job/app/", "build number"},
+		{"unrelated", "https://example.com/builds/123", ""},
+		{"not a URL", "not-a-url", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseURL(tt.url)
+			var urlErr *URLError
+			if !errors.As(err, &urlErr) {
+				t.Fatalf("ParseURL(%q) error = %v, want a *URLError", tt.url, err)
+			}
+			if !errors.Is(err, ErrInvalidURL) {
+				t.Errorf("errors.Is(err, ErrInvalidURL) = false, want true")
+			}
+			if tt.want == "" {
+				if urlErr.Hint != "" {
+					t.Errorf("Hint = %q, want none", urlErr.Hint)
+				}
+				return
+			}
+			if !strings.Contains(urlErr.Hint, tt.want) {
+				t.Errorf("Hint = %q, want one containing %q", urlErr.Hint, tt.want)
+			}
+			if wrapped := WrapError(err).Error(); !strings.Contains(wrapped, urlErr.Hint) || !strings.Contains(wrapped, "Supported formats") {
+				t.Errorf("WrapError() = %q, want the hint and the supported formats", wrapped)
+			}
+		})
+	}
+}
+
+func TestParseURL_JenkinsOutsideConfiguredURL(t *testing.T) {
+	t.Setenv("JENKINS_URL", "https://jenkins.internal")
+
+	_, err := ParseURL("https://other.example.com/job/app/12/")
+	var urlErr *URLError
+	if !errors.As(err, &urlErr) || !strings.Contains(urlErr.Hint, "JENKINS_URL is https://jenkins.internal") {
+		t.Errorf("ParseURL() error = %v, want a hint naming JENKINS_URL", err)
+	}
+}
@@ -0,0 +1,120 @@
+package ranking
+
+import (
+	"fmt"
+
+	"destill-agent/src/contracts"
+)
+
+// Comparison counts the findings of two requests, e.g. a branch build and
+// a main build, by which of them has them.
+type Comparison struct {
+	First      string `json:"first_request_id"`
+	Second     string `json:"second_request_id"`
+	Shared     int    `json:"shared"`      // Unique findings in both requests
+	OnlyFirst  int    `json:"only_first"`  // Unique findings only in the first
+	OnlySecond int    `json:"only_second"` // Unique findings only in the second
+}
+
+// String describes the comparison, e.g. "req-1 vs req-2: 2 shared, 1 only
+// in req-1, 3 only in req-2".
+func (c Comparison) String() string {
+	return fmt.Sprintf("%s vs %s: %d shared, %d only in %s, %d only in %s",
+		c.First, c.Second, c.Shared, c.OnlyFirst, c.First, c.OnlySecond, c.Second)
+}
+
+// CompareRequests labels the findings of two requests by which of them has
+// them, matched by message hash: metadata compare_first_request_id,
+// compare_second_request_id, and comparison only_first, only_second, or
+// shared. The result holds the first request's findings, then the second's
+// that the first doesn't have, so a shared finding is shown once. Returns
+// a new slice and the counts; the input cards are not modified.
+func CompareRequests(first, second []contracts.TriageCard, firstID, secondID string) ([]contracts.TriageCard, Comparison) {
+	inFirst := make(map[string]bool, len(first))
+	for _, card := range first {
+		inFirst[card.MessageHash] = true
+	}
+	inSecond := make(map[string]bool, len(second))
+	for _, card := range second {
+		inSecond[card.MessageHash] = true
+	}
+
+	comparison := Comparison{First: firstID, Second: secondID}
+	result := make([]contracts.TriageCard, 0, len(first)+len(second))
+	label := func(card contracts.TriageCard, side string) {
+		metadata := make(map[string]string, len(card.Metadata)+3)
+		for k, v := range card.Metadata {
+			metadata[k] = v
+		}
+		metadata[contracts.MetadataCompareFirst] = firstID
+		metadata[contracts.MetadataCompareSecond] = secondID
+		metadata[contracts.MetadataComparison] = side
+		card.Metadata = metadata
+		result = append(result, card)
+	}
+	for _, card := range first {
+		if inSecond[card.MessageHash] {
+			label(card, contracts.ComparisonShared)
+		} else {
+			label(card, contracts.ComparisonOnlyFirst)
+		}
+	}
+	for _, card := range second {
+		if !inFirst[card.MessageHash] {
+			label(card, contracts.ComparisonOnlySecond)
+		}
+	}
+
+	for hash := range inFirst {
+		if inSecond[hash] {
+			comparison.Shared++
+		} else {
+			comparison.OnlyFirst++
+		}
+	}
+	for hash := range inSecond {
+		if !inFirst[hash] {
+			comparison.OnlySecond++
+		}
+	}
+	return result, comparison
+}
+
+// ComparisonOf recounts the comparison from cards labeled by
+// CompareRequests. ok is false when the cards aren't labeled.
+func ComparisonOf(cards []contracts.TriageCard) (comparison Comparison, ok bool) {
+	seen := make(map[string]bool, len(cards))
+	for _, card := range cards {
+		side := card.Metadata[contracts.MetadataComparison]
+		if side == "" || seen[card.MessageHash] {
+			continue
+		}
+		seen[card.MessageHash] = true
+		comparison.First = card.Metadata[contracts.MetadataCompareFirst]
+		comparison.Second = card.Metadata[contracts.MetadataCompareSecond]
+		switch side {
+		case contracts.ComparisonShared:
+			comparison.Shared++
+		case contracts.ComparisonOnlyFirst:
+			comparison.OnlyFirst++
+		case contracts.ComparisonOnlySecond:
+			comparison.OnlySecond++
+		}
+	}
+	return comparison, len(seen) > 0
+}
+
+// ComparisonNote describes which of the compared requests have the card,
+// e.g. "Only in req-1", or "" when the card isn't labeled.
+func ComparisonNote(card contracts.TriageCard) string {
+	first, second := card.Metadata[contracts.MetadataCompareFirst], card.Metadata[contracts.MetadataCompareSecond]
+	switch card.Metadata[contracts.MetadataComparison] {
+	case contracts.ComparisonShared:
+		return fmt.Sprintf("In both %s and %s", first, second)
+	case contracts.ComparisonOnlyFirst:
+		return fmt.Sprintf("Only in %s (not in %s)", first, second)
+	case contracts.ComparisonOnlySecond:
+		return fmt.Sprintf("Only in %s (not in %s)", second, first)
+	}
+	return ""
+}
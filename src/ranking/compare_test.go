@@ -0,0 +1,59 @@
+package ranking
+
+import (
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestCompareRequests(t *testing.T) {
+	first := []contracts.TriageCard{
+		{MessageHash: "hash-shared", Metadata: map[string]string{"step_name": "test"}},
+		{MessageHash: "hash-shared"},
+		{MessageHash: "hash-branch"},
+	}
+	second := []contracts.TriageCard{
+		{MessageHash: "hash-shared"},
+		{MessageHash: "hash-main"},
+		{MessageHash: "hash-main"},
+	}
+
+	compared, comparison := CompareRequests(first, second, "req-branch", "req-main")
+
+	want := Comparison{First: "req-branch", Second: "req-main", Shared: 1, OnlyFirst: 1, OnlySecond: 1}
+	if comparison != want {
+		t.Errorf("CompareRequests() comparison = %+v, want %+v", comparison, want)
+	}
+	wantSides := []string{
+		contracts.ComparisonShared, contracts.ComparisonShared, contracts.ComparisonOnlyFirst,
+		contracts.ComparisonOnlySecond, contracts.ComparisonOnlySecond,
+	}
+	if len(compared) != len(wantSides) {
+		t.Fatalf("CompareRequests() returned %d cards, want %d", len(compared), len(wantSides))
+	}
+	for i, card := range compared {
+		if got := card.Metadata[contracts.MetadataComparison]; got != wantSides[i] {
+			t.Errorf("card %d comparison = %q, want %q", i, got, wantSides[i])
+		}
+	}
+	if compared[0].Metadata["step_name"] != "test" {
+		t.Error("CompareRequests() dropped existing metadata")
+	}
+	if _, ok := first[0].Metadata[contracts.MetadataComparison]; ok {
+		t.Error("CompareRequests() modified the input cards")
+	}
+	if got := ComparisonNote(compared[3]); got != "Only in req-main (not in req-branch)" {
+		t.Errorf("ComparisonNote() = %q", got)
+	}
+
+	recounted, ok := ComparisonOf(compared)
+	if !ok || recounted != want {
+		t.Errorf("ComparisonOf() = %+v, %v, want %+v, true", recounted, ok, want)
+	}
+	if _, ok := ComparisonOf(first); ok {
+		t.Error("ComparisonOf(unlabeled) ok = true, want false")
+	}
+	if got, wantText := want.String(), "req-branch vs req-main: 1 shared, 1 only in req-branch, 1 only in req-main"; got != wantText {
+		t.Errorf("String() = %q, want %q", got, wantText)
+	}
+}
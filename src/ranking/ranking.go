@@ -4,8 +4,6 @@
 package ranking
 
 import (
-	"sort"
-
 	"destill-agent/src/contracts"
 )
 
@@ -17,37 +15,35 @@ const (
 
 // RankedCard wraps a TriageCard with tier and rank information.
 type RankedCard struct {
-	Card contracts.TriageCard
-	Tier int // TierUnique (1) or TierNoise (3)
-	Rank int // Position within the flattened list (1-indexed)
+	Card  contracts.TriageCard
+	Tier  int     // TierUnique (1) or TierNoise (3)
+	Rank  int     // Position within the flattened list (1-indexed)
+	Score float64 // Composite score (see SortCards)
 }
 
-// TieredCards groups cards by tier, each tier sorted by confidence.
+// TieredCards groups cards by tier, each tier sorted by composite score.
 type TieredCards struct {
 	Unique []RankedCard // Unique failures (highest signal)
 	Noise  []RankedCard // Common noise (lowest signal)
 }
 
 // RankCards classifies cards into tiers and returns grouped results.
-// Each tier is sorted by confidence (descending), then recurrence (descending).
-// Duplicates (same NormalizedMsg) are removed, keeping highest confidence.
+// Each tier is sorted by composite score (see SortCards), descending.
+// Duplicates (same NormalizedMsg) are removed, keeping the highest score.
+// Findings that disappeared after a retry are penalized (see MarkTransients).
 func RankCards(cards []contracts.TriageCard) TieredCards {
 	if len(cards) == 0 {
 		return TieredCards{}
 	}
+	cards = MarkTransients(cards)
 
 	// Build job state map for cross-job analysis
 	jobStates := BuildJobStateMap(cards)
 
-	// Sort by confidence descending, then recurrence descending
 	sorted := make([]contracts.TriageCard, len(cards))
 	copy(sorted, cards)
-	sort.Slice(sorted, func(i, j int) bool {
-		if sorted[i].ConfidenceScore != sorted[j].ConfidenceScore {
-			return sorted[i].ConfidenceScore > sorted[j].ConfidenceScore
-		}
-		return sorted[i].GetRecurrenceCount() > sorted[j].GetRecurrenceCount()
-	})
+	SortCards(sorted)
+	score := currentScorer()
 
 	// Track seen patterns to deduplicate
 	seen := make(map[string]bool)
@@ -55,7 +51,7 @@ func RankCards(cards []contracts.TriageCard) TieredCards {
 	var unique, noise []RankedCard
 
 	for _, card := range sorted {
-		// Skip duplicates (keep first occurrence = highest confidence)
+		// Skip duplicates (keep first occurrence = highest score)
 		if seen[card.NormalizedMsg] {
 			continue
 		}
@@ -63,8 +59,9 @@ func RankCards(cards []contracts.TriageCard) TieredCards {
 
 		tier := ClassifyTier(card, jobStates)
 		ranked := RankedCard{
-			Card: card,
-			Tier: tier,
+			Card:  card,
+			Tier:  tier,
+			Score: score(FactorsOf(card, jobStates)),
 		}
 
 		switch tier {
@@ -108,7 +105,13 @@ func (tc TieredCards) Counts() (unique, noise int) {
 
 // ClassifyTier determines which tier a card belongs to.
 // Returns TierUnique (unique failures) or TierNoise (common noise).
+// Findings that disappeared after a retry and informational findings (such
+// as slow jobs) are noise.
 func ClassifyTier(card contracts.TriageCard, jobStates map[string]string) int {
+	if IsTransient(card) || IsInformational(card) {
+		return TierNoise
+	}
+
 	state, exists := jobStates[card.NormalizedMsg]
 	if !exists {
 		// Unknown pattern - treat as unique failure
@@ -124,6 +127,12 @@ func ClassifyTier(card contracts.TriageCard, jobStates map[string]string) int {
 	return TierNoise
 }
 
+// IsInformational reports whether the card is an informational finding that
+// doesn't come from a log line, such as a slow job.
+func IsInformational(card contracts.TriageCard) bool {
+	return card.Metadata[contracts.MetadataFindingType] != ""
+}
+
 // BuildJobStateMap creates a map of normalized_msg -> job state.
 // Values are "failed", "passed", or "both".
 // Cards with missing or empty job_state are skipped.
@@ -156,9 +165,11 @@ func CountPassingJobs(cards []contracts.TriageCard, pattern string) int {
 	seenJobs := make(map[string]bool)
 	for _, card := range cards {
 		if card.NormalizedMsg == pattern && card.Metadata["job_state"] == "passed" {
-			if !seenJobs[card.JobName] {
-				seenJobs[card.JobName] = true
-				count++
+			for _, job := range card.Jobs() {
+				if !seenJobs[job] {
+					seenJobs[job] = true
+					count++
+				}
 			}
 		}
 	}
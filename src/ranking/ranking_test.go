@@ -100,6 +100,16 @@ func TestClassifyTier(t *testing.T) {
 	}
 }
 
+func TestClassifyTierInformational(t *testing.T) {
+	card := contracts.TriageCard{
+		NormalizedMsg: "slow job",
+		Metadata:      map[string]string{contracts.MetadataFindingType: contracts.FindingTypeSlowJob},
+	}
+	if got := ClassifyTier(card, map[string]string{"slow job": "failed"}); got != TierNoise {
+		t.Errorf("ClassifyTier(slow job) = %d, want %d", got, TierNoise)
+	}
+}
+
 func TestRankCards(t *testing.T) {
 	cards := []contracts.TriageCard{
 		{
@@ -0,0 +1,97 @@
+package ranking
+
+import (
+	"fmt"
+
+	"destill-agent/src/contracts"
+)
+
+// RerunSummary compares an analysis with the previous analysis of the same
+// build.
+type RerunSummary struct {
+	PreviousRequestID string `json:"previous_request_id"`
+	CarriedOver       int    `json:"carried_over"` // Unique findings also in the previous analysis
+	New               int    `json:"new"`          // Unique findings only in this one
+	Resolved          int    `json:"resolved"`     // Unique findings only in the previous one
+}
+
+// String describes the comparison, e.g. "Re-run of req-1: 2 carried over,
+// 1 new, 3 resolved". Resolved is left out when zero.
+func (s RerunSummary) String() string {
+	text := fmt.Sprintf("Re-run of %s: %d carried over, %d new", s.PreviousRequestID, s.CarriedOver, s.New)
+	if s.Resolved > 0 {
+		text += fmt.Sprintf(", %d resolved", s.Resolved)
+	}
+	return text
+}
+
+// MarkRerun labels the findings of a re-analyzed build against the findings
+// of its previous analysis, matched by message hash: metadata
+// previous_request_id, and rerun_status carried_over or new. Returns a new
+// slice and the counts; the input cards are not modified.
+func MarkRerun(cards, previous []contracts.TriageCard, previousRequestID string) ([]contracts.TriageCard, RerunSummary) {
+	before := make(map[string]bool, len(previous))
+	for _, card := range previous {
+		before[card.MessageHash] = true
+	}
+
+	summary := RerunSummary{PreviousRequestID: previousRequestID}
+	seen := make(map[string]bool, len(cards))
+	result := make([]contracts.TriageCard, len(cards))
+	for i, card := range cards {
+		status := contracts.RerunNew
+		if before[card.MessageHash] {
+			status = contracts.RerunCarriedOver
+		}
+		if !seen[card.MessageHash] {
+			seen[card.MessageHash] = true
+			if status == contracts.RerunNew {
+				summary.New++
+			} else {
+				summary.CarriedOver++
+			}
+		}
+
+		metadata := make(map[string]string, len(card.Metadata)+2)
+		for k, v := range card.Metadata {
+			metadata[k] = v
+		}
+		metadata[contracts.MetadataPreviousRequest] = previousRequestID
+		metadata[contracts.MetadataRerunStatus] = status
+		result[i] = card
+		result[i].Metadata = metadata
+	}
+
+	for hash := range before {
+		if !seen[hash] {
+			summary.Resolved++
+		}
+	}
+	return result, summary
+}
+
+// RerunSummaryOf recounts the summary from cards labeled by MarkRerun.
+// Resolved findings aren't among the cards, so Resolved is always 0. ok is
+// false when the cards aren't labeled.
+func RerunSummaryOf(cards []contracts.TriageCard) (summary RerunSummary, ok bool) {
+	seen := make(map[string]bool, len(cards))
+	for _, card := range cards {
+		status := card.Metadata[contracts.MetadataRerunStatus]
+		if status == "" || seen[card.MessageHash] {
+			continue
+		}
+		seen[card.MessageHash] = true
+		summary.PreviousRequestID = card.Metadata[contracts.MetadataPreviousRequest]
+		if status == contracts.RerunNew {
+			summary.New++
+		} else {
+			summary.CarriedOver++
+		}
+	}
+	return summary, len(seen) > 0
+}
+
+// IsNewInRerun reports whether MarkRerun labeled the card as new.
+func IsNewInRerun(card contracts.TriageCard) bool {
+	return card.Metadata[contracts.MetadataRerunStatus] == contracts.RerunNew
+}
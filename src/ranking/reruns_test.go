@@ -0,0 +1,67 @@
+package ranking
+
+import (
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestMarkRerun(t *testing.T) {
+	previous := []contracts.TriageCard{
+		{MessageHash: "hash-a"},
+		{MessageHash: "hash-b"},
+		{MessageHash: "hash-gone"},
+	}
+	cards := []contracts.TriageCard{
+		{MessageHash: "hash-a", Metadata: map[string]string{"step_name": "test"}},
+		{MessageHash: "hash-a"},
+		{MessageHash: "hash-b"},
+		{MessageHash: "hash-new"},
+	}
+
+	marked, summary := MarkRerun(cards, previous, "req-1")
+
+	want := RerunSummary{PreviousRequestID: "req-1", CarriedOver: 2, New: 1, Resolved: 1}
+	if summary != want {
+		t.Errorf("MarkRerun() summary = %+v, want %+v", summary, want)
+	}
+	wantNew := []bool{false, false, false, true}
+	for i, card := range marked {
+		if got := IsNewInRerun(card); got != wantNew[i] {
+			t.Errorf("IsNewInRerun(card %d) = %v, want %v", i, got, wantNew[i])
+		}
+		if got := card.Metadata[contracts.MetadataPreviousRequest]; got != "req-1" {
+			t.Errorf("card %d previous request = %q, want %q", i, got, "req-1")
+		}
+	}
+	if marked[0].Metadata["step_name"] != "test" {
+		t.Error("MarkRerun() dropped existing metadata")
+	}
+	if _, ok := cards[0].Metadata[contracts.MetadataRerunStatus]; ok {
+		t.Error("MarkRerun() modified the input cards")
+	}
+
+	recounted, ok := RerunSummaryOf(marked)
+	want.Resolved = 0
+	if !ok || recounted != want {
+		t.Errorf("RerunSummaryOf() = %+v, %v, want %+v, true", recounted, ok, want)
+	}
+	if _, ok := RerunSummaryOf(cards); ok {
+		t.Error("RerunSummaryOf(unlabeled) ok = true, want false")
+	}
+}
+
+func TestRerunSummaryString(t *testing.T) {
+	tests := []struct {
+		summary RerunSummary
+		want    string
+	}{
+		{RerunSummary{PreviousRequestID: "req-1", CarriedOver: 2, New: 1}, "Re-run of req-1: 2 carried over, 1 new"},
+		{RerunSummary{PreviousRequestID: "req-1", CarriedOver: 2, Resolved: 3}, "Re-run of req-1: 2 carried over, 0 new, 3 resolved"},
+	}
+	for _, tt := range tests {
+		if got := tt.summary.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ranking
+
+import (
+	"strconv"
+
+	"destill-agent/src/contracts"
+)
+
+// TransientPenalty scales the confidence of findings that disappeared after
+// a retry.
+const TransientPenalty = 0.5
+
+// MarkTransients labels findings from an earlier attempt of a retried job
+// whose message doesn't appear in the job's final attempt: the retry made
+// them go away, so they are likely transient (infrastructure blips, flaky
+// tests) rather than the cause of the build failure. Labeled findings get
+// metadata retry_outcome=transient and their confidence scaled by
+// TransientPenalty. Returns a new slice; the input cards are not modified,
+// and cards labeled by an earlier call are not penalized again.
+func MarkTransients(cards []contracts.TriageCard) []contracts.TriageCard {
+	final := make(map[string]map[string]bool) // Retry group -> messages in the final attempt
+	for _, card := range cards {
+		group, attempt, attempts := retryInfo(card)
+		if group == "" || attempt != attempts {
+			continue
+		}
+		if final[group] == nil {
+			final[group] = make(map[string]bool)
+		}
+		final[group][card.NormalizedMsg] = true
+	}
+
+	result := make([]contracts.TriageCard, len(cards))
+	for i, card := range cards {
+		result[i] = card
+		group, attempt, attempts := retryInfo(card)
+		if group == "" || attempt >= attempts || IsTransient(card) || final[group][card.NormalizedMsg] {
+			continue
+		}
+
+		metadata := make(map[string]string, len(card.Metadata)+1)
+		for k, v := range card.Metadata {
+			metadata[k] = v
+		}
+		metadata[contracts.MetadataRetryOutcome] = contracts.RetryTransient
+		result[i].Metadata = metadata
+		result[i].ConfidenceScore = card.ConfidenceScore * TransientPenalty
+	}
+	return result
+}
+
+// IsTransient reports whether MarkTransients labeled the card.
+func IsTransient(card contracts.TriageCard) bool {
+	return card.Metadata[contracts.MetadataRetryOutcome] == contracts.RetryTransient
+}
+
+// retryInfo reads a card's retry metadata. group is empty for jobs that
+// weren't retried.
+func retryInfo(card contracts.TriageCard) (group string, attempt, attempts int) {
+	group = card.Metadata[contracts.MetadataRetryGroup]
+	if group == "" {
+		return "", 0, 0
+	}
+	attempt, err1 := strconv.Atoi(card.Metadata[contracts.MetadataAttempt])
+	attempts, err2 := strconv.Atoi(card.Metadata[contracts.MetadataAttempts])
+	if err1 != nil || err2 != nil {
+		return "", 0, 0
+	}
+	return group, attempt, attempts
+}
@@ -0,0 +1,125 @@
+package ranking
+
+import (
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func retryCard(msg, attempt, attempts string) contracts.TriageCard {
+	return contracts.TriageCard{
+		NormalizedMsg:   msg,
+		ConfidenceScore: 0.9,
+		Metadata: map[string]string{
+			contracts.MetadataRetryGroup: "job-1",
+			contracts.MetadataAttempt:    attempt,
+			contracts.MetadataAttempts:   attempts,
+		},
+	}
+}
+
+func TestMarkTransients(t *testing.T) {
+	tests := []struct {
+		name      string
+		cards     []contracts.TriageCard
+		transient []bool
+	}{
+		{
+			name:      "no retries",
+			cards:     []contracts.TriageCard{{NormalizedMsg: "error-1", ConfidenceScore: 0.9}},
+			transient: []bool{false},
+		},
+		{
+			name: "gone after retry",
+			cards: []contracts.TriageCard{
+				retryCard("timeout", "1", "2"),
+				retryCard("assertion failed", "2", "2"),
+			},
+			transient: []bool{true, false},
+		},
+		{
+			name: "persists across attempts",
+			cards: []contracts.TriageCard{
+				retryCard("assertion failed", "1", "2"),
+				retryCard("assertion failed", "2", "2"),
+			},
+			transient: []bool{false, false},
+		},
+		{
+			name: "final attempt findings never transient",
+			cards: []contracts.TriageCard{
+				retryCard("assertion failed", "3", "3"),
+			},
+			transient: []bool{false},
+		},
+		{
+			name: "invalid attempt metadata ignored",
+			cards: []contracts.TriageCard{
+				retryCard("timeout", "x", "2"),
+				retryCard("assertion failed", "2", "2"),
+			},
+			transient: []bool{false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MarkTransients(tt.cards)
+			if len(got) != len(tt.cards) {
+				t.Fatalf("MarkTransients() returned %d cards, want %d", len(got), len(tt.cards))
+			}
+			for i, card := range got {
+				if IsTransient(card) != tt.transient[i] {
+					t.Errorf("card %d (%s): IsTransient = %v, want %v", i, card.NormalizedMsg, IsTransient(card), tt.transient[i])
+				}
+				wantConfidence := tt.cards[i].ConfidenceScore
+				if tt.transient[i] {
+					wantConfidence *= TransientPenalty
+				}
+				if card.ConfidenceScore != wantConfidence {
+					t.Errorf("card %d: ConfidenceScore = %v, want %v", i, card.ConfidenceScore, wantConfidence)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkTransients_DoesNotMutateInput(t *testing.T) {
+	cards := []contracts.TriageCard{
+		retryCard("timeout", "1", "2"),
+		retryCard("assertion failed", "2", "2"),
+	}
+
+	MarkTransients(cards)
+
+	if IsTransient(cards[0]) {
+		t.Error("input card metadata was modified")
+	}
+	if cards[0].ConfidenceScore != 0.9 {
+		t.Errorf("input ConfidenceScore = %v, want 0.9", cards[0].ConfidenceScore)
+	}
+}
+
+func TestMarkTransients_Idempotent(t *testing.T) {
+	cards := []contracts.TriageCard{
+		retryCard("timeout", "1", "2"),
+		retryCard("assertion failed", "2", "2"),
+	}
+
+	twice := MarkTransients(MarkTransients(cards))
+
+	if want := 0.9 * TransientPenalty; twice[0].ConfidenceScore != want {
+		t.Errorf("ConfidenceScore after two passes = %v, want %v", twice[0].ConfidenceScore, want)
+	}
+}
+
+func TestClassifyTier_Transient(t *testing.T) {
+	cards := MarkTransients([]contracts.TriageCard{
+		retryCard("timeout", "1", "2"),
+		retryCard("assertion failed", "2", "2"),
+	})
+
+	if tier := ClassifyTier(cards[0], BuildJobStateMap(cards)); tier != TierNoise {
+		t.Errorf("ClassifyTier(transient) = %v, want %v", tier, TierNoise)
+	}
+}
@@ -0,0 +1,237 @@
+package ranking
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"destill-agent/src/contracts"
+)
+
+// Weights weigh the factors of the composite score. A zero weight leaves
+// its factor out.
+type Weights struct {
+	Confidence    float64
+	Recurrence    float64
+	Tier          float64
+	Novelty       float64
+	JobFailed     float64
+	RootCause     float64
+	ExitProximity float64
+}
+
+// DefaultWeights keep confidence dominant; the other factors reorder
+// findings of similar confidence. Root cause weighs the most of them, so
+// the failure a downstream job echoes ranks above the echo; then the
+// errors right before a job exited.
+var DefaultWeights = Weights{Confidence: 1, Recurrence: 0.1, Tier: 0.2, Novelty: 0.1, JobFailed: 0.1, RootCause: 0.3, ExitProximity: 0.2}
+
+// weightNames are the names of the weights in DESTILL_SCORE_WEIGHTS and the
+// config file's score_weights.
+var weightNames = []string{"confidence", "recurrence", "tier", "novelty", "job_failed", "root_cause", "exit_proximity"}
+
+// Factors are what a finding is scored on, each between 0 and 1.
+type Factors struct {
+	Confidence    float64 // The analyzer's confidence
+	Recurrence    float64 // 1 - 1/n for a message seen n times
+	Tier          float64 // 1 for TierUnique, 0 for TierNoise
+	Novelty       float64 // 1 if new in a re-run (see MarkRerun), 0 if carried over, 0.5 without a previous run
+	JobFailed     float64 // 1 if the finding's job failed, 0 if it passed, 0.5 if unknown
+	RootCause     float64 // 1 if its job is a root failure, 0 if downstream of one, 0.5 otherwise (see contracts.MetadataCascade)
+	ExitProximity float64 // 1 for the exit anchor, down to 0 at contracts.ExitWindowLines before the exit line (see contracts.MetadataExitDistance)
+}
+
+// Scorer turns a finding's factors into its score. Higher scores rank first.
+type Scorer func(Factors) float64
+
+// Score is the weighted sum of the factors.
+func (w Weights) Score(f Factors) float64 {
+	return w.Confidence*f.Confidence +
+		w.Recurrence*f.Recurrence +
+		w.Tier*f.Tier +
+		w.Novelty*f.Novelty +
+		w.JobFailed*f.JobFailed +
+		w.RootCause*f.RootCause +
+		w.ExitProximity*f.ExitProximity
+}
+
+// ParseWeights parses comma-separated name=weight pairs, e.g.
+// "confidence=1,tier=0.5". Names are confidence, recurrence, tier, novelty,
+// job_failed, root_cause, and exit_proximity; weights left out keep their
+// DefaultWeights value.
+func ParseWeights(spec string) (Weights, error) {
+	w := DefaultWeights
+	fields := map[string]*float64{
+		"confidence":     &w.Confidence,
+		"recurrence":     &w.Recurrence,
+		"tier":           &w.Tier,
+		"novelty":        &w.Novelty,
+		"job_failed":     &w.JobFailed,
+		"root_cause":     &w.RootCause,
+		"exit_proximity": &w.ExitProximity,
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return Weights{}, fmt.Errorf("invalid score weight %q (expected name=weight)", pair)
+		}
+		field, known := fields[strings.TrimSpace(name)]
+		if !known {
+			return Weights{}, fmt.Errorf("unknown score weight %q (expected %s)", name, strings.Join(weightNames, ", "))
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 {
+			return Weights{}, fmt.Errorf("invalid score weight %s=%s (expected a number >= 0)", name, value)
+		}
+		*field = weight
+	}
+	return w, nil
+}
+
+// WeightsFromEnv parses DESTILL_SCORE_WEIGHTS (see ParseWeights).
+func WeightsFromEnv() (Weights, error) {
+	return ParseWeights(os.Getenv("DESTILL_SCORE_WEIGHTS"))
+}
+
+var (
+	scorerMu sync.RWMutex
+	scorer   Scorer
+)
+
+// SetScorer replaces the scorer SortCards and RankCards use. nil restores
+// the default: DefaultWeights, or DESTILL_SCORE_WEIGHTS when it's valid.
+func SetScorer(s Scorer) {
+	scorerMu.Lock()
+	defer scorerMu.Unlock()
+	scorer = s
+}
+
+// currentScorer returns the scorer set with SetScorer, or the default.
+func currentScorer() Scorer {
+	scorerMu.RLock()
+	s := scorer
+	scorerMu.RUnlock()
+	if s != nil {
+		return s
+	}
+	return ConfiguredWeights().Score
+}
+
+// ConfiguredWeights returns DESTILL_SCORE_WEIGHTS, or DefaultWeights when
+// it's unset or invalid.
+func ConfiguredWeights() Weights {
+	w, err := WeightsFromEnv()
+	if err != nil {
+		return DefaultWeights
+	}
+	return w
+}
+
+// FactorsOf computes a card's factors. jobStates is BuildJobStateMap of the
+// cards being ranked together, which the tier depends on.
+func FactorsOf(card contracts.TriageCard, jobStates map[string]string) Factors {
+	f := Factors{
+		Confidence: card.ConfidenceScore,
+		Recurrence: 1 - 1/float64(max(card.GetRecurrenceCount(), 1)),
+		Novelty:    0.5,
+		JobFailed:  0.5,
+		RootCause:  0.5,
+	}
+	if ClassifyTier(card, jobStates) == TierUnique {
+		f.Tier = 1
+	}
+	switch card.Metadata[contracts.MetadataRerunStatus] {
+	case contracts.RerunNew:
+		f.Novelty = 1
+	case contracts.RerunCarriedOver:
+		f.Novelty = 0
+	}
+	switch card.Metadata["job_state"] {
+	case "failed":
+		f.JobFailed = 1
+	case "passed":
+		f.JobFailed = 0
+	}
+	switch card.Metadata[contracts.MetadataCascade] {
+	case contracts.CascadeRoot:
+		f.RootCause = 1
+	case contracts.CascadeDownstream:
+		f.RootCause = 0
+	}
+	f.ExitProximity = exitProximity(card)
+	return f
+}
+
+// exitProximity is the ExitProximity factor of a card: 1 for its job's exit anchor,
+// and for the other findings before the exit line at most half, falling
+// with their distance from it.
+func exitProximity(card contracts.TriageCard) float64 {
+	if card.Metadata[contracts.MetadataExitAnchor] == "true" {
+		return 1
+	}
+	distance, err := strconv.Atoi(card.Metadata[contracts.MetadataExitDistance])
+	if err != nil || distance <= 0 || distance > contracts.ExitWindowLines {
+		return 0
+	}
+	return 0.5 * (1 - float64(distance)/contracts.ExitWindowLines)
+}
+
+// SortCards sorts cards by composite score, highest first. Equal scores
+// fall back to recurrence, then keep their order.
+func SortCards(cards []contracts.TriageCard) {
+	score := currentScorer()
+	jobStates := BuildJobStateMap(cards)
+	keyed := make([]struct {
+		card  contracts.TriageCard
+		score float64
+	}, len(cards))
+	for i := range cards {
+		keyed[i].card = cards[i]
+		keyed[i].score = score(FactorsOf(cards[i], jobStates))
+	}
+	sort.SliceStable(keyed, func(i, j int) bool {
+		if keyed[i].score != keyed[j].score {
+			return keyed[i].score > keyed[j].score
+		}
+		return keyed[i].card.GetRecurrenceCount() > keyed[j].card.GetRecurrenceCount()
+	})
+	for i := range keyed {
+		cards[i] = keyed[i].card
+	}
+}
+
+// ScoreSQL returns a SQL expression of the score of a row of the findings
+// table, for ORDER BY. Tier and novelty depend on the other findings, so
+// they are left out; a constant would not change the order.
+func (w Weights) ScoreSQL() string {
+	return fmt.Sprintf(
+		"(%g * confidence_score"+
+			" + %g * (CASE WHEN metadata->>'recurrence_count' ~ '^[1-9][0-9]*$' THEN 1 - 1.0 / (metadata->>'recurrence_count')::int ELSE 0 END)"+
+			" + %g * (CASE metadata->>'job_state' WHEN 'failed' THEN 1 WHEN 'passed' THEN 0 ELSE 0.5 END)"+
+			" + %g * (CASE metadata->>'cascade' WHEN 'root' THEN 1 WHEN 'downstream' THEN 0 ELSE 0.5 END)"+
+			" + %g * (CASE WHEN metadata->>'exit_anchor' = 'true' THEN 1"+
+			" WHEN metadata->>'exit_distance' ~ '^[1-9][0-9]*$'"+
+			" THEN GREATEST(0, 0.5 * (1 - (metadata->>'exit_distance')::int / %d.0)) ELSE 0 END))",
+		w.Confidence, w.Recurrence, w.JobFailed, w.RootCause, w.ExitProximity, contracts.ExitWindowLines)
+}
+
+// ScoreSQLite is ScoreSQL for SQLite, where metadata is a JSON text column.
+func (w Weights) ScoreSQLite() string {
+	return fmt.Sprintf(
+		"(%g * confidence_score"+
+			" + %g * (CASE WHEN CAST(json_extract(metadata, '$.recurrence_count') AS INTEGER) > 0"+
+			" THEN 1 - 1.0 / CAST(json_extract(metadata, '$.recurrence_count') AS INTEGER) ELSE 0 END)"+
+			" + %g * (CASE json_extract(metadata, '$.job_state') WHEN 'failed' THEN 1 WHEN 'passed' THEN 0 ELSE 0.5 END)"+
+			" + %g * (CASE json_extract(metadata, '$.cascade') WHEN 'root' THEN 1 WHEN 'downstream' THEN 0 ELSE 0.5 END)"+
+			" + %g * (CASE WHEN json_extract(metadata, '$.exit_anchor') = 'true' THEN 1"+
+			" WHEN CAST(json_extract(metadata, '$.exit_distance') AS INTEGER) > 0"+
+			" THEN MAX(0, 0.5 * (1 - CAST(json_extract(metadata, '$.exit_distance') AS INTEGER) / %d.0)) ELSE 0 END))",
+		w.Confidence, w.Recurrence, w.JobFailed, w.RootCause, w.ExitProximity, contracts.ExitWindowLines)
+}
@@ -0,0 +1,153 @@
+package ranking
+
+import (
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestParseWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Weights
+		wantErr string
+	}{
+		{name: "empty", spec: "", want: DefaultWeights},
+		{
+			name: "overrides",
+			spec: "confidence=2, tier=0 ,job_failed=0.5",
+			want: Weights{Confidence: 2, Recurrence: 0.1, Tier: 0, Novelty: 0.1, JobFailed: 0.5, RootCause: 0.3, ExitProximity: 0.2},
+		},
+		{name: "unknown name", spec: "speed=1", wantErr: "unknown score weight"},
+		{name: "missing value", spec: "tier", wantErr: "expected name=weight"},
+		{name: "not a number", spec: "tier=high", wantErr: "invalid score weight"},
+		{name: "negative", spec: "tier=-1", wantErr: "invalid score weight"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWeights(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseWeights(%q) error = %v, want containing %q", tt.spec, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWeights(%q) error = %v", tt.spec, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseWeights(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFactorsOf(t *testing.T) {
+	card := contracts.TriageCard{
+		NormalizedMsg:   "error",
+		ConfidenceScore: 0.9,
+		Metadata: map[string]string{
+			"job_state":                   "failed",
+			"recurrence_count":            "4",
+			contracts.MetadataRerunStatus: contracts.RerunNew,
+			contracts.MetadataCascade:     contracts.CascadeDownstream,
+		},
+	}
+	got := FactorsOf(card, BuildJobStateMap([]contracts.TriageCard{card}))
+	want := Factors{Confidence: 0.9, Recurrence: 0.75, Tier: 1, Novelty: 1, JobFailed: 1, RootCause: 0}
+	if got != want {
+		t.Errorf("FactorsOf() = %+v, want %+v", got, want)
+	}
+
+	got = FactorsOf(contracts.TriageCard{ConfidenceScore: 0.5}, nil)
+	want = Factors{Confidence: 0.5, Tier: 1, Novelty: 0.5, JobFailed: 0.5, RootCause: 0.5}
+	if got != want {
+		t.Errorf("FactorsOf(bare card) = %+v, want %+v", got, want)
+	}
+}
+
+func TestSortCards(t *testing.T) {
+	t.Setenv("DESTILL_SCORE_WEIGHTS", "")
+	cards := []contracts.TriageCard{
+		{ID: "low", ConfidenceScore: 0.4, Metadata: map[string]string{"job_state": "failed"}, NormalizedMsg: "a"},
+		{ID: "passed", ConfidenceScore: 0.8, Metadata: map[string]string{"job_state": "passed"}, NormalizedMsg: "b"},
+		{ID: "failed", ConfidenceScore: 0.8, Metadata: map[string]string{"job_state": "failed"}, NormalizedMsg: "c"},
+		{ID: "high", ConfidenceScore: 0.95, NormalizedMsg: "d"},
+	}
+
+	SortCards(cards)
+	assertOrder(t, cards, "high", "failed", "passed", "low")
+
+	SetScorer(func(f Factors) float64 { return -f.Confidence })
+	defer SetScorer(nil)
+	SortCards(cards)
+	assertOrder(t, cards, "low", "failed", "passed", "high")
+}
+
+func TestSortCards_RootCauseAboveDownstream(t *testing.T) {
+	t.Setenv("DESTILL_SCORE_WEIGHTS", "")
+	failed := func(cascade string) map[string]string {
+		return map[string]string{"job_state": "failed", contracts.MetadataCascade: cascade}
+	}
+	cards := []contracts.TriageCard{
+		{ID: "echo", ConfidenceScore: 0.9, Metadata: failed(contracts.CascadeDownstream), NormalizedMsg: "a"},
+		{ID: "unknown", ConfidenceScore: 0.8, Metadata: map[string]string{"job_state": "failed"}, NormalizedMsg: "b"},
+		{ID: "root", ConfidenceScore: 0.75, Metadata: failed(contracts.CascadeRoot), NormalizedMsg: "c"},
+	}
+
+	SortCards(cards)
+	assertOrder(t, cards, "root", "unknown", "echo")
+}
+
+func TestSortCards_ExitAnchorFirst(t *testing.T) {
+	t.Setenv("DESTILL_SCORE_WEIGHTS", "")
+	exit := func(distance, anchor string) map[string]string {
+		return map[string]string{"job_state": "failed", contracts.MetadataExitDistance: distance, contracts.MetadataExitAnchor: anchor}
+	}
+	cards := []contracts.TriageCard{
+		{ID: "early", ConfidenceScore: 0.8, Metadata: map[string]string{"job_state": "failed"}, NormalizedMsg: "a"},
+		{ID: "near", ConfidenceScore: 0.75, Metadata: exit("10", ""), NormalizedMsg: "b"},
+		{ID: "anchor", ConfidenceScore: 0.75, Metadata: exit("3", "true"), NormalizedMsg: "c"},
+	}
+
+	SortCards(cards)
+	assertOrder(t, cards, "anchor", "near", "early")
+
+	if got := exitProximity(contracts.TriageCard{Metadata: exit("60", "")}); got != 0 {
+		t.Errorf("exitProximity(past the window) = %v, want 0", got)
+	}
+}
+
+func TestSortCardsWeightsFromEnv(t *testing.T) {
+	t.Setenv("DESTILL_SCORE_WEIGHTS", "confidence=0,job_failed=1")
+	cards := []contracts.TriageCard{
+		{ID: "passed", ConfidenceScore: 0.9, Metadata: map[string]string{"job_state": "passed"}, NormalizedMsg: "a"},
+		{ID: "failed", ConfidenceScore: 0.5, Metadata: map[string]string{"job_state": "failed"}, NormalizedMsg: "b"},
+	}
+
+	SortCards(cards)
+	assertOrder(t, cards, "failed", "passed")
+}
+
+func TestScoreSQL(t *testing.T) {
+	sql := Weights{Confidence: 2, Recurrence: 0.25, JobFailed: 0.5, RootCause: 0.3, ExitProximity: 0.2}.ScoreSQL()
+	for _, want := range []string{"2 * confidence_score", "0.25 * (CASE WHEN metadata->>'recurrence_count'", "0.5 * (CASE metadata->>'job_state'", "0.3 * (CASE metadata->>'cascade'", "0.2 * (CASE WHEN metadata->>'exit_anchor'"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("ScoreSQL() = %s, want containing %q", sql, want)
+		}
+	}
+}
+
+func assertOrder(t *testing.T, cards []contracts.TriageCard, ids ...string) {
+	t.Helper()
+	got := make([]string, len(cards))
+	for i, card := range cards {
+		got[i] = card.ID
+	}
+	if strings.Join(got, ",") != strings.Join(ids, ",") {
+		t.Errorf("order = %v, want %v", got, ids)
+	}
+}
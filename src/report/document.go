@@ -0,0 +1,138 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/permalink"
+	"destill-agent/src/ranking"
+	"destill-agent/src/sanitize"
+)
+
+// maxHeadline caps finding headlines, which are shown on one line.
+const maxHeadline = 200
+
+// DefaultTitle is the title of reports that don't set one.
+const DefaultTitle = "Destill report"
+
+// Options describe a Markdown or HTML report.
+type Options struct {
+	Title     string    // Defaults to DefaultTitle
+	Source    string    // Build URL or request ID the findings came from
+	Generated time.Time // Omitted when zero
+}
+
+// document is the view of findings shared by the Markdown and HTML reports.
+type document struct {
+	Title     string
+	Source    string
+	Generated string
+	Rerun     string // Comparison with the build's previous analysis, if any
+	Findings  int
+	Jobs      []jobSection
+}
+
+type jobSection struct {
+	Name     string
+	Anchor   string // HTML id of the section
+	BuildURL string
+	Findings []findingView
+}
+
+type findingView struct {
+	Headline    string
+	Message     string
+	Severity    string
+	Hash        string
+	Permalink   string // destill:// link for 'destill view'
+	LogURL      string // Provider link to the log line
+	Confidence  float64
+	Percent     int
+	Occurrences int
+	Transient   bool
+	NewInRerun  bool // Not in the build's previous analysis
+	PreContext  []string
+	PostContext []string
+	Summary     *contracts.Summary
+	Remediation *contracts.Remediation
+}
+
+// HasContext reports whether the finding has log lines around it.
+func (f findingView) HasContext() bool {
+	return len(f.PreContext) > 0 || len(f.PostContext) > 0
+}
+
+// newDocument groups findings by job and cleans them for display.
+func newDocument(cards []contracts.TriageCard, opts Options) document {
+	doc := document{Title: opts.Title, Source: opts.Source}
+	if doc.Title == "" {
+		doc.Title = DefaultTitle
+	}
+	if !opts.Generated.IsZero() {
+		doc.Generated = opts.Generated.UTC().Format("2006-01-02 15:04 MST")
+	}
+	if rerun, ok := ranking.RerunSummaryOf(cards); ok {
+		doc.Rerun = rerun.String()
+	}
+
+	for i, job := range groupByJob(cards) {
+		section := jobSection{
+			Name:     job.Name,
+			Anchor:   fmt.Sprintf("job-%d", i+1),
+			BuildURL: job.Cards[0].Metadata["build_url"],
+		}
+		if section.BuildURL == "" {
+			section.BuildURL = job.Cards[0].BuildURL
+		}
+		for _, card := range job.Cards {
+			section.Findings = append(section.Findings, findingView{
+				Headline:    headline(card),
+				Message:     sanitize.Clean(card.RawMessage),
+				Severity:    card.Severity,
+				Hash:        card.MessageHash,
+				Permalink:   permalinkFor(card),
+				LogURL:      card.Metadata[contracts.MetadataProviderLink],
+				Confidence:  card.ConfidenceScore,
+				Percent:     confidencePercent(card.ConfidenceScore),
+				Occurrences: card.GetRecurrenceCount(),
+				Transient:   ranking.IsTransient(card),
+				NewInRerun:  ranking.IsNewInRerun(card),
+				PreContext:  sanitize.CleanLines(card.PreContext),
+				PostContext: sanitize.CleanLines(card.PostContext),
+				Summary:     card.Summary,
+				Remediation: card.Remediation,
+			})
+		}
+		doc.Findings += len(section.Findings)
+		doc.Jobs = append(doc.Jobs, section)
+	}
+	return doc
+}
+
+// headline returns a finding's one-line title: its normalized message,
+// falling back to the raw message, capped at maxHeadline bytes.
+func headline(card contracts.TriageCard) string {
+	name := sanitize.Clean(card.NormalizedMsg)
+	if name == "" {
+		name = sanitize.Clean(card.RawMessage)
+	}
+	if len(name) > maxHeadline {
+		name = name[:maxHeadline] + "..."
+	}
+	return name
+}
+
+// permalinkFor returns the finding's destill:// link, or "" for cards
+// without a message hash.
+func permalinkFor(card contracts.TriageCard) string {
+	if card.MessageHash == "" {
+		return ""
+	}
+	return permalink.For(card).String()
+}
+
+// confidencePercent converts a confidence score to a 0-100 bar width.
+func confidencePercent(score float64) int {
+	return int(min(max(score, 0), 1)*100 + 0.5)
+}
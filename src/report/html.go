@@ -0,0 +1,80 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"destill-agent/src/contracts"
+)
+
+// htmlTemplate is a standalone page: styles are inline and there are no
+// scripts, so the file can be attached to a PR or mailed as is.
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 64rem; padding: 0 1rem; color: #1f2328; }
+header p, .meta { color: #59636e; font-size: 0.9rem; }
+table { border-collapse: collapse; margin: 1rem 0; }
+th, td { border: 1px solid #d1d9e0; padding: 0.3rem 0.7rem; text-align: left; }
+section { border-top: 1px solid #d1d9e0; margin-top: 2rem; }
+article { margin: 1.2rem 0; }
+h3 { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 0.95rem; word-break: break-word; }
+.bar { display: inline-block; vertical-align: middle; width: 8rem; height: 0.6rem; background: #eaeef2; border-radius: 0.3rem; overflow: hidden; }
+.fill { display: block; height: 100%; background: #cf222e; }
+.fill.medium { background: #bf8700; }
+.fill.low { background: #8c959f; }
+.tag { border: 1px solid #d1d9e0; border-radius: 1rem; padding: 0 0.5rem; }
+blockquote { border-left: 0.25rem solid #d1d9e0; margin: 0.5rem 0; padding: 0 1rem; }
+pre { background: #f6f8fa; padding: 0.8rem; overflow-x: auto; font-size: 0.85rem; }
+mark { background: #fff8c5; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p>{{if .Source}}Source: {{.Source}} · {{end}}{{if .Generated}}Generated {{.Generated}} · {{end}}{{.Findings}} findings in {{len .Jobs}} jobs{{if .Rerun}} · {{.Rerun}}{{end}}</p>
+</header>
+{{if .Jobs}}<table>
+<tr><th>Job</th><th>Findings</th><th>Top confidence</th></tr>
+{{range .Jobs}}{{$top := index .Findings 0}}<tr><td><a href="#{{.Anchor}}">{{.Name}}</a></td><td>{{len .Findings}}</td><td>{{template "bar" $top}}</td></tr>
+{{end}}</table>
+{{else}}<p>No findings.</p>
+{{end}}{{range .Jobs}}<section id="{{.Anchor}}">
+<h2>{{.Name}}</h2>
+{{if .BuildURL}}<p><a href="{{.BuildURL}}">View build</a></p>
+{{end}}{{range .Findings}}<article>
+<h3>{{.Headline}}</h3>
+<p class="meta">{{template "bar" .}}{{if .Severity}} · {{.Severity}}{{end}} · {{.Occurrences}}×{{if .Transient}} · <span class="tag">transient (gone after retry)</span>{{end}}{{if .NewInRerun}} · <span class="tag">new in this run</span>{{end}}{{if .Hash}} · <code>{{.Hash}}</code>{{end}}{{if .LogURL}} · <a href="{{.LogURL}}">log line</a>{{end}}</p>
+{{- if .Permalink}}
+<p class="meta">Open with <code>destill view '{{.Permalink}}'</code></p>
+{{- end}}
+{{with .Summary}}<blockquote><p><strong>Root cause:</strong> {{.RootCause}}</p>{{if .SuggestedFix}}<p><strong>Suggested fix:</strong> {{.SuggestedFix}}</p>{{end}}</blockquote>
+{{end}}{{with .Remediation}}<p><strong>Known fix</strong> ({{.Name}}){{if .Description}}: {{.Description}}{{end}}</p>
+<pre><code>{{.Fix}}</code></pre>
+{{end}}<details><summary>{{if .HasContext}}Log context{{else}}Log{{end}}</summary>
+<pre>{{range .PreContext}}   {{.}}
+{{end}}<mark>&gt;&gt; {{.Message}}</mark>
+{{range .PostContext}}   {{.}}
+{{end}}</pre>
+</details>
+</article>
+{{end}}</section>
+{{end}}</body>
+</html>
+{{define "bar"}}<span class="bar" title="confidence {{printf "%.2f" .Confidence}}"><span class="fill{{if lt .Percent 50}} low{{else if lt .Percent 80}} medium{{end}}" style="width: {{.Percent}}%"></span></span> {{printf "%.2f" .Confidence}}{{end}}`))
+
+// HTML renders findings as a standalone HTML page: a summary table, then one
+// section per job with a confidence bar per finding and its log context in
+// a collapsible block.
+func HTML(cards []contracts.TriageCard, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, newDocument(cards, opts)); err != nil {
+		return nil, fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
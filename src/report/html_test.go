@@ -0,0 +1,48 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	data, err := HTML(reportCards(), Options{Title: "Build <4091>"})
+	if err != nil {
+		t.Fatalf("HTML() error = %v", err)
+	}
+	out := string(data)
+
+	for _, want := range []string{
+		"<title>Build &lt;4091&gt;</title>",
+		`<a href="#job-1">lint</a>`,
+		`<section id="job-2">`,
+		`<a href="https://buildkite.com/org/p/builds/1">View build</a>`,
+		"<h3>FATAL: &lt;db&gt; pool *exhausted*</h3>",
+		`style="width: 95%"`,
+		`class="fill low" style="width: 40%"`,
+		"<strong>Root cause:</strong> Too many connections",
+		"<strong>Known fix</strong> (pool size): The pool is too small.</p>\n<pre><code>export DB_POOL_SIZE=50</code></pre>",
+		"transient (gone after retry)",
+		`<a href="https://buildkite.com/org/p/builds/1#job-1/12">log line</a>`,
+		"<details><summary>Log context</summary>",
+		"   === RUN TestCart\n<mark>&gt;&gt; panic: nil map</mark>\n   goroutine 1 [running]:",
+		"Open with <code>destill view 'destill://finding/c?build=https%3A%2F%2Fbuildkite.com%2Forg%2Fp%2Fbuilds%2F1'</code>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML() missing %q", want)
+		}
+	}
+	if strings.Contains(out, "<db>") {
+		t.Error("HTML() did not escape finding text")
+	}
+}
+
+func TestHTML_NoFindings(t *testing.T) {
+	data, err := HTML(nil, Options{})
+	if err != nil {
+		t.Fatalf("HTML() error = %v", err)
+	}
+	if out := string(data); !strings.Contains(out, "<h1>Destill report</h1>") || !strings.Contains(out, "No findings.") {
+		t.Errorf("HTML(nil) = %s", out)
+	}
+}
@@ -0,0 +1,63 @@
+package report
+
+import (
+	"sort"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/ranking"
+)
+
+// jobFindings holds the unique findings of one job.
+type jobFindings struct {
+	Name  string
+	Cards []contracts.TriageCard
+}
+
+// groupByJob groups findings by job, keeping one card per message hash
+// within a job with its recurrence count. A finding merged across jobs is
+// listed under each of them. Jobs are ordered by name and
+// findings by confidence, highest first. The input cards are not modified.
+func groupByJob(cards []contracts.TriageCard) []jobFindings {
+	type group struct {
+		cards []contracts.TriageCard
+		seen  map[string]int // Message hash -> index in cards
+	}
+	groups := make(map[string]*group)
+	var perJob []contracts.TriageCard
+	for _, card := range cards {
+		perJob = append(perJob, card.PerJob()...)
+	}
+	for _, card := range perJob {
+		g := groups[card.JobName]
+		if g == nil {
+			g = &group{seen: make(map[string]int)}
+			groups[card.JobName] = g
+		}
+		if i, ok := g.seen[card.MessageHash]; ok {
+			g.cards[i].SetRecurrenceCount(g.cards[i].GetRecurrenceCount() + 1)
+			continue
+		}
+		g.seen[card.MessageHash] = len(g.cards)
+		card.Metadata = copyMetadata(card.Metadata)
+		card.SetRecurrenceCount(1)
+		g.cards = append(g.cards, card)
+	}
+
+	jobs := make([]jobFindings, 0, len(groups))
+	for name, g := range groups {
+		ranking.SortCards(g.cards)
+		jobs = append(jobs, jobFindings{Name: name, Cards: g.cards})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
+
+// copyMetadata copies a metadata map so recurrence counts don't leak into
+// the caller's cards.
+func copyMetadata(metadata map[string]string) map[string]string {
+	copied := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		copied[k] = v
+	}
+	return copied
+}
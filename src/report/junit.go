@@ -0,0 +1,112 @@
+// Package report renders findings as documents for people and other tools:
+// JUnit XML for CI dashboards, and Markdown or HTML for sharing.
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/sanitize"
+)
+
+// JUnit test report elements, as understood by Jenkins, GitLab, Buildkite
+// Test Analytics, and most CI dashboards.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string       `xml:"name,attr"`
+	Classname string       `xml:"classname,attr"`
+	Failure   junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit renders findings as a JUnit XML report: one test suite per job and
+// one failed test case per unique finding (by message hash) in the job.
+// Suites are ordered by job name and cases by confidence, highest first.
+func JUnit(cards []contracts.TriageCard) ([]byte, error) {
+	report := junitTestSuites{Name: "destill"}
+	for _, job := range groupByJob(cards) {
+		ts := junitTestSuite{Name: job.Name, Tests: len(job.Cards), Failures: len(job.Cards)}
+		first := job.Cards[0]
+		for _, key := range []string{"build_url", "step_name", "exit_status"} {
+			if value := first.Metadata[key]; value != "" {
+				ts.Properties = append(ts.Properties, junitProperty{Name: key, Value: value})
+			}
+		}
+		for _, card := range job.Cards {
+			ts.Cases = append(ts.Cases, junitCase(card))
+		}
+		report.Suites = append(report.Suites, ts)
+		report.Tests += ts.Tests
+		report.Failures += ts.Failures
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JUnit report: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// junitCase renders one finding. The failure body holds the log excerpt
+// and the finding's score, so dashboards show it without destill.
+func junitCase(card contracts.TriageCard) junitTestCase {
+	message := sanitize.Clean(card.RawMessage)
+	name := headline(card)
+
+	var body strings.Builder
+	for _, line := range sanitize.CleanLines(card.PreContext) {
+		fmt.Fprintf(&body, "   %s\n", line)
+	}
+	fmt.Fprintf(&body, ">> %s\n", message)
+	for _, line := range sanitize.CleanLines(card.PostContext) {
+		fmt.Fprintf(&body, "   %s\n", line)
+	}
+	fmt.Fprintf(&body, "\nConfidence: %.2f | Occurrences: %d | Hash: %s\n",
+		card.ConfidenceScore, card.GetRecurrenceCount(), card.MessageHash)
+	if card.Summary != nil {
+		fmt.Fprintf(&body, "Root cause: %s\n", card.Summary.RootCause)
+		if card.Summary.SuggestedFix != "" {
+			fmt.Fprintf(&body, "Suggested fix: %s\n", card.Summary.SuggestedFix)
+		}
+	}
+	if card.BuildURL != "" {
+		fmt.Fprintf(&body, "Build: %s\n", card.BuildURL)
+	}
+
+	return junitTestCase{
+		Name:      name,
+		Classname: "destill." + card.JobName,
+		Failure: junitFailure{
+			Message: message,
+			Type:    card.Severity,
+			Text:    body.String(),
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestJUnit(t *testing.T) {
+	cards := []contracts.TriageCard{
+		{JobName: "unit", MessageHash: "a", Severity: "ERROR", RawMessage: "panic: nil map", NormalizedMsg: "panic: nil map", ConfidenceScore: 0.7, PreContext: []string{"=== RUN TestCart"}},
+		{JobName: "unit", MessageHash: "b", Severity: "FATAL", RawMessage: "FATAL: <db> & \"pool\" exhausted", NormalizedMsg: "FATAL: db pool exhausted", ConfidenceScore: 0.9},
+		{JobName: "unit", MessageHash: "a", Severity: "ERROR", RawMessage: "panic: nil map", NormalizedMsg: "panic: nil map", ConfidenceScore: 0.7},
+		{JobName: "lint", MessageHash: "c", Severity: "ERROR", RawMessage: "unused variable", NormalizedMsg: "unused variable", ConfidenceScore: 0.6,
+			Metadata: map[string]string{"build_url": "https://buildkite.com/org/p/builds/1"}},
+	}
+
+	data, err := JUnit(cards)
+	if err != nil {
+		t.Fatalf("JUnit() error = %v", err)
+	}
+	if !strings.HasPrefix(string(data), "<?xml") {
+		t.Error("JUnit() output has no XML header")
+	}
+
+	var got junitTestSuites
+	if err := xml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, data)
+	}
+	if got.Tests != 3 || got.Failures != 3 || len(got.Suites) != 2 {
+		t.Fatalf("testsuites = %d tests, %d failures, %d suites; want 3, 3, 2", got.Tests, got.Failures, len(got.Suites))
+	}
+
+	lint, unit := got.Suites[0], got.Suites[1]
+	if lint.Name != "lint" || unit.Name != "unit" {
+		t.Errorf("suites = %s, %s; want lint, unit", lint.Name, unit.Name)
+	}
+	if len(lint.Properties) != 1 || lint.Properties[0].Value != "https://buildkite.com/org/p/builds/1" {
+		t.Errorf("lint properties = %+v", lint.Properties)
+	}
+	if len(unit.Cases) != 2 || unit.Cases[0].Name != "FATAL: db pool exhausted" {
+		t.Fatalf("unit cases = %+v, want 2 with the most confident first", unit.Cases)
+	}
+	if msg := unit.Cases[0].Failure.Message; msg != "FATAL: <db> & \"pool\" exhausted" {
+		t.Errorf("failure message = %q, want the raw message round-tripped", msg)
+	}
+	panicCase := unit.Cases[1].Failure
+	if !strings.Contains(panicCase.Text, "Occurrences: 2") || !strings.Contains(panicCase.Text, "=== RUN TestCart") {
+		t.Errorf("failure text = %q, want context and the occurrence count", panicCase.Text)
+	}
+	if unit.Cases[1].Classname != "destill.unit" || panicCase.Type != "ERROR" {
+		t.Errorf("case = %+v", unit.Cases[1])
+	}
+
+	if cards[0].Metadata != nil {
+		t.Error("JUnit() modified the input cards")
+	}
+}
+
+func TestJUnit_Empty(t *testing.T) {
+	data, err := JUnit(nil)
+	if err != nil {
+		t.Fatalf("JUnit() error = %v", err)
+	}
+	if !strings.Contains(string(data), `<testsuites name="destill" tests="0" failures="0">`) {
+		t.Errorf("JUnit(nil) = %s", data)
+	}
+}
@@ -0,0 +1,144 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"destill-agent/src/contracts"
+)
+
+// barCells is the width of Markdown confidence bars.
+const barCells = 10
+
+// markdownEscaper escapes characters that would turn finding text into
+// Markdown or HTML markup.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"#", `\#`, "|", `\|`, "<", "&lt;", ">", "&gt;",
+)
+
+// Markdown renders findings as a Markdown report: a summary table, then one
+// section per job with a confidence bar per finding and its log context in
+// a collapsible block. GitHub, GitLab, and Buildkite annotations render the
+// collapsible blocks.
+func Markdown(cards []contracts.TriageCard, opts Options) []byte {
+	doc := newDocument(cards, opts)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", markdownEscaper.Replace(doc.Title))
+	var about []string
+	if doc.Source != "" {
+		about = append(about, "Source: "+markdownEscaper.Replace(doc.Source))
+	}
+	if doc.Generated != "" {
+		about = append(about, "Generated "+doc.Generated)
+	}
+	about = append(about, fmt.Sprintf("%d findings in %d jobs", doc.Findings, len(doc.Jobs)))
+	if doc.Rerun != "" {
+		about = append(about, markdownEscaper.Replace(doc.Rerun))
+	}
+	fmt.Fprintf(&b, "%s\n", strings.Join(about, " · "))
+
+	if len(doc.Jobs) == 0 {
+		b.WriteString("\nNo findings.\n")
+		return []byte(b.String())
+	}
+
+	b.WriteString("\n| Job | Findings | Top confidence |\n|---|---:|---|\n")
+	for _, job := range doc.Jobs {
+		top := job.Findings[0]
+		fmt.Fprintf(&b, "| %s | %d | %s |\n", markdownEscaper.Replace(job.Name), len(job.Findings), markdownBar(top.Percent, top.Confidence))
+	}
+
+	for _, job := range doc.Jobs {
+		fmt.Fprintf(&b, "\n## %s\n", markdownEscaper.Replace(job.Name))
+		if job.BuildURL != "" {
+			fmt.Fprintf(&b, "\n[View build](%s)\n", job.BuildURL)
+		}
+		for _, f := range job.Findings {
+			writeMarkdownFinding(&b, f)
+		}
+	}
+	return []byte(b.String())
+}
+
+// writeMarkdownFinding writes one finding: headline, score line, summary,
+// known fix, and collapsible context.
+func writeMarkdownFinding(b *strings.Builder, f findingView) {
+	fmt.Fprintf(b, "\n### %s\n\n", markdownEscaper.Replace(f.Headline))
+
+	details := []string{markdownBar(f.Percent, f.Confidence)}
+	if f.Severity != "" {
+		details = append(details, f.Severity)
+	}
+	details = append(details, fmt.Sprintf("%d×", f.Occurrences))
+	if f.Transient {
+		details = append(details, "transient (gone after retry)")
+	}
+	if f.NewInRerun {
+		details = append(details, "new in this run")
+	}
+	if f.Hash != "" {
+		details = append(details, "`"+f.Hash+"`")
+	}
+	if f.LogURL != "" {
+		details = append(details, fmt.Sprintf("[log line](%s)", f.LogURL))
+	}
+	fmt.Fprintf(b, "%s\n", strings.Join(details, " · "))
+	if f.Permalink != "" {
+		fmt.Fprintf(b, "\nOpen with `destill view '%s'`\n", f.Permalink)
+	}
+
+	if f.Summary != nil {
+		fmt.Fprintf(b, "\n> **Root cause:** %s\n", markdownEscaper.Replace(f.Summary.RootCause))
+		if f.Summary.SuggestedFix != "" {
+			fmt.Fprintf(b, ">\n> **Suggested fix:** %s\n", markdownEscaper.Replace(f.Summary.SuggestedFix))
+		}
+	}
+
+	if r := f.Remediation; r != nil {
+		fmt.Fprintf(b, "\n**Known fix** (%s)", markdownEscaper.Replace(r.Name))
+		if r.Description != "" {
+			fmt.Fprintf(b, ": %s", markdownEscaper.Replace(r.Description))
+		}
+		fence := codeFence(r.Fix)
+		fmt.Fprintf(b, "\n\n%ssh\n%s\n%s\n", fence, r.Fix, fence)
+	}
+
+	lines := make([]string, 0, len(f.PreContext)+len(f.PostContext)+1)
+	for _, line := range f.PreContext {
+		lines = append(lines, "   "+line)
+	}
+	lines = append(lines, ">> "+f.Message)
+	for _, line := range f.PostContext {
+		lines = append(lines, "   "+line)
+	}
+	text := strings.Join(lines, "\n")
+	fence := codeFence(text)
+	summary := "Log"
+	if f.HasContext() {
+		summary = "Log context"
+	}
+	fmt.Fprintf(b, "\n<details><summary>%s</summary>\n\n%stext\n%s\n%s\n\n</details>\n", summary, fence, text, fence)
+}
+
+// markdownBar draws a confidence bar such as `████████░░ 0.80`.
+func markdownBar(percent int, confidence float64) string {
+	filled := (percent*barCells + 50) / 100
+	return fmt.Sprintf("`%s%s %.2f`", strings.Repeat("█", filled), strings.Repeat("░", barCells-filled), confidence)
+}
+
+// codeFence returns a backtick fence longer than any backtick run in text,
+// so log lines can't close the code block.
+func codeFence(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
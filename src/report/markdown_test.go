@@ -0,0 +1,87 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+func reportCards() []contracts.TriageCard {
+	return []contracts.TriageCard{
+		{JobName: "unit", MessageHash: "a", Severity: "ERROR", RawMessage: "panic: nil map", NormalizedMsg: "panic: nil map", ConfidenceScore: 0.7,
+			PreContext: []string{"=== RUN TestCart"}, PostContext: []string{"goroutine 1 [running]:"}},
+		{JobName: "unit", MessageHash: "b", Severity: "FATAL", RawMessage: "FATAL: <db> pool *exhausted*", NormalizedMsg: "FATAL: <db> pool *exhausted*", ConfidenceScore: 0.95,
+			Summary:     &contracts.Summary{RootCause: "Too many connections", SuggestedFix: "Raise max_connections"},
+			Remediation: &contracts.Remediation{Name: "pool size", Fix: "export DB_POOL_SIZE=50", Description: "The pool is too small."}},
+		{JobName: "unit", MessageHash: "a", Severity: "ERROR", RawMessage: "panic: nil map", NormalizedMsg: "panic: nil map", ConfidenceScore: 0.7},
+		{JobName: "lint", MessageHash: "c", Severity: "ERROR", RawMessage: "found ``` in output", NormalizedMsg: "found ``` in output", ConfidenceScore: 0.4,
+			Metadata: map[string]string{"build_url": "https://buildkite.com/org/p/builds/1", contracts.MetadataRetryOutcome: contracts.RetryTransient,
+				contracts.MetadataProviderLink: "https://buildkite.com/org/p/builds/1#job-1/12"}},
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	cards := reportCards()
+	out := string(Markdown(cards, Options{
+		Source:    "https://buildkite.com/org/p/builds/1",
+		Generated: time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC),
+	}))
+
+	for _, want := range []string{
+		"# Destill report\n",
+		"Generated 2024-01-15 14:30 UTC · 3 findings in 2 jobs",
+		"| lint | 1 | `████░░░░░░ 0.40` |",
+		"| unit | 2 | `██████████ 0.95` |",
+		"## lint\n\n[View build](https://buildkite.com/org/p/builds/1)",
+		"### FATAL: &lt;db&gt; pool \\*exhausted\\*",
+		"> **Root cause:** Too many connections",
+		"> **Suggested fix:** Raise max\\_connections",
+		"**Known fix** (pool size): The pool is too small.\n\n```sh\nexport DB_POOL_SIZE=50\n```",
+		"2×",
+		"transient (gone after retry)",
+		"[log line](https://buildkite.com/org/p/builds/1#job-1/12)",
+		"<details><summary>Log context</summary>",
+		"   === RUN TestCart\n>> panic: nil map\n   goroutine 1 [running]:",
+		"````text\n>> found ``` in output\n````",
+		"Open with `destill view 'destill://finding/c?build=https%3A%2F%2Fbuildkite.com%2Forg%2Fp%2Fbuilds%2F1'`",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Markdown() missing %q\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "## lint") > strings.Index(out, "## unit") {
+		t.Error("jobs should be ordered by name")
+	}
+	if strings.Index(out, "pool") > strings.Index(out, "panic: nil map") {
+		t.Error("findings should be ordered by confidence")
+	}
+	if cards[0].Metadata != nil {
+		t.Error("Markdown() modified the input cards")
+	}
+}
+
+func TestMarkdown_NoFindings(t *testing.T) {
+	out := string(Markdown(nil, Options{Title: "Build #4091"}))
+	if !strings.HasPrefix(out, "# Build \\#4091\n") || !strings.Contains(out, "No findings.") {
+		t.Errorf("Markdown(nil) = %q", out)
+	}
+}
+
+func TestMarkdownBar(t *testing.T) {
+	tests := []struct {
+		confidence float64
+		want       string
+	}{
+		{0, "`░░░░░░░░░░ 0.00`"},
+		{0.84, "`████████░░ 0.84`"},
+		{1, "`██████████ 1.00`"},
+		{1.3, "`██████████ 1.30`"},
+	}
+	for _, tt := range tests {
+		if got := markdownBar(confidencePercent(tt.confidence), tt.confidence); got != tt.want {
+			t.Errorf("markdownBar(%v) = %s, want %s", tt.confidence, got, tt.want)
+		}
+	}
+}
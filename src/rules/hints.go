@@ -0,0 +1,185 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Priorities assigned to a build's findings.
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// Notification behaviors for a build's findings.
+const (
+	NotifyImmediate = "immediate" // Alert as soon as findings arrive
+	NotifyDigest    = "digest"    // Batch into a periodic summary
+	NotifyNone      = "none"      // Record only
+)
+
+// PipelineRule assigns a priority, notification behavior, and triage
+// deadline to builds matching all of its non-empty conditions. The first
+// matching rule wins.
+//
+//	pipelines:
+//	  - name: nightly
+//	    trigger: schedule
+//	    priority: low
+//	    notify: digest
+//	  - name: release branches
+//	    pipeline: 'acme/*'
+//	    branch: 'release/*'
+//	    priority: high
+//	    deadline: 30m
+type PipelineRule struct {
+	Name     string `yaml:"name" json:"name"`
+	Pipeline string `yaml:"pipeline,omitempty" json:"pipeline,omitempty"` // Glob, e.g. "acme/*"
+	Branch   string `yaml:"branch,omitempty" json:"branch,omitempty"`     // Glob, e.g. "release/*"
+	Trigger  string `yaml:"trigger,omitempty" json:"trigger,omitempty"`   // schedule, pull_request, push, manual, api, upstream
+	Priority string `yaml:"priority,omitempty" json:"priority,omitempty"`
+	Notify   string `yaml:"notify,omitempty" json:"notify,omitempty"`
+	Deadline string `yaml:"deadline,omitempty" json:"deadline,omitempty"` // Go duration, e.g. "30m"
+}
+
+// BuildInfo is the build metadata pipeline rules match on.
+type BuildInfo struct {
+	Pipeline string
+	Branch   string
+	Trigger  string
+}
+
+// Hints are the priority and notification behavior assigned to a build.
+type Hints struct {
+	Priority string
+	Notify   string
+	Deadline time.Duration // Zero means no deadline
+	Rule     string        // Name of the matching rule, or "default"
+}
+
+var (
+	validPriorities = []string{PriorityHigh, PriorityNormal, PriorityLow}
+	validNotify     = []string{NotifyImmediate, NotifyDigest, NotifyNone}
+	validTriggers   = []string{"schedule", "pull_request", "push", "manual", "api", "upstream"}
+)
+
+type compiledPipelineRule struct {
+	PipelineRule
+	pipeline *regexp.Regexp
+	branch   *regexp.Regexp
+	deadline time.Duration
+}
+
+// DefaultHints returns the hints used when no pipeline rule matches:
+// scheduled builds (nightlies) are low priority and go to the digest,
+// pull request builds block someone and are high priority.
+func DefaultHints(b BuildInfo) Hints {
+	switch b.Trigger {
+	case "schedule":
+		return Hints{Priority: PriorityLow, Notify: NotifyDigest, Rule: "default"}
+	case "pull_request":
+		return Hints{Priority: PriorityHigh, Notify: NotifyImmediate, Rule: "default"}
+	default:
+		return Hints{Priority: PriorityNormal, Notify: NotifyImmediate, Rule: "default"}
+	}
+}
+
+// Hints returns the hints for a build from the first matching pipeline rule.
+// Fields the rule leaves empty fall back to DefaultHints. A nil RuleSet
+// returns DefaultHints.
+func (rs *RuleSet) Hints(b BuildInfo) Hints {
+	hints := DefaultHints(b)
+	if rs == nil {
+		return hints
+	}
+
+	for _, r := range rs.pipelines {
+		if !r.matches(b) {
+			continue
+		}
+		hints.Rule = r.Name
+		if r.Priority != "" {
+			hints.Priority = r.Priority
+		}
+		if r.Notify != "" {
+			hints.Notify = r.Notify
+		}
+		hints.Deadline = r.deadline
+		break
+	}
+	return hints
+}
+
+func (r *compiledPipelineRule) matches(b BuildInfo) bool {
+	if r.pipeline != nil && !r.pipeline.MatchString(b.Pipeline) {
+		return false
+	}
+	if r.branch != nil && !r.branch.MatchString(b.Branch) {
+		return false
+	}
+	return r.Trigger == "" || r.Trigger == b.Trigger
+}
+
+// compilePipelineRule compiles a rule that has already passed Lint.
+func compilePipelineRule(r PipelineRule) compiledPipelineRule {
+	c := compiledPipelineRule{PipelineRule: r}
+	if r.Pipeline != "" {
+		c.pipeline = globRegexp(r.Pipeline)
+	}
+	if r.Branch != "" {
+		c.branch = globRegexp(r.Branch)
+	}
+	if r.Deadline != "" {
+		c.deadline, _ = time.ParseDuration(r.Deadline)
+	}
+	return c
+}
+
+// globRegexp converts a glob where "*" matches any run of characters
+// (including "/") into an anchored regexp.
+func globRegexp(glob string) *regexp.Regexp {
+	parts := strings.Split(glob, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// lintPipelineRule reports problems with a single pipeline rule.
+func lintPipelineRule(r PipelineRule, report func(level, format string, args ...any)) {
+	if r.Pipeline == "" && r.Branch == "" && r.Trigger == "" {
+		report(LevelWarning, "no pipeline, branch, or trigger condition, rule matches every build")
+	}
+	if r.Trigger != "" && !slices.Contains(validTriggers, r.Trigger) {
+		report(LevelWarning, "unknown trigger %q, expected one of %s", r.Trigger, strings.Join(validTriggers, ", "))
+	}
+	if r.Priority != "" && !slices.Contains(validPriorities, r.Priority) {
+		report(LevelError, "invalid priority %q, expected one of %s", r.Priority, strings.Join(validPriorities, ", "))
+	}
+	if r.Notify != "" && !slices.Contains(validNotify, r.Notify) {
+		report(LevelError, "invalid notify %q, expected one of %s", r.Notify, strings.Join(validNotify, ", "))
+	}
+	if r.Deadline != "" {
+		if d, err := time.ParseDuration(r.Deadline); err != nil {
+			report(LevelError, "deadline %q is not a duration (e.g. 30m, 2h)", r.Deadline)
+		} else if d <= 0 {
+			report(LevelError, "deadline %q must be positive", r.Deadline)
+		}
+	}
+	if r.Priority == "" && r.Notify == "" && r.Deadline == "" {
+		report(LevelWarning, "sets no priority, notify, or deadline, rule has no effect")
+	}
+}
+
+// String formats hints for logs, e.g. "priority=low notify=digest (rule nightly)".
+func (h Hints) String() string {
+	s := fmt.Sprintf("priority=%s notify=%s", h.Priority, h.Notify)
+	if h.Deadline > 0 {
+		s += fmt.Sprintf(" deadline=%s", h.Deadline)
+	}
+	return s + fmt.Sprintf(" (rule %s)", h.Rule)
+}
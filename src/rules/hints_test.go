@@ -0,0 +1,80 @@
+package rules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRuleSet_Hints(t *testing.T) {
+	rs, err := Compile(&Config{Pipelines: []PipelineRule{
+		{Name: "release", Pipeline: "acme/*", Branch: "release/*", Priority: PriorityHigh, Deadline: "30m"},
+		{Name: "nightly", Trigger: "schedule", Notify: NotifyNone},
+	}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		rs    *RuleSet
+		build BuildInfo
+		want  Hints
+	}{
+		{
+			name:  "first match wins",
+			rs:    rs,
+			build: BuildInfo{Pipeline: "acme/api", Branch: "release/1.2", Trigger: "schedule"},
+			want:  Hints{Priority: PriorityHigh, Notify: NotifyDigest, Deadline: 30 * time.Minute, Rule: "release"},
+		},
+		{
+			name:  "unset fields fall back to defaults",
+			rs:    rs,
+			build: BuildInfo{Pipeline: "other/web", Branch: "main", Trigger: "schedule"},
+			want:  Hints{Priority: PriorityLow, Notify: NotifyNone, Rule: "nightly"},
+		},
+		{
+			name:  "glob is anchored",
+			rs:    rs,
+			build: BuildInfo{Pipeline: "notacme/api", Branch: "release/1.2", Trigger: "push"},
+			want:  Hints{Priority: PriorityNormal, Notify: NotifyImmediate, Rule: "default"},
+		},
+		{
+			name:  "pull request default",
+			rs:    rs,
+			build: BuildInfo{Pipeline: "acme/api", Branch: "feature", Trigger: "pull_request"},
+			want:  Hints{Priority: PriorityHigh, Notify: NotifyImmediate, Rule: "default"},
+		},
+		{
+			name:  "nil rule set",
+			rs:    nil,
+			build: BuildInfo{Trigger: "schedule"},
+			want:  Hints{Priority: PriorityLow, Notify: NotifyDigest, Rule: "default"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rs.Hints(tt.build); got != tt.want {
+				t.Errorf("Hints() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Pipelines(t *testing.T) {
+	cfg, err := Parse([]byte(`
+pipelines:
+  - name: nightly
+    trigger: schedule
+    priority: low
+    notify: digest
+    deadline: 12h
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := PipelineRule{Name: "nightly", Trigger: "schedule", Priority: "low", Notify: "digest", Deadline: "12h"}
+	if len(cfg.Pipelines) != 1 || cfg.Pipelines[0] != want {
+		t.Errorf("Pipelines = %+v, want [%+v]", cfg.Pipelines, want)
+	}
+}
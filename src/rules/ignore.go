@@ -0,0 +1,159 @@
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"destill-agent/src/config"
+)
+
+const (
+	// IgnoreFileName is the repo-level file of findings never to surface,
+	// found by walking up from the working directory to the repository root.
+	IgnoreFileName = ".destill-ignore.yaml"
+
+	// EnvIgnoreFile overrides the ignore file path.
+	EnvIgnoreFile = "DESTILL_IGNORE_FILE"
+
+	// minIgnoreHash is the shortest message hash prefix an ignore rule may
+	// list, so a typo can't match most findings.
+	minIgnoreHash = 8
+)
+
+// IgnoreRule suppresses the findings of matching pipelines whose message
+// matches Regex or whose message hash starts with one of Hashes. Unlike a
+// Suppression, which drops a line before it's scored, the finding is kept
+// and annotated with the rule, so the TUI can still show it.
+//
+//	ignore:
+//	  - name: teardown 404s
+//	    pipeline: 'acme/api'
+//	    regex: '404 Not Found.*teardown'
+//	    reason: known noisy teardown
+//	  - name: cache warmup
+//	    hashes: [3fa2c1d4e5b6]
+type IgnoreRule struct {
+	Name     string   `yaml:"name" json:"name"`
+	Pipeline string   `yaml:"pipeline,omitempty" json:"pipeline,omitempty"` // Glob, e.g. "acme/*"; empty matches every pipeline
+	Regex    string   `yaml:"regex,omitempty" json:"regex,omitempty"`
+	Hashes   []string `yaml:"hashes,omitempty" json:"hashes,omitempty"` // Message hashes, or prefixes of at least 8 characters
+	Reason   string   `yaml:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// ignoreFile is the format of the ignore file.
+type ignoreFile struct {
+	Ignore []IgnoreRule `yaml:"ignore"`
+}
+
+type compiledIgnoreRule struct {
+	IgnoreRule
+	pipeline *regexp.Regexp
+	re       *regexp.Regexp
+}
+
+// IgnorePath returns the ignore file path: DESTILL_IGNORE_FILE, or the
+// repository's .destill-ignore.yaml for the working directory. Returns ""
+// if there is none.
+func IgnorePath() string {
+	if path := os.Getenv(EnvIgnoreFile); path != "" {
+		return path
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return config.FindRepoFile(wd, IgnoreFileName)
+}
+
+// LoadIgnoreFile reads the ignore rules at path.
+func LoadIgnoreFile(path string) ([]IgnoreRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ignore file: %w", err)
+	}
+	var file ignoreFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: invalid ignore file: %w", path, err)
+	}
+	return file.Ignore, nil
+}
+
+// LoadIgnores reads the ignore rules at IgnorePath, returning them and the
+// path read ("" without an ignore file). A missing file named by
+// DESTILL_IGNORE_FILE is an error.
+func LoadIgnores() ([]IgnoreRule, string, error) {
+	path := IgnorePath()
+	if path == "" {
+		return nil, "", nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && os.Getenv(EnvIgnoreFile) == "" {
+		return nil, "", nil
+	}
+	ignores, err := LoadIgnoreFile(path)
+	return ignores, path, err
+}
+
+// withIgnoreFile adds the rules of the ignore file, if there is one, to cfg.
+func withIgnoreFile(cfg *Config) (*Config, error) {
+	ignores, _, err := LoadIgnores()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Ignores = append(cfg.Ignores, ignores...)
+	return cfg, nil
+}
+
+// compileIgnoreRule compiles a rule that has already passed Lint.
+func compileIgnoreRule(r IgnoreRule) compiledIgnoreRule {
+	c := compiledIgnoreRule{IgnoreRule: r}
+	if r.Pipeline != "" {
+		c.pipeline = globRegexp(r.Pipeline)
+	}
+	if r.Regex != "" {
+		c.re = regexp.MustCompile(r.Regex)
+	}
+	return c
+}
+
+// Ignored returns the first ignore rule matching a finding of pipeline, by
+// its raw message or message hash. A nil RuleSet ignores nothing.
+func (rs *RuleSet) Ignored(message, hash, pipeline string) (IgnoreRule, bool) {
+	if rs == nil {
+		return IgnoreRule{}, false
+	}
+	for _, r := range rs.ignores {
+		if r.pipeline != nil && !r.pipeline.MatchString(pipeline) {
+			continue
+		}
+		if r.re != nil && r.re.MatchString(message) {
+			return r.IgnoreRule, true
+		}
+		for _, prefix := range r.Hashes {
+			if hash != "" && strings.HasPrefix(hash, prefix) {
+				return r.IgnoreRule, true
+			}
+		}
+	}
+	return IgnoreRule{}, false
+}
+
+// lintIgnoreRule reports problems with a single ignore rule.
+func lintIgnoreRule(r IgnoreRule, report func(level, format string, args ...any)) {
+	if r.Regex == "" && len(r.Hashes) == 0 {
+		report(LevelError, "no regex or hashes, rule matches nothing")
+	}
+	for _, hash := range r.Hashes {
+		if len(hash) < minIgnoreHash {
+			report(LevelError, "hash %q is shorter than %d characters", hash, minIgnoreHash)
+		}
+	}
+}
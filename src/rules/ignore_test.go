@@ -0,0 +1,85 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRuleSet_Ignored(t *testing.T) {
+	rs, err := Compile(&Config{Ignores: []IgnoreRule{
+		{Name: "teardown", Pipeline: "acme/*", Regex: `404 Not Found.*teardown`, Reason: "known noisy teardown"},
+		{Name: "cache warmup", Hashes: []string{"3fa2c1d4e5b6"}},
+	}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	tests := []struct {
+		message, hash, pipeline string
+		want                    string // Rule name, "" for none
+	}{
+		{"GET /health 404 Not Found during teardown", "", "acme/api", "teardown"},
+		{"GET /health 404 Not Found during teardown", "", "other/api", ""},
+		{"cache miss", "3fa2c1d4e5b6a7c8", "other/api", "cache warmup"},
+		{"cache miss", "3fa2c1d4", "other/api", ""},
+		{"ERROR: connection refused", "", "acme/api", ""},
+	}
+	for _, tt := range tests {
+		rule, ok := rs.Ignored(tt.message, tt.hash, tt.pipeline)
+		if rule.Name != tt.want || ok != (tt.want != "") {
+			t.Errorf("Ignored(%q, %q, %q) = %q, %v; want %q", tt.message, tt.hash, tt.pipeline, rule.Name, ok, tt.want)
+		}
+	}
+
+	if _, ok := (*RuleSet)(nil).Ignored("anything", "hash", "acme/api"); ok {
+		t.Error("nil RuleSet ignored a finding")
+	}
+}
+
+func TestLint_Ignores(t *testing.T) {
+	issues := Lint(&Config{Ignores: []IgnoreRule{
+		{Name: "nothing"},
+		{Name: "short hash", Hashes: []string{"3fa2"}},
+		{Name: "bad regex", Regex: "("},
+	}})
+	var messages []string
+	for _, issue := range issues {
+		if issue.Level == LevelError {
+			messages = append(messages, issue.String())
+		}
+	}
+	got := strings.Join(messages, "\n")
+	for _, want := range []string{"ignore[0] (nothing): no regex or hashes", `ignore[1] (short hash): hash "3fa2" is shorter`, "ignore[2] (bad regex): regex does not compile"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Lint() errors missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestLoadRuleSet_IgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvPatternsFile, filepath.Join(dir, "patterns.yaml"))
+	os.WriteFile(filepath.Join(dir, "patterns.yaml"), []byte("suppressions: []\n"), 0o644)
+
+	path := filepath.Join(dir, IgnoreFileName)
+	t.Setenv(EnvIgnoreFile, path)
+	if _, err := LoadRuleSet(); err == nil {
+		t.Error("LoadRuleSet() with a missing DESTILL_IGNORE_FILE succeeded")
+	}
+
+	os.WriteFile(path, []byte("ignore:\n  - name: teardown\n    regex: 'teardown'\n"), 0o644)
+	rs, err := LoadRuleSet()
+	if err != nil {
+		t.Fatalf("LoadRuleSet() error = %v", err)
+	}
+	if _, ok := rs.Ignored("404 during teardown", "", "acme/api"); !ok {
+		t.Error("rule of the ignore file not applied")
+	}
+
+	os.WriteFile(path, []byte("ignore:\n  - name: teardown\n    regexp: 'teardown'\n"), 0o644)
+	if _, err := LoadRuleSet(); err == nil || !strings.Contains(err.Error(), "invalid ignore file") {
+		t.Errorf("LoadRuleSet(unknown field) error = %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Issue levels reported by Lint.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// Issue is a single lint finding for a pattern, suppression, or pipeline rule.
+type Issue struct {
+	Level   string `json:"level"`
+	Rule    string `json:"rule"` // e.g. "patterns[0] (flaky db pool)"
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Rule, i.Message)
+}
+
+// benignLines are ordinary log lines a useful rule should not all match.
+var benignLines = []string{
+	"",
+	"Downloading dependencies...",
+	"Step 3/10 : RUN make build",
+	"ok  \tgithub.com/example/pkg\t0.012s",
+	"All tests passed",
+}
+
+// Lint checks cfg for invalid regexes, out-of-range weights, unknown
+// severities, invalid pipeline rule values, ignore rules that match
+// nothing, and rules that match everything. Errors make the config
+// unusable; warnings do not.
+func Lint(cfg *Config) []Issue {
+	var issues []Issue
+	report := func(level, rule, format string, args ...any) {
+		issues = append(issues, Issue{Level: level, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seen := make(map[string]string)
+	checkName := func(rule, name string) {
+		if strings.TrimSpace(name) == "" {
+			report(LevelWarning, rule, "missing name")
+			return
+		}
+		if prev, ok := seen[name]; ok {
+			report(LevelWarning, rule, "duplicate name %q (also used by %s)", name, prev)
+			return
+		}
+		seen[name] = rule
+	}
+
+	checkRegex := func(rule, expr string) {
+		if expr == "" {
+			report(LevelError, rule, "regex is empty")
+			return
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			report(LevelError, rule, "regex does not compile: %v", err)
+			return
+		}
+		if matchesEverything(re) {
+			report(LevelWarning, rule, "regex %q matches every line", expr)
+		}
+	}
+
+	for i, p := range cfg.Patterns {
+		rule := packLabel(ruleLabel("patterns", i, p.Name), p.Pack)
+		checkName(rule, p.Name)
+		checkRegex(rule, p.Regex)
+		switch {
+		case p.Weight < MinWeight || p.Weight > MaxWeight:
+			report(LevelError, rule, "weight %.2f is outside [%.1f, %.1f]", p.Weight, MinWeight, MaxWeight)
+		case p.Weight == 0:
+			report(LevelWarning, rule, "weight is 0, pattern has no effect")
+		}
+	}
+
+	for i, s := range cfg.Suppressions {
+		rule := packLabel(ruleLabel("suppressions", i, s.Name), s.Pack)
+		checkName(rule, s.Name)
+		checkRegex(rule, s.Regex)
+	}
+
+	for i, r := range cfg.Severities {
+		rule := packLabel(ruleLabel("severities", i, r.Name), r.Pack)
+		checkName(rule, r.Name)
+		checkRegex(rule, r.Regex)
+		if !slices.Contains(Severities, strings.ToUpper(r.Severity)) {
+			report(LevelError, rule, "severity %q is not one of %s", r.Severity, strings.Join(Severities, ", "))
+		}
+	}
+
+	for i, n := range cfg.Normalizations {
+		rule := packLabel(ruleLabel("normalizations", i, n.Name), n.Pack)
+		checkName(rule, n.Name)
+		checkRegex(rule, n.Regex)
+	}
+
+	for i, r := range cfg.Remediations {
+		rule := packLabel(ruleLabel("remediations", i, r.Name), r.Pack)
+		checkName(rule, r.Name)
+		checkRegex(rule, r.Regex)
+		if strings.TrimSpace(r.Fix) == "" {
+			report(LevelError, rule, "fix is empty")
+		}
+	}
+
+	for i, r := range cfg.Pipelines {
+		rule := ruleLabel("pipelines", i, r.Name)
+		checkName(rule, r.Name)
+		lintPipelineRule(r, func(level, format string, args ...any) {
+			report(level, rule, format, args...)
+		})
+	}
+
+	for i, r := range cfg.Ignores {
+		rule := ruleLabel("ignore", i, r.Name)
+		checkName(rule, r.Name)
+		if r.Regex != "" {
+			checkRegex(rule, r.Regex)
+		}
+		lintIgnoreRule(r, func(level, format string, args ...any) {
+			report(level, rule, format, args...)
+		})
+	}
+
+	return issues
+}
+
+// HasErrors reports whether any issue is an error.
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Level == LevelError {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesEverything reports whether re matches every benign sample line.
+func matchesEverything(re *regexp.Regexp) bool {
+	for _, line := range benignLines {
+		if !re.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+func ruleLabel(section string, index int, name string) string {
+	if name == "" {
+		return fmt.Sprintf("%s[%d]", section, index)
+	}
+	return fmt.Sprintf("%s[%d] (%s)", section, index, name)
+}
+
+// packLabel notes the pack a rule came from, if any.
+func packLabel(label, pack string) string {
+	if pack == "" {
+		return label
+	}
+	return fmt.Sprintf("%s in pack %s", label, pack)
+}
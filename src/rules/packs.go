@@ -0,0 +1,103 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// loadPacks appends the rules of every pack listed in cfg.Packs to cfg.
+// Entries are file paths or globs, relative to dir unless absolute; a
+// leading ~/ means the home directory. Packs may only contain patterns,
+// suppressions, severities, normalizations, and remediations.
+func loadPacks(cfg *Config, dir string) error {
+	loaded := make(map[string]bool)
+	for _, entry := range cfg.Packs {
+		paths, err := packPaths(entry, dir)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if loaded[path] {
+				continue
+			}
+			loaded[path] = true
+
+			pack, err := loadPack(path)
+			if err != nil {
+				return err
+			}
+			cfg.Patterns = append(cfg.Patterns, pack.Patterns...)
+			cfg.Suppressions = append(cfg.Suppressions, pack.Suppressions...)
+			cfg.Severities = append(cfg.Severities, pack.Severities...)
+			cfg.Normalizations = append(cfg.Normalizations, pack.Normalizations...)
+			cfg.Remediations = append(cfg.Remediations, pack.Remediations...)
+		}
+	}
+	return nil
+}
+
+// packPaths resolves a packs entry to the files it names. A glob matching
+// nothing is not an error; a missing plain path is.
+func packPaths(entry, dir string) ([]string, error) {
+	path := entry
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("pack %q: %w", entry, err)
+		}
+		path = filepath.Join(home, rest)
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	if !strings.ContainsAny(entry, "*?[") {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("pack %q: %w", entry, err)
+		}
+		return []string{path}, nil
+	}
+
+	paths, err := filepath.Glob(path)
+	if err != nil {
+		return nil, fmt.Errorf("pack %q: %w", entry, err)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// loadPack reads one pack file and tags its rules with the pack name (the
+// file name without extension).
+func loadPack(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pattern pack: %w", err)
+	}
+	pack, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("pack %s: %w", path, err)
+	}
+	if len(pack.Pipelines) > 0 || len(pack.Packs) > 0 {
+		return nil, fmt.Errorf("pack %s: pipelines and packs are only allowed in the main config", path)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	for i := range pack.Patterns {
+		pack.Patterns[i].Pack = name
+	}
+	for i := range pack.Suppressions {
+		pack.Suppressions[i].Pack = name
+	}
+	for i := range pack.Severities {
+		pack.Severities[i].Pack = name
+	}
+	for i := range pack.Normalizations {
+		pack.Normalizations[i].Pack = name
+	}
+	for i := range pack.Remediations {
+		pack.Remediations[i].Pack = name
+	}
+	return pack, nil
+}
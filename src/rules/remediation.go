@@ -0,0 +1,93 @@
+package rules
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+)
+
+// Remediation suggests a fix for failures whose message or log context
+// matches Regex: a command or config snippet, and what it does.
+//
+//	remediations:
+//	  - name: npm ERESOLVE
+//	    regex: 'npm ERR! code ERESOLVE'
+//	    fix: npm install --legacy-peer-deps
+//	    description: npm 7+ refuses conflicting peer dependencies.
+type Remediation struct {
+	Name        string `yaml:"name" json:"name"`
+	Regex       string `yaml:"regex" json:"regex"`
+	Fix         string `yaml:"fix" json:"fix"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Pack        string `yaml:"-" json:"pack,omitempty"`
+}
+
+type compiledRemediation struct {
+	Remediation
+	re *regexp.Regexp
+}
+
+//go:embed remediations.yaml
+var builtinRemediationsYAML []byte
+
+// builtinRemediations are the curated remediations of remediations.yaml,
+// tried after the user's.
+var builtinRemediations = mustCompileRemediations(builtinRemediationsYAML)
+
+func mustCompileRemediations(data []byte) []compiledRemediation {
+	cfg, err := Parse(data)
+	if err != nil {
+		panic(fmt.Sprintf("built-in remediations: %v", err))
+	}
+	if issues := Lint(cfg); HasErrors(issues) {
+		panic(fmt.Sprintf("built-in remediations: %v", issues))
+	}
+	return compileRemediations(cfg.Remediations)
+}
+
+func compileRemediations(list []Remediation) []compiledRemediation {
+	compiled := make([]compiledRemediation, 0, len(list))
+	for _, r := range list {
+		compiled = append(compiled, compiledRemediation{Remediation: r, re: regexp.MustCompile(r.Regex)})
+	}
+	return compiled
+}
+
+// BuiltinRemediations returns the curated remediations, in the order they're
+// tried.
+func BuiltinRemediations() []Remediation {
+	list := make([]Remediation, len(builtinRemediations))
+	for i, r := range builtinRemediations {
+		list[i] = r.Remediation
+	}
+	return list
+}
+
+// Remediate returns the first remediation matching message, or else any of
+// the context lines, trying the user's remediations before the built-in
+// ones. A nil RuleSet tries the built-in ones only.
+func (rs *RuleSet) Remediate(message string, context ...[]string) (Remediation, bool) {
+	var user []compiledRemediation
+	if rs != nil {
+		user = rs.remediations
+	}
+	for _, list := range [][]compiledRemediation{user, builtinRemediations} {
+		for _, r := range list {
+			if r.re.MatchString(message) {
+				return r.Remediation, true
+			}
+		}
+	}
+	for _, list := range [][]compiledRemediation{user, builtinRemediations} {
+		for _, r := range list {
+			for _, lines := range context {
+				for _, line := range lines {
+					if r.re.MatchString(line) {
+						return r.Remediation, true
+					}
+				}
+			}
+		}
+	}
+	return Remediation{}, false
+}
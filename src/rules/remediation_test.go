@@ -0,0 +1,74 @@
+package rules
+
+import "testing"
+
+func TestBuiltinRemediations(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"npm ERR! code ERESOLVE", "npm ERESOLVE"},
+		{"FAILURE: Build failed with an exception. Gradle build daemon disappeared unexpectedly (it may have been killed or may have crashed)", "gradle daemon out of memory"},
+		{"Error response from daemon: pull access denied for acme/api, repository does not exist or may require 'docker login'", "docker pull unauthorized"},
+		{"toomanyrequests: You have reached your pull rate limit.", "docker pull rate limit"},
+		{"write /tmp/build: no space left on device", "disk full"},
+		{"npm ERR! code ENOSPC", "disk full"},
+		{"FAIL TestCart", ""},
+	}
+	for _, tt := range tests {
+		r, ok := (*RuleSet)(nil).Remediate(tt.line)
+		if got := r.Name; got != tt.want || ok != (tt.want != "") {
+			t.Errorf("Remediate(%q) = %q, %v, want %q", tt.line, got, ok, tt.want)
+		}
+	}
+	for _, r := range BuiltinRemediations() {
+		if r.Fix == "" || r.Description == "" {
+			t.Errorf("built-in remediation %q needs a fix and a description", r.Name)
+		}
+	}
+}
+
+func TestRuleSet_Remediate(t *testing.T) {
+	cfg, err := Parse([]byte(`
+remediations:
+  - name: acme npm
+    regex: 'ERESOLVE'
+    fix: make deps
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	rs, err := Compile(cfg)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	// The user's remediations come before the built-in ones
+	if r, _ := rs.Remediate("npm ERR! code ERESOLVE"); r.Name != "acme npm" || r.Fix != "make deps" {
+		t.Errorf("Remediate() = %+v, want the user's remediation", r)
+	}
+	// A match on the message beats one in its context
+	r, ok := rs.Remediate("Error: pull access denied for acme/api", []string{"npm ERR! code ERESOLVE"})
+	if !ok || r.Name != "docker pull unauthorized" {
+		t.Errorf("Remediate() = %+v, want the message's remediation", r)
+	}
+	if r, ok := rs.Remediate("npm ERR! A complete log of this run", nil, []string{"npm ERR! code ERESOLVE"}); !ok || r.Name != "acme npm" {
+		t.Errorf("Remediate() = %+v, %v, want a match in the context", r, ok)
+	}
+}
+
+func TestLint_Remediations(t *testing.T) {
+	issues := Lint(&Config{Remediations: []Remediation{
+		{Name: "no fix", Regex: "x"},
+		{Name: "bad regex", Regex: "(", Fix: "retry"},
+	}})
+	if len(issues) != 2 {
+		t.Fatalf("Lint() = %v, want 2 issues", issues)
+	}
+	if issues[0].Rule != "remediations[0] (no fix)" || issues[0].Message != "fix is empty" {
+		t.Errorf("issues[0] = %+v", issues[0])
+	}
+	if issues[1].Rule != "remediations[1] (bad regex)" || issues[1].Level != LevelError {
+		t.Errorf("issues[1] = %+v", issues[1])
+	}
+}
@@ -0,0 +1,324 @@
+// Package rules loads user-defined analyzer patterns and suppressions.
+//
+// Rules are read from a YAML file (default ~/.destill/patterns.yaml, override
+// with DESTILL_PATTERNS_FILE):
+//
+//	patterns:
+//	  - name: flaky db pool
+//	    regex: 'pq: too many connections'
+//	    weight: 0.3
+//	suppressions:
+//	  - name: teardown 404
+//	    regex: '404 Not Found.*teardown'
+//	    reason: expected during teardown
+//	severities:
+//	  - name: acme test failures
+//	    regex: '^ACME-FAIL '
+//	    severity: error
+//	normalizations:
+//	  - name: acme job ids
+//	    regex: 'acmejob-[0-9a-f]+'
+//	    replace: 'acmejob-<id>'
+//	remediations:
+//	  - name: acme license server
+//	    regex: 'ACME-LICENSE-EXPIRED'
+//	    fix: acme license renew --ci
+//	pipelines:
+//	  - name: nightly
+//	    trigger: schedule
+//	    priority: low
+//	    notify: digest
+//	packs:
+//	  - packs/*.yaml
+//
+// Packs are pattern pack files, relative to the config file, holding
+// patterns, suppressions, severities, normalizations, and remediations a
+// team shares.
+package rules
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPatternsFile overrides the default pattern config path.
+const EnvPatternsFile = "DESTILL_PATTERNS_FILE"
+
+const (
+	// MinWeight and MaxWeight bound the confidence delta of a user pattern.
+	MinWeight = -1.0
+	MaxWeight = 1.0
+)
+
+// Config is the on-disk pattern/suppression configuration.
+type Config struct {
+	Patterns       []Pattern       `yaml:"patterns" json:"patterns"`
+	Suppressions   []Suppression   `yaml:"suppressions" json:"suppressions"`
+	Severities     []SeverityRule  `yaml:"severities,omitempty" json:"severities,omitempty"`
+	Normalizations []Normalization `yaml:"normalizations,omitempty" json:"normalizations,omitempty"`
+	Pipelines      []PipelineRule  `yaml:"pipelines,omitempty" json:"pipelines,omitempty"`
+	Remediations   []Remediation   `yaml:"remediations,omitempty" json:"remediations,omitempty"`
+	Packs          []string        `yaml:"packs,omitempty" json:"packs,omitempty"`
+
+	// Ignores come from the repo's ignore file, not the pattern config (see
+	// IgnoreRule).
+	Ignores []IgnoreRule `yaml:"-" json:"ignore,omitempty"`
+}
+
+// Pattern adjusts the confidence of any ERROR/FATAL line it matches.
+type Pattern struct {
+	Name   string  `yaml:"name" json:"name"`
+	Regex  string  `yaml:"regex" json:"regex"`
+	Weight float64 `yaml:"weight" json:"weight"` // Positive = boost, negative = penalty
+	Pack   string  `yaml:"-" json:"pack,omitempty"`
+}
+
+// Suppression drops any line it matches before scoring.
+type Suppression struct {
+	Name   string `yaml:"name" json:"name"`
+	Regex  string `yaml:"regex" json:"regex"`
+	Reason string `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Pack   string `yaml:"-" json:"pack,omitempty"`
+}
+
+// Severity levels a SeverityRule can assign.
+var Severities = []string{"FATAL", "ERROR", "WARN", "INFO"}
+
+// SeverityRule overrides the detected severity of any line it matches, so
+// lines from in-house tools can be reported (ERROR) or ignored (INFO).
+type SeverityRule struct {
+	Name     string `yaml:"name" json:"name"`
+	Regex    string `yaml:"regex" json:"regex"`
+	Severity string `yaml:"severity" json:"severity"` // FATAL, ERROR, WARN, or INFO (any case)
+	Pack     string `yaml:"-" json:"pack,omitempty"`
+}
+
+// Normalization rewrites matches of Regex with Replace before a message is
+// normalized for deduplication, so volatile values (internal IDs, hostnames)
+// don't split one error into many. Replace may use $1-style references.
+type Normalization struct {
+	Name    string `yaml:"name" json:"name"`
+	Regex   string `yaml:"regex" json:"regex"`
+	Replace string `yaml:"replace" json:"replace"`
+	Pack    string `yaml:"-" json:"pack,omitempty"`
+}
+
+// DefaultPath returns the pattern config path, honoring DESTILL_PATTERNS_FILE.
+func DefaultPath() string {
+	if path := os.Getenv(EnvPatternsFile); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".destill", "patterns.yaml")
+}
+
+// Load reads the config at DefaultPath. A missing default file yields an
+// empty config; a missing file named by DESTILL_PATTERNS_FILE is an error.
+func Load() (*Config, string, error) {
+	path := DefaultPath()
+	if path == "" {
+		return &Config{}, "", nil
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && os.Getenv(EnvPatternsFile) == "" {
+		return &Config{}, path, nil
+	}
+	cfg, err := LoadFile(path)
+	return cfg, path, err
+}
+
+// LoadFile reads and parses a pattern config file, including the rules of
+// every pack it lists.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pattern config: %w", err)
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := loadPacks(cfg, filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse decodes YAML pattern config. Unknown fields are rejected so typos
+// surface instead of being silently ignored.
+func Parse(data []byte) (*Config, error) {
+	cfg := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid pattern config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Match is a user pattern that matched a line.
+type Match struct {
+	Name   string
+	Weight float64
+}
+
+type compiledPattern struct {
+	Pattern
+	re *regexp.Regexp
+}
+
+type compiledSuppression struct {
+	Suppression
+	re *regexp.Regexp
+}
+
+type compiledSeverityRule struct {
+	SeverityRule
+	re *regexp.Regexp
+}
+
+type compiledNormalization struct {
+	Normalization
+	re *regexp.Regexp
+}
+
+// RuleSet is a compiled Config, safe for concurrent use.
+type RuleSet struct {
+	patterns       []compiledPattern
+	suppressions   []compiledSuppression
+	severities     []compiledSeverityRule
+	normalizations []compiledNormalization
+	pipelines      []compiledPipelineRule
+	remediations   []compiledRemediation
+	ignores        []compiledIgnoreRule
+	hash           string
+}
+
+// Compile validates cfg and compiles its regexes. It fails on the same
+// problems Lint reports as errors.
+func Compile(cfg *Config) (*RuleSet, error) {
+	for _, issue := range Lint(cfg) {
+		if issue.Level == LevelError {
+			return nil, fmt.Errorf("invalid pattern config: %s", issue)
+		}
+	}
+
+	rs := &RuleSet{hash: configHash(cfg)}
+	for _, p := range cfg.Patterns {
+		rs.patterns = append(rs.patterns, compiledPattern{Pattern: p, re: regexp.MustCompile(p.Regex)})
+	}
+	for _, s := range cfg.Suppressions {
+		rs.suppressions = append(rs.suppressions, compiledSuppression{Suppression: s, re: regexp.MustCompile(s.Regex)})
+	}
+	for _, r := range cfg.Severities {
+		r.Severity = strings.ToUpper(r.Severity)
+		rs.severities = append(rs.severities, compiledSeverityRule{SeverityRule: r, re: regexp.MustCompile(r.Regex)})
+	}
+	for _, n := range cfg.Normalizations {
+		rs.normalizations = append(rs.normalizations, compiledNormalization{Normalization: n, re: regexp.MustCompile(n.Regex)})
+	}
+	for _, r := range cfg.Pipelines {
+		rs.pipelines = append(rs.pipelines, compilePipelineRule(r))
+	}
+	rs.remediations = compileRemediations(cfg.Remediations)
+	for _, r := range cfg.Ignores {
+		rs.ignores = append(rs.ignores, compileIgnoreRule(r))
+	}
+	return rs, nil
+}
+
+// Hash identifies the config the RuleSet was compiled from. Formatting and
+// comments in the YAML do not affect it. A nil RuleSet has an empty hash.
+func (rs *RuleSet) Hash() string {
+	if rs == nil {
+		return ""
+	}
+	return rs.hash
+}
+
+// configHash returns a short content hash of cfg.
+func configHash(cfg *Config) string {
+	data, _ := json.Marshal(cfg)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Match returns every user pattern that matches line. A nil RuleSet matches nothing.
+func (rs *RuleSet) Match(line string) []Match {
+	if rs == nil {
+		return nil
+	}
+	var matches []Match
+	for _, p := range rs.patterns {
+		if p.re.MatchString(line) {
+			matches = append(matches, Match{Name: p.Name, Weight: p.Weight})
+		}
+	}
+	return matches
+}
+
+// Suppressed reports whether line matches a suppression, returning its name.
+func (rs *RuleSet) Suppressed(line string) (string, bool) {
+	if rs == nil {
+		return "", false
+	}
+	for _, s := range rs.suppressions {
+		if s.re.MatchString(line) {
+			return s.Name, true
+		}
+	}
+	return "", false
+}
+
+// Severity returns the severity assigned by the first severity rule that
+// matches line, and the rule's name.
+func (rs *RuleSet) Severity(line string) (severity, rule string, ok bool) {
+	if rs == nil {
+		return "", "", false
+	}
+	for _, r := range rs.severities {
+		if r.re.MatchString(line) {
+			return r.Severity, r.Name, true
+		}
+	}
+	return "", "", false
+}
+
+// Normalize applies every normalization rule to msg, in order. A nil RuleSet
+// returns msg unchanged.
+func (rs *RuleSet) Normalize(msg string) string {
+	if rs == nil {
+		return msg
+	}
+	for _, n := range rs.normalizations {
+		msg = n.re.ReplaceAllString(msg, n.Replace)
+	}
+	return msg
+}
+
+// LoadRuleSet loads the config at DefaultPath and the ignore file at
+// IgnorePath, and compiles them.
+func LoadRuleSet() (*RuleSet, error) {
+	cfg, _, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if cfg, err = withIgnoreFile(cfg); err != nil {
+		return nil, err
+	}
+	return Compile(cfg)
+}
@@ -0,0 +1,292 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const sampleConfig = `
+patterns:
+  - name: db pool exhausted
+    regex: 'pq: too many connections'
+    weight: 0.3
+suppressions:
+  - name: teardown 404
+    regex: '404 Not Found.*teardown'
+    reason: expected during teardown
+`
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(sampleConfig))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(cfg.Patterns) != 1 || cfg.Patterns[0].Weight != 0.3 {
+		t.Errorf("Patterns = %+v, want one pattern with weight 0.3", cfg.Patterns)
+	}
+	if len(cfg.Suppressions) != 1 || cfg.Suppressions[0].Reason != "expected during teardown" {
+		t.Errorf("Suppressions = %+v, want one suppression with reason", cfg.Suppressions)
+	}
+}
+
+func TestParse_UnknownField(t *testing.T) {
+	if _, err := Parse([]byte("patterns:\n  - name: x\n    regx: foo\n")); err == nil {
+		t.Error("Parse() expected error for unknown field, got nil")
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	cfg, err := Parse(nil)
+	if err != nil {
+		t.Fatalf("Parse(nil) error = %v", err)
+	}
+	if len(cfg.Patterns) != 0 || len(cfg.Suppressions) != 0 {
+		t.Errorf("Parse(nil) = %+v, want empty config", cfg)
+	}
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       Config
+		wantLevel string // "" means no issues
+	}{
+		{
+			name:      "valid pattern",
+			cfg:       Config{Patterns: []Pattern{{Name: "p", Regex: "boom", Weight: 0.2}}},
+			wantLevel: "",
+		},
+		{
+			name:      "bad regex",
+			cfg:       Config{Patterns: []Pattern{{Name: "p", Regex: "(unclosed", Weight: 0.2}}},
+			wantLevel: LevelError,
+		},
+		{
+			name:      "weight out of range",
+			cfg:       Config{Patterns: []Pattern{{Name: "p", Regex: "boom", Weight: 1.5}}},
+			wantLevel: LevelError,
+		},
+		{
+			name:      "empty regex",
+			cfg:       Config{Suppressions: []Suppression{{Name: "s"}}},
+			wantLevel: LevelError,
+		},
+		{
+			name:      "matches everything",
+			cfg:       Config{Suppressions: []Suppression{{Name: "s", Regex: ".*"}}},
+			wantLevel: LevelWarning,
+		},
+		{
+			name:      "zero weight",
+			cfg:       Config{Patterns: []Pattern{{Name: "p", Regex: "boom"}}},
+			wantLevel: LevelWarning,
+		},
+		{
+			name: "duplicate name",
+			cfg: Config{
+				Patterns:     []Pattern{{Name: "dup", Regex: "a", Weight: 0.1}},
+				Suppressions: []Suppression{{Name: "dup", Regex: "b"}},
+			},
+			wantLevel: LevelWarning,
+		},
+		{
+			name:      "valid pipeline rule",
+			cfg:       Config{Pipelines: []PipelineRule{{Name: "n", Trigger: "schedule", Priority: "low", Deadline: "2h"}}},
+			wantLevel: "",
+		},
+		{
+			name:      "invalid priority",
+			cfg:       Config{Pipelines: []PipelineRule{{Name: "n", Trigger: "schedule", Priority: "urgent"}}},
+			wantLevel: LevelError,
+		},
+		{
+			name:      "invalid deadline",
+			cfg:       Config{Pipelines: []PipelineRule{{Name: "n", Branch: "main", Deadline: "soon"}}},
+			wantLevel: LevelError,
+		},
+		{
+			name:      "pipeline rule without conditions",
+			cfg:       Config{Pipelines: []PipelineRule{{Name: "n", Notify: "none"}}},
+			wantLevel: LevelWarning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := Lint(&tt.cfg)
+			if tt.wantLevel == "" {
+				if len(issues) != 0 {
+					t.Errorf("Lint() = %v, want no issues", issues)
+				}
+				return
+			}
+			if len(issues) != 1 || issues[0].Level != tt.wantLevel {
+				t.Errorf("Lint() = %v, want one %s", issues, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	cfg, err := Parse([]byte(sampleConfig))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	rs, err := Compile(cfg)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	matches := rs.Match("ERROR: pq: too many connections for role app")
+	if len(matches) != 1 || matches[0].Name != "db pool exhausted" {
+		t.Errorf("Match() = %v, want db pool exhausted", matches)
+	}
+	if name, ok := rs.Suppressed("ERROR: 404 Not Found during teardown"); !ok || name != "teardown 404" {
+		t.Errorf("Suppressed() = %q, %v, want teardown 404, true", name, ok)
+	}
+	if _, ok := rs.Suppressed("ERROR: 404 Not Found"); ok {
+		t.Error("Suppressed() = true for non-matching line")
+	}
+
+	if _, err := Compile(&Config{Patterns: []Pattern{{Name: "p", Regex: "(", Weight: 0.1}}}); err == nil {
+		t.Error("Compile() expected error for invalid regex, got nil")
+	}
+}
+
+func TestLint_SeveritiesAndNormalizations(t *testing.T) {
+	cfg := &Config{
+		Severities: []SeverityRule{
+			{Name: "ok", Regex: "ACME-FAIL", Severity: "error"},
+			{Name: "bad level", Regex: "boom", Severity: "critical"},
+		},
+		Normalizations: []Normalization{{Name: "bad regex", Regex: "(", Replace: "x", Pack: "acme"}},
+	}
+
+	issues := Lint(cfg)
+	if len(issues) != 2 {
+		t.Fatalf("Lint() = %v, want 2 issues", issues)
+	}
+	if issues[0].Rule != "severities[1] (bad level)" || issues[0].Level != LevelError {
+		t.Errorf("issues[0] = %+v", issues[0])
+	}
+	if issues[1].Rule != "normalizations[0] (bad regex) in pack acme" || issues[1].Level != LevelError {
+		t.Errorf("issues[1] = %+v", issues[1])
+	}
+}
+
+func TestRuleSet_SeverityAndNormalize(t *testing.T) {
+	rs, err := Compile(&Config{
+		Severities:     []SeverityRule{{Name: "acme", Regex: "^ACME-FAIL", Severity: "Error"}},
+		Normalizations: []Normalization{{Name: "ids", Regex: `job-(\w+)-\d+`, Replace: "job-$1-<n>"}},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	if severity, rule, ok := rs.Severity("ACME-FAIL widget"); !ok || severity != "ERROR" || rule != "acme" {
+		t.Errorf("Severity() = %q, %q, %v, want ERROR from acme", severity, rule, ok)
+	}
+	if _, _, ok := rs.Severity("ERROR: other"); ok {
+		t.Error("Severity() matched a line without an override")
+	}
+	if got := rs.Normalize("failed in job-build-42"); got != "failed in job-build-<n>" {
+		t.Errorf("Normalize() = %q", got)
+	}
+
+	var nilSet *RuleSet
+	if got := nilSet.Normalize("x"); got != "x" {
+		t.Errorf("nil Normalize() = %q", got)
+	}
+}
+
+func TestLoadFile_Packs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "packs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, "packs", "acme.yaml"), `
+patterns:
+  - name: acme crash
+    regex: 'ACME core dumped'
+    weight: 0.4
+severities:
+  - name: acme failures
+    regex: '^ACME-FAIL '
+    severity: error
+`)
+	writeTestFile(t, filepath.Join(dir, "packs", "jvm.yaml"), `
+suppressions:
+  - name: jvm warmup
+    regex: 'JIT warmup'
+`)
+	path := filepath.Join(dir, "patterns.yaml")
+	writeTestFile(t, path, sampleConfig+"packs:\n  - packs/*.yaml\n")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if len(cfg.Patterns) != 2 || cfg.Patterns[1].Pack != "acme" {
+		t.Errorf("Patterns = %+v, want the config's and acme's", cfg.Patterns)
+	}
+	if len(cfg.Suppressions) != 2 || cfg.Suppressions[1].Pack != "jvm" {
+		t.Errorf("Suppressions = %+v, want the config's and jvm's", cfg.Suppressions)
+	}
+	if len(cfg.Severities) != 1 {
+		t.Errorf("Severities = %+v, want acme's", cfg.Severities)
+	}
+
+	t.Run("missing pack", func(t *testing.T) {
+		writeTestFile(t, path, "packs:\n  - missing.yaml\n")
+		if _, err := LoadFile(path); err == nil {
+			t.Error("LoadFile() expected error for a missing pack")
+		}
+	})
+
+	t.Run("pipelines in pack", func(t *testing.T) {
+		writeTestFile(t, filepath.Join(dir, "packs", "jvm.yaml"), "pipelines:\n  - name: p\n    priority: low\n")
+		writeTestFile(t, path, "packs:\n  - packs/jvm.yaml\n")
+		if _, err := LoadFile(path); err == nil {
+			t.Error("LoadFile() expected error for pipelines in a pack")
+		}
+	})
+}
+
+func writeTestFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	// Missing default file is not an error
+	t.Setenv("HOME", dir)
+	t.Setenv(EnvPatternsFile, "")
+	cfg, _, err := Load()
+	if err != nil || len(cfg.Patterns) != 0 {
+		t.Errorf("Load() with no file = %+v, %v, want empty config", cfg, err)
+	}
+
+	// Missing explicit file is an error
+	t.Setenv(EnvPatternsFile, filepath.Join(dir, "missing.yaml"))
+	if _, _, err := Load(); err == nil {
+		t.Error("Load() expected error for missing explicit file, got nil")
+	}
+
+	path := filepath.Join(dir, "patterns.yaml")
+	if err := os.WriteFile(path, []byte(sampleConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvPatternsFile, path)
+	cfg, gotPath, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if gotPath != path || len(cfg.Patterns) != 1 {
+		t.Errorf("Load() = %+v, %q, want 1 pattern from %q", cfg, gotPath, path)
+	}
+}
@@ -0,0 +1,234 @@
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// suppressionsKey is the config section suppressions live in.
+const suppressionsKey = "suppressions"
+
+// suppressionList is the file format of exported suppressions: a pattern
+// pack with only a suppressions section, so it can also be listed under
+// packs: as is.
+type suppressionList struct {
+	Suppressions []Suppression `yaml:"suppressions"`
+}
+
+// ExportSuppressions renders suppressions as a YAML pattern pack.
+func ExportSuppressions(suppressions []Suppression) ([]byte, error) {
+	if suppressions == nil {
+		suppressions = []Suppression{}
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(suppressionList{Suppressions: suppressions}); err != nil {
+		return nil, fmt.Errorf("failed to encode suppressions: %w", err)
+	}
+	quoteRegexes(node)
+	return encodeYAML(node)
+}
+
+// ParseSuppressions reads suppressions to import from a pattern pack or
+// exported list. Files with other rules are rejected, since only
+// suppressions are imported; list those under packs: instead. Errors
+// found by Lint, such as invalid regexes, are returned too.
+func ParseSuppressions(data []byte) ([]Suppression, error) {
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Patterns) > 0 || len(cfg.Severities) > 0 || len(cfg.Normalizations) > 0 ||
+		len(cfg.Remediations) > 0 || len(cfg.Pipelines) > 0 || len(cfg.Packs) > 0 {
+		return nil, fmt.Errorf("only suppressions can be imported; list files with other rules under packs: instead")
+	}
+	for _, issue := range Lint(&Config{Suppressions: cfg.Suppressions}) {
+		if issue.Level == LevelError {
+			return nil, fmt.Errorf("%s", issue)
+		}
+	}
+	for i, s := range cfg.Suppressions {
+		if s.Name == "" {
+			return nil, fmt.Errorf("%s: missing name", ruleLabel("suppressions", i, s.Regex))
+		}
+	}
+	return cfg.Suppressions, nil
+}
+
+// ImportResult lists what an import did, by suppression name.
+type ImportResult struct {
+	Added     []string `json:"added"`
+	Updated   []string `json:"updated"`   // Same name, new regex or reason (with overwrite)
+	Unchanged []string `json:"unchanged"` // Already present, by name or regex
+	Conflicts []string `json:"conflicts"` // Same name, different rule (without overwrite)
+}
+
+// Changed reports whether the import modifies the config.
+func (r ImportResult) Changed() bool {
+	return len(r.Added) > 0 || len(r.Updated) > 0
+}
+
+// ImportSuppressions merges suppressions into the pattern config at path,
+// creating it if needed. A suppression whose name is already in the config
+// is unchanged if the rule is the same, and otherwise a conflict that is
+// replaced only with overwrite. One whose regex is already used under
+// another name is unchanged. The rest are appended. The file's other
+// sections and comments are kept. With dryRun, nothing is written.
+func ImportSuppressions(path string, suppressions []Suppression, overwrite, dryRun bool) (ImportResult, error) {
+	var result ImportResult
+
+	doc, err := readDocument(path)
+	if err != nil {
+		return result, err
+	}
+	list, err := suppressionsNode(doc)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", path, err)
+	}
+
+	existing := make([]Suppression, len(list.Content))
+	for i, node := range list.Content {
+		if err := node.Decode(&existing[i]); err != nil {
+			return result, fmt.Errorf("%s: invalid suppression: %w", path, err)
+		}
+	}
+
+	for _, s := range suppressions {
+		index := slices.IndexFunc(existing, func(e Suppression) bool { return e.Name == s.Name })
+		switch {
+		case index >= 0 && existing[index].Regex == s.Regex && existing[index].Reason == s.Reason:
+			result.Unchanged = append(result.Unchanged, s.Name)
+		case index >= 0 && !overwrite:
+			result.Conflicts = append(result.Conflicts, s.Name)
+		case index >= 0:
+			node, err := suppressionNode(s)
+			if err != nil {
+				return result, err
+			}
+			list.Content[index], existing[index] = node, s
+			result.Updated = append(result.Updated, s.Name)
+		case slices.IndexFunc(existing, func(e Suppression) bool { return e.Regex == s.Regex }) >= 0:
+			result.Unchanged = append(result.Unchanged, s.Name)
+		default:
+			node, err := suppressionNode(s)
+			if err != nil {
+				return result, err
+			}
+			list.Content = append(list.Content, node)
+			existing = append(existing, s)
+			result.Added = append(result.Added, s.Name)
+		}
+	}
+
+	if dryRun || !result.Changed() {
+		return result, nil
+	}
+	data, err := encodeYAML(doc)
+	if err != nil {
+		return result, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return result, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return result, fmt.Errorf("failed to write pattern config: %w", err)
+	}
+	return result, nil
+}
+
+// readDocument parses the YAML document at path, or returns an empty one
+// if the file doesn't exist.
+func readDocument(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return doc, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pattern config: %w", err)
+	}
+	// Validate against the schema before editing
+	if _, err := Parse(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("%s: invalid pattern config: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+	}
+	return doc, nil
+}
+
+// suppressionsNode returns the suppressions sequence of doc, adding an
+// empty one if the config has none.
+func suppressionsNode(doc *yaml.Node) (*yaml.Node, error) {
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("pattern config is not a mapping")
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != suppressionsKey {
+			continue
+		}
+		value := root.Content[i+1]
+		if value.Tag == "!!null" {
+			*value = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		}
+		if value.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("suppressions is not a list")
+		}
+		return value, nil
+	}
+
+	value := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: suppressionsKey}, value)
+	return value, nil
+}
+
+func suppressionNode(s Suppression) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(s); err != nil {
+		return nil, fmt.Errorf("failed to encode suppression %q: %w", s.Name, err)
+	}
+	quoteRegexes(node)
+	return node, nil
+}
+
+// quoteRegexes single-quotes regex values, as the docs write them, so
+// backslashes read the same as in Go.
+func quoteRegexes(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "regex" && node.Content[i+1].Kind == yaml.ScalarNode {
+				node.Content[i+1].Style = yaml.SingleQuotedStyle
+			}
+		}
+	}
+	for _, child := range node.Content {
+		quoteRegexes(child)
+	}
+}
+
+// encodeYAML marshals v with the two-space indent the docs use.
+func encodeYAML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,134 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExportSuppressions_RoundTrip(t *testing.T) {
+	suppressions := []Suppression{
+		{Name: "teardown 404", Regex: "404 Not Found.*teardown", Reason: "expected during teardown", Pack: "team"},
+		{Name: "retry notice", Regex: `^retrying in \d+s`},
+	}
+
+	data, err := ExportSuppressions(suppressions)
+	if err != nil {
+		t.Fatalf("ExportSuppressions() error = %v", err)
+	}
+	if strings.Contains(string(data), "team") {
+		t.Errorf("export should not include pack names:\n%s", data)
+	}
+
+	got, err := ParseSuppressions(data)
+	if err != nil {
+		t.Fatalf("ParseSuppressions() error = %v\n%s", err, data)
+	}
+	suppressions[0].Pack = ""
+	if !slices.Equal(got, suppressions) {
+		t.Errorf("round trip = %+v, want %+v", got, suppressions)
+	}
+}
+
+func TestParseSuppressions_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"other rules", "patterns:\n  - name: p\n    regex: x\n    weight: 0.1\n", "only suppressions"},
+		{"bad regex", "suppressions:\n  - name: s\n    regex: '('\n", "does not compile"},
+		{"no name", "suppressions:\n  - regex: 'x'\n", "missing name"},
+		{"unknown field", "suppressions:\n  - name: s\n    regexp: 'x'\n", "invalid pattern config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSuppressions([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseSuppressions() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportSuppressions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.yaml")
+	original := `# Team patterns
+patterns:
+  - name: db pool
+    regex: 'pq: too many connections'
+    weight: 0.3
+suppressions:
+  - name: teardown 404 # keep me
+    regex: '404 Not Found.*teardown'
+`
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	incoming := []Suppression{
+		{Name: "teardown 404", Regex: "404 Not Found.*teardown"},
+		{Name: "same regex", Regex: "404 Not Found.*teardown"},
+		{Name: "retry notice", Regex: `^retrying`, Reason: "noise"},
+	}
+
+	result, err := ImportSuppressions(path, incoming, false, true)
+	if err != nil {
+		t.Fatalf("dry run error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != original {
+		t.Error("dry run modified the file")
+	}
+	if !slices.Equal(result.Added, []string{"retry notice"}) || len(result.Unchanged) != 2 {
+		t.Errorf("dry run result = %+v", result)
+	}
+
+	if _, err := ImportSuppressions(path, incoming, false, false); err != nil {
+		t.Fatalf("ImportSuppressions() error = %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if len(cfg.Patterns) != 1 || len(cfg.Suppressions) != 2 || cfg.Suppressions[1].Reason != "noise" {
+		t.Errorf("config after import = %+v", cfg)
+	}
+	data, _ := os.ReadFile(path)
+	for _, comment := range []string{"# Team patterns", "# keep me"} {
+		if !strings.Contains(string(data), comment) {
+			t.Errorf("import dropped comment %q:\n%s", comment, data)
+		}
+	}
+
+	changed := []Suppression{{Name: "retry notice", Regex: `^retrying in`}}
+	result, err = ImportSuppressions(path, changed, false, false)
+	if err != nil || !slices.Equal(result.Conflicts, []string{"retry notice"}) || result.Changed() {
+		t.Errorf("conflict result = %+v, %v", result, err)
+	}
+	result, err = ImportSuppressions(path, changed, true, false)
+	if err != nil || !slices.Equal(result.Updated, []string{"retry notice"}) {
+		t.Errorf("overwrite result = %+v, %v", result, err)
+	}
+	if cfg, _ := LoadFile(path); cfg.Suppressions[1].Regex != `^retrying in` {
+		t.Errorf("overwrite left %+v", cfg.Suppressions[1])
+	}
+}
+
+func TestImportSuppressions_NewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".destill", "patterns.yaml")
+
+	result, err := ImportSuppressions(path, []Suppression{{Name: "s", Regex: "x"}}, false, false)
+	if err != nil {
+		t.Fatalf("ImportSuppressions() error = %v", err)
+	}
+	if !slices.Equal(result.Added, []string{"s"}) {
+		t.Errorf("result = %+v", result)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil || len(cfg.Suppressions) != 1 {
+		t.Errorf("LoadFile() = %+v, %v", cfg, err)
+	}
+}
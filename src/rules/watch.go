@@ -0,0 +1,70 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultWatchInterval is how often long-running agents poll the pattern config.
+const DefaultWatchInterval = 5 * time.Second
+
+// Watch polls path every interval and calls onChange with a newly compiled
+// RuleSet whenever the effective config, including its packs, differs from
+// current. A deleted file reverts to an empty config. Invalid configs are
+// passed to onError and the previous rules stay in effect. Blocks until ctx
+// is done.
+func Watch(ctx context.Context, path string, interval time.Duration, current *RuleSet,
+	onChange func(*RuleSet), onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastHash := current.Hash()
+	lastErr := ""
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		rs, err := loadWatched(path)
+		if err != nil {
+			// Report each distinct problem once rather than on every poll
+			if err.Error() != lastErr {
+				lastErr = err.Error()
+				onError(err)
+			}
+			continue
+		}
+		lastErr = ""
+
+		if rs.Hash() != lastHash {
+			lastHash = rs.Hash()
+			onChange(rs)
+		}
+	}
+}
+
+// loadWatched loads and compiles the config at path, with the ignore file;
+// a missing file is an empty config.
+func loadWatched(path string) (*RuleSet, error) {
+	cfg := &Config{}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		if cfg, err = LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	cfg, err := withIgnoreFile(cfg)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := Compile(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rs, nil
+}
@@ -0,0 +1,82 @@
+package rules
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.yaml")
+	if err := os.WriteFile(path, []byte(sampleConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	current, err := Compile(cfg)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan *RuleSet, 1)
+	errs := make(chan error, 1)
+	go Watch(ctx, path, 10*time.Millisecond, current,
+		func(rs *RuleSet) { changes <- rs },
+		func(err error) { errs <- err })
+
+	// An invalid edit is reported and does not replace the rules
+	if err := os.WriteFile(path, []byte("patterns:\n  - name: bad\n    regex: '('\n    weight: 0.1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-errs:
+	case rs := <-changes:
+		t.Fatalf("Watch() applied invalid config %s", rs.Hash())
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for invalid config error")
+	}
+
+	// A valid edit is applied
+	if err := os.WriteFile(path, []byte("patterns:\n  - name: oom\n    regex: 'OOMKilled'\n    weight: 0.4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case rs := <-changes:
+		if rs.Hash() == current.Hash() {
+			t.Error("reloaded RuleSet has the same hash as the original")
+		}
+		if len(rs.Match("pod OOMKilled")) != 1 {
+			t.Error("reloaded RuleSet does not match new pattern")
+		}
+	case err := <-errs:
+		t.Fatalf("Watch() error = %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for reload")
+	}
+}
+
+func TestRuleSetHash(t *testing.T) {
+	a, _ := Compile(&Config{Patterns: []Pattern{{Name: "p", Regex: "boom", Weight: 0.2}}})
+	b, _ := Compile(&Config{Patterns: []Pattern{{Name: "p", Regex: "boom", Weight: 0.2}}})
+	c, _ := Compile(&Config{Patterns: []Pattern{{Name: "p", Regex: "boom", Weight: 0.3}}})
+
+	if a.Hash() == "" || a.Hash() != b.Hash() {
+		t.Errorf("identical configs hash to %q and %q, want equal non-empty", a.Hash(), b.Hash())
+	}
+	if a.Hash() == c.Hash() {
+		t.Error("different configs have the same hash")
+	}
+
+	var nilSet *RuleSet
+	if nilSet.Hash() != "" {
+		t.Errorf("nil RuleSet Hash() = %q, want empty", nilSet.Hash())
+	}
+}
@@ -0,0 +1,198 @@
+// Package sampledata synthesizes CI builds and their job logs for demos,
+// benchmarks, and evaluations. Generation is deterministic: the same Options
+// (including Seed) always produce the same build, byte for byte.
+package sampledata
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"destill-agent/src/provider"
+)
+
+// Options control the size and shape of a generated build. Zero values take
+// the defaults noted on each field.
+type Options struct {
+	Seed         int64    // Seed for the generator; builds with the same seed are identical
+	Jobs         int      // Number of jobs (default 6)
+	LinesPerJob  int      // Log lines per job (default 500)
+	ErrorDensity float64  // Fraction of a failed job's lines that are errors (default 0.01)
+	FailedJobs   int      // Number of failed jobs (default 1, at most Jobs)
+	Toolchains   []string // Toolchains the jobs cycle through (default all, see Toolchains)
+}
+
+// Build is a generated build: its metadata and the log of every job.
+type Build struct {
+	provider.Build
+	Logs map[string]string // job ID -> log content
+}
+
+// epoch is the start of every generated build, so timestamps don't depend on
+// the wall clock.
+var epoch = time.Date(2025, 1, 15, 9, 30, 0, 0, time.UTC)
+
+// Toolchains returns the names of the supported toolchains, sorted.
+func Toolchains() []string {
+	names := make([]string, 0, len(toolchains))
+	for name := range toolchains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generate synthesizes a build. Failed jobs come first and end in an error
+// with a non-zero exit code; passing jobs contain only noise, some of which
+// (warnings, retried connections) looks like errors, as in real logs. An
+// unknown toolchain is an error.
+func Generate(opts Options) (*Build, error) {
+	opts = withDefaults(opts)
+	for _, name := range opts.Toolchains {
+		if _, ok := toolchains[name]; !ok {
+			return nil, fmt.Errorf("unknown toolchain %q (known: %s)", name, strings.Join(Toolchains(), ", "))
+		}
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	build := &Build{
+		Build: provider.Build{
+			ID:        fmt.Sprintf("sample-%d", opts.Seed),
+			Number:    fmt.Sprintf("%d", 1000+rng.Intn(9000)),
+			URL:       fmt.Sprintf("https://buildkite.com/destill/sample/builds/%d", opts.Seed),
+			State:     "passed",
+			Branch:    "main",
+			Timestamp: epoch,
+		},
+		Logs: make(map[string]string, opts.Jobs),
+	}
+	if opts.FailedJobs > 0 {
+		build.State = "failed"
+	}
+
+	for i := 0; i < opts.Jobs; i++ {
+		tc := toolchains[opts.Toolchains[i%len(opts.Toolchains)]]
+		failed := i < opts.FailedJobs
+		job := provider.Job{
+			ID:        fmt.Sprintf("%s-job-%02d", build.ID, i+1),
+			Name:      fmt.Sprintf("%s %d", tc.jobName, i+1),
+			Type:      "script",
+			State:     "passed",
+			BuildID:   build.ID,
+			Timestamp: epoch.Add(time.Duration(i) * time.Second),
+			Duration:  time.Duration(60+rng.Intn(600)) * time.Second,
+		}
+		if failed {
+			job.State = "failed"
+			job.ExitCode = 1
+		}
+		build.Jobs = append(build.Jobs, job)
+		build.Logs[job.ID] = generateLog(rng, tc, job.Timestamp, opts.LinesPerJob, opts.ErrorDensity, failed)
+	}
+	return build, nil
+}
+
+// WriteDir writes each job's log to dir as <job name>.log, the layout the
+// local directory provider reads, so a generated build can be analyzed with
+// 'destill analyze <dir>'. dir is created if needed.
+func (b *Build) WriteDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	for _, job := range b.Jobs {
+		name := strings.ReplaceAll(job.Name, " ", "-") + ".log"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(b.Logs[job.ID]), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func withDefaults(opts Options) Options {
+	if opts.Jobs <= 0 {
+		opts.Jobs = 6
+	}
+	if opts.LinesPerJob <= 0 {
+		opts.LinesPerJob = 500
+	}
+	if opts.ErrorDensity <= 0 {
+		opts.ErrorDensity = 0.01
+	}
+	if opts.FailedJobs == 0 {
+		opts.FailedJobs = 1
+	}
+	if opts.FailedJobs < 0 {
+		opts.FailedJobs = 0
+	}
+	if opts.FailedJobs > opts.Jobs {
+		opts.FailedJobs = opts.Jobs
+	}
+	if len(opts.Toolchains) == 0 {
+		opts.Toolchains = Toolchains()
+	}
+	return opts
+}
+
+// generateLog returns a log of the given number of timestamped lines of
+// noise, with errors at the given density in failed jobs. A failed job
+// always ends in its toolchain's fatal error, so it has at least one finding.
+func generateLog(rng *rand.Rand, tc toolchain, start time.Time, lines int, density float64, failed bool) string {
+	var sb strings.Builder
+	ts := start
+	for i := 0; i < lines; i++ {
+		ts = ts.Add(time.Duration(rng.Intn(1500)) * time.Millisecond)
+		var line string
+		switch {
+		case failed && i == lines-1:
+			line = fill(rng, tc.fatal)
+		case failed && rng.Float64() < density:
+			line = fill(rng, pick(rng, tc.errors))
+		case rng.Float64() < 0.02:
+			line = fill(rng, pick(rng, commonNoise))
+		default:
+			line = fill(rng, pick(rng, tc.info))
+		}
+		fmt.Fprintf(&sb, "%s %s\n", ts.Format("2006-01-02T15:04:05.000Z"), line)
+	}
+	return sb.String()
+}
+
+func pick(rng *rand.Rand, lines []string) string {
+	return lines[rng.Intn(len(lines))]
+}
+
+// fill replaces the placeholders of a template line with random values:
+// {n} a number, {ms} a duration in milliseconds, {hex} a short hash, {ip} an
+// address, {pkg} a package or module name, and {test} a test name.
+func fill(rng *rand.Rand, tmpl string) string {
+	for strings.Contains(tmpl, "{") {
+		start := strings.Index(tmpl, "{")
+		end := strings.Index(tmpl[start:], "}")
+		if end < 0 {
+			break
+		}
+		var value string
+		switch tmpl[start+1 : start+end] {
+		case "n":
+			value = fmt.Sprintf("%d", rng.Intn(1000))
+		case "ms":
+			value = fmt.Sprintf("%d", 1+rng.Intn(5000))
+		case "hex":
+			value = fmt.Sprintf("%08x", rng.Uint32())
+		case "ip":
+			value = fmt.Sprintf("10.%d.%d.%d", rng.Intn(256), rng.Intn(256), 1+rng.Intn(254))
+		case "pkg":
+			value = pick(rng, packageNames)
+		case "test":
+			value = pick(rng, testNames)
+		default:
+			value = tmpl[start+1 : start+end]
+		}
+		tmpl = tmpl[:start] + value + tmpl[start+end+1:]
+	}
+	return tmpl
+}
@@ -0,0 +1,88 @@
+package sampledata
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	opts := Options{Seed: 42, Jobs: 4, LinesPerJob: 200, ErrorDensity: 0.05, FailedJobs: 2}
+
+	a, err := Generate(opts)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	b, err := Generate(opts)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Error("two builds generated with the same options differ")
+	}
+
+	opts.Seed = 43
+	c, err := Generate(opts)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if reflect.DeepEqual(a.Logs, c.Logs) {
+		t.Error("builds generated with different seeds have the same logs")
+	}
+}
+
+func TestGenerateShape(t *testing.T) {
+	build, err := Generate(Options{Seed: 1, Jobs: 5, LinesPerJob: 100, FailedJobs: 2, Toolchains: []string{"go", "pytest"}})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if build.State != "failed" {
+		t.Errorf("State = %q, want failed", build.State)
+	}
+	if len(build.Jobs) != 5 {
+		t.Fatalf("got %d jobs, want 5", len(build.Jobs))
+	}
+	for i, job := range build.Jobs {
+		wantState := "passed"
+		if i < 2 {
+			wantState = "failed"
+		}
+		if job.State != wantState {
+			t.Errorf("job %d State = %q, want %q", i, job.State, wantState)
+		}
+		if !strings.HasPrefix(job.Name, "go test") && !strings.HasPrefix(job.Name, "pytest") {
+			t.Errorf("job %d Name = %q, want a go or pytest job", i, job.Name)
+		}
+		if lines := strings.Count(build.Logs[job.ID], "\n"); lines != 100 {
+			t.Errorf("job %d has %d lines, want 100", i, lines)
+		}
+	}
+}
+
+func TestGenerateUnknownToolchain(t *testing.T) {
+	if _, err := Generate(Options{Toolchains: []string{"cobol"}}); err == nil {
+		t.Error("Generate() with an unknown toolchain succeeded, want an error")
+	}
+}
+
+func TestWriteDir(t *testing.T) {
+	build, err := Generate(Options{Seed: 7, Jobs: 2, LinesPerJob: 10})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "logs")
+	if err := build.WriteDir(dir); err != nil {
+		t.Fatalf("WriteDir() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("wrote %d files, want 2", len(entries))
+	}
+}
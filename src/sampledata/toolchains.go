@@ -0,0 +1,110 @@
+package sampledata
+
+// toolchain is the vocabulary of one kind of job's log.
+type toolchain struct {
+	jobName string
+	info    []string // Routine output
+	errors  []string // Errors sprinkled through a failed job
+	fatal   string   // The last line of a failed job
+}
+
+var toolchains = map[string]toolchain{
+	"go": {
+		jobName: "go test",
+		info: []string{
+			"=== RUN   {test}",
+			"--- PASS: {test} (0.{n}s)",
+			"ok  \tgithub.com/acme/{pkg}\t{ms}ms",
+			"go: downloading github.com/acme/{pkg} v1.{n}.0",
+			"?   \tgithub.com/acme/{pkg}\t[no test files]",
+		},
+		errors: []string{
+			"--- FAIL: {test} (0.{n}s)",
+			"    {pkg}_test.go:{n}: expected status 200, got 500",
+			"panic: runtime error: invalid memory address or nil pointer dereference",
+			"FAIL\tgithub.com/acme/{pkg}\t{ms}ms",
+		},
+		fatal: "FAIL: github.com/acme/{pkg} exited with status 1",
+	},
+	"pytest": {
+		jobName: "pytest",
+		info: []string{
+			"tests/test_{pkg}.py::{test} PASSED",
+			"collected {n} items",
+			"Requirement already satisfied: {pkg} in /usr/lib/python3/site-packages",
+			"tests/test_{pkg}.py::{test} SKIPPED (requires network)",
+		},
+		errors: []string{
+			"tests/test_{pkg}.py::{test} FAILED",
+			"E       AssertionError: assert {n} == {n}",
+			"E       KeyError: '{pkg}'",
+			"ERROR tests/test_{pkg}.py - ModuleNotFoundError: No module named '{pkg}'",
+		},
+		fatal: "ERROR: {n} failed, {n} passed in {ms}ms",
+	},
+	"node": {
+		jobName: "npm test",
+		info: []string{
+			"  ✓ {test} ({ms}ms)",
+			"added {n} packages in {ms}ms",
+			"> @acme/{pkg}@1.{n}.0 test",
+			"PASS src/{pkg}.test.ts",
+		},
+		errors: []string{
+			"  ✕ {test} ({ms}ms)",
+			"FAIL src/{pkg}.test.ts",
+			"TypeError: Cannot read properties of undefined (reading '{pkg}')",
+			"Error: connect ECONNREFUSED {ip}:5432",
+		},
+		fatal: "npm ERR! Test failed. See above for more details.",
+	},
+	"maven": {
+		jobName: "mvn verify",
+		info: []string{
+			"[INFO] Building {pkg} 1.{n}.0-SNAPSHOT",
+			"[INFO] Tests run: {n}, Failures: 0, Errors: 0, Skipped: 0",
+			"[INFO] Downloaded from central: https://repo.maven.apache.org/maven2/org/acme/{pkg}/1.{n}/{pkg}-1.{n}.jar",
+			"[INFO] --- maven-surefire-plugin:3.{n}.0:test (default-test) @ {pkg} ---",
+		},
+		errors: []string{
+			"[ERROR] {test}(org.acme.{pkg}Test)  Time elapsed: 0.{n} s  <<< FAILURE!",
+			"java.lang.NullPointerException: Cannot invoke \"{pkg}.get()\" because value is null",
+			"[ERROR] Tests run: {n}, Failures: {n}, Errors: 0, Skipped: 0",
+		},
+		fatal: "[ERROR] BUILD FAILURE",
+	},
+	"rust": {
+		jobName: "cargo test",
+		info: []string{
+			"   Compiling {pkg} v0.{n}.0",
+			"test {pkg}::tests::{test} ... ok",
+			"    Finished test [unoptimized + debuginfo] target(s) in {ms}ms",
+			"     Running unittests src/lib.rs (target/debug/deps/{pkg}-{hex})",
+		},
+		errors: []string{
+			"test {pkg}::tests::{test} ... FAILED",
+			"thread '{test}' panicked at src/{pkg}.rs:{n}:5:",
+			"error[E0308]: mismatched types",
+		},
+		fatal: "error: test failed, to rerun pass `-p {pkg} --lib`",
+	},
+}
+
+// commonNoise is output any job may print, including lines that look like
+// errors but aren't the cause of a failure.
+var commonNoise = []string{
+	"warning: {pkg} is deprecated and will be removed in a future release",
+	"Retrying connection to {ip}:443 (attempt {n})",
+	"Cache miss for key {hex}, downloading",
+	"Uploading artifact {pkg}-{hex}.tar.gz",
+	"WARN: slow response from {ip} after {ms}ms",
+}
+
+var packageNames = []string{
+	"auth", "billing", "cache", "config", "gateway", "ledger", "notify", "orders", "search", "storage",
+}
+
+var testNames = []string{
+	"TestCreateOrder", "TestRefund", "TestLogin", "TestRetryBackoff", "TestParseConfig",
+	"test_checkout", "test_invoice_totals", "renders the dashboard", "handles timeouts",
+}
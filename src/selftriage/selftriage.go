@@ -0,0 +1,236 @@
+// Package selftriage runs the analyzer over destill's own agent logs, so
+// pipeline failures such as broker disconnects are triaged like CI failures.
+package selftriage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"destill-agent/src/analyze"
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/ingest"
+	"destill-agent/src/logger"
+	"destill-agent/src/ranking"
+	"destill-agent/src/rules"
+)
+
+// RequestID is the request ID assigned to self-triage findings.
+const RequestID = "self-triage"
+
+// EnvPublishLogs enables publishing agent logs to contracts.TopicAgentLogs.
+const EnvPublishLogs = "DESTILL_PUBLISH_LOGS"
+
+var (
+	// consoleLinePattern matches ConsoleLogger output, e.g. "[ERROR] [IngestAgent] Failed to publish"
+	consoleLinePattern = regexp.MustCompile(`^\[(INFO|ERROR|DEBUG)\]\s?(.*)$`)
+
+	// componentPattern matches the component prefix agents put on their messages
+	componentPattern = regexp.MustCompile(`^\[([A-Za-z][\w-]*)\]`)
+)
+
+// operationalConfig boosts failures specific to running destill itself.
+var operationalConfig = rules.Config{
+	Patterns: []rules.Pattern{
+		{Name: "broker disconnect", Regex: `(?i)(broker|redpanda|kafka|kgo).*(disconnect|unreachable|refused|closed|EOF|timed? ?out)`, Weight: 0.3},
+		{Name: "publish/subscribe failure", Regex: `(?i)failed to (publish|subscribe|create consumer)`, Weight: 0.2},
+		{Name: "store failure", Regex: `(?i)(postgres|store|database).*(failed|refused|timeout)`, Weight: 0.2},
+		{Name: "message decode failure", Regex: `(?i)failed to (unmarshal|decode)`, Weight: 0.1},
+	},
+}
+
+// OperationalRules compiles the built-in operational patterns together with
+// the user's patterns and suppressions (user may be nil).
+func OperationalRules(user *rules.Config) (*rules.RuleSet, error) {
+	cfg := rules.Config{
+		Patterns: append([]rules.Pattern{}, operationalConfig.Patterns...),
+	}
+	if user != nil {
+		cfg.Patterns = append(cfg.Patterns, user.Patterns...)
+		cfg.Suppressions = user.Suppressions
+	}
+	return rules.Compile(&cfg)
+}
+
+// ParseLine parses one agent log line: either JSON (an AgentLogEntry, as
+// published to the log topic) or ConsoleLogger output. Other lines are kept
+// as info messages from defaultAgent. Blank lines are skipped.
+func ParseLine(line, defaultAgent string) (contracts.AgentLogEntry, bool) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return contracts.AgentLogEntry{}, false
+	}
+
+	if strings.HasPrefix(line, "{") {
+		var entry contracts.AgentLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err == nil && entry.Message != "" {
+			if entry.Agent == "" {
+				entry.Agent = defaultAgent
+			}
+			return entry, true
+		}
+	}
+
+	entry := contracts.AgentLogEntry{Agent: defaultAgent, Level: logger.LevelInfo, Message: line}
+	if matches := consoleLinePattern.FindStringSubmatch(line); matches != nil {
+		entry.Level = strings.ToLower(matches[1])
+		entry.Message = matches[2]
+	}
+	return entry, true
+}
+
+// ReadEntries parses every line of r with ParseLine.
+func ReadEntries(r io.Reader, defaultAgent string) ([]contracts.AgentLogEntry, error) {
+	var entries []contracts.AgentLogEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if entry, ok := ParseLine(scanner.Text(), defaultAgent); ok {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read agent logs: %w", err)
+	}
+	return entries, nil
+}
+
+// Since drops entries older than cutoff. Entries without a parseable
+// timestamp (e.g. console output) are kept.
+func Since(entries []contracts.AgentLogEntry, cutoff time.Time) []contracts.AgentLogEntry {
+	var kept []contracts.AgentLogEntry
+	for _, entry := range entries {
+		ts, err := time.Parse(time.RFC3339, entry.Timestamp)
+		if err == nil && ts.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// Analyze groups entries by component (the "[IngestAgent]" message prefix,
+// or the entry's agent) and analyzes each group as one job. Findings are
+// sorted by confidence, highest first.
+func Analyze(entries []contracts.AgentLogEntry, rs *rules.RuleSet) []contracts.TriageCard {
+	var order []string
+	groups := make(map[string][]contracts.AgentLogEntry)
+	for _, entry := range entries {
+		name := Component(entry)
+		if _, ok := groups[name]; !ok {
+			order = append(order, name)
+		}
+		groups[name] = append(groups[name], entry)
+	}
+
+	var cards []contracts.TriageCard
+	for _, name := range order {
+		group := groups[name]
+
+		lines := make([]string, len(group))
+		exitStatus := "0"
+		for i, entry := range group {
+			lines[i] = FormatLine(entry)
+			if entry.Level == logger.LevelError {
+				exitStatus = "1" // Treat an agent that logged errors like a failed job
+			}
+		}
+
+		metadata := map[string]string{
+			"provider":    "self",
+			"job_state":   "passed",
+			"exit_status": exitStatus,
+		}
+		if exitStatus != "0" {
+			metadata["job_state"] = "failed"
+		}
+
+		content := strings.Join(lines, "\n")
+		for _, chunk := range ingest.ChunkLog(content, RequestID, RequestID, name, name, metadata) {
+			for _, finding := range analyze.AnalyzeChunkWithRules(chunk, rs) {
+				cards = append(cards, analyze.ConvertToTriageCard(finding, chunk, RequestID))
+			}
+		}
+	}
+
+	cards = contracts.DeduplicateCards(cards)
+	ranking.SortCards(cards)
+	return cards
+}
+
+// Component returns the component an entry belongs to.
+func Component(entry contracts.AgentLogEntry) string {
+	if matches := componentPattern.FindStringSubmatch(entry.Message); matches != nil {
+		return matches[1]
+	}
+	if entry.Agent != "" {
+		return entry.Agent
+	}
+	return "unknown"
+}
+
+// FormatLine renders an entry the way ConsoleLogger does, so the analyzer
+// sees the level, e.g. "[ERROR] [IngestAgent] Failed to publish chunk".
+func FormatLine(entry contracts.AgentLogEntry) string {
+	return fmt.Sprintf("[%s] %s", strings.ToUpper(entry.Level), entry.Message)
+}
+
+// PublishLogs returns a logger that also publishes Info and Error messages to
+// contracts.TopicAgentLogs, keyed by agent. Publish failures are reported
+// to base at debug level only, so a broken broker cannot cause a log loop.
+func PublishLogs(ctx context.Context, base logger.Logger, brk broker.Broker, agent string) logger.Logger {
+	return logger.NewHookLogger(base, func(level, message string) {
+		if level == logger.LevelDebug {
+			return
+		}
+		data, err := json.Marshal(contracts.AgentLogEntry{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Agent:     agent,
+			Level:     level,
+			Message:   message,
+		})
+		if err != nil {
+			return
+		}
+		if err := brk.Publish(ctx, contracts.TopicAgentLogs, agent, data); err != nil {
+			base.Debug("failed to publish agent log: %v", err)
+		}
+	})
+}
+
+// ReadTopic collects entries from contracts.TopicAgentLogs until no message
+// arrives for idle, or ctx is done. groupID should be unique per run so the
+// topic is read from the beginning.
+func ReadTopic(ctx context.Context, brk broker.Broker, groupID string, idle time.Duration) ([]contracts.AgentLogEntry, error) {
+	msgs, err := brk.Subscribe(ctx, contracts.TopicAgentLogs, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", contracts.TopicAgentLogs, err)
+	}
+
+	var entries []contracts.AgentLogEntry
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+
+	for {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				return entries, nil
+			}
+			if entry, ok := ParseLine(string(msg.Value), msg.Key); ok {
+				entries = append(entries, entry)
+			}
+			timer.Reset(idle)
+		case <-timer.C:
+			return entries, nil
+		case <-ctx.Done():
+			return entries, nil
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package selftriage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/broker"
+	"destill-agent/src/contracts"
+	"destill-agent/src/logger"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		wantOK    bool
+		wantAgent string
+		wantLevel string
+		wantMsg   string
+	}{
+		{
+			name:      "console error",
+			line:      "[ERROR] [IngestAgent] Failed to publish chunk: broker closed",
+			wantOK:    true,
+			wantAgent: "ingest",
+			wantLevel: logger.LevelError,
+			wantMsg:   "[IngestAgent] Failed to publish chunk: broker closed",
+		},
+		{
+			name:      "json entry",
+			line:      `{"timestamp":"2025-01-01T00:00:00Z","agent":"analyze-agent","level":"info","message":"started"}`,
+			wantOK:    true,
+			wantAgent: "analyze-agent",
+			wantLevel: logger.LevelInfo,
+			wantMsg:   "started",
+		},
+		{
+			name:      "plain line",
+			line:      "panic: runtime error\r",
+			wantOK:    true,
+			wantAgent: "ingest",
+			wantLevel: logger.LevelInfo,
+			wantMsg:   "panic: runtime error",
+		},
+		{
+			name:   "blank line",
+			line:   "   ",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := ParseLine(tt.line, "ingest")
+			if ok != tt.wantOK {
+				t.Fatalf("ParseLine() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if entry.Agent != tt.wantAgent || entry.Level != tt.wantLevel || entry.Message != tt.wantMsg {
+				t.Errorf("ParseLine() = %+v, want agent=%q level=%q message=%q",
+					entry, tt.wantAgent, tt.wantLevel, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestSince(t *testing.T) {
+	entries := []contracts.AgentLogEntry{
+		{Timestamp: "2025-01-01T00:00:00Z", Message: "old"},
+		{Timestamp: "2025-01-02T00:00:00Z", Message: "new"},
+		{Message: "no timestamp"},
+	}
+
+	kept := Since(entries, time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	if len(kept) != 2 || kept[0].Message != "new" || kept[1].Message != "no timestamp" {
+		t.Errorf("Since() = %+v, want [new, no timestamp]", kept)
+	}
+}
+
+func TestAnalyzeRanksBrokerDisconnect(t *testing.T) {
+	rs, err := OperationalRules(nil)
+	if err != nil {
+		t.Fatalf("OperationalRules() error = %v", err)
+	}
+
+	log := strings.Join([]string{
+		"[INFO] [IngestAgent] Processing request req-1",
+		"[ERROR] [IngestAgent] Failed to publish chunk: broker connection refused",
+		"[INFO] [AnalyzeAgent] Listening for log chunks",
+		"[ERROR] [AnalyzeAgent] Failed to unmarshal chunk: unexpected end of JSON input",
+	}, "\n")
+	entries, err := ReadEntries(strings.NewReader(log), "agent")
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+
+	cards := Analyze(entries, rs)
+	if len(cards) == 0 {
+		t.Fatal("Analyze() returned no findings")
+	}
+
+	top := cards[0]
+	if top.JobName != "IngestAgent" {
+		t.Errorf("top finding component = %q, want IngestAgent", top.JobName)
+	}
+	if !strings.Contains(top.RawMessage, "broker connection refused") {
+		t.Errorf("top finding = %q, want the broker failure", top.RawMessage)
+	}
+	if top.RequestID != RequestID {
+		t.Errorf("RequestID = %q, want %q", top.RequestID, RequestID)
+	}
+	for i := 1; i < len(cards); i++ {
+		if cards[i].ConfidenceScore > cards[i-1].ConfidenceScore {
+			t.Errorf("findings not sorted by confidence at %d", i)
+		}
+	}
+}
+
+func TestPublishLogsAndReadTopic(t *testing.T) {
+	brk := broker.NewInMemoryBroker()
+	defer brk.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make(chan []contracts.AgentLogEntry, 1)
+	go func() {
+		entries, err := ReadTopic(ctx, brk, "test-group", 500*time.Millisecond)
+		if err != nil {
+			t.Errorf("ReadTopic() error = %v", err)
+		}
+		result <- entries
+	}()
+	time.Sleep(50 * time.Millisecond) // Let ReadTopic subscribe
+
+	log := PublishLogs(ctx, logger.NewSilentLogger(), brk, "ingest-agent")
+	log.Info("[IngestAgent] Starting...")
+	log.Debug("[IngestAgent] not published")
+	log.Error("[IngestAgent] Failed to publish chunk: %v", "broker closed")
+
+	entries := <-result
+	if len(entries) != 2 {
+		t.Fatalf("ReadTopic() returned %d entries, want 2: %+v", len(entries), entries)
+	}
+	if entries[1].Agent != "ingest-agent" || entries[1].Level != logger.LevelError ||
+		entries[1].Message != "[IngestAgent] Failed to publish chunk: broker closed" {
+		t.Errorf("entries[1] = %+v", entries[1])
+	}
+	if entries[1].Timestamp == "" {
+		t.Error("published entry has no timestamp")
+	}
+}
@@ -0,0 +1,231 @@
+// Package stats aggregates findings across builds to find infrastructure
+// patterns in failures, e.g. one error that mostly happens on one queue.
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"destill-agent/src/contracts"
+)
+
+// Options tunes Cluster. Zero values use the defaults.
+type Options struct {
+	// Dimensions are the finding metadata keys to pivot on
+	// (default contracts.RunnerMetadataKeys).
+	Dimensions []string
+
+	// MinJobs skips error signatures seen in fewer jobs (default 5).
+	MinJobs int
+
+	// MinShare is the fraction of a signature's jobs that must share a value
+	// for it to be an outlier (default 0.6).
+	MinShare float64
+
+	// MinLift is how many times more common a value must be for the signature
+	// than across all failing jobs (default 1.5).
+	MinLift float64
+}
+
+// Report is the result of Cluster. Counts are of distinct jobs (build URL and
+// job name), so a job that logs the same error many times counts once.
+type Report struct {
+	Jobs       int       `json:"jobs"`
+	Findings   int       `json:"findings"`
+	Dimensions []Pivot   `json:"dimensions"`
+	Outliers   []Outlier `json:"outliers"`
+}
+
+// Pivot breaks down the failing jobs by one dimension.
+type Pivot struct {
+	Dimension string       `json:"dimension"`
+	Values    []PivotValue `json:"values"`  // Most jobs first
+	Unknown   int          `json:"unknown"` // Jobs that don't report the dimension
+}
+
+// PivotValue is the number of failing jobs with one dimension value.
+type PivotValue struct {
+	Value string  `json:"value"`
+	Jobs  int     `json:"jobs"`
+	Share float64 `json:"share"` // Of the jobs that report the dimension
+}
+
+// Outlier is an error signature concentrated on one dimension value.
+type Outlier struct {
+	MessageHash string  `json:"message_hash"`
+	Signature   string  `json:"signature"` // Normalized message
+	Dimension   string  `json:"dimension"`
+	Value       string  `json:"value"`
+	Jobs        int     `json:"jobs"`     // Jobs with the signature and value
+	Total       int     `json:"total"`    // Jobs with the signature that report the dimension
+	Share       float64 `json:"share"`    // Jobs / Total
+	Baseline    float64 `json:"baseline"` // Share of all failing jobs with the value
+	Lift        float64 `json:"lift"`     // Share / Baseline
+}
+
+func (o Outlier) String() string {
+	return fmt.Sprintf("%.0f%% of %q (%d/%d jobs) ran on %s=%s, vs %.0f%% of all failing jobs",
+		o.Share*100, o.Signature, o.Jobs, o.Total, strings.TrimPrefix(o.Dimension, "runner_"), o.Value, o.Baseline*100)
+}
+
+// job is one failing job and its dimension values.
+type job struct {
+	dims map[string]string
+}
+
+// Cluster pivots cards by each dimension and reports error signatures whose
+// jobs are concentrated on one value well beyond that value's overall share.
+func Cluster(cards []contracts.TriageCard, opts Options) Report {
+	if len(opts.Dimensions) == 0 {
+		opts.Dimensions = contracts.RunnerMetadataKeys
+	}
+	if opts.MinJobs <= 0 {
+		opts.MinJobs = 5
+	}
+	if opts.MinShare <= 0 {
+		opts.MinShare = 0.6
+	}
+	if opts.MinLift <= 0 {
+		opts.MinLift = 1.5
+	}
+
+	jobs := make(map[string]*job)
+	signatures := make(map[string]map[string]bool) // Message hash -> job keys
+	normalized := make(map[string]string)          // Message hash -> normalized message
+	for _, card := range cards {
+		key := card.BuildURL + "\x00" + card.JobName
+		if _, ok := jobs[key]; !ok {
+			j := &job{dims: make(map[string]string)}
+			for _, dim := range opts.Dimensions {
+				if value := card.Metadata[dim]; value != "" {
+					j.dims[dim] = value
+				}
+			}
+			jobs[key] = j
+		}
+
+		hash := card.MessageHash
+		if hash == "" {
+			hash = card.NormalizedMsg
+		}
+		if signatures[hash] == nil {
+			signatures[hash] = make(map[string]bool)
+			normalized[hash] = card.NormalizedMsg
+		}
+		signatures[hash][key] = true
+	}
+
+	report := Report{Jobs: len(jobs), Findings: len(cards)}
+	for _, dim := range opts.Dimensions {
+		pivot := pivotJobs(dim, jobs, nil)
+		report.Dimensions = append(report.Dimensions, pivot)
+
+		baseline := make(map[string]float64, len(pivot.Values))
+		for _, v := range pivot.Values {
+			baseline[v.Value] = v.Share
+		}
+
+		for hash, jobKeys := range signatures {
+			if len(jobKeys) < opts.MinJobs {
+				continue
+			}
+			sigPivot := pivotJobs(dim, jobs, jobKeys)
+			total := len(jobKeys) - sigPivot.Unknown
+			if total < opts.MinJobs || len(sigPivot.Values) == 0 {
+				continue
+			}
+			top := sigPivot.Values[0]
+			base := baseline[top.Value]
+			if top.Share < opts.MinShare || base == 0 || top.Share/base < opts.MinLift {
+				continue
+			}
+			report.Outliers = append(report.Outliers, Outlier{
+				MessageHash: hash,
+				Signature:   normalized[hash],
+				Dimension:   dim,
+				Value:       top.Value,
+				Jobs:        top.Jobs,
+				Total:       total,
+				Share:       top.Share,
+				Baseline:    base,
+				Lift:        top.Share / base,
+			})
+		}
+	}
+
+	sort.Slice(report.Outliers, func(i, j int) bool {
+		a, b := report.Outliers[i], report.Outliers[j]
+		if a.Lift != b.Lift {
+			return a.Lift > b.Lift
+		}
+		if a.Jobs != b.Jobs {
+			return a.Jobs > b.Jobs
+		}
+		return a.MessageHash+a.Dimension < b.MessageHash+b.Dimension
+	})
+	return report
+}
+
+// pivotJobs counts jobs per value of dim: the jobs named in keys, or all
+// jobs if keys is nil.
+func pivotJobs(dim string, jobs map[string]*job, keys map[string]bool) Pivot {
+	pivot := Pivot{Dimension: dim}
+	counts := make(map[string]int)
+	known := 0
+	count := func(j *job) {
+		value, ok := j.dims[dim]
+		if !ok {
+			pivot.Unknown++
+			return
+		}
+		counts[value]++
+		known++
+	}
+	if keys == nil {
+		for _, j := range jobs {
+			count(j)
+		}
+	} else {
+		for key := range keys {
+			count(jobs[key])
+		}
+	}
+
+	for value, n := range counts {
+		pivot.Values = append(pivot.Values, PivotValue{Value: value, Jobs: n, Share: float64(n) / float64(known)})
+	}
+	sort.Slice(pivot.Values, func(i, j int) bool {
+		if pivot.Values[i].Jobs != pivot.Values[j].Jobs {
+			return pivot.Values[i].Jobs > pivot.Values[j].Jobs
+		}
+		return pivot.Values[i].Value < pivot.Values[j].Value
+	})
+	return pivot
+}
+
+// ParseDimensions expands a list of dimension names. Runner metadata keys may
+// be given without their runner_ prefix (queue, os, labels).
+func ParseDimensions(names []string) ([]string, error) {
+	var dims []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !strings.HasPrefix(name, "runner_") && name != contracts.MetadataContainerImage {
+			prefixed := "runner_" + name
+			for _, key := range contracts.RunnerMetadataKeys {
+				if key == prefixed {
+					name = prefixed
+					break
+				}
+			}
+		}
+		if strings.ContainsAny(name, " =") {
+			return nil, fmt.Errorf("invalid dimension %q", name)
+		}
+		dims = append(dims, name)
+	}
+	return dims, nil
+}
@@ -0,0 +1,94 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+// card builds a finding for job n of a build with the given signature and queue.
+func card(n int, signature, queue string) contracts.TriageCard {
+	c := contracts.TriageCard{
+		BuildURL:      fmt.Sprintf("https://buildkite.com/org/app/builds/%d", n),
+		JobName:       "tests",
+		MessageHash:   signature,
+		NormalizedMsg: signature,
+		Metadata:      map[string]string{},
+	}
+	if queue != "" {
+		c.Metadata[contracts.MetadataRunnerQueue] = queue
+	}
+	return c
+}
+
+func TestCluster(t *testing.T) {
+	var cards []contracts.TriageCard
+	n := 0
+	add := func(count int, signature, queue string) {
+		for i := 0; i < count; i++ {
+			n++
+			cards = append(cards, card(n, signature, queue))
+		}
+	}
+	// OOMs mostly on large-gpu; timeouts spread evenly
+	add(8, "OutOfMemoryError", "large-gpu")
+	add(2, "OutOfMemoryError", "default")
+	add(5, "connection timed out", "large-gpu")
+	add(15, "connection timed out", "default")
+	add(3, "connection timed out", "")
+	// A job logging the same error twice counts once
+	cards = append(cards, card(1, "OutOfMemoryError", "large-gpu"))
+
+	report := Cluster(cards, Options{Dimensions: []string{contracts.MetadataRunnerQueue}})
+
+	if report.Jobs != 33 || report.Findings != 34 {
+		t.Errorf("Jobs = %d, Findings = %d, want 33 and 34", report.Jobs, report.Findings)
+	}
+
+	pivot := report.Dimensions[0]
+	if pivot.Unknown != 3 || len(pivot.Values) != 2 || pivot.Values[0].Value != "default" || pivot.Values[0].Jobs != 17 {
+		t.Errorf("pivot = %+v", pivot)
+	}
+
+	if len(report.Outliers) != 1 {
+		t.Fatalf("Outliers = %+v, want only the OOMs", report.Outliers)
+	}
+	o := report.Outliers[0]
+	if o.Signature != "OutOfMemoryError" || o.Value != "large-gpu" || o.Jobs != 8 || o.Total != 10 {
+		t.Errorf("outlier = %+v", o)
+	}
+	if !strings.Contains(o.String(), "80% of \"OutOfMemoryError\" (8/10 jobs) ran on queue=large-gpu") {
+		t.Errorf("String() = %q", o.String())
+	}
+}
+
+func TestCluster_MinJobs(t *testing.T) {
+	cards := []contracts.TriageCard{
+		card(1, "rare", "gpu"), card(2, "rare", "gpu"),
+		card(3, "common", "cpu"), card(4, "common", "cpu"), card(5, "common", "cpu"),
+	}
+
+	if got := Cluster(cards, Options{}).Outliers; len(got) != 0 {
+		t.Errorf("Outliers with default MinJobs = %+v, want none", got)
+	}
+	if got := Cluster(cards, Options{MinJobs: 2}).Outliers; len(got) != 2 {
+		t.Errorf("Outliers with MinJobs 2 = %+v, want both signatures", got)
+	}
+}
+
+func TestParseDimensions(t *testing.T) {
+	dims, err := ParseDimensions([]string{"queue", " os ", "container_image", "pipeline_name", ""})
+	if err != nil {
+		t.Fatalf("ParseDimensions() error = %v", err)
+	}
+	want := "runner_queue,runner_os,container_image,pipeline_name"
+	if got := strings.Join(dims, ","); got != want {
+		t.Errorf("ParseDimensions() = %s, want %s", got, want)
+	}
+
+	if _, err := ParseDimensions([]string{"queue=gpu"}); err == nil {
+		t.Error("ParseDimensions() expected error for key=value")
+	}
+}
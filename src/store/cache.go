@@ -0,0 +1,192 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+// Cache defaults for long-running readers such as the MCP server.
+const (
+	DefaultCacheSize = 128             // Requests whose findings are kept
+	DefaultCacheTTL  = 5 * time.Second // How long findings are served before being read again
+)
+
+// CachedStore keeps the findings of recently read requests in memory, so
+// readers asking for the same request again and again don't query the
+// database each time. The least recently read request is evicted when
+// size requests are cached.
+//
+// Findings stored through the cache invalidate it. Writes by other
+// processes, such as the agents storing findings as they're analyzed, are
+// picked up within ttl.
+type CachedStore struct {
+	st   Persistent
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // request ID -> element of lru
+	lru     *list.List               // *cacheEntry, most recently read first
+}
+
+// cacheEntry is the findings of one request, as read at readAt.
+type cacheEntry struct {
+	requestID string
+	cards     []contracts.TriageCard
+	readAt    time.Time
+}
+
+// NewCachedStore creates a cache of up to size requests' findings, read
+// from st again after ttl.
+func NewCachedStore(st Persistent, size int, ttl time.Duration) *CachedStore {
+	return &CachedStore{
+		st:      st,
+		size:    max(size, 1),
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// GetFindings returns the cached findings of a request, reading them from
+// the store when they aren't cached or are older than the TTL. Errors
+// aren't cached.
+func (s *CachedStore) GetFindings(ctx context.Context, requestID string) ([]contracts.TriageCard, error) {
+	if cards, ok := s.cached(requestID); ok {
+		return cards, nil
+	}
+
+	cards, err := s.st.GetFindings(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	s.put(requestID, cards)
+	return slices.Clone(cards), nil
+}
+
+// GetByHash returns a finding from the cached findings of its request, or
+// else from the store.
+func (s *CachedStore) GetByHash(ctx context.Context, requestID, messageHash string) (contracts.TriageCard, error) {
+	if cards, ok := s.cached(requestID); ok {
+		for _, card := range cards {
+			if card.MessageHash == messageHash {
+				return card, nil
+			}
+		}
+	}
+	return s.st.GetByHash(ctx, requestID, messageHash)
+}
+
+// Store saves findings for a request and invalidates its cached findings.
+func (s *CachedStore) Store(ctx context.Context, requestID string, cards []contracts.TriageCard) error {
+	defer s.Invalidate(requestID)
+	return s.st.Store(ctx, requestID, cards)
+}
+
+// GetFindingsSince retrieves the findings stored at or after since from
+// the store; they aren't cached.
+func (s *CachedStore) GetFindingsSince(ctx context.Context, since time.Time) ([]contracts.TriageCard, error) {
+	return s.st.GetFindingsSince(ctx, since)
+}
+
+// GetLatestRequestByBuildURL returns the most recent request for a build
+// URL from the store.
+func (s *CachedStore) GetLatestRequestByBuildURL(ctx context.Context, buildURL string) (string, error) {
+	return s.st.GetLatestRequestByBuildURL(ctx, buildURL)
+}
+
+// GetRequestStatus returns the status of a request from the store.
+func (s *CachedStore) GetRequestStatus(ctx context.Context, requestID string) (RequestStatus, error) {
+	return s.st.GetRequestStatus(ctx, requestID)
+}
+
+// StoreChunks saves log chunks in the store.
+func (s *CachedStore) StoreChunks(ctx context.Context, chunks []contracts.LogChunk) error {
+	return s.st.StoreChunks(ctx, chunks)
+}
+
+// GetFindingContext reads the lines around a finding from the store.
+func (s *CachedStore) GetFindingContext(ctx context.Context, findingID string, before, after int) (FindingContext, error) {
+	return s.st.GetFindingContext(ctx, findingID, before, after)
+}
+
+// GetChunks returns the stored chunks of a request from the store; they
+// aren't cached (see ChunkReader).
+func (s *CachedStore) GetChunks(ctx context.Context, requestID string) ([]contracts.LogChunk, error) {
+	return getChunks(ctx, s.st, requestID)
+}
+
+// PreviousRequest returns the previous request for buildURL in the store.
+func (s *CachedStore) PreviousRequest(ctx context.Context, buildURL, requestID string) (string, error) {
+	return s.st.PreviousRequest(ctx, buildURL, requestID)
+}
+
+// Close closes the store.
+func (s *CachedStore) Close() error {
+	return s.st.Close()
+}
+
+// Invalidate drops the cached findings of a request, so the next read
+// goes to the store.
+func (s *CachedStore) Invalidate(requestID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[requestID]; ok {
+		s.lru.Remove(elem)
+		delete(s.entries, requestID)
+	}
+}
+
+// Purge drops every cached request.
+func (s *CachedStore) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = make(map[string]*list.Element)
+	s.lru.Init()
+}
+
+// cached returns a copy of the findings of a request read within the TTL.
+func (s *CachedStore) cached(requestID string) ([]contracts.TriageCard, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[requestID]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Since(entry.readAt) > s.ttl {
+		s.lru.Remove(elem)
+		delete(s.entries, requestID)
+		return nil, false
+	}
+	s.lru.MoveToFront(elem)
+	return slices.Clone(entry.cards), true
+}
+
+// put caches the findings of a request, evicting the least recently read
+// request when the cache is full.
+func (s *CachedStore) put(requestID string, cards []contracts.TriageCard) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &cacheEntry{requestID: requestID, cards: slices.Clone(cards), readAt: time.Now()}
+	if elem, ok := s.entries[requestID]; ok {
+		elem.Value = entry
+		s.lru.MoveToFront(elem)
+		return
+	}
+	s.entries[requestID] = s.lru.PushFront(entry)
+	for s.lru.Len() > s.size {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(*cacheEntry).requestID)
+	}
+}
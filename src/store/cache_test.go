@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+// countingStore is a Persistent InMemoryStore counting GetFindings calls.
+type countingStore struct {
+	*InMemoryStore
+	reads int
+}
+
+func (s *countingStore) GetFindings(ctx context.Context, requestID string) ([]contracts.TriageCard, error) {
+	s.reads++
+	return s.InMemoryStore.GetFindings(ctx, requestID)
+}
+
+func (s *countingStore) GetFindingsSince(ctx context.Context, since time.Time) ([]contracts.TriageCard, error) {
+	return nil, nil
+}
+
+func (s *countingStore) GetLatestRequestByBuildURL(ctx context.Context, buildURL string) (string, error) {
+	return "", nil
+}
+
+func (s *countingStore) GetRequestStatus(ctx context.Context, requestID string) (RequestStatus, error) {
+	return RequestStatus{}, nil
+}
+
+func TestCachedStore(t *testing.T) {
+	ctx := context.Background()
+	backing := &countingStore{InMemoryStore: NewInMemoryStore()}
+	st := NewCachedStore(backing, 2, time.Hour)
+
+	for _, id := range []string{"req-1", "req-2", "req-3"} {
+		backing.Store(ctx, id, []contracts.TriageCard{{ID: id, RequestID: id, MessageHash: "hash-1"}})
+	}
+
+	for range 3 {
+		if cards, err := st.GetFindings(ctx, "req-1"); err != nil || len(cards) != 1 {
+			t.Fatalf("GetFindings() = %d cards, error %v", len(cards), err)
+		}
+	}
+	if _, err := st.GetByHash(ctx, "req-1", "hash-1"); err != nil {
+		t.Fatalf("GetByHash() error = %v", err)
+	}
+	if backing.reads != 1 {
+		t.Errorf("store read %d times, want 1 (cached)", backing.reads)
+	}
+
+	// Storing through the cache invalidates the request
+	st.Store(ctx, "req-1", []contracts.TriageCard{{ID: "a"}, {ID: "b"}})
+	if cards, _ := st.GetFindings(ctx, "req-1"); len(cards) != 2 {
+		t.Errorf("GetFindings() after Store() = %d cards, want 2", len(cards))
+	}
+
+	// req-1 was read least recently, so req-3 evicts it
+	st.GetFindings(ctx, "req-2")
+	st.GetFindings(ctx, "req-3")
+	backing.reads = 0
+	st.GetFindings(ctx, "req-1")
+	st.GetFindings(ctx, "req-3")
+	if backing.reads != 1 {
+		t.Errorf("store read %d times, want 1 (req-1 evicted)", backing.reads)
+	}
+
+	// Errors aren't cached
+	if _, err := st.GetFindings(ctx, "req-missing"); err == nil {
+		t.Error("GetFindings(req-missing) error = nil")
+	}
+	backing.reads = 0
+	st.GetFindings(ctx, "req-missing")
+	if backing.reads != 1 {
+		t.Errorf("store read %d times for a missing request, want 1", backing.reads)
+	}
+}
+
+func TestCachedStoreTTL(t *testing.T) {
+	ctx := context.Background()
+	backing := &countingStore{InMemoryStore: NewInMemoryStore()}
+	backing.Store(ctx, "req-1", []contracts.TriageCard{{ID: "a"}})
+	st := NewCachedStore(backing, DefaultCacheSize, 10*time.Millisecond)
+
+	st.GetFindings(ctx, "req-1")
+	// Written by another process, e.g. an agent storing more findings
+	backing.Store(ctx, "req-1", []contracts.TriageCard{{ID: "a"}, {ID: "b"}})
+	if cards, _ := st.GetFindings(ctx, "req-1"); len(cards) != 1 {
+		t.Errorf("GetFindings() within the TTL = %d cards, want 1 (cached)", len(cards))
+	}
+	time.Sleep(20 * time.Millisecond)
+	if cards, _ := st.GetFindings(ctx, "req-1"); len(cards) != 2 {
+		t.Errorf("GetFindings() after the TTL = %d cards, want 2", len(cards))
+	}
+}
@@ -0,0 +1,291 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"destill-agent/src/contracts"
+)
+
+// Size caps for GetFindingContext, so a caller can't pull a whole log
+// through it. Requests beyond them are cut and marked truncated.
+const (
+	MaxContextLines     = 200      // Lines on each side of the finding
+	MaxContextLineBytes = 2 << 10  // Longer lines are cut
+	MaxContextBytes     = 64 << 10 // Across all lines
+)
+
+// MaxJobLogBytes caps GetJobLog, so a runaway log can't exhaust the
+// reader's memory. Later lines are left out and the log marked truncated.
+const MaxJobLogBytes = 32 << 20
+
+// MaxExcerptLines caps ReadExcerpt, which caps line and total sizes as
+// GetFindingContext does (MaxContextLineBytes, MaxContextBytes).
+const MaxExcerptLines = 500
+
+// ErrNoChunks is returned by GetFindingContext and GetChunks when the log
+// chunks of a finding or request weren't stored, such as for requests
+// analyzed before chunks were.
+var ErrNoChunks = errors.New("log chunks of the finding are not stored")
+
+// chunkHeader encodes the fields of chunk besides its content, stored
+// beside it so the chunk can be analyzed again (see ChunkReader).
+func chunkHeader(chunk contracts.LogChunk) ([]byte, error) {
+	chunk.Content = ""
+	return json.Marshal(chunk)
+}
+
+// storedChunk rebuilds a chunk from its columns and header. Chunks stored
+// without a header, before headers were, get only the job and section.
+func storedChunk(requestID, jobID, section string, index, lineStart, lineEnd int, content string, header []byte) (contracts.LogChunk, error) {
+	var chunk contracts.LogChunk
+	if len(header) > 0 {
+		if err := json.Unmarshal(header, &chunk); err != nil {
+			return contracts.LogChunk{}, fmt.Errorf("failed to decode chunk header: %w", err)
+		}
+	}
+	if chunk.Metadata == nil {
+		chunk.Metadata = map[string]string{"job_id": jobID}
+		if section != "" {
+			chunk.Metadata["step_index"] = section
+		}
+	}
+	chunk.RequestID, chunk.JobID, chunk.ChunkIndex = requestID, jobID, index
+	chunk.LineStart, chunk.LineEnd, chunk.Content = lineStart, lineEnd, content
+	return chunk, nil
+}
+
+// FindingContext is the log around a finding, read from its stored chunks.
+type FindingContext struct {
+	FindingID string   `json:"finding_id"`
+	Line      int      `json:"line"` // 1-based, within the job log (or its step)
+	Before    []string `json:"before"`
+	Message   string   `json:"message"`
+	After     []string `json:"after"`
+	Truncated bool     `json:"truncated,omitempty"` // A size cap cut the context short
+}
+
+// JobLog is the log section a finding was found in, read back from its
+// stored chunks.
+type JobLog struct {
+	FindingID string   `json:"finding_id"`
+	Line      int      `json:"line"`  // 1-based line of the finding, within Lines
+	Lines     []string `json:"lines"` // From line 1; lines no chunk covers are empty
+	Truncated bool     `json:"truncated,omitempty"` // MaxJobLogBytes cut the log short
+}
+
+// LogExcerpt is a range of lines of a job's log, or of one of its steps,
+// read back from its stored chunks.
+type LogExcerpt struct {
+	JobID      string   `json:"job_id"`
+	JobName    string   `json:"job_name,omitempty"`
+	Step       string   `json:"step,omitempty"`  // Step index, for a log chunked by step
+	Steps      []string `json:"steps,omitempty"` // Step indexes of the job, when it has several
+	StartLine  int      `json:"start_line"`      // 1-based line of Lines[0]
+	EndLine    int      `json:"end_line"`
+	TotalLines int      `json:"total_lines"`
+	Lines      []string `json:"lines"`               // Lines no chunk covers are empty
+	Truncated  bool     `json:"truncated,omitempty"` // A size cap cut the excerpt short
+}
+
+// chunkSection identifies the part of a job log a chunk was cut from.
+// Logs split by step are chunked per step, restarting line and chunk
+// numbers, so chunks are only comparable within a section.
+func chunkSection(metadata map[string]string) string {
+	return metadata["step_index"]
+}
+
+// chunkLine returns the line number of a finding in the chunk with index
+// chunkIndex, from its 0-based line within that chunk.
+func chunkLine(chunks []contracts.LogChunk, chunkIndex, lineInChunk int) (int, bool) {
+	for _, chunk := range chunks {
+		if chunk.ChunkIndex == chunkIndex {
+			return chunk.LineStart + lineInChunk, true
+		}
+	}
+	return 0, false
+}
+
+// clampContext limits the lines requested on each side to MaxContextLines.
+func clampContext(before, after int) (int, int, bool) {
+	before, after = max(before, 0), max(after, 0)
+	truncated := before > MaxContextLines || after > MaxContextLines
+	return min(before, MaxContextLines), min(after, MaxContextLines), truncated
+}
+
+// contextAt reads the lines around line from chunks of one section.
+// Chunks overlap, so a line may appear in several; any copy will do. Lines
+// closest to the finding are kept first when the byte cap is reached.
+func contextAt(findingID string, chunks []contracts.LogChunk, line, before, after int) (FindingContext, error) {
+	before, after, truncated := clampContext(before, after)
+
+	lines := make(map[int]string)
+	for _, chunk := range chunks {
+		for i, text := range strings.Split(chunk.Content, "\n") {
+			n := chunk.LineStart + i
+			if n > chunk.LineEnd {
+				break
+			}
+			if n >= line-before && n <= line+after {
+				lines[n] = strings.TrimSuffix(text, "\r")
+			}
+		}
+	}
+
+	message, ok := lines[line]
+	if !ok {
+		return FindingContext{}, ErrNoChunks
+	}
+	result := FindingContext{FindingID: findingID, Line: line, Truncated: truncated}
+	message, result.Truncated = capLine(message, result.Truncated)
+	result.Message = message
+	budget := MaxContextBytes - len(message)
+
+	// take adds the line at n if it's stored and fits the budget
+	take := func(n int, dst *[]string) bool {
+		text, ok := lines[n]
+		if !ok {
+			return false
+		}
+		text, result.Truncated = capLine(text, result.Truncated)
+		if len(text) > budget {
+			result.Truncated = true
+			return false
+		}
+		budget -= len(text)
+		*dst = append(*dst, text)
+		return true
+	}
+
+	moreBefore, moreAfter := before > 0, after > 0
+	for d := 1; moreBefore || moreAfter; d++ {
+		if moreBefore {
+			moreBefore = take(line-d, &result.Before) && d < before
+		}
+		if moreAfter {
+			moreAfter = take(line+d, &result.After) && d < after
+		}
+	}
+	slices.Reverse(result.Before)
+
+	return result, nil
+}
+
+// capLine cuts text to MaxContextLineBytes, setting truncated if it did.
+func capLine(text string, truncated bool) (string, bool) {
+	if len(text) <= MaxContextLineBytes {
+		return text, truncated
+	}
+	return strings.ToValidUTF8(text[:MaxContextLineBytes], ""), true
+}
+
+// assembleLog joins the chunks of one log section into its lines, up to
+// MaxJobLogBytes. Chunks overlap, so a line may appear in several; any
+// copy will do.
+func assembleLog(findingID string, chunks []contracts.LogChunk, line int) JobLog {
+	lines := make(map[int]string)
+	last := 0
+	for _, chunk := range chunks {
+		for i, text := range strings.Split(chunk.Content, "\n") {
+			n := chunk.LineStart + i
+			if n > chunk.LineEnd {
+				break
+			}
+			lines[n] = strings.TrimSuffix(text, "\r")
+			last = max(last, n)
+		}
+	}
+
+	result := JobLog{FindingID: findingID, Line: line}
+	budget := MaxJobLogBytes
+	for n := 1; n <= last; n++ {
+		text := lines[n]
+		if len(text) > budget {
+			result.Truncated = true
+			break
+		}
+		budget -= len(text)
+		result.Lines = append(result.Lines, text)
+	}
+	return result
+}
+
+// ReadExcerpt reads lines start through end of a job's log, by its ID or
+// name, from a request's chunks (see ChunkReader). step picks the section
+// of a log chunked by step, by default the job's first. Requests beyond the
+// end of the log, or MaxExcerptLines, are cut; the excerpt is marked
+// truncated only when a size cap cuts it. Returns ErrNoChunks when none of
+// the job's chunks are stored.
+func ReadExcerpt(chunks []contracts.LogChunk, job, step string, start, end int) (LogExcerpt, error) {
+	var jobChunks []contracts.LogChunk
+	for _, chunk := range chunks {
+		if chunk.JobID == job || chunk.JobName == job {
+			jobChunks = append(jobChunks, chunk)
+		}
+	}
+	if len(jobChunks) == 0 {
+		return LogExcerpt{}, ErrNoChunks
+	}
+
+	excerpt := LogExcerpt{JobID: jobChunks[0].JobID, JobName: jobChunks[0].JobName, Step: step}
+	for _, chunk := range jobChunks {
+		if section := chunkSection(chunk.Metadata); !slices.Contains(excerpt.Steps, section) {
+			excerpt.Steps = append(excerpt.Steps, section)
+		}
+	}
+	if step == "" {
+		excerpt.Step = excerpt.Steps[0]
+	} else if !slices.Contains(excerpt.Steps, step) {
+		return LogExcerpt{}, ErrNoChunks
+	}
+	if len(excerpt.Steps) < 2 {
+		excerpt.Steps = nil
+	}
+
+	lines := make(map[int]string)
+	for _, chunk := range jobChunks {
+		if chunkSection(chunk.Metadata) != excerpt.Step {
+			continue
+		}
+		for i, text := range strings.Split(chunk.Content, "\n") {
+			n := chunk.LineStart + i
+			if n > chunk.LineEnd {
+				break
+			}
+			lines[n] = strings.TrimSuffix(text, "\r")
+			excerpt.TotalLines = max(excerpt.TotalLines, n)
+		}
+	}
+
+	start = max(start, 1)
+	end = min(end, excerpt.TotalLines, start+MaxExcerptLines-1)
+	excerpt.StartLine = start
+	budget := MaxContextBytes
+	for n := start; n <= end; n++ {
+		text, truncated := capLine(lines[n], excerpt.Truncated)
+		if len(text) > budget {
+			excerpt.Truncated = true
+			break
+		}
+		excerpt.Truncated = truncated
+		budget -= len(text)
+		excerpt.Lines = append(excerpt.Lines, text)
+	}
+	excerpt.EndLine = start + len(excerpt.Lines) - 1
+	return excerpt, nil
+}
+
+// FindingLine returns the line of a finding within its job's log (or its
+// step's), from a request's chunks, and false if they don't cover it.
+func FindingLine(chunks []contracts.LogChunk, card contracts.TriageCard) (int, bool) {
+	var section []contracts.LogChunk
+	for _, chunk := range chunks {
+		if chunk.JobID == card.Metadata["job_id"] && chunkSection(chunk.Metadata) == chunkSection(card.Metadata) {
+			section = append(section, chunk)
+		}
+	}
+	return chunkLine(section, card.ChunkIndex, card.LineInChunk)
+}
@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+// numberedChunks splits a log of n lines "line 1".."line n" into chunks of
+// size lines that overlap by overlap lines, as the ingest chunker does.
+func numberedChunks(n, size, overlap int, metadata map[string]string) []contracts.LogChunk {
+	var chunks []contracts.LogChunk
+	for start := 1; ; start += size - overlap {
+		end := min(start+size-1, n)
+		var lines []string
+		for i := start; i <= end; i++ {
+			lines = append(lines, fmt.Sprintf("line %d", i))
+		}
+		chunks = append(chunks, contracts.LogChunk{
+			RequestID:  "req-1",
+			JobID:      "job-1",
+			ChunkIndex: len(chunks),
+			Content:    strings.Join(lines, "\n"),
+			LineStart:  start,
+			LineEnd:    end,
+			Metadata:   metadata,
+		})
+		if end == n {
+			return chunks
+		}
+	}
+}
+
+func TestContextAt(t *testing.T) {
+	chunks := numberedChunks(100, 30, 10, nil)
+
+	tests := []struct {
+		name          string
+		line          int
+		before, after int
+		wantFirst     string
+		wantLast      string
+		wantBefore    int
+		wantAfter     int
+		wantTruncated bool
+	}{
+		{"within one chunk", 10, 3, 3, "line 7", "line 13", 3, 3, false},
+		{"across chunk boundaries", 30, 25, 25, "line 5", "line 55", 25, 25, false},
+		{"clipped at log start", 3, 10, 2, "line 1", "line 5", 2, 2, false},
+		{"clipped at log end", 98, 1, 10, "line 97", "line 100", 1, 2, false},
+		{"no context", 50, 0, 0, "", "", 0, 0, false},
+		{"over the line cap", 50, MaxContextLines + 1, 1, "line 1", "line 51", 49, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := contextAt("f-1", chunks, tt.line, tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("contextAt() error = %v", err)
+			}
+			if want := fmt.Sprintf("line %d", tt.line); got.Message != want {
+				t.Errorf("Message = %q, want %q", got.Message, want)
+			}
+			if len(got.Before) != tt.wantBefore || len(got.After) != tt.wantAfter {
+				t.Fatalf("got %d before, %d after; want %d, %d", len(got.Before), len(got.After), tt.wantBefore, tt.wantAfter)
+			}
+			if len(got.Before) > 0 && got.Before[0] != tt.wantFirst {
+				t.Errorf("first line = %q, want %q", got.Before[0], tt.wantFirst)
+			}
+			if len(got.After) > 0 && got.After[len(got.After)-1] != tt.wantLast {
+				t.Errorf("last line = %q, want %q", got.After[len(got.After)-1], tt.wantLast)
+			}
+			if got.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", got.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestContextAtByteCaps(t *testing.T) {
+	long := strings.Repeat("x", MaxContextLineBytes*2)
+	var lines []string
+	for i := 0; i < 2*MaxContextLines+1; i++ {
+		lines = append(lines, long)
+	}
+	chunks := []contracts.LogChunk{{
+		Content:   strings.Join(lines, "\n"),
+		LineStart: 1,
+		LineEnd:   len(lines),
+	}}
+
+	got, err := contextAt("f-1", chunks, MaxContextLines+1, MaxContextLines, MaxContextLines)
+	if err != nil {
+		t.Fatalf("contextAt() error = %v", err)
+	}
+	if !got.Truncated {
+		t.Error("Truncated = false, want true")
+	}
+	size := len(got.Message)
+	for _, line := range append(got.Before, got.After...) {
+		if len(line) > MaxContextLineBytes {
+			t.Fatalf("line of %d bytes, want at most %d", len(line), MaxContextLineBytes)
+		}
+		size += len(line)
+	}
+	if size > MaxContextBytes {
+		t.Errorf("context of %d bytes, want at most %d", size, MaxContextBytes)
+	}
+	// Lines closest to the finding are kept on both sides
+	if len(got.Before) == 0 || len(got.After) == 0 || len(got.Before)-len(got.After) > 1 {
+		t.Errorf("got %d before, %d after; want the budget split evenly", len(got.Before), len(got.After))
+	}
+}
+
+func TestInMemoryStoreFindingContext(t *testing.T) {
+	st := NewInMemoryStore()
+	ctx := context.Background()
+
+	if err := st.StoreChunks(ctx, numberedChunks(100, 30, 10, map[string]string{"step_index": "0"})); err != nil {
+		t.Fatalf("StoreChunks() error = %v", err)
+	}
+	if err := st.StoreChunks(ctx, numberedChunks(40, 30, 10, map[string]string{"step_index": "1"})); err != nil {
+		t.Fatalf("StoreChunks() error = %v", err)
+	}
+
+	cards := []contracts.TriageCard{
+		// Line 2 of chunk 2 (lines 41-70) of step 0
+		{ID: "f-1", RequestID: "req-1", ChunkIndex: 2, LineInChunk: 1, Metadata: map[string]string{"job_id": "job-1", "step_index": "0"}},
+		// Line 5 of step 1
+		{ID: "f-2", RequestID: "req-1", ChunkIndex: 0, LineInChunk: 4, Metadata: map[string]string{"job_id": "job-1", "step_index": "1"}},
+		// Chunks of another job weren't stored
+		{ID: "f-3", RequestID: "req-1", ChunkIndex: 0, Metadata: map[string]string{"job_id": "job-2"}},
+	}
+	if err := st.Store(ctx, "req-1", cards); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	got, err := st.GetFindingContext(ctx, "f-1", 2, 2)
+	if err != nil {
+		t.Fatalf("GetFindingContext(f-1) error = %v", err)
+	}
+	if got.Line != 42 || got.Message != "line 42" || strings.Join(got.Before, ",") != "line 40,line 41" {
+		t.Errorf("GetFindingContext(f-1) = %+v, want line 42 of step 0", got)
+	}
+
+	got, err = st.GetFindingContext(ctx, "f-2", 10, 0)
+	if err != nil {
+		t.Fatalf("GetFindingContext(f-2) error = %v", err)
+	}
+	if got.Line != 5 || len(got.Before) != 4 {
+		t.Errorf("GetFindingContext(f-2) = %+v, want line 5 of step 1", got)
+	}
+
+	if _, err := st.GetFindingContext(ctx, "f-3", 2, 2); !errors.Is(err, ErrNoChunks) {
+		t.Errorf("GetFindingContext(f-3) error = %v, want ErrNoChunks", err)
+	}
+	var notFound ErrNotFound
+	if _, err := st.GetFindingContext(ctx, "f-9", 2, 2); !errors.As(err, &notFound) {
+		t.Errorf("GetFindingContext(f-9) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestInMemoryStoreJobLog(t *testing.T) {
+	st := NewInMemoryStore()
+	ctx := context.Background()
+	if err := st.StoreChunks(ctx, numberedChunks(100, 30, 10, nil)); err != nil {
+		t.Fatalf("StoreChunks() error = %v", err)
+	}
+	cards := []contracts.TriageCard{
+		{ID: "f-1", RequestID: "req-1", ChunkIndex: 2, LineInChunk: 1, Metadata: map[string]string{"job_id": "job-1"}},
+		{ID: "f-2", RequestID: "req-1", Metadata: map[string]string{"job_id": "job-2"}},
+	}
+	if err := st.Store(ctx, "req-1", cards); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	log, err := st.GetJobLog(ctx, "f-1")
+	if err != nil {
+		t.Fatalf("GetJobLog(f-1) error = %v", err)
+	}
+	if log.Line != 42 || len(log.Lines) != 100 || log.Lines[41] != "line 42" || log.Lines[99] != "line 100" || log.Truncated {
+		t.Errorf("GetJobLog(f-1) = line %d of %d lines, want line 42 of 100", log.Line, len(log.Lines))
+	}
+	if _, err := st.GetJobLog(ctx, "f-2"); !errors.Is(err, ErrNoChunks) {
+		t.Errorf("GetJobLog(f-2) error = %v, want ErrNoChunks", err)
+	}
+}
+
+func TestAssembleLogByteCap(t *testing.T) {
+	big := strings.Repeat("x", MaxJobLogBytes/2+1)
+	chunks := []contracts.LogChunk{{Content: "first\n" + big + "\n" + big, LineStart: 1, LineEnd: 3}}
+	log := assembleLog("f-1", chunks, 1)
+	if len(log.Lines) != 2 || !log.Truncated {
+		t.Errorf("assembleLog() = %d lines, truncated %v, want 2 lines truncated", len(log.Lines), log.Truncated)
+	}
+}
+
+func TestReadExcerpt(t *testing.T) {
+	chunks := numberedChunks(100, 30, 10, map[string]string{"job_id": "job-1"})
+	chunks = append(chunks, numberedChunks(5, 30, 10, map[string]string{"job_id": "job-1", "step_index": "2"})...)
+	for i := range chunks {
+		chunks[i].JobName = "tests"
+	}
+
+	excerpt, err := ReadExcerpt(chunks, "tests", "", 40, 45)
+	if err != nil {
+		t.Fatalf("ReadExcerpt() error = %v", err)
+	}
+	if excerpt.JobID != "job-1" || excerpt.StartLine != 40 || excerpt.EndLine != 45 || excerpt.TotalLines != 100 ||
+		len(excerpt.Lines) != 6 || excerpt.Lines[0] != "line 40" || excerpt.Truncated {
+		t.Errorf("ReadExcerpt(40-45) = %+v, want lines 40 to 45 of 100", excerpt)
+	}
+	if len(excerpt.Steps) != 2 || excerpt.Step != "" {
+		t.Errorf("steps = %q, step %q; want the default section and step 2", excerpt.Steps, excerpt.Step)
+	}
+
+	// Ranges are cut to the log and to MaxExcerptLines
+	excerpt, _ = ReadExcerpt(chunks, "job-1", "", 95, 200)
+	if excerpt.EndLine != 100 || len(excerpt.Lines) != 6 {
+		t.Errorf("ReadExcerpt(95-200) = lines %d to %d, want 95 to 100", excerpt.StartLine, excerpt.EndLine)
+	}
+	long := numberedChunks(MaxExcerptLines+100, MaxExcerptLines+100, 0, nil)
+	if excerpt, _ := ReadExcerpt(long, "job-1", "", 1, MaxExcerptLines+100); len(excerpt.Lines) != MaxExcerptLines {
+		t.Errorf("ReadExcerpt() returned %d lines, want at most %d", len(excerpt.Lines), MaxExcerptLines)
+	}
+
+	excerpt, _ = ReadExcerpt(chunks, "job-1", "2", 1, 10)
+	if excerpt.TotalLines != 5 || excerpt.Lines[4] != "line 5" {
+		t.Errorf("ReadExcerpt(step 2) = %+v, want the step's 5 lines", excerpt)
+	}
+	for _, tt := range []struct{ job, step string }{{"job-2", ""}, {"job-1", "7"}} {
+		if _, err := ReadExcerpt(chunks, tt.job, tt.step, 1, 10); !errors.Is(err, ErrNoChunks) {
+			t.Errorf("ReadExcerpt(%s, step %q) error = %v, want ErrNoChunks", tt.job, tt.step, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+// FallbackStore reads from a primary store, falling back to a second one
+// for requests and findings the primary doesn't have. Writes go to the
+// primary only. The MCP server uses it to serve its own analyses from
+// memory and those of the distributed agents from Postgres.
+type FallbackStore struct {
+	primary  Store
+	fallback Store
+}
+
+// NewFallbackStore creates a store reading from primary, then fallback.
+func NewFallbackStore(primary, fallback Store) *FallbackStore {
+	return &FallbackStore{primary: primary, fallback: fallback}
+}
+
+// GetFindings retrieves all findings for a request.
+func (s *FallbackStore) GetFindings(ctx context.Context, requestID string) ([]contracts.TriageCard, error) {
+	cards, err := s.primary.GetFindings(ctx, requestID)
+	if isNotFound(err) {
+		return s.fallback.GetFindings(ctx, requestID)
+	}
+	return cards, err
+}
+
+// GetByHash retrieves a single finding by message hash.
+func (s *FallbackStore) GetByHash(ctx context.Context, requestID, messageHash string) (contracts.TriageCard, error) {
+	card, err := s.primary.GetByHash(ctx, requestID, messageHash)
+	if isNotFound(err) {
+		return s.fallback.GetByHash(ctx, requestID, messageHash)
+	}
+	return card, err
+}
+
+// GetFindingContext reads the lines around a finding from the store that
+// has it. Finding IDs differ between stores, so an ID returned by one is
+// only found in that one.
+func (s *FallbackStore) GetFindingContext(ctx context.Context, findingID string, before, after int) (FindingContext, error) {
+	fc, err := s.primary.GetFindingContext(ctx, findingID, before, after)
+	if isNotFound(err) {
+		return s.fallback.GetFindingContext(ctx, findingID, before, after)
+	}
+	return fc, err
+}
+
+// PreviousRequest returns the previous request for buildURL in the primary
+// store, or else in the fallback.
+func (s *FallbackStore) PreviousRequest(ctx context.Context, buildURL, requestID string) (string, error) {
+	previous, err := s.primary.PreviousRequest(ctx, buildURL, requestID)
+	if previous != "" || err != nil {
+		return previous, err
+	}
+	return s.fallback.PreviousRequest(ctx, buildURL, requestID)
+}
+
+// GetFindingsSince retrieves the findings stored at or after since in the
+// fallback store, when it is Persistent; the primary's findings aren't
+// included. Without a Persistent fallback there are none.
+func (s *FallbackStore) GetFindingsSince(ctx context.Context, since time.Time) ([]contracts.TriageCard, error) {
+	persistent, ok := s.fallback.(Persistent)
+	if !ok {
+		return nil, nil
+	}
+	return persistent.GetFindingsSince(ctx, since)
+}
+
+// Store saves findings for a request in the primary store.
+func (s *FallbackStore) Store(ctx context.Context, requestID string, cards []contracts.TriageCard) error {
+	return s.primary.Store(ctx, requestID, cards)
+}
+
+// StoreChunks saves log chunks in the primary store.
+func (s *FallbackStore) StoreChunks(ctx context.Context, chunks []contracts.LogChunk) error {
+	return s.primary.StoreChunks(ctx, chunks)
+}
+
+// GetChunks returns the stored chunks of a request from the store that has
+// them (see ChunkReader).
+func (s *FallbackStore) GetChunks(ctx context.Context, requestID string) ([]contracts.LogChunk, error) {
+	chunks, err := getChunks(ctx, s.primary, requestID)
+	if errors.Is(err, ErrNoChunks) {
+		return getChunks(ctx, s.fallback, requestID)
+	}
+	return chunks, err
+}
+
+// getChunks returns the stored chunks of a request, or ErrNoChunks when st
+// isn't a ChunkReader.
+func getChunks(ctx context.Context, st Store, requestID string) ([]contracts.LogChunk, error) {
+	reader, ok := st.(ChunkReader)
+	if !ok {
+		return nil, ErrNoChunks
+	}
+	return reader.GetChunks(ctx, requestID)
+}
+
+// Close closes both stores.
+func (s *FallbackStore) Close() error {
+	return errors.Join(s.primary.Close(), s.fallback.Close())
+}
+
+// isNotFound reports whether err is an ErrNotFound.
+func isNotFound(err error) bool {
+	var notFound ErrNotFound
+	return errors.As(err, &notFound)
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestFallbackStore(t *testing.T) {
+	ctx := context.Background()
+	local, remote := NewInMemoryStore(), NewInMemoryStore()
+	st := NewFallbackStore(local, remote)
+
+	if err := st.Store(ctx, "req-local", []contracts.TriageCard{{ID: "l-1", RequestID: "req-local", MessageHash: "hash-1", RawMessage: "local"}}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	remote.Store(ctx, "req-remote", []contracts.TriageCard{{ID: "r-1", RequestID: "req-remote", MessageHash: "hash-1", RawMessage: "remote", ChunkIndex: 0, LineInChunk: 1}})
+	remote.StoreChunks(ctx, []contracts.LogChunk{{RequestID: "req-remote", Content: "before\nfailure\nafter", LineStart: 1, LineEnd: 3}})
+
+	tests := []struct {
+		requestID   string
+		wantMessage string
+		wantErr     bool
+	}{
+		{"req-local", "local", false},
+		{"req-remote", "remote", false},
+		{"req-missing", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.requestID, func(t *testing.T) {
+			card, err := st.GetByHash(ctx, tt.requestID, "hash-1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetByHash() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if card.RawMessage != tt.wantMessage {
+				t.Errorf("GetByHash() message = %q, want %q", card.RawMessage, tt.wantMessage)
+			}
+			cards, err := st.GetFindings(ctx, tt.requestID)
+			if (err != nil) != tt.wantErr || (!tt.wantErr && len(cards) != 1) {
+				t.Errorf("GetFindings() = %d cards, error %v", len(cards), err)
+			}
+		})
+	}
+
+	// Writes only go to the primary
+	if _, err := remote.GetFindings(ctx, "req-local"); err == nil {
+		t.Error("Store() wrote to the fallback store")
+	}
+
+	fc, err := st.GetFindingContext(ctx, "r-1", 1, 1)
+	if err != nil {
+		t.Fatalf("GetFindingContext() error = %v", err)
+	}
+	if fc.Message != "failure" || len(fc.Before) != 1 || len(fc.After) != 1 {
+		t.Errorf("GetFindingContext() = %+v, want the remote finding's context", fc)
+	}
+	if _, err := st.GetFindingContext(ctx, "l-1", 1, 1); !errors.Is(err, ErrNoChunks) {
+		t.Errorf("GetFindingContext(l-1) error = %v, want ErrNoChunks from the primary", err)
+	}
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"strconv"
+
+	"destill-agent/src/contracts"
+)
+
+// DefaultHistoryBuilds is how many recent builds of a pipeline cross-build
+// recurrence is counted over.
+const DefaultHistoryBuilds = 20
+
+// MarkBuildRecurrence records on each card how many of the last builds
+// builds of its pipeline its message appeared in (metadata builds_seen and
+// builds_window), when st keeps a message hash history (see HashHistorian).
+// Cards without a pipeline, or whose message isn't in the history, are
+// returned as is. Returns a new slice; the input cards are not modified.
+func MarkBuildRecurrence(ctx context.Context, st Store, cards []contracts.TriageCard, builds int) ([]contracts.TriageCard, error) {
+	historian, ok := st.(HashHistorian)
+	if !ok || len(cards) == 0 {
+		return cards, nil
+	}
+
+	// One lookup per pipeline; a request's findings are all of one
+	hashes := make(map[string][]string)
+	for _, card := range cards {
+		if pipeline := card.Metadata["pipeline_name"]; pipeline != "" {
+			hashes[pipeline] = append(hashes[pipeline], card.MessageHash)
+		}
+	}
+	history := make(map[string]map[string]HashHistory, len(hashes))
+	for pipeline, pipelineHashes := range hashes {
+		h, err := historian.GetHashHistory(ctx, pipeline, pipelineHashes, builds)
+		if err != nil {
+			return cards, err
+		}
+		history[pipeline] = h
+	}
+
+	result := make([]contracts.TriageCard, len(cards))
+	for i, card := range cards {
+		result[i] = card
+		h, ok := history[card.Metadata["pipeline_name"]][card.MessageHash]
+		if !ok {
+			continue
+		}
+		metadata := make(map[string]string, len(card.Metadata)+2)
+		for k, v := range card.Metadata {
+			metadata[k] = v
+		}
+		metadata[contracts.MetadataBuildsSeen] = strconv.Itoa(h.BuildsSeen)
+		metadata[contracts.MetadataBuildsWindow] = strconv.Itoa(h.Builds)
+		result[i].Metadata = metadata
+	}
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+// historyStore is an InMemoryStore with a fixed message hash history.
+type historyStore struct {
+	*InMemoryStore
+	history map[string]HashHistory
+	err     error
+}
+
+func (s historyStore) GetHashHistory(ctx context.Context, pipeline string, messageHashes []string, builds int) (map[string]HashHistory, error) {
+	if pipeline != "org/backend" {
+		return nil, nil
+	}
+	return s.history, s.err
+}
+
+func TestMarkBuildRecurrence(t *testing.T) {
+	ctx := context.Background()
+	cards := []contracts.TriageCard{
+		{MessageHash: "hash-a", Metadata: map[string]string{"pipeline_name": "org/backend"}},
+		{MessageHash: "hash-b", Metadata: map[string]string{"pipeline_name": "org/backend"}},
+		{MessageHash: "hash-a"},
+	}
+	st := historyStore{
+		InMemoryStore: NewInMemoryStore(),
+		history:       map[string]HashHistory{"hash-a": {MessageHash: "hash-a", BuildsSeen: 14, Builds: 20}},
+	}
+
+	marked, err := MarkBuildRecurrence(ctx, st, cards, DefaultHistoryBuilds)
+	if err != nil {
+		t.Fatalf("MarkBuildRecurrence() error = %v", err)
+	}
+	if seen, builds := marked[0].GetBuildRecurrence(); seen != 14 || builds != 20 {
+		t.Errorf("hash-a GetBuildRecurrence() = %d, %d, want 14, 20", seen, builds)
+	}
+	if seen, builds := marked[1].GetBuildRecurrence(); seen != 0 || builds != 0 {
+		t.Errorf("hash-b (not in history) GetBuildRecurrence() = %d, %d, want 0, 0", seen, builds)
+	}
+	if seen, _ := marked[2].GetBuildRecurrence(); seen != 0 {
+		t.Errorf("card without pipeline GetBuildRecurrence() = %d, want 0", seen)
+	}
+	if _, ok := cards[0].Metadata[contracts.MetadataBuildsSeen]; ok {
+		t.Error("MarkBuildRecurrence() modified the input cards")
+	}
+
+	// Stores without a history, and lookup errors, leave the cards as is
+	if got, err := MarkBuildRecurrence(ctx, NewInMemoryStore(), cards, DefaultHistoryBuilds); err != nil || len(got[0].Metadata) != 1 {
+		t.Errorf("MarkBuildRecurrence(in-memory) = %+v, %v, want cards unchanged", got, err)
+	}
+	st.err = errors.New("connection refused")
+	if got, err := MarkBuildRecurrence(ctx, st, cards, DefaultHistoryBuilds); err == nil || len(got[0].Metadata) != 1 {
+		t.Errorf("MarkBuildRecurrence() with failing store = %+v, %v, want error and cards unchanged", got, err)
+	}
+}
@@ -2,7 +2,10 @@
 package store
 
 import (
+	"cmp"
 	"context"
+	"slices"
+	"strings"
 	"sync"
 
 	"destill-agent/src/contracts"
@@ -12,8 +15,16 @@ import (
 // Used for local mode and MCP server.
 type InMemoryStore struct {
 	mu       sync.RWMutex
-	requests map[string][]contracts.TriageCard         // request_id -> cards
+	requests map[string][]contracts.TriageCard          // request_id -> cards
 	byHash   map[string]map[string]contracts.TriageCard // request_id -> message_hash -> card
+	byID     map[string]contracts.TriageCard            // finding id -> card
+	chunks   map[chunkKey][]contracts.LogChunk          // log section -> chunks
+	order    []string                                   // request IDs, first stored first
+}
+
+// chunkKey identifies the chunks of one section of a job log.
+type chunkKey struct {
+	requestID, jobID, section string
 }
 
 // NewInMemoryStore creates a new in-memory store.
@@ -21,6 +32,8 @@ func NewInMemoryStore() *InMemoryStore {
 	return &InMemoryStore{
 		requests: make(map[string][]contracts.TriageCard),
 		byHash:   make(map[string]map[string]contracts.TriageCard),
+		byID:     make(map[string]contracts.TriageCard),
+		chunks:   make(map[chunkKey][]contracts.LogChunk),
 	}
 }
 
@@ -29,12 +42,16 @@ func (s *InMemoryStore) Store(ctx context.Context, requestID string, cards []con
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if _, ok := s.requests[requestID]; !ok {
+		s.order = append(s.order, requestID)
+	}
 	s.requests[requestID] = cards
 
 	// Build lookup index by message_hash
 	hashMap := make(map[string]contracts.TriageCard)
 	for _, card := range cards {
 		hashMap[card.MessageHash] = card
+		s.byID[card.ID] = card
 	}
 	s.byHash[requestID] = hashMap
 
@@ -71,6 +88,126 @@ func (s *InMemoryStore) GetByHash(ctx context.Context, requestID, messageHash st
 	return card, nil
 }
 
+// StoreChunks saves log chunks, replacing any with the same index.
+func (s *InMemoryStore) StoreChunks(ctx context.Context, chunks []contracts.LogChunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, chunk := range chunks {
+		key := chunkKey{chunk.RequestID, chunk.JobID, chunkSection(chunk.Metadata)}
+		stored := s.chunks[key]
+		if i := slices.IndexFunc(stored, func(c contracts.LogChunk) bool { return c.ChunkIndex == chunk.ChunkIndex }); i >= 0 {
+			stored[i] = chunk
+			continue
+		}
+		s.chunks[key] = append(stored, chunk)
+	}
+	return nil
+}
+
+// GetFindingContext reads the lines around a finding from its stored chunks.
+func (s *InMemoryStore) GetFindingContext(ctx context.Context, findingID string, before, after int) (FindingContext, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	card, ok := s.byID[findingID]
+	if !ok {
+		return FindingContext{}, ErrNotFound{FindingID: findingID}
+	}
+	chunks := s.chunks[chunkKey{card.RequestID, card.Metadata["job_id"], chunkSection(card.Metadata)}]
+	line, ok := chunkLine(chunks, card.ChunkIndex, card.LineInChunk)
+	if !ok {
+		return FindingContext{}, ErrNoChunks
+	}
+	return contextAt(findingID, chunks, line, before, after)
+}
+
+// GetJobLog reads the whole log section of a finding from its stored chunks.
+func (s *InMemoryStore) GetJobLog(ctx context.Context, findingID string) (JobLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	card, ok := s.byID[findingID]
+	if !ok {
+		return JobLog{}, ErrNotFound{FindingID: findingID}
+	}
+	chunks := s.chunks[chunkKey{card.RequestID, card.Metadata["job_id"], chunkSection(card.Metadata)}]
+	line, ok := chunkLine(chunks, card.ChunkIndex, card.LineInChunk)
+	if !ok {
+		return JobLog{}, ErrNoChunks
+	}
+	return assembleLog(findingID, chunks, line), nil
+}
+
+// GetChunks returns the stored chunks of a request (see ChunkReader).
+func (s *InMemoryStore) GetChunks(ctx context.Context, requestID string) ([]contracts.LogChunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []chunkKey
+	for key := range s.chunks {
+		if key.requestID == requestID {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, ErrNoChunks
+	}
+	slices.SortFunc(keys, func(a, b chunkKey) int {
+		return cmp.Or(strings.Compare(a.jobID, b.jobID), strings.Compare(a.section, b.section))
+	})
+
+	var chunks []contracts.LogChunk
+	for _, key := range keys {
+		section := slices.Clone(s.chunks[key])
+		slices.SortFunc(section, func(a, b contracts.LogChunk) int { return a.ChunkIndex - b.ChunkIndex })
+		chunks = append(chunks, section...)
+	}
+	return chunks, nil
+}
+
+// PreviousRequest returns the last request for buildURL stored before
+// requestID.
+func (s *InMemoryStore) PreviousRequest(ctx context.Context, buildURL, requestID string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	end := slices.Index(s.order, requestID)
+	if end < 0 {
+		end = len(s.order)
+	}
+	for i := end - 1; i >= 0; i-- {
+		cards := s.requests[s.order[i]]
+		if len(cards) > 0 && cards[0].BuildURL == buildURL {
+			return s.order[i], nil
+		}
+	}
+	return "", nil
+}
+
+// PreviousOccurrence returns the last finding with card's message hash
+// stored before card's request, from another build of its pipeline.
+func (s *InMemoryStore) PreviousOccurrence(ctx context.Context, card contracts.TriageCard) (contracts.TriageCard, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	end := slices.Index(s.order, card.RequestID)
+	if end < 0 {
+		end = len(s.order)
+	}
+	pipeline := card.Metadata["pipeline_name"]
+	for i := end - 1; i >= 0; i-- {
+		previous, ok := s.byHash[s.order[i]][card.MessageHash]
+		if !ok || previous.BuildURL == card.BuildURL {
+			continue
+		}
+		if pipeline == "" || previous.Metadata["pipeline_name"] == pipeline {
+			return previous, nil
+		}
+	}
+	return contracts.TriageCard{}, ErrNotFound{MessageHash: card.MessageHash}
+}
+
 // Close is a no-op for in-memory store.
 func (s *InMemoryStore) Close() error {
 	return nil
@@ -96,3 +96,28 @@ func TestErrNotFound(t *testing.T) {
 		t.Errorf("ErrNotFound.Error() = %q, want %q", err.Error(), "finding not found: request_id=req-123, message_hash=hash-456")
 	}
 }
+
+func TestInMemoryStorePreviousOccurrence(t *testing.T) {
+	ctx := context.Background()
+	st := NewInMemoryStore()
+	finding := func(requestID, build, pipeline, message string) contracts.TriageCard {
+		return contracts.TriageCard{RequestID: requestID, BuildURL: build, MessageHash: "hash-1", RawMessage: message,
+			Metadata: map[string]string{"pipeline_name": pipeline}}
+	}
+	st.Store(ctx, "req-1", []contracts.TriageCard{finding("req-1", "builds/1", "org/api", "first")})
+	st.Store(ctx, "req-2", []contracts.TriageCard{finding("req-2", "builds/2", "org/web", "other pipeline")})
+	st.Store(ctx, "req-3", []contracts.TriageCard{finding("req-3", "builds/3", "org/api", "rerun")})
+	st.Store(ctx, "req-4", []contracts.TriageCard{finding("req-4", "builds/3", "org/api", "current")})
+
+	// Earlier runs of the same build and other pipelines are skipped
+	got, err := st.PreviousOccurrence(ctx, finding("req-4", "builds/3", "org/api", "current"))
+	if err != nil || got.RequestID != "req-1" {
+		t.Errorf("PreviousOccurrence() = %q, %v, want req-1", got.RequestID, err)
+	}
+
+	_, err = st.PreviousOccurrence(ctx, finding("req-1", "builds/1", "org/api", "first"))
+	var notFound ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("PreviousOccurrence(first) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvSkipMigrations turns off the migrations NewPostgresStore runs, for
+// databases whose schema is managed elsewhere.
+const EnvSkipMigrations = "DESTILL_SKIP_MIGRATIONS"
+
+// migrationLockID is the Postgres advisory lock held while migrating, so
+// agents starting together don't run the same migration twice.
+const migrationLockID = 0x64657374696c6c // "destill"
+
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+// Migration is one schema change, from a file named NNNN_name.sql.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// loadMigrations reads the migrations in dir of the embedded files, in
+// version order. Versions must start at 1 with no gaps.
+func loadMigrations(dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, rest, ok := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s (expected NNNN_name.sql)", entry.Name())
+		}
+		data, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: rest, SQL: string(data)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %s is out of sequence (expected version %d)", m, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// MigratePostgres brings the database up to the current schema, running
+// each migration not yet recorded in schema_migrations in its own
+// transaction. It returns the migrations it ran.
+func MigratePostgres(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	migrations, err := loadMigrations("migrations/postgres")
+	if err != nil {
+		return nil, err
+	}
+
+	// Hold the lock on one connection for the whole run
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return nil, fmt.Errorf("failed to lock for migrations: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	applied, err := appliedPostgresMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) > len(migrations) {
+		return nil, fmt.Errorf("database schema version %d is newer than this destill (%d)", len(applied), len(migrations))
+	}
+
+	var ran []Migration
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+		if err := runPostgresMigration(ctx, conn, m); err != nil {
+			return ran, err
+		}
+		ran = append(ran, m)
+	}
+	return ran, nil
+}
+
+// appliedPostgresMigrations returns the versions recorded in
+// schema_migrations, creating the table on first run. The table is only
+// created when missing, so an up-to-date schema needs no DDL privileges.
+func appliedPostgresMigrations(ctx context.Context, conn *sql.Conn) (map[int]bool, error) {
+	var exists bool
+	if err := conn.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check schema_migrations: %w", err)
+	}
+	if !exists {
+		_, err := conn.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version INTEGER PRIMARY KEY,
+				name TEXT NOT NULL,
+				applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+			)
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan migration version: %w", err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// runPostgresMigration runs m and records it, in one transaction.
+func runPostgresMigration(ctx context.Context, conn *sql.Conn, m Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %s: %w", m, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return fmt.Errorf("failed to run migration %s: %w", m, err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", m, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", m, err)
+	}
+	return nil
+}
+
+// migrateSQLite runs the migrations the database hasn't had yet, each in
+// its own transaction. PRAGMA user_version records how many have run.
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	migrations, err := loadMigrations("migrations/sqlite")
+	if err != nil {
+		return err
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this destill (%d)", version, len(migrations))
+	}
+
+	for _, m := range migrations[version:] {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %s: %w", m, err)
+		}
+		if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to run migration %s: %w", m, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", m.Version)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %s: %w", m, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %s: %w", m, err)
+		}
+	}
+	return nil
+}
+
+// skipMigrations reports whether DESTILL_SKIP_MIGRATIONS is set.
+func skipMigrations() bool {
+	skip, _ := strconv.ParseBool(os.Getenv(EnvSkipMigrations))
+	return skip
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	for _, dir := range []string{"migrations/postgres", "migrations/sqlite"} {
+		migrations, err := loadMigrations(dir)
+		if err != nil {
+			t.Fatalf("loadMigrations(%q) error = %v", dir, err)
+		}
+		if len(migrations) == 0 {
+			t.Fatalf("loadMigrations(%q) = no migrations", dir)
+		}
+		for i, m := range migrations {
+			if m.Version != i+1 || m.Name == "" || strings.TrimSpace(m.SQL) == "" {
+				t.Errorf("loadMigrations(%q)[%d] = %+v, want version %d with a name and SQL", dir, i, m, i+1)
+			}
+		}
+	}
+
+	if got := (Migration{Version: 3, Name: "log_chunks"}).String(); got != "0003_log_chunks" {
+		t.Errorf("Migration.String() = %q, want 0003_log_chunks", got)
+	}
+}
+
+func TestSkipMigrations(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"", false},
+		{"true", true},
+		{"1", true},
+		{"false", false},
+		{"nope", false},
+	}
+	for _, tt := range tests {
+		t.Setenv(EnvSkipMigrations, tt.value)
+		if got := skipMigrations(); got != tt.want {
+			t.Errorf("skipMigrations() with %q = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+-- Initial schema. IF NOT EXISTS lets databases set up from
+-- docker/init-db.sql before migrations existed adopt them.
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS findings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    request_id VARCHAR(255) NOT NULL,
+    build_url TEXT NOT NULL,
+    job_name VARCHAR(255) NOT NULL,
+    message_hash VARCHAR(64) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    confidence_score DECIMAL(3,2) NOT NULL,
+    raw_message TEXT NOT NULL,
+    normalized_message TEXT NOT NULL,
+    pre_context JSONB NOT NULL DEFAULT '[]',
+    post_context JSONB NOT NULL DEFAULT '[]',
+    source VARCHAR(50) NOT NULL DEFAULT 'buildkite',
+    line_number INTEGER,
+    chunk_index INTEGER,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    analyzed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT findings_confidence_check CHECK (confidence_score >= 0 AND confidence_score <= 1)
+);
+
+CREATE INDEX IF NOT EXISTS idx_findings_request_id ON findings(request_id);
+CREATE INDEX IF NOT EXISTS idx_findings_message_hash ON findings(message_hash);
+CREATE INDEX IF NOT EXISTS idx_findings_job_name ON findings(job_name);
+CREATE INDEX IF NOT EXISTS idx_findings_confidence ON findings(confidence_score DESC);
+CREATE INDEX IF NOT EXISTS idx_findings_created_at ON findings(created_at DESC);
+
+CREATE TABLE IF NOT EXISTS requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    request_id VARCHAR(255) UNIQUE NOT NULL,
+    build_url TEXT NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    chunks_total INTEGER DEFAULT 0,
+    chunks_processed INTEGER DEFAULT 0,
+    findings_count INTEGER DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT requests_status_check CHECK (status IN ('pending', 'processing', 'completed', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_requests_status ON requests(status);
+CREATE INDEX IF NOT EXISTS idx_requests_created_at ON requests(created_at DESC);
+
+CREATE OR REPLACE VIEW findings_summary AS
+SELECT
+    message_hash,
+    normalized_message,
+    MAX(severity) as severity,
+    AVG(confidence_score) as avg_confidence,
+    COUNT(*) as recurrence_count,
+    array_agg(DISTINCT job_name) as job_names,
+    MIN(created_at) as first_seen,
+    MAX(created_at) as last_seen
+FROM findings
+GROUP BY message_hash, normalized_message;
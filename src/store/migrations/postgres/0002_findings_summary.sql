@@ -0,0 +1,2 @@
+-- Optional LLM root-cause summary of a finding
+ALTER TABLE findings ADD COLUMN IF NOT EXISTS summary JSONB;
@@ -0,0 +1,14 @@
+-- Raw log chunks, for reading context around findings
+CREATE TABLE IF NOT EXISTS log_chunks (
+    request_id VARCHAR(255) NOT NULL,
+    job_id VARCHAR(255) NOT NULL,
+    section VARCHAR(20) NOT NULL DEFAULT '',  -- step_index for logs split by step
+    chunk_index INTEGER NOT NULL,
+    line_start INTEGER NOT NULL,
+    line_end INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (request_id, job_id, section, chunk_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_log_chunks_lines ON log_chunks(request_id, job_id, section, line_start, line_end);
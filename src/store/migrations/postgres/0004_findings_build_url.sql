@@ -0,0 +1,2 @@
+-- Previous analyses of a build
+CREATE INDEX IF NOT EXISTS idx_findings_build_url ON findings(build_url);
@@ -0,0 +1,9 @@
+-- One row per message per request, which PostgresStore.Store upserts on.
+-- Keep the most recently analyzed copy of existing duplicates.
+DELETE FROM findings a
+USING findings b
+WHERE a.request_id = b.request_id
+    AND a.message_hash = b.message_hash
+    AND (COALESCE(a.analyzed_at, 'epoch'), a.id::text) < (COALESCE(b.analyzed_at, 'epoch'), b.id::text);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_findings_request_hash ON findings(request_id, message_hash);
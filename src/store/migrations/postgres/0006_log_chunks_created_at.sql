@@ -0,0 +1,2 @@
+-- Pruning log chunks by age
+CREATE INDEX IF NOT EXISTS idx_log_chunks_created_at ON log_chunks(created_at);
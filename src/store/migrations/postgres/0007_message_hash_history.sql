@@ -0,0 +1,41 @@
+-- Builds of each pipeline each message was seen in, for cross-build
+-- recurrence (see PostgresStore.GetHashHistory). Filled by a trigger, so
+-- findings written by the Connect sink are recorded too. Re-runs of a
+-- build share its URL and count once.
+CREATE TABLE IF NOT EXISTS message_hash_history (
+    pipeline VARCHAR(255) NOT NULL,
+    build_url TEXT NOT NULL,
+    message_hash VARCHAR(64) NOT NULL,
+    request_id VARCHAR(255) NOT NULL,        -- First request the message was seen in
+    seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (pipeline, build_url, message_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_hash_history_hash ON message_hash_history(pipeline, message_hash);
+CREATE INDEX IF NOT EXISTS idx_message_hash_history_seen_at ON message_hash_history(pipeline, seen_at DESC);
+
+CREATE OR REPLACE FUNCTION record_message_hash_history() RETURNS trigger AS $$
+BEGIN
+    IF COALESCE(NEW.metadata->>'pipeline_name', '') <> '' THEN
+        INSERT INTO message_hash_history (pipeline, build_url, message_hash, request_id, seen_at)
+        VALUES (NEW.metadata->>'pipeline_name', NEW.build_url, NEW.message_hash, NEW.request_id,
+                COALESCE(NEW.created_at, CURRENT_TIMESTAMP))
+        ON CONFLICT DO NOTHING;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS findings_message_hash_history ON findings;
+CREATE TRIGGER findings_message_hash_history
+    AFTER INSERT ON findings
+    FOR EACH ROW EXECUTE FUNCTION record_message_hash_history();
+
+-- Findings stored before the history existed
+INSERT INTO message_hash_history (pipeline, build_url, message_hash, request_id, seen_at)
+SELECT DISTINCT ON (metadata->>'pipeline_name', build_url, message_hash)
+    metadata->>'pipeline_name', build_url, message_hash, request_id, COALESCE(created_at, CURRENT_TIMESTAMP)
+FROM findings
+WHERE COALESCE(metadata->>'pipeline_name', '') <> ''
+ORDER BY metadata->>'pipeline_name', build_url, message_hash, created_at
+ON CONFLICT DO NOTHING;
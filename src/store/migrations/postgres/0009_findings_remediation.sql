@@ -0,0 +1,2 @@
+-- Suggested fix for a well-known failure, from the remediation rules
+ALTER TABLE findings ADD COLUMN IF NOT EXISTS remediation JSONB;
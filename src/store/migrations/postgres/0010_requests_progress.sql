@@ -0,0 +1,5 @@
+-- Request progress, from the agents' progress updates (chunks_total and
+-- chunks_processed are in the initial schema)
+ALTER TABLE requests ADD COLUMN IF NOT EXISTS stage TEXT NOT NULL DEFAULT '';
+ALTER TABLE requests ADD COLUMN IF NOT EXISTS jobs_fetched INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE requests ADD COLUMN IF NOT EXISTS jobs_total INTEGER NOT NULL DEFAULT 0;
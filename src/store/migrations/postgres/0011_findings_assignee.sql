@@ -0,0 +1,2 @@
+-- Who is triaging a finding, set with 'destill assign' or the TUI
+ALTER TABLE findings ADD COLUMN IF NOT EXISTS assignee TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- The fields of each log chunk besides its content (job name, metadata,
+-- options), so 'destill reanalyze' can analyze it again
+ALTER TABLE log_chunks ADD COLUMN IF NOT EXISTS header JSONB;
@@ -0,0 +1,2 @@
+-- Language or tool ecosystem of a finding, e.g. go or docker
+ALTER TABLE findings ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT '';
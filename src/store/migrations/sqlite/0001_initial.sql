@@ -0,0 +1,47 @@
+-- Findings, log chunks, and requests, as in the Postgres migrations
+CREATE TABLE findings (
+    id TEXT PRIMARY KEY,
+    request_id TEXT NOT NULL,
+    build_url TEXT NOT NULL,
+    job_name TEXT NOT NULL,
+    message_hash TEXT NOT NULL,
+    severity TEXT NOT NULL,
+    confidence_score REAL NOT NULL CHECK (confidence_score >= 0 AND confidence_score <= 1),
+    raw_message TEXT NOT NULL,
+    normalized_message TEXT NOT NULL,
+    pre_context TEXT NOT NULL DEFAULT '[]',
+    post_context TEXT NOT NULL DEFAULT '[]',
+    source TEXT NOT NULL DEFAULT 'buildkite',
+    line_number INTEGER,
+    chunk_index INTEGER,
+    metadata TEXT NOT NULL DEFAULT '{}',
+    summary TEXT,
+    created_at TEXT NOT NULL,
+    analyzed_at TEXT NOT NULL,
+    UNIQUE (request_id, message_hash)
+);
+CREATE INDEX idx_findings_created_at ON findings(created_at);
+CREATE INDEX idx_findings_build_url ON findings(build_url);
+
+CREATE TABLE log_chunks (
+    request_id TEXT NOT NULL,
+    job_id TEXT NOT NULL,
+    section TEXT NOT NULL DEFAULT '',
+    chunk_index INTEGER NOT NULL,
+    line_start INTEGER NOT NULL,
+    line_end INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (request_id, job_id, section, chunk_index)
+);
+CREATE INDEX idx_log_chunks_lines ON log_chunks(request_id, job_id, section, line_start, line_end);
+
+CREATE TABLE requests (
+    request_id TEXT PRIMARY KEY,
+    build_url TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+    created_at TEXT NOT NULL,
+    completed_at TEXT
+);
+CREATE INDEX idx_requests_build_url ON requests(build_url, created_at);
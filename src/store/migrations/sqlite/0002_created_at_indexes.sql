@@ -0,0 +1,3 @@
+-- Pruning log chunks and requests by age
+CREATE INDEX idx_log_chunks_created_at ON log_chunks(created_at);
+CREATE INDEX idx_requests_created_at ON requests(created_at);
@@ -0,0 +1,2 @@
+-- Why a finding's context is shorter than asked, e.g. "truncated at chunk start"
+ALTER TABLE findings ADD COLUMN context_note TEXT NOT NULL DEFAULT '';
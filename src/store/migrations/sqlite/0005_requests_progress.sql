@@ -0,0 +1,6 @@
+-- Request progress, from the agents' progress updates
+ALTER TABLE requests ADD COLUMN stage TEXT NOT NULL DEFAULT '';
+ALTER TABLE requests ADD COLUMN jobs_fetched INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE requests ADD COLUMN jobs_total INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE requests ADD COLUMN chunks_processed INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE requests ADD COLUMN chunks_total INTEGER NOT NULL DEFAULT 0;
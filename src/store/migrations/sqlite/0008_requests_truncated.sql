@@ -0,0 +1,2 @@
+-- What the agents' limits left out of a request's analysis, one note per line
+ALTER TABLE requests ADD COLUMN truncated TEXT NOT NULL DEFAULT '';
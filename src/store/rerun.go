@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/ranking"
+)
+
+// MarkRerun compares the findings of one request with those of the previous
+// request for the same build in st (see ranking.MarkRerun). Without a
+// previous request, cards are returned as is and the summary is nil.
+func MarkRerun(ctx context.Context, st Store, cards []contracts.TriageCard) ([]contracts.TriageCard, *ranking.RerunSummary, error) {
+	if len(cards) == 0 {
+		return cards, nil, nil
+	}
+
+	previousID, err := st.PreviousRequest(ctx, cards[0].BuildURL, cards[0].RequestID)
+	if err != nil || previousID == "" {
+		return cards, nil, err
+	}
+	previous, err := st.GetFindings(ctx, previousID)
+	if err != nil {
+		return cards, nil, fmt.Errorf("failed to read previous request %s: %w", previousID, err)
+	}
+
+	marked, summary := ranking.MarkRerun(cards, previous, previousID)
+	return marked, &summary, nil
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestMarkRerun(t *testing.T) {
+	ctx := context.Background()
+	st := NewInMemoryStore()
+	build := "https://buildkite.com/org/pipeline/builds/1"
+	card := func(requestID, hash string) contracts.TriageCard {
+		return contracts.TriageCard{RequestID: requestID, BuildURL: build, MessageHash: hash}
+	}
+
+	st.Store(ctx, "req-1", []contracts.TriageCard{card("req-1", "hash-a")})
+	st.Store(ctx, "req-other", []contracts.TriageCard{{RequestID: "req-other", BuildURL: "https://buildkite.com/org/pipeline/builds/2", MessageHash: "hash-a"}})
+	st.Store(ctx, "req-2", []contracts.TriageCard{card("req-2", "hash-a"), card("req-2", "hash-b")})
+
+	first, _ := st.GetFindings(ctx, "req-1")
+	if _, summary, err := MarkRerun(ctx, st, first); err != nil || summary != nil {
+		t.Errorf("MarkRerun(first) = %+v, %v, want nil summary", summary, err)
+	}
+
+	second, _ := st.GetFindings(ctx, "req-2")
+	marked, summary, err := MarkRerun(ctx, st, second)
+	if err != nil {
+		t.Fatalf("MarkRerun() error = %v", err)
+	}
+	if summary == nil || summary.PreviousRequestID != "req-1" || summary.CarriedOver != 1 || summary.New != 1 {
+		t.Fatalf("MarkRerun() summary = %+v, want req-1 with 1 carried over, 1 new", summary)
+	}
+	if got := marked[1].Metadata[contracts.MetadataRerunStatus]; got != contracts.RerunNew {
+		t.Errorf("hash-b rerun status = %q, want %q", got, contracts.RerunNew)
+	}
+}
+
+func TestPreviousRequest(t *testing.T) {
+	ctx := context.Background()
+	local, remote := NewInMemoryStore(), NewInMemoryStore()
+	build := "https://buildkite.com/org/pipeline/builds/1"
+	remote.Store(ctx, "req-1", []contracts.TriageCard{{RequestID: "req-1", BuildURL: build}})
+	local.Store(ctx, "req-2", []contracts.TriageCard{{RequestID: "req-2", BuildURL: build}})
+	local.Store(ctx, "req-3", []contracts.TriageCard{{RequestID: "req-3", BuildURL: build}})
+
+	tests := []struct {
+		name      string
+		st        Store
+		requestID string
+		want      string
+	}{
+		{"in memory", local, "req-3", "req-2"},
+		{"in memory first", local, "req-2", ""},
+		{"fallback local", NewFallbackStore(local, remote), "req-3", "req-2"},
+		{"fallback remote", NewFallbackStore(local, remote), "req-2", "req-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.st.PreviousRequest(ctx, build, tt.requestID)
+			if err != nil {
+				t.Fatalf("PreviousRequest() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("PreviousRequest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvRetention is how long the analyze agents keep findings, log chunks,
+// and requests before pruning them, e.g. "30d". Unset means forever.
+const EnvRetention = "DESTILL_RETENTION"
+
+// DefaultPruneInterval is how often the agents prune with EnvRetention set.
+const DefaultPruneInterval = time.Hour
+
+// ParseRetention parses a retention period: a number of days ("30d") or
+// weeks ("2w"), or a Go duration ("36h").
+func ParseRetention(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+
+	var d time.Duration
+	if unit != 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid retention %q (expected e.g. 30d, 2w, or 36h)", s)
+		}
+		d = time.Duration(n) * unit
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid retention %q (expected e.g. 30d, 2w, or 36h)", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid retention %q: must be positive", s)
+	}
+	return d, nil
+}
+
+// RetentionFromEnv reads DESTILL_RETENTION, returning 0 when it's unset.
+func RetentionFromEnv() (time.Duration, error) {
+	value := os.Getenv(EnvRetention)
+	if strings.TrimSpace(value) == "" {
+		return 0, nil
+	}
+	d, err := ParseRetention(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", EnvRetention, err)
+	}
+	return d, nil
+}
+
+// prunedTable is a table Prune deletes from, by the column of when each row
+// was stored.
+type prunedTable struct {
+	name   string
+	column string
+}
+
+// prunedTables are the tables Prune deletes from in every store.
+var prunedTables = []prunedTable{
+	{"findings", "created_at"},
+	{"log_chunks", "created_at"},
+	{"requests", "created_at"},
+}
+
+// postgresPrunedTables adds the message_hash_history table Postgres keeps
+// (see PostgresStore.GetHashHistory), so recurrence counts don't cite
+// pruned builds.
+var postgresPrunedTables = append(prunedTables[:len(prunedTables):len(prunedTables)],
+	prunedTable{"message_hash_history", "seen_at"})
+
+// pruneTables runs query, a DELETE with a %s for the table name, one for
+// its column, and one placeholder for before, against each of tables in
+// one transaction.
+func pruneTables(ctx context.Context, db *sql.DB, tables []prunedTable, query string, before any) (PruneResult, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return PruneResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	counts := make(map[string]int64)
+	for _, table := range tables {
+		res, err := tx.ExecContext(ctx, fmt.Sprintf(query, table.name, table.column), before)
+		if err != nil {
+			return PruneResult{}, fmt.Errorf("failed to prune %s: %w", table.name, err)
+		}
+		if counts[table.name], err = res.RowsAffected(); err != nil {
+			return PruneResult{}, fmt.Errorf("failed to count pruned %s: %w", table.name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return PruneResult{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return PruneResult{
+		Findings:    counts["findings"],
+		LogChunks:   counts["log_chunks"],
+		Requests:    counts["requests"],
+		HashHistory: counts["message_hash_history"],
+	}, nil
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{" 2w ", 14 * 24 * time.Hour, false},
+		{"36h", 36 * time.Hour, false},
+		{"0d", 0, true},
+		{"-1d", 0, true},
+		{"d", 0, true},
+		{"30 days", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRetention(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRetention(%q) = %v, %v, want %v (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRetentionFromEnv(t *testing.T) {
+	t.Setenv(EnvRetention, "")
+	if d, err := RetentionFromEnv(); d != 0 || err != nil {
+		t.Errorf("RetentionFromEnv() unset = %v, %v, want 0, nil", d, err)
+	}
+	t.Setenv(EnvRetention, "7d")
+	if d, err := RetentionFromEnv(); d != 7*24*time.Hour || err != nil {
+		t.Errorf("RetentionFromEnv() = %v, %v, want 168h", d, err)
+	}
+	t.Setenv(EnvRetention, "soon")
+	if _, err := RetentionFromEnv(); err == nil {
+		t.Error("RetentionFromEnv() with invalid value = nil error")
+	}
+}
@@ -0,0 +1,802 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite" // SQLite driver (pure Go)
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/ranking"
+)
+
+// sqliteTimeFormat stores timestamps as fixed-width UTC text, so they sort
+// as strings.
+const sqliteTimeFormat = "2006-01-02 15:04:05.000000000"
+
+// requestStatusRank orders statuses so SetRequestStatus never moves one
+// back.
+var requestStatusRank = map[string]int{
+	RequestPending:    0,
+	RequestProcessing: 1,
+	RequestCompleted:  2,
+	RequestFailed:     2,
+}
+
+// addTruncated adds the notes not already in truncated, the requests
+// table's newline-separated notes (see RequestTracker.SetRequestTruncated).
+func addTruncated(truncated string, notes []string) string {
+	current := splitTruncated(truncated)
+	for _, note := range notes {
+		if note != "" && !slices.Contains(current, note) {
+			current = append(current, note)
+		}
+	}
+	return strings.Join(current, "\n")
+}
+
+// splitTruncated splits the requests table's truncation notes.
+func splitTruncated(truncated string) []string {
+	if truncated == "" {
+		return nil
+	}
+	return strings.Split(truncated, "\n")
+}
+
+// SQLiteStore is a SQLite implementation of Store, for running the agents
+// and 'destill view' on one machine without a Postgres server. Agents in
+// several processes may share the file.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if needed) the SQLite database at path and
+// migrates it to the current schema.
+func NewSQLiteStore(ctx context.Context, path string) (*SQLiteStore, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// One connection serializes the writes of this process; other processes
+	// wait on the busy timeout
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// findingColumns are the columns scanFinding reads.
+const findingColumns = `
+	id, request_id, build_url, job_name, message_hash, severity, confidence_score,
+	raw_message, normalized_message, pre_context, post_context,
+	source, line_number, chunk_index, metadata, summary, context_note, remediation, category, assignee, analyzed_at`
+
+// GetFindings retrieves all findings for a request, ordered by the
+// composite score of ranking.ConfiguredWeights.
+func (s *SQLiteStore) GetFindings(ctx context.Context, requestID string) ([]contracts.TriageCard, error) {
+	query := `SELECT ` + findingColumns + `
+		FROM findings
+		WHERE request_id = ?
+		ORDER BY ` + ranking.ConfiguredWeights().ScoreSQLite() + ` DESC, analyzed_at ASC`
+
+	return s.queryFindings(ctx, query, requestID)
+}
+
+// GetFindingsSince retrieves all findings stored at or after since, across
+// requests, for reports over a time window.
+func (s *SQLiteStore) GetFindingsSince(ctx context.Context, since time.Time) ([]contracts.TriageCard, error) {
+	query := `SELECT ` + findingColumns + `
+		FROM findings
+		WHERE created_at >= ?
+		ORDER BY created_at ASC`
+
+	return s.queryFindings(ctx, query, sqliteTime(since))
+}
+
+// queryFindings runs a findings query selecting findingColumns.
+func (s *SQLiteStore) queryFindings(ctx context.Context, query string, args ...any) ([]contracts.TriageCard, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query findings: %w", err)
+	}
+	defer rows.Close()
+
+	var findings []contracts.TriageCard
+	for rows.Next() {
+		finding, err := scanFinding(rows)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, finding)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating findings: %w", err)
+	}
+
+	return findings, nil
+}
+
+// scanFinding reads a row of findingColumns.
+func scanFinding(row interface{ Scan(...any) error }) (contracts.TriageCard, error) {
+	var finding contracts.TriageCard
+	var preContextJSON, postContextJSON, metadataJSON, summaryJSON, remediationJSON []byte
+	var lineNumber, chunkIndex sql.NullInt64
+	var analyzedAt string
+
+	err := row.Scan(
+		&finding.ID,
+		&finding.RequestID,
+		&finding.BuildURL,
+		&finding.JobName,
+		&finding.MessageHash,
+		&finding.Severity,
+		&finding.ConfidenceScore,
+		&finding.RawMessage,
+		&finding.NormalizedMsg,
+		&preContextJSON,
+		&postContextJSON,
+		&finding.Source,
+		&lineNumber,
+		&chunkIndex,
+		&metadataJSON,
+		&summaryJSON,
+		&finding.ContextNote,
+		&remediationJSON,
+		&finding.Category,
+		&finding.Assignee,
+		&analyzedAt,
+	)
+	if err != nil {
+		return contracts.TriageCard{}, err
+	}
+	finding.LineInChunk = int(lineNumber.Int64)
+	finding.ChunkIndex = int(chunkIndex.Int64)
+
+	if err := json.Unmarshal(preContextJSON, &finding.PreContext); err != nil {
+		return contracts.TriageCard{}, fmt.Errorf("failed to unmarshal pre_context: %w", err)
+	}
+	if err := json.Unmarshal(postContextJSON, &finding.PostContext); err != nil {
+		return contracts.TriageCard{}, fmt.Errorf("failed to unmarshal post_context: %w", err)
+	}
+	if err := json.Unmarshal(metadataJSON, &finding.Metadata); err != nil {
+		return contracts.TriageCard{}, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+	if finding.Summary, err = unmarshalSummary(summaryJSON); err != nil {
+		return contracts.TriageCard{}, err
+	}
+	if finding.Remediation, err = unmarshalRemediation(remediationJSON); err != nil {
+		return contracts.TriageCard{}, err
+	}
+	if t, err := time.Parse(sqliteTimeFormat, analyzedAt); err == nil {
+		finding.Timestamp = t.UTC().Format(time.RFC3339)
+	}
+
+	return finding, nil
+}
+
+// GetByHash retrieves a single finding by message hash.
+func (s *SQLiteStore) GetByHash(ctx context.Context, requestID, messageHash string) (contracts.TriageCard, error) {
+	query := `SELECT ` + findingColumns + `
+		FROM findings
+		WHERE request_id = ? AND message_hash = ?
+		LIMIT 1`
+
+	finding, err := scanFinding(s.db.QueryRowContext(ctx, query, requestID, messageHash))
+	if err == sql.ErrNoRows {
+		return contracts.TriageCard{}, ErrNotFound{RequestID: requestID, MessageHash: messageHash}
+	}
+	if err != nil {
+		return contracts.TriageCard{}, fmt.Errorf("failed to query finding: %w", err)
+	}
+	return finding, nil
+}
+
+// PreviousOccurrence returns the latest finding with card's message hash
+// from another build of its pipeline, analyzed before card.
+func (s *SQLiteStore) PreviousOccurrence(ctx context.Context, card contracts.TriageCard) (contracts.TriageCard, error) {
+	query := `SELECT ` + findingColumns + `
+		FROM findings
+		WHERE message_hash = ? AND build_url <> ? AND analyzed_at < ?
+			AND (? = '' OR json_extract(metadata, '$.pipeline_name') = ?)
+		ORDER BY analyzed_at DESC
+		LIMIT 1`
+
+	pipeline := card.Metadata["pipeline_name"]
+	finding, err := scanFinding(s.db.QueryRowContext(ctx, query,
+		card.MessageHash, card.BuildURL, sqliteTime(cardAnalyzedAt(card)), pipeline, pipeline))
+	if err == sql.ErrNoRows {
+		return contracts.TriageCard{}, ErrNotFound{MessageHash: card.MessageHash}
+	}
+	if err != nil {
+		return contracts.TriageCard{}, fmt.Errorf("failed to query previous occurrence: %w", err)
+	}
+	return finding, nil
+}
+
+// AssignFinding sets the assignee of a finding.
+func (s *SQLiteStore) AssignFinding(ctx context.Context, requestID, messageHash, assignee string) (contracts.TriageCard, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE findings SET assignee = ? WHERE request_id = ? AND message_hash = ?`,
+		assignee, requestID, messageHash)
+	if err != nil {
+		return contracts.TriageCard{}, fmt.Errorf("failed to assign finding: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return contracts.TriageCard{}, ErrNotFound{RequestID: requestID, MessageHash: messageHash}
+	}
+	return s.GetByHash(ctx, requestID, messageHash)
+}
+
+// PreviousRequest returns the request for buildURL whose first finding was
+// analyzed most recently before requestID's.
+func (s *SQLiteStore) PreviousRequest(ctx context.Context, buildURL, requestID string) (string, error) {
+	query := `
+		SELECT request_id
+		FROM findings
+		WHERE build_url = ? AND request_id <> ?
+		GROUP BY request_id
+		HAVING MIN(analyzed_at) < COALESCE(
+			(SELECT MIN(analyzed_at) FROM findings WHERE request_id = ?), ?)
+		ORDER BY MIN(analyzed_at) DESC
+		LIMIT 1`
+
+	var previous string
+	err := s.db.QueryRowContext(ctx, query, buildURL, requestID, requestID, sqliteTime(time.Now())).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query previous request: %w", err)
+	}
+	return previous, nil
+}
+
+// Store saves findings for a request, and marks the request as processing
+// in the requests table.
+func (s *SQLiteStore) Store(ctx context.Context, requestID string, cards []contracts.TriageCard) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO findings (
+			id, request_id, build_url, job_name, message_hash, severity, confidence_score,
+			raw_message, normalized_message, pre_context, post_context,
+			source, line_number, chunk_index, metadata, summary, context_note, remediation, category, created_at, analyzed_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (request_id, message_hash) DO UPDATE SET
+			confidence_score = excluded.confidence_score,
+			metadata = excluded.metadata,
+			summary = COALESCE(excluded.summary, findings.summary),
+			remediation = excluded.remediation,
+			category = excluded.category
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, card := range cards {
+		preContextJSON, err := json.Marshal(card.PreContext)
+		if err != nil {
+			return fmt.Errorf("failed to marshal pre_context: %w", err)
+		}
+		postContextJSON, err := json.Marshal(card.PostContext)
+		if err != nil {
+			return fmt.Errorf("failed to marshal post_context: %w", err)
+		}
+		metadataJSON, err := json.Marshal(card.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		var summaryJSON any // NULL without a summary
+		if card.Summary != nil {
+			data, err := json.Marshal(card.Summary)
+			if err != nil {
+				return fmt.Errorf("failed to marshal summary: %w", err)
+			}
+			summaryJSON = string(data)
+		}
+		var remediationJSON any // NULL without a remediation
+		if card.Remediation != nil {
+			data, err := json.Marshal(card.Remediation)
+			if err != nil {
+				return fmt.Errorf("failed to marshal remediation: %w", err)
+			}
+			remediationJSON = string(data)
+		}
+
+		analyzedAt := now
+		if card.Timestamp != "" {
+			if t, err := time.Parse(time.RFC3339, card.Timestamp); err == nil {
+				analyzedAt = t
+			}
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			uuid.NewString(),
+			card.RequestID,
+			card.BuildURL,
+			card.JobName,
+			card.MessageHash,
+			card.Severity,
+			card.ConfidenceScore,
+			card.RawMessage,
+			card.NormalizedMsg,
+			string(preContextJSON),
+			string(postContextJSON),
+			card.Source,
+			card.LineInChunk,
+			card.ChunkIndex,
+			string(metadataJSON),
+			summaryJSON,
+			card.ContextNote,
+			remediationJSON,
+			card.Category,
+			sqliteTime(now),
+			sqliteTime(analyzedAt),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert finding: %w", err)
+		}
+
+		if err := setRequestStatus(ctx, tx, card.RequestID, card.BuildURL, RequestProcessing); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// StoreChunks saves log chunks. Chunks already stored are left as is.
+func (s *SQLiteStore) StoreChunks(ctx context.Context, chunks []contracts.LogChunk) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO log_chunks (
+			request_id, job_id, section, chunk_index, line_start, line_end, content, header, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (request_id, job_id, section, chunk_index) DO NOTHING
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := sqliteTime(time.Now())
+	for _, chunk := range chunks {
+		header, err := chunkHeader(chunk)
+		if err != nil {
+			return fmt.Errorf("failed to encode chunk header: %w", err)
+		}
+		_, err = stmt.ExecContext(ctx,
+			chunk.RequestID,
+			chunk.JobID,
+			chunkSection(chunk.Metadata),
+			chunk.ChunkIndex,
+			chunk.LineStart,
+			chunk.LineEnd,
+			chunk.Content,
+			string(header),
+			now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert chunk: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetChunks returns the stored chunks of a request (see ChunkReader).
+func (s *SQLiteStore) GetChunks(ctx context.Context, requestID string) ([]contracts.LogChunk, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT job_id, section, chunk_index, line_start, line_end, content, header
+		FROM log_chunks
+		WHERE request_id = ?
+		ORDER BY job_id, section, chunk_index
+	`, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []contracts.LogChunk
+	for rows.Next() {
+		var jobID, section, content string
+		var index, lineStart, lineEnd int
+		var header []byte
+		if err := rows.Scan(&jobID, &section, &index, &lineStart, &lineEnd, &content, &header); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		chunk, err := storedChunk(requestID, jobID, section, index, lineStart, lineEnd, content, header)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chunks: %w", err)
+	}
+	if len(chunks) == 0 {
+		return nil, ErrNoChunks
+	}
+	return chunks, nil
+}
+
+// GetFindingContext reads the lines around a finding from the stored
+// chunks of its log section that overlap the requested range.
+func (s *SQLiteStore) GetFindingContext(ctx context.Context, findingID string, before, after int) (FindingContext, error) {
+	clampedBefore, clampedAfter, _ := clampContext(before, after)
+	chunks, line, err := s.findingChunks(ctx, findingID, clampedBefore, clampedAfter)
+	if err != nil {
+		return FindingContext{}, err
+	}
+	return contextAt(findingID, chunks, line, before, after)
+}
+
+// GetJobLog reads the whole log section of a finding from its stored
+// chunks, up to MaxJobLogBytes.
+func (s *SQLiteStore) GetJobLog(ctx context.Context, findingID string) (JobLog, error) {
+	chunks, line, err := s.findingChunks(ctx, findingID, math.MaxInt32, math.MaxInt32)
+	if err != nil {
+		return JobLog{}, err
+	}
+	return assembleLog(findingID, chunks, line), nil
+}
+
+// findingChunks locates a finding within its log section, returning its
+// line and the section's stored chunks overlapping before and after lines
+// around it.
+func (s *SQLiteStore) findingChunks(ctx context.Context, findingID string, before, after int) ([]contracts.LogChunk, int, error) {
+	// Locate the finding within its log via the chunk it was found in
+	query := `
+		SELECT f.request_id, f.line_number, c.job_id, c.section, c.line_start
+		FROM findings f
+		LEFT JOIN log_chunks c
+			ON c.request_id = f.request_id
+			AND c.job_id = COALESCE(json_extract(f.metadata, '$.job_id'), '')
+			AND c.section = COALESCE(json_extract(f.metadata, '$.step_index'), '')
+			AND c.chunk_index = f.chunk_index
+		WHERE f.id = ?
+		LIMIT 1`
+
+	var requestID string
+	var lineInChunk, lineStart sql.NullInt64
+	var jobID, section sql.NullString
+	err := s.db.QueryRowContext(ctx, query, findingID).Scan(&requestID, &lineInChunk, &jobID, &section, &lineStart)
+	if err == sql.ErrNoRows {
+		return nil, 0, ErrNotFound{FindingID: findingID}
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query finding: %w", err)
+	}
+	if !lineStart.Valid {
+		return nil, 0, ErrNoChunks
+	}
+
+	line := int(lineStart.Int64 + lineInChunk.Int64)
+	from, to := max(line-before, 0), min(line+after, math.MaxInt32)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT chunk_index, line_start, line_end, content
+		FROM log_chunks
+		WHERE request_id = ? AND job_id = ? AND section = ?
+			AND line_end >= ? AND line_start <= ?
+		ORDER BY chunk_index`,
+		requestID, jobID.String, section.String, from, to)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []contracts.LogChunk
+	for rows.Next() {
+		var chunk contracts.LogChunk
+		if err := rows.Scan(&chunk.ChunkIndex, &chunk.LineStart, &chunk.LineEnd, &chunk.Content); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating chunks: %w", err)
+	}
+
+	return chunks, line, nil
+}
+
+// GetLatestRequestByBuildURL retrieves the most recent request ID for a given build URL.
+func (s *SQLiteStore) GetLatestRequestByBuildURL(ctx context.Context, buildURL string) (string, error) {
+	query := `
+		SELECT request_id
+		FROM requests
+		WHERE build_url = ?
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	var requestID string
+	err := s.db.QueryRowContext(ctx, query, buildURL).Scan(&requestID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("no requests found for build URL: %s", buildURL)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query request: %w", err)
+	}
+
+	return requestID, nil
+}
+
+// sqliteRequestStatusQuery selects requests as scanned by
+// scanSQLiteRequestStatus, counting their findings.
+const sqliteRequestStatusQuery = `
+	SELECT
+		r.request_id, r.build_url, r.status,
+		(SELECT COUNT(*) FROM findings f WHERE f.request_id = r.request_id),
+		r.created_at, r.completed_at,
+		r.stage, r.jobs_fetched, r.jobs_total, r.chunks_processed, r.chunks_total,
+		r.truncated
+	FROM requests r
+`
+
+// scanSQLiteRequestStatus scans a row of sqliteRequestStatusQuery.
+func scanSQLiteRequestStatus(row interface{ Scan(...any) error }) (RequestStatus, error) {
+	var status RequestStatus
+	var createdAt string
+	var completedAt sql.NullString
+	var truncated string
+	err := row.Scan(
+		&status.RequestID, &status.BuildURL, &status.Status, &status.FindingsCount, &createdAt, &completedAt,
+		&status.Stage, &status.JobsFetched, &status.JobsTotal, &status.ChunksProcessed, &status.ChunksTotal,
+		&truncated)
+	status.Truncated = splitTruncated(truncated)
+	status.CreatedAt, _ = time.Parse(sqliteTimeFormat, createdAt)
+	if completedAt.Valid {
+		status.CompletedAt, _ = time.Parse(sqliteTimeFormat, completedAt.String)
+	}
+	return status, err
+}
+
+// GetRequestStatus returns the status of a request, counting its findings.
+func (s *SQLiteStore) GetRequestStatus(ctx context.Context, requestID string) (RequestStatus, error) {
+	row := s.db.QueryRowContext(ctx, sqliteRequestStatusQuery+"WHERE r.request_id = ?", requestID)
+	status, err := scanSQLiteRequestStatus(row)
+	if err == sql.ErrNoRows {
+		return RequestStatus{}, ErrNotFound{RequestID: requestID}
+	}
+	if err != nil {
+		return RequestStatus{}, fmt.Errorf("failed to query request: %w", err)
+	}
+	return status, nil
+}
+
+// QueuedRequests returns the pending and processing requests, oldest first
+// (see QueueLister).
+func (s *SQLiteStore) QueuedRequests(ctx context.Context) ([]RequestStatus, error) {
+	rows, err := s.db.QueryContext(ctx, sqliteRequestStatusQuery+"WHERE r.status IN (?, ?) ORDER BY r.created_at",
+		RequestPending, RequestProcessing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []RequestStatus
+	for rows.Next() {
+		status, err := scanSQLiteRequestStatus(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan request: %w", err)
+		}
+		requests = append(requests, status)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating requests: %w", err)
+	}
+	return requests, nil
+}
+
+// ListRequests returns the requests matching filter, newest first (see
+// RequestLister).
+func (s *SQLiteStore) ListRequests(ctx context.Context, filter RequestFilter) ([]RequestStatus, error) {
+	var where []string
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return "?"
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "r.created_at >= "+arg(sqliteTime(filter.Since)))
+	}
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			placeholders[i] = arg(status)
+		}
+		where = append(where, "r.status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	query := sqliteRequestStatusQuery
+	if len(where) > 0 {
+		query += "WHERE " + strings.Join(where, " AND ") + " "
+	}
+	query += "ORDER BY r.created_at DESC, r.request_id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []RequestStatus
+	for rows.Next() {
+		status, err := scanSQLiteRequestStatus(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan request: %w", err)
+		}
+		requests = append(requests, status)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating requests: %w", err)
+	}
+	return requests, nil
+}
+
+// SetRequestStatus records the status of a request (see RequestTracker).
+func (s *SQLiteStore) SetRequestStatus(ctx context.Context, requestID, buildURL, status string) error {
+	if _, ok := requestStatusRank[status]; !ok {
+		return fmt.Errorf("invalid request status %q", status)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setRequestStatus(ctx, tx, requestID, buildURL, status); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetRequestProgress records a progress update of a request (see
+// RequestTracker), moving it to processing if it's still pending.
+func (s *SQLiteStore) SetRequestProgress(ctx context.Context, update contracts.ProgressUpdate) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setRequestStatus(ctx, tx, update.RequestID, "", RequestProcessing); err != nil {
+		return err
+	}
+	switch update.Unit {
+	case contracts.ProgressUnitChunks:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE requests SET
+				chunks_processed = MAX(chunks_processed, ?),
+				chunks_total = MAX(chunks_total, ?)
+			WHERE request_id = ?
+		`, update.Current, update.Total, update.RequestID)
+	default: // Jobs, also from agents predating Unit
+		_, err = tx.ExecContext(ctx, `
+			UPDATE requests SET
+				stage = ?,
+				jobs_fetched = MAX(jobs_fetched, ?),
+				jobs_total = MAX(jobs_total, ?)
+			WHERE request_id = ?
+		`, update.Stage, update.Current, update.Total, update.RequestID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record request progress: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetRequestTruncated records what the agents' limits left out of a
+// request's analysis (see RequestTracker).
+func (s *SQLiteStore) SetRequestTruncated(ctx context.Context, requestID string, notes []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setRequestStatus(ctx, tx, requestID, "", RequestProcessing); err != nil {
+		return err
+	}
+	var truncated string
+	if err := tx.QueryRowContext(ctx, `SELECT truncated FROM requests WHERE request_id = ?`, requestID).Scan(&truncated); err != nil {
+		return fmt.Errorf("failed to query request: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE requests SET truncated = ? WHERE request_id = ?`,
+		addTruncated(truncated, notes), requestID); err != nil {
+		return fmt.Errorf("failed to record request truncation: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// setRequestStatus records a request's status within tx, keeping the
+// current one if it's further along and filling in the build URL if unset.
+func setRequestStatus(ctx context.Context, tx *sql.Tx, requestID, buildURL, status string) error {
+	var current string
+	err := tx.QueryRowContext(ctx, `SELECT status FROM requests WHERE request_id = ?`, requestID).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to query request: %w", err)
+	}
+	if err == nil && requestStatusRank[current] >= requestStatusRank[status] {
+		status = current
+	}
+
+	now := sqliteTime(time.Now())
+	var completedAt any // NULL until completed
+	if status == RequestCompleted || status == RequestFailed {
+		completedAt = now
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO requests (request_id, build_url, status, created_at, completed_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (request_id) DO UPDATE SET
+			build_url = CASE WHEN requests.build_url = '' THEN excluded.build_url ELSE requests.build_url END,
+			status = excluded.status,
+			completed_at = COALESCE(requests.completed_at, excluded.completed_at)
+	`, requestID, buildURL, status, now, completedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record request status: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes the findings, log chunks, and requests created before
+// before (see Pruner).
+func (s *SQLiteStore) Prune(ctx context.Context, before time.Time) (PruneResult, error) {
+	return pruneTables(ctx, s.db, prunedTables, "DELETE FROM %s WHERE %s < ?", sqliteTime(before))
+}
+
+// Close closes the database connection.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// sqliteTime formats t as stored in SQLite (see sqliteTimeFormat).
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
@@ -0,0 +1,485 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+func newTestSQLiteStore(t *testing.T) (*SQLiteStore, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "destill.db")
+	st, err := NewSQLiteStore(context.Background(), path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st, path
+}
+
+func TestSQLiteStore(t *testing.T) {
+	ctx := context.Background()
+	st, path := newTestSQLiteStore(t)
+	build := "https://buildkite.com/org/pipeline/builds/1"
+
+	cards := []contracts.TriageCard{
+		{
+			RequestID: "req-1", BuildURL: build, JobName: "test", MessageHash: "hash-low",
+			Severity: "ERROR", ConfidenceScore: 0.5, RawMessage: "low", NormalizedMsg: "low",
+			Metadata: map[string]string{"job_state": "failed"},
+		},
+		{
+			RequestID: "req-1", BuildURL: build, JobName: "test", MessageHash: "hash-high",
+			Severity: "ERROR", ConfidenceScore: 0.9, RawMessage: "high", NormalizedMsg: "high",
+			PreContext: []string{"before"}, ContextNote: "truncated at chunk start", ChunkIndex: 2, LineInChunk: 7,
+			Category: contracts.CategoryGo,
+			Metadata: map[string]string{"job_state": "failed"}, Summary: &contracts.Summary{RootCause: "disk full"},
+		},
+	}
+	if err := st.Store(ctx, "req-1", cards); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	findings, err := st.GetFindings(ctx, "req-1")
+	if err != nil {
+		t.Fatalf("GetFindings() error = %v", err)
+	}
+	if len(findings) != 2 || findings[0].MessageHash != "hash-high" {
+		t.Fatalf("GetFindings() = %+v, want hash-high first", findings)
+	}
+	if findings[0].PreContext[0] != "before" || findings[0].Summary == nil || findings[0].Summary.RootCause != "disk full" ||
+		findings[0].ContextNote != "truncated at chunk start" || findings[0].ChunkIndex != 2 || findings[0].LineInChunk != 7 ||
+		findings[0].Category != contracts.CategoryGo {
+		t.Errorf("GetFindings() lost fields: %+v", findings[0])
+	}
+	if findings[0].ID == "" || findings[0].Timestamp == "" {
+		t.Errorf("GetFindings() ID = %q, Timestamp = %q, want both set", findings[0].ID, findings[0].Timestamp)
+	}
+
+	// Storing a finding again updates it instead of adding a duplicate
+	cards[0].ConfidenceScore = 0.7
+	if err := st.Store(ctx, "req-1", cards[:1]); err != nil {
+		t.Fatalf("Store() again error = %v", err)
+	}
+	card, err := st.GetByHash(ctx, "req-1", "hash-low")
+	if err != nil || card.ConfidenceScore != 0.7 {
+		t.Errorf("GetByHash() = %+v, %v, want confidence 0.7", card, err)
+	}
+	if _, err := st.GetByHash(ctx, "req-1", "missing"); !errors.As(err, new(ErrNotFound)) {
+		t.Errorf("GetByHash(missing) error = %v, want ErrNotFound", err)
+	}
+
+	latest, err := st.GetLatestRequestByBuildURL(ctx, build)
+	if err != nil || latest != "req-1" {
+		t.Errorf("GetLatestRequestByBuildURL() = %q, %v, want req-1", latest, err)
+	}
+	status, err := st.GetRequestStatus(ctx, "req-1")
+	if err != nil {
+		t.Fatalf("GetRequestStatus() error = %v", err)
+	}
+	if status.Status != RequestProcessing || status.FindingsCount != 2 || status.BuildURL != build {
+		t.Errorf("GetRequestStatus() = %+v, want processing with 2 findings", status)
+	}
+
+	// The data outlives the process
+	st.Close()
+	reopened, err := NewSQLiteStore(context.Background(), path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore(reopen) error = %v", err)
+	}
+	defer reopened.Close()
+	if findings, err := reopened.GetFindings(ctx, "req-1"); err != nil || len(findings) != 2 {
+		t.Errorf("GetFindings() after reopen = %d findings, %v, want 2", len(findings), err)
+	}
+}
+
+func TestSQLiteStoreRequestStatus(t *testing.T) {
+	ctx := context.Background()
+	st, _ := newTestSQLiteStore(t)
+	build := "https://github.com/org/repo/actions/runs/1"
+
+	if _, err := st.GetRequestStatus(ctx, "req-1"); !errors.As(err, new(ErrNotFound)) {
+		t.Errorf("GetRequestStatus(unknown) error = %v, want ErrNotFound", err)
+	}
+
+	steps := []struct {
+		buildURL string
+		status   string
+		want     string
+	}{
+		{build, RequestPending, RequestPending},
+		{"", RequestProcessing, RequestProcessing},
+		{"", RequestCompleted, RequestCompleted},
+		{"", RequestProcessing, RequestCompleted}, // Never moves back
+	}
+	for _, step := range steps {
+		if err := st.SetRequestStatus(ctx, "req-1", step.buildURL, step.status); err != nil {
+			t.Fatalf("SetRequestStatus(%s) error = %v", step.status, err)
+		}
+		status, err := st.GetRequestStatus(ctx, "req-1")
+		if err != nil {
+			t.Fatalf("GetRequestStatus() error = %v", err)
+		}
+		if status.Status != step.want || status.BuildURL != build {
+			t.Errorf("after %s: status = %+v, want %s for %s", step.status, status, step.want, build)
+		}
+	}
+
+	status, _ := st.GetRequestStatus(ctx, "req-1")
+	if status.CompletedAt.IsZero() || status.CreatedAt.IsZero() {
+		t.Errorf("GetRequestStatus() = %+v, want created and completed times", status)
+	}
+	if err := st.SetRequestStatus(ctx, "req-1", "", "done"); err == nil {
+		t.Error("SetRequestStatus(done) error = nil, want invalid status")
+	}
+}
+
+func TestSQLiteStoreRequestProgress(t *testing.T) {
+	ctx := context.Background()
+	st, _ := newTestSQLiteStore(t)
+
+	updates := []contracts.ProgressUpdate{
+		{RequestID: "req-1", Stage: "Fetching logs", Unit: contracts.ProgressUnitJobs, Current: 3, Total: 5},
+		{RequestID: "req-1", Stage: contracts.StageAnalyzing, Unit: contracts.ProgressUnitChunks, Current: 12},
+		{RequestID: "req-1", Stage: contracts.StageAnalyzing, Unit: contracts.ProgressUnitChunks, Current: 10}, // Out of order
+		{RequestID: "req-1", Stage: "complete", Unit: contracts.ProgressUnitJobs, Current: 5, Total: 5},
+		{RequestID: "req-1", Stage: contracts.StageAnalyzing, Unit: contracts.ProgressUnitChunks, Current: 13, Total: 40},
+	}
+	for _, update := range updates {
+		if err := st.SetRequestProgress(ctx, update); err != nil {
+			t.Fatalf("SetRequestProgress(%+v) error = %v", update, err)
+		}
+	}
+
+	status, err := st.GetRequestStatus(ctx, "req-1")
+	if err != nil {
+		t.Fatalf("GetRequestStatus() error = %v", err)
+	}
+	if status.Status != RequestProcessing || status.Stage != "complete" ||
+		status.JobsFetched != 5 || status.JobsTotal != 5 ||
+		status.ChunksProcessed != 13 || status.ChunksTotal != 40 {
+		t.Errorf("GetRequestStatus() = %+v, want processing, complete, 5/5 jobs and 13/40 chunks", status)
+	}
+
+	// Truncation notes accumulate, each once
+	st.SetRequestTruncated(ctx, "req-1", []string{"log of job tests cut at 256 MB"})
+	st.SetRequestTruncated(ctx, "req-1", []string{"log of job tests cut at 256 MB", "dropped 3 findings"})
+	if status, _ := st.GetRequestStatus(ctx, "req-1"); len(status.Truncated) != 2 || status.Truncated[1] != "dropped 3 findings" {
+		t.Errorf("Truncated = %q, want both notes once", status.Truncated)
+	}
+}
+
+func TestSQLiteStoreQueuedRequests(t *testing.T) {
+	ctx := context.Background()
+	st, _ := newTestSQLiteStore(t)
+
+	for _, r := range []struct{ id, status string }{
+		{"req-1", RequestPending},
+		{"req-2", RequestCompleted},
+		{"req-3", RequestProcessing},
+		{"req-4", RequestFailed},
+		{"req-5", RequestPending},
+	} {
+		if err := st.SetRequestStatus(ctx, r.id, "https://example.com/"+r.id, r.status); err != nil {
+			t.Fatalf("SetRequestStatus(%s) error = %v", r.id, err)
+		}
+	}
+
+	queued, err := st.QueuedRequests(ctx)
+	if err != nil {
+		t.Fatalf("QueuedRequests() error = %v", err)
+	}
+	var ids []string
+	for _, r := range queued {
+		ids = append(ids, r.RequestID+":"+r.Status)
+	}
+	want := []string{"req-1:pending", "req-3:processing", "req-5:pending"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("QueuedRequests() = %v, want %v", ids, want)
+	}
+}
+
+func TestSQLiteStoreListRequests(t *testing.T) {
+	ctx := context.Background()
+	st, _ := newTestSQLiteStore(t)
+
+	for _, r := range []struct{ id, status string }{
+		{"req-1", RequestCompleted},
+		{"req-2", RequestFailed},
+		{"req-3", RequestProcessing},
+		{"req-4", RequestFailed},
+	} {
+		if err := st.SetRequestStatus(ctx, r.id, "https://example.com/"+r.id, r.status); err != nil {
+			t.Fatalf("SetRequestStatus(%s) error = %v", r.id, err)
+		}
+	}
+	st.Store(ctx, "req-2", []contracts.TriageCard{{RequestID: "req-2", MessageHash: "hash-1", RawMessage: "boom"}})
+
+	ids := func(filter RequestFilter) []string {
+		t.Helper()
+		requests, err := st.ListRequests(ctx, filter)
+		if err != nil {
+			t.Fatalf("ListRequests(%+v) error = %v", filter, err)
+		}
+		var ids []string
+		for _, r := range requests {
+			ids = append(ids, fmt.Sprintf("%s:%d", r.RequestID, r.FindingsCount))
+		}
+		return ids
+	}
+	if got, want := ids(RequestFilter{}), []string{"req-4:0", "req-3:0", "req-2:1", "req-1:0"}; !slices.Equal(got, want) {
+		t.Errorf("ListRequests() = %v, want %v", got, want)
+	}
+	if got, want := ids(RequestFilter{Statuses: []string{RequestFailed}, Limit: 1}), []string{"req-4:0"}; !slices.Equal(got, want) {
+		t.Errorf("ListRequests(failed, limit 1) = %v, want %v", got, want)
+	}
+	if got := ids(RequestFilter{Since: time.Now().Add(time.Hour)}); len(got) != 0 {
+		t.Errorf("ListRequests(since an hour from now) = %v, want none", got)
+	}
+}
+
+func TestSQLiteStoreGetChunks(t *testing.T) {
+	ctx := context.Background()
+	st, _ := newTestSQLiteStore(t)
+
+	if _, err := st.GetChunks(ctx, "req-1"); !errors.Is(err, ErrNoChunks) {
+		t.Errorf("GetChunks(unknown) error = %v, want ErrNoChunks", err)
+	}
+
+	chunk := func(job string, index int, content string) contracts.LogChunk {
+		return contracts.LogChunk{
+			RequestID: "req-1", JobID: job, JobName: "test " + job, ChunkIndex: index, TotalChunks: 2,
+			Content: content, LineStart: index*10 + 1, LineEnd: index*10 + 10,
+			Metadata: map[string]string{"job_id": job, "build_url": "https://example.com/builds/1"},
+			Options:  &contracts.AnalysisOptions{MinConfidence: 0.7},
+		}
+	}
+	stored := []contracts.LogChunk{chunk("job-b", 0, "b0"), chunk("job-a", 1, "a1"), chunk("job-a", 0, "a0")}
+	if err := st.StoreChunks(ctx, stored); err != nil {
+		t.Fatalf("StoreChunks() error = %v", err)
+	}
+
+	chunks, err := st.GetChunks(ctx, "req-1")
+	if err != nil {
+		t.Fatalf("GetChunks() error = %v", err)
+	}
+	var order []string
+	for _, c := range chunks {
+		order = append(order, c.Content)
+	}
+	if want := []string{"a0", "a1", "b0"}; !slices.Equal(order, want) {
+		t.Errorf("GetChunks() order = %v, want %v", order, want)
+	}
+	got := chunks[1]
+	if got.JobName != "test job-a" || got.TotalChunks != 2 || got.LineStart != 11 || got.Metadata["build_url"] == "" ||
+		got.Options == nil || got.Options.MinConfidence != 0.7 {
+		t.Errorf("GetChunks()[1] = %+v, want the stored chunk", got)
+	}
+}
+
+func TestSQLiteStoreAssignFinding(t *testing.T) {
+	ctx := context.Background()
+	st, _ := newTestSQLiteStore(t)
+
+	card := contracts.TriageCard{
+		RequestID: "req-1", JobName: "test", MessageHash: "hash-1", Severity: "ERROR",
+		ConfidenceScore: 0.9, RawMessage: "boom", NormalizedMsg: "boom",
+	}
+	if err := st.Store(ctx, "req-1", []contracts.TriageCard{card}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	assigned, err := st.AssignFinding(ctx, "req-1", "hash-1", "alex")
+	if err != nil || assigned.Assignee != "alex" || assigned.RawMessage != "boom" {
+		t.Fatalf("AssignFinding() = %+v, %v, want the finding assigned to alex", assigned, err)
+	}
+
+	// Storing the finding again, as a re-delivered message would, keeps it
+	if err := st.Store(ctx, "req-1", []contracts.TriageCard{card}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	if found, err := st.GetByHash(ctx, "req-1", "hash-1"); err != nil || found.Assignee != "alex" {
+		t.Errorf("GetByHash() = %+v, %v, want still assigned to alex", found, err)
+	}
+
+	if unassigned, err := st.AssignFinding(ctx, "req-1", "hash-1", ""); err != nil || unassigned.Assignee != "" {
+		t.Errorf("AssignFinding(\"\") = %+v, %v, want unassigned", unassigned, err)
+	}
+	var notFound ErrNotFound
+	if _, err := st.AssignFinding(ctx, "req-1", "missing", "alex"); !errors.As(err, &notFound) {
+		t.Errorf("AssignFinding(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSQLiteStoreFindingContext(t *testing.T) {
+	ctx := context.Background()
+	st, _ := newTestSQLiteStore(t)
+
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, "line "+string(rune('a'+i-1)))
+	}
+	chunks := []contracts.LogChunk{
+		{RequestID: "req-1", JobID: "job-1", ChunkIndex: 0, LineStart: 1, LineEnd: 10, Content: strings.Join(lines[:10], "\n")},
+		{RequestID: "req-1", JobID: "job-1", ChunkIndex: 1, LineStart: 11, LineEnd: 20, Content: strings.Join(lines[10:], "\n")},
+	}
+	if err := st.StoreChunks(ctx, chunks); err != nil {
+		t.Fatalf("StoreChunks() error = %v", err)
+	}
+	// Stored twice, e.g. redelivered; kept once
+	if err := st.StoreChunks(ctx, chunks); err != nil {
+		t.Fatalf("StoreChunks() again error = %v", err)
+	}
+
+	card := contracts.TriageCard{
+		RequestID: "req-1", MessageHash: "hash", ChunkIndex: 1, LineInChunk: 0,
+		Metadata: map[string]string{"job_id": "job-1"},
+	}
+	if err := st.Store(ctx, "req-1", []contracts.TriageCard{card}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	stored, _ := st.GetByHash(ctx, "req-1", "hash")
+
+	fc, err := st.GetFindingContext(ctx, stored.ID, 2, 1)
+	if err != nil {
+		t.Fatalf("GetFindingContext() error = %v", err)
+	}
+	if fc.Line != 11 || fc.Message != "line k" {
+		t.Errorf("GetFindingContext() = line %d %q, want line 11 %q", fc.Line, fc.Message, "line k")
+	}
+	if strings.Join(fc.Before, ",") != "line i,line j" || strings.Join(fc.After, ",") != "line l" {
+		t.Errorf("GetFindingContext() before = %v, after = %v", fc.Before, fc.After)
+	}
+
+	if _, err := st.GetFindingContext(ctx, "missing", 2, 2); !errors.As(err, new(ErrNotFound)) {
+		t.Errorf("GetFindingContext(missing) error = %v, want ErrNotFound", err)
+	}
+
+	log, err := st.GetJobLog(ctx, stored.ID)
+	if err != nil {
+		t.Fatalf("GetJobLog() error = %v", err)
+	}
+	if log.Line != 11 || len(log.Lines) != 20 || log.Lines[0] != "line a" || log.Lines[19] != "line t" {
+		t.Errorf("GetJobLog() = line %d of %d lines, want line 11 of 20", log.Line, len(log.Lines))
+	}
+}
+
+func TestSQLiteStorePreviousRequest(t *testing.T) {
+	ctx := context.Background()
+	st, _ := newTestSQLiteStore(t)
+	build := "https://buildkite.com/org/pipeline/builds/1"
+	st.Store(ctx, "req-1", []contracts.TriageCard{{RequestID: "req-1", BuildURL: build, MessageHash: "a", Timestamp: "2026-01-01T10:00:00Z"}})
+	st.Store(ctx, "req-2", []contracts.TriageCard{{RequestID: "req-2", BuildURL: build, MessageHash: "a", Timestamp: "2026-01-01T11:00:00Z"}})
+
+	for requestID, want := range map[string]string{"req-2": "req-1", "req-1": "", "req-new": "req-2"} {
+		got, err := st.PreviousRequest(ctx, build, requestID)
+		if err != nil || got != want {
+			t.Errorf("PreviousRequest(%s) = %q, %v, want %q", requestID, got, err, want)
+		}
+	}
+}
+
+func TestSQLiteStorePreviousOccurrence(t *testing.T) {
+	ctx := context.Background()
+	st, _ := newTestSQLiteStore(t)
+	finding := func(requestID, build, pipeline, timestamp string) contracts.TriageCard {
+		return contracts.TriageCard{RequestID: requestID, BuildURL: build, MessageHash: "hash-1", RawMessage: "failed in " + build,
+			Timestamp: timestamp, Metadata: map[string]string{"pipeline_name": pipeline}}
+	}
+	st.Store(ctx, "req-1", []contracts.TriageCard{finding("req-1", "builds/1", "org/api", "2026-01-01T10:00:00Z")})
+	st.Store(ctx, "req-2", []contracts.TriageCard{finding("req-2", "builds/2", "org/web", "2026-01-01T11:00:00Z")})
+	st.Store(ctx, "req-3", []contracts.TriageCard{finding("req-3", "builds/3", "org/api", "2026-01-01T12:00:00Z")})
+	current := finding("req-4", "builds/3", "org/api", "2026-01-01T13:00:00Z")
+	st.Store(ctx, "req-4", []contracts.TriageCard{current})
+
+	// Earlier runs of the same build and other pipelines are skipped
+	got, err := st.PreviousOccurrence(ctx, current)
+	if err != nil || got.RequestID != "req-1" || got.RawMessage != "failed in builds/1" {
+		t.Errorf("PreviousOccurrence() = %q, %v, want req-1", got.RequestID, err)
+	}
+
+	_, err = st.PreviousOccurrence(ctx, finding("req-1", "builds/1", "org/api", "2026-01-01T10:00:00Z"))
+	var notFound ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("PreviousOccurrence(first) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMigrateSQLiteRejectsNewerSchema(t *testing.T) {
+	st, path := newTestSQLiteStore(t)
+	if _, err := st.db.Exec("PRAGMA user_version = 99"); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	if _, err := NewSQLiteStore(context.Background(), path); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("NewSQLiteStore() error = %v, want newer schema error", err)
+	}
+}
+
+func TestSQLiteStorePrune(t *testing.T) {
+	ctx := context.Background()
+	st, _ := newTestSQLiteStore(t)
+	build := "https://buildkite.com/org/pipeline/builds/1"
+	for _, requestID := range []string{"req-old", "req-new"} {
+		st.Store(ctx, requestID, []contracts.TriageCard{{RequestID: requestID, BuildURL: build, MessageHash: "a"}})
+		st.StoreChunks(ctx, []contracts.LogChunk{{RequestID: requestID, JobID: "job", Content: "line"}})
+	}
+	old := sqliteTime(time.Now().Add(-48 * time.Hour))
+	for _, table := range prunedTables {
+		if _, err := st.db.Exec("UPDATE "+table.name+" SET created_at = ? WHERE request_id = 'req-old'", old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := st.Prune(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if res != (PruneResult{Findings: 1, LogChunks: 1, Requests: 1}) {
+		t.Errorf("Prune() = %+v, want one of each", res)
+	}
+	if findings, _ := st.GetFindings(ctx, "req-old"); len(findings) != 0 {
+		t.Errorf("GetFindings(req-old) = %d findings after prune, want 0", len(findings))
+	}
+	if findings, _ := st.GetFindings(ctx, "req-new"); len(findings) != 1 {
+		t.Errorf("GetFindings(req-new) = %d findings after prune, want 1", len(findings))
+	}
+}
+
+// TestPrunePostgresTables prunes Postgres's tables, message_hash_history
+// with them, in a SQLite database with that table added.
+func TestPrunePostgresTables(t *testing.T) {
+	ctx := context.Background()
+	st, _ := newTestSQLiteStore(t)
+	if _, err := st.db.Exec(`CREATE TABLE message_hash_history (
+		pipeline TEXT, build_url TEXT, message_hash TEXT, request_id TEXT, seen_at TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	old, recent := sqliteTime(time.Now().Add(-48*time.Hour)), sqliteTime(time.Now())
+	for _, row := range [][]string{{"req-old", old}, {"req-new", recent}} {
+		if _, err := st.db.Exec(`INSERT INTO message_hash_history VALUES ('pipe', ?, 'a', ?, ?)`,
+			"https://buildkite.com/org/pipe/builds/"+row[0], row[0], row[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := pruneTables(ctx, st.db, postgresPrunedTables, "DELETE FROM %s WHERE %s < ?", sqliteTime(time.Now().Add(-24*time.Hour)))
+	if err != nil {
+		t.Fatalf("pruneTables() error = %v", err)
+	}
+	if res != (PruneResult{HashHistory: 1}) {
+		t.Errorf("pruneTables() = %+v, want the old history row", res)
+	}
+	var requestID string
+	if err := st.db.QueryRow(`SELECT request_id FROM message_hash_history`).Scan(&requestID); err != nil || requestID != "req-new" {
+		t.Errorf("remaining history = %q, %v; want req-new's", requestID, err)
+	}
+}
@@ -3,6 +3,8 @@ package store
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"destill-agent/src/contracts"
 )
@@ -13,6 +15,7 @@ import (
 // Implementations:
 //   - InMemoryStore: For local mode and MCP server
 //   - PostgresStore: For distributed mode with Kafka sink
+//   - SQLiteStore: For single-node distributed mode without Postgres
 type Store interface {
 	// GetFindings retrieves all findings for a request.
 	GetFindings(ctx context.Context, requestID string) ([]contracts.TriageCard, error)
@@ -23,17 +26,213 @@ type Store interface {
 	// Store saves findings for a request.
 	Store(ctx context.Context, requestID string, cards []contracts.TriageCard) error
 
+	// StoreChunks saves log chunks, so context around findings can be
+	// read back with GetFindingContext.
+	StoreChunks(ctx context.Context, chunks []contracts.LogChunk) error
+
+	// GetFindingContext reads up to before and after lines around a finding,
+	// by its ID, from the stored chunks of its log. Sizes are capped; see
+	// MaxContextLines and MaxContextBytes.
+	GetFindingContext(ctx context.Context, findingID string, before, after int) (FindingContext, error)
+
+	// PreviousRequest returns the most recent request for the same build
+	// URL stored before requestID (or before now, if requestID isn't
+	// stored), or "" if there is none.
+	PreviousRequest(ctx context.Context, buildURL, requestID string) (string, error)
+
 	// Close closes the store connection.
 	Close() error
 }
 
+// Persistent is a Store kept in a database the agents and the CLI share.
+type Persistent interface {
+	Store
+
+	// GetFindingsSince retrieves all findings stored at or after since.
+	GetFindingsSince(ctx context.Context, since time.Time) ([]contracts.TriageCard, error)
+
+	// GetLatestRequestByBuildURL returns the most recent request for a
+	// build URL.
+	GetLatestRequestByBuildURL(ctx context.Context, buildURL string) (string, error)
+
+	// GetRequestStatus returns the status of a request.
+	GetRequestStatus(ctx context.Context, requestID string) (RequestStatus, error)
+}
+
+// Request statuses, as in the requests table.
+const (
+	RequestPending    = "pending"
+	RequestProcessing = "processing"
+	RequestCompleted  = "completed"
+	RequestFailed     = "failed"
+)
+
+// RequestStatus is the state of an analysis request.
+type RequestStatus struct {
+	RequestID     string
+	BuildURL      string
+	Status        string // RequestPending, RequestProcessing, RequestCompleted, or RequestFailed
+	FindingsCount int
+	CreatedAt     time.Time
+	CompletedAt   time.Time // Zero until completed
+
+	// Progress, from the agents' progress updates (see RequestTracker)
+	Stage           string // The ingest agent's latest stage, e.g. "Fetching logs"
+	JobsFetched     int
+	JobsTotal       int
+	ChunksProcessed int
+	ChunksTotal     int // Zero until ingest completes
+
+	// Truncated says what the agents' limits left out of the analysis,
+	// such as a log cut at its size limit; empty if nothing was
+	Truncated []string
+}
+
+// RequestTracker is implemented by stores that record request statuses
+// themselves rather than through an external sink (see
+// pipeline.TrackRequests).
+type RequestTracker interface {
+	// SetRequestStatus records the status of a request. buildURL may be
+	// empty once the request is known. A status never moves back, e.g.
+	// from completed to processing.
+	SetRequestStatus(ctx context.Context, requestID, buildURL, status string) error
+
+	// SetRequestProgress records a progress update of a request: the jobs
+	// fetched or the chunks analyzed, by its Unit. Counts never go back,
+	// so updates arriving out of order or twice are harmless.
+	SetRequestProgress(ctx context.Context, update contracts.ProgressUpdate) error
+
+	// SetRequestTruncated records what the agents' limits left out of a
+	// request's analysis (contracts.ControlMessage.Truncated). Notes
+	// already recorded aren't added again.
+	SetRequestTruncated(ctx context.Context, requestID string, notes []string) error
+}
+
+// Assigner is implemented by stores that record who is triaging a
+// finding (see 'destill assign').
+type Assigner interface {
+	// AssignFinding sets the assignee of a request's finding, by message
+	// hash, and returns the updated finding. An empty assignee unassigns
+	// it. Returns ErrNotFound if the finding isn't stored.
+	AssignFinding(ctx context.Context, requestID, messageHash, assignee string) (contracts.TriageCard, error)
+}
+
+// QueueLister is implemented by stores that can list the requests the
+// agents haven't finished (see 'destill queue list').
+type QueueLister interface {
+	// QueuedRequests returns the pending and processing requests, oldest
+	// first.
+	QueuedRequests(ctx context.Context) ([]RequestStatus, error)
+}
+
+// RequestLister is implemented by stores that can list recent requests
+// (see 'destill list').
+type RequestLister interface {
+	// ListRequests returns the requests matching filter, newest first.
+	ListRequests(ctx context.Context, filter RequestFilter) ([]RequestStatus, error)
+}
+
+// RequestFilter selects the requests ListRequests returns. Zero fields
+// don't filter.
+type RequestFilter struct {
+	Since    time.Time // Created at or after
+	Statuses []string  // Any of these statuses
+	Limit    int       // At most this many
+}
+
+// Pruner is implemented by stores that can delete old data, to keep the
+// database from growing without bound (see 'destill prune').
+type Pruner interface {
+	// Prune deletes the findings, log chunks, and requests stored before
+	// before, with any history kept of them, and reports how many of each
+	// it deleted.
+	Prune(ctx context.Context, before time.Time) (PruneResult, error)
+}
+
+// PruneResult counts the rows deleted by Prune.
+type PruneResult struct {
+	Findings    int64
+	LogChunks   int64
+	Requests    int64
+	HashHistory int64 // Rows of message_hash_history, in Postgres
+}
+
+// HashHistorian is implemented by stores that keep which builds of each
+// pipeline each message was seen in, for cross-build recurrence.
+type HashHistorian interface {
+	// GetHashHistory returns, for each of messageHashes seen in the last
+	// builds analyzed builds of pipeline, how many of those builds it
+	// appeared in. Hashes not seen in them are left out.
+	GetHashHistory(ctx context.Context, pipeline string, messageHashes []string, builds int) (map[string]HashHistory, error)
+}
+
+// JobLogReader is implemented by stores that can read back the whole log
+// of a finding's job from its stored chunks, for the TUI's log viewer.
+type JobLogReader interface {
+	// GetJobLog returns the log section a finding was found in, up to
+	// MaxJobLogBytes. Returns ErrNoChunks if its chunks weren't stored.
+	GetJobLog(ctx context.Context, findingID string) (JobLog, error)
+}
+
+// ChunkReader is implemented by stores that can read back all the log
+// chunks of a request, to analyze them again (see 'destill reanalyze').
+type ChunkReader interface {
+	// GetChunks returns the stored chunks of a request, by job, section,
+	// and index. Returns ErrNoChunks if none are stored.
+	GetChunks(ctx context.Context, requestID string) ([]contracts.LogChunk, error)
+}
+
+// OccurrenceFinder is implemented by stores that can look up earlier
+// occurrences of a finding, to compare a recurring failure across builds.
+type OccurrenceFinder interface {
+	// PreviousOccurrence returns the finding with card's message hash
+	// analyzed most recently before card in another build of its
+	// pipeline. Returns ErrNotFound if there is none.
+	PreviousOccurrence(ctx context.Context, card contracts.TriageCard) (contracts.TriageCard, error)
+}
+
+// cardAnalyzedAt returns when card was analyzed, from its Timestamp, or now
+// when it has none.
+func cardAnalyzedAt(card contracts.TriageCard) time.Time {
+	if t, err := time.Parse(time.RFC3339, card.Timestamp); err == nil {
+		return t
+	}
+	return time.Now()
+}
+
+// HashHistory is how often a message appeared in recent builds of a
+// pipeline.
+type HashHistory struct {
+	MessageHash string
+	BuildsSeen  int // Builds the message appeared in
+	Builds      int // Recent builds looked at, at most the number asked for
+	FirstSeen   time.Time
+	LastSeen    time.Time
+}
+
+// OpenPersistent opens Postgres at postgresDSN or, without it, SQLite at
+// sqlitePath.
+func OpenPersistent(ctx context.Context, postgresDSN, sqlitePath string) (Persistent, error) {
+	if postgresDSN != "" {
+		return NewPostgresStore(ctx, postgresDSN)
+	}
+	if sqlitePath != "" {
+		return NewSQLiteStore(ctx, sqlitePath)
+	}
+	return nil, errors.New("POSTGRES_DSN or DESTILL_SQLITE_PATH is required")
+}
+
 // ErrNotFound is returned when a finding is not found.
 type ErrNotFound struct {
 	RequestID   string
 	MessageHash string
+	FindingID   string // Set by lookups by ID
 }
 
 func (e ErrNotFound) Error() string {
+	if e.FindingID != "" {
+		return "finding not found: id=" + e.FindingID
+	}
 	if e.MessageHash != "" {
 		return "finding not found: request_id=" + e.RequestID + ", message_hash=" + e.MessageHash
 	}
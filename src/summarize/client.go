@@ -0,0 +1,218 @@
+// Package summarize implements the optional LLM analysis stage: it sends
+// top findings to a chat model and attaches a short root-cause summary and
+// suggested fix to the TriageCard.
+package summarize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"destill-agent/src/contracts"
+)
+
+// Environment variables configuring the stage.
+const (
+	EnvEnabled  = "DESTILL_LLM_SUMMARIES" // true to enable
+	EnvProvider = "DESTILL_LLM_PROVIDER"  // openai (default; also local OpenAI-compatible servers) or anthropic
+	EnvEndpoint = "DESTILL_LLM_ENDPOINT"  // API base URL, e.g. http://localhost:11434/v1
+	EnvModel    = "DESTILL_LLM_MODEL"
+	EnvAPIKey   = "DESTILL_LLM_API_KEY" // Falls back to OPENAI_API_KEY or ANTHROPIC_API_KEY
+)
+
+// Supported providers.
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+// DefaultTimeout bounds one summary request.
+const DefaultTimeout = 30 * time.Second
+
+// Client summarizes a single finding.
+type Client interface {
+	Summarize(ctx context.Context, card contracts.TriageCard) (*contracts.Summary, error)
+}
+
+// Config selects and configures the LLM endpoint.
+type Config struct {
+	Provider string
+	Endpoint string
+	Model    string
+	APIKey   string
+	Timeout  time.Duration
+}
+
+var defaults = map[string]Config{
+	ProviderOpenAI:    {Endpoint: "https://api.openai.com/v1", Model: "gpt-4o-mini"},
+	ProviderAnthropic: {Endpoint: "https://api.anthropic.com/v1", Model: "claude-3-5-haiku-latest"},
+}
+
+// Enabled reports whether summaries are turned on in the environment.
+func Enabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(EnvEnabled))
+	return enabled
+}
+
+// ConfigFromEnv reads the LLM settings from the environment.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Provider: strings.ToLower(os.Getenv(EnvProvider)),
+		Endpoint: os.Getenv(EnvEndpoint),
+		Model:    os.Getenv(EnvModel),
+		APIKey:   os.Getenv(EnvAPIKey),
+	}
+	if cfg.APIKey == "" {
+		switch cfg.Provider {
+		case ProviderAnthropic:
+			cfg.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+		case ProviderOpenAI, "":
+			cfg.APIKey = os.Getenv("OPENAI_API_KEY")
+		}
+	}
+	return cfg
+}
+
+// New creates a client for cfg, filling in provider defaults. An API key is
+// only required for the hosted endpoints; local servers usually need none.
+func New(cfg Config) (Client, error) {
+	if cfg.Provider == "" {
+		cfg.Provider = ProviderOpenAI
+	}
+	def, ok := defaults[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown LLM provider %q (want %s or %s)", cfg.Provider, ProviderOpenAI, ProviderAnthropic)
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = def.Endpoint
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("%s is required for the %s API", EnvAPIKey, cfg.Provider)
+		}
+	}
+	if cfg.Model == "" {
+		cfg.Model = def.Model
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+
+	httpClient := &http.Client{Timeout: cfg.Timeout}
+	if cfg.Provider == ProviderAnthropic {
+		return &anthropicClient{cfg: cfg, http: httpClient}, nil
+	}
+	return &openAIClient{cfg: cfg, http: httpClient}, nil
+}
+
+// openAIClient calls an OpenAI-compatible chat completions API. Ollama,
+// vLLM, and llama.cpp serve the same API locally.
+type openAIClient struct {
+	cfg  Config
+	http *http.Client
+}
+
+func (c *openAIClient) Summarize(ctx context.Context, card contracts.TriageCard) (*contracts.Summary, error) {
+	body := map[string]any{
+		"model":       c.cfg.Model,
+		"max_tokens":  maxTokens,
+		"temperature": 0.2,
+		"messages": []map[string]string{
+			{"role": "system", "content": systemPrompt},
+			{"role": "user", "content": BuildPrompt(card)},
+		},
+	}
+	headers := map[string]string{}
+	if c.cfg.APIKey != "" {
+		headers["Authorization"] = "Bearer " + c.cfg.APIKey
+	}
+
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := post(ctx, c.http, c.cfg.Endpoint+"/chat/completions", headers, body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("LLM response has no choices")
+	}
+	return parseSummary(resp.Choices[0].Message.Content, c.cfg.Model)
+}
+
+// anthropicClient calls the Anthropic messages API.
+type anthropicClient struct {
+	cfg  Config
+	http *http.Client
+}
+
+func (c *anthropicClient) Summarize(ctx context.Context, card contracts.TriageCard) (*contracts.Summary, error) {
+	body := map[string]any{
+		"model":      c.cfg.Model,
+		"max_tokens": maxTokens,
+		"system":     systemPrompt,
+		"messages": []map[string]string{
+			{"role": "user", "content": BuildPrompt(card)},
+		},
+	}
+	headers := map[string]string{"anthropic-version": "2023-06-01"}
+	if c.cfg.APIKey != "" {
+		headers["x-api-key"] = c.cfg.APIKey
+	}
+
+	var resp struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := post(ctx, c.http, c.cfg.Endpoint+"/messages", headers, body, &resp); err != nil {
+		return nil, err
+	}
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			return parseSummary(block.Text, c.cfg.Model)
+		}
+	}
+	return nil, fmt.Errorf("LLM response has no text")
+}
+
+// post sends body as JSON and decodes the JSON response into out.
+func post(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("LLM request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
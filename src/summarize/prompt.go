@@ -0,0 +1,76 @@
+package summarize
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/sanitize"
+)
+
+const (
+	// maxTokens caps the response; summaries are two short sentences
+	maxTokens = 300
+
+	// maxPromptLineLength truncates long context lines (minified output,
+	// base64 blobs) so one line can't dominate the prompt
+	maxPromptLineLength = 300
+)
+
+const systemPrompt = `You triage CI build failures. Given an error from a job log and the lines around it, ` +
+	`reply with only a JSON object: {"root_cause": "...", "suggested_fix": "..."}. ` +
+	`Each value is one or two plain sentences. Say "unknown" rather than guess.`
+
+// BuildPrompt renders a finding and its context as the user message.
+func BuildPrompt(card contracts.TriageCard) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Job: %s\n", card.JobName)
+	if step := card.Metadata["step_name"]; step != "" {
+		fmt.Fprintf(&b, "Step: %s\n", step)
+	}
+	if status := card.Metadata["exit_status"]; status != "" {
+		fmt.Fprintf(&b, "Exit status: %s\n", status)
+	}
+	fmt.Fprintf(&b, "Severity: %s\n\nLog excerpt (the error is marked >>):\n", card.Severity)
+	for _, line := range sanitize.CleanLines(card.PreContext) {
+		fmt.Fprintf(&b, "   %s\n", truncateLine(line))
+	}
+	fmt.Fprintf(&b, ">> %s\n", truncateLine(sanitize.Clean(card.RawMessage)))
+	for _, line := range sanitize.CleanLines(card.PostContext) {
+		fmt.Fprintf(&b, "   %s\n", truncateLine(line))
+	}
+	return b.String()
+}
+
+func truncateLine(line string) string {
+	if len(line) > maxPromptLineLength {
+		return line[:maxPromptLineLength] + "..."
+	}
+	return line
+}
+
+// parseSummary extracts the JSON object from a model reply. Models often
+// wrap it in a code fence or a sentence, so the outermost braces are used.
+func parseSummary(text, model string) (*contracts.Summary, error) {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("LLM reply is not JSON: %q", truncateLine(text))
+	}
+
+	var reply struct {
+		RootCause    string `json:"root_cause"`
+		SuggestedFix string `json:"suggested_fix"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &reply); err != nil {
+		return nil, fmt.Errorf("failed to parse LLM reply: %w", err)
+	}
+	if strings.TrimSpace(reply.RootCause) == "" {
+		return nil, fmt.Errorf("LLM reply has no root_cause")
+	}
+	return &contracts.Summary{
+		RootCause:    strings.TrimSpace(reply.RootCause),
+		SuggestedFix: strings.TrimSpace(reply.SuggestedFix),
+		Model:        model,
+	}, nil
+}
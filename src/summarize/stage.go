@@ -0,0 +1,105 @@
+package summarize
+
+import (
+	"context"
+	"sync"
+
+	"destill-agent/src/contracts"
+)
+
+const (
+	// DefaultMinConfidence is the lowest confidence that gets a summary
+	DefaultMinConfidence = 0.8
+
+	// DefaultPerRequest caps LLM calls per analysis request
+	DefaultPerRequest = 5
+
+	// maxTrackedRequests bounds the per-request state kept by a Stage
+	maxTrackedRequests = 1000
+)
+
+// Stage decides which findings are worth an LLM call and summarizes them.
+// Only top-tier findings qualify: high confidence, from a failed job, and
+// the first of their message hash in the request. Safe for concurrent use.
+type Stage struct {
+	client        Client
+	minConfidence float64
+	perRequest    int
+
+	mu       sync.Mutex
+	requests map[string]*requestState
+	order    []string // Request IDs, oldest first
+}
+
+type requestState struct {
+	calls int
+	seen  map[string]bool
+}
+
+// NewStage creates a stage with the default limits.
+func NewStage(client Client) *Stage {
+	return &Stage{
+		client:        client,
+		minConfidence: DefaultMinConfidence,
+		perRequest:    DefaultPerRequest,
+		requests:      make(map[string]*requestState),
+	}
+}
+
+// StageFromEnv returns the stage configured by the environment, or nil if
+// summaries are disabled.
+func StageFromEnv() (*Stage, error) {
+	if !Enabled() {
+		return nil, nil
+	}
+	client, err := New(ConfigFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	return NewStage(client), nil
+}
+
+// Apply sets card.Summary if the card qualifies. Errors leave the card
+// unchanged; callers should publish it anyway.
+func (s *Stage) Apply(ctx context.Context, card *contracts.TriageCard) error {
+	if !s.reserve(card) {
+		return nil
+	}
+	summary, err := s.client.Summarize(ctx, *card)
+	if err != nil {
+		return err
+	}
+	card.Summary = summary
+	return nil
+}
+
+// reserve reports whether card qualifies and counts it against its
+// request's budget.
+func (s *Stage) reserve(card *contracts.TriageCard) bool {
+	if card.ConfidenceScore < s.minConfidence {
+		return false
+	}
+	if status, ok := card.Metadata["exit_status"]; ok && status == "0" {
+		return false // Passing job: noise tier
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.requests[card.RequestID]
+	if !ok {
+		if len(s.order) >= maxTrackedRequests {
+			delete(s.requests, s.order[0])
+			s.order = s.order[1:]
+		}
+		state = &requestState{seen: make(map[string]bool)}
+		s.requests[card.RequestID] = state
+		s.order = append(s.order, card.RequestID)
+	}
+	if state.calls >= s.perRequest || state.seen[card.MessageHash] {
+		return false
+	}
+	state.seen[card.MessageHash] = true
+	state.calls++
+	return true
+}
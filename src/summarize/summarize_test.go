@@ -0,0 +1,243 @@
+package summarize
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+const reply = `{"root_cause": "The JVM ran out of heap.", "suggested_fix": "Raise -Xmx."}`
+
+func testCard() contracts.TriageCard {
+	return contracts.TriageCard{
+		RequestID:       "req-1",
+		MessageHash:     "hash-1",
+		JobName:         "tests",
+		Severity:        "ERROR",
+		RawMessage:      "java.lang.OutOfMemoryError: Java heap space",
+		ConfidenceScore: 0.95,
+		PreContext:      []string{"> Task :test"},
+		Metadata:        map[string]string{"exit_status": "1"},
+	}
+}
+
+func TestOpenAIClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var body struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Model != "llama3" || len(body.Messages) != 2 || !strings.Contains(body.Messages[1].Content, "OutOfMemoryError") {
+			t.Errorf("request = %+v", body)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"content": reply}}},
+		})
+	}))
+	defer server.Close()
+
+	client, err := New(Config{Endpoint: server.URL + "/v1/", Model: "llama3", APIKey: "sk-test"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	summary, err := client.Summarize(context.Background(), testCard())
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if summary.RootCause != "The JVM ran out of heap." || summary.SuggestedFix != "Raise -Xmx." || summary.Model != "llama3" {
+		t.Errorf("Summarize() = %+v", summary)
+	}
+}
+
+func TestAnthropicClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "key" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"content": []map[string]string{{"type": "text", "text": "Here you go:\n```json\n" + reply + "\n```"}},
+		})
+	}))
+	defer server.Close()
+
+	client, err := New(Config{Provider: ProviderAnthropic, Endpoint: server.URL + "/v1", APIKey: "key"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	summary, err := client.Summarize(context.Background(), testCard())
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if summary.RootCause != "The JVM ran out of heap." || summary.Model != defaults[ProviderAnthropic].Model {
+		t.Errorf("Summarize() = %+v", summary)
+	}
+}
+
+func TestClientHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client, _ := New(Config{Endpoint: server.URL})
+	if _, err := client.Summarize(context.Background(), testCard()); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("Summarize() error = %v, want status 429", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "local endpoint without key", cfg: Config{Endpoint: "http://localhost:11434/v1"}},
+		{name: "hosted with key", cfg: Config{Provider: ProviderAnthropic, APIKey: "key"}},
+		{name: "hosted without key", cfg: Config{Provider: ProviderOpenAI}, wantErr: EnvAPIKey},
+		{name: "unknown provider", cfg: Config{Provider: "gemini"}, wantErr: "unknown LLM provider"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("New() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("New() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "bare json", text: reply, want: "The JVM ran out of heap."},
+		{name: "fenced json", text: "```json\n" + reply + "\n```", want: "The JVM ran out of heap."},
+		{name: "prose", text: "I think the heap is too small.", wantErr: true},
+		{name: "missing root cause", text: `{"suggested_fix": "retry"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSummary(tt.text, "m")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseSummary() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil || got.RootCause != tt.want {
+				t.Errorf("parseSummary() = %+v, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestBuildPrompt_TruncatesLongLines(t *testing.T) {
+	card := testCard()
+	card.PostContext = []string{strings.Repeat("x", 5000)}
+	prompt := BuildPrompt(card)
+	if len(prompt) > 1000 {
+		t.Errorf("prompt is %d bytes, want long lines truncated", len(prompt))
+	}
+	if !strings.Contains(prompt, ">> java.lang.OutOfMemoryError") {
+		t.Errorf("prompt does not mark the error line:\n%s", prompt)
+	}
+}
+
+// fakeClient counts calls and returns a fixed summary or error.
+type fakeClient struct {
+	calls int
+	err   error
+}
+
+func (c *fakeClient) Summarize(ctx context.Context, card contracts.TriageCard) (*contracts.Summary, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &contracts.Summary{RootCause: "cause"}, nil
+}
+
+func TestStage_Selection(t *testing.T) {
+	client := &fakeClient{}
+	stage := NewStage(client)
+
+	apply := func(card contracts.TriageCard) *contracts.Summary {
+		if err := stage.Apply(context.Background(), &card); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		return card.Summary
+	}
+
+	card := testCard()
+	if apply(card) == nil {
+		t.Error("top finding was not summarized")
+	}
+	if apply(card) != nil {
+		t.Error("duplicate message hash was summarized twice")
+	}
+
+	low := testCard()
+	low.MessageHash, low.ConfidenceScore = "low", 0.5
+	if apply(low) != nil {
+		t.Error("low-confidence finding was summarized")
+	}
+
+	passing := testCard()
+	passing.MessageHash, passing.Metadata = "passing", map[string]string{"exit_status": "0"}
+	if apply(passing) != nil {
+		t.Error("finding from a passing job was summarized")
+	}
+
+	for i := range DefaultPerRequest + 2 {
+		c := testCard()
+		c.MessageHash = strings.Repeat("h", i+2)
+		apply(c)
+	}
+	if client.calls != DefaultPerRequest {
+		t.Errorf("calls = %d, want the per-request cap %d", client.calls, DefaultPerRequest)
+	}
+
+	other := testCard()
+	other.RequestID = "req-2"
+	if apply(other) == nil {
+		t.Error("another request's finding was not summarized")
+	}
+}
+
+func TestStage_ErrorLeavesCard(t *testing.T) {
+	stage := NewStage(&fakeClient{err: errors.New("timeout")})
+	card := testCard()
+	if err := stage.Apply(context.Background(), &card); err == nil {
+		t.Error("Apply() error = nil, want client error")
+	}
+	if card.Summary != nil {
+		t.Errorf("Summary = %+v, want nil after an error", card.Summary)
+	}
+}
@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"destill-agent/src/config"
+	"destill-agent/src/contracts"
+)
+
+func TestStylesFromEnv(t *testing.T) {
+	tests := []struct {
+		value      string
+		accessible bool
+	}{
+		{"", false},
+		{"0", false},
+		{"false", false},
+		{"1", true},
+		{"true", true},
+	}
+
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("NO_COLOR", "")
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(EnvAccessible, tt.value)
+			if styles, err := StylesFromEnv(); err != nil || styles.Accessible != tt.accessible {
+				t.Errorf("StylesFromEnv() = accessible %v, %v, want %v", styles.Accessible, err, tt.accessible)
+			}
+		})
+	}
+}
+
+func TestStylesFromEnv_Theme(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("NO_COLOR", "")
+	t.Setenv(EnvAccessible, "")
+
+	t.Setenv(config.EnvTheme, config.ThemeLight)
+	t.Setenv(config.EnvThemeColors, "error=#d70000,muted=244")
+	styles, err := StylesFromEnv()
+	if err != nil {
+		t.Fatalf("StylesFromEnv() error = %v", err)
+	}
+	if styles.PrimaryBlue != LightStyles().PrimaryBlue || styles.ErrorForeground != "#d70000" || styles.TextSecondary != "244" {
+		t.Errorf("StylesFromEnv() = %+v, want the light theme with error and muted replaced", styles)
+	}
+	if styles.Accessible || styles.ColumnSep != "│" {
+		t.Error("StylesFromEnv() limited the light theme to ASCII")
+	}
+
+	// Invalid settings are reported, and the rest still applies
+	t.Setenv(config.EnvTheme, "solarized")
+	t.Setenv(config.EnvThemeColors, "error=red")
+	t.Setenv(config.EnvASCII, "true")
+	styles, err = StylesFromEnv()
+	if err == nil || !strings.Contains(err.Error(), "solarized") || !strings.Contains(err.Error(), "error=red") {
+		t.Errorf("StylesFromEnv() error = %v, want the theme and color reported", err)
+	}
+	if styles.ErrorForeground != DefaultStyles().ErrorForeground || !styles.Accessible || styles.ColumnSep != "|" {
+		t.Errorf("StylesFromEnv() = %+v, want the default theme in ASCII", styles)
+	}
+
+	// NO_COLOR spells out what colors convey
+	t.Setenv(config.EnvTheme, "")
+	t.Setenv(config.EnvThemeColors, "")
+	t.Setenv(config.EnvASCII, "")
+	t.Setenv("NO_COLOR", "1")
+	if styles, _ := StylesFromEnv(); !styles.Accessible {
+		t.Error("StylesFromEnv() with NO_COLOR doesn't spell out colors")
+	}
+}
+
+func TestSetColors_CoversRoles(t *testing.T) {
+	roles := DefaultStyles().colorRoles()
+	for _, role := range config.ThemeColorRoles {
+		if _, ok := roles[role]; !ok {
+			t.Errorf("theme color %q sets no style color", role)
+		}
+	}
+	if len(roles) != len(config.ThemeColorRoles) {
+		t.Errorf("%d style color roles, want the %d of config.ThemeColorRoles", len(roles), len(config.ThemeColorRoles))
+	}
+}
+
+// assertASCII fails when the rendered output contains anything a screen
+// reader or a limited terminal could trip over.
+func assertASCII(t *testing.T, name, rendered string) {
+	t.Helper()
+	for _, r := range ansi.Strip(rendered) {
+		if r > unicode.MaxASCII {
+			t.Errorf("%s contains non-ASCII %q:\n%s", name, r, ansi.Strip(rendered))
+			return
+		}
+	}
+}
+
+func TestAccessibleRendering_ASCIIOnly(t *testing.T) {
+	cards := []contracts.TriageCard{
+		{
+			ID:              "card-1",
+			JobName:         "tests",
+			NormalizedMsg:   "Test failed",
+			ConfidenceScore: 0.95,
+			Metadata: map[string]string{
+				"step_name":                    "go test",
+				contracts.MetadataRetryOutcome: contracts.RetryTransient,
+				contracts.MetadataAttempt:      "1",
+				contracts.MetadataAttempts:     "2",
+			},
+		},
+	}
+
+	model := createTestModel(cards)
+	model.styles = AccessibleStyles()
+	model.header = NewHeaderWithStyles("Destill Analysis", []JobInfo{{Name: "tests", Failed: true}}, model.styles)
+	model.header.SetPendingCount(3)
+	model.header.SetSearch("timeout", true)
+	model.listView.SetStyles(model.styles)
+
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m := updated.(MainModel)
+
+	assertASCII(t, "view", m.View())
+	assertASCII(t, "detail", m.renderDetail(m.items[0], 80))
+
+	progress := NewProgressModel().WithAccessible(true)
+	assertASCII(t, "progress", progress.View())
+	progress, _ = progress.Update(ProgressMsg{Stage: "complete"})
+	assertASCII(t, "progress complete", progress.View())
+}
+
+func TestAccessibleProgress_NoSpinnerTick(t *testing.T) {
+	progress := NewProgressModel().WithAccessible(true)
+	if _, cmd := progress.Update(SpinnerTickMsg{}); cmd != nil {
+		t.Error("accessible progress should not schedule spinner ticks")
+	}
+}
+
+func TestAccessiblePrefix(t *testing.T) {
+	tests := []struct {
+		selected bool
+		tier     int
+		want     string
+	}{
+		{true, 1, "> U "},
+		{false, 1, "  U "},
+		{false, 3, "  N "},
+	}
+
+	for _, tt := range tests {
+		got := accessiblePrefix(tt.selected, tt.tier)
+		if got != tt.want {
+			t.Errorf("accessiblePrefix(%v, %d) = %q, want %q", tt.selected, tt.tier, got, tt.want)
+		}
+		if len(got) != accessiblePrefixWidth {
+			t.Errorf("accessiblePrefix width = %d, want %d", len(got), accessiblePrefixWidth)
+		}
+	}
+}
+
+func TestCategoryBadge(t *testing.T) {
+	card := contracts.TriageCard{Category: contracts.CategoryDocker}
+	if got := categoryBadge(card, false); got != "🐳 " {
+		t.Errorf("categoryBadge() = %q, want the docker icon", got)
+	}
+	if got := categoryBadge(card, true); got != "[docker] " {
+		t.Errorf("categoryBadge(accessible) = %q, want [docker]", got)
+	}
+	if got := categoryBadge(contracts.TriageCard{}, false); got != "" {
+		t.Errorf("categoryBadge(no category) = %q, want none", got)
+	}
+}
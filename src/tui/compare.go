@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"destill-agent/src/contracts"
+)
+
+// renderComparison renders the finding beside its previous occurrence in
+// another build (c key): each build's raw message between its context, with
+// the lines the other build doesn't have marked, so it's clear whether the
+// failure actually changed.
+func (m MainModel) renderComparison(card, previous contracts.TriageCard, maxWidth int) string {
+	content := strings.Builder{}
+
+	title := lipgloss.NewStyle().Foreground(m.styles.PrimaryBlue).Bold(true)
+	fmt.Fprintln(&content, title.Render(Truncate("Compared with the previous build (c to close)", maxWidth, true)))
+
+	currentLines, previousLines := comparisonLines(card), comparisonLines(previous)
+	changed := countMissing(currentLines, previousLines) + countMissing(previousLines, currentLines)
+	verdict, verdictColor := "Unchanged since the previous build", m.styles.AccentGreen
+	switch {
+	case comparisonMessage(card) != comparisonMessage(previous):
+		verdict, verdictColor = "Message changed since the previous build", m.styles.AccentYellow
+	case changed > 0:
+		verdict, verdictColor = fmt.Sprintf("Same message, %d context lines differ", changed), m.styles.AccentYellow
+	}
+	fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(verdictColor).Render(Truncate(verdict, maxWidth, true)))
+	fmt.Fprintln(&content, m.styles.Dim(lipgloss.NewStyle().Foreground(m.styles.TextSecondary)).
+		Render(Truncate("~ marks lines the other build doesn't have", maxWidth, true)))
+	fmt.Fprintln(&content)
+
+	separator := " │ "
+	if m.styles.Accessible {
+		separator = " | "
+	}
+	columnWidth := (maxWidth - lipgloss.Width(separator)) / 2
+	if columnWidth < 10 {
+		columnWidth = 10
+	}
+	left := m.renderOccurrence("This build", card, previousLines, columnWidth)
+	right := m.renderOccurrence("Previous build", previous, currentLines, columnWidth)
+	height := max(lipgloss.Height(left), lipgloss.Height(right))
+	divider := strings.TrimSuffix(strings.Repeat(separator+"\n", height), "\n")
+	divider = lipgloss.NewStyle().Foreground(m.styles.BorderColor).Render(divider)
+
+	fmt.Fprint(&content, lipgloss.JoinHorizontal(lipgloss.Top, left, divider, right))
+	return content.String()
+}
+
+// renderOccurrence renders one column of the comparison: the build, then
+// the card's lines, marking with ~ those missing from other.
+func (m MainModel) renderOccurrence(label string, card contracts.TriageCard, other map[string]bool, width int) string {
+	column := lipgloss.NewStyle().Width(width)
+	content := strings.Builder{}
+
+	fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Bold(true).Render(Truncate(label, width, true)))
+	fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Render(Truncate(card.BuildURL, width, true)))
+	if card.Timestamp != "" {
+		fmt.Fprintln(&content, m.styles.Dim(lipgloss.NewStyle().Foreground(m.styles.TextSecondary)).Render(Truncate(card.Timestamp, width, true)))
+	}
+	fmt.Fprintln(&content)
+
+	contextStyle := m.styles.Dim(lipgloss.NewStyle().Foreground(m.styles.TextSecondary))
+	changedStyle := lipgloss.NewStyle().Foreground(m.styles.AccentYellow)
+	writeLine := func(line string) {
+		line = CleanLogText(line)
+		if strings.TrimSpace(line) == "" {
+			return
+		}
+		if other[line] {
+			fmt.Fprintln(&content, column.Render(contextStyle.Render(Wrap("  "+line, width))))
+			return
+		}
+		fmt.Fprintln(&content, column.Render(changedStyle.Render(Wrap("~ "+line, width))))
+	}
+	for _, line := range card.PreContext {
+		writeLine(line)
+	}
+	message := ">> " + comparisonMessage(card)
+	if !other[comparisonMessage(card)] {
+		message = "~" + message
+	}
+	fmt.Fprintln(&content, column.Render(lipgloss.NewStyle().
+		Foreground(m.styles.ErrorForeground).
+		Background(m.styles.ErrorBackground).
+		Render(Wrap(message, width))))
+	for _, line := range card.PostContext {
+		writeLine(line)
+	}
+
+	return column.Render(strings.TrimSuffix(content.String(), "\n"))
+}
+
+// comparisonMessage returns the card's raw message as shown, falling back
+// to the normalized one like renderDetail.
+func comparisonMessage(card contracts.TriageCard) string {
+	if card.RawMessage == "" {
+		return CleanLogText(card.NormalizedMsg)
+	}
+	return CleanLogText(card.RawMessage)
+}
+
+// comparisonLines returns the set of the card's cleaned log lines: its
+// message and its context.
+func comparisonLines(card contracts.TriageCard) map[string]bool {
+	lines := map[string]bool{comparisonMessage(card): true}
+	for _, line := range append(append([]string{}, card.PreContext...), card.PostContext...) {
+		if line = CleanLogText(line); strings.TrimSpace(line) != "" {
+			lines[line] = true
+		}
+	}
+	return lines
+}
+
+// countMissing counts the lines of lines that other doesn't have.
+func countMissing(lines, other map[string]bool) int {
+	missing := 0
+	for line := range lines {
+		if !other[line] {
+			missing++
+		}
+	}
+	return missing
+}
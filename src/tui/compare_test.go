@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/store"
+)
+
+func TestCompareWithPreviousBuild(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewInMemoryStore()
+	finding := func(id, requestID, build, message, after string) contracts.TriageCard {
+		return contracts.TriageCard{
+			ID:          id,
+			RequestID:   requestID,
+			BuildURL:    build,
+			JobName:     "tests",
+			MessageHash: "hash-1",
+			RawMessage:  message,
+			PreContext:  []string{"=== RUN TestCheckout"},
+			PostContext: []string{after},
+			Metadata:    map[string]string{"pipeline_name": "org/api"},
+		}
+	}
+	previous := finding("finding-1", "req-1", "builds/1", "timeout after 30s", "retrying in 5s")
+	current := finding("finding-2", "req-2", "builds/2", "timeout after 30s", "giving up")
+	st.Store(ctx, "req-1", []contracts.TriageCard{previous})
+	st.Store(ctx, "req-2", []contracts.TriageCard{current})
+
+	model := createTestModel([]contracts.TriageCard{current})
+
+	// Without a store there's nothing to compare with
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	if m := updated.(MainModel); cmd != nil || !strings.Contains(m.header.notice, "stored findings") {
+		t.Errorf("notice = %q, want a hint that comparing needs stored findings", m.header.notice)
+	}
+
+	model.occurrences = st
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	if cmd == nil {
+		t.Fatal("c returned no command")
+	}
+	updated, _ = updated.(MainModel).Update(cmd())
+	m := updated.(MainModel)
+	if m.comparing != "finding-2" {
+		t.Fatalf("comparing = %q, want finding-2", m.comparing)
+	}
+	detail := m.renderComparison(current, m.previous["finding-2"], 100)
+	for _, want := range []string{"Same message, 2 context lines differ", "builds/1", "builds/2", "~ giving up", "~ retrying in 5s", ">> timeout after 30s"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("comparison missing %q:\n%s", want, detail)
+		}
+	}
+	if strings.Contains(detail, "~ === RUN") {
+		t.Error("comparison marks a line both builds have")
+	}
+
+	// c again goes back to the finding's detail, without reading it again
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	if m := updated.(MainModel); cmd != nil || m.comparing != "" {
+		t.Errorf("comparing = %q after second c, want none", m.comparing)
+	}
+
+	// The first build has nothing earlier to compare with
+	model = createTestModel([]contracts.TriageCard{previous})
+	model.occurrences = st
+	_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	updated, _ = model.Update(cmd())
+	if m := updated.(MainModel); m.comparing != "" || !strings.Contains(m.header.notice, "No earlier occurrence") {
+		t.Errorf("notice = %q, want no earlier occurrence", m.header.notice)
+	}
+}
+
+func TestRenderComparison_MessageChanged(t *testing.T) {
+	model := createTestModel(nil)
+	current := contracts.TriageCard{BuildURL: "builds/2", RawMessage: "expected 3, got 4"}
+	previous := contracts.TriageCard{BuildURL: "builds/1", RawMessage: "expected 3, got 5"}
+	if detail := model.renderComparison(current, previous, 100); !strings.Contains(detail, "Message changed") {
+		t.Errorf("comparison doesn't say the message changed:\n%s", detail)
+	}
+	if detail := model.renderComparison(current, current, 100); !strings.Contains(detail, "Unchanged") {
+		t.Errorf("comparison of the same finding isn't unchanged:\n%s", detail)
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/flaky"
 )
 
 const (
@@ -22,6 +25,7 @@ type Delegate struct {
 	RankWidth  int
 	RecurWidth int
 	styles     *StyleConfig
+	grouping   int // Labels rows with their group unless GroupByError
 }
 
 // NewDelegate creates a new triage table delegate with default styles
@@ -126,12 +130,15 @@ func (d Delegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
 	// Calculate available width for snippet
 	// Fixed columns: rank + conf (3) + recurrence + separators (9)
 	fixedWidth := d.RankWidth + 3 + d.RecurWidth + 9
+	if d.styles.Accessible {
+		fixedWidth += accessiblePrefixWidth
+	}
 	availableWidth := m.Width() - fixedWidth - listRenderingOverhead
 
 	var snippet string
 	if availableWidth > 0 {
 		// Get snippet text - use RawMessage, or fall back to Message/PreContext/PostContext
-		snippetText := getSnippetText(entry)
+		snippetText := categoryBadge(entry.Card, d.styles.Accessible) + groupBadge(entry.Card, d.grouping) + markBadge(entry.Mark) + suppressedBadge(entry.Card) + flakinessBadge(entry.Card) + getSnippetText(entry)
 		snippet = TruncateAndPad(snippetText, availableWidth, true)
 	}
 
@@ -141,7 +148,7 @@ func (d Delegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
 	case 1: // Unique failures
 		rankStyle = lipgloss.NewStyle().Foreground(d.styles.Tier1Color).Bold(true)
 	case 3: // Noise
-		rankStyle = lipgloss.NewStyle().Foreground(d.styles.Tier3Color).Faint(true)
+		rankStyle = d.styles.Dim(lipgloss.NewStyle().Foreground(d.styles.Tier3Color))
 	default:
 		rankStyle = lipgloss.NewStyle().Foreground(d.styles.TextSecondary)
 	}
@@ -149,28 +156,106 @@ func (d Delegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
 	rankCol := rankStyle.Render(rankNum)
 
 	// Build row: rank (colored) │ conf │ recur │ snippet
-	// Tier 3 (noise) and low confidence cards (< 0.80) are dimmed
+	// Tier 3 (noise), low confidence cards (< 0.80), and marked findings are dimmed
 	isLowConfidence := entry.Card.ConfidenceScore < 0.80
 	isNoise := entry.Tier == 3
+	isMarked := entry.Mark != "" || entry.Card.Suppressed()
 
 	var rowStyle lipgloss.Style
 	if isSelected {
 		rowStyle = lipgloss.NewStyle().Bold(true).Foreground(d.styles.PrimaryBlue).Background(d.styles.SelectedColor)
-	} else if isNoise || isLowConfidence {
-		rowStyle = lipgloss.NewStyle().Foreground(d.styles.TextSecondary).Faint(true)
+	} else if isNoise || isLowConfidence || isMarked {
+		rowStyle = d.styles.Dim(lipgloss.NewStyle().Foreground(d.styles.TextSecondary))
 	} else {
 		rowStyle = lipgloss.NewStyle().Foreground(d.styles.TextSecondary)
 	}
 
 	// Build row with styled rank and rest of content
-	restOfLine := fmt.Sprintf(" │ %s │ %s │ %s", confCol, recurCol, snippet)
+	sep := d.styles.ColumnSep
+	restOfLine := fmt.Sprintf(" %s %s %s %s %s %s", sep, confCol, sep, recurCol, sep, snippet)
+
+	// Selection and tier are otherwise only conveyed by color
+	prefix := ""
+	if d.styles.Accessible {
+		prefix = accessiblePrefix(isSelected, entry.Tier)
+	}
 
 	if isSelected {
 		// When selected, apply uniform style to entire row
-		line := fmt.Sprintf("%s │ %s │ %s │ %s", rankNum, confCol, recurCol, snippet)
+		line := prefix + rankNum + restOfLine
 		fmt.Fprint(w, rowStyle.Render(line))
 	} else {
 		// When not selected, keep rank colored by tier
-		fmt.Fprint(w, rankCol+rowStyle.Render(restOfLine))
+		fmt.Fprint(w, prefix+rankCol+rowStyle.Render(restOfLine))
+	}
+}
+
+// categoryIcons are the icons of the finding categories in the list.
+var categoryIcons = map[string]string{
+	contracts.CategoryGo:         "🐹",
+	contracts.CategoryJVM:        "☕",
+	contracts.CategoryPython:     "🐍",
+	contracts.CategoryNode:       "🟢",
+	contracts.CategoryDocker:     "🐳",
+	contracts.CategoryTerraform:  "🟪",
+	contracts.CategoryKubernetes: "🚢",
+}
+
+// categoryBadge shows the icon of a finding's category, or its name in the
+// accessible profile, which has no emoji.
+func categoryBadge(card contracts.TriageCard, accessible bool) string {
+	icon, ok := categoryIcons[card.Category]
+	if !ok {
+		return ""
+	}
+	if accessible {
+		return "[" + card.Category + "] "
+	}
+	return icon + " "
+}
+
+// markBadge shows the triage mark the user set on a finding (see marks).
+func markBadge(mark string) string {
+	if mark == "" {
+		return ""
+	}
+	return "[" + mark + "] "
+}
+
+// suppressedBadge marks findings suppressed by the repo's ignore file,
+// shown with S.
+func suppressedBadge(card contracts.TriageCard) string {
+	if !card.Suppressed() {
+		return ""
+	}
+	return "[suppressed] "
+}
+
+// flakinessBadge marks findings classified against earlier builds of the
+// pipeline (see flaky.Classify).
+func flakinessBadge(card contracts.TriageCard) string {
+	switch {
+	case flaky.IsLikelyFlaky(card):
+		return "[flaky] "
+	case flaky.IsNewRegression(card):
+		return "[regression] "
+	}
+	return ""
+}
+
+// accessiblePrefixWidth is the width of accessiblePrefix output.
+const accessiblePrefixWidth = 4
+
+// accessiblePrefix spells out selection and tier for accessible rows,
+// e.g. "> U " for the selected unique finding.
+func accessiblePrefix(selected bool, tier int) string {
+	marker := " "
+	if selected {
+		marker = ">"
+	}
+	tierLetter := "U"
+	if tier == 3 {
+		tierLetter = "N"
 	}
+	return marker + " " + tierLetter + " "
 }
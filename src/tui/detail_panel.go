@@ -2,9 +2,15 @@ package tui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/flaky"
+	"destill-agent/src/provider"
+	"destill-agent/src/ranking"
 )
 
 // renderDetail renders the detail content for a triage item
@@ -28,19 +34,122 @@ func (m MainModel) renderDetail(item Item, maxWidth int) string {
 		Foreground(m.styles.PrimaryBlue).
 		Bold(true).
 		Render(headerText)
-	fmt.Fprintf(&content, "%s\n\n", header)
+	fmt.Fprintf(&content, "%s\n", header)
+
+	// Who is triaging the finding
+	if assignee := item.Card.Assignee; assignee != "" {
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.AccentGreen).Render(Truncate("Assigned to "+assignee, maxWidth, true)))
+	}
+	// Runner details parsed from the job's environment dump
+	if runner := formatRunnerMetadata(item.Card.Metadata); runner != "" {
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Render(Truncate(runner, maxWidth, true)))
+	}
+	// How often the message shows up in recent builds of the pipeline
+	if history := formatBuildRecurrence(item.Card); history != "" {
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Render(Truncate(history, maxWidth, true)))
+	}
+	// Whether the failure is intermittent or new on the pipeline
+	if note := formatFlakiness(item.Card); note != "" {
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.AccentYellow).Render(Truncate(note, maxWidth, true)))
+	}
+	// Which rule of the repo's ignore file suppressed the finding
+	if note := formatSuppression(item.Card); note != "" {
+		fmt.Fprintln(&content, m.styles.Dim(lipgloss.NewStyle().Foreground(m.styles.TextSecondary)).Render(Truncate(note, maxWidth, true)))
+	}
+	// The other jobs a finding merged across jobs appeared in
+	if note := formatJobs(item.Card); note != "" {
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Render(Truncate(note, maxWidth, true)))
+	}
+	// Whether the job failed because a job it depends on did
+	if note := formatCascade(item.Card); note != "" {
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Render(Truncate(note, maxWidth, true)))
+	}
+	// How close the finding is to where its job exited
+	if note := formatExitDistance(item.Card); note != "" {
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Render(Truncate(note, maxWidth, true)))
+	}
+	// Where a nightly failure came in since the last clean nightly
+	if hint := formatBisectHint(item.Card); hint != "" {
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Render(Truncate(hint, maxWidth, true)))
+	}
+	// Findings that went away when the job was retried
+	if card := item.Card; ranking.IsTransient(card) {
+		note := fmt.Sprintf("Transient: gone after retry (attempt %s of %s)",
+			card.Metadata[contracts.MetadataAttempt], card.Metadata[contracts.MetadataAttempts])
+		if !m.styles.Accessible {
+			note = "↻ " + note
+		}
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.AccentYellow).Render(Truncate(note, maxWidth, true)))
+	}
+	// Which of the compared requests have the finding (view --compare)
+	if note := ranking.ComparisonNote(item.Card); note != "" {
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.AccentYellow).Render(Truncate(note, maxWidth, true)))
+	}
+	// Findings that appeared since the build's previous analysis
+	if card := item.Card; ranking.IsNewInRerun(card) {
+		note := "New in this run (not in " + card.Metadata[contracts.MetadataPreviousRequest] + ")"
+		if !m.styles.Accessible {
+			note = "✚ " + note
+		}
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.AccentYellow).Render(Truncate(note, maxWidth, true)))
+	}
+	fmt.Fprintln(&content)
+
+	// LLM root-cause summary, when the summarization stage ran
+	if summary := item.Card.Summary; summary != nil {
+		label := "Summary:"
+		if summary.Model != "" {
+			label = fmt.Sprintf("Summary (%s):", summary.Model)
+		}
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.AccentYellow).Bold(true).Render(Truncate(label, maxWidth, true)))
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextPrimary).Render(Wrap(summary.RootCause, maxWidth)))
+		if summary.SuggestedFix != "" {
+			fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.AccentGreen).Render(Wrap("Fix: "+summary.SuggestedFix, maxWidth)))
+		}
+		fmt.Fprintln(&content)
+	}
+
+	// Known fix for a well-known failure, from the remediation rules
+	if remediation := item.Card.Remediation; remediation != nil {
+		label := fmt.Sprintf("Known fix (%s):", remediation.Name)
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.AccentGreen).Bold(true).Render(Truncate(label, maxWidth, true)))
+		if remediation.Description != "" {
+			fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextPrimary).Render(Wrap(remediation.Description, maxWidth)))
+		}
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.AccentGreen).Render(Wrap("$ "+remediation.Fix, maxWidth)))
+		fmt.Fprintln(&content)
+	}
+
+	// Context from the stored log replaces the card's when expanded
+	preContext, postContext := item.GetPreContext(), item.GetPostContext()
+	preLabel, postLabel := "Pre-Context:", "Post-Context:"
+	contextNote := item.Card.ContextNote
+	if expanded, ok := m.expanded[item.Card.ID]; ok {
+		preContext, postContext = expanded.Before, expanded.After
+		preLabel = fmt.Sprintf("Pre-Context (expanded, line %d):", expanded.Line)
+		postLabel = "Post-Context (expanded):"
+		if expanded.Truncated {
+			postLabel = "Post-Context (expanded, cut at size limit):"
+		}
+		contextNote = ""
+	}
+
+	// Say why the card's context is short, e.g. truncated at chunk start
+	if contextNote != "" {
+		fmt.Fprintln(&content, m.styles.Dim(lipgloss.NewStyle().Foreground(m.styles.TextSecondary)).Render(Truncate("Context "+contextNote, maxWidth, true)))
+		fmt.Fprintln(&content)
+	}
 
 	// Pre-context - clean and wrap each line
-	preContext := item.GetPreContext()
 	if len(preContext) > 0 {
-		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Bold(true).Render("Pre-Context:"))
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Bold(true).Render(preLabel))
 		for _, line := range preContext {
 			// Clean Buildkite escape sequences and normalize
 			cleanLine := CleanLogText(line)
 			if strings.TrimSpace(cleanLine) != "" {
 				// Wrap line before styling
 				wrapped := Wrap(cleanLine, maxWidth)
-				fmt.Fprint(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Faint(true).Render(wrapped))
+				fmt.Fprint(&content, m.styles.Dim(lipgloss.NewStyle().Foreground(m.styles.TextSecondary)).Render(wrapped))
 				fmt.Fprintln(&content)
 			}
 		}
@@ -64,16 +173,15 @@ func (m MainModel) renderDetail(item Item, maxWidth int) string {
 	fmt.Fprintln(&content, "")
 
 	// Post-context - clean and wrap each line
-	postContext := item.GetPostContext()
 	if len(postContext) > 0 {
-		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Bold(true).Render("Post-Context:"))
+		fmt.Fprintln(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Bold(true).Render(postLabel))
 		for _, line := range postContext {
 			// Clean Buildkite escape sequences and normalize
 			cleanLine := CleanLogText(line)
 			if strings.TrimSpace(cleanLine) != "" {
 				// Wrap line before styling
 				wrapped := Wrap(cleanLine, maxWidth)
-				fmt.Fprint(&content, lipgloss.NewStyle().Foreground(m.styles.TextSecondary).Faint(true).Render(wrapped))
+				fmt.Fprint(&content, m.styles.Dim(lipgloss.NewStyle().Foreground(m.styles.TextSecondary)).Render(wrapped))
 				fmt.Fprintln(&content)
 			}
 		}
@@ -88,6 +196,9 @@ func (m *MainModel) updateDetailContent(item Item) {
 	// Subtract a small amount for internal padding.
 	maxWidth := m.detailViewport.Width - 2 // 1 char padding on each side
 	content := m.renderDetail(item, maxWidth)
+	if previous, ok := m.previous[item.Card.ID]; ok && m.comparing == item.Card.ID {
+		content = m.renderComparison(item.Card, previous, maxWidth)
+	}
 	m.detailViewport.SetContent(content)
 }
 
@@ -97,6 +208,20 @@ func (m MainModel) renderDetailPanel(width, height int) string {
 		// Add header row with job name
 		// Truncate to width-4 to account for padding (2 chars)
 		jobText := fmt.Sprintf("Job: %s", selectedItem.Card.JobName)
+		stepSep := "›"
+		if m.styles.Accessible {
+			stepSep = ">"
+		}
+		if step := selectedItem.Card.Metadata["step_name"]; step != "" {
+			jobText += fmt.Sprintf(" %s Step: %s", stepSep, step)
+		}
+		if artifact := selectedItem.Card.Metadata[contracts.MetadataArtifact]; artifact != "" {
+			jobText += fmt.Sprintf(" %s Artifact: %s", stepSep, artifact)
+		}
+		if m.styles.Accessible && m.detailFocused {
+			// Focus is otherwise only shown by the border color
+			jobText = "[Focused] " + jobText
+		}
 		truncatedJobText := Truncate(jobText, width-4, true)
 		headerRow := lipgloss.NewStyle().
 			Foreground(m.styles.PrimaryBlue).
@@ -113,7 +238,7 @@ func (m MainModel) renderDetailPanel(width, height int) string {
 
 		detailWithHeader := lipgloss.JoinVertical(lipgloss.Left, headerRow,
 			lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
+				Border(m.styles.Border).
 				BorderForeground(borderStyle).
 				Width(width-2).
 				Height(height).
@@ -129,14 +254,115 @@ func (m MainModel) renderDetailPanel(width, height int) string {
 		Padding(0, 1).
 		Render(" ")
 
-	emptyStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
+	emptyStyle := m.styles.Dim(lipgloss.NewStyle().
+		Border(m.styles.Border).
 		BorderForeground(m.styles.BorderColor).
 		Width(width).
 		Height(height).
 		Align(lipgloss.Center, lipgloss.Center).
-		Foreground(m.styles.TextSecondary).
-		Faint(true)
+		Foreground(m.styles.TextSecondary))
+
+	placeholder := "← Navigate list to view details"
+	if m.styles.Accessible {
+		placeholder = "Select a finding in the list to view details"
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, placeholderRow, emptyStyle.Render(placeholder))
+}
+
+// formatRunnerMetadata renders the runner environment metadata as
+// "key: value | ..." in display order, or "" when there is none.
+func formatRunnerMetadata(metadata map[string]string) string {
+	var parts []string
+	for _, key := range contracts.RunnerMetadataKeys {
+		if value := metadata[key]; value != "" {
+			parts = append(parts, fmt.Sprintf("%s: %s", runnerMetadataLabel(key), value))
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// formatBuildRecurrence describes the cross-build recurrence of a finding
+// (see store.MarkBuildRecurrence), e.g. "Seen in 14 of the last 20 builds
+// of org/backend", or returns "" when it wasn't looked up.
+func formatBuildRecurrence(card contracts.TriageCard) string {
+	seen, builds := card.GetBuildRecurrence()
+	if builds == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Seen in %d of the last %d builds of %s", seen, builds, card.Metadata["pipeline_name"])
+}
+
+// formatSuppression names the ignore rule that suppressed the finding and
+// its reason, or returns "" when none did.
+func formatSuppression(card contracts.TriageCard) string {
+	if !card.Suppressed() {
+		return ""
+	}
+	note := "Suppressed by " + card.Metadata[contracts.MetadataSuppressedBy]
+	if reason := card.Metadata[contracts.MetadataSuppressedReason]; reason != "" {
+		note += ": " + reason
+	}
+	return note
+}
+
+// formatJobs names the other jobs a finding merged across jobs appeared in
+// (see contracts.MetadataJobs), or returns "" for other findings.
+func formatJobs(card contracts.TriageCard) string {
+	jobs := card.Jobs()
+	others := slices.DeleteFunc(slices.Clone(jobs), func(job string) bool { return job == card.JobName })
+	if len(others) == 0 {
+		return ""
+	}
+	return "Also in " + strings.Join(others, contracts.FailedUpstreamSeparator)
+}
+
+// formatCascade names the failed jobs upstream of a downstream finding's
+// job, whose failure it likely echoes, or returns "" for other findings.
+func formatCascade(card contracts.TriageCard) string {
+	if card.Metadata[contracts.MetadataCascade] != contracts.CascadeDownstream {
+		return ""
+	}
+	return "Downstream of failed job " + card.Metadata[contracts.MetadataFailedUpstream]
+}
+
+// formatExitDistance says how many lines before its failed job's exit line
+// a finding is, or returns "" when it isn't near it.
+func formatExitDistance(card contracts.TriageCard) string {
+	if card.Metadata[contracts.MetadataExitAnchor] == "true" {
+		return "Last error before the job exited"
+	}
+	distance := card.Metadata[contracts.MetadataExitDistance]
+	if distance == "" {
+		return ""
+	}
+	return distance + " lines before the job exited"
+}
+
+// formatFlakiness describes how the finding compares with earlier builds of
+// its pipeline (see flaky.Classify), or returns "" when it wasn't
+// classified or is an ongoing failure.
+func formatFlakiness(card contracts.TriageCard) string {
+	switch {
+	case flaky.IsLikelyFlaky(card):
+		return "Likely flaky: failed intermittently in earlier builds of " + card.Metadata["pipeline_name"]
+	case flaky.IsNewRegression(card):
+		return "New regression: not seen in earlier builds of " + card.Metadata["pipeline_name"]
+	}
+	return ""
+}
+
+// formatBisectHint describes the commits a nightly failure was introduced
+// in (see provider.FindCommitRange), e.g. "Failure introduced somewhere in
+// 14 commits between 1a2b3c4 and 5d6e7f8", or returns "" when unknown.
+func formatBisectHint(card contracts.TriageCard) string {
+	if r := provider.CommitRangeOf(card.Metadata); r != nil {
+		return "Failure " + r.String()
+	}
+	return ""
+}
 
-	return lipgloss.JoinVertical(lipgloss.Left, placeholderRow, emptyStyle.Render("← Navigate list to view details"))
+// runnerMetadataLabel shortens a runner metadata key for display and
+// filtering: runner_queue -> queue, container_image -> container_image.
+func runnerMetadataLabel(key string) string {
+	return strings.TrimPrefix(key, "runner_")
 }
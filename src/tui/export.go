@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/report"
+	"destill-agent/src/store"
+)
+
+// Formats findings are exported in (e and E keys).
+const (
+	exportMarkdown = "md"
+	exportJSON     = "json"
+)
+
+// exportDir is the directory findings are exported to, replaced in tests
+var exportDir = "."
+
+// cardText formats a finding for pasting into Slack or a ticket: its job,
+// severity, and link, then its log lines with the finding marked. expanded
+// replaces the card's context when it was read from the stored log.
+func cardText(card contracts.TriageCard, expanded *store.FindingContext) string {
+	var b strings.Builder
+
+	about := []string{card.JobName}
+	if card.Severity != "" {
+		about = append(about, card.Severity)
+	}
+	about = append(about, fmt.Sprintf("confidence %.2f", card.ConfidenceScore))
+	fmt.Fprintln(&b, strings.Join(about, " · "))
+	if link := card.Metadata[contracts.MetadataProviderLink]; link != "" {
+		fmt.Fprintln(&b, link)
+	} else if card.BuildURL != "" {
+		fmt.Fprintln(&b, card.BuildURL)
+	}
+	fmt.Fprintln(&b)
+
+	preContext, postContext := card.PreContext, card.PostContext
+	if expanded != nil {
+		preContext, postContext = expanded.Before, expanded.After
+	}
+	message := card.RawMessage
+	if message == "" {
+		message = card.NormalizedMsg
+	}
+	for _, line := range preContext {
+		fmt.Fprintln(&b, "   "+CleanLogText(line))
+	}
+	fmt.Fprintln(&b, ">> "+CleanLogText(message))
+	for _, line := range postContext {
+		fmt.Fprintln(&b, "   "+CleanLogText(line))
+	}
+	return b.String()
+}
+
+// copyCard returns a command that copies the card and its log context to the clipboard
+func copyCard(card contracts.TriageCard, expanded *store.FindingContext) tea.Cmd {
+	return func() tea.Msg {
+		if err := copyToClipboard(cardText(card, expanded)); err != nil {
+			return noticeMsg{text: fmt.Sprintf("Copy failed: %v", err)}
+		}
+		return noticeMsg{text: "Copied finding with context to clipboard"}
+	}
+}
+
+// exportFindings returns a command that writes the cards to a new file in
+// exportDir, as a Markdown report (see report.Markdown) or as JSON that
+// 'destill stats --input' and 'destill flaky --input' read.
+func exportFindings(cards []contracts.TriageCard, format string, now time.Time) tea.Cmd {
+	return func() tea.Msg {
+		if len(cards) == 0 {
+			return noticeMsg{text: "No findings to export"}
+		}
+
+		var data []byte
+		switch format {
+		case exportJSON:
+			var err error
+			if data, err = json.MarshalIndent(cards, "", "  "); err != nil {
+				return noticeMsg{text: fmt.Sprintf("Export failed: %v", err)}
+			}
+			data = append(data, '\n')
+		default:
+			data = report.Markdown(cards, report.Options{Source: cards[0].BuildURL, Generated: now})
+		}
+
+		name := fmt.Sprintf("destill-findings-%s.%s", now.Format("20060102-150405"), format)
+		path := filepath.Join(exportDir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return noticeMsg{text: fmt.Sprintf("Export failed: %v", err)}
+		}
+		return noticeMsg{text: fmt.Sprintf("Exported %d findings to %s", len(cards), path)}
+	}
+}
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/store"
+)
+
+func TestCardText(t *testing.T) {
+	card := contracts.TriageCard{
+		JobName:         "tests",
+		Severity:        "ERROR",
+		ConfidenceScore: 0.9,
+		BuildURL:        "https://buildkite.com/org/pipeline/builds/1",
+		RawMessage:      "ERROR: connection refused",
+		PreContext:      []string{"connecting to db:5432"},
+		PostContext:     []string{"retrying"},
+	}
+
+	text := cardText(card, nil)
+	for _, want := range []string{"tests · ERROR · confidence 0.90", card.BuildURL, "   connecting to db:5432\n>> ERROR: connection refused\n   retrying\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("cardText() missing %q in:\n%s", want, text)
+		}
+	}
+
+	expanded := &store.FindingContext{Before: []string{"earlier"}, After: []string{"later"}}
+	if text := cardText(card, expanded); !strings.Contains(text, "   earlier\n>> ERROR: connection refused\n   later\n") || strings.Contains(text, "retrying") {
+		t.Errorf("cardText() with expanded context:\n%s", text)
+	}
+}
+
+func TestMainModel_CopyAndExport(t *testing.T) {
+	defer func(origCopy func(string) error, origDir string) {
+		copyToClipboard, exportDir = origCopy, origDir
+	}(copyToClipboard, exportDir)
+
+	var copied string
+	copyToClipboard = func(text string) error { copied = text; return nil }
+	exportDir = t.TempDir()
+
+	cards := []contracts.TriageCard{
+		{JobName: "tests", NormalizedMsg: "connection refused", RawMessage: "ERROR: connection refused",
+			PreContext: []string{"connecting"}, BuildURL: "https://buildkite.com/org/pipeline/builds/1"},
+		{JobName: "lint", NormalizedMsg: "unused variable", RawMessage: "lint: unused variable x"},
+	}
+	model := createTestModel(cards)
+
+	press := func(m MainModel, key string) MainModel {
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		m = updated.(MainModel)
+		if cmd == nil {
+			t.Fatalf("key %q returned no command", key)
+		}
+		updated, _ = m.Update(cmd())
+		return updated.(MainModel)
+	}
+
+	m := press(model, "Y")
+	if !strings.Contains(copied, "   connecting\n>> ERROR: connection refused") {
+		t.Errorf("copied %q, want the finding with its context", copied)
+	}
+
+	// Only the findings shown are exported
+	m.searchQuery = "lint"
+	m.applyFilter()
+	for _, key := range []string{"e", "E"} {
+		m = press(m, key)
+		if !strings.Contains(m.header.notice, "Exported 1 findings") {
+			t.Errorf("notice after %q = %q, want an export confirmation", key, m.header.notice)
+		}
+	}
+
+	md, _ := filepath.Glob(filepath.Join(exportDir, "destill-findings-*.md"))
+	js, _ := filepath.Glob(filepath.Join(exportDir, "destill-findings-*.json"))
+	if len(md) != 1 || len(js) != 1 {
+		t.Fatalf("exported files: %v %v, want one Markdown and one JSON file", md, js)
+	}
+	data, err := os.ReadFile(md[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "unused variable") || strings.Contains(string(data), "connection refused") {
+		t.Errorf("Markdown export:\n%s", data)
+	}
+	data, err = os.ReadFile(js[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported []contracts.TriageCard
+	if err := json.Unmarshal(data, &exported); err != nil || len(exported) != 1 || exported[0].JobName != "lint" {
+		t.Errorf("JSON export = %+v, error %v", exported, err)
+	}
+
+	m.searchQuery = "nothing matches"
+	m.applyFilter()
+	if m = press(m, "e"); m.header.notice != "No findings to export" {
+		t.Errorf("notice = %q, want nothing to export", m.header.notice)
+	}
+}
+
+func TestExportFindingsNamesFileByTime(t *testing.T) {
+	defer func(orig string) { exportDir = orig }(exportDir)
+	exportDir = t.TempDir()
+
+	now := time.Date(2026, 3, 2, 14, 30, 5, 0, time.UTC)
+	exportFindings([]contracts.TriageCard{{JobName: "tests"}}, exportJSON, now)()
+	if _, err := os.Stat(filepath.Join(exportDir, "destill-findings-20260302-143005.json")); err != nil {
+		t.Error(err)
+	}
+}
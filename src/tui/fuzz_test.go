@@ -0,0 +1,39 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"destill-agent/src/fuzzlimit"
+)
+
+func FuzzCleanLogText(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"\x1b_bk;t=1700000000000\x07ERROR: build failed",
+		"\x1b]0;title\x1b\\\x1b[31;1mred\x1b[0m",
+		"\x1bPdcs payload\x1b\\text",
+		"line1\r\r\nline2\r\nline3\rline4",
+		"\x00\x01\x08\x0b\x0c\x1a\x1c\x1f\x7f",
+		strings.Repeat("\x1b[", 1000),
+		strings.Repeat("\x1b]", 1000) + "\x07",
+		"日本語\t\x1b[1mエラー\x1b[0m",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		// Unterminated escape sequences are what could rescan the text
+		var got string
+		fuzzlimit.Check(t, fuzzlimit.PerInput, fmt.Sprintf("CleanLogText(%d bytes)", len(s)), func() {
+			got = CleanLogText(s)
+		})
+		if strings.Contains(got, "\r") {
+			t.Errorf("CleanLogText(%q) = %q, still has a carriage return", s, got)
+		}
+		if c0ControlPattern.MatchString(got) {
+			t.Errorf("CleanLogText(%q) = %q, still has control characters", s, got)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"maps"
+	"slices"
+	"sort"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/rules"
+)
+
+// Grouping values for the list's primary axis (v cycles)
+const (
+	GroupByError    = 0 // One row per distinct error (message hash), ranked (default)
+	GroupByJob      = 1 // One row per error in each job, ranked within the job
+	GroupBySeverity = 2 // One row per distinct error, most severe first
+)
+
+// groupingNames names each grouping for the header and notices
+var groupingNames = []string{"error", "job", "severity"}
+
+// groupedItems returns the list's items for the model's grouping, from the
+// findings grouped by message hash, or for GroupByJob from all the cards
+func (m *MainModel) groupedItems() []Item {
+	switch m.grouping {
+	case GroupByJob:
+		return groupByJob(m.cards)
+	case GroupBySeverity:
+		return groupBySeverity(hashMapToSortedItems(m.hashMap))
+	}
+	return hashMapToSortedItems(m.hashMap)
+}
+
+// cycleGrouping moves the list to the next grouping, after severity back
+// to grouping by error
+func (m *MainModel) cycleGrouping() {
+	m.grouping = (m.grouping + 1) % len(groupingNames)
+	m.items = m.groupedItems()
+	m.listView.SetGrouping(m.grouping)
+	m.header.SetGrouping(groupingName(m.grouping))
+	m.header.SetNotice("Grouped by " + groupingNames[m.grouping])
+	m.applyFilter()
+}
+
+// groupingName returns the name the header shows for grouping, or "" for
+// the default grouping by error
+func groupingName(grouping int) string {
+	if grouping == GroupByError {
+		return ""
+	}
+	return groupingNames[grouping]
+}
+
+// groupByJob ranks each job's findings on their own, so an error in
+// several jobs (even one merged across them) is listed under each of them,
+// and lists the jobs whose top finding is a unique failure first, then by
+// its confidence
+func groupByJob(cards []contracts.TriageCard) []Item {
+	byJob := make(map[string]map[string]*Item)
+	var perJob []contracts.TriageCard
+	for _, card := range cards {
+		perJob = append(perJob, card.PerJob()...)
+	}
+	for _, card := range perJob {
+		// Counting duplicates sets the metadata, which the cards share
+		card.Metadata = maps.Clone(card.Metadata)
+		if byJob[card.JobName] == nil {
+			byJob[card.JobName] = make(map[string]*Item)
+		}
+		if existing, ok := byJob[card.JobName][card.MessageHash]; ok {
+			mergeDuplicate(existing, card)
+		} else {
+			byJob[card.JobName][card.MessageHash] = &Item{Card: card}
+		}
+	}
+
+	var jobs [][]Item
+	for _, hashMap := range byJob {
+		if items := hashMapToSortedItems(hashMap); len(items) > 0 {
+			jobs = append(jobs, items)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		a, b := jobs[i][0], jobs[j][0]
+		switch {
+		case a.Tier != b.Tier:
+			return a.Tier < b.Tier
+		case a.Card.ConfidenceScore != b.Card.ConfidenceScore:
+			return a.Card.ConfidenceScore > b.Card.ConfidenceScore
+		}
+		return a.Card.JobName < b.Card.JobName
+	})
+	var items []Item
+	for _, job := range jobs {
+		items = append(items, job...)
+	}
+	return items
+}
+
+// groupBySeverity orders ranked items by severity, most severe first (see
+// rules.Severities), keeping their rank order within each severity
+func groupBySeverity(items []Item) []Item {
+	sort.SliceStable(items, func(i, j int) bool {
+		return severityOrder(items[i].Card.Severity) < severityOrder(items[j].Card.Severity)
+	})
+	return items
+}
+
+// severityOrder returns the position of severity in rules.Severities, with
+// unknown severities last
+func severityOrder(severity string) int {
+	if i := slices.Index(rules.Severities, severity); i >= 0 {
+		return i
+	}
+	return len(rules.Severities)
+}
+
+// groupBadge labels a row with its group, e.g. "[build] " for GroupByJob,
+// or "" for the default grouping by error
+func groupBadge(card contracts.TriageCard, grouping int) string {
+	switch grouping {
+	case GroupByJob:
+		return "[" + card.JobName + "] "
+	case GroupBySeverity:
+		if card.Severity == "" {
+			return "[?] "
+		}
+		return "[" + card.Severity + "] "
+	}
+	return ""
+}
+
+// cloneCards copies cards with metadata of their own
+func cloneCards(cards []contracts.TriageCard) []contracts.TriageCard {
+	clones := make([]contracts.TriageCard, len(cards))
+	for i, card := range cards {
+		card.Metadata = maps.Clone(card.Metadata)
+		clones[i] = card
+	}
+	return clones
+}
@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"destill-agent/src/contracts"
+)
+
+func TestCycleGrouping(t *testing.T) {
+	cards := []contracts.TriageCard{
+		{ID: "finding-1", JobName: "lint", MessageHash: "hash-timeout", Severity: "ERROR", RawMessage: "timeout", NormalizedMsg: "timeout", ConfidenceScore: 0.9},
+		{ID: "finding-2", JobName: "tests", MessageHash: "hash-timeout", Severity: "ERROR", RawMessage: "timeout", NormalizedMsg: "timeout", ConfidenceScore: 0.9},
+		{ID: "finding-3", JobName: "tests", MessageHash: "hash-panic", Severity: "FATAL", RawMessage: "panic", NormalizedMsg: "panic", ConfidenceScore: 0.8},
+		{ID: "finding-4", JobName: "lint", MessageHash: "hash-slow", Severity: "WARN", RawMessage: "slow", NormalizedMsg: "slow", ConfidenceScore: 0.95},
+	}
+	state := buildInitialState(cards)
+	model := createTestModel(nil)
+	model.hashMap, model.cards = state.hashMap, cloneCards(cards)
+	model.items = state.items
+	model.listView.SetItems(model.items)
+
+	shown := func(m MainModel) []string {
+		var rows []string
+		for _, listItem := range m.listView.list.Items() {
+			item := listItem.(Item)
+			rows = append(rows, item.Card.JobName+":"+item.Card.RawMessage)
+		}
+		return rows
+	}
+	press := func(m MainModel) MainModel {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+		return updated.(MainModel)
+	}
+
+	// By job, the timeout in both jobs is listed under each
+	m := press(model)
+	if got := shown(m); len(got) != 4 || strings.Split(got[0], ":")[0] != strings.Split(got[1], ":")[0] {
+		t.Errorf("grouped by job = %v, want 4 rows with each job's together", got)
+	}
+	if m.header.grouping != "job" || !strings.Contains(m.header.notice, "Grouped by job") {
+		t.Errorf("header grouping = %q, notice = %q", m.header.grouping, m.header.notice)
+	}
+
+	// By severity, one row per error, most severe first
+	m = press(m)
+	if got := shown(m); len(got) != 3 || !strings.HasSuffix(got[0], "panic") || !strings.HasSuffix(got[2], "slow") {
+		t.Errorf("grouped by severity = %v, want panic, timeout, slow", got)
+	}
+	if badge := groupBadge(cards[3], GroupBySeverity); badge != "[WARN] " {
+		t.Errorf("groupBadge() = %q, want [WARN]", badge)
+	}
+
+	// And back to one row per error, counting the timeout in both jobs
+	m = press(m)
+	if got := shown(m); len(got) != 3 || m.header.grouping != "" {
+		t.Errorf("grouped by error = %v (header %q), want 3 rows", got, m.header.grouping)
+	}
+	for _, listItem := range m.listView.list.Items() {
+		if item := listItem.(Item); item.Card.MessageHash == "hash-timeout" && item.GetRecurrence() != 2 {
+			t.Errorf("timeout recurrence = %d after regrouping, want 2", item.GetRecurrence())
+		}
+	}
+}
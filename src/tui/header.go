@@ -21,6 +21,9 @@ type Header struct {
 	currentFilterIndex int
 	searchQuery        string
 	searchMode         bool
+	notice             string // Result of the last action, e.g. "Copied finding to clipboard"
+	rerun              string // Comparison with the build's previous analysis, if any
+	comparison         string // Comparison of the two requests shown (view --compare), if any
 	styles             *StyleConfig
 
 	// Streaming status
@@ -34,6 +37,9 @@ type Header struct {
 	uniqueCount int
 	noiseCount  int
 	tierFilter  int // 0=all (default), 1=unique only, 2=noise only
+	teamFilter  string
+	sideFilter  string // Which of the compared requests' findings are shown, "" for all
+	grouping    string // Grouping of the list other than by error, e.g. "job"
 }
 
 // NewHeaderWithStyles creates a new header with custom styles
@@ -59,8 +65,11 @@ func (h Header) formatFilterDisplay(filterName string, failed bool) string {
 	displayName := filterName
 	if failed {
 		// Apply red color to failed job name
-		redStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000"))
+		redStyle := lipgloss.NewStyle().Foreground(h.styles.ErrorForeground)
 		displayName = redStyle.Render(filterName)
+		if h.styles.Accessible {
+			displayName += " (failed)"
+		}
 	}
 	return fmt.Sprintf("%s [%d/%d]", displayName, h.currentFilterIndex, len(h.availableJobs))
 }
@@ -121,6 +130,23 @@ func (h *Header) SetSearch(query string, mode bool) {
 	h.searchMode = mode
 }
 
+// SetNotice shows a short message until it is replaced or cleared with ""
+func (h *Header) SetNotice(notice string) {
+	h.notice = notice
+}
+
+// SetRerun shows how the findings compare with the previous analysis of
+// the same build ("" to hide)
+func (h *Header) SetRerun(rerun string) {
+	h.rerun = rerun
+}
+
+// SetComparison shows how the findings of the two requests shown compare
+// ("" to hide)
+func (h *Header) SetComparison(comparison string) {
+	h.comparison = comparison
+}
+
 // SetLoadStatus updates the loading status display
 func (h *Header) SetLoadStatus(status LoadStatus, cardCount, jobCount int) {
 	h.loadStatus = status
@@ -149,6 +175,23 @@ func (h *Header) SetTierFilter(filter int) {
 	h.tierFilter = filter
 }
 
+// SetTeamFilter sets the owner team shown, or "" for all teams.
+func (h *Header) SetTeamFilter(team string) {
+	h.teamFilter = team
+}
+
+// SetSideFilter sets which of the compared requests' findings are shown,
+// e.g. "only in req-1", or "" for all.
+func (h *Header) SetSideFilter(side string) {
+	h.sideFilter = side
+}
+
+// SetGrouping shows how the list is grouped, or "" for the default
+// grouping by error.
+func (h *Header) SetGrouping(grouping string) {
+	h.grouping = grouping
+}
+
 // AddJob adds a new job to the available jobs list
 func (h *Header) AddJob(jobName string, failed bool) {
 	// Check if already exists - if so, update failed status
@@ -214,7 +257,11 @@ func (h Header) Render(width int) string {
 			Foreground(h.styles.AccentYellow).
 			Bold(true).
 			Padding(0, 1)
-		pending = pendingStyle.Render(fmt.Sprintf("⚡ %d new (r)", h.pendingCount))
+		pendingText := fmt.Sprintf("⚡ %d new (r)", h.pendingCount)
+		if h.styles.Accessible {
+			pendingText = fmt.Sprintf("%d new, press r to show", h.pendingCount)
+		}
+		pending = pendingStyle.Render(pendingText)
 	}
 
 	// Tier counts section - colored numbers matching delegate tier colors
@@ -236,11 +283,22 @@ func (h Header) Render(width int) string {
 		noiseStyle = noiseStyle.Bold(true)
 	}
 
-	unique := uniqueStyle.Render(fmt.Sprintf("Unique:%d", h.uniqueCount))
-	noise := noiseStyle.Render(fmt.Sprintf("Noise:%d", h.noiseCount))
+	uniqueText := fmt.Sprintf("Unique:%d", h.uniqueCount)
+	noiseText := fmt.Sprintf("Noise:%d", h.noiseCount)
+	if h.styles.Accessible {
+		// Bold alone doesn't tell which tier is shown
+		switch h.tierFilter {
+		case 1:
+			uniqueText = "[" + uniqueText + "]"
+		case 2:
+			noiseText = "[" + noiseText + "]"
+		}
+	}
+	unique := uniqueStyle.Render(uniqueText)
+	noise := noiseStyle.Render(noiseText)
 
 	tierStyle := lipgloss.NewStyle().Padding(0, 1)
-	tiers := tierStyle.Render(fmt.Sprintf("│ %s %s │", unique, noise))
+	tiers := tierStyle.Render(fmt.Sprintf("%s %s %s %s", h.styles.ColumnSep, unique, noise, h.styles.ColumnSep))
 
 	// Filter section - truncate if necessary to prevent wrapping
 	filterStyle := lipgloss.NewStyle().
@@ -249,16 +307,30 @@ func (h Header) Render(width int) string {
 		Padding(0, 2).
 		MaxWidth(width / 4) // Limit filter width to prevent wrapping
 
-	filter := filterStyle.Render(fmt.Sprintf("⚙️ Job: %s", h.selectedFilter))
+	filterIcon, searchIcon, cursor := "⚙️ ", "🔍 ", "█"
+	if h.styles.Accessible {
+		filterIcon, searchIcon, cursor = "", "", "_"
+	}
+	filterText := fmt.Sprintf("%sJob: %s", filterIcon, h.selectedFilter)
+	if h.teamFilter != "" {
+		filterText += " Team: " + h.teamFilter
+	}
+	if h.sideFilter != "" {
+		filterText += " Side: " + h.sideFilter
+	}
+	if h.grouping != "" {
+		filterText += " Group: " + h.grouping
+	}
+	filter := filterStyle.Render(filterText)
 
 	// Search section
 	var searchText string
 	if h.searchMode {
-		searchText = fmt.Sprintf("🔍 Search: %s█", h.searchQuery)
+		searchText = fmt.Sprintf("%sSearch: %s%s", searchIcon, h.searchQuery, cursor)
 	} else if h.searchQuery != "" {
-		searchText = fmt.Sprintf("🔍 Search: %s", h.searchQuery)
+		searchText = fmt.Sprintf("%sSearch: %s", searchIcon, h.searchQuery)
 	} else {
-		searchText = "🔍 [/] to search"
+		searchText = searchIcon + "[/] to search"
 	}
 
 	searchStyle := lipgloss.NewStyle().
@@ -271,13 +343,40 @@ func (h Header) Render(width int) string {
 
 	search := searchStyle.Render(searchText)
 
+	// Re-run section (carried-over vs new findings)
+	var rerun string
+	if h.rerun != "" {
+		rerunStyle := lipgloss.NewStyle().
+			Foreground(h.styles.TextSecondary).
+			Padding(0, 1).
+			MaxWidth(width / 4)
+		rerun = rerunStyle.Render(h.rerun)
+	}
+	if h.comparison != "" {
+		comparisonStyle := lipgloss.NewStyle().
+			Foreground(h.styles.TextSecondary).
+			Padding(0, 1).
+			MaxWidth(width / 4)
+		rerun += comparisonStyle.Render(h.comparison)
+	}
+
+	// Notice section (result of open/copy actions)
+	var notice string
+	if h.notice != "" {
+		noticeStyle := lipgloss.NewStyle().
+			Foreground(h.styles.AccentYellow).
+			Padding(0, 1).
+			MaxWidth(width / 4)
+		notice = noticeStyle.Render(h.notice)
+	}
+
 	// Combine sections
-	leftSection := lipgloss.JoinHorizontal(lipgloss.Left, status, pending, tiers, filter, search)
+	leftSection := lipgloss.JoinHorizontal(lipgloss.Left, status, pending, tiers, rerun, filter, search, notice)
 
 	// Create header bar - no background to ensure visibility on any terminal
 	// Note: BorderBottom adds 2 chars (left and right corners), so content width is width - 2
 	headerStyle := lipgloss.NewStyle().
-		BorderStyle(lipgloss.NormalBorder()).
+		BorderStyle(h.styles.Rule).
 		BorderBottom(true).
 		BorderForeground(h.styles.BorderColor).
 		Width(width - 2)
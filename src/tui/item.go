@@ -7,7 +7,8 @@ import "destill-agent/src/contracts"
 type Item struct {
 	Card contracts.TriageCard
 	Rank int
-	Tier int // 1=unique failure, 2=frequency spike, 3=common noise
+	Tier int    // 1=unique failure, 2=frequency spike, 3=common noise
+	Mark string // marks.Triaged or marks.Ignored, if the user marked the finding
 }
 
 // FilterValue is the value used for fuzzy filtering.
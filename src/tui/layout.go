@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"destill-agent/src/ranking"
 )
 
 // panelDimensions holds calculated layout dimensions
@@ -37,6 +39,11 @@ func (m MainModel) View() string {
 		return "\n  Initializing..."
 	}
 
+	// The log viewer takes the whole screen while open
+	if m.logView != nil {
+		return m.logView.View()
+	}
+
 	// Render header
 	header := m.header.Render(m.width)
 
@@ -49,7 +56,13 @@ func (m MainModel) View() string {
 			Align(lipgloss.Center).
 			PaddingTop(2).
 			Render(progressView)
-		return lipgloss.JoinVertical(lipgloss.Left, header, centeredProgress)
+		// Placeholder rows where the findings will appear
+		rows := m.height - lipgloss.Height(header) - lipgloss.Height(centeredProgress) - 2
+		if m.styles.Accessible || rows <= 0 {
+			return lipgloss.JoinVertical(lipgloss.Left, header, centeredProgress)
+		}
+		skeleton := lipgloss.NewStyle().PaddingTop(1).Render(skeletonRows(m.width, rows))
+		return lipgloss.JoinVertical(lipgloss.Left, header, centeredProgress, skeleton)
 	}
 
 	// Calculate panel dimensions
@@ -73,22 +86,53 @@ func (m MainModel) renderHelpText() string {
 	keyStyle := lipgloss.NewStyle().Foreground(m.styles.PrimaryBlue).Bold(true)
 	sepStyle := lipgloss.NewStyle().Foreground(m.styles.TextSecondary)
 
+	// Comparing with the previous build needs a store that can look it up
+	compare := ""
+	if m.occurrences != nil {
+		compare = fmt.Sprintf("%s: Previous build %s ", keyStyle.Render("c"), sepStyle.Render(m.styles.HelpSep))
+	}
+
+	// Sides of a comparison of two requests (view --compare)
+	side := ""
+	if _, ok := ranking.ComparisonOf(itemCards(m.items)); ok {
+		side = fmt.Sprintf("%s: Side %s ", keyStyle.Render("s"), sepStyle.Render(m.styles.HelpSep))
+	}
+
 	var helpText string
-	if m.detailFocused {
+	if m.detailFocused && m.assigner != nil {
+		helpText = fmt.Sprintf("%s: Scroll %s %s: More context %s %s%s: Assign %s %s: Back %s %s: Quit",
+			keyStyle.Render("j/k"), sepStyle.Render(m.styles.HelpSep),
+			keyStyle.Render("x"), sepStyle.Render(m.styles.HelpSep),
+			compare,
+			keyStyle.Render("a"), sepStyle.Render(m.styles.HelpSep),
+			keyStyle.Render("Esc"), sepStyle.Render(m.styles.HelpSep),
+			keyStyle.Render("q"))
+	} else if m.detailFocused && m.contextSource != nil {
+		helpText = fmt.Sprintf("%s: Scroll %s %s: More context %s %s%s: Back %s %s: Quit",
+			keyStyle.Render("j/k"), sepStyle.Render(m.styles.HelpSep),
+			keyStyle.Render("x"), sepStyle.Render(m.styles.HelpSep),
+			compare,
+			keyStyle.Render("Esc"), sepStyle.Render(m.styles.HelpSep),
+			keyStyle.Render("q"))
+	} else if m.detailFocused {
 		helpText = fmt.Sprintf("%s: Scroll %s %s: Back %s %s: Quit",
-			keyStyle.Render("j/k"), sepStyle.Render("•"),
-			keyStyle.Render("Esc"), sepStyle.Render("•"),
+			keyStyle.Render("j/k"), sepStyle.Render(m.styles.HelpSep),
+			keyStyle.Render("Esc"), sepStyle.Render(m.styles.HelpSep),
 			keyStyle.Render("q"))
 	} else {
-		helpText = fmt.Sprintf("%s: Nav %s %s: All/Unique/Noise %s %s: View %s %s: Job %s %s %s",
-			keyStyle.Render("j/k"), sepStyle.Render("•"),
-			keyStyle.Render("0/1/2"), sepStyle.Render("•"),
-			keyStyle.Render("Enter"), sepStyle.Render("•"),
-			keyStyle.Render("Tab"), sepStyle.Render("•"),
+		helpText = fmt.Sprintf("%s: Nav %s %s: Tier %s %s: View %s %s: Job %s %s: Team %s %s: Group %s %s%s %s",
+			keyStyle.Render("j/k"), sepStyle.Render(m.styles.HelpSep),
+			keyStyle.Render("0/1/2"), sepStyle.Render(m.styles.HelpSep),
+			keyStyle.Render("Enter"), sepStyle.Render(m.styles.HelpSep),
+			keyStyle.Render("Tab"), sepStyle.Render(m.styles.HelpSep),
+			keyStyle.Render("t"), sepStyle.Render(m.styles.HelpSep),
+			keyStyle.Render("v"), sepStyle.Render(m.styles.HelpSep),
+			side,
 			keyStyle.Render("/"), keyStyle.Render("q"))
 	}
 
-	return m.styles.HelpStyle().Render(helpText)
+	// Cut rather than wrap, which would push the panels off the screen
+	return m.styles.HelpStyle().MaxWidth(m.width).Render(helpText)
 }
 
 // resizeComponents handles window resize events
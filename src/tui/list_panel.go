@@ -12,7 +12,7 @@ func (m MainModel) renderListPanel(width, height int) string {
 
 	// Render list with border
 	listPanel := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
+		Border(m.styles.Border).
 		BorderForeground(m.styles.BorderColor).
 		Width(width - 2).
 		Height(height).
@@ -24,7 +24,12 @@ func (m MainModel) renderListPanel(width, height int) string {
 	recurHeader := fmt.Sprintf("%*s", delegate.RecurWidth, "Rc")
 
 	// Truncate to width-4 to account for padding (2 chars)
-	headerText := fmt.Sprintf("%s │ Conf │ %s │ Message", rankHeader, recurHeader)
+	sep := m.styles.ColumnSep
+	headerText := fmt.Sprintf("%s %s Conf %s %s %s Message", rankHeader, sep, sep, recurHeader, sep)
+	if m.styles.Accessible {
+		// Line up with the selection and tier prefix on each row
+		headerText = fmt.Sprintf("%-*s", accessiblePrefixWidth, "  T") + headerText
+	}
 	truncatedHeaderText := Truncate(headerText, width-4, true)
 	headerRow := lipgloss.NewStyle().
 		Foreground(m.styles.PrimaryBlue).
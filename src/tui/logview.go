@@ -0,0 +1,315 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/provider"
+	"destill-agent/src/store"
+)
+
+// fetchJobLog fetches the raw log of the card's job from its CI provider,
+// replaced in tests
+var fetchJobLog = func(ctx context.Context, card contracts.TriageCard) (string, error) {
+	ref, err := provider.ParseURL(card.BuildURL)
+	if err != nil {
+		return "", err
+	}
+	p, err := provider.GetProvider(ref)
+	if err != nil {
+		return "", err
+	}
+	return p.FetchJobLog(ctx, card.Metadata["job_id"])
+}
+
+// logLoadedMsg carries the log read for a finding's job
+type logLoadedMsg struct {
+	findingID string
+	log       store.JobLog
+	source    string // Where the log was read from, for the viewer's title
+	err       error
+}
+
+// openLog opens the full-screen viewer on the item's job log, returning a
+// command that reads it, from the stored chunks when the context source
+// has them and otherwise from the provider, when it isn't loaded yet
+func (m *MainModel) openLog(item Item) tea.Cmd {
+	card := item.Card
+	if loaded, ok := m.logs[card.ID]; ok {
+		m.logView = newLogViewer(card, loaded.log, loaded.source, m.styles, m.width, m.height)
+		return nil
+	}
+	if card.Metadata["job_id"] == "" {
+		m.header.SetNotice("No job log for this finding")
+		return nil
+	}
+
+	reader, _ := m.contextSource.(store.JobLogReader)
+	ctx := m.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	m.header.SetNotice("Loading job log...")
+	return func() tea.Msg {
+		log, source, err := loadJobLog(ctx, reader, card)
+		return logLoadedMsg{findingID: card.ID, log: log, source: source, err: err}
+	}
+}
+
+// loadJobLog reads the card's job log from reader, or from its provider when
+// reader is nil or doesn't have the log's chunks. It also returns where the
+// log was read from.
+func loadJobLog(ctx context.Context, reader store.JobLogReader, card contracts.TriageCard) (store.JobLog, string, error) {
+	if reader != nil {
+		log, err := reader.GetJobLog(ctx, card.ID)
+		var notFound store.ErrNotFound
+		switch {
+		case err == nil:
+			return log, "stored log", nil
+		case !errors.Is(err, store.ErrNoChunks) && !errors.As(err, &notFound):
+			return store.JobLog{}, "", err
+		}
+	}
+
+	raw, err := fetchJobLog(ctx, card)
+	if err != nil {
+		return store.JobLog{}, "", provider.WrapError(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(raw, "\n"), "\n")
+	return store.JobLog{FindingID: card.ID, Line: findLine(lines, card), Lines: lines}, "provider", nil
+}
+
+// findLine returns the 1-based line of lines holding the card's message, or
+// 0 when none does. The provider's log isn't split like the stored chunks,
+// so the finding's line is found by its text.
+func findLine(lines []string, card contracts.TriageCard) int {
+	message := strings.TrimSpace(comparisonMessage(card))
+	if message == "" {
+		return 0
+	}
+	for i, line := range lines {
+		if strings.Contains(CleanLogText(line), message) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// logViewer is the full-screen, searchable view of a job's raw log (L key),
+// opened centered on the finding's line
+type logViewer struct {
+	title     string
+	lines     []string // Cleaned log lines
+	line      int      // 1-based line of the finding, 0 when unknown
+	truncated bool
+	styles    *StyleConfig
+	viewport  viewport.Model
+
+	// Search within the log
+	searchMode bool
+	query      string
+	matches    []int // 0-based indexes of the lines matching query
+	match      int   // Current match, an index into matches
+}
+
+// newLogViewer returns a viewer of log sized to the terminal, centered on the
+// finding's line
+func newLogViewer(card contracts.TriageCard, log store.JobLog, source string, styles *StyleConfig, width, height int) *logViewer {
+	lines := make([]string, len(log.Lines))
+	for i, line := range log.Lines {
+		lines[i] = CleanLogText(line)
+	}
+	v := &logViewer{
+		title:     fmt.Sprintf("Log: %s (%d lines, from %s)", card.JobName, len(lines), source),
+		lines:     lines,
+		line:      log.Line,
+		truncated: log.Truncated,
+		styles:    styles,
+		viewport:  viewport.New(0, 0),
+	}
+	v.setSize(width, height)
+	v.center(v.line)
+	return v
+}
+
+// setSize fits the viewer to the terminal, leaving a title and a help line
+func (v *logViewer) setSize(width, height int) {
+	v.viewport.Width = width
+	v.viewport.Height = max(height-2, 1)
+	v.render()
+}
+
+// center scrolls so line (1-based) is in the middle of the screen
+func (v *logViewer) center(line int) {
+	if line < 1 {
+		return
+	}
+	v.viewport.SetYOffset(line - 1 - v.viewport.Height/2)
+}
+
+// render sets the viewport content: each line after its number, cut to the
+// width, with the finding's line and search matches marked in the gutter
+func (v *logViewer) render() {
+	digits := len(fmt.Sprint(len(v.lines)))
+	textWidth := max(v.viewport.Width-digits-3, 10)
+	matching := make(map[int]bool, len(v.matches))
+	for _, i := range v.matches {
+		matching[i] = true
+	}
+
+	findingStyle := lipgloss.NewStyle().Foreground(v.styles.ErrorForeground).Background(v.styles.ErrorBackground)
+	matchStyle := lipgloss.NewStyle().Foreground(v.styles.AccentYellow)
+	gutterStyle := v.styles.Dim(lipgloss.NewStyle().Foreground(v.styles.TextSecondary))
+
+	var b strings.Builder
+	for i, line := range v.lines {
+		marker, style := " ", lipgloss.NewStyle().Foreground(v.styles.TextPrimary)
+		switch {
+		case i+1 == v.line:
+			marker, style = ">", findingStyle
+		case matching[i]:
+			marker, style = "*", matchStyle
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(gutterStyle.Render(fmt.Sprintf("%*d %s ", digits, i+1, marker)))
+		b.WriteString(style.Render(Truncate(line, textWidth, true)))
+	}
+	v.viewport.SetContent(b.String())
+}
+
+// search finds the lines containing query, ignoring case, and moves to the
+// first match at or after the top of the screen
+func (v *logViewer) search(query string) {
+	v.query = query
+	v.matches = nil
+	v.match = 0
+	if query != "" {
+		lower := strings.ToLower(query)
+		for i, line := range v.lines {
+			if strings.Contains(strings.ToLower(line), lower) {
+				v.matches = append(v.matches, i)
+			}
+		}
+	}
+	v.render()
+	for j, i := range v.matches {
+		if i >= v.viewport.YOffset {
+			v.match = j
+			break
+		}
+	}
+	if len(v.matches) > 0 {
+		v.center(v.matches[v.match] + 1)
+	}
+}
+
+// step moves to the next (1) or previous (-1) match, wrapping around
+func (v *logViewer) step(direction int) {
+	if len(v.matches) == 0 {
+		return
+	}
+	v.match = (v.match + direction + len(v.matches)) % len(v.matches)
+	v.center(v.matches[v.match] + 1)
+}
+
+// Update handles a key while the viewer is open, returning false when the
+// key closes it
+func (v *logViewer) Update(msg tea.KeyMsg) (bool, tea.Cmd) {
+	if v.searchMode {
+		switch msg.String() {
+		case "esc":
+			v.searchMode = false
+			v.search("")
+		case "enter":
+			v.searchMode = false
+		case "backspace":
+			if len(v.query) > 0 {
+				v.search(v.query[:len(v.query)-1])
+			}
+		default:
+			if len(msg.String()) == 1 || msg.String() == " " {
+				v.search(v.query + msg.String())
+			}
+		}
+		return true, nil
+	}
+
+	switch msg.String() {
+	case "esc", "q", "L":
+		return false, nil
+	case "/":
+		v.searchMode = true
+		v.search("")
+		return true, nil
+	case "n":
+		v.step(1)
+		return true, nil
+	case "N":
+		v.step(-1)
+		return true, nil
+	case "f":
+		// Back to the finding's line
+		v.center(v.line)
+		return true, nil
+	case "g", "home":
+		v.viewport.GotoTop()
+		return true, nil
+	case "G", "end":
+		v.viewport.GotoBottom()
+		return true, nil
+	}
+
+	var cmd tea.Cmd
+	v.viewport, cmd = v.viewport.Update(msg)
+	return true, cmd
+}
+
+// View renders the title, the log, and the search prompt or help
+func (v *logViewer) View() string {
+	title := v.title
+	if v.line == 0 {
+		title += " · finding's line not found"
+	}
+	if v.truncated {
+		title += " · cut at size limit"
+	}
+	titleRow := lipgloss.NewStyle().Foreground(v.styles.PrimaryBlue).Bold(true).
+		Render(Truncate(title, v.viewport.Width, true))
+
+	keyStyle := lipgloss.NewStyle().Foreground(v.styles.PrimaryBlue).Bold(true)
+	sepStyle := lipgloss.NewStyle().Foreground(v.styles.TextSecondary)
+	var footer string
+	switch {
+	case v.searchMode:
+		footer = "/" + v.query
+		if v.query != "" {
+			footer += fmt.Sprintf("  (%d matches)", len(v.matches))
+		}
+	default:
+		footer = fmt.Sprintf("%s: Scroll %s %s: Search %s %s: Next/prev %s %s: Finding %s %s: Top/bottom %s %s: Back",
+			keyStyle.Render("j/k"), sepStyle.Render(v.styles.HelpSep),
+			keyStyle.Render("/"), sepStyle.Render(v.styles.HelpSep),
+			keyStyle.Render("n/N"), sepStyle.Render(v.styles.HelpSep),
+			keyStyle.Render("f"), sepStyle.Render(v.styles.HelpSep),
+			keyStyle.Render("g/G"), sepStyle.Render(v.styles.HelpSep),
+			keyStyle.Render("Esc"))
+		if v.query != "" {
+			status := fmt.Sprintf("No matches for %q", v.query)
+			if len(v.matches) > 0 {
+				status = fmt.Sprintf("Match %d of %d for %q", v.match+1, len(v.matches), v.query)
+			}
+			footer = sepStyle.Render(status) + "  " + footer
+		}
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, titleRow, v.viewport.View(), v.styles.HelpStyle().Render(footer))
+}
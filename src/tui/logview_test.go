@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/store"
+)
+
+// keys sends each key to the model in turn
+func keys(t *testing.T, m MainModel, keys ...string) MainModel {
+	t.Helper()
+	for _, key := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		if key == "esc" {
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(MainModel)
+	}
+	return m
+}
+
+func TestLogViewer_StoredLog(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewInMemoryStore()
+	var lines []string
+	for i := 1; i <= 300; i++ {
+		lines = append(lines, fmt.Sprintf("log line %d", i))
+	}
+	lines[199] = "panic: connection refused"
+	st.StoreChunks(ctx, []contracts.LogChunk{{RequestID: "req-1", JobID: "job-1", Content: strings.Join(lines, "\n"), LineStart: 1, LineEnd: 300}})
+	card := contracts.TriageCard{
+		ID:          "finding-1",
+		RequestID:   "req-1",
+		JobName:     "tests",
+		RawMessage:  "log line 150",
+		LineInChunk: 149,
+		Metadata:    map[string]string{"job_id": "job-1"},
+	}
+	st.Store(ctx, "req-1", []contracts.TriageCard{card})
+
+	defer func(orig func(context.Context, contracts.TriageCard) (string, error)) { fetchJobLog = orig }(fetchJobLog)
+	fetchJobLog = func(context.Context, contracts.TriageCard) (string, error) {
+		t.Error("fetched the log from the provider with a stored copy")
+		return "", nil
+	}
+
+	model := createTestModel([]contracts.TriageCard{card})
+	model.contextSource = st
+	model.width, model.height, model.ready = 100, 30, true
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	if cmd == nil {
+		t.Fatal("L returned no command")
+	}
+	updated, _ = updated.(MainModel).Update(cmd())
+	m := updated.(MainModel)
+	if m.logView == nil {
+		t.Fatalf("log viewer not open, notice = %q", m.header.notice)
+	}
+
+	// Opened centered on the finding's line
+	view := m.View()
+	for _, want := range []string{"Log: tests (300 lines, from stored log)", "150 > log line 150", "log line 140", "log line 160"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("log view missing %q", want)
+		}
+	}
+
+	// Search jumps to the match, and n wraps around to it again
+	m = keys(t, m, "/", "p", "a", "n", "i", "c")
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(MainModel)
+	if view := m.View(); !strings.Contains(view, "200 * panic: connection refused") || !strings.Contains(view, "Match 1 of 1") {
+		t.Errorf("search didn't move to the match:\n%s", view)
+	}
+	m = keys(t, m, "n", "f")
+	if view := m.View(); !strings.Contains(view, "150 > log line 150") {
+		t.Error("f didn't go back to the finding's line")
+	}
+
+	// Esc closes the viewer; opening it again doesn't read the log again
+	m = keys(t, m, "esc")
+	if m.logView != nil {
+		t.Fatal("esc didn't close the log viewer")
+	}
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	if cmd != nil || updated.(MainModel).logView == nil {
+		t.Error("reopening the log read it again")
+	}
+}
+
+func TestLogViewer_ProviderLog(t *testing.T) {
+	card := contracts.TriageCard{
+		ID:         "finding-1",
+		JobName:    "build",
+		BuildURL:   "https://buildkite.com/org/pipeline/builds/1",
+		RawMessage: "error: undefined symbol",
+		Metadata:   map[string]string{"job_id": "job-1"},
+	}
+	defer func(orig func(context.Context, contracts.TriageCard) (string, error)) { fetchJobLog = orig }(fetchJobLog)
+	fetchJobLog = func(_ context.Context, got contracts.TriageCard) (string, error) {
+		if got.Metadata["job_id"] != "job-1" {
+			t.Errorf("fetched job %q, want job-1", got.Metadata["job_id"])
+		}
+		return "compiling\n\x1b[31merror: undefined symbol\x1b[0m\nexit 1\n", nil
+	}
+
+	log, source, err := loadJobLog(context.Background(), nil, card)
+	if err != nil {
+		t.Fatalf("loadJobLog() error = %v", err)
+	}
+	if source != "provider" || log.Line != 2 || len(log.Lines) != 3 {
+		t.Errorf("loadJobLog() = line %d of %d lines from %s, want line 2 of 3 from provider", log.Line, len(log.Lines), source)
+	}
+
+	// A store without the chunks falls back to the provider, but other
+	// errors are reported
+	log, source, err = loadJobLog(context.Background(), store.NewInMemoryStore(), card)
+	if err != nil || source != "provider" || log.Line != 2 {
+		t.Errorf("loadJobLog(store without chunks) = line %d from %q, %v", log.Line, source, err)
+	}
+	fetchJobLog = func(context.Context, contracts.TriageCard) (string, error) {
+		return "", errors.New("401 unauthorized")
+	}
+	if _, _, err := loadJobLog(context.Background(), nil, card); err == nil {
+		t.Error("loadJobLog() error = nil, want the provider's error")
+	}
+
+	// Findings without a job have no log to show
+	model := createTestModel([]contracts.TriageCard{{ID: "finding-2", JobName: "build"}})
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	if m := updated.(MainModel); cmd != nil || !strings.Contains(m.header.notice, "No job log") {
+		t.Errorf("notice = %q, want no job log", m.header.notice)
+	}
+}
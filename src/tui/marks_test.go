@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/marks"
+)
+
+func TestMainModel_Marks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "marks.json")
+	marksFile, err := marks.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cards := []contracts.TriageCard{
+		{JobName: "build", MessageHash: "hash-noise", NormalizedMsg: "npm WARN deprecated", RawMessage: "npm WARN deprecated"},
+		{JobName: "tests", MessageHash: "hash-flake", NormalizedMsg: "connection reset", RawMessage: "connection reset"},
+	}
+	m := createTestModel(cards)
+	m.marks = marksFile
+	m.applyFilter()
+
+	press := func(m MainModel, key string) MainModel {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		return updated.(MainModel)
+	}
+	shown := func(m MainModel) []string {
+		var hashes []string
+		for _, item := range m.listView.items {
+			hashes = append(hashes, item.Card.MessageHash+":"+item.Mark)
+		}
+		return hashes
+	}
+
+	// Ignoring the selected finding hides it; I shows it again
+	m = press(m, "i")
+	if got := strings.Join(shown(m), ","); got != "hash-flake:" {
+		t.Errorf("shown after ignoring = %s, want hash-flake only", got)
+	}
+	m = press(m, "I")
+	if got := strings.Join(shown(m), ","); got != "hash-noise:ignored,hash-flake:" {
+		t.Errorf("shown with ignored = %s", got)
+	}
+
+	// Marking triaged keeps the finding shown, marked
+	m.listView.list.Select(1)
+	m = press(m, "m")
+	if got := strings.Join(shown(m), ","); got != "hash-noise:ignored,hash-flake:triaged" {
+		t.Errorf("shown after marking triaged = %s", got)
+	}
+	m.listView.SetSize(120, 10)
+	if !strings.Contains(m.listView.Render(), "[triaged]") {
+		t.Error("list doesn't show the triaged badge")
+	}
+
+	// A later build with the same failures starts with the marks applied
+	reloaded, err := marks.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := createTestModel(cards)
+	later.marks = reloaded
+	later.applyFilter()
+	if got := strings.Join(shown(later), ","); got != "hash-flake:triaged" {
+		t.Errorf("shown in a later build = %s, want the ignored finding hidden", got)
+	}
+
+	// Pressing m again unmarks
+	later.listView.list.Select(0)
+	later = press(later, "m")
+	if got := strings.Join(shown(later), ","); got != "hash-flake:" || later.header.notice != "Unmarked finding" {
+		t.Errorf("shown after unmarking = %s, notice %q", got, later.header.notice)
+	}
+}
+
+func TestMainModel_ShowSuppressed(t *testing.T) {
+	cards := []contracts.TriageCard{
+		{JobName: "build", MessageHash: "hash-teardown", NormalizedMsg: "404 during teardown", RawMessage: "404 during teardown",
+			Metadata: map[string]string{contracts.MetadataSuppressedBy: "teardown"}},
+		{JobName: "tests", MessageHash: "hash-flake", NormalizedMsg: "connection reset", RawMessage: "connection reset"},
+	}
+	m := createTestModel(cards)
+	m.applyFilter()
+
+	shown := func(m MainModel) string {
+		var hashes []string
+		for _, item := range m.listView.items {
+			hashes = append(hashes, item.Card.MessageHash)
+		}
+		return strings.Join(hashes, ",")
+	}
+	if got := shown(m); got != "hash-flake" {
+		t.Errorf("shown = %s, want the suppressed finding hidden", got)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	m = updated.(MainModel)
+	if got := shown(m); got != "hash-teardown,hash-flake" {
+		t.Errorf("shown with suppressed = %s", got)
+	}
+	m.listView.SetSize(120, 10)
+	if !strings.Contains(m.listView.Render(), "[suppressed]") {
+		t.Error("list doesn't show the suppressed badge")
+	}
+}
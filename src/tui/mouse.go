@@ -0,0 +1,59 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// handleMouse clicks a finding in the list to select it, or the detail panel
+// to focus it, and scrolls the panel under the pointer with the wheel
+func (m MainModel) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	// The log viewer takes the whole screen, so it gets all the scrolling
+	if m.logView != nil {
+		m.logView.viewport, cmd = m.logView.viewport.Update(msg)
+		return m, cmd
+	}
+	// Clicking elsewhere while typing an assignee would change who gets it
+	if !m.ready || m.assignMode || len(m.items) == 0 {
+		return m, nil
+	}
+
+	dims := m.calculateDimensions()
+	inList := msg.X < dims.leftPanelWidth
+	// List rows start below the header, the column header row, and the border
+	row := msg.Y - lipgloss.Height(m.header.Render(m.width)) - 2
+
+	switch {
+	case msg.Action != tea.MouseActionPress:
+		return m, nil
+	case msg.Button == tea.MouseButtonWheelUp || msg.Button == tea.MouseButtonWheelDown:
+		if !inList {
+			m.detailViewport, cmd = m.detailViewport.Update(msg)
+			return m, cmd
+		}
+		if msg.Button == tea.MouseButtonWheelUp {
+			m.listView.MoveCursor(-1)
+		} else {
+			m.listView.MoveCursor(1)
+		}
+	case msg.Button != tea.MouseButtonLeft:
+		return m, nil
+	case !inList:
+		m.detailFocused = true
+		return m, nil
+	default:
+		index, ok := m.listView.IndexAt(row)
+		if !ok {
+			return m, nil
+		}
+		m.listView.Select(index)
+		m.detailFocused = false
+	}
+
+	if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+		m.updateDetailContent(selectedItem)
+	}
+	return m, nil
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"destill-agent/src/contracts"
+)
+
+func TestMouse_SelectAndScroll(t *testing.T) {
+	var cards []contracts.TriageCard
+	for i := 1; i <= 5; i++ {
+		cards = append(cards, contracts.TriageCard{
+			ID:          fmt.Sprintf("finding-%d", i),
+			JobName:     "tests",
+			MessageHash: fmt.Sprintf("hash-%d", i),
+			RawMessage:  fmt.Sprintf("error %d", i),
+			PostContext: strings.Split(strings.Repeat("context line\n", 80), "\n"),
+		})
+	}
+	model := createTestModel(cards)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m := updated.(MainModel)
+	firstRow := lipgloss.Height(m.header.Render(m.width)) + 2
+
+	mouse := func(m MainModel, x, y int, button tea.MouseButton) MainModel {
+		t.Helper()
+		updated, _ := m.Update(tea.MouseMsg{X: x, Y: y, Button: button, Action: tea.MouseActionPress})
+		return updated.(MainModel)
+	}
+	selected := func(m MainModel) string {
+		item, _ := m.listView.GetSelectedItem()
+		return item.Card.ID
+	}
+
+	// Clicking a row selects its finding
+	m = mouse(m, 5, firstRow+2, tea.MouseButtonLeft)
+	if got := selected(m); got != "finding-3" {
+		t.Errorf("clicked third row, selected %s", got)
+	}
+	if m = mouse(m, 5, firstRow+20, tea.MouseButtonLeft); selected(m) != "finding-3" {
+		t.Errorf("clicking below the last row selected %s", selected(m))
+	}
+
+	// The wheel over the list moves the selection
+	m = mouse(m, 5, firstRow, tea.MouseButtonWheelDown)
+	if got := selected(m); got != "finding-4" {
+		t.Errorf("wheel down selected %s, want finding-4", got)
+	}
+	m = mouse(m, 5, firstRow, tea.MouseButtonWheelUp)
+	m = mouse(m, 5, firstRow, tea.MouseButtonWheelUp)
+	if got := selected(m); got != "finding-2" {
+		t.Errorf("wheel up twice selected %s, want finding-2", got)
+	}
+
+	// Over the detail panel it scrolls the detail, and a click focuses it
+	m = mouse(m, 100, firstRow, tea.MouseButtonWheelDown)
+	if m.detailViewport.YOffset == 0 {
+		t.Error("wheel over the detail panel didn't scroll it")
+	}
+	if got := selected(m); got != "finding-2" {
+		t.Errorf("wheel over the detail panel changed the selection to %s", got)
+	}
+	if m = mouse(m, 100, firstRow, tea.MouseButtonLeft); !m.detailFocused {
+		t.Error("clicking the detail panel didn't focus it")
+	}
+	if m = mouse(m, 5, firstRow, tea.MouseButtonLeft); m.detailFocused || selected(m) != "finding-1" {
+		t.Errorf("clicking the first row: focused = %v, selected %s", m.detailFocused, selected(m))
+	}
+}
@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/pager"
+	"destill-agent/src/ranking"
+)
+
+// StartPlain shows findings as plain text, in the same order as the TUI,
+// for terminals where a full-screen TUI is unusable: CI logs, dumb
+// terminals, and screen readers. Output is paged when stdin and stdout are
+// terminals and written in full otherwise.
+func StartPlain(cards []contracts.TriageCard) error {
+	lines := RenderPlain(cards)
+	if !pager.Interactive() {
+		lines = append(lines, "")
+		_, err := fmt.Print(strings.Join(lines, "\n"))
+		return err
+	}
+	return pager.New().Page(lines)
+}
+
+// RenderPlain renders ranked findings with their context as plain text
+// lines: no colors, box drawing, or truncation, so every line reads the
+// same in a log file and through a screen reader. Findings suppressed by the
+// repo's ignore file are left out, as in the TUI, and only counted.
+func RenderPlain(cards []contracts.TriageCard) []string {
+	shown := make([]contracts.TriageCard, 0, len(cards))
+	for _, card := range cards {
+		if !card.Suppressed() {
+			shown = append(shown, card)
+		}
+	}
+	suppressed := len(cards) - len(shown)
+	cards = shown
+
+	state := buildInitialState(cards)
+	unique, noise := getTierCounts(state.hashMap)
+
+	lines := []string{
+		fmt.Sprintf("Destill: %d findings (%d unique, %d noise) in %d jobs (%d failed)",
+			len(state.items), unique, noise, len(state.jobsDiscovered), len(state.jobsFailed)),
+	}
+	if rerun, ok := ranking.RerunSummaryOf(cards); ok {
+		lines = append(lines, rerun.String())
+	}
+	if comparison, ok := ranking.ComparisonOf(cards); ok {
+		lines = append(lines, comparison.String())
+	}
+	if suppressed > 0 {
+		lines = append(lines, fmt.Sprintf("%d findings suppressed by the ignore file are not shown", suppressed))
+	}
+	if len(state.items) == 0 {
+		return append(lines, "", "No findings.")
+	}
+	for i, item := range state.items {
+		lines = append(lines, "")
+		lines = append(lines, plainFinding(item, i+1, len(state.items))...)
+	}
+	return lines
+}
+
+// plainFinding renders one finding: a header line with its rank and
+// scores, job and runner details, the summary, and the log context with
+// the error line marked by ">".
+func plainFinding(item Item, n, total int) []string {
+	card := item.Card
+	tier := "UNIQUE"
+	if item.Tier == ranking.TierNoise {
+		tier = "NOISE"
+	}
+	header := fmt.Sprintf("[%d/%d] %s  confidence %.2f  seen %dx", n, total, tier, card.ConfidenceScore, item.GetRecurrence())
+	if card.Severity != "" {
+		header += "  " + card.Severity
+	}
+	lines := []string{header, "Job: " + card.JobName}
+	if artifact := card.Metadata[contracts.MetadataArtifact]; artifact != "" {
+		lines = append(lines, "Artifact: "+artifact)
+	}
+
+	if card.BuildURL != "" {
+		lines = append(lines, "Build: "+card.BuildURL)
+	}
+	if card.Assignee != "" {
+		lines = append(lines, "Assigned to: "+card.Assignee)
+	}
+	if runner := formatRunnerMetadata(card.Metadata); runner != "" {
+		lines = append(lines, "Runner: "+runner)
+	}
+	if history := formatBuildRecurrence(card); history != "" {
+		lines = append(lines, history)
+	}
+	if note := formatFlakiness(card); note != "" {
+		lines = append(lines, note)
+	}
+	if hint := formatBisectHint(card); hint != "" {
+		lines = append(lines, hint)
+	}
+	if ranking.IsTransient(card) {
+		lines = append(lines, fmt.Sprintf("Transient: gone after retry (attempt %s of %s)",
+			card.Metadata[contracts.MetadataAttempt], card.Metadata[contracts.MetadataAttempts]))
+	}
+	if note := ranking.ComparisonNote(card); note != "" {
+		lines = append(lines, note)
+	}
+	if ranking.IsNewInRerun(card) {
+		lines = append(lines, "New in this run (not in "+card.Metadata[contracts.MetadataPreviousRequest]+")")
+	}
+	if summary := card.Summary; summary != nil {
+		lines = append(lines, "Summary: "+summary.RootCause)
+		if summary.SuggestedFix != "" {
+			lines = append(lines, "Fix: "+summary.SuggestedFix)
+		}
+	}
+
+	message := card.RawMessage
+	if message == "" {
+		message = card.NormalizedMsg
+	}
+	for _, line := range item.GetPreContext() {
+		lines = appendPlain(lines, "  ", line)
+	}
+	lines = appendPlain(lines, "> ", message)
+	for _, line := range item.GetPostContext() {
+		lines = appendPlain(lines, "  ", line)
+	}
+	return lines
+}
+
+// appendPlain cleans a log line and appends it with a prefix, one entry per
+// line when cleaning splits it at carriage returns.
+func appendPlain(lines []string, prefix, text string) []string {
+	for _, line := range strings.Split(CleanLogText(text), "\n") {
+		lines = append(lines, prefix+line)
+	}
+	return lines
+}
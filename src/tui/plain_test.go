@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"destill-agent/src/contracts"
+)
+
+func TestRenderPlain(t *testing.T) {
+	cards := []contracts.TriageCard{
+		{MessageHash: "a", JobName: "unit", Severity: "ERROR", RawMessage: "\x1b[31mpanic: nil map\x1b[0m", NormalizedMsg: "panic: nil map", ConfidenceScore: 0.95,
+			PreContext: []string{"=== RUN TestCart"}, PostContext: []string{"goroutine 1 [running]:\r\nmain.main()"},
+			Summary: &contracts.Summary{RootCause: "Map used before make", SuggestedFix: "Initialize the map"},
+			Metadata: map[string]string{"exit_status": "1", "job_state": "failed", "runner_queue": "linux",
+				"pipeline_name": "org/backend", contracts.MetadataBuildsSeen: "14", contracts.MetadataBuildsWindow: "20",
+				contracts.MetadataFlakiness: contracts.FlakinessLikelyFlaky}},
+		{MessageHash: "b", JobName: "lint", Severity: "WARN", RawMessage: "deprecated flag", NormalizedMsg: "deprecated flag", ConfidenceScore: 0.5,
+			Metadata: map[string]string{"exit_status": "0", "job_state": "passed"}},
+		{MessageHash: "a", JobName: "unit", RawMessage: "panic: nil map", NormalizedMsg: "panic: nil map", ConfidenceScore: 0.95},
+	}
+
+	out := strings.Join(RenderPlain(cards), "\n")
+
+	for _, want := range []string{
+		"Destill: 2 findings (1 unique, 1 noise) in 2 jobs (1 failed)",
+		"[1/2] UNIQUE  confidence 0.95  seen 2x  ERROR\nJob: unit\nRunner: queue: linux",
+		"Runner: queue: linux\nSeen in 14 of the last 20 builds of org/backend\nLikely flaky: failed intermittently in earlier builds of org/backend",
+		"Summary: Map used before make\nFix: Initialize the map",
+		"  === RUN TestCart\n> panic: nil map\n  goroutine 1 [running]:\n  main.main()",
+		"[2/2] NOISE  confidence 0.50  seen 1x  WARN\nJob: lint\n> deprecated flag",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("RenderPlain() missing %q\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "\x1b") {
+		t.Error("RenderPlain() output contains escape sequences")
+	}
+}
+
+func TestRenderPlain_NoFindings(t *testing.T) {
+	lines := RenderPlain(nil)
+	if len(lines) != 3 || lines[2] != "No findings." {
+		t.Errorf("RenderPlain(nil) = %q", lines)
+	}
+}
@@ -7,6 +7,8 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"destill-agent/src/contracts"
 )
 
 // ASCII art logo lines for loading screen
@@ -33,6 +35,7 @@ var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "
 // ProgressMsg updates progress display
 type ProgressMsg struct {
 	Stage   string
+	Unit    string // contracts.ProgressUnitJobs or ProgressUnitChunks; empty counts jobs
 	Current int
 	Total   int
 }
@@ -44,14 +47,24 @@ type ProgressModel struct {
 	stage        string
 	current      int
 	total        int
+	chunks       int // Log chunks analyzed, from the analyze agent
+	chunksTotal  int // Zero until ingest completes
 	done         bool
 	spinnerFrame int
+	accessible   bool
 }
 
 func NewProgressModel() ProgressModel {
 	return ProgressModel{spinnerFrame: 0}
 }
 
+// WithAccessible returns a copy that renders plain text status without
+// the logo or spinner animation.
+func (m ProgressModel) WithAccessible(accessible bool) ProgressModel {
+	m.accessible = accessible
+	return m
+}
+
 // SpinnerTick returns a command that sends SpinnerTickMsg after a delay
 func SpinnerTick() tea.Cmd {
 	return tea.Tick(80*time.Millisecond, func(t time.Time) tea.Msg {
@@ -62,15 +75,23 @@ func SpinnerTick() tea.Cmd {
 func (m ProgressModel) Update(msg tea.Msg) (ProgressModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case ProgressMsg:
-		m.stage = msg.Stage
-		m.current = msg.Current
-		m.total = msg.Total
-		if msg.Stage == "complete" {
+		if msg.Unit == contracts.ProgressUnitChunks {
+			m.chunks = max(m.chunks, msg.Current)
+			m.chunksTotal = max(m.chunksTotal, msg.Total)
+		} else {
+			m.stage = msg.Stage
+			m.current = msg.Current
+			m.total = msg.Total
+		}
+		// Once chunks are counted, ingest completing isn't the end
+		if m.stage == "complete" && (m.chunks == 0 || m.chunks >= m.chunksTotal && m.chunksTotal > 0) {
 			m.done = true
 		}
+	case analysisCompleteMsg:
+		m.done = true
 	case SpinnerTickMsg:
 		m.spinnerFrame = (m.spinnerFrame + 1) % len(spinnerFrames)
-		if !m.done {
+		if !m.done && !m.accessible {
 			return m, SpinnerTick()
 		}
 	}
@@ -78,6 +99,10 @@ func (m ProgressModel) Update(msg tea.Msg) (ProgressModel, tea.Cmd) {
 }
 
 func (m ProgressModel) View() string {
+	if m.accessible {
+		return m.accessibleView()
+	}
+
 	// Render logo with gradient colors (each line gets a different color)
 	var logoLines []string
 	for i, line := range destillLogo {
@@ -109,6 +134,69 @@ func (m ProgressModel) View() string {
 	} else {
 		statusLine = fmt.Sprintf("%s Loading...", spinnerStyle.Render(spinner))
 	}
+	if chunks := m.chunkStatus(); chunks != "" {
+		statusLine += "\n" + chunks
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Center, logo, "", statusLine)
 }
+
+// chunkStatus describes the analyze agent's progress, or is empty before
+// its first update.
+func (m ProgressModel) chunkStatus() string {
+	switch {
+	case m.chunksTotal > 0:
+		pct := float64(m.chunks) / float64(m.chunksTotal) * 100
+		return fmt.Sprintf("Analyzed %d/%d log chunks (%.0f%%)", m.chunks, m.chunksTotal, pct)
+	case m.chunks > 0:
+		return fmt.Sprintf("Analyzed %d log chunks", m.chunks)
+	}
+	return ""
+}
+
+// skeletonRowLimit caps the placeholder rows shown while loading.
+const skeletonRowLimit = 8
+
+// skeletonRows renders dim placeholder rows the width of the finding list,
+// up to rows of them, so the loading screen has the shape of what's coming.
+// Their lengths vary like finding messages do.
+func skeletonRows(width, rows int) string {
+	if rows > skeletonRowLimit {
+		rows = skeletonRowLimit
+	}
+	listWidth := int(float64(width) * 0.4)
+	if listWidth < 10 {
+		return ""
+	}
+
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("238"))
+	lines := make([]string, rows)
+	for i := range lines {
+		barWidth := listWidth - 4 - (i*7)%(listWidth/3)
+		lines[i] = "  " + style.Render(strings.Repeat("░", barWidth))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// accessibleView renders progress as plain text for screen readers.
+func (m ProgressModel) accessibleView() string {
+	title := lipgloss.NewStyle().Bold(true).Render("DESTILL")
+
+	var statusLine string
+	switch {
+	case m.done:
+		statusLine = "Complete. Press r to refresh."
+	case m.total > 0:
+		pct := float64(m.current) / float64(m.total) * 100
+		statusLine = fmt.Sprintf("Working: %s (%d of %d, %.0f%%)", m.stage, m.current, m.total, pct)
+	case m.stage != "":
+		statusLine = fmt.Sprintf("Working: %s", m.stage)
+	default:
+		statusLine = "Loading"
+	}
+	if chunks := m.chunkStatus(); chunks != "" && !m.done {
+		statusLine += "\n" + chunks
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Center, title, "", statusLine)
+}
@@ -5,6 +5,9 @@ import (
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"destill-agent/src/contracts"
 )
 
 func TestProgressModel_InitialState(t *testing.T) {
@@ -80,8 +83,51 @@ func TestProgressModel_Complete(t *testing.T) {
 	}
 }
 
+func TestProgressModel_ChunkProgress(t *testing.T) {
+	model := NewProgressModel()
+
+	updates := []ProgressMsg{
+		{Stage: "Fetching logs", Unit: contracts.ProgressUnitJobs, Current: 2, Total: 2},
+		{Stage: contracts.StageAnalyzing, Unit: contracts.ProgressUnitChunks, Current: 3},
+		{Stage: "complete", Unit: contracts.ProgressUnitJobs, Current: 2, Total: 2},
+		{Stage: contracts.StageAnalyzing, Unit: contracts.ProgressUnitChunks, Current: 4, Total: 10},
+	}
+	for _, msg := range updates {
+		model, _ = model.Update(msg)
+	}
+
+	// Ingest is done but the analysis isn't
+	if model.done {
+		t.Error("expected not done while chunks are still being analyzed")
+	}
+	if view := model.View(); !strings.Contains(view, "4/10 log chunks (40%)") {
+		t.Errorf("expected view to contain the chunk progress, got: %s", view)
+	}
+
+	model, _ = model.Update(analysisCompleteMsg{findings: 1})
+	if !model.done {
+		t.Error("expected model to be done once the analysis completes")
+	}
+}
+
 func TestProgressModel_ImplementsUpdate(t *testing.T) {
 	var _ interface {
 		Update(tea.Msg) (ProgressModel, tea.Cmd)
 	} = ProgressModel{}
 }
+
+func TestSkeletonRows(t *testing.T) {
+	rows := strings.Split(skeletonRows(100, 20), "\n")
+	if len(rows) != skeletonRowLimit {
+		t.Fatalf("got %d rows, want the limit of %d", len(rows), skeletonRowLimit)
+	}
+	for i, row := range rows {
+		if !strings.Contains(row, "░") || lipgloss.Width(row) > 40 {
+			t.Errorf("row %d = %q, want a placeholder within the list width", i, row)
+		}
+	}
+
+	if got := skeletonRows(20, 3); got != "" {
+		t.Errorf("skeletonRows() on a narrow terminal = %q, want none", got)
+	}
+}
@@ -1,12 +1,34 @@
 package tui
 
 import (
+	"slices"
 	"strings"
+
+	"destill-agent/src/contracts"
+	"destill-agent/src/marks"
+	"destill-agent/src/ranking"
 )
 
 // itemMatchesQuery checks if an item matches the search query.
-// Searches in message, job name, hash, severity, and context lines.
+// Searches in message, job name, hash, severity, runner metadata, and context
+// lines. A query of the form key=value, such as queue=gpu or
+// container_image=golang, only matches that runner metadata field.
 func itemMatchesQuery(item Item, query string) bool {
+	if key, value, ok := strings.Cut(query, "="); ok {
+		for _, metaKey := range contracts.RunnerMetadataKeys {
+			if key == metaKey || key == runnerMetadataLabel(metaKey) {
+				return strings.Contains(strings.ToLower(item.Card.Metadata[metaKey]), value)
+			}
+		}
+	}
+
+	// Search in runner metadata
+	for _, key := range contracts.RunnerMetadataKeys {
+		if strings.Contains(strings.ToLower(item.Card.Metadata[key]), query) {
+			return true
+		}
+	}
+
 	// Search in primary fields
 	if strings.Contains(strings.ToLower(item.Card.NormalizedMsg), query) ||
 		strings.Contains(strings.ToLower(item.Card.JobName), query) ||
@@ -42,13 +64,35 @@ func (m *MainModel) applyFilter() {
 		filtered = m.items
 	} else {
 		for _, item := range m.items {
-			if item.Card.JobName == filter {
+			if slices.Contains(item.Card.Jobs(), filter) {
 				filtered = append(filtered, item)
 			}
 		}
 	}
 
-	// 2. Filter by Search Query
+	// 2. Filter by owner team
+	if m.teamFilter != "" {
+		var teamFiltered []Item
+		for _, item := range filtered {
+			if item.Card.OwnedBy(m.teamFilter) {
+				teamFiltered = append(teamFiltered, item)
+			}
+		}
+		filtered = teamFiltered
+	}
+
+	// 3. Filter by compared request (view --compare)
+	if m.sideFilter != "" {
+		var sideFiltered []Item
+		for _, item := range filtered {
+			if item.Card.Metadata[contracts.MetadataComparison] == m.sideFilter {
+				sideFiltered = append(sideFiltered, item)
+			}
+		}
+		filtered = sideFiltered
+	}
+
+	// 4. Filter by Search Query
 	if m.searchQuery != "" {
 		query := strings.ToLower(m.searchQuery)
 		var searchFiltered []Item
@@ -60,7 +104,7 @@ func (m *MainModel) applyFilter() {
 		filtered = searchFiltered
 	}
 
-	// 3. Filter by Tier
+	// 5. Filter by Tier
 	// tierFilter: 0=all (default), 1=unique only, 2=noise only
 	if m.tierFilter != TierFilterAll {
 		var tierFiltered []Item
@@ -79,9 +123,85 @@ func (m *MainModel) applyFilter() {
 		filtered = tierFiltered
 	}
 
+	// 6. Triage marks: findings marked ignored are hidden unless shown with I
+	if m.marks != nil {
+		marked := make([]Item, 0, len(filtered))
+		for _, item := range filtered {
+			item.Mark = m.marks.State(item.Card.MessageHash)
+			if item.Mark == marks.Ignored && !m.showIgnored {
+				continue
+			}
+			marked = append(marked, item)
+		}
+		filtered = marked
+	}
+
+	// 7. Findings suppressed by the repo's ignore file are hidden unless shown with S
+	if !m.showSuppressed {
+		shown := make([]Item, 0, len(filtered))
+		for _, item := range filtered {
+			if !item.Card.Suppressed() {
+				shown = append(shown, item)
+			}
+		}
+		filtered = shown
+	}
+
 	m.listView.SetItems(filtered)
 	// Update detail content for new selection
 	if selectedItem, ok := m.listView.GetSelectedItem(); ok {
 		m.updateDetailContent(selectedItem)
 	}
 }
+
+// cycleTeam moves the team filter to the next team owning findings, after
+// the last one back to all teams.
+func (m *MainModel) cycleTeam() {
+	cards := make([]contracts.TriageCard, len(m.items))
+	for i, item := range m.items {
+		cards[i] = item.Card
+	}
+	teams := contracts.OwnerTeams(cards)
+	if len(teams) == 0 {
+		m.header.SetNotice("No findings have an owner team")
+		return
+	}
+
+	next := teams[0]
+	for i, team := range teams {
+		if team == m.teamFilter {
+			next = ""
+			if i+1 < len(teams) {
+				next = teams[i+1]
+			}
+			break
+		}
+	}
+	m.teamFilter = next
+	m.header.SetTeamFilter(next)
+	m.applyFilter()
+}
+
+// cycleSide moves the comparison filter from all findings to those only in
+// the first compared request, only in the second, in both, and back to all.
+func (m *MainModel) cycleSide() {
+	comparison, ok := ranking.ComparisonOf(itemCards(m.items))
+	if !ok {
+		m.header.SetNotice("Not comparing requests (destill view --compare)")
+		return
+	}
+
+	label := ""
+	switch m.sideFilter {
+	case "":
+		m.sideFilter, label = contracts.ComparisonOnlyFirst, "only in "+comparison.First
+	case contracts.ComparisonOnlyFirst:
+		m.sideFilter, label = contracts.ComparisonOnlySecond, "only in "+comparison.Second
+	case contracts.ComparisonOnlySecond:
+		m.sideFilter, label = contracts.ComparisonShared, "in both"
+	default:
+		m.sideFilter = ""
+	}
+	m.header.SetSideFilter(label)
+	m.applyFilter()
+}
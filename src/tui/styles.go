@@ -1,6 +1,19 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"destill-agent/src/config"
+)
+
+// EnvAccessible selects the accessible style profile when set to a true
+// value such as 1 (see AccessibleStyles).
+const EnvAccessible = "DESTILL_ACCESSIBLE"
 
 // StyleConfig holds all customizable style colors for the triage UI.
 type StyleConfig struct {
@@ -26,6 +39,14 @@ type StyleConfig struct {
 
 	// Accent colors for different job types
 	JobColors []lipgloss.Color
+
+	// Accessible drops emoji, spinners, and faint text, and spells out in
+	// words what colors and icons convey, for screen readers and low vision.
+	Accessible bool
+	Border     lipgloss.Border // Panel borders
+	Rule       lipgloss.Border // Header underline
+	ColumnSep  string          // Separator between list columns
+	HelpSep    string          // Separator between help entries
 }
 
 // DefaultStyles returns the default color palette
@@ -52,7 +73,169 @@ func DefaultStyles() *StyleConfig {
 			lipgloss.Color("#A142F4"), // Purple
 			lipgloss.Color("#24C1E0"), // Cyan
 		},
+		Border:    lipgloss.RoundedBorder(),
+		Rule:      lipgloss.NormalBorder(),
+		ColumnSep: "│",
+		HelpSep:   "•",
+	}
+}
+
+// LightStyles returns a palette for terminals with a light background, where
+// the default's pale text is hard to read.
+func LightStyles() *StyleConfig {
+	return &StyleConfig{
+		PrimaryBlue:     lipgloss.Color("#1A73E8"),
+		AccentBlue:      lipgloss.Color("#1967D2"),
+		AccentYellow:    lipgloss.Color("#B06000"),
+		AccentGreen:     lipgloss.Color("#188038"),
+		DarkBackground:  lipgloss.Color("#FFFFFF"),
+		CardBackground:  lipgloss.Color("#F1F3F4"),
+		TextPrimary:     lipgloss.Color("#202124"),
+		TextSecondary:   lipgloss.Color("#5F6368"),
+		BorderColor:     lipgloss.Color("#9AA0A6"),
+		SelectedColor:   lipgloss.Color("#E8F0FE"),
+		ErrorForeground: lipgloss.Color("#C5221F"),
+		ErrorBackground: lipgloss.Color("#FCE8E6"),
+		Tier1Color:      lipgloss.Color("#D93025"), // Red - unique failures
+		Tier3Color:      lipgloss.Color("#9AA0A6"), // Gray - noise
+		JobColors: []lipgloss.Color{
+			lipgloss.Color("#188038"), // Green
+			lipgloss.Color("#B06000"), // Amber
+			lipgloss.Color("#C5221F"), // Red
+			lipgloss.Color("#8430CE"), // Purple
+			lipgloss.Color("#007B83"), // Teal
+		},
+		Border:    lipgloss.RoundedBorder(),
+		Rule:      lipgloss.NormalBorder(),
+		ColumnSep: "│",
+		HelpSep:   "•",
+	}
+}
+
+// AccessibleStyles returns the accessible profile: high-contrast ANSI colors
+// that follow the terminal's own palette, ASCII-only borders, and no emoji,
+// spinners, or faint text.
+func AccessibleStyles() *StyleConfig {
+	return &StyleConfig{
+		PrimaryBlue:     lipgloss.Color("14"), // Bright cyan
+		AccentBlue:      lipgloss.Color("11"), // Bright yellow
+		AccentYellow:    lipgloss.Color("11"),
+		AccentGreen:     lipgloss.Color("10"),
+		DarkBackground:  lipgloss.Color("0"),
+		CardBackground:  lipgloss.Color("0"),
+		TextPrimary:     lipgloss.Color("15"),
+		TextSecondary:   lipgloss.Color("15"),
+		BorderColor:     lipgloss.Color("15"),
+		SelectedColor:   lipgloss.Color("4"),
+		ErrorForeground: lipgloss.Color("9"),
+		ErrorBackground: lipgloss.Color("0"),
+		Tier1Color:      lipgloss.Color("9"),
+		Tier3Color:      lipgloss.Color("15"),
+		JobColors: []lipgloss.Color{
+			lipgloss.Color("10"),
+			lipgloss.Color("11"),
+			lipgloss.Color("9"),
+			lipgloss.Color("13"),
+			lipgloss.Color("14"),
+		},
+		Accessible: true,
+		Border:     lipgloss.ASCIIBorder(),
+		Rule:       lipgloss.ASCIIBorder(),
+		ColumnSep:  "|",
+		HelpSep:    "|",
+	}
+}
+
+// StylesFromEnv returns the theme named by DESTILL_THEME (DefaultStyles
+// unless it's light or accessible, AccessibleStyles when DESTILL_ACCESSIBLE
+// is true), with the colors DESTILL_THEME_COLORS replaces (see
+// config.ParseThemeColors). DESTILL_ASCII or TERM=dumb limit it to ASCII,
+// and NO_COLOR spells out what its colors convey, as the accessible profile
+// does. An invalid setting is left out of the styles and returned as the
+// error.
+func StylesFromEnv() (*StyleConfig, error) {
+	var errs []error
+	name := os.Getenv(config.EnvTheme)
+	if err := config.ValidateThemeName(name); err != nil {
+		errs = append(errs, err)
+		name = ""
+	}
+	if accessible, _ := strconv.ParseBool(os.Getenv(EnvAccessible)); accessible {
+		name = config.ThemeAccessible
+	}
+
+	var styles *StyleConfig
+	switch name {
+	case config.ThemeLight:
+		styles = LightStyles()
+	case config.ThemeAccessible:
+		styles = AccessibleStyles()
+	default:
+		styles = DefaultStyles()
+	}
+
+	if colors, err := config.ParseThemeColors(os.Getenv(config.EnvThemeColors)); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", config.EnvThemeColors, err))
+	} else {
+		styles.SetColors(colors)
+	}
+	if ascii, _ := strconv.ParseBool(os.Getenv(config.EnvASCII)); ascii || os.Getenv("TERM") == "dumb" {
+		styles.SetASCII()
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		// lipgloss drops the colors itself
+		styles.Accessible = true
+	}
+	return styles, errors.Join(errs...)
+}
+
+// SetColors replaces colors of the styles by role (see
+// config.ThemeColorRoles). Unknown roles are ignored.
+func (s *StyleConfig) SetColors(colors map[string]string) {
+	roles := s.colorRoles()
+	for role, color := range colors {
+		if field, ok := roles[role]; ok {
+			*field = lipgloss.Color(color)
+		}
+	}
+}
+
+// colorRoles maps the roles of config.ThemeColorRoles to the colors they set.
+func (s *StyleConfig) colorRoles() map[string]*lipgloss.Color {
+	return map[string]*lipgloss.Color{
+		"primary":          &s.PrimaryBlue,
+		"accent":           &s.AccentBlue,
+		"warning":          &s.AccentYellow,
+		"success":          &s.AccentGreen,
+		"text":             &s.TextPrimary,
+		"muted":            &s.TextSecondary,
+		"border":           &s.BorderColor,
+		"selected":         &s.SelectedColor,
+		"error":            &s.ErrorForeground,
+		"error_background": &s.ErrorBackground,
+		"unique":           &s.Tier1Color,
+		"noise":            &s.Tier3Color,
+	}
+}
+
+// SetASCII limits the styles to ASCII for terminals that can't draw more:
+// ASCII borders and separators, and, as in the accessible profile, no
+// emoji, spinners, or faint text, with text for what icons convey.
+func (s *StyleConfig) SetASCII() {
+	s.Accessible = true
+	s.Border = lipgloss.ASCIIBorder()
+	s.Rule = lipgloss.ASCIIBorder()
+	s.ColumnSep = "|"
+	s.HelpSep = "|"
+}
+
+// Dim fades a style for secondary text, except in the accessible profile,
+// where faint text would be hard to read.
+func (s *StyleConfig) Dim(style lipgloss.Style) lipgloss.Style {
+	if s.Accessible {
+		return style
 	}
+	return style.Faint(true)
 }
 
 // HelpStyle returns a help text lipgloss style using this config
@@ -2,15 +2,22 @@ package tui
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 
-	"destill-agent/src/broker"
 	"destill-agent/src/contracts"
+	"destill-agent/src/events"
+	"destill-agent/src/marks"
+	"destill-agent/src/permalink"
+	"destill-agent/src/platform"
 	"destill-agent/src/ranking"
+	"destill-agent/src/store"
 )
 
 // LoadStatus represents the current loading state of the TUI
@@ -22,7 +29,7 @@ const (
 	StatusError                      // Error occurred
 )
 
-// cardReceivedMsg is sent when a new triage card arrives from the broker
+// cardReceivedMsg is sent when a new triage card arrives from the event bus
 type cardReceivedMsg struct {
 	card contracts.TriageCard
 }
@@ -30,11 +37,28 @@ type cardReceivedMsg struct {
 // pipelineCompleteMsg is sent when the pipeline signals completion
 type pipelineCompleteMsg struct{}
 
+// analysisCompleteMsg is sent when every chunk of the request has been
+// analyzed; findings is how many the request has in all
+type analysisCompleteMsg struct {
+	findings int
+}
+
 // pipelineErrorMsg is sent when there's an error in the pipeline
 type pipelineErrorMsg struct {
 	err error
 }
 
+// noticeMsg reports the result of an action (open, copy) in the header
+type noticeMsg struct {
+	text string
+}
+
+// OS integrations, replaced in tests
+var (
+	openURL         = platform.OpenURL
+	copyToClipboard = platform.CopyToClipboard
+)
+
 // ConfidenceThreshold is the threshold for "high confidence" cards
 // Cards below this are shown dimmed but still included
 const ConfidenceThreshold = 0.80
@@ -50,14 +74,6 @@ type initialState struct {
 	allJobs        []JobInfo
 }
 
-// brokerChannels holds the channels and context for broker subscriptions
-type brokerChannels struct {
-	cardChan     <-chan broker.Message
-	progressChan <-chan broker.Message
-	ctx          context.Context
-	cancel       context.CancelFunc
-}
-
 // buildInitialState processes the initial cards and builds the state needed for the TUI.
 func buildInitialState(cards []contracts.TriageCard) *initialState {
 	hashMap := make(map[string]*Item)
@@ -66,18 +82,17 @@ func buildInitialState(cards []contracts.TriageCard) *initialState {
 
 	for _, card := range cards {
 		if existing, ok := hashMap[card.MessageHash]; ok {
-			// Increment recurrence count
-			existing.Card.SetRecurrenceCount(existing.GetRecurrence() + 1)
+			mergeDuplicate(existing, card)
 		} else {
 			item := Item{Card: card, Rank: 0}
 			hashMap[card.MessageHash] = &item
 		}
-		if !jobsDiscovered[card.JobName] {
-			jobsDiscovered[card.JobName] = true
-		}
-		// Track if this job failed (exit_status != "0")
-		if exitStatus, ok := card.Metadata["exit_status"]; ok && exitStatus != "0" {
-			jobsFailed[card.JobName] = true
+		for _, job := range card.Jobs() {
+			jobsDiscovered[job] = true
+			// Track if this job failed (exit_status != "0")
+			if exitStatus, ok := card.Metadata["exit_status"]; ok && exitStatus != "0" {
+				jobsFailed[job] = true
+			}
 		}
 	}
 
@@ -111,47 +126,34 @@ func buildInitialState(cards []contracts.TriageCard) *initialState {
 func initializeHeader(styles *StyleConfig, state *initialState, status LoadStatus) Header {
 	header := NewHeaderWithStyles("Destill Analysis", state.allJobs, styles)
 	header.SetLoadStatus(status, len(state.items), len(state.jobsDiscovered))
+	if rerun, ok := ranking.RerunSummaryOf(itemCards(state.items)); ok {
+		header.SetRerun(rerun.String())
+	}
+	if comparison, ok := ranking.ComparisonOf(itemCards(state.items)); ok {
+		header.SetComparison(comparison.String())
+	}
 	// Stay on "ALL" - failed job findings are already boosted to top by confidence
 	return header
 }
 
+// itemCards returns the cards of items.
+func itemCards(items []Item) []contracts.TriageCard {
+	cards := make([]contracts.TriageCard, len(items))
+	for i, item := range items {
+		cards[i] = item.Card
+	}
+	return cards
+}
+
 // initializeListView creates and configures the list view with initial items.
-func initializeListView(state *initialState) View {
+func initializeListView(styles *StyleConfig, state *initialState) View {
 	listView := NewView()
+	listView.SetStyles(styles)
 	// Show all items - failed job findings are already boosted to top by confidence
 	listView.SetItems(state.items)
 	return listView
 }
 
-// subscribeToBroker sets up subscriptions to the broker channels.
-// Returns nil channels if broker is nil.
-func subscribeToBroker(brk broker.Broker) (*brokerChannels, error) {
-	if brk == nil {
-		return &brokerChannels{}, nil
-	}
-
-	ctx, cancel := context.WithCancel(context.Background())
-
-	cardChan, err := brk.Subscribe(ctx, contracts.TopicAnalysisFindings, "tui-consumer")
-	if err != nil {
-		cancel()
-		return nil, err
-	}
-
-	progressChan, err := brk.Subscribe(ctx, contracts.TopicProgress, "tui-progress-consumer")
-	if err != nil {
-		cancel()
-		return nil, err
-	}
-
-	return &brokerChannels{
-		cardChan:     cardChan,
-		progressChan: progressChan,
-		ctx:          ctx,
-		cancel:       cancel,
-	}, nil
-}
-
 // TierFilter values for filtering by tier
 const (
 	TierFilterAll    = 0 // Show all tiers (default)
@@ -172,20 +174,29 @@ type MainModel struct {
 	searchMode     bool
 	searchQuery    string
 	ready          bool
-	tierFilter     int // TierFilterAll (default), TierFilterUnique, or TierFilterNoise
+	tierFilter     int                    // TierFilterAll (default), TierFilterUnique, or TierFilterNoise
+	teamFilter     string                 // Owner team shown (t cycles), or "" for all
+	grouping       int                    // GroupByError (default), GroupByJob, or GroupBySeverity (v cycles)
+	cards          []contracts.TriageCard // Every card shown, before grouping, with metadata of their own
+	sideFilter     string                 // Compared requests' findings shown (s cycles): a contracts.Comparison* value, or "" for all
 
 	// Streaming support
-	broker         broker.Broker         // Message broker
-	cardChan       <-chan broker.Message // Channel receiving cards from broker
-	progressChan   <-chan broker.Message // Channel receiving progress updates
-	pendingCards   []Item                // Cards waiting to be merged
-	hashMap        map[string]*Item      // For grouping by hash
-	status         LoadStatus            // Current loading status
-	cardCount      int                   // Total cards received (above threshold)
-	droppedCount   int                   // Cards dropped due to low confidence
-	jobsDiscovered map[string]bool       // Jobs we've seen so far
-	ctx            context.Context       // Context for broker operations
-	cancel         context.CancelFunc    // Cancel function
+	eventChan      <-chan events.Event // Findings, progress, and errors from the event bus
+	pendingCards   []Item              // Cards waiting to be merged
+	hashMap        map[string]*Item    // For grouping by hash
+	status         LoadStatus          // Current loading status
+	cardCount      int                 // Total cards received (above threshold)
+	droppedCount   int                 // Cards dropped due to low confidence
+	jobsDiscovered map[string]bool     // Jobs we've seen so far
+	analyzed       bool                // Whether the request's analysis is complete
+	expectedCards  int                 // Cards the request has in all, once analyzed
+	ctx            context.Context     // Context for the subscription and context reads
+	cancel         context.CancelFunc  // Cancel function
+	submit         func() error        // Publishes the analysis request once subscribed (see StartStreaming)
+
+	// Time to first card, from when the TUI started
+	started        time.Time
+	firstCardAfter time.Duration // Zero until the first card arrives
 
 	// Progress tracking
 	progress ProgressModel // Progress model for showing loading state
@@ -193,38 +204,121 @@ type MainModel struct {
 	// Tier counts for header display
 	uniqueCount int
 	noiseCount  int
+
+	// Expanded log context, when findings can be looked up in a store
+	contextSource ContextSource
+	expanded      map[string]store.FindingContext // finding ID -> context
+
+	// Assignment, when the context source is a store.Assigner
+	assigner    store.Assigner
+	assignMode  bool   // Typing the assignee of the selected finding
+	assignInput string // Assignee typed so far
+
+	// Side-by-side comparison with the previous build's occurrence, when the
+	// context source is a store.OccurrenceFinder
+	occurrences store.OccurrenceFinder
+	previous    map[string]contracts.TriageCard // finding ID -> previous occurrence
+	comparing   string                          // Finding ID shown beside its previous occurrence (c toggles)
+
+	// Full-screen raw log of the selected finding's job (L key), while open
+	logView *logViewer
+	logs    map[string]logLoadedMsg // finding ID -> job log read
+
+	// Triage marks kept across builds, when the marks file could be read
+	marks       *marks.File
+	showIgnored bool // Show findings marked ignored (I toggles)
+
+	// Show findings suppressed by the repo's ignore file (S toggles)
+	showSuppressed bool
+}
+
+// ExpandedContextLines is how many lines on each side of a finding the
+// x key shows, read from the stored log.
+const ExpandedContextLines = 100
+
+// ContextSource reads the log around a finding by ID, such as a store.Store.
+type ContextSource interface {
+	GetFindingContext(ctx context.Context, findingID string, before, after int) (store.FindingContext, error)
+}
+
+// assignedMsg reports the result of assigning a finding
+type assignedMsg struct {
+	card contracts.TriageCard // The finding as stored, when it was assigned
+	err  error                // With card set, the assignment was stored but not all went well
+}
+
+// previousLoadedMsg carries the previous occurrence read for a finding
+type previousLoadedMsg struct {
+	findingID string
+	previous  contracts.TriageCard
+	err       error
+}
+
+// contextLoadedMsg carries expanded context read for a finding
+type contextLoadedMsg struct {
+	findingID string
+	context   store.FindingContext
+	err       error
 }
 
 // Start initializes and runs the TUI with the provided triage cards.
 func Start(cards []contracts.TriageCard) error {
-	return StartWithBroker(nil, cards)
+	return start(nil, cards, "", nil, nil)
+}
+
+// StartAt runs the TUI like Start, opening the detail of the finding with
+// the given message hash.
+func StartAt(cards []contracts.TriageCard, messageHash string) error {
+	return start(nil, cards, messageHash, nil, nil)
 }
 
-// StartWithBroker initializes the TUI in streaming mode with a message broker.
-// If broker is nil, uses the provided initial cards only (no streaming).
-// If broker is provided, subscribes to ci_failures_ranked for live updates.
-// Invariant: If broker is not nil, initialCards must be empty.
-func StartWithBroker(brk broker.Broker, initialCards []contracts.TriageCard) error {
-	// Enforce invariant: broker and initialCards are mutually exclusive
-	if brk != nil && len(initialCards) > 0 {
-		return fmt.Errorf("invalid arguments: broker and initialCards are mutually exclusive (broker != nil requires empty initialCards)")
+// StartWithContext runs the TUI like Start, letting the x key expand the
+// log context of findings from source. If source is also a
+// store.Assigner, the a key assigns the selected finding, and if it is a
+// store.OccurrenceFinder, the c key compares it with its previous build.
+func StartWithContext(cards []contracts.TriageCard, source ContextSource) error {
+	return start(nil, cards, "", source, nil)
+}
+
+// StartStreaming runs the TUI in streaming mode, showing findings as they
+// arrive on bus, along with progress and errors. It calls submit to publish
+// the analysis request only once it has subscribed and is drawing, so no
+// finding is missed and the loading screen shows while the build is still
+// being fetched. A submit error is shown in the header.
+func StartStreaming(bus *events.Bus, submit func() error) error {
+	return start(bus, nil, "", nil, submit)
+}
+
+// start implements the Start functions. With bus nil, initialCards are
+// shown without streaming; with a bus, initialCards must be empty.
+// selected is the message hash of the finding to open, if any, and source
+// and submit may be nil.
+func start(bus *events.Bus, initialCards []contracts.TriageCard, selected string, source ContextSource, submit func() error) error {
+	// Enforce invariant: bus and initialCards are mutually exclusive
+	if bus != nil && len(initialCards) > 0 {
+		return fmt.Errorf("invalid arguments: bus and initialCards are mutually exclusive (bus != nil requires empty initialCards)")
 	}
 
-	styles := DefaultStyles()
+	styles, stylesErr := StylesFromEnv()
 	state := buildInitialState(initialCards)
 
 	// Determine initial status
 	status := StatusComplete
-	if brk != nil {
+	if bus != nil {
 		status = StatusLoading
 	}
 
 	header := initializeHeader(styles, state, status)
-	listView := initializeListView(state)
+	listView := initializeListView(styles, state)
 
-	channels, err := subscribeToBroker(brk)
-	if err != nil {
-		return err
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var eventChan <-chan events.Event
+	if bus != nil {
+		var err error
+		if eventChan, err = bus.Subscribe(ctx); err != nil {
+			return err
+		}
 	}
 
 	// Get tier counts for header
@@ -238,31 +332,48 @@ func StartWithBroker(brk broker.Broker, initialCards []contracts.TriageCard) err
 		detailViewport: viewport.New(0, 0),
 		ready:          false,
 		tierFilter:     TierFilterAll, // Show all by default
-		broker:         brk,
-		cardChan:       channels.cardChan,
-		progressChan:   channels.progressChan,
+		eventChan:      eventChan,
 		pendingCards:   nil,
 		hashMap:        state.hashMap,
 		status:         status,
+		cards:          cloneCards(initialCards),
 		cardCount:      len(initialCards),
 		droppedCount:   0,
 		jobsDiscovered: state.jobsDiscovered,
-		ctx:            channels.ctx,
-		cancel:         channels.cancel,
-		progress:       NewProgressModel(),
+		ctx:            ctx,
+		cancel:         cancel,
+		submit:         submit,
+		started:        time.Now(),
+		progress:       NewProgressModel().WithAccessible(styles.Accessible),
 		uniqueCount:    unique,
 		noiseCount:     noise,
+		contextSource:  source,
+		expanded:       make(map[string]store.FindingContext),
+	}
+	if assigner, ok := source.(store.Assigner); ok {
+		model.assigner = assigner
+	}
+	if occurrences, ok := source.(store.OccurrenceFinder); ok {
+		model.occurrences = occurrences
+	}
+	if stylesErr != nil {
+		model.header.SetNotice(fmt.Sprintf("Theme setting skipped: %v", stylesErr))
+	}
+	if marksFile, err := marks.Load(marks.DefaultPath()); err != nil {
+		model.header.SetNotice(fmt.Sprintf("Marks unavailable: %v", err))
+	} else {
+		model.marks = marksFile
 	}
 	// Update header with tier counts
 	model.header.SetTierCounts(unique, noise)
 	// Apply default tier filter (hide noise)
 	model.applyFilter()
-
-	p := tea.NewProgram(model, tea.WithAltScreen())
-	_, err = p.Run()
-	if channels.cancel != nil {
-		channels.cancel()
+	if selected != "" && model.listView.SelectHash(selected) {
+		model.detailFocused = true
 	}
+
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	_, err := p.Run()
 	return err
 }
 
@@ -292,6 +403,25 @@ func hashMapToSortedItems(hashMap map[string]*Item) []Item {
 	return items
 }
 
+// mergeDuplicate counts another occurrence of an item's finding. When the
+// duplicate comes from a later attempt of the same retried job, it replaces
+// the earlier attempt's card: the finding survived the retry, so it must not
+// be ranked as transient.
+func mergeDuplicate(existing *Item, card contracts.TriageCard) {
+	count := existing.GetRecurrence() + card.GetRecurrenceCount()
+	jobs := append(existing.Card.Jobs(), card.Jobs()...)
+	group := card.Metadata[contracts.MetadataRetryGroup]
+	if group != "" && group == existing.Card.Metadata[contracts.MetadataRetryGroup] {
+		attempt, _ := strconv.Atoi(card.Metadata[contracts.MetadataAttempt])
+		existingAttempt, _ := strconv.Atoi(existing.Card.Metadata[contracts.MetadataAttempt])
+		if attempt > existingAttempt {
+			existing.Card = card
+		}
+	}
+	existing.Card.AddJobs(jobs...)
+	existing.Card.SetRecurrenceCount(count)
+}
+
 // getTierCounts returns the count of unique failures and noise from a hash map
 func getTierCounts(hashMap map[string]*Item) (unique, noise int) {
 	cards := make([]contracts.TriageCard, 0, len(hashMap))
@@ -305,54 +435,75 @@ func getTierCounts(hashMap map[string]*Item) (unique, noise int) {
 // Init initializes the model
 func (m MainModel) Init() tea.Cmd {
 	var cmds []tea.Cmd
-	if m.cardChan != nil {
-		// Start listening for cards from broker
-		cmds = append(cmds, listenForCards(m.cardChan))
-	}
-	if m.progressChan != nil {
-		// Start listening for progress updates
-		cmds = append(cmds, listenForProgress(m.progressChan))
+	if m.eventChan != nil {
+		// Start listening for cards and progress from the bus
+		cmds = append(cmds, listenForEvents(m.eventChan))
 	}
 	// Start spinner animation for loading screen
-	cmds = append(cmds, SpinnerTick())
+	if !m.styles.Accessible {
+		cmds = append(cmds, SpinnerTick())
+	}
+	if m.submit != nil {
+		// Submit only now that the subscriptions are listening
+		cmds = append(cmds, submitRequest(m.submit))
+	}
 	return tea.Batch(cmds...)
 }
 
-// listenForCards returns a command that waits for the next card from the broker
-func listenForCards(cardChan <-chan broker.Message) tea.Cmd {
+// submitRequest returns a command that publishes the analysis request,
+// reporting a failure as a pipeline error
+func submitRequest(submit func() error) tea.Cmd {
 	return func() tea.Msg {
-		msg, ok := <-cardChan
-		if !ok {
-			// Channel closed, pipeline complete
-			return pipelineCompleteMsg{}
-		}
-
-		var card contracts.TriageCard
-		if err := json.Unmarshal(msg.Value, &card); err != nil {
+		if err := submit(); err != nil {
 			return pipelineErrorMsg{err: err}
 		}
-		return cardReceivedMsg{card: card}
+		return nil
 	}
 }
 
-// listenForProgress returns a command that waits for the next progress update from the broker
-func listenForProgress(progressChan <-chan broker.Message) tea.Cmd {
+// listenForEvents returns a command that waits for the next event from the
+// bus: a card, a progress update, the end of the analysis, or an error. The
+// channel closing means the pipeline is done.
+func listenForEvents(eventChan <-chan events.Event) tea.Cmd {
 	return func() tea.Msg {
-		msg, ok := <-progressChan
-		if !ok {
-			// Channel closed
-			return nil
+		for event := range eventChan {
+			switch event := event.(type) {
+			case events.Finding:
+				return cardReceivedMsg{card: event.Card}
+			case events.Progress:
+				return ProgressMsg{
+					Stage:   event.Update.Stage,
+					Unit:    event.Update.Unit,
+					Current: event.Update.Current,
+					Total:   event.Update.Total,
+				}
+			case events.Complete:
+				return analysisCompleteMsg{findings: event.Findings}
+			case events.Error:
+				return pipelineErrorMsg{err: event.Err}
+			}
 		}
+		// Channel closed, pipeline complete
+		return pipelineCompleteMsg{}
+	}
+}
 
-		var update contracts.ProgressUpdate
-		if err := json.Unmarshal(msg.Value, &update); err != nil {
-			return nil
-		}
-		return ProgressMsg{
-			Stage:   update.Stage,
-			Current: update.Current,
-			Total:   update.Total,
-		}
+// finishIfAnalyzed marks loading complete once the request's analysis is
+// done and all its cards have arrived, saying so definitively when the
+// build has no findings.
+func (m *MainModel) finishIfAnalyzed() {
+	if !m.analyzed || m.cardCount < m.expectedCards || m.status != StatusLoading {
+		return
+	}
+	m.status = StatusComplete
+	m.header.SetLoadStatus(m.status, m.cardCount, len(m.jobsDiscovered))
+	if len(m.pendingCards) > 0 {
+		m.mergePendingCards()
+	}
+	if m.cardCount == 0 {
+		m.header.SetNotice("Analysis complete: no errors found in the build logs")
+	} else {
+		m.header.SetNotice(fmt.Sprintf("Analysis complete: %d findings", m.cardCount))
 	}
 }
 
@@ -365,9 +516,9 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ProgressMsg:
 		m.progress, cmd = m.progress.Update(msg)
 		cmds = append(cmds, cmd)
-		// Keep listening for more progress updates
-		if m.progressChan != nil {
-			cmds = append(cmds, listenForProgress(m.progressChan))
+		// Keep listening for more events
+		if m.eventChan != nil {
+			cmds = append(cmds, listenForEvents(m.eventChan))
 		}
 		return m, tea.Batch(cmds...)
 
@@ -376,7 +527,7 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case cardReceivedMsg:
-		// New card arrived from broker - include all cards (low confidence shown dimmed)
+		// New card arrived from the bus - include all cards (low confidence shown dimmed)
 		m.cardCount++
 
 		// Track low confidence count for display
@@ -386,15 +537,15 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		// Track new jobs
-		if !m.jobsDiscovered[msg.card.JobName] {
-			m.jobsDiscovered[msg.card.JobName] = true
-		}
 		// Update job status in header (handles both new jobs and updating failed status)
 		failed := false
 		if exitStatus, ok := msg.card.Metadata["exit_status"]; ok && exitStatus != "0" {
 			failed = true
 		}
-		m.header.AddJob(msg.card.JobName, failed)
+		for _, job := range msg.card.Jobs() {
+			m.jobsDiscovered[job] = true
+			m.header.AddJob(job, failed)
+		}
 
 		// Add card to pending
 		item := Item{Card: msg.card, Rank: 0}
@@ -403,12 +554,34 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.header.SetPendingCount(len(m.pendingCards))
 		m.header.SetLoadStatus(m.status, m.cardCount, len(m.jobsDiscovered))
 
-		// Keep listening for more cards
-		if m.cardChan != nil {
-			cmds = append(cmds, listenForCards(m.cardChan))
+		// The first card replaces the loading screen right away; later
+		// ones wait for (r) so the list doesn't shift while it's read
+		if m.firstCardAfter == 0 {
+			m.firstCardAfter = time.Since(m.started)
+			m.header.SetNotice(fmt.Sprintf("First finding in %s", m.firstCardAfter.Round(10*time.Millisecond)))
+		}
+		if len(m.items) == 0 {
+			m.mergePendingCards()
+		}
+		m.finishIfAnalyzed()
+
+		// Keep listening for more events
+		if m.eventChan != nil {
+			cmds = append(cmds, listenForEvents(m.eventChan))
 		}
 		return m, tea.Batch(cmds...)
 
+	case analysisCompleteMsg:
+		// Done once the last of the request's cards has arrived
+		m.analyzed = true
+		m.expectedCards = msg.findings
+		m.progress, _ = m.progress.Update(msg)
+		m.finishIfAnalyzed()
+		if m.eventChan != nil {
+			return m, listenForEvents(m.eventChan)
+		}
+		return m, nil
+
 	case pipelineCompleteMsg:
 		// Pipeline finished - auto-merge any pending cards
 		m.status = StatusComplete
@@ -421,6 +594,9 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case pipelineErrorMsg:
 		m.status = StatusError
 		m.header.SetLoadStatus(m.status, m.cardCount, len(m.jobsDiscovered))
+		// The first line only: a provider.UserError goes on with a hint
+		summary, _, _ := strings.Cut(msg.err.Error(), "\n")
+		m.header.SetNotice("Analysis failed: " + summary)
 		return m, nil
 
 	case tea.WindowSizeMsg:
@@ -435,13 +611,129 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		m.resizeComponents()
+		if m.logView != nil {
+			m.logView.setSize(m.width, m.height)
+		}
 
 		// Initialize detail content with first item on first render
 		if selectedItem, ok := m.listView.GetSelectedItem(); ok {
 			m.updateDetailContent(selectedItem)
 		}
 
+	case noticeMsg:
+		m.header.SetNotice(msg.text)
+		return m, nil
+
+	case contextLoadedMsg:
+		if msg.err != nil {
+			m.header.SetNotice(fmt.Sprintf("Context unavailable: %v", msg.err))
+			return m, nil
+		}
+		if m.expanded == nil {
+			m.expanded = make(map[string]store.FindingContext)
+		}
+		m.expanded[msg.findingID] = msg.context
+		m.header.SetNotice(fmt.Sprintf("Expanded context to %d lines", len(msg.context.Before)+len(msg.context.After)+1))
+		if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+			m.updateDetailContent(selectedItem)
+		}
+		return m, nil
+
+	case logLoadedMsg:
+		if msg.err != nil {
+			m.header.SetNotice(fmt.Sprintf("Log unavailable: %v", msg.err))
+			return m, nil
+		}
+		if m.logs == nil {
+			m.logs = make(map[string]logLoadedMsg)
+		}
+		m.logs[msg.findingID] = msg
+		m.header.SetNotice("")
+		if selectedItem, ok := m.listView.GetSelectedItem(); ok && selectedItem.Card.ID == msg.findingID {
+			m.logView = newLogViewer(selectedItem.Card, msg.log, msg.source, m.styles, m.width, m.height)
+		}
+		return m, nil
+
+	case previousLoadedMsg:
+		var notFound store.ErrNotFound
+		switch {
+		case errors.As(msg.err, &notFound):
+			m.header.SetNotice("No earlier occurrence in other builds")
+			return m, nil
+		case msg.err != nil:
+			m.header.SetNotice(fmt.Sprintf("Previous occurrence unavailable: %v", msg.err))
+			return m, nil
+		}
+		if m.previous == nil {
+			m.previous = make(map[string]contracts.TriageCard)
+		}
+		m.previous[msg.findingID] = msg.previous
+		m.comparing = msg.findingID
+		if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+			m.updateDetailContent(selectedItem)
+		}
+		return m, nil
+
+	case assignedMsg:
+		if msg.card.MessageHash != "" {
+			m.setAssignee(msg.card.MessageHash, msg.card.Assignee)
+		}
+		switch {
+		case msg.err != nil && msg.card.MessageHash != "":
+			m.header.SetNotice(fmt.Sprintf("Assigned finding to %s, but: %v", msg.card.Assignee, msg.err))
+		case msg.err != nil:
+			m.header.SetNotice(fmt.Sprintf("Assign failed: %v", msg.err))
+		case msg.card.Assignee == "":
+			m.header.SetNotice("Unassigned finding")
+		default:
+			m.header.SetNotice("Assigned finding to " + msg.card.Assignee)
+		}
+		return m, nil
+
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
+
 	case tea.KeyMsg:
+		// Any key dismisses the last notice
+		m.header.SetNotice("")
+
+		// Keys go to the log viewer while it's open
+		if m.logView != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			open, cmd := m.logView.Update(msg)
+			if !open {
+				m.logView = nil
+			}
+			return m, cmd
+		}
+
+		// Handle assignee input
+		if m.assignMode {
+			switch msg.String() {
+			case "esc":
+				m.assignMode = false
+				return m, nil
+			case "enter":
+				m.assignMode = false
+				if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+					return m, m.assign(selectedItem, strings.TrimSpace(m.assignInput))
+				}
+				return m, nil
+			case "backspace":
+				if len(m.assignInput) > 0 {
+					m.assignInput = m.assignInput[:len(m.assignInput)-1]
+				}
+			default:
+				if len(msg.String()) == 1 || msg.String() == " " {
+					m.assignInput += msg.String()
+				}
+			}
+			m.header.SetNotice(assignPrompt(m.assignInput))
+			return m, nil
+		}
+
 		// Handle search mode input
 		if m.searchMode {
 			switch msg.String() {
@@ -511,11 +803,121 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.header.CycleFilterBackward()
 			m.applyFilter()
 			return m, nil
+		case "t":
+			// Cycle through the teams owning findings
+			m.cycleTeam()
+			return m, nil
+		case "v":
+			// Group the list by error, job, or severity
+			m.cycleGrouping()
+			return m, nil
+		case "s":
+			// Cycle through the findings only in either compared request and those in both
+			m.cycleSide()
+			return m, nil
 		case "/":
 			m.searchMode = true
 			m.searchQuery = ""
 			m.header.SetSearch(m.searchQuery, m.searchMode)
 			return m, nil
+		case "o":
+			// Open the selected finding's build in the browser
+			if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+				return m, openBuild(selectedItem.Card)
+			}
+			return m, nil
+		case "y":
+			// Copy the selected finding's raw message
+			if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+				return m, copyFinding(selectedItem.Card)
+			}
+			return m, nil
+		case "Y":
+			// Copy the selected finding with its log context
+			if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+				var expanded *store.FindingContext
+				if fc, ok := m.expanded[selectedItem.Card.ID]; ok {
+					expanded = &fc
+				}
+				return m, copyCard(selectedItem.Card, expanded)
+			}
+			return m, nil
+		case "e":
+			// Export the findings shown to a Markdown report
+			return m, exportFindings(itemCards(m.listView.items), exportMarkdown, time.Now())
+		case "E":
+			// Export the findings shown as JSON
+			return m, exportFindings(itemCards(m.listView.items), exportJSON, time.Now())
+		case "p":
+			// Copy the selected finding's permalink
+			if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+				return m, copyPermalink(selectedItem.Card)
+			}
+			return m, nil
+		case "a":
+			// Assign the selected finding to someone, or unassign it
+			selectedItem, ok := m.listView.GetSelectedItem()
+			if !ok {
+				return m, nil
+			}
+			if m.assigner == nil {
+				m.header.SetNotice("Assigning needs stored findings (destill view)")
+				return m, nil
+			}
+			m.assignMode = true
+			m.assignInput = selectedItem.Card.Assignee
+			m.header.SetNotice(assignPrompt(m.assignInput))
+			return m, nil
+		case "m":
+			// Mark the selected finding triaged, or unmark it
+			if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+				m.mark(selectedItem, marks.Triaged)
+			}
+			return m, nil
+		case "i":
+			// Mark the selected finding ignored, or unmark it
+			if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+				m.mark(selectedItem, marks.Ignored)
+			}
+			return m, nil
+		case "I":
+			// Show or hide the findings marked ignored
+			m.showIgnored = !m.showIgnored
+			if m.showIgnored {
+				m.header.SetNotice("Showing ignored findings")
+			} else {
+				m.header.SetNotice("Hiding ignored findings")
+			}
+			m.applyFilter()
+			return m, nil
+		case "S":
+			// Show or hide the findings suppressed by the repo's ignore file
+			m.showSuppressed = !m.showSuppressed
+			if m.showSuppressed {
+				m.header.SetNotice("Showing suppressed findings")
+			} else {
+				m.header.SetNotice("Hiding suppressed findings")
+			}
+			m.applyFilter()
+			return m, nil
+		case "x":
+			// Expand the selected finding's context from the stored log, or collapse it
+			if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+				return m, m.toggleContext(selectedItem)
+			}
+			return m, nil
+		case "c":
+			// Compare the selected finding with its previous build, or stop comparing
+			if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+				return m, m.toggleComparison(selectedItem)
+			}
+			return m, nil
+		case "L":
+			// Show the selected finding's whole job log
+			if selectedItem, ok := m.listView.GetSelectedItem(); ok {
+				return m, m.openLog(selectedItem)
+			}
+			return m, nil
 		case "enter":
 			// Toggle focus to detail viewport
 			m.detailFocused = !m.detailFocused
@@ -530,6 +932,10 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.header.ResetFilter()
 			m.tierFilter = TierFilterAll
 			m.header.SetTierFilter(m.tierFilter)
+			m.teamFilter = ""
+			m.header.SetTeamFilter(m.teamFilter)
+			m.sideFilter = ""
+			m.header.SetSideFilter("")
 			m.applyFilter()
 			return m, nil
 		}
@@ -553,13 +959,161 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// openBuild returns a command that opens the card's log line in the browser,
+// or its build URL when the provider has no line links
+func openBuild(card contracts.TriageCard) tea.Cmd {
+	return func() tea.Msg {
+		if link := card.Metadata[contracts.MetadataProviderLink]; link != "" {
+			if err := openURL(link); err != nil {
+				return noticeMsg{text: fmt.Sprintf("Open failed: %v", err)}
+			}
+			return noticeMsg{text: "Opened log in browser"}
+		}
+		if card.BuildURL == "" {
+			return noticeMsg{text: "No build URL for this finding"}
+		}
+		if err := openURL(card.BuildURL); err != nil {
+			return noticeMsg{text: fmt.Sprintf("Open failed: %v", err)}
+		}
+		return noticeMsg{text: "Opened build in browser"}
+	}
+}
+
+// copyFinding returns a command that copies the card's raw message to the clipboard
+func copyFinding(card contracts.TriageCard) tea.Cmd {
+	return func() tea.Msg {
+		if err := copyToClipboard(card.RawMessage); err != nil {
+			return noticeMsg{text: fmt.Sprintf("Copy failed: %v", err)}
+		}
+		return noticeMsg{text: "Copied finding to clipboard"}
+	}
+}
+
+// copyPermalink returns a command that copies the card's destill:// link to the clipboard
+func copyPermalink(card contracts.TriageCard) tea.Cmd {
+	return func() tea.Msg {
+		if err := copyToClipboard(permalink.For(card).String()); err != nil {
+			return noticeMsg{text: fmt.Sprintf("Copy failed: %v", err)}
+		}
+		return noticeMsg{text: "Copied permalink to clipboard"}
+	}
+}
+
+// toggleComparison stops comparing the item with its previous occurrence,
+// or starts, returning a command that reads the occurrence from the store
+// when it isn't loaded yet
+func (m *MainModel) toggleComparison(item Item) tea.Cmd {
+	findingID := item.Card.ID
+	if m.comparing == findingID {
+		m.comparing = ""
+		m.updateDetailContent(item)
+		return nil
+	}
+	if _, ok := m.previous[findingID]; ok {
+		m.comparing = findingID
+		m.updateDetailContent(item)
+		return nil
+	}
+	if m.occurrences == nil {
+		m.header.SetNotice("Comparing builds needs stored findings (destill view)")
+		return nil
+	}
+	occurrences, ctx, card := m.occurrences, m.ctx, item.Card
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return func() tea.Msg {
+		previous, err := occurrences.PreviousOccurrence(ctx, card)
+		return previousLoadedMsg{findingID: findingID, previous: previous, err: err}
+	}
+}
+
+// toggleContext collapses the item's expanded context, or returns a command
+// that reads it from the context source
+func (m *MainModel) toggleContext(item Item) tea.Cmd {
+	findingID := item.Card.ID
+	if _, ok := m.expanded[findingID]; ok {
+		delete(m.expanded, findingID)
+		m.updateDetailContent(item)
+		return nil
+	}
+	if m.contextSource == nil {
+		m.header.SetNotice("Expanded context needs stored findings (destill view)")
+		return nil
+	}
+	source, ctx := m.contextSource, m.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return func() tea.Msg {
+		fc, err := source.GetFindingContext(ctx, findingID, ExpandedContextLines, ExpandedContextLines)
+		return contextLoadedMsg{findingID: findingID, context: fc, err: err}
+	}
+}
+
+// assignPrompt is the header notice while an assignee is typed.
+func assignPrompt(input string) string {
+	return "Assign to: " + input + "_ (enter: save, empty: unassign, esc: cancel)"
+}
+
+// assign returns a command that stores the assignee of the item's finding.
+func (m *MainModel) assign(item Item, assignee string) tea.Cmd {
+	assigner, ctx := m.assigner, m.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	card := item.Card
+	return func() tea.Msg {
+		assigned, err := assigner.AssignFinding(ctx, card.RequestID, card.MessageHash, assignee)
+		return assignedMsg{card: assigned, err: err}
+	}
+}
+
+// setAssignee records the assignee of the findings with messageHash.
+func (m *MainModel) setAssignee(messageHash, assignee string) {
+	for i := range m.items {
+		if m.items[i].Card.MessageHash == messageHash {
+			m.items[i].Card.Assignee = assignee
+		}
+	}
+	if item, ok := m.hashMap[messageHash]; ok {
+		item.Card.Assignee = assignee
+	}
+	m.applyFilter()
+}
+
+// mark sets the item's finding to state and saves it in the marks file,
+// or unmarks it if it already has that state.
+func (m *MainModel) mark(item Item, state string) {
+	if m.marks == nil {
+		m.header.SetNotice("Marks unavailable: the marks file couldn't be read")
+		return
+	}
+	if item.Mark == state {
+		state = ""
+	}
+	if err := m.marks.Set(item.Card, state, time.Now()); err != nil {
+		m.header.SetNotice(fmt.Sprintf("Mark failed: %v", err))
+		return
+	}
+	switch state {
+	case marks.Triaged:
+		m.header.SetNotice("Marked finding triaged")
+	case marks.Ignored:
+		m.header.SetNotice("Ignored finding (I shows ignored findings)")
+	default:
+		m.header.SetNotice("Unmarked finding")
+	}
+	m.applyFilter()
+}
+
 // mergePendingCards merges pending cards into the main list and re-ranks
 func (m *MainModel) mergePendingCards() {
 	// Add pending cards to hash map (grouping by hash)
 	for _, item := range m.pendingCards {
+		m.cards = append(m.cards, cloneCards([]contracts.TriageCard{item.Card})...)
 		if existing, ok := m.hashMap[item.Card.MessageHash]; ok {
-			// Increment recurrence count
-			existing.Card.SetRecurrenceCount(existing.GetRecurrence() + 1)
+			mergeDuplicate(existing, item.Card)
 		} else {
 			itemCopy := item
 			m.hashMap[item.Card.MessageHash] = &itemCopy
@@ -571,7 +1125,7 @@ func (m *MainModel) mergePendingCards() {
 	m.header.SetPendingCount(0)
 
 	// Rebuild sorted items list with tier info
-	m.items = hashMapToSortedItems(m.hashMap)
+	m.items = m.groupedItems()
 
 	// Update tier counts
 	m.uniqueCount, m.noiseCount = getTierCounts(m.hashMap)
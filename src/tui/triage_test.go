@@ -1,12 +1,18 @@
 package tui
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"destill-agent/src/contracts"
+	"destill-agent/src/events"
+	"destill-agent/src/ranking"
+	"destill-agent/src/store"
 )
 
 // Helper to create a model for testing
@@ -128,6 +134,77 @@ func TestMainModel_Filter(t *testing.T) {
 	}
 }
 
+func TestMainModel_TeamFilter(t *testing.T) {
+	owned := func(team string) map[string]string {
+		return map[string]string{contracts.MetadataOwnerTeam: team}
+	}
+	cards := []contracts.TriageCard{
+		{JobName: "api", NormalizedMsg: "payment declined", Metadata: owned("payments")},
+		{JobName: "api", NormalizedMsg: "search timeout", Metadata: owned("search")},
+		{JobName: "web", NormalizedMsg: "checkout failed", Metadata: owned("Payments")},
+		{JobName: "web", NormalizedMsg: "unowned error"},
+	}
+	model := createTestModel(cards)
+
+	// t cycles through the teams, sorted, then back to all
+	for _, want := range []struct {
+		team  string
+		items int
+	}{{"payments", 2}, {"search", 1}, {"", 4}} {
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+		model = updated.(MainModel)
+		if model.teamFilter != want.team || len(model.listView.items) != want.items {
+			t.Errorf("team filter = %q with %d items, want %q with %d", model.teamFilter, len(model.listView.items), want.team, want.items)
+		}
+	}
+}
+
+// fakeAssigner records assignments like a store.Assigner.
+type fakeAssigner struct {
+	assigned map[string]string // message hash -> assignee
+}
+
+func (f *fakeAssigner) AssignFinding(ctx context.Context, requestID, messageHash, assignee string) (contracts.TriageCard, error) {
+	f.assigned[messageHash] = assignee
+	return contracts.TriageCard{RequestID: requestID, MessageHash: messageHash, Assignee: assignee}, nil
+}
+
+func TestMainModel_Assign(t *testing.T) {
+	cards := []contracts.TriageCard{{RequestID: "req-1", JobName: "api", MessageHash: "h1", NormalizedMsg: "payment declined"}}
+	model := createTestModel(cards)
+	assigner := &fakeAssigner{assigned: make(map[string]string)}
+	model.assigner = assigner
+
+	press := func(keys ...tea.KeyMsg) tea.Cmd {
+		var cmd tea.Cmd
+		for _, key := range keys {
+			var updated tea.Model
+			updated, cmd = model.Update(key)
+			model = updated.(MainModel)
+		}
+		return cmd
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	press(runes("a"), runes("a"), runes("l"), runes("e"), runes("x"), tea.KeyMsg{Type: tea.KeyBackspace}, runes("x"))
+	if !model.assignMode || model.assignInput != "alex" {
+		t.Fatalf("assign mode %v with input %q, want typing alex", model.assignMode, model.assignInput)
+	}
+	cmd := press(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.assignMode || cmd == nil {
+		t.Fatalf("enter should leave assign mode and assign")
+	}
+	updated, _ := model.Update(cmd())
+	model = updated.(MainModel)
+
+	if assigner.assigned["h1"] != "alex" {
+		t.Errorf("assigned = %v, want h1 to alex", assigner.assigned)
+	}
+	if item, ok := model.listView.GetSelectedItem(); !ok || item.Card.Assignee != "alex" {
+		t.Errorf("selected item assignee = %q, want alex", item.Card.Assignee)
+	}
+}
+
 func TestMainModel_View(t *testing.T) {
 	cards := []contracts.TriageCard{
 		{
@@ -163,3 +240,324 @@ func TestMainModel_View(t *testing.T) {
 	// Check logic for rendering details (should contain message again in details pane)
 	// "Connection timeout" appears in list AND details.
 }
+
+func TestMainModel_OpenAndCopy(t *testing.T) {
+	defer func(origOpen func(string) error, origCopy func(string) error) {
+		openURL, copyToClipboard = origOpen, origCopy
+	}(openURL, copyToClipboard)
+
+	var opened, copied string
+	openURL = func(url string) error { opened = url; return nil }
+	copyToClipboard = func(text string) error { copied = text; return nil }
+
+	cards := []contracts.TriageCard{
+		{
+			JobName:       "tests",
+			NormalizedMsg: "connection refused",
+			RawMessage:    "ERROR: connection refused to db:5432",
+			BuildURL:      "https://buildkite.com/org/pipeline/builds/1",
+		},
+	}
+	model := createTestModel(cards)
+
+	press := func(m MainModel, key string) MainModel {
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		m = updated.(MainModel)
+		if cmd == nil {
+			t.Fatalf("key %q returned no command", key)
+		}
+		updated, _ = m.Update(cmd())
+		return updated.(MainModel)
+	}
+
+	m := press(model, "o")
+	if opened != cards[0].BuildURL {
+		t.Errorf("opened %q, want %q", opened, cards[0].BuildURL)
+	}
+	if !strings.Contains(m.header.notice, "Opened") {
+		t.Errorf("notice = %q, want an open confirmation", m.header.notice)
+	}
+
+	m = press(m, "y")
+	if copied != cards[0].RawMessage {
+		t.Errorf("copied %q, want %q", copied, cards[0].RawMessage)
+	}
+	if !strings.Contains(m.header.notice, "Copied") {
+		t.Errorf("notice = %q, want a copy confirmation", m.header.notice)
+	}
+
+	m.listView.items[0].Card.Metadata = map[string]string{contracts.MetadataProviderLink: "https://buildkite.com/org/pipeline/builds/1#job/42"}
+	m.listView.SetItems(m.listView.items)
+	m = press(m, "o")
+	if opened != "https://buildkite.com/org/pipeline/builds/1#job/42" {
+		t.Errorf("opened %q, want the provider link", opened)
+	}
+
+	m = press(m, "p")
+	if !strings.HasPrefix(copied, "destill://finding/") || !strings.Contains(copied, "builds%2F1") {
+		t.Errorf("copied %q, want the finding's permalink", copied)
+	}
+}
+
+func TestViewSelectHash(t *testing.T) {
+	model := createTestModel([]contracts.TriageCard{
+		{JobName: "tests", MessageHash: "aaa", NormalizedMsg: "first"},
+		{JobName: "tests", MessageHash: "bbb", NormalizedMsg: "second"},
+	})
+
+	if !model.listView.SelectHash("bbb") {
+		t.Fatal("SelectHash(bbb) = false")
+	}
+	if item, _ := model.listView.GetSelectedItem(); item.Card.MessageHash != "bbb" {
+		t.Errorf("selected %q, want bbb", item.Card.MessageHash)
+	}
+	if model.listView.SelectHash("ccc") {
+		t.Error("SelectHash(ccc) = true for a missing finding")
+	}
+}
+
+func TestItemMatchesQuery_RunnerMetadata(t *testing.T) {
+	item := Item{Card: contracts.TriageCard{
+		JobName:       "tests",
+		NormalizedMsg: "Test failed",
+		Metadata: map[string]string{
+			contracts.MetadataRunnerQueue:    "gpu",
+			contracts.MetadataContainerImage: "golang:1.22",
+		},
+	}}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"queue=gpu", true},
+		{"runner_queue=gpu", true},
+		{"queue=cpu", false},
+		{"container_image=golang", true},
+		{"golang:1.22", true},
+		{"runner_name=agent", false},
+		{"test failed", true},
+	}
+	for _, tt := range tests {
+		if got := itemMatchesQuery(item, tt.query); got != tt.want {
+			t.Errorf("itemMatchesQuery(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	if got := formatRunnerMetadata(item.Card.Metadata); got != "queue: gpu | container_image: golang:1.22" {
+		t.Errorf("formatRunnerMetadata() = %q", got)
+	}
+}
+
+func TestRenderDetail_Summary(t *testing.T) {
+	m := createTestModel(nil)
+	item := Item{Card: contracts.TriageCard{
+		JobName:    "tests",
+		RawMessage: "java.lang.OutOfMemoryError: Java heap space",
+		Summary: &contracts.Summary{
+			RootCause:    "The test JVM ran out of heap.",
+			SuggestedFix: "Raise -Xmx in the test task.",
+			Model:        "gpt-4o-mini",
+		},
+	}}
+
+	detail := stripAnsi(m.renderDetail(item, 80))
+	for _, want := range []string{"Summary (gpt-4o-mini):", "The test JVM ran out of heap.", "Fix: Raise -Xmx"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("detail missing %q:\n%s", want, detail)
+		}
+	}
+
+	item.Card.Summary = nil
+	if detail := stripAnsi(m.renderDetail(item, 80)); strings.Contains(detail, "Summary") {
+		t.Errorf("detail without a summary shows one:\n%s", detail)
+	}
+}
+
+func TestMergeDuplicate_LaterAttempt(t *testing.T) {
+	card := func(attempt string) contracts.TriageCard {
+		return contracts.TriageCard{
+			NormalizedMsg: "assertion failed",
+			Metadata: map[string]string{
+				contracts.MetadataRetryGroup: "job-1",
+				contracts.MetadataAttempt:    attempt,
+				contracts.MetadataAttempts:   "2",
+			},
+		}
+	}
+	item := &Item{Card: card("1")}
+
+	mergeDuplicate(item, card("2"))
+
+	if got := item.Card.Metadata[contracts.MetadataAttempt]; got != "2" {
+		t.Errorf("attempt = %q, want the later attempt 2", got)
+	}
+	if got := item.GetRecurrence(); got != 2 {
+		t.Errorf("recurrence = %d, want 2", got)
+	}
+	if ranking.IsTransient(ranking.MarkTransients([]contracts.TriageCard{item.Card})[0]) {
+		t.Error("finding present in the final attempt should not be transient")
+	}
+}
+
+func TestExpandContext(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewInMemoryStore()
+	var lines []string
+	for i := 1; i <= 300; i++ {
+		lines = append(lines, fmt.Sprintf("log line %d", i))
+	}
+	st.StoreChunks(ctx, []contracts.LogChunk{{RequestID: "req-1", JobID: "job-1", Content: strings.Join(lines, "\n"), LineStart: 1, LineEnd: 300}})
+	card := contracts.TriageCard{
+		ID:          "finding-1",
+		RequestID:   "req-1",
+		JobName:     "tests",
+		RawMessage:  "log line 150",
+		PreContext:  []string{"log line 149"},
+		LineInChunk: 149,
+		Metadata:    map[string]string{"job_id": "job-1"},
+	}
+	st.Store(ctx, "req-1", []contracts.TriageCard{card})
+
+	model := createTestModel([]contracts.TriageCard{card})
+	item, _ := model.listView.GetSelectedItem()
+
+	// Without a source there's nothing to expand from
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if m := updated.(MainModel); cmd != nil || !strings.Contains(m.header.notice, "stored findings") {
+		t.Errorf("notice = %q, want a hint that context needs stored findings", m.header.notice)
+	}
+
+	model.contextSource = st
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if cmd == nil {
+		t.Fatal("x returned no command")
+	}
+	updated, _ = updated.(MainModel).Update(cmd())
+	m := updated.(MainModel)
+	detail := m.renderDetail(item, 80)
+	for _, want := range []string{"expanded, line 150", "log line 50", "log line 250"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("expanded detail missing %q", want)
+		}
+	}
+
+	// x again collapses to the finding's own context
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if detail := updated.(MainModel).renderDetail(item, 80); strings.Contains(detail, "log line 50\n") || strings.Contains(detail, "expanded") {
+		t.Error("detail still expanded after second x")
+	}
+}
+
+func TestCycleSide(t *testing.T) {
+	branch := []contracts.TriageCard{
+		{ID: "finding-1", JobName: "tests", MessageHash: "hash-shared", RawMessage: "shared error"},
+		{ID: "finding-2", JobName: "tests", MessageHash: "hash-branch", RawMessage: "branch error"},
+	}
+	mainCards := []contracts.TriageCard{
+		{ID: "finding-3", JobName: "tests", MessageHash: "hash-shared", RawMessage: "shared error"},
+		{ID: "finding-4", JobName: "tests", MessageHash: "hash-main", RawMessage: "main error"},
+	}
+	cards, _ := ranking.CompareRequests(branch, mainCards, "req-branch", "req-main")
+
+	// Without a comparison there are no sides to cycle through
+	model := createTestModel(branch)
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if m := updated.(MainModel); !strings.Contains(m.header.notice, "Not comparing") {
+		t.Errorf("notice = %q, want a hint that s needs --compare", m.header.notice)
+	}
+
+	m := createTestModel(cards)
+	for _, want := range []string{"branch error", "main error", "shared error", ""} {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+		m = updated.(MainModel)
+		shown := m.listView.list.Items()
+		if want == "" {
+			if len(shown) != 3 {
+				t.Errorf("after cycling back to all, %d findings shown, want 3", len(shown))
+			}
+			continue
+		}
+		if len(shown) != 1 || shown[0].(Item).Card.RawMessage != want {
+			t.Errorf("side %q shows %d findings, want only %q", m.sideFilter, len(shown), want)
+		}
+	}
+
+	item, _ := m.listView.GetSelectedItem()
+	if detail := m.renderDetail(item, 100); !strings.Contains(detail, "req-branch") {
+		t.Errorf("detail doesn't say which request has the finding:\n%s", detail)
+	}
+}
+
+func TestMainModel_Streaming(t *testing.T) {
+	submitted := false
+	model := createTestModel(nil)
+	model.hashMap = make(map[string]*Item)
+	model.jobsDiscovered = make(map[string]bool)
+	model.status = StatusLoading
+	model.started = time.Now()
+	model.submit = func() error {
+		submitted = true
+		return nil
+	}
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m := updated.(MainModel)
+
+	// The request is submitted by Init, once the TUI is listening
+	cmd := submitRequest(m.submit)
+	if msg := cmd(); msg != nil || !submitted {
+		t.Fatalf("submitRequest() = %v, submitted %v; want the request submitted", msg, submitted)
+	}
+	if view := m.View(); !strings.Contains(view, "░") {
+		t.Error("loading view should show placeholder rows")
+	}
+
+	card := func(hash string) cardReceivedMsg {
+		return cardReceivedMsg{card: contracts.TriageCard{ID: hash, JobName: "tests", MessageHash: hash, NormalizedMsg: "error " + hash, ConfidenceScore: 0.9}}
+	}
+	updated, _ = m.Update(card("first"))
+	m = updated.(MainModel)
+	if len(m.items) != 1 || len(m.pendingCards) != 0 {
+		t.Errorf("after the first card: %d items, %d pending; want it shown right away", len(m.items), len(m.pendingCards))
+	}
+	if m.firstCardAfter <= 0 || !strings.Contains(m.header.notice, "First finding in") {
+		t.Errorf("firstCardAfter = %v, notice %q; want the time to first card recorded", m.firstCardAfter, m.header.notice)
+	}
+
+	updated, _ = m.Update(card("second"))
+	m = updated.(MainModel)
+	if len(m.items) != 1 || len(m.pendingCards) != 1 {
+		t.Errorf("after the second card: %d items, %d pending; want it pending", len(m.items), len(m.pendingCards))
+	}
+}
+
+func TestSubmitRequest_Error(t *testing.T) {
+	msg := submitRequest(func() error { return fmt.Errorf("broker closed") })()
+	model := createTestModel(nil)
+	updated, _ := model.Update(msg)
+	m := updated.(MainModel)
+	if m.status != StatusError || !strings.Contains(m.header.notice, "broker closed") {
+		t.Errorf("status %v, notice %q; want the submit error shown", m.status, m.header.notice)
+	}
+}
+
+func TestListenForEvents(t *testing.T) {
+	ch := make(chan events.Event, 4)
+	ch <- events.Progress{Update: contracts.ProgressUpdate{Stage: "Fetching logs", Current: 1, Total: 3}}
+	ch <- events.Finding{Card: contracts.TriageCard{MessageHash: "abc"}}
+	ch <- events.Error{RequestID: "req-1", Err: fmt.Errorf("build not found")}
+	close(ch)
+
+	if msg, ok := listenForEvents(ch)().(ProgressMsg); !ok || msg.Stage != "Fetching logs" || msg.Total != 3 {
+		t.Errorf("first message = %+v, want the progress update", msg)
+	}
+	if msg, ok := listenForEvents(ch)().(cardReceivedMsg); !ok || msg.card.MessageHash != "abc" {
+		t.Errorf("second message = %+v, want the card", msg)
+	}
+	if msg, ok := listenForEvents(ch)().(pipelineErrorMsg); !ok || msg.err == nil {
+		t.Errorf("third message = %+v, want the error", msg)
+	}
+	if _, ok := listenForEvents(ch)().(pipelineCompleteMsg); !ok {
+		t.Error("closed channel should complete the pipeline")
+	}
+}
@@ -29,6 +29,16 @@ func NewView() View {
 	}
 }
 
+// SetStyles switches the row rendering to the given style profile.
+func (v *View) SetStyles(styles *StyleConfig) {
+	v.delegate.styles = styles
+}
+
+// SetGrouping labels rows with their group for the given grouping.
+func (v *View) SetGrouping(grouping int) {
+	v.delegate.grouping = grouping
+}
+
 // Update handles triage list updates
 func (v View) Update(msg tea.Msg) (View, tea.Cmd) {
 	var cmd tea.Cmd
@@ -83,6 +93,50 @@ func (v View) GetSelectedItem() (Item, bool) {
 	return item, true
 }
 
+// SelectHash selects the item with the given message hash. Returns false
+// if no visible item has it.
+func (v *View) SelectHash(messageHash string) bool {
+	for i, listItem := range v.list.Items() {
+		if item, ok := listItem.(Item); ok && item.Card.MessageHash == messageHash {
+			v.list.Select(i)
+			return true
+		}
+	}
+	return false
+}
+
+// IndexAt returns the index of the item drawn on row (0-based, from the top
+// of the list). Returns false if no item is drawn there.
+func (v View) IndexAt(row int) (int, bool) {
+	if row < 0 {
+		return 0, false
+	}
+	rowHeight := v.delegate.Height() + v.delegate.Spacing()
+	if row%rowHeight >= v.delegate.Height() {
+		return 0, false
+	}
+	index := v.list.Paginator.Page*v.list.Paginator.PerPage + row/rowHeight
+	if row/rowHeight >= v.list.Paginator.PerPage || index >= len(v.list.Items()) {
+		return 0, false
+	}
+	return index, true
+}
+
+// Select selects the item at index
+func (v *View) Select(index int) {
+	v.list.Select(index)
+}
+
+// MoveCursor moves the selection down (positive) or up (negative) by n items
+func (v *View) MoveCursor(n int) {
+	for ; n > 0; n-- {
+		v.list.CursorDown()
+	}
+	for ; n < 0; n++ {
+		v.list.CursorUp()
+	}
+}
+
 // Render returns the string representation of the view
 func (v View) Render() string {
 	return v.list.View()